@@ -14,9 +14,10 @@ type MetricMaker interface {
 }
 
 type accumulator struct {
-	maker     MetricMaker
-	metrics   chan<- cua.Metric
-	precision time.Duration
+	maker      MetricMaker
+	metrics    chan<- cua.Metric
+	precision  time.Duration
+	timestamps *timestampPolicy
 }
 
 func NewAccumulator(
@@ -31,6 +32,22 @@ func NewAccumulator(
 	return &acc
 }
 
+// newInputAccumulator creates the accumulator of an input, adjusting the
+// timestamps of its metrics according to the timestamp policy, if any.
+func newInputAccumulator(
+	input MetricMaker,
+	metrics chan<- cua.Metric,
+	precision time.Duration,
+	timestamps *timestampPolicy,
+) cua.Accumulator {
+	return &accumulator{
+		maker:      input,
+		metrics:    metrics,
+		precision:  precision,
+		timestamps: timestamps,
+	}
+}
+
 func (ac *accumulator) AddFields(
 	measurement string,
 	fields map[string]interface{},
@@ -85,8 +102,35 @@ func (ac *accumulator) AddCumulativeHistogram(
 	ac.addFields(measurement, tags, fields, cua.CumulativeHistogram, t...)
 }
 
+func (ac *accumulator) AddSamples(
+	measurement string,
+	samples []float64,
+	tags map[string]string,
+	t ...time.Time,
+) {
+	fields := metric.SampleFields(samples)
+	if len(fields) == 0 {
+		return
+	}
+	ac.addFields(measurement, tags, fields, cua.Histogram, t...)
+}
+
+func (ac *accumulator) AddEvent(
+	title, body string,
+	severity cua.Severity,
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.addFields(metric.EventName, tags, metric.EventFields(title, body, severity), cua.Event, t...)
+}
+
 func (ac *accumulator) AddMetric(m cua.Metric) {
-	m.SetTime(m.Time().Round(ac.precision))
+	t, ok := ac.adjustTime(m.Name(), m.Time(), m.Type())
+	if !ok {
+		m.Drop()
+		return
+	}
+	m.SetTime(t)
 	if m := ac.maker.MakeMetric(m); m != nil {
 		ac.metrics <- m
 	}
@@ -99,13 +143,17 @@ func (ac *accumulator) addFields(
 	tp cua.ValueType,
 	t ...time.Time,
 ) {
-	m, err := metric.New(measurement, tags, fields, ac.getTime(t), tp)
-	if err != nil {
+	tm, ok := ac.adjustTime(measurement, ac.getTime(t), tp)
+	if !ok {
 		return
 	}
-	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+	// recycled once written by outputs done with them, or dropped by filters
+	m := metric.Get(measurement, tags, fields, tm, tp)
+	if mm := ac.maker.MakeMetric(m); mm != nil {
+		ac.metrics <- mm
+		return
 	}
+	metric.Recycle(m)
 }
 
 // AddError passes a runtime error to the accumulator.
@@ -122,13 +170,77 @@ func (ac *accumulator) SetPrecision(precision time.Duration) {
 }
 
 func (ac *accumulator) getTime(t []time.Time) time.Time {
-	var timestamp time.Time
 	if len(t) > 0 {
-		timestamp = t[0]
-	} else {
-		timestamp = time.Now()
+		return t[0]
+	}
+	return time.Now()
+}
+
+// adjustTime answers the timestamp of a metric rounded to the precision, or
+// adjusted by the timestamp policy, false when the metric is to be dropped
+func (ac *accumulator) adjustTime(measurement string, t time.Time, tp cua.ValueType) (time.Time, bool) {
+	if ac.timestamps == nil {
+		return t.Round(ac.precision), true
+	}
+	adjusted, ok := ac.timestamps.adjust(t, tp, ac.precision)
+	if !ok {
+		ac.maker.Log().Debugf("Dropping metric %s with timestamp %s out of range", measurement, t)
+	}
+	return adjusted, ok
+}
+
+// NewStream opens a stream of at most size pending metrics, which are
+// added by a goroutine as the agent takes them.
+func (ac *accumulator) NewStream(size int) cua.MetricStream {
+	s := &metricStream{
+		acc:     ac,
+		metrics: make(chan cua.Metric, size),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+type metricStream struct {
+	acc     *accumulator
+	metrics chan cua.Metric
+	done    chan struct{}
+}
+
+func (s *metricStream) NewMetric(
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	t time.Time,
+	tp cua.ValueType,
+) cua.Metric {
+	return metric.Get(measurement, tags, fields, t, tp)
+}
+
+func (s *metricStream) AddMetric(m cua.Metric) {
+	s.metrics <- m
+}
+
+func (s *metricStream) Close() {
+	close(s.metrics)
+	<-s.done
+}
+
+func (s *metricStream) run() {
+	defer close(s.done)
+	for m := range s.metrics {
+		t, ok := s.acc.adjustTime(m.Name(), m.Time(), m.Type())
+		if !ok {
+			metric.Recycle(m)
+			continue
+		}
+		m.SetTime(t)
+		if mm := s.acc.maker.MakeMetric(m); mm != nil {
+			s.acc.metrics <- mm
+			continue
+		}
+		metric.Recycle(m)
 	}
-	return timestamp.Round(ac.precision)
 }
 
 func (ac *accumulator) WithTracking(maxTracked int) cua.TrackingAccumulator {
@@ -46,6 +46,38 @@ func TestAddFields(t *testing.T) {
 	require.Equal(t, cua.Counter, tp)
 }
 
+func TestAddSamples(t *testing.T) {
+	metrics := make(chan cua.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	now := time.Now()
+	a.AddSamples("rtt", []float64{1.5, 2, 1.5}, map[string]string{"url": "example.org"}, now)
+	// nothing to record
+	a.AddSamples("rtt", nil, map[string]string{"url": "example.org"}, now)
+
+	testm := <-metrics
+	require.Equal(t, "rtt", testm.Name())
+	require.Equal(t, cua.Histogram, testm.Type())
+	require.Equal(t, map[string]interface{}{"1.5": int64(2), "2": int64(1)}, testm.Fields())
+	require.Len(t, metrics, 0)
+}
+
+func TestAddEvent(t *testing.T) {
+	metrics := make(chan cua.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	now := time.Now()
+	a.AddEvent("container etcd stopped", "", cua.Warning, map[string]string{"container_name": "etcd"}, now)
+
+	testm := <-metrics
+	require.Equal(t, "event", testm.Name())
+	require.Equal(t, cua.Event, testm.Type())
+	require.Equal(t, map[string]interface{}{"title": "container etcd stopped", "severity": "warning"}, testm.Fields())
+	require.Equal(t, map[string]string{"container_name": "etcd"}, testm.Tags())
+}
+
 func TestAccAddError(t *testing.T) {
 	errBuf := bytes.NewBuffer(nil)
 	log.SetOutput(errBuf)
@@ -141,6 +173,50 @@ func TestAddTrackingMetricGroupEmpty(t *testing.T) {
 	}
 }
 
+func TestStream(t *testing.T) {
+	ch := make(chan cua.Metric, 10)
+	acc, ok := NewAccumulator(&dropMetricMaker{drop: "drop"}, ch).(cua.StreamingAccumulator)
+	require.True(t, ok)
+	acc.SetPrecision(time.Second)
+
+	now := time.Unix(10, 400000000)
+	stream := acc.NewStream(1)
+	for i := 0; i < 6; i++ {
+		name := "keep"
+		if i%2 == 1 {
+			name = "drop"
+		}
+		stream.AddMetric(stream.NewMetric(name,
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": int64(i)}, now, cua.Gauge))
+	}
+	stream.Close()
+	close(ch)
+
+	var values []interface{}
+	for m := range ch {
+		require.Equal(t, "keep", m.Name())
+		require.Equal(t, map[string]string{"host": "a"}, m.Tags())
+		require.Equal(t, cua.Gauge, m.Type())
+		require.Equal(t, time.Unix(10, 0), m.Time())
+		v, _ := m.GetField("value")
+		values = append(values, v)
+	}
+	require.Equal(t, []interface{}{int64(0), int64(2), int64(4)}, values)
+}
+
+type dropMetricMaker struct {
+	TestMetricMaker
+	drop string
+}
+
+func (tm *dropMetricMaker) MakeMetric(metric cua.Metric) cua.Metric {
+	if metric.Name() == tm.drop {
+		return nil
+	}
+	return metric
+}
+
 type TestMetricMaker struct {
 }
 
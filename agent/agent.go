@@ -7,13 +7,17 @@ import (
 	"os"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/capability"
+	"github.com/circonus-labs/circonus-unified-agent/internal/sdnotify"
 	"github.com/circonus-labs/circonus-unified-agent/models"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/election"
 	circjson "github.com/circonus-labs/circonus-unified-agent/plugins/serializers/circonus"
 )
 
@@ -78,7 +82,7 @@ type aggregatorUnit struct {
 }
 
 // outputUnit is a group of Outputs and their source channel.  Metrics on the
-// channel are written to all outputs.
+// channel are written to all outputs of their pipeline.
 //
 //                            ┌────────┐
 //                       ┌──▶ │ Output │
@@ -106,6 +110,31 @@ func (a *Agent) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	a.checkPipelines()
+
+	elector, err := a.startElection(ctx)
+	if err != nil {
+		return err
+	}
+	if elector != nil {
+		defer elector.Stop()
+	}
+
+	var state *stateStore
+	if a.Config.Agent.Statefile != "" {
+		log.Printf("D! [agent] Restoring plugin state")
+		state, err = a.restoreState()
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.Config.Agent.DropCapabilities {
+		log.Printf("D! [agent] Dropping capabilities")
+		if err := a.dropCapabilities(); err != nil {
+			return err
+		}
+	}
 
 	startTime := time.Now()
 
@@ -143,6 +172,12 @@ func (a *Agent) Run(ctx context.Context) error {
 	}
 
 	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		notifyServiceManager(ctx)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -179,10 +214,162 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	if state != nil {
+		log.Printf("D! [agent] Storing plugin state")
+		if err := state.store(); err != nil {
+			log.Printf("E! [agent] Error storing plugin state: %v", err)
+		}
+	}
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return err
 }
 
+// notifyServiceManager tells systemd, when run as a service of Type=notify,
+// that the agent is ready, with the outputs connected and the service inputs
+// started, and keeps its watchdog from restarting the agent until the context
+// is done.
+func notifyServiceManager(ctx context.Context) {
+	if sent, err := sdnotify.Notify(sdnotify.Ready); err != nil {
+		log.Printf("E! [agent] Notifying service manager: %v", err)
+	} else if !sent {
+		return
+	}
+	defer func() {
+		if _, err := sdnotify.Notify(sdnotify.Stopping); err != nil {
+			log.Printf("E! [agent] Notifying service manager: %v", err)
+		}
+	}()
+
+	interval, err := sdnotify.WatchdogInterval()
+	if err != nil {
+		log.Printf("E! [agent] Watchdog disabled: %v", err)
+	}
+	if interval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	// ping twice per interval, so a late ping does not trigger the watchdog
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := sdnotify.Notify(sdnotify.Watchdog); err != nil {
+				log.Printf("E! [agent] Notifying service manager: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// restoreState registers the stateful plugins with the statefile and
+// restores their state, before any of them is started.
+func (a *Agent) restoreState() (*stateStore, error) {
+	state := newStateStore(a.Config.Agent.Statefile)
+	for _, input := range a.Config.Inputs {
+		if err := state.register(input.StateID(), input.Input); err != nil {
+			return nil, err
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if err := state.register(aggregator.LogName(), aggregator.Aggregator); err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range a.Config.Outputs {
+		if err := state.register(output.LogName(), output.Output); err != nil {
+			return nil, err
+		}
+	}
+	if err := state.load(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// dropCapabilities drops the capabilities of the process but the ones the
+// plugins need and the configured ones to keep, before any plugin is started.
+func (a *Agent) dropCapabilities() error {
+	keep := append([]string{}, a.Config.Agent.KeepCapabilities...)
+	needed := make(map[string][]string)
+	add := func(name string, plugin interface{}) {
+		pp, ok := plugin.(cua.PrivilegedPlugin)
+		if !ok {
+			return
+		}
+		for _, c := range pp.Capabilities() {
+			if _, ok := needed[c]; !ok {
+				keep = append(keep, c)
+			}
+			needed[c] = append(needed[c], name)
+		}
+	}
+	for _, input := range a.Config.Inputs {
+		add(input.LogName(), input.Input)
+	}
+	for _, output := range a.Config.Outputs {
+		add(output.LogName(), output.Output)
+	}
+
+	missing, err := capability.Drop(keep)
+	if err != nil {
+		return fmt.Errorf("dropping capabilities: %w", err)
+	}
+	for _, c := range missing {
+		if plugins, ok := needed[c]; ok {
+			log.Printf("W! [agent] Capability %s needed by %s is not held", c, strings.Join(plugins, ", "))
+		}
+	}
+	log.Printf("I! [agent] Dropped capabilities, keeping [%s]", strings.Join(keep, " "))
+	return nil
+}
+
+// checkPipelines warns of inputs in pipelines without outputs, their metrics
+// are dropped
+func (a *Agent) checkPipelines() {
+	outputs := make(map[string]bool)
+	for _, output := range a.Config.Outputs {
+		outputs[output.Pipeline()] = true
+	}
+	for _, input := range a.Config.Inputs {
+		if pipeline := models.PipelineName(input.Config.Pipeline); !outputs[pipeline] {
+			log.Printf("W! [agent] Pipeline %q of %s has no outputs, its metrics are dropped", pipeline, input.LogName())
+		}
+	}
+}
+
+// startElection starts campaigning for the lease of the election lock when
+// inputs have cluster_scope, and skips their collections unless elected
+func (a *Agent) startElection(ctx context.Context) (*election.Elector, error) {
+	var inputs []*models.RunningInput
+	for _, input := range a.Config.Inputs {
+		if input.Config.ClusterScope {
+			inputs = append(inputs, input)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	if a.Config.Agent.Election.Type == "" {
+		log.Printf("W! [agent] No election configured, the cluster scope inputs are collected by every agent")
+		return nil, nil
+	}
+
+	elector, err := election.New(&a.Config.Agent.Election, a.Config.Agent.Hostname, models.NewLogger("agent", "election", ""))
+	if err != nil {
+		return nil, fmt.Errorf("election: %w", err)
+	}
+	for _, input := range inputs {
+		input.SetLeader(elector)
+	}
+	log.Printf("I! [agent] Campaigning as %s for the %d cluster scope inputs", elector.ID(), len(inputs))
+	elector.Start(ctx)
+	return elector, nil
+}
+
 // initPlugins runs the Init function on plugins.
 func (a *Agent) initPlugins() error {
 	for _, input := range a.Config.Inputs {
@@ -232,18 +419,9 @@ func (a *Agent) startInputs(
 			// This only applies to the accumulator passed to Start(), the
 			// Gather() accumulator does apply rounding according to the
 			// precision and interval agent/plugin settings.
-			var interval time.Duration
-			var precision time.Duration
-			if input.Config.Precision != 0 {
-				precision = input.Config.Precision
-			}
-
-			acc := NewAccumulator(input, dst)
-			acc.SetPrecision(getPrecision(precision, interval))
-
-			err := si.Start(ctx, acc)
+			err := si.Start(ctx, a.serviceAccumulator(input, dst))
 			if err != nil {
-				stopServiceInputs(unit.inputs)
+				stopInputs(unit.inputs)
 				return nil, fmt.Errorf("starting input %s: %w", input.LogName(), err)
 			}
 		}
@@ -253,6 +431,52 @@ func (a *Agent) startInputs(
 	return unit, nil
 }
 
+// serviceAccumulator returns the accumulator passed to Start of a service
+// input.
+func (a *Agent) serviceAccumulator(input *models.RunningInput, dst chan<- cua.Metric) cua.Accumulator {
+	var interval time.Duration
+	var precision time.Duration
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
+
+	return newInputAccumulator(input, dst, getPrecision(precision, interval), newTimestampPolicy(a.Config.Agent, interval))
+}
+
+// restartServiceInput stops and starts a service input, e.g. after its
+// Gather hung.  Stop is given up on after timeout, the plugin is left as is
+// then.
+func (a *Agent) restartServiceInput(
+	ctx context.Context,
+	input *models.RunningInput,
+	dst chan<- cua.Metric,
+	timeout time.Duration,
+) {
+	si, ok := input.Input.(cua.ServiceInput)
+	if !ok {
+		return
+	}
+
+	log.Printf("W! [%s] Restarting service input", input.LogName())
+	stopped := make(chan struct{})
+	go func() {
+		si.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Printf("E! [%s] Stop did not complete within %s; not restarted", input.LogName(), timeout)
+		return
+	}
+
+	if err := si.Start(ctx, a.serviceAccumulator(input, dst)); err != nil {
+		log.Printf("E! [%s] Restarting input: %v", input.LogName(), err)
+		return
+	}
+	input.Restarts.Incr(1)
+}
+
 // runInputs starts and triggers the periodic gather for Inputs.
 //
 // When the context is done the timers are stopped and this function returns
@@ -290,20 +514,25 @@ func (a *Agent) runInputs(
 		}
 		defer ticker.Stop()
 
-		acc := NewAccumulator(input, unit.dst)
-		acc.SetPrecision(getPrecision(precision, interval))
+		// Overwrite agent gather_timeout if this plugin has its own.
+		timeout := a.Config.Agent.GatherTimeout.Duration
+		if input.Config.GatherTimeout != 0 {
+			timeout = input.Config.GatherTimeout
+		}
+
+		acc := newInputAccumulator(input, unit.dst, getPrecision(precision, interval), newTimestampPolicy(a.Config.Agent, interval))
 
 		wg.Add(1)
 		go func(input *models.RunningInput) {
 			defer wg.Done()
-			a.gatherLoop(ctx, acc, input, ticker, interval)
+			a.gatherLoop(ctx, acc, input, ticker, interval, timeout, unit.dst)
 		}(input)
 	}
 
 	wg.Wait()
 
 	log.Printf("D! [agent] Stopping service inputs")
-	stopServiceInputs(unit.inputs)
+	stopInputs(unit.inputs)
 
 	close(unit.dst)
 	log.Printf("D! [agent] Input channel closed")
@@ -403,18 +632,24 @@ func (a *Agent) testRunInputs(
 	_ = internal.SleepContext(ctx, wait)
 
 	log.Printf("D! [agent] Stopping service inputs")
-	stopServiceInputs(unit.inputs)
+	stopInputs(unit.inputs)
 
 	close(unit.dst)
 	log.Printf("D! [agent] Input channel closed")
 }
 
-// stopServiceInputs stops all service inputs.
-func stopServiceInputs(inputs []*models.RunningInput) {
+// stopInputs stops all service inputs and closes the inputs keeping
+// resources between collections.
+func stopInputs(inputs []*models.RunningInput) {
 	for _, input := range inputs {
 		if si, ok := input.Input.(cua.ServiceInput); ok {
 			si.Stop()
 		}
+		if ci, ok := input.Input.(cua.ClosingInput); ok {
+			if err := ci.Close(); err != nil {
+				log.Printf("E! [%s] Closing input: %v", input.LogName(), err)
+			}
+		}
 	}
 }
 
@@ -426,32 +661,72 @@ func (a *Agent) gatherLoop(
 	input *models.RunningInput,
 	ticker Ticker,
 	interval time.Duration,
+	timeout time.Duration,
+	dst chan<- cua.Metric,
 ) {
 	defer panicRecover(input)
 
+	// completion of a Gather abandoned after the timeout
+	var hung <-chan error
+
 	for {
 		select {
 		case <-ticker.Elapsed():
-			err := a.gatherOnce(ctx, acc, input, ticker, interval)
+			if hung != nil {
+				select {
+				case <-hung:
+					hung = nil
+				default:
+					log.Printf("W! [%s] Collection exceeding the gather timeout has not completed; scheduled collection skipped",
+						input.LogName())
+					continue
+				}
+			}
+
+			var err error
+			hung, err = a.gatherOnce(ctx, acc, input, ticker, interval, timeout)
 			if err != nil {
 				acc.AddError(err)
 			}
+			if hung != nil {
+				a.restartServiceInput(ctx, input, dst, timeout)
+			}
 		case <-ctx.Done():
+			// the abandoned Gather still writes to the accumulator
+			if hung != nil {
+				log.Printf("I! [%s] Waiting for collection exceeding the gather timeout to complete", input.LogName())
+				<-hung
+			}
 			return
 		}
 	}
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each
-// interval it fails to complete before.
+// interval it fails to complete before.  When a timeout is set, a Gather
+// exceeding it is abandoned; the returned channel receives its result once
+// it completes.
 func (a *Agent) gatherOnce(
 	ctx context.Context,
 	acc cua.Accumulator,
 	input *models.RunningInput,
 	ticker Ticker,
 	interval time.Duration,
-) error {
-	done := make(chan error)
+	timeout time.Duration,
+) (<-chan error, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// buffered, an abandoned Gather must not block once it completes
+	done := make(chan error, 1)
 	go func() {
 		done <- input.Gather(ctx, acc)
 	}()
@@ -465,7 +740,10 @@ func (a *Agent) gatherOnce(
 	for {
 		select {
 		case err := <-done:
-			return err
+			return nil, err
+		case <-deadline:
+			input.GatherTimeouts.Incr(1)
+			return done, fmt.Errorf("collection did not complete within gather timeout of %s", timeout)
 		case <-slowWarning.C:
 			log.Printf("W! [%s] Collection took longer than expected; not complete after interval of %s",
 				input.LogName(), interval)
@@ -681,7 +959,7 @@ func (a *Agent) startOutputs(
 // connectOutputs connects to all outputs.
 func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput) error {
 	log.Printf("D! [agent] Attempting connection to [%s]", output.LogName())
-	err := output.Output.Connect()
+	err := output.Connect()
 	if err != nil {
 		log.Printf("E! [agent] Failed to connect to [%s], retrying in 15s, "+
 			"error was '%s'", output.LogName(), err)
@@ -691,7 +969,7 @@ func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput)
 			return fmt.Errorf("sleepcontext: %w", err)
 		}
 
-		err = output.Output.Connect()
+		err = output.Connect()
 		if err != nil {
 			return fmt.Errorf("Error connecting to output %q: %w", output.LogName(), err)
 		}
@@ -706,15 +984,14 @@ func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput)
 func (a *Agent) runOutputs(
 	unit *outputUnit,
 ) {
-	var wg sync.WaitGroup
-
 	// Start flush loop
 	interval := a.Config.Agent.FlushInterval.Duration
 	jitter := a.Config.Agent.FlushJitter.Duration
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	for _, output := range unit.outputs {
+	flushed := make([]chan struct{}, len(unit.outputs))
+	for i, output := range unit.outputs {
 		interval := interval
 		// Overwrite agent flush_interval if this plugin has its own.
 		if output.Config.FlushInterval != 0 {
@@ -727,20 +1004,30 @@ func (a *Agent) runOutputs(
 			jitter = output.Config.FlushJitter
 		}
 
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
+		flushed[i] = make(chan struct{})
+		go func(output *models.RunningOutput, done chan struct{}) {
+			defer close(done)
 
 			ticker := NewRollingTicker(interval, jitter)
 			defer ticker.Stop()
 
 			a.flushLoop(ctx, output, ticker)
-		}(output)
+		}(output, flushed[i])
+	}
+
+	pipelines := make(map[string][]*models.RunningOutput)
+	for _, output := range unit.outputs {
+		pipelines[output.Pipeline()] = append(pipelines[output.Pipeline()], output)
 	}
 
 	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(a.Config.Outputs)-1 {
+		outputs := pipelines[models.PipelineName(metric.Pipeline())]
+		if len(outputs) == 0 {
+			metric.Drop()
+			continue
+		}
+		for i, output := range outputs {
+			if i == len(outputs)-1 {
 				output.AddMetric(metric)
 			} else {
 				output.AddMetric(metric.Copy())
@@ -750,7 +1037,28 @@ func (a *Agent) runOutputs(
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
-	wg.Wait()
+
+	waitCtx := context.Background()
+	if timeout := a.Config.Agent.ShutdownFlushTimeout.Duration; timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, timeout)
+		defer cancel()
+	}
+
+	for i, output := range unit.outputs {
+		select {
+		case <-flushed[i]:
+		case <-waitCtx.Done():
+		}
+		select {
+		case <-flushed[i]:
+			output.Close()
+		default:
+			// the final flush is abandoned, the output is still writing
+			log.Printf("E! [agent] Final flush of %s did not complete within shutdown_flush_timeout, %d metrics are not written",
+				output.LogName(), output.BufferLength())
+		}
+	}
 }
 
 // flushLoop runs an output's flush function periodically until the context is
@@ -1,12 +1,17 @@
 package agent
 
 import (
+	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/models"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/all"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/all"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -167,3 +172,173 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+type recordingOutput struct {
+	sync.Mutex
+	metrics []cua.Metric
+}
+
+func (o *recordingOutput) Connect() error       { return nil }
+func (o *recordingOutput) Close() error         { return nil }
+func (o *recordingOutput) Description() string  { return "" }
+func (o *recordingOutput) SampleConfig() string { return "" }
+func (o *recordingOutput) Write(metrics []cua.Metric) (int, error) {
+	o.Lock()
+	defer o.Unlock()
+	o.metrics = append(o.metrics, metrics...)
+	return len(metrics), nil
+}
+
+func (o *recordingOutput) names() []string {
+	o.Lock()
+	defer o.Unlock()
+	names := make([]string, 0, len(o.metrics))
+	for _, m := range o.metrics {
+		names = append(names, m.Name())
+	}
+	return names
+}
+
+func TestAgent_RunOutputsFilteredPerOutput(t *testing.T) {
+	c := config.NewConfig()
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	newOutput := func(name string, filter models.Filter) (*models.RunningOutput, *recordingOutput) {
+		require.NoError(t, filter.Compile())
+		out := &recordingOutput{}
+		return models.NewRunningOutput(name, out, &models.OutputConfig{
+			Name:          name,
+			Filter:        filter,
+			FlushInterval: time.Hour,
+		}, 0, 0), out
+	}
+	primary, primaryOut := newOutput("primary", models.Filter{NameDrop: []string{"audit_*"}})
+	audit, auditOut := newOutput("audit", models.Filter{NamePass: []string{"audit_*"}})
+
+	src := make(chan cua.Metric, 3)
+	src <- testutil.TestMetric(1, "cpu")
+	src <- testutil.TestMetric(1, "audit_login")
+	src <- testutil.TestMetric(1, "mem")
+	close(src)
+
+	// outputs are flushed when the source is drained
+	a.runOutputs(&outputUnit{src: src, outputs: []*models.RunningOutput{primary, audit}})
+
+	require.Equal(t, []string{"cpu", "mem"}, primaryOut.names())
+	require.Equal(t, []string{"audit_login"}, auditOut.names())
+}
+
+type hangingInput struct {
+	release chan struct{}
+}
+
+func (i *hangingInput) SampleConfig() string { return "" }
+func (i *hangingInput) Description() string  { return "" }
+func (i *hangingInput) Gather(_ context.Context, acc cua.Accumulator) error {
+	<-i.release
+	acc.AddFields("hung", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+type closingInput struct {
+	closed int
+}
+
+func (i *closingInput) SampleConfig() string                          { return "" }
+func (i *closingInput) Description() string                           { return "" }
+func (i *closingInput) Gather(context.Context, cua.Accumulator) error { return nil }
+func (i *closingInput) Close() error {
+	i.closed++
+	return nil
+}
+
+func TestAgent_RunInputsClosesInputs(t *testing.T) {
+	plugin := &closingInput{}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "closing"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := &Agent{Config: config.NewConfig()}
+	a.runInputs(ctx, time.Now(), &inputUnit{
+		dst:    make(chan cua.Metric, 1),
+		inputs: []*models.RunningInput{input},
+	})
+	require.Equal(t, 1, plugin.closed)
+}
+
+type manualTicker struct {
+	ch chan time.Time
+}
+
+func (t *manualTicker) Elapsed() <-chan time.Time { return t.ch }
+func (t *manualTicker) Stop()                     {}
+
+func TestAgent_GatherTimeout(t *testing.T) {
+	plugin := &hangingInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "hanging"})
+	ticker := &manualTicker{ch: make(chan time.Time)}
+	acc := &testutil.Accumulator{}
+
+	a := &Agent{}
+	hung, err := a.gatherOnce(context.Background(), acc, input, ticker, time.Minute, 10*time.Millisecond)
+	require.Error(t, err)
+	require.NotNil(t, hung)
+	require.Equal(t, int64(1), input.GatherTimeouts.Get())
+
+	// the abandoned gather completes later without blocking
+	close(plugin.release)
+	require.NoError(t, <-hung)
+	require.Equal(t, uint64(1), acc.NMetrics())
+
+	hung, err = a.gatherOnce(context.Background(), acc, input, ticker, time.Minute, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Nil(t, hung)
+}
+
+type blockingOutput struct {
+	release chan struct{}
+	closed  bool
+}
+
+func (o *blockingOutput) SampleConfig() string { return "" }
+func (o *blockingOutput) Description() string  { return "" }
+func (o *blockingOutput) Connect() error       { return nil }
+func (o *blockingOutput) Close() error {
+	o.closed = true
+	return nil
+}
+func (o *blockingOutput) Write(metrics []cua.Metric) (int, error) {
+	<-o.release
+	return len(metrics), nil
+}
+
+func TestAgent_ShutdownFlushTimeout(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.FlushInterval.Duration = time.Hour
+	c.Agent.ShutdownFlushTimeout.Duration = 50 * time.Millisecond
+	a := &Agent{Config: c}
+
+	hung := &blockingOutput{release: make(chan struct{})}
+	flushed := &blockingOutput{release: make(chan struct{})}
+	close(flushed.release)
+
+	src := make(chan cua.Metric, 1)
+	src <- testutil.TestMetric(42)
+	close(src)
+	unit := &outputUnit{
+		src: src,
+		outputs: []*models.RunningOutput{
+			models.NewRunningOutput("hung", hung, &models.OutputConfig{Name: "hung"}, 100, 1000),
+			models.NewRunningOutput("flushed", flushed, &models.OutputConfig{Name: "flushed"}, 100, 1000),
+		},
+	}
+
+	a.runOutputs(unit)
+	require.False(t, hung.closed)
+	require.Equal(t, 1, unit.outputs[0].BufferLength())
+	require.True(t, flushed.closed)
+	require.Equal(t, 0, unit.outputs[1].BufferLength())
+	close(hung.release)
+}
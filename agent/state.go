@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// stateStore keeps the state of stateful plugins in a JSON file, an object
+// with the state of each plugin instance keyed by the plugin ID.
+type stateStore struct {
+	filename string
+	plugins  map[string]cua.StatefulPlugin
+}
+
+func newStateStore(filename string) *stateStore {
+	return &stateStore{
+		filename: filename,
+		plugins:  make(map[string]cua.StatefulPlugin),
+	}
+}
+
+// register adds a plugin to the store if it is stateful.  The ID must be
+// unique, e.g. by setting the alias or instance_id of plugins of the same
+// type.
+func (s *stateStore) register(id string, plugin interface{}) error {
+	sp, ok := plugin.(cua.StatefulPlugin)
+	if !ok {
+		return nil
+	}
+	if _, found := s.plugins[id]; found {
+		return fmt.Errorf("duplicate state id %q, set distinct aliases, or instance_ids of inputs, to persist the state of each plugin", id)
+	}
+	s.plugins[id] = sp
+	return nil
+}
+
+// load restores the stored state of the registered plugins.  A missing file
+// is not an error, nothing has been stored yet.
+func (s *stateStore) load() error {
+	if len(s.plugins) == 0 {
+		return nil
+	}
+
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	for id, plugin := range s.plugins {
+		raw, ok := states[id]
+		if !ok {
+			continue
+		}
+
+		// decode into the type of state the plugin hands out
+		var state interface{}
+		if current := plugin.GetState(); current != nil {
+			v := reflect.New(reflect.TypeOf(current))
+			if err := json.Unmarshal(raw, v.Interface()); err != nil {
+				return fmt.Errorf("decoding state of %s: %w", id, err)
+			}
+			state = v.Elem().Interface()
+		} else if err := json.Unmarshal(raw, &state); err != nil {
+			return fmt.Errorf("decoding state of %s: %w", id, err)
+		}
+
+		if err := plugin.SetState(state); err != nil {
+			return fmt.Errorf("restoring state of %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// read answers the stored states keyed by plugin ID, none when the file is
+// missing.
+func (s *stateStore) read() (map[string]json.RawMessage, error) {
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading statefile: %w", err)
+	}
+
+	var states map[string]json.RawMessage
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("parsing statefile %s: %w", s.filename, err)
+	}
+	return states, nil
+}
+
+// store writes the state of the registered plugins, keeping the stored
+// state of the other plugins, e.g. removed from the configuration or failing
+// to load for now.  The file is replaced atomically, so a crash while
+// writing leaves the previous state.
+func (s *stateStore) store() error {
+	if len(s.plugins) == 0 {
+		return nil
+	}
+
+	stored, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(s.plugins))
+	for id := range s.plugins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	states := make(map[string]interface{}, len(stored)+len(ids))
+	for id, raw := range stored {
+		states[id] = raw
+	}
+	for _, id := range ids {
+		states[id] = s.plugins[id].GetState()
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.filename), filepath.Base(s.filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf("writing statefile: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing statefile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing statefile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.filename); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing statefile: %w", err)
+	}
+	return nil
+}
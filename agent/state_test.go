@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type statefulPlugin struct {
+	state map[string]int64
+}
+
+func (p *statefulPlugin) GetState() interface{} {
+	return p.state
+}
+
+func (p *statefulPlugin) SetState(state interface{}) error {
+	s, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	p.state = s
+	return nil
+}
+
+func TestStateStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+
+	s := newStateStore(filename)
+	tail := &statefulPlugin{state: map[string]int64{"/var/log/messages": 42}}
+	require.NoError(t, s.register("inputs.tail", tail))
+	require.NoError(t, s.register("inputs.cpu", struct{}{}))
+	// nothing stored yet
+	require.NoError(t, s.load())
+	require.NoError(t, s.store())
+
+	data, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"inputs.tail":{"/var/log/messages":42}}`, string(data))
+
+	s = newStateStore(filename)
+	restored := &statefulPlugin{state: map[string]int64{}}
+	other := &statefulPlugin{state: map[string]int64{}}
+	require.NoError(t, s.register("inputs.tail", restored))
+	require.NoError(t, s.register("inputs.tail::other", other))
+	require.NoError(t, s.load())
+	require.Equal(t, map[string]int64{"/var/log/messages": 42}, restored.state)
+	require.Empty(t, other.state)
+}
+
+func TestStateStoreKeepsOtherPlugins(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`{"inputs.tail::removed":{"/var/log/old":7}}`), 0600))
+
+	s := newStateStore(filename)
+	tail := &statefulPlugin{state: map[string]int64{"/var/log/messages": 42}}
+	require.NoError(t, s.register("inputs.tail", tail))
+	require.NoError(t, s.load())
+	require.NoError(t, s.store())
+
+	data, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"inputs.tail":{"/var/log/messages":42},"inputs.tail::removed":{"/var/log/old":7}}`, string(data))
+}
+
+func TestStateStoreDuplicateID(t *testing.T) {
+	s := newStateStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, s.register("inputs.tail", &statefulPlugin{}))
+	require.Error(t, s.register("inputs.tail", &statefulPlugin{}))
+}
+
+func TestStateStoreInvalidFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("not json"), 0600))
+
+	s := newStateStore(filename)
+	require.NoError(t, s.register("inputs.tail", &statefulPlugin{}))
+	require.Error(t, s.load())
+}
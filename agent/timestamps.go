@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// timestampPolicy adjusts the timestamps of the metrics of an input beyond
+// rounding them to the precision
+type timestampPolicy struct {
+	now       func() time.Time
+	align     time.Duration // interval gauges are aligned to, 0 for none
+	maxPast   time.Duration
+	maxFuture time.Duration
+	truncate  bool
+	clamp     bool
+}
+
+// newTimestampPolicy answers the policy of the agent timestamp settings for
+// an input collecting each interval, 0 for service inputs, or nil when the
+// timestamps are only rounded
+func newTimestampPolicy(ac *config.AgentConfig, interval time.Duration) *timestampPolicy {
+	p := &timestampPolicy{
+		now:       time.Now,
+		maxPast:   ac.TimestampMaxPast.Duration,
+		maxFuture: ac.TimestampMaxFuture.Duration,
+		truncate:  ac.TimestampTruncate,
+		clamp:     ac.TimestampOutOfRange == "clamp",
+	}
+	if ac.AlignGaugeTimestamps {
+		p.align = interval
+	}
+	if p.align == 0 && p.maxPast == 0 && p.maxFuture == 0 && !p.truncate {
+		return nil
+	}
+	return p
+}
+
+// adjust answers the timestamp of a metric of the type, false when the
+// metric is to be dropped
+func (p *timestampPolicy) adjust(t time.Time, tp cua.ValueType, precision time.Duration) (time.Time, bool) {
+	if p.truncate {
+		t = t.Truncate(precision)
+	} else {
+		t = t.Round(precision)
+	}
+	if p.align > 0 && (tp == cua.Gauge || tp == cua.Untyped) {
+		t = t.Truncate(p.align)
+	}
+
+	if p.maxPast > 0 || p.maxFuture > 0 {
+		now := p.now()
+		if p.maxPast > 0 && t.Before(now.Add(-p.maxPast)) {
+			if !p.clamp {
+				return t, false
+			}
+			t = now.Add(-p.maxPast)
+		}
+		if p.maxFuture > 0 && t.After(now.Add(p.maxFuture)) {
+			if !p.clamp {
+				return t, false
+			}
+			t = now.Add(p.maxFuture)
+		}
+	}
+	return t, true
+}
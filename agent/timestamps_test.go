@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampPolicy(t *testing.T) {
+	require.Nil(t, newTimestampPolicy(&config.AgentConfig{}, 10*time.Second))
+	// alignment only applies to inputs collecting each interval
+	require.Nil(t, newTimestampPolicy(&config.AgentConfig{AlignGaugeTimestamps: true}, 0))
+
+	now := time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC)
+	ts := now.Add(-3*time.Second - 700*time.Millisecond) // 10:20:26.3
+
+	p := newTimestampPolicy(&config.AgentConfig{
+		TimestampTruncate:    true,
+		AlignGaugeTimestamps: true,
+	}, 5*time.Second)
+	p.now = func() time.Time { return now }
+
+	adjusted, ok := p.adjust(ts, cua.Counter, time.Second)
+	require.True(t, ok)
+	require.Equal(t, now.Add(-4*time.Second), adjusted)
+	adjusted, ok = p.adjust(ts, cua.Gauge, time.Second)
+	require.True(t, ok)
+	require.Equal(t, now.Add(-5*time.Second), adjusted)
+
+	p = newTimestampPolicy(&config.AgentConfig{
+		TimestampMaxPast:   internal.Duration{Duration: time.Minute},
+		TimestampMaxFuture: internal.Duration{Duration: time.Second},
+	}, 5*time.Second)
+	p.now = func() time.Time { return now }
+
+	adjusted, ok = p.adjust(ts, cua.Gauge, time.Second)
+	require.True(t, ok)
+	require.Equal(t, now.Add(-4*time.Second), adjusted)
+	_, ok = p.adjust(now.Add(-time.Hour), cua.Gauge, time.Second)
+	require.False(t, ok)
+	_, ok = p.adjust(now.Add(time.Hour), cua.Gauge, time.Second)
+	require.False(t, ok)
+
+	p.clamp = true
+	adjusted, ok = p.adjust(now.Add(-time.Hour), cua.Gauge, time.Second)
+	require.True(t, ok)
+	require.Equal(t, now.Add(-time.Minute), adjusted)
+	adjusted, ok = p.adjust(now.Add(time.Hour), cua.Gauge, time.Second)
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Second), adjusted)
+}
+
+func TestInputAccumulatorDropsOutOfRange(t *testing.T) {
+	metrics := make(chan cua.Metric, 10)
+	defer close(metrics)
+	p := newTimestampPolicy(&config.AgentConfig{
+		TimestampMaxPast: internal.Duration{Duration: time.Minute},
+	}, 0)
+	a := newInputAccumulator(&TestMetricMaker{}, metrics, time.Second, p)
+
+	a.AddGauge("acctest", map[string]interface{}{"value": 1}, nil, time.Now().Add(-time.Hour))
+	a.AddGauge("acctest", map[string]interface{}{"value": 2}, nil)
+
+	testm := <-metrics
+	require.Equal(t, map[string]interface{}{"value": int64(2)}, testm.Fields())
+	require.Len(t, metrics, 0)
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	_ "net/http/pprof" //nolint:gosec // G108 -- Comment this line to disable pprof endpoint.
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"syscall"
@@ -23,7 +25,9 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/internal/circonus"
 	"github.com/circonus-labs/circonus-unified-agent/internal/goplugin"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/internal/release"
+	"github.com/circonus-labs/circonus-unified-agent/internal/sdnotify"
 	"github.com/circonus-labs/circonus-unified-agent/logger"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/all"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
@@ -38,16 +42,22 @@ var fDebug = flag.Bool("debug", false,
 	"turn on debug logging")
 var pprofAddr = flag.String("pprof-addr", "",
 	"pprof address to listen on, not activate pprof if empty")
+var fProfileDir = flag.String("profile-dir", "",
+	"directory to dump goroutine stacks and heap profiles to on SIGUSR1, defaults to the temp directory")
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false,
-	"enable test mode: gather metrics, print them out, and exit. Note: Test mode only runs inputs, not processors, aggregators, or outputs")
+	"enable test mode: gather metrics, print them out, and exit. Note: Test mode runs inputs, processors and aggregators, not outputs")
 var fTestWait = flag.Int("test-wait", 0,
 	"wait up to this many seconds for service inputs to complete in test mode")
 var fConfig = flag.String("config", "",
 	"configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fConfigPublicKey = flag.String("config-public-key", "",
+	"ed25519 public key verifying the signatures of configs fetched over http(s)")
+var fConfigPollInterval = flag.Duration("config-poll-interval", 0,
+	"interval to check configs fetched over http(s) for changes, not checked if zero")
 var fVersion = flag.Bool("version", false,
 	"display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
@@ -73,7 +83,7 @@ var fUsage = flag.String("usage", "",
 var fPlugins = flag.String("plugin-directory", "",
 	"path to directory containing external plugins")
 var fRunOnce = flag.Bool("once", false,
-	"run one gather and exit")
+	"run one gather, write the metrics to the outputs, and exit")
 
 var (
 	version   string
@@ -85,6 +95,14 @@ var (
 
 var stop chan struct{}
 
+// reloadRequests asks the reload loop to load the config again, e.g. when
+// the inventory of inputs instantiated per discovered target changed; the
+// request is the reason logged
+var reloadRequests = make(chan string, 1)
+
+// remote keeps the configs fetched over http(s) across reloads
+var remote *config.Remote
+
 func reloadLoop(
 	inputFilters []string,
 	outputFilters []string,
@@ -105,10 +123,23 @@ func reloadLoop(
 			case sig := <-signals:
 				if sig == syscall.SIGHUP {
 					log.Printf("I! Reloading config")
+					// fetch the remote configs again
+					remote.Forget()
+					if _, err := sdnotify.Notify(sdnotify.Reloading); err != nil {
+						log.Printf("E! Notifying service manager: %v", err)
+					}
 					<-reload
 					reload <- true
 				}
 				cancel()
+			case reason := <-reloadRequests:
+				log.Printf("I! Reloading config, %s", reason)
+				if _, err := sdnotify.Notify(sdnotify.Reloading); err != nil {
+					log.Printf("E! Notifying service manager: %v", err)
+				}
+				<-reload
+				reload <- true
+				cancel()
 			case <-stop:
 				cancel()
 			}
@@ -131,16 +162,10 @@ func runAgent(ctx context.Context,
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
-	err := c.LoadConfig(*fConfig)
-	if err != nil {
-		return fmt.Errorf("loadconfig (%s): %w", *fConfig, err)
+	if err := loadConfigs(c, remote); err != nil {
+		return err
 	}
-
 	if *fConfigDirectory != "" {
-		err = c.LoadDirectory(*fConfigDirectory)
-		if err != nil {
-			return fmt.Errorf("loaddir (%s): %w", *fConfigDirectory, err)
-		}
 		log.Printf("I! Completed loading configs from %s", *fConfigDirectory)
 	}
 
@@ -158,6 +183,11 @@ func runAgent(ctx context.Context,
 	if len(c.Tags) > 0 {
 		circonus.AddGlobalTags(c.Tags)
 	}
+	for _, input := range c.Inputs {
+		if len(input.Config.Tags) > 0 {
+			circonus.AddInstanceTags(input.Config.InstanceID, input.Config.Tags)
+		}
+	}
 
 	if !*fTest && len(c.Outputs) == 0 {
 		return fmt.Errorf("Error: no outputs found, did you provide a valid config file?")
@@ -171,7 +201,7 @@ func runAgent(ctx context.Context,
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s", c.Agent.Interval.Duration)
+		return fmt.Errorf("Agent flush_interval must be positive; found %s", c.Agent.FlushInterval.Duration)
 	}
 
 	ag, err := agent.NewAgent(c)
@@ -188,6 +218,10 @@ func runAgent(ctx context.Context,
 		RotationInterval:    ag.Config.Agent.LogfileRotationInterval,
 		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
 		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		LogFormat:           ag.Config.Agent.LogFormat,
+		LogLevels:           ag.Config.Agent.LogLevels,
+		RateLimit:           ag.Config.Agent.LogRateLimit,
+		RateLimitInterval:   ag.Config.Agent.LogRateLimitInterval,
 	}
 
 	logger.SetupLogging(logConfig)
@@ -208,6 +242,14 @@ func runAgent(ctx context.Context,
 	log.Printf("I! Loaded outputs: %s", strings.Join(c.OutputNames(), " "))
 	log.Printf("I! Tags enabled: %s", c.ListTags())
 
+	if lite.Enabled() {
+		log.Printf("I! Running in lite mode")
+		// trade collection cycles for a smaller heap, unless tuned by GOGC
+		if os.Getenv("GOGC") == "" {
+			debug.SetGCPercent(lite.GCPercent)
+		}
+	}
+
 	if *fPidfile != "" {
 		f, err := os.OpenFile(*fPidfile, os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
@@ -226,9 +268,102 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	if interval := c.InventoryInterval(); interval > 0 {
+		go watchInventory(ctx, c, interval)
+	}
+	if *fConfigPollInterval > 0 && remote.Fetched() {
+		go watchRemote(ctx, inputFilters, outputFilters, *fConfigPollInterval)
+	}
+
 	return ag.Run(ctx)
 }
 
+// watchInventory requests reloading the config when the targets of inputs
+// instantiated from an inventory changed
+func watchInventory(ctx context.Context, c *config.Config, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.InventoryChanged(ctx) {
+				continue
+			}
+			select {
+			case reloadRequests <- "inventory changed":
+			default:
+			}
+			return
+		}
+	}
+}
+
+// loadConfigs loads the config file and directory of the flags into c,
+// fetching the remote configs with r
+func loadConfigs(c *config.Config, r *config.Remote) error {
+	c.Remote = r
+	if err := c.LoadConfig(*fConfig); err != nil {
+		return fmt.Errorf("loadconfig (%s): %w", *fConfig, err)
+	}
+	if *fConfigDirectory != "" {
+		if err := c.LoadDirectory(*fConfigDirectory); err != nil {
+			return fmt.Errorf("loaddir (%s): %w", *fConfigDirectory, err)
+		}
+	}
+	return nil
+}
+
+// watchRemote requests reloading the config when a config fetched over
+// http(s) changed. The changes are only applied once they were verified
+// and the whole config loaded without error; until then, and when they
+// are rejected, the agent keeps running with the config it has.
+func watchRemote(ctx context.Context, inputFilters, outputFilters []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := remote.Poll(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("E! Checking remote config: %v", err)
+			}
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		staged := config.NewConfig()
+		staged.OutputFilters = outputFilters
+		staged.InputFilters = inputFilters
+		err = loadConfigs(staged, remote.Staged())
+		if err == nil {
+			remote.Apply()
+			select {
+			case reloadRequests <- "remote config changed":
+			default:
+			}
+			return
+		}
+		log.Printf("E! Rejecting changed remote config: %v", err)
+		remote.Discard()
+		// loading set the agent modes, e.g. fips_mode, of the rejected
+		// config; load the applied config to set them back
+		applied := config.NewConfig()
+		applied.OutputFilters = outputFilters
+		applied.InputFilters = inputFilters
+		if err := loadConfigs(applied, remote); err != nil {
+			log.Printf("E! Loading applied remote config: %v", err)
+		}
+	}
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage) //nolint
 	os.Exit(rc)
@@ -281,6 +416,16 @@ func main() {
 	flag.Parse()
 	args := flag.Args()
 
+	// flags may follow the command as well, e.g.
+	// config --section-filter inputs --input-filter snmp_trap:mongodb
+	if len(args) > 0 && (args[0] == "config" || args[0] == "version") {
+		command := args[0]
+		if err := flag.CommandLine.Parse(args[1:]); err != nil {
+			usageExit(1)
+		}
+		args = append([]string{command}, flag.Args()...)
+	}
+
 	sectionFilters, inputFilters, outputFilters := []string{}, []string{}, []string{}
 	if *fSectionFilters != "" {
 		sectionFilters = strings.Split(":"+strings.TrimSpace(*fSectionFilters)+":", ":")
@@ -399,6 +544,16 @@ func main() {
 		log.Println("circonus-unified-agent version already configured to: " + internal.Version())
 	}
 
+	var publicKey ed25519.PublicKey
+	if *fConfigPublicKey != "" {
+		key, err := config.LoadPublicKey(*fConfigPublicKey)
+		if err != nil {
+			log.Fatal("E! " + err.Error())
+		}
+		publicKey = key
+	}
+	remote = config.NewRemote(publicKey)
+
 	run(
 		inputFilters,
 		outputFilters,
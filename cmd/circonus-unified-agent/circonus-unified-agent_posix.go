@@ -3,8 +3,16 @@
 
 package main
 
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
 func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []string) {
 	stop = make(chan struct{})
+	go dumpProfilesOnSignal()
 	reloadLoop(
 		inputFilters,
 		outputFilters,
@@ -12,3 +20,15 @@ func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []stri
 		processorFilters,
 	)
 }
+
+// dumpProfilesOnSignal dumps the goroutine stacks and a heap profile to the
+// profile directory on each SIGUSR1.
+func dumpProfilesOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	for range signals {
+		if err := dumpProfiles(*fProfileDir); err != nil {
+			log.Printf("E! Dumping profiles: %v", err)
+		}
+	}
+}
@@ -5,18 +5,23 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/logger"
 	"github.com/kardianos/service"
+	"golang.org/x/sys/windows/svc/mgr"
 )
 
 var fService = flag.String("service", "", "operate on the service (windows only)")
 var fServiceName = flag.String("service-name", "circonus-unified-agent", "service name (windows only)")
 var fServiceDisplayName = flag.String("service-display-name", "Circonus Unified Agent Data Collector Service", "service display name (windows only)")
 var fRunAsConsole = flag.Bool("console", false, "run as console application (windows only)")
+var fServiceDelayedStart = flag.Bool("service-delayed-start", false, "start the installed service delayed after the other automatic services (windows only)")
+var fServiceRestartDelay = flag.Duration("service-restart-delay", time.Minute, "restart the installed service this long after it failed, 0 to not restart it (windows only)")
 
 func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []string) {
 	if runtime.GOOS == "windows" && windowsRunAsService() {
@@ -94,6 +99,12 @@ func runAsWindowsService(inputFilters, outputFilters, aggregatorFilters, process
 		if *fConfigDirectory != "" {
 			svcConfig.Arguments = append(svcConfig.Arguments, "--config-directory", *fConfigDirectory)
 		}
+		if *fConfigPublicKey != "" {
+			svcConfig.Arguments = append(svcConfig.Arguments, "--config-public-key", *fConfigPublicKey)
+		}
+		if *fConfigPollInterval > 0 {
+			svcConfig.Arguments = append(svcConfig.Arguments, "--config-poll-interval", fConfigPollInterval.String())
+		}
 		// set servicename to service cmd line, to have a custom name after relaunch as a service
 		svcConfig.Arguments = append(svcConfig.Arguments, "--service-name", *fServiceName)
 
@@ -101,6 +112,11 @@ func runAsWindowsService(inputFilters, outputFilters, aggregatorFilters, process
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
+		if *fService == "install" {
+			if err := configureWindowsService(*fServiceName); err != nil {
+				log.Fatal("E! " + err.Error())
+			}
+		}
 		os.Exit(0)
 	} else {
 		winlogger, err := s.Logger(nil)
@@ -117,6 +133,46 @@ func runAsWindowsService(inputFilters, outputFilters, aggregatorFilters, process
 	}
 }
 
+// configureWindowsService sets the start type and the recovery actions of
+// the installed service.
+func configureWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("opening service %s: %w", name, err)
+	}
+	defer s.Close()
+
+	if *fServiceDelayedStart {
+		cfg, err := s.Config()
+		if err != nil {
+			return fmt.Errorf("reading config of service %s: %w", name, err)
+		}
+		cfg.DelayedAutoStart = true
+		if err := s.UpdateConfig(cfg); err != nil {
+			return fmt.Errorf("setting delayed start of service %s: %w", name, err)
+		}
+	}
+
+	if *fServiceRestartDelay > 0 {
+		// restart after each failure, counting failures anew after a day
+		actions := []mgr.RecoveryAction{
+			{Type: mgr.ServiceRestart, Delay: *fServiceRestartDelay},
+			{Type: mgr.ServiceRestart, Delay: *fServiceRestartDelay},
+			{Type: mgr.ServiceRestart, Delay: *fServiceRestartDelay},
+		}
+		if err := s.SetRecoveryActions(actions, uint32((24 * time.Hour).Seconds())); err != nil {
+			return fmt.Errorf("setting recovery actions of service %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // Return true if agent should create a Windows service.
 func windowsRunAsService() bool {
 	if *fService != "" {
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// dumpProfiles writes the stacks of all goroutines and a heap profile to
+// dir, named by the current time, for diagnosing a running agent.
+func dumpProfiles(dir string) error {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	ts := time.Now().UTC().Format("20060102T150405Z")
+
+	goroutines := filepath.Join(dir, "circonus-unified-agent-goroutines-"+ts+".txt")
+	if err := writeProfile(goroutines, func(f *os.File) error {
+		return pprof.Lookup("goroutine").WriteTo(f, 2) //nolint:wrapcheck
+	}); err != nil {
+		return err
+	}
+
+	heap := filepath.Join(dir, "circonus-unified-agent-heap-"+ts+".pprof")
+	if err := writeProfile(heap, func(f *os.File) error {
+		// up to date statistics of the live objects
+		runtime.GC()
+		return pprof.WriteHeapProfile(f) //nolint:wrapcheck
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("I! Wrote goroutine stacks to %s and heap profile to %s", goroutines, heap)
+	return nil
+}
+
+func writeProfile(filename string, write func(*os.File) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("creating profile: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing profile %s: %w", filename, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing profile %s: %w", filename, err)
+	}
+	return nil
+}
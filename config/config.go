@@ -3,10 +3,7 @@ package config
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -18,9 +15,14 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/capability"
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/models"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/aggregators"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/election"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
@@ -72,12 +74,22 @@ type Config struct {
 	OutputFilters []string
 
 	Agent       *AgentConfig
+	Pipelines   map[string]*Pipeline
 	Inputs      []*models.RunningInput
 	Outputs     []*models.RunningOutput
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors    models.RunningProcessors
 	AggProcessors models.RunningProcessors
+
+	inventories []*inventory
+
+	// Remote fetches the configs given as http(s) URLs
+	Remote *Remote
+
+	allowedPlugins filter.Filter
+
+	externalTagsLoaded bool
 }
 
 // NewConfig creates a new struct to hold the agent config.
@@ -96,6 +108,7 @@ func NewConfig() *Config {
 		},
 
 		Tags:          make(map[string]string),
+		Pipelines:     make(map[string]*Pipeline),
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
@@ -129,6 +142,18 @@ type AgentConfig struct {
 	// is determined by the "logfile" setting.
 	LogTarget string `toml:"logtarget"`
 
+	// LogFormat is the format of log lines, "text" or "json".
+	LogFormat string `toml:"log_format"`
+
+	// LogLevels overrides the log level of plugins, e.g. "debug" for
+	// "inputs.snmp_trap".
+	LogLevels map[string]string `toml:"log_levels"`
+
+	// LogRateLimit is the number of similar log messages of a plugin passed
+	// each LogRateLimitInterval, further ones are summarized.
+	LogRateLimit         int               `toml:"log_rate_limit"`
+	LogRateLimitInterval internal.Duration `toml:"log_rate_limit_interval"`
+
 	Circonus CirconusConfig `toml:"circonus"`
 
 	// FlushInterval is the Interval at which to flush data
@@ -146,6 +171,15 @@ type AgentConfig struct {
 	// same time, which can have a measurable effect on the system.
 	CollectionJitter internal.Duration
 
+	// ShutdownFlushTimeout is the deadline of the final flush of the outputs
+	// on shutdown, metrics not written by then are lost; 0 for no deadline.
+	ShutdownFlushTimeout internal.Duration
+
+	// GatherTimeout is the deadline of each Gather of an input.  Gathers
+	// exceeding it are abandoned and further gathers skipped until the
+	// hung one returns, service inputs are restarted; 0 for no timeout.
+	GatherTimeout internal.Duration
+
 	// MetricBufferLimit is the max number of metrics that each output plugin
 	// will cache. The buffer is cleared when a successful write occurs. When
 	// full, the oldest metrics will be overwritten. This number should be a
@@ -153,6 +187,19 @@ type AgentConfig struct {
 	// not be less than 2 times MetricBatchSize.
 	MetricBufferLimit int
 
+	// MetricBufferOverflow is the policy of a full output buffer, drop_oldest
+	// (the default) overwrites the oldest metrics, drop_newest keeps them and
+	// drops new metrics instead.
+	MetricBufferOverflow string
+
+	// MaxSeriesPerInput is the number of unique series each input may
+	// produce per interval, metrics of further series are dropped.
+	MaxSeriesPerInput int
+
+	// MaxMetricsPerFlush is the number of metrics each output accepts
+	// between flushes, further metrics are dropped.
+	MaxMetricsPerFlush int
+
 	// Maximum number of rotated archives to keep, any older logs are deleted.
 	// If set to -1, no archives are removed.
 	LogfileRotationMaxArchives int `toml:"logfile_rotation_max_archives"`
@@ -169,6 +216,22 @@ type AgentConfig struct {
 	// service input to set the timestamp at the appropriate precision.
 	Precision internal.Duration
 
+	// TimestampTruncate truncates timestamps to the precision rather than
+	// rounding them.
+	TimestampTruncate bool
+
+	// AlignGaugeTimestamps aligns the timestamps of gauges to the start of
+	// the collection interval.
+	AlignGaugeTimestamps bool
+
+	// TimestampMaxPast and TimestampMaxFuture limit how far the timestamps
+	// of metrics are from now, metrics beyond are handled according to
+	// TimestampOutOfRange, "drop" (the default) or "clamp" to the limit;
+	// 0 for no limit.
+	TimestampMaxPast    internal.Duration
+	TimestampMaxFuture  internal.Duration
+	TimestampOutOfRange string
+
 	// The file will be rotated after the time interval specified.  When set
 	// to 0 no time based rotation is performed.
 	LogfileRotationInterval internal.Duration `toml:"logfile_rotation_interval"`
@@ -192,6 +255,71 @@ type AgentConfig struct {
 
 	// Debug is the option for running in debug mode
 	Debug bool `toml:"debug"`
+
+	// Statefile is the file the state of stateful plugins, e.g. the offsets
+	// of tailed files, is kept in across restarts.  No state is kept when
+	// empty.
+	Statefile string
+
+	// DropCapabilities drops the Linux capabilities of the agent not needed
+	// by the configured plugins or listed in KeepCapabilities before the
+	// plugins are started.
+	DropCapabilities bool
+	KeepCapabilities []string
+
+	// FIPSMode restricts the plugins to FIPS approved algorithms, configuring
+	// others is an error.
+	FIPSMode bool `toml:"fips_mode"`
+
+	// LiteMode reduces the footprint of the agent for edge devices with
+	// smaller defaults of MetricBatchSize and MetricBufferLimit and a lower
+	// GC percent.  Always enabled in agents built with the lite tag.
+	LiteMode bool `toml:"lite_mode"`
+
+	// AllowedPlugins limits the plugins that can be configured, e.g.
+	// "inputs.cpu" or "outputs.*"; all plugins are allowed when empty.
+	AllowedPlugins []string `toml:"allowed_plugins"`
+
+	// DisableExec disables the plugins and settings running commands, and
+	// DisableMIBs the MIB lookups of the snmp plugins.
+	DisableExec bool `toml:"disable_exec"`
+	DisableMIBs bool `toml:"disable_mibs"`
+
+	// Trace selects the metrics traced through the pipeline.
+	Trace TraceConfig `toml:"trace"`
+
+	// Election elects one of the agents sharing a lock to collect the
+	// inputs with cluster_scope; all agents collect them when no type is
+	// set.
+	Election election.Config `toml:"election"`
+
+	// GlobalTagsFile and GlobalTagsCommand add the tags of a JSON or YAML
+	// object, read from the file or the output of the command, to the
+	// global tags when the config is loaded.
+	GlobalTagsFile    string   `toml:"global_tags_file"`
+	GlobalTagsCommand []string `toml:"global_tags_command"`
+}
+
+// Pipeline is a named group of inputs with their own processors, aggregators
+// and outputs, isolated from the other pipelines, e.g. for the metrics of
+// different teams on a shared host reported to their own Circonus accounts.
+// Plugins not configured for a pipeline are in the default one.
+type Pipeline struct {
+	// Tags are added to the metrics of the inputs of the pipeline, taking
+	// precedence over the global tags.
+	Tags map[string]string `toml:"tags"`
+
+	// MetricBatchSize and MetricBufferLimit are the defaults of the outputs
+	// of the pipeline, the agent settings when 0.
+	MetricBatchSize   int `toml:"metric_batch_size"`
+	MetricBufferLimit int `toml:"metric_buffer_limit"`
+}
+
+// TraceConfig selects the metrics logged at each stage of the pipeline, by
+// the namepass and tagpass filters of a plugin, to debug metrics not arriving.
+type TraceConfig struct {
+	NamePass []string            `toml:"namepass"`
+	TagPass  map[string][]string `toml:"tagpass"`
 }
 
 // CirconusConfig configures circonus check management
@@ -342,12 +470,29 @@ var agentConfig = `
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Policy of a full output buffer: "drop_oldest" overwrites the oldest
+  ## metrics, "drop_newest" keeps the buffered metrics and drops new ones.
+  # metric_buffer_overflow = "drop_oldest"
+
+  ## Guards against inputs producing far more metrics than expected, e.g. a
+  ## label explosion of a scrape.  Metrics of new series beyond
+  ## max_series_per_input per interval of an input, and metrics beyond
+  ## max_metrics_per_flush between flushes of an output, are dropped and
+  ## counted.  0 for no limit.
+  # max_series_per_input = 0
+  # max_metrics_per_flush = 0
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
   ## same time, which can have a measurable effect on the system.
   collection_jitter = "0s"
 
+  ## Deadline of each collection of an input.  A collection exceeding it is
+  ## reported and abandoned, so a hung plugin does not block its later
+  ## collections; service inputs are restarted.  0s for no deadline.
+  # gather_timeout = "0s"
+
   ## Default flushing interval for all outputs. Maximum flush_interval will be
   ## flush_interval + flush_jitter
   flush_interval = "10s"
@@ -356,6 +501,12 @@ var agentConfig = `
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
 
+  ## On shutdown the inputs are stopped and the outputs flushed a final time.
+  ## Deadline of the final flush, the metrics not written by then are lost.
+  ## Set it below the grace period of the service manager, e.g. of a
+  ## container, so the agent exits before it is killed.  0s for no deadline.
+  # shutdown_flush_timeout = "0s"
+
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
   ##   ie, when interval = "10s", precision will be "1s"
@@ -365,6 +516,63 @@ var agentConfig = `
   ## Valid time units are "ns", "us" (or "µs"), "ms", "s".
   precision = ""
 
+  ## Truncate timestamps to the precision rather than rounding them.
+  # timestamp_truncate = false
+
+  ## Align the timestamps of gauges and untyped metrics to the start of the
+  ## collection interval, e.g. to :00, :10, :20 with interval = "10s".
+  # align_gauge_timestamps = false
+
+  ## Limit how far the timestamps of metrics are in the past or future, the
+  ## broker silently drops samples too far from its clock.  Metrics beyond
+  ## the limits are dropped, or with timestamp_out_of_range = "clamp" given
+  ## the timestamp of the limit.  0s for no limit.
+  # timestamp_max_past = "0s"
+  # timestamp_max_future = "0s"
+  # timestamp_out_of_range = "drop"
+
+  ## File to keep the state of plugins, e.g. the offsets of tailed files, in
+  ## across restarts.  No state is kept when empty.
+  # statefile = ""
+
+  ## Drop the Linux capabilities of the agent, e.g. retained with
+  ## AmbientCapabilities of a systemd unit running the agent as unprivileged
+  ## user, not needed by the configured plugins, such as CAP_NET_RAW of the
+  ## native ping or CAP_NET_BIND_SERVICE of snmp_trap on port 162.
+  ## keep_capabilities lists further ones to keep, e.g. for commands run by
+  ## exec inputs.  Dropped capabilities are not regained on reload.
+  # drop_capabilities = false
+  # keep_capabilities = []
+
+  ## Restrict the plugins to FIPS approved algorithms: TLS 1.2 or later with
+  ## AES-GCM cipher suites, no MD5 or DES with SNMPv3.  Configuring others is
+  ## an error.  Always enabled in agents built with the fips tag.
+  # fips_mode = false
+
+  ## Reduce the footprint of the agent for edge gateways and other devices
+  ## low on memory: metric_batch_size and metric_buffer_limit default to 250
+  ## and 2500 when not set, and the heap is collected more often.  Always
+  ## enabled in agents built with the lite tag.
+  # lite_mode = false
+
+  ## Plugins allowed to be configured, e.g. ["inputs.cpu", "outputs.*"];
+  ## configuring others is an error.  All plugins are allowed when empty.
+  # allowed_plugins = []
+
+  ## Disable the plugins and settings running commands: the exec and execd
+  ## plugins and global_tags_command.
+  # disable_exec = false
+  ## Disable the MIB lookups of the snmp input and snmp_trap, only numeric
+  ## OIDs can be used.
+  # disable_mibs = false
+
+  ## Add the tags of a JSON or YAML object, e.g. {"rack": "r12"}, read from a
+  ## file or the output of a command to the global tags when the config is
+  ## loaded or reloaded.  Tags of global_tags take precedence, the command
+  ## over the file.
+  # global_tags_file = ""
+  # global_tags_command = []
+
   ## Log at debug level.
   # debug = false
   ## Log only error level messages.
@@ -375,6 +583,20 @@ var agentConfig = `
   ## is determined by the "logfile" setting.
   # logtarget = "file"
 
+  ## Format of the log lines, "text" or "json" lines with the time, level,
+  ## source and message of each log line.  Not used by the "eventlog" target.
+  # log_format = "text"
+
+  ## Log levels of individual plugins, overriding the debug and quiet
+  ## settings; one of "debug", "info", "warn" or "error".
+  # log_levels = { "inputs.snmp_trap" = "debug" }
+
+  ## Maximum number of similar messages, differing in numbers only, a plugin
+  ## logs each log_rate_limit_interval.  Further messages are suppressed and
+  ## summarized at the end of the interval.  0 for no limit.
+  # log_rate_limit = 0
+  # log_rate_limit_interval = "1m"
+
   ## Name of the file to be logged to when using the "file" logtarget.  If set to
   ## the empty string then logs are written to stderr.
   # logfile = ""
@@ -392,6 +614,35 @@ var agentConfig = `
   ## If set to -1, no archives are removed.
   # logfile_rotation_max_archives = 5
 
+  ## Trace the metrics matching namepass and tagpass, like the filters of a
+  ## plugin, through the pipeline.  Each input, processor, aggregator and
+  ## output logs what happened to a traced metric, e.g. "dropped by the
+  ## filters" or "written", identified by the hash of its series and its time.
+  # [agent.trace]
+  #   namepass = ["cpu"]
+  #   [agent.trace.tagpass]
+  #     cpu = ["cpu-total"]
+
+  ## Elect one of the agents sharing a lock to collect the inputs with
+  ## cluster_scope = true, e.g. vsphere polling a vCenter all agents reach.
+  ## The lock is a "file" on shared storage, a "consul" key or a
+  ## "kubernetes" ConfigMap; the agent holds it as id, by default the
+  ## hostname, for lease_duration, renewed at a third of it.
+  # [agent.election]
+  #   type = "file"
+  #   # id = ""
+  #   # lease_duration = "15s"
+  #   ## file
+  #   path = "/mnt/shared/circonus-unified-agent.leader"
+  #   ## consul
+  #   # consul_address = "localhost:8500"
+  #   # consul_token = ""
+  #   # key = "circonus-unified-agent/leader"
+  #   ## kubernetes
+  #   # kubeconfig = ""
+  #   # namespace = ""
+  #   # name = "circonus-unified-agent-leader"
+
   [agent.circonus]
     ## Circonus API token must be provided to use this plugin
     ## REQUIRED
@@ -731,7 +982,21 @@ func PrintOutputConfig(name string) error {
 }
 
 // LoadDirectory loads all toml config files found in the specified path, recursively.
+// An http(s) URL is loaded from the *.conf files listed in its SHA256SUMS manifest.
 func (c *Config) LoadDirectory(path string) error {
+	if IsRemote(path) {
+		files, err := c.remote().directory(path)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := c.LoadConfigData(f.data); err != nil {
+				return fmt.Errorf("Error loading config file %s: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! circonus-unified-agent is not permitted to read %s", thispath)
@@ -797,7 +1062,7 @@ func (c *Config) LoadConfig(path string) error {
 			return err
 		}
 	}
-	data, err := loadConfig(path)
+	data, err := c.loadConfig(path)
 	if err != nil {
 		return fmt.Errorf("Error loading config file %s: %w", path, err)
 	}
@@ -839,6 +1104,66 @@ func (c *Config) LoadConfigData(data []byte) error {
 		}
 	}
 
+	switch c.Agent.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("invalid agent log_format %q, must be text or json", c.Agent.LogFormat)
+	}
+	if c.Agent.LogRateLimit < 0 {
+		return fmt.Errorf("invalid agent log_rate_limit %d, must not be negative", c.Agent.LogRateLimit)
+	}
+	if c.Agent.CollectionJitter.Duration < 0 {
+		return fmt.Errorf("invalid agent collection_jitter %s, must not be negative", c.Agent.CollectionJitter.Duration)
+	}
+	if c.Agent.ShutdownFlushTimeout.Duration < 0 {
+		return fmt.Errorf("invalid agent shutdown_flush_timeout %s, must not be negative", c.Agent.ShutdownFlushTimeout.Duration)
+	}
+	if c.Agent.GatherTimeout.Duration < 0 {
+		return fmt.Errorf("invalid agent gather_timeout %s, must not be negative", c.Agent.GatherTimeout.Duration)
+	}
+	if c.Agent.FlushJitter.Duration < 0 {
+		return fmt.Errorf("invalid agent flush_jitter %s, must not be negative", c.Agent.FlushJitter.Duration)
+	}
+	if c.Agent.MaxSeriesPerInput < 0 {
+		return fmt.Errorf("invalid agent max_series_per_input %d, must not be negative", c.Agent.MaxSeriesPerInput)
+	}
+	// before the plugins are built, so their configuration is checked
+	fips.Set(c.Agent.FIPSMode)
+	lite.Set(c.Agent.LiteMode)
+	if lite.Enabled() {
+		if c.Agent.MetricBatchSize == 0 {
+			c.Agent.MetricBatchSize = lite.MetricBatchSize
+		}
+		if c.Agent.MetricBufferLimit == 0 {
+			c.Agent.MetricBufferLimit = lite.MetricBufferLimit
+		}
+	}
+	lite.SetExecDisabled(c.Agent.DisableExec)
+	lite.SetMIBsDisabled(c.Agent.DisableMIBs)
+	if c.allowedPlugins, err = filter.Compile(c.Agent.AllowedPlugins); err != nil {
+		return fmt.Errorf("invalid agent allowed_plugins: %w", err)
+	}
+	if err := capability.Validate(c.Agent.KeepCapabilities); err != nil {
+		return fmt.Errorf("invalid agent keep_capabilities: %w", err)
+	}
+	if c.Agent.MaxMetricsPerFlush < 0 {
+		return fmt.Errorf("invalid agent max_metrics_per_flush %d, must not be negative", c.Agent.MaxMetricsPerFlush)
+	}
+	if c.Agent.TimestampMaxPast.Duration < 0 {
+		return fmt.Errorf("invalid agent timestamp_max_past %s, must not be negative", c.Agent.TimestampMaxPast.Duration)
+	}
+	if c.Agent.TimestampMaxFuture.Duration < 0 {
+		return fmt.Errorf("invalid agent timestamp_max_future %s, must not be negative", c.Agent.TimestampMaxFuture.Duration)
+	}
+	switch c.Agent.TimestampOutOfRange {
+	case "", "drop", "clamp":
+	default:
+		return fmt.Errorf("invalid agent timestamp_out_of_range %q, must be drop or clamp", c.Agent.TimestampOutOfRange)
+	}
+	if err := models.EnableTracing(c.Agent.Trace.NamePass, c.Agent.Trace.TagPass); err != nil {
+		return fmt.Errorf("invalid agent trace: %w", err)
+	}
+
 	// mgm: hard set the agent.hostname and circonus.checknameprefix
 	if c.Agent.Hostname == "" {
 		hostname, err := os.Hostname()
@@ -853,6 +1178,10 @@ func (c *Config) LoadConfigData(data []byte) error {
 		c.Agent.Circonus.Hostname = c.Agent.Hostname
 	}
 
+	if err := c.loadExternalTags(); err != nil {
+		return fmt.Errorf("invalid agent global tags: %w", err)
+	}
+
 	// mgm: ignore omit hostname - do not set host:hostname tag on each metric
 	// if !c.Agent.OmitHostname {
 	// 	c.Tags["host"] = c.Agent.Hostname
@@ -862,6 +1191,17 @@ func (c *Config) LoadConfigData(data []byte) error {
 		return fmt.Errorf("line %d: configuration specified the fields %q, but they weren't used", tbl.Line, keys(c.UnusedFields))
 	}
 
+	// Parse pipelines table before the plugins in them:
+	if val, ok := tbl.Fields["pipelines"]; ok {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("invalid configuration, error parsing pipelines table")
+		}
+		if err = c.addPipelines(subTable); err != nil {
+			return err
+		}
+	}
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
 		subTable, ok := val.(*ast.Table)
@@ -870,7 +1210,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 		}
 
 		switch name {
-		case "agent", "global_tags", "tags":
+		case "agent", "global_tags", "tags", "pipelines":
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -967,6 +1307,74 @@ func (c *Config) LoadConfigData(data []byte) error {
 	return nil
 }
 
+// addPipelines adds the pipelines of the [pipelines.<name>] tables
+func (c *Config) addPipelines(tbl *ast.Table) error {
+	for name, val := range tbl.Fields {
+		subTable, ok := val.(*ast.Table)
+		if !ok {
+			return fmt.Errorf("invalid configuration, error parsing pipeline %q", name)
+		}
+		if _, ok := c.Pipelines[name]; ok {
+			return fmt.Errorf("pipeline %q defined more than once", name)
+		}
+		p := &Pipeline{Tags: make(map[string]string)}
+		if err := c.toml.UnmarshalTable(subTable, p); err != nil {
+			return fmt.Errorf("error parsing pipeline %q: %w", name, err)
+		}
+		if len(c.UnusedFields) > 0 {
+			return fmt.Errorf("pipeline %s: line %d: configuration specified the fields %q, but they weren't used", name, subTable.Line, keys(c.UnusedFields))
+		}
+		if p.MetricBatchSize < 0 || p.MetricBufferLimit < 0 {
+			return fmt.Errorf("invalid pipeline %q metric_batch_size or metric_buffer_limit, must not be negative", name)
+		}
+		c.Pipelines[name] = p
+	}
+	return nil
+}
+
+// pipeline returns the pipeline of the name, nil for the default pipeline
+// when it is not configured; pipelines must be defined before the plugins in
+// them, in the same or an earlier loaded file
+func (c *Config) pipeline(name string) (*Pipeline, error) {
+	if p, ok := c.Pipelines[models.PipelineName(name)]; ok {
+		return p, nil
+	}
+	if models.PipelineName(name) == models.DefaultPipeline {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("undefined pipeline %q", name)
+}
+
+// execPlugins are the plugins running commands
+var execPlugins = map[string]bool{
+	"inputs.exec":      true,
+	"inputs.execd":     true,
+	"outputs.execd":    true,
+	"processors.execd": true,
+}
+
+// checkPlugin returns an error for configuring the plugin of the kind, e.g.
+// "inputs", when the agent settings do not allow it
+func (c *Config) checkPlugin(kind, name string) error {
+	plugin := kind + "." + name
+	if c.allowedPlugins != nil && !c.allowedPlugins.Match(plugin) {
+		return fmt.Errorf("%s is not in the agent allowed_plugins", plugin)
+	}
+	if lite.ExecDisabled() && execPlugins[plugin] {
+		return lite.ExecNotAllowed(plugin)
+	}
+	return nil
+}
+
+// notBuiltIn answers the hint for undefined plugins of agents built with the
+// lite tag, which have a reduced set of plugins built in
+func notBuiltIn() string {
+	if lite.Built() {
+		return " (not built into lite agents)"
+	}
+	return ""
+}
+
 // trimBOM trims the Byte-Order-Marks from the beginning of the file.
 // this is for Windows compatibility only.
 func trimBOM(f []byte) []byte {
@@ -978,44 +1386,21 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-func loadConfig(config string) ([]byte, error) {
-	u, err := url.Parse(config)
-	if err != nil {
-		return nil, fmt.Errorf("url parse (%s): %w", config, err)
-	}
-
-	switch u.Scheme {
-	case "https", "http":
-		return fetchConfig(u)
-	default:
-		// If it isn't a https scheme, try it as a file.
+func (c *Config) loadConfig(config string) ([]byte, error) {
+	if IsRemote(config) {
+		return c.remote().file(config)
 	}
+	// If it isn't a https scheme, try it as a file.
 	return os.ReadFile(config) //nolint:wrapcheck
-
 }
 
-func fetchConfig(u fmt.Stringer) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("http new req (%s): %w", u.String(), err)
-	}
-
-	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
-		req.Header.Add("Authorization", "Token "+v)
+// remote answers the Remote of the config, one fetching without keeping
+// the configs when not set
+func (c *Config) remote() *Remote {
+	if c.Remote == nil {
+		return NewRemote(nil)
 	}
-	req.Header.Add("Accept", "application/toml")
-	req.Header.Set("User-Agent", internal.ProductToken())
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http do: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
-	}
-
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body) //nolint:wrapcheck
+	return c.Remote
 }
 
 // parseConfig loads a TOML configuration from a provided path and
@@ -1051,9 +1436,12 @@ func parseConfig(contents []byte) (*ast.Table, error) {
 }
 
 func (c *Config) addAggregator(name string, table *ast.Table) error {
+	if err := c.checkPlugin("aggregators", name); err != nil {
+		return err
+	}
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested aggregator: %s", name)
+		return fmt.Errorf("Undefined but requested aggregator: %s%s", name, notBuiltIn())
 	}
 	aggregator := creator()
 
@@ -1071,9 +1459,12 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 }
 
 func (c *Config) addProcessor(name string, table *ast.Table) error {
+	if err := c.checkPlugin("processors", name); err != nil {
+		return err
+	}
 	creator, ok := processors.Processors[name]
 	if !ok {
-		return fmt.Errorf("undefined but requested processor: %s", name)
+		return fmt.Errorf("undefined but requested processor: %s%s", name, notBuiltIn())
 	}
 
 	processorConfig, err := c.buildProcessor(name, table)
@@ -1123,10 +1514,66 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
 	}
+	if err := c.checkPlugin("outputs", name); err != nil {
+		return err
+	}
 	creator, ok := outputs.Outputs[name]
 	if !ok {
-		return fmt.Errorf("undefined but requested output: %s", name)
+		return fmt.Errorf("undefined but requested output: %s%s", name, notBuiltIn())
+	}
+	outputConfig, err := c.buildOutput(name, table)
+	if err != nil {
+		return err
+	}
+
+	output, err := c.newOutput(name, creator, table)
+	if err != nil {
+		return err
+	}
+
+	// outputs not supporting concurrent writes get an instance per write
+	// worker
+	var workerOutputs []cua.Output
+	if o, ok := output.(cua.ConcurrentOutput); !ok || !o.ConcurrentWrites() {
+		for i := 1; i < outputConfig.WriteWorkers; i++ {
+			wo, err := c.newOutput(name, creator, table)
+			if err != nil {
+				return err
+			}
+			workerOutputs = append(workerOutputs, wo)
+		}
+	}
+
+	if outputConfig.MetricBufferOverflow == "" {
+		outputConfig.MetricBufferOverflow = c.Agent.MetricBufferOverflow
+	}
+	if outputConfig.MaxMetricsPerFlush == 0 {
+		outputConfig.MaxMetricsPerFlush = c.Agent.MaxMetricsPerFlush
+	}
+	switch outputConfig.MetricBufferOverflow {
+	case "", models.DropOldest, models.DropNewest:
+	default:
+		return fmt.Errorf("invalid metric_buffer_overflow %q for output %s, must be %s or %s",
+			outputConfig.MetricBufferOverflow, name, models.DropOldest, models.DropNewest)
 	}
+
+	batchSize, bufferLimit := c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit
+	if p, _ := c.pipeline(outputConfig.Pipeline); p != nil {
+		if p.MetricBatchSize > 0 {
+			batchSize = p.MetricBatchSize
+		}
+		if p.MetricBufferLimit > 0 {
+			bufferLimit = p.MetricBufferLimit
+		}
+	}
+	ro := models.NewRunningOutput(name, output, outputConfig, batchSize, bufferLimit)
+	ro.SetWorkerOutputs(workerOutputs)
+	c.Outputs = append(c.Outputs, ro)
+	return nil
+}
+
+// newOutput creates an instance of an output configured by the table
+func (c *Config) newOutput(name string, creator outputs.Creator, table *ast.Table) (cua.Output, error) {
 	output := creator()
 
 	// If the output has a SetSerializer function, then this means it can write
@@ -1135,25 +1582,16 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	case serializers.SerializerOutput:
 		serializer, err := c.buildSerializer(name, table)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		t.SetSerializer(serializer)
 	default:
 	}
 
-	outputConfig, err := c.buildOutput(name, table)
-	if err != nil {
-		return err
-	}
-
 	if err := c.toml.UnmarshalTable(table, output); err != nil {
-		return fmt.Errorf("toml unmarshaltable: %w", err)
+		return nil, fmt.Errorf("toml unmarshaltable: %w", err)
 	}
-
-	ro := models.NewRunningOutput(name, output, outputConfig,
-		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
-	c.Outputs = append(c.Outputs, ro)
-	return nil
+	return output, nil
 }
 
 func (c *Config) addInput(name string, table *ast.Table) error {
@@ -1165,9 +1603,17 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		name = "diskio"
 	}
 
+	if err := c.checkPlugin("inputs", name); err != nil {
+		return err
+	}
+
+	if node, ok := table.Fields["inventory"]; ok {
+		return c.addInputInventory(name, table, node)
+	}
+
 	creator, ok := inputs.Inputs[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested input: %s", name)
+		return fmt.Errorf("Undefined but requested input: %s%s", name, notBuiltIn())
 	}
 	input := creator()
 
@@ -1195,6 +1641,9 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if err != nil {
 		return err
 	}
+	if pluginConfig.MaxSeries == 0 {
+		pluginConfig.MaxSeries = c.Agent.MaxSeriesPerInput
+	}
 
 	if err := c.toml.UnmarshalTable(table, input); err != nil {
 		return fmt.Errorf("toml unmarshaltable: %w", err)
@@ -1204,6 +1653,18 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if pluginConfig.Alias == "" {
 		return fmt.Errorf("input plugin missing required 'instance_id' setting")
 	}
+	if _, ok := input.(cua.ServiceInput); ok {
+		if pluginConfig.ClusterScope {
+			return fmt.Errorf("input %s: cluster_scope is not supported by service inputs", name)
+		}
+		if pluginConfig.CPUBudget > 0 || pluginConfig.AllocBudget > 0 {
+			return fmt.Errorf("input %s: cpu_budget and alloc_budget are not supported by service inputs", name)
+		}
+	}
+
+	if _, ok := input.(cua.IncrementalInput); !ok && pluginConfig.FullGatherInterval > 0 {
+		return fmt.Errorf("input %s: full_gather_interval is not supported by the input", name)
+	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
@@ -1230,6 +1691,7 @@ func (c *Config) buildAggregator(name string, tbl *ast.Table) (*models.Aggregato
 	c.getFieldString(tbl, "name_suffix", &conf.MeasurementSuffix)
 	c.getFieldString(tbl, "name_override", &conf.NameOverride)
 	c.getFieldString(tbl, "alias", &conf.Alias)
+	c.getFieldString(tbl, "pipeline", &conf.Pipeline)
 
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
@@ -1243,6 +1705,9 @@ func (c *Config) buildAggregator(name string, tbl *ast.Table) (*models.Aggregato
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
+	if _, err := c.pipeline(conf.Pipeline); err != nil {
+		return nil, err
+	}
 
 	var err error
 	conf.Filter, err = c.buildFilter(tbl)
@@ -1260,10 +1725,14 @@ func (c *Config) buildProcessor(name string, tbl *ast.Table) (*models.ProcessorC
 
 	c.getFieldInt64(tbl, "order", &conf.Order)
 	c.getFieldString(tbl, "alias", &conf.Alias)
+	c.getFieldString(tbl, "pipeline", &conf.Pipeline)
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
+	if _, err := c.pipeline(conf.Pipeline); err != nil {
+		return nil, err
+	}
 
 	var err error
 	conf.Filter, err = c.buildFilter(tbl)
@@ -1311,6 +1780,15 @@ func (c *Config) buildInput(name string, tbl *ast.Table) (*models.InputConfig, e
 	c.getFieldDuration(tbl, "interval", &cp.Interval)
 	c.getFieldDuration(tbl, "precision", &cp.Precision)
 	c.getFieldDuration(tbl, "collection_jitter", &cp.CollectionJitter)
+	c.getFieldDuration(tbl, "gather_timeout", &cp.GatherTimeout)
+	c.getFieldInt(tbl, "breaker_threshold", &cp.BreakerThreshold)
+	c.getFieldDuration(tbl, "breaker_probe_interval", &cp.BreakerProbeInterval)
+	c.getFieldDuration(tbl, "breaker_max_probe_interval", &cp.BreakerMaxProbeInterval)
+	c.getFieldInt(tbl, "max_series", &cp.MaxSeries)
+	c.getFieldDuration(tbl, "cpu_budget", &cp.CPUBudget)
+	c.getFieldSize(tbl, "alloc_budget", &cp.AllocBudget)
+	c.getFieldBool(tbl, "disable_over_budget", &cp.DisableOverBudget)
+	c.getFieldDuration(tbl, "full_gather_interval", &cp.FullGatherInterval)
 	c.getFieldString(tbl, "name_prefix", &cp.MeasurementPrefix)
 	c.getFieldString(tbl, "name_suffix", &cp.MeasurementSuffix)
 	c.getFieldString(tbl, "name_override", &cp.NameOverride)
@@ -1320,6 +1798,8 @@ func (c *Config) buildInput(name string, tbl *ast.Table) (*models.InputConfig, e
 	if cp.Alias == "" {
 		cp.Alias = cp.InstanceID
 	}
+	c.getFieldString(tbl, "pipeline", &cp.Pipeline)
+	c.getFieldBool(tbl, "cluster_scope", &cp.ClusterScope)
 
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
@@ -1334,7 +1814,38 @@ func (c *Config) buildInput(name string, tbl *ast.Table) (*models.InputConfig, e
 		return nil, c.firstErr()
 	}
 
-	var err error
+	if cp.CollectionJitter < 0 {
+		return nil, fmt.Errorf("invalid collection_jitter %s for input %s, must not be negative", cp.CollectionJitter, name)
+	}
+	if cp.GatherTimeout < 0 {
+		return nil, fmt.Errorf("invalid gather_timeout %s for input %s, must not be negative", cp.GatherTimeout, name)
+	}
+	if cp.BreakerThreshold < 0 {
+		return nil, fmt.Errorf("invalid breaker_threshold %d for input %s, must not be negative", cp.BreakerThreshold, name)
+	}
+	if cp.BreakerProbeInterval < 0 || cp.BreakerMaxProbeInterval < 0 {
+		return nil, fmt.Errorf("invalid breaker probe interval for input %s, must not be negative", name)
+	}
+	if cp.MaxSeries < 0 {
+		return nil, fmt.Errorf("invalid max_series %d for input %s, must not be negative", cp.MaxSeries, name)
+	}
+	if cp.CPUBudget < 0 || cp.AllocBudget < 0 {
+		return nil, fmt.Errorf("invalid budget for input %s, must not be negative", name)
+	}
+	if cp.DisableOverBudget && cp.CPUBudget == 0 && cp.AllocBudget == 0 {
+		return nil, fmt.Errorf("input %s: disable_over_budget requires cpu_budget or alloc_budget", name)
+	}
+	if cp.FullGatherInterval < 0 {
+		return nil, fmt.Errorf("invalid full_gather_interval %s for input %s, must not be negative", cp.FullGatherInterval, name)
+	}
+	p, err := c.pipeline(cp.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		cp.PipelineTags = p.Tags
+	}
+
 	cp.Filter, err = c.buildFilter(tbl)
 	if err != nil {
 		return cp, err
@@ -1442,10 +1953,10 @@ func (c *Config) buildSerializer(name string, tbl *ast.Table) (serializers.Seria
 	c.getFieldString(tbl, "template", &sc.Template)
 	c.getFieldStringSlice(tbl, "templates", &sc.Templates)
 	c.getFieldString(tbl, "carbon2_format", &sc.Carbon2Format)
-	// c.getFieldInt(tbl, "influx_max_line_bytes", &sc.InfluxMaxLineBytes)
+	c.getFieldInt(tbl, "influx_max_line_bytes", &sc.InfluxMaxLineBytes)
 
-	// c.getFieldBool(tbl, "influx_sort_fields", &sc.InfluxSortFields)
-	// c.getFieldBool(tbl, "influx_uint_support", &sc.InfluxUintSupport)
+	c.getFieldBool(tbl, "influx_sort_fields", &sc.InfluxSortFields)
+	c.getFieldBool(tbl, "influx_uint_support", &sc.InfluxUintSupport)
 	c.getFieldBool(tbl, "graphite_tag_support", &sc.GraphiteTagSupport)
 	c.getFieldString(tbl, "graphite_separator", &sc.GraphiteSeparator)
 
@@ -1461,6 +1972,11 @@ func (c *Config) buildSerializer(name string, tbl *ast.Table) (serializers.Seria
 	c.getFieldBool(tbl, "prometheus_sort_metrics", &sc.PrometheusSortMetrics)
 	c.getFieldBool(tbl, "prometheus_string_as_label", &sc.PrometheusStringAsLabel)
 
+	c.getFieldString(tbl, "histogram_format", &sc.HistogramFormat)
+	c.getFieldFloatSlice(tbl, "histogram_percentiles", &sc.HistogramPercentiles)
+
+	c.getFieldInt(tbl, "serializer_workers", &sc.SerializerWorkers)
+
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
@@ -1478,50 +1994,69 @@ func (c *Config) buildOutput(name string, tbl *ast.Table) (*models.OutputConfig,
 		return nil, err
 	}
 	oc := &models.OutputConfig{
-		Name:   name,
-		Filter: filter,
+		Name:         name,
+		Filter:       filter,
+		WriteWorkers: 1,
 	}
 
-	// TODO: support FieldPass/FieldDrop on outputs
-
 	c.getFieldDuration(tbl, "flush_interval", &oc.FlushInterval)
-	c.getFieldDuration(tbl, "flush_jitter", oc.FlushJitter)
+	c.getFieldDuration(tbl, "flush_jitter", &oc.FlushJitter)
 
 	c.getFieldInt(tbl, "metric_buffer_limit", &oc.MetricBufferLimit)
 	c.getFieldInt(tbl, "metric_batch_size", &oc.MetricBatchSize)
+	c.getFieldString(tbl, "metric_buffer_overflow", &oc.MetricBufferOverflow)
+	c.getFieldInt(tbl, "write_workers", &oc.WriteWorkers)
+	c.getFieldInt(tbl, "max_metrics_per_flush", &oc.MaxMetricsPerFlush)
 	c.getFieldString(tbl, "alias", &oc.Alias)
 	c.getFieldString(tbl, "name_override", &oc.NameOverride)
 	c.getFieldString(tbl, "name_suffix", &oc.NameSuffix)
 	c.getFieldString(tbl, "name_prefix", &oc.NamePrefix)
+	c.getFieldString(tbl, "pipeline", &oc.Pipeline)
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
+	if _, err := c.pipeline(oc.Pipeline); err != nil {
+		return nil, err
+	}
+
+	if oc.FlushInterval < 0 {
+		return nil, fmt.Errorf("invalid flush_interval %s for output %s, must not be negative", oc.FlushInterval, name)
+	}
+	if oc.FlushJitter < 0 {
+		return nil, fmt.Errorf("invalid flush_jitter %s for output %s, must not be negative", oc.FlushJitter, name)
+	}
+	if oc.MaxMetricsPerFlush < 0 {
+		return nil, fmt.Errorf("invalid max_metrics_per_flush %d for output %s, must not be negative", oc.MaxMetricsPerFlush, name)
+	}
+	if oc.WriteWorkers < 1 {
+		return nil, fmt.Errorf("invalid write_workers %d for output %s, must be 1 or more", oc.WriteWorkers, name)
+	}
 
 	return oc, nil
 }
 
 func (c *Config) missingTomlField(typ reflect.Type, key string) error {
 	switch key {
-	case "alias", "instance_id", "carbon2_format", "collectd_auth_file", "collectd_parse_multivalue",
-		"collectd_security_level", "collectd_typesdb", "collection_jitter", "csv_column_names",
+	case "alias", "alloc_budget", "instance_id", "breaker_max_probe_interval", "breaker_probe_interval", "breaker_threshold", "carbon2_format", "collectd_auth_file", "collectd_parse_multivalue",
+		"cluster_scope", "collectd_security_level", "cpu_budget", "collectd_typesdb", "collection_jitter", "csv_column_names", "disable_over_budget",
 		"csv_column_types", "csv_comment", "csv_delimiter", "csv_header_row_count",
 		"csv_measurement_column", "csv_skip_columns", "csv_skip_rows", "csv_tag_columns",
 		"csv_timestamp_column", "csv_timestamp_format", "csv_timezone", "csv_trim_space",
 		"data_format", "data_type", "delay", "drop", "drop_original", "dropwizard_metric_registry_path",
 		"dropwizard_tag_paths", "dropwizard_tags_path", "dropwizard_time_format", "dropwizard_time_path",
-		"fielddrop", "fieldpass", "flush_interval", "flush_jitter", "form_urlencoded_tag_keys",
-		"grace", "graphite_separator", "graphite_tag_support", "grok_custom_pattern_files",
+		"fielddrop", "fieldpass", "flush_interval", "flush_jitter", "form_urlencoded_tag_keys", "full_gather_interval",
+		"gather_timeout", "grace", "graphite_separator", "graphite_tag_support", "grok_custom_pattern_files",
 		"grok_custom_patterns", "grok_named_patterns", "grok_patterns", "grok_timezone",
-		"grok_unique_timestamp", "influx_max_line_bytes", "influx_sort_fields", "influx_uint_support",
+		"grok_unique_timestamp", "histogram_format", "histogram_percentiles", "influx_max_line_bytes", "influx_sort_fields", "influx_uint_support",
 		"interval", "json_name_key", "json_query", "json_strict", "json_string_fields",
 		"json_time_format", "json_time_key", "json_timestamp_units", "json_timezone",
-		"metric_batch_size", "metric_buffer_limit", "name_override", "name_prefix",
-		"name_suffix", "namedrop", "namepass", "order", "pass", "period", "precision",
+		"max_metrics_per_flush", "max_series", "metric_batch_size", "metric_buffer_limit", "metric_buffer_overflow", "name_override", "name_prefix",
+		"name_suffix", "namedrop", "namepass", "order", "pass", "period", "pipeline", "precision",
 		"prefix", "prometheus_export_timestamp", "prometheus_sort_metrics", "prometheus_string_as_label",
-		"separator", "splunkmetric_hec_routing", "splunkmetric_multimetric", "tag_keys",
+		"separator", "serializer_workers", "splunkmetric_hec_routing", "splunkmetric_multimetric", "tag_keys",
 		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "template", "templates",
-		"wavefront_source_override", "wavefront_use_strict":
+		"wavefront_source_override", "wavefront_use_strict", "write_workers":
 
 		// ignore fields that are common to all plugins.
 	default:
@@ -1556,6 +2091,19 @@ func (c *Config) getFieldDuration(tbl *ast.Table, fieldName string, target inter
 	}
 }
 
+func (c *Config) getFieldSize(tbl *ast.Table, fieldName string, target *int64) {
+	if node, ok := tbl.Fields[fieldName]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				c.addError(tbl, fmt.Errorf("error parsing size: %w", err))
+				return
+			}
+			*target = size.Size
+		}
+	}
+}
+
 func (c *Config) getFieldBool(tbl *ast.Table, fieldName string, target *bool) {
 	var err error
 	if node, ok := tbl.Fields[fieldName]; ok {
@@ -1625,6 +2173,33 @@ func (c *Config) getFieldStringSlice(tbl *ast.Table, fieldName string, target *[
 	}
 }
 
+func (c *Config) getFieldFloatSlice(tbl *ast.Table, fieldName string, target *[]float64) {
+	if node, ok := tbl.Fields[fieldName]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					switch v := elem.(type) {
+					case *ast.Float:
+						f, err := v.Float()
+						if err != nil {
+							c.addError(tbl, fmt.Errorf("unexpected float type %q, expecting float", v.Value))
+							return
+						}
+						*target = append(*target, f)
+					case *ast.Integer:
+						i, err := v.Int()
+						if err != nil {
+							c.addError(tbl, fmt.Errorf("unexpected int type %q, expecting int", v.Value))
+							return
+						}
+						*target = append(*target, float64(i))
+					}
+				}
+			}
+		}
+	}
+}
+
 func (c *Config) getFieldTagFilter(tbl *ast.Table, fieldName string, target *[]models.TagFilter) {
 	if node, ok := tbl.Fields[fieldName]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1888,6 +2463,9 @@ func (c *Config) addDefaultPlugins() error {
 		if !pluginConfig.Enabled {
 			continue // user override in configuration
 		}
+		if _, ok := inputs.Inputs[pluginName]; !ok || c.checkPlugin("inputs", pluginName) != nil {
+			continue // not built in or not allowed
+		}
 		tbl, err := parseConfig(pluginConfig.Data)
 		if err != nil {
 			return fmt.Errorf("error parsing data: %w", err)
@@ -1963,6 +2541,9 @@ func (c *Config) addAgentPlugins() error {
 		if !pluginConfig.Enabled {
 			continue // user override in configuration
 		}
+		if _, ok := inputs.Inputs[pluginName]; !ok || c.checkPlugin("inputs", pluginName) != nil {
+			continue // not built in or not allowed
+		}
 		tbl, err := parseConfig(pluginConfig.Data)
 		if err != nil {
 			return fmt.Errorf("error parsing data: %w", err)
@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/models"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/docker"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/exec"
 	httplistenerv2 "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http_listener_v2"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/memcached"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/procstat"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/file"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -248,6 +252,293 @@ func TestConfig_WrongFieldType(t *testing.T) {
 // 	assert.Equal(t, true, ok)
 // }
 
+func TestConfig_MultipleOutputs(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/multiple_outputs.toml"))
+	require.Len(t, c.Outputs, 2)
+
+	primary, audit := c.Outputs[0], c.Outputs[1]
+	require.Equal(t, "primary", primary.Config.Alias)
+	require.Equal(t, time.Duration(0), primary.Config.FlushInterval)
+	require.Equal(t, 10000, primary.MetricBufferLimit)
+	require.Equal(t, []string{"debug_*"}, primary.Config.Filter.NameDrop)
+
+	require.Equal(t, "audit", audit.Config.Alias)
+	require.Equal(t, time.Minute, audit.Config.FlushInterval)
+	require.Equal(t, 5*time.Second, audit.Config.FlushJitter)
+	require.Equal(t, 50000, audit.MetricBufferLimit)
+	require.Equal(t, 500, audit.MetricBatchSize)
+	require.Equal(t, []string{"audit_*"}, audit.Config.Filter.NamePass)
+	require.Equal(t, []string{"secret"}, audit.Config.Filter.FieldDrop)
+}
+
+func TestConfig_NegativeJitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{
+			name:   "agent collection_jitter",
+			config: "[agent]\n  collection_jitter = \"-1s\"\n",
+		},
+		{
+			name:   "agent flush_jitter",
+			config: "[agent]\n  flush_jitter = \"-1s\"\n",
+		},
+		{
+			name:   "input collection_jitter",
+			config: "[[inputs.memcached]]\n  collection_jitter = \"-1s\"\n",
+		},
+		{
+			name:   "output flush_jitter",
+			config: "[[outputs.discard]]\n  flush_jitter = \"-1s\"\n",
+		},
+		{
+			name:   "output flush_interval",
+			config: "[[outputs.discard]]\n  flush_interval = \"-1s\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			err := c.LoadConfigData([]byte(tt.config))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "must not be negative")
+		})
+	}
+}
+
+func TestConfig_AllowedPlugins(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  allowed_plugins = ["inputs.mem*", "outputs.discard"]
+[[inputs.memcached]]
+  instance_id = "memcached"
+[[outputs.discard]]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+	require.Len(t, c.Outputs, 1)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[agent]
+  allowed_plugins = ["inputs.mem*", "outputs.discard"]
+[[inputs.procstat]]
+  instance_id = "procstat"
+`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "inputs.procstat is not in the agent allowed_plugins")
+}
+
+func TestConfig_DisableExec(t *testing.T) {
+	t.Cleanup(func() { lite.SetExecDisabled(false) })
+
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  disable_exec = true
+[[inputs.exec]]
+  instance_id = "exec"
+  commands = ["/bin/true"]
+`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "inputs.exec runs commands")
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[agent]
+  disable_exec = true
+  global_tags_command = ["/bin/echo", "{}"]
+`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "global_tags_command runs commands")
+}
+
+func TestConfig_LiteMode(t *testing.T) {
+	t.Cleanup(func() { lite.Set(false) })
+
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  lite_mode = true
+  metric_batch_size = 100
+`))
+	require.NoError(t, err)
+	require.True(t, lite.Enabled())
+	require.Equal(t, 100, c.Agent.MetricBatchSize)
+	require.Equal(t, lite.MetricBufferLimit, c.Agent.MetricBufferLimit)
+}
+
+func TestConfig_ClusterScope(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  [agent.election]
+    type = "consul"
+    lease_duration = "20s"
+    key = "cua/vsphere"
+[[inputs.memcached]]
+  instance_id = "memcached"
+  cluster_scope = true
+`))
+	require.NoError(t, err)
+	require.Equal(t, "consul", c.Agent.Election.Type)
+	require.Equal(t, 20*time.Second, c.Agent.Election.LeaseDuration.Duration)
+	require.Equal(t, "cua/vsphere", c.Agent.Election.Key)
+	require.Len(t, c.Inputs, 1)
+	require.True(t, c.Inputs[0].Config.ClusterScope)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.http_listener_v2]]
+  instance_id = "listener"
+  cluster_scope = true
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_Budgets(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  instance_id = "memcached"
+  cpu_budget = "500ms"
+  alloc_budget = "64MiB"
+  disable_over_budget = true
+[[inputs.memcached]]
+  instance_id = "memcached-bytes"
+  alloc_budget = 1048576
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 2)
+	require.Equal(t, 500*time.Millisecond, c.Inputs[0].Config.CPUBudget)
+	require.Equal(t, int64(64*1024*1024), c.Inputs[0].Config.AllocBudget)
+	require.Equal(t, int64(1048576), c.Inputs[1].Config.AllocBudget)
+	require.True(t, c.Inputs[0].Config.DisableOverBudget)
+	require.False(t, c.Inputs[1].Config.DisableOverBudget)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  instance_id = "memcached"
+  disable_over_budget = true
+`))
+	require.Error(t, err)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.http_listener_v2]]
+  instance_id = "listener"
+  cpu_budget = "1s"
+`))
+	require.Error(t, err)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  instance_id = "memcached"
+  alloc_budget = "lots"
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_FullGatherInterval(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[inputs.docker]]
+  instance_id = "docker"
+  full_gather_interval = "10m"
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+	require.Equal(t, 10*time.Minute, c.Inputs[0].Config.FullGatherInterval)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  instance_id = "memcached"
+  full_gather_interval = "10m"
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_WriteWorkers(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  write_workers = 4
+[[outputs.file]]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Outputs, 2)
+	for _, o := range c.Outputs {
+		switch o.Config.Name {
+		case "discard":
+			require.Equal(t, 4, o.Config.WriteWorkers)
+		case "file":
+			require.Equal(t, 1, o.Config.WriteWorkers)
+		}
+	}
+
+	// outputs not supporting concurrent writes get an instance per worker
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[outputs.file]]
+  write_workers = 2
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Outputs, 1)
+	require.Equal(t, 2, c.Outputs[0].Config.WriteWorkers)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  write_workers = 0
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_Pipelines(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[global_tags]
+  dc = "us-east-1"
+[pipelines.team_a]
+  metric_buffer_limit = 5000
+  [pipelines.team_a.tags]
+    team = "a"
+[[inputs.memcached]]
+  instance_id = "memcached-a"
+  pipeline = "team_a"
+[[inputs.memcached]]
+  instance_id = "memcached"
+[[outputs.discard]]
+  pipeline = "team_a"
+[[outputs.discard]]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 2)
+	require.Equal(t, "team_a", c.Inputs[0].Config.Pipeline)
+	require.Equal(t, map[string]string{"team": "a"}, c.Inputs[0].Config.PipelineTags)
+	require.Equal(t, "", c.Inputs[1].Config.Pipeline)
+	require.Nil(t, c.Inputs[1].Config.PipelineTags)
+
+	require.Len(t, c.Outputs, 2)
+	require.Equal(t, "team_a", c.Outputs[0].Pipeline())
+	require.Equal(t, 5000, c.Outputs[0].MetricBufferLimit)
+	require.Equal(t, models.DefaultPipeline, c.Outputs[1].Pipeline())
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[outputs.discard]]
+  pipeline = "team_b"
+`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `undefined pipeline "team_b"`)
+}
+
 func TestConfig_BadOrdering(t *testing.T) {
 	// #3444: when not using inline tables, care has to be taken so subsequent configuration
 	// doesn't become part of the table. This is not a bug, but TOML syntax.
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
+	"github.com/ghodss/yaml"
+)
+
+// externalTagsTimeout bounds running the global_tags_command while loading
+// the config
+const externalTagsTimeout = 30 * time.Second
+
+// loadExternalTags adds the tags of the agent global_tags_file and the
+// output of its global_tags_command to the global tags, once per config.
+// Tags set in global_tags take precedence, the command over the file.
+func (c *Config) loadExternalTags() error {
+	if c.externalTagsLoaded {
+		return nil
+	}
+	if c.Agent.GlobalTagsFile == "" && len(c.Agent.GlobalTagsCommand) == 0 {
+		return nil
+	}
+	c.externalTagsLoaded = true
+
+	tags := make(map[string]string)
+	if c.Agent.GlobalTagsFile != "" {
+		data, err := os.ReadFile(c.Agent.GlobalTagsFile)
+		if err != nil {
+			return fmt.Errorf("global_tags_file: %w", err)
+		}
+		if err := parseExternalTags(data, tags); err != nil {
+			return fmt.Errorf("global_tags_file %s: %w", c.Agent.GlobalTagsFile, err)
+		}
+	}
+	if len(c.Agent.GlobalTagsCommand) > 0 {
+		if lite.ExecDisabled() {
+			return lite.ExecNotAllowed("global_tags_command")
+		}
+		cmd := exec.Command(c.Agent.GlobalTagsCommand[0], c.Agent.GlobalTagsCommand[1:]...)
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		if err := internal.RunTimeout(cmd, externalTagsTimeout); err != nil {
+			return fmt.Errorf("global_tags_command: %w", err)
+		}
+		if err := parseExternalTags(out.Bytes(), tags); err != nil {
+			return fmt.Errorf("global_tags_command output: %w", err)
+		}
+	}
+
+	for k, v := range tags {
+		if _, ok := c.Tags[k]; !ok {
+			c.Tags[k] = v
+		}
+	}
+	return nil
+}
+
+// parseExternalTags adds the tags of a JSON or YAML object to tags, numbers
+// and booleans are formatted as tag values
+func parseExternalTags(data []byte, tags map[string]string) error {
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("parsing tags: %w", err)
+	}
+	for k, v := range obj {
+		switch v := v.(type) {
+		case string:
+			tags[k] = v
+		case float64:
+			tags[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			tags[k] = strconv.FormatBool(v)
+		case nil:
+		default:
+			return fmt.Errorf("tag %s: value of type %T, must be a string, number or boolean", k, v)
+		}
+	}
+	return nil
+}
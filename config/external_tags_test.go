@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ExternalTags(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tags.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("rack: r12\nunit: 4\nbusiness_unit: ops\n"), 0600))
+
+	c := NewConfig()
+	c.Agent.Hostname = "host"
+	require.NoError(t, c.LoadConfigData([]byte(`
+[global_tags]
+  business_unit = "platform"
+[agent]
+  global_tags_file = "`+filepath.ToSlash(file)+`"
+  global_tags_command = ["echo", "{\"rack\": \"r13\", \"chassis_serial\": \"CZ1234\"}"]
+`)))
+	require.Equal(t, map[string]string{
+		"business_unit":  "platform",
+		"rack":           "r13",
+		"unit":           "4",
+		"chassis_serial": "CZ1234",
+	}, c.Tags)
+
+	tags := make(map[string]string)
+	require.Error(t, parseExternalTags([]byte(`{"rack": ["r12"]}`), tags))
+	require.Error(t, parseExternalTags([]byte(`rack`), tags))
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/models"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
+	"github.com/influxdata/toml/ast"
+)
+
+// inventoryTimeout bounds discovering the targets while loading the config
+const inventoryTimeout = 30 * time.Second
+
+// inventory of an input declared once and instantiated per discovered target
+type inventory struct {
+	name       string
+	discoverer *discovery.Discoverer
+	targets    []discovery.Target
+}
+
+// addInputInventory instantiates the input for each target of its inventory
+// table, replacing the placeholders in the string settings, e.g.
+// instance_id = "mongodb-{{.Address}}", with the values of the target.
+func (c *Config) addInputInventory(name string, table *ast.Table, node interface{}) error {
+	invTable, ok := node.(*ast.Table)
+	if !ok {
+		return fmt.Errorf("invalid inventory of input %s", name)
+	}
+	var cfg discovery.Config
+	if err := c.toml.UnmarshalTable(invTable, &cfg); err != nil {
+		return fmt.Errorf("inventory of input %s: %w", name, err)
+	}
+	d, err := discovery.New(&cfg, "{{.Address}}", models.NewLogger("inputs", name, "inventory"))
+	if err != nil {
+		return fmt.Errorf("inventory of input %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), inventoryTimeout)
+	defer cancel()
+	targets, err := d.Targets(ctx)
+	if err != nil {
+		return fmt.Errorf("inventory of input %s: %w", name, err)
+	}
+
+	tmpl := &ast.Table{
+		Position: table.Position,
+		Line:     table.Line,
+		Name:     table.Name,
+		Type:     table.Type,
+		Data:     table.Data,
+		Fields:   make(map[string]interface{}, len(table.Fields)),
+	}
+	for k, v := range table.Fields {
+		if k != "inventory" {
+			tmpl.Fields[k] = v
+		}
+	}
+
+	aliases := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		rendered, err := renderTable(tmpl, target)
+		if err != nil {
+			return fmt.Errorf("inventory of input %s, target %s: %w", name, target.Address, err)
+		}
+		count := len(c.Inputs)
+		if err := c.addInput(name, rendered); err != nil {
+			return fmt.Errorf("inventory of input %s, target %s: %w", name, target.Address, err)
+		}
+		if len(c.Inputs) == count {
+			// filtered out
+			return nil
+		}
+		alias := c.Inputs[len(c.Inputs)-1].Config.Alias
+		if aliases[alias] {
+			return fmt.Errorf("inventory of input %s: instance_id %q is not unique per target, use placeholders like {{.Address}}", name, alias)
+		}
+		aliases[alias] = true
+	}
+
+	c.inventories = append(c.inventories, &inventory{
+		name:       name,
+		discoverer: d,
+		targets:    targets,
+	})
+	return nil
+}
+
+// InventoryInterval answers the shortest interval of the inventories, zero
+// when no input is instantiated from an inventory
+func (c *Config) InventoryInterval() time.Duration {
+	var interval time.Duration
+	for _, inv := range c.inventories {
+		if interval == 0 || inv.discoverer.Interval() < interval {
+			interval = inv.discoverer.Interval()
+		}
+	}
+	return interval
+}
+
+// InventoryChanged answers whether the targets of an inventory changed since
+// the config was loaded, so the inputs have to be instantiated again
+func (c *Config) InventoryChanged(ctx context.Context) bool {
+	for _, inv := range c.inventories {
+		targets, err := inv.discoverer.Targets(ctx)
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(targets, inv.targets) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTable answers a copy of the table with the placeholders of all
+// strings, including those of sub-tables and arrays, replaced
+func renderTable(tbl *ast.Table, target discovery.Target) (*ast.Table, error) {
+	out := *tbl
+	out.Fields = make(map[string]interface{}, len(tbl.Fields))
+	for k, v := range tbl.Fields {
+		switch node := v.(type) {
+		case *ast.KeyValue:
+			value, err := renderValue(node.Value, target)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			kv := *node
+			kv.Value = value
+			out.Fields[k] = &kv
+		case *ast.Table:
+			sub, err := renderTable(node, target)
+			if err != nil {
+				return nil, err
+			}
+			out.Fields[k] = sub
+		case []*ast.Table:
+			subs := make([]*ast.Table, 0, len(node))
+			for _, t := range node {
+				sub, err := renderTable(t, target)
+				if err != nil {
+					return nil, err
+				}
+				subs = append(subs, sub)
+			}
+			out.Fields[k] = subs
+		default:
+			out.Fields[k] = v
+		}
+	}
+	return &out, nil
+}
+
+func renderValue(v ast.Value, target discovery.Target) (ast.Value, error) {
+	switch value := v.(type) {
+	case *ast.String:
+		if !strings.Contains(value.Value, "{{") {
+			return value, nil
+		}
+		t, err := template.New("value").Option("missingkey=zero").Parse(value.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder: %w", err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, target); err != nil {
+			return nil, fmt.Errorf("invalid placeholder: %w", err)
+		}
+		s := *value
+		s.Value = b.String()
+		return &s, nil
+	case *ast.Array:
+		a := *value
+		a.Value = make([]ast.Value, 0, len(value.Value))
+		for _, e := range value.Value {
+			r, err := renderValue(e, target)
+			if err != nil {
+				return nil, err
+			}
+			a.Value = append(a.Value, r)
+		}
+		return &a, nil
+	default:
+		return v, nil
+	}
+}
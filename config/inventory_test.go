@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/memcached"
+	"github.com/stretchr/testify/require"
+)
+
+const inventoryConfig = `
+[[inputs.memcached]]
+  instance_id = "%s"
+  servers = ["{{.Address}}"]
+  [inputs.memcached.tags]
+    node = "{{.Labels.node}}"
+  [inputs.memcached.inventory]
+    type = "file"
+    interval = "1ns"
+    files = ["%s"]
+`
+
+func writeTargets(t *testing.T, filename, nodes string) {
+	require.NoError(t, os.WriteFile(filename, []byte(`[
+		{"targets": ["10.0.0.1:11211"], "labels": {"node": "a"}},
+		{"targets": ["10.0.0.2:11211"], "labels": {"node": "`+nodes+`"}}
+	]`), 0600))
+}
+
+func TestConfig_InputInventory(t *testing.T) {
+	targets := filepath.Join(t.TempDir(), "targets.json")
+	writeTargets(t, targets, "b")
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(fmt.Sprintf(inventoryConfig, "memcached-{{.Labels.node}}", targets))))
+	require.Len(t, c.Inputs, 2)
+
+	for i, node := range []string{"a", "b"} {
+		input := c.Inputs[i]
+		require.Equal(t, "memcached-"+node, input.Config.Alias)
+		require.Equal(t, map[string]string{"node": node}, input.Config.Tags)
+		address := fmt.Sprintf("10.0.0.%d:11211", i+1)
+		require.Equal(t, []string{address}, input.Input.(*memcached.Memcached).Servers)
+	}
+
+	require.Greater(t, int64(c.InventoryInterval()), int64(0))
+	require.False(t, c.InventoryChanged(context.Background()))
+	writeTargets(t, targets, "c")
+	require.True(t, c.InventoryChanged(context.Background()))
+}
+
+func TestConfig_InputInventoryUniqueInstanceID(t *testing.T) {
+	targets := filepath.Join(t.TempDir(), "targets.json")
+	writeTargets(t, targets, "b")
+
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(fmt.Sprintf(inventoryConfig, "memcached", targets)))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not unique")
+}
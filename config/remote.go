@@ -0,0 +1,464 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+)
+
+const (
+	// remoteTimeout bounds fetching a single config, manifest or signature
+	remoteTimeout = 30 * time.Second
+	// remoteMaxSize bounds the size of a fetched config
+	remoteMaxSize = 16 << 20
+	// remoteManifest lists the checksums of the *.conf files of a config
+	// directory served over http(s), in the format of sha256sum
+	remoteManifest = "SHA256SUMS"
+	// remoteSignature is the suffix of the URL of the signature of a config
+	// or manifest
+	remoteSignature = ".sig"
+)
+
+// Remote fetches configs and config directories over http(s) and keeps the
+// verified content, so loading the config again, e.g. on reload, answers the
+// same content until Poll found a change which was applied.
+//
+// With a public key, a config or manifest is only accepted with a valid
+// ed25519 signature at its URL with .sig appended. The content is checked
+// against the SHA-256 Digest header when the server sends one, the files
+// of a directory against the checksums of its manifest.
+type Remote struct {
+	PublicKey ed25519.PublicKey
+
+	client *http.Client
+
+	mu     sync.Mutex
+	docs   map[string]*remoteDoc // applied, by URL
+	staged map[string]*remoteDoc // changed by Poll, by URL
+}
+
+// remoteDoc is a config fetched from a URL, or the manifest and *.conf
+// files of a config directory
+type remoteDoc struct {
+	dir   bool
+	etag  string
+	data  []byte
+	files []remoteFile
+}
+
+type remoteFile struct {
+	name string
+	sum  [sha256.Size]byte
+	data []byte
+}
+
+// NewRemote answers a Remote verifying the signatures with the public key,
+// none when nil
+func NewRemote(publicKey ed25519.PublicKey) *Remote {
+	return &Remote{
+		PublicKey: publicKey,
+		client:    &http.Client{Timeout: remoteTimeout},
+		docs:      make(map[string]*remoteDoc),
+	}
+}
+
+// LoadPublicKey reads an ed25519 public key, PEM encoded as written by
+// `openssl pkey -pubout`, or the base64 encoded raw 32 bytes
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %s: %w", path, err)
+		}
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key %s: not an ed25519 key", path)
+		}
+		return pub, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %s: %w", path, err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key %s: %d bytes, not an ed25519 key", path, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// IsRemote answers whether the config path is an http(s) URL
+func IsRemote(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// Fetched answers whether any config was fetched
+func (r *Remote) Fetched() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.docs) > 0
+}
+
+// Staged answers a Remote with the changes found by Poll applied, to load
+// and check the changed config before applying it
+func (r *Remote) Staged() *Remote {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := &Remote{
+		PublicKey: r.PublicKey,
+		client:    r.client,
+		docs:      make(map[string]*remoteDoc, len(r.docs)),
+	}
+	for u, doc := range r.docs {
+		s.docs[u] = doc
+	}
+	for u, doc := range r.staged {
+		s.docs[u] = doc
+	}
+	return s
+}
+
+// Apply applies the changes found by Poll
+func (r *Remote) Apply() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for u, doc := range r.staged {
+		r.docs[u] = doc
+	}
+	r.staged = nil
+}
+
+// Discard drops the changes found by Poll; they are not fetched again
+// until their ETag changes
+func (r *Remote) Discard() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for u, doc := range r.staged {
+		if applied, ok := r.docs[u]; ok {
+			kept := *applied
+			kept.etag = doc.etag
+			r.docs[u] = &kept
+		}
+	}
+	r.staged = nil
+}
+
+// Forget drops the fetched configs, so they are fetched again when loaded
+func (r *Remote) Forget() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.docs = make(map[string]*remoteDoc)
+	r.staged = nil
+}
+
+// Poll fetches the configs again, with their ETags, and answers whether the
+// content of any of them changed; the changes are verified and staged
+func (r *Remote) Poll(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	docs := make(map[string]*remoteDoc, len(r.docs))
+	for u, doc := range r.docs {
+		docs[u] = doc
+	}
+	r.mu.Unlock()
+
+	staged := make(map[string]*remoteDoc)
+	for u, doc := range docs {
+		var (
+			next *remoteDoc
+			err  error
+		)
+		if doc.dir {
+			next, err = r.fetchDirectory(ctx, u, doc)
+		} else {
+			next, err = r.fetchFile(ctx, u, doc)
+		}
+		if err != nil {
+			return false, err
+		}
+		if next == nil {
+			continue
+		}
+		if bytes.Equal(next.data, doc.data) {
+			// same content with another ETag, keep it for the next poll
+			r.mu.Lock()
+			if r.docs[u] == doc {
+				kept := *doc
+				kept.etag = next.etag
+				r.docs[u] = &kept
+			}
+			r.mu.Unlock()
+			continue
+		}
+		staged[u] = next
+	}
+
+	r.mu.Lock()
+	r.staged = staged
+	r.mu.Unlock()
+	return len(staged) > 0, nil
+}
+
+// file answers the config of the URL, fetching it when not fetched before
+func (r *Remote) file(u string) ([]byte, error) {
+	r.mu.Lock()
+	doc, ok := r.docs[u]
+	r.mu.Unlock()
+	if ok {
+		return doc.data, nil
+	}
+
+	r.warnUnverified(u)
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	doc, err := r.fetchFile(ctx, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.docs[u] = doc
+	r.mu.Unlock()
+	return doc.data, nil
+}
+
+// directory answers the *.conf files of the config directory of the URL,
+// ordered by name, fetching them when not fetched before
+func (r *Remote) directory(u string) ([]remoteFile, error) {
+	u = strings.TrimSuffix(u, "/") + "/"
+	r.mu.Lock()
+	doc, ok := r.docs[u]
+	r.mu.Unlock()
+	if ok {
+		return doc.files, nil
+	}
+
+	r.warnUnverified(u)
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	doc, err := r.fetchDirectory(ctx, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.docs[u] = doc
+	r.mu.Unlock()
+	return doc.files, nil
+}
+
+func (r *Remote) warnUnverified(u string) {
+	if r.PublicKey == nil && strings.HasPrefix(u, "http:") {
+		log.Printf("W! Config %s is fetched over plain http without signature verification", u)
+	}
+}
+
+// fetchFile fetches and verifies the config of the URL; it answers nil when
+// its ETag is the one of prev
+func (r *Remote) fetchFile(ctx context.Context, u string, prev *remoteDoc) (*remoteDoc, error) {
+	data, etag, err := r.get(ctx, u, prev)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	if err := r.verify(ctx, u, data); err != nil {
+		return nil, err
+	}
+	return &remoteDoc{etag: etag, data: data}, nil
+}
+
+// fetchDirectory fetches and verifies the manifest of the config directory
+// of the URL, and the *.conf files it lists which changed since prev; it
+// answers nil when the ETag of the manifest is the one of prev
+func (r *Remote) fetchDirectory(ctx context.Context, u string, prev *remoteDoc) (*remoteDoc, error) {
+	base, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("url parse (%s): %w", u, err)
+	}
+	manifest := base.ResolveReference(&url.URL{Path: remoteManifest}).String()
+	data, etag, err := r.get(ctx, manifest, prev)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	if err := r.verify(ctx, manifest, data); err != nil {
+		return nil, err
+	}
+	files, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifest, err)
+	}
+
+	known := make(map[string]remoteFile)
+	if prev != nil {
+		for _, f := range prev.files {
+			known[f.name] = f
+		}
+	}
+	for i, f := range files {
+		if k, ok := known[f.name]; ok && k.sum == f.sum {
+			files[i].data = k.data
+			continue
+		}
+		fu := base.ResolveReference(&url.URL{Path: f.name}).String()
+		content, _, err := r.get(ctx, fu, nil)
+		if err != nil {
+			return nil, err
+		}
+		if sha256.Sum256(content) != f.sum {
+			return nil, fmt.Errorf("%s: checksum does not match %s", fu, remoteManifest)
+		}
+		files[i].data = content
+	}
+	return &remoteDoc{dir: true, etag: etag, data: data, files: files}, nil
+}
+
+// get fetches the URL, conditionally on the ETag of prev, and checks the
+// content against the Digest header; it answers no content when not modified
+func (r *Remote) get(ctx context.Context, u string, prev *remoteDoc) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("http new req (%s): %w", u, err)
+	}
+	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	req.Header.Add("Accept", "application/toml")
+	req.Header.Set("User-Agent", internal.ProductToken())
+	if prev != nil && prev.etag != "" {
+		req.Header.Set("If-None-Match", prev.etag)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("http do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if prev != nil {
+			return nil, prev.etag, nil
+		}
+		fallthrough
+	default:
+		return nil, "", fmt.Errorf("failed to retrieve remote config %s: %s", u, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", u, err)
+	}
+	if len(data) > remoteMaxSize {
+		return nil, "", fmt.Errorf("%s: larger than %d bytes", u, remoteMaxSize)
+	}
+	if sum, ok := digestSHA256(resp.Header.Values("Digest")); ok && sha256.Sum256(data) != sum {
+		return nil, "", fmt.Errorf("%s: checksum does not match Digest header", u)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// verify checks the signature of the content of the URL, when a public key
+// is set; the signature is read raw or base64 encoded
+func (r *Remote) verify(ctx context.Context, u string, data []byte) error {
+	if r.PublicKey == nil {
+		return nil
+	}
+	sig, _, err := r.get(ctx, u+remoteSignature, nil)
+	if err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		if sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil {
+			return fmt.Errorf("signature %s%s: %w", u, remoteSignature, err)
+		}
+	}
+	if !ed25519.Verify(r.PublicKey, data, sig) {
+		return fmt.Errorf("%s: signature verification failed", u)
+	}
+	return nil
+}
+
+// digestSHA256 answers the SHA-256 checksum of the Digest header values,
+// e.g. "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="
+func digestSHA256(values []string) ([sha256.Size]byte, bool) {
+	var sum [sha256.Size]byte
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			parts := strings.SplitN(strings.TrimSpace(d), "=", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "SHA-256") {
+				continue
+			}
+			raw, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil || len(raw) != sha256.Size {
+				continue
+			}
+			copy(sum[:], raw)
+			return sum, true
+		}
+	}
+	return sum, false
+}
+
+// parseManifest answers the *.conf files of a manifest in the format of
+// sha256sum, ordered by name, e.g.
+//
+//	3a6e...c1f2  inputs.conf
+//	9b0d...77aa  outputs/circonus.conf
+func parseManifest(data []byte) ([]remoteFile, error) {
+	var files []remoteFile
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: no file name", n)
+		}
+		// "*" marks files read in binary mode
+		sum, name := parts[0], strings.TrimPrefix(strings.TrimSpace(parts[1]), "*")
+		raw, err := hex.DecodeString(sum)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("line %d: invalid checksum %q", n, sum)
+		}
+		if name == "" || path.IsAbs(name) || path.Clean(name) != name || strings.HasPrefix(name, "../") || strings.Contains(name, `\`) {
+			return nil, fmt.Errorf("line %d: invalid file name %q", n, name)
+		}
+		if !strings.HasSuffix(name, ".conf") {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("line %d: duplicate file name %q", n, name)
+		}
+		seen[name] = true
+		f := remoteFile{name: name}
+		copy(f.sum[:], raw)
+		files = append(files, f)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
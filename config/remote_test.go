@@ -0,0 +1,208 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteServer serves documents by path with their SHA-256 as ETag
+type remoteServer struct {
+	sync.Mutex
+	docs map[string][]byte
+}
+
+func (s *remoteServer) set(path string, data []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.docs[path] = data
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	data, ok := s.docs[r.URL.Path]
+	s.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:8]))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	_, _ = w.Write(data)
+}
+
+func manifest(files map[string][]byte) []byte {
+	var m []byte
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		m = append(m, fmt.Sprintf("%x  %s\n", sum, name)...)
+	}
+	return m
+}
+
+const remoteOutput = `
+[[outputs.discard]]
+`
+
+func TestRemote_File(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	srv := &remoteServer{docs: map[string][]byte{}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	conf := []byte(remoteOutput)
+	srv.set("/cua.conf", conf)
+	srv.set("/cua.conf.sig", ed25519.Sign(priv, conf))
+
+	r := NewRemote(pub)
+	c := NewConfig()
+	c.Remote = r
+	require.NoError(t, c.LoadConfig(ts.URL+"/cua.conf"))
+	require.Len(t, c.Outputs, 1)
+	require.True(t, r.Fetched())
+
+	// unchanged, answered by ETag
+	changed, err := r.Poll(context.Background())
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// changed without a valid signature
+	srv.set("/cua.conf", []byte(remoteOutput+remoteOutput))
+	_, err = r.Poll(context.Background())
+	require.Error(t, err)
+
+	srv.set("/cua.conf.sig", []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(remoteOutput+remoteOutput)))))
+	changed, err = r.Poll(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	// not applied until Apply
+	c = NewConfig()
+	c.Remote = r
+	require.NoError(t, c.LoadConfig(ts.URL+"/cua.conf"))
+	require.Len(t, c.Outputs, 1)
+
+	c = NewConfig()
+	c.Remote = r.Staged()
+	require.NoError(t, c.LoadConfig(ts.URL+"/cua.conf"))
+	require.Len(t, c.Outputs, 2)
+
+	r.Apply()
+	c = NewConfig()
+	c.Remote = r
+	require.NoError(t, c.LoadConfig(ts.URL+"/cua.conf"))
+	require.Len(t, c.Outputs, 2)
+}
+
+func TestRemote_Discard(t *testing.T) {
+	srv := &remoteServer{docs: map[string][]byte{}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	srv.set("/cua.conf", []byte(remoteOutput))
+	r := NewRemote(nil)
+	data, err := r.file(ts.URL + "/cua.conf")
+	require.NoError(t, err)
+	require.Equal(t, remoteOutput, string(data))
+
+	srv.set("/cua.conf", []byte("[[outputs.discard"))
+	changed, err := r.Poll(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+	r.Discard()
+
+	// the rejected content is not staged again
+	changed, err = r.Poll(context.Background())
+	require.NoError(t, err)
+	require.False(t, changed)
+	data, err = r.file(ts.URL + "/cua.conf")
+	require.NoError(t, err)
+	require.Equal(t, remoteOutput, string(data))
+}
+
+func TestRemote_Directory(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	srv := &remoteServer{docs: map[string][]byte{}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	files := map[string][]byte{
+		"a.conf":         []byte(remoteOutput),
+		"outputs/b.conf": []byte(remoteOutput),
+		"README":         []byte("not loaded"),
+	}
+	for name, data := range files {
+		srv.set("/conf.d/"+name, data)
+	}
+	m := manifest(files)
+	srv.set("/conf.d/SHA256SUMS", m)
+	srv.set("/conf.d/SHA256SUMS.sig", ed25519.Sign(priv, m))
+
+	r := NewRemote(pub)
+	c := NewConfig()
+	c.Remote = r
+	require.NoError(t, c.LoadDirectory(ts.URL+"/conf.d"))
+	require.Len(t, c.Outputs, 2)
+
+	// a file not matching its checksum
+	files["c.conf"] = []byte(remoteOutput)
+	m = manifest(files)
+	srv.set("/conf.d/c.conf", []byte("[[outputs.discard]]\n[[outputs.discard]]\n"))
+	srv.set("/conf.d/SHA256SUMS", m)
+	srv.set("/conf.d/SHA256SUMS.sig", ed25519.Sign(priv, m))
+	_, err = r.Poll(context.Background())
+	require.Error(t, err)
+
+	srv.set("/conf.d/c.conf", files["c.conf"])
+	changed, err := r.Poll(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+	r.Apply()
+
+	for _, u := range []string{ts.URL + "/conf.d", ts.URL + "/conf.d/"} {
+		c = NewConfig()
+		c.Remote = r
+		require.NoError(t, c.LoadDirectory(u))
+		require.Len(t, c.Outputs, 3)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	sum := sha256.Sum256(nil)
+	line := hex.EncodeToString(sum[:])
+
+	files, err := parseManifest([]byte("# comment\n" + line + " *b.conf\n" + line + "  a.conf\n" + line + "  a.txt\n"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "a.conf", files[0].name)
+	require.Equal(t, "b.conf", files[1].name)
+
+	for _, bad := range []string{
+		line + "  ../a.conf",
+		line + "  /etc/a.conf",
+		line + "  a.conf\n" + line + "  a.conf",
+		"abc  a.conf",
+		line,
+	} {
+		_, err := parseManifest([]byte(bad))
+		require.Error(t, err, bad)
+	}
+}
@@ -44,6 +44,22 @@ type Accumulator interface {
 		tags map[string]string,
 		t ...time.Time)
 
+	// AddSamples adds individual samples of a distribution, e.g. response
+	// times, as a "Histogram" metric counting the samples of each value, so
+	// outputs record them into a histogram instead of pre-aggregated values.
+	AddSamples(measurement string,
+		samples []float64,
+		tags map[string]string,
+		t ...time.Time)
+
+	// AddEvent adds a discrete event, e.g. a received trap or a container
+	// stopping, as an "Event" metric with the title, body and severity of
+	// the event, so outputs can record it instead of a counter.
+	AddEvent(title, body string,
+		severity Severity,
+		tags map[string]string,
+		t ...time.Time)
+
 	// AddMetric adds an metric to the accumulator.
 	AddMetric(Metric)
 
@@ -60,6 +76,36 @@ type Accumulator interface {
 	WithTracking(maxTracked int) TrackingAccumulator
 }
 
+// StreamingAccumulator is an Accumulator which takes the metrics of a
+// gather as they are produced. Inputs producing a very large number of
+// metrics, e.g. scrapes with hundreds of thousands of series, stream them
+// instead of building a slice of all of them first.
+type StreamingAccumulator interface {
+	Accumulator
+
+	// NewStream opens a stream holding at most size pending metrics.
+	NewStream(size int) MetricStream
+}
+
+// MetricStream adds metrics in the order they are sent, blocking the sender
+// while the stream is full.
+type MetricStream interface {
+	// NewMetric creates a metric for the stream, possibly recycling a metric
+	// dropped by the filters of the input.
+	NewMetric(measurement string,
+		tags map[string]string,
+		fields map[string]interface{},
+		t time.Time,
+		tp ValueType) Metric
+
+	// AddMetric sends a metric down the stream, it must not be modified
+	// afterwards.
+	AddMetric(Metric)
+
+	// Close ends the stream and waits until all metrics are added.
+	Close()
+}
+
 // TrackingID uniquely identifies a tracked metric group
 type TrackingID uint64
 
@@ -0,0 +1,48 @@
+package cua
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of an event added with AddEvent.
+type Severity int
+
+// Possible values for the Severity enum.
+const (
+	Info Severity = iota
+	Warning
+	Error
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	case Critical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// ParseSeverity answers the severity named by s, e.g. "warning".
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return Info, nil
+	case "warning", "warn":
+		return Warning, nil
+	case "error":
+		return Error, nil
+	case "critical":
+		return Critical, nil
+	default:
+		return Info, fmt.Errorf("unknown severity (%s)", s)
+	}
+}
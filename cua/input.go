@@ -1,6 +1,14 @@
 package cua
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrFullGather is returned by GatherChanges when the input lost track of
+// the changes, e.g. its change feed expired, so the collection is retried
+// with a full Gather
+var ErrFullGather = errors.New("full gather required")
 
 type Input interface {
 	PluginDescriber
@@ -10,6 +18,28 @@ type Input interface {
 	Gather(context.Context, Accumulator) error
 }
 
+// IncrementalInput is an Input whose Gather enumerates many objects, e.g. the
+// virtual machines of a vCenter, able to gather in between by only applying
+// the changes since the last collection to the objects it knows
+type IncrementalInput interface {
+	Input
+
+	// GatherChanges updates the objects known from the previous collections
+	// with the ones changed since, and adds the metrics of all of them like
+	// Gather.  It is only called after a successful collection.
+	GatherChanges(context.Context, Accumulator) error
+}
+
+// ClosingInput is implemented by inputs keeping resources, e.g. connections,
+// open between collections.  Close is called once when the input is stopped,
+// after its gather loop ended.
+type ClosingInput interface {
+	Input
+
+	// Close releases the resources kept between collections
+	Close() error
+}
+
 type ServiceInput interface {
 	Input
 
@@ -16,6 +16,7 @@ const (
 	Summary
 	Histogram
 	CumulativeHistogram
+	Event
 )
 
 // Tag represents a single tag key and value.
@@ -142,4 +143,8 @@ type Metric interface {
 	OriginInstance() string
 	// SetOriginInstance sets the origin instance id
 	SetOriginInstance(string)
+	// Pipeline gets the name of the pipeline the metric is routed in
+	Pipeline() string
+	// SetPipeline sets the name of the pipeline
+	SetPipeline(string)
 }
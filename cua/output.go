@@ -27,3 +27,26 @@ type AggregatingOutput interface {
 	// Reset signals the the aggregator period is completed.
 	Reset()
 }
+
+// RecyclingOutput is implemented by outputs done with the metrics written
+// once Write returns, e.g. having serialized them.  The agent then reuses
+// the metrics written for new metrics, so the output must not keep them,
+// nor their tags and fields, afterwards.
+type RecyclingOutput interface {
+	Output
+
+	// RecyclesMetrics answers whether the output is done with the metrics
+	// written once Write returns, e.g. depending on its settings
+	RecyclesMetrics() bool
+}
+
+// ConcurrentOutput is implemented by outputs supporting calls of Write from
+// several goroutines at once.  The write workers of such outputs share an
+// instance of the output, the other outputs get an instance per worker.
+type ConcurrentOutput interface {
+	Output
+
+	// ConcurrentWrites answers whether Write may be called concurrently,
+	// e.g. depending on its settings
+	ConcurrentWrites() bool
+}
@@ -40,3 +40,24 @@ type Logger interface {
 	// Info logs an information message, patterned after log.Print.
 	Info(args ...interface{})
 }
+
+// StatefulPlugin is an interface that plugins can optionally implement to
+// keep state, e.g. read offsets, across restarts of the agent.  The state is
+// stored at shutdown when the agent has a statefile configured.
+type StatefulPlugin interface {
+	// GetState returns the state to store, it must be serializable to JSON.
+	GetState() interface{}
+
+	// SetState restores the stored state before the plugin is started. The
+	// state has the type of the value returned by GetState.
+	SetState(state interface{}) error
+}
+
+// PrivilegedPlugin is an interface that plugins can optionally implement to
+// declare the Linux capabilities, e.g. CAP_NET_RAW, they need beyond those of
+// an unprivileged user.  They are kept when the agent drops its capabilities.
+type PrivilegedPlugin interface {
+	// Capabilities returns the names of the capabilities the plugin needs
+	// with its configuration.
+	Capabilities() []string
+}
@@ -152,19 +152,19 @@ __configure_agent() {
     [[ -f $cua_conf_file ]] || fail "config file (${cua_conf_file}) not found"
 
     log "\tSetting Circonus API key in configuration"
-    \sed -i -e "s/  api_token = \"\"/  api_token = \"${cua_api_key}\"/" $cua_conf_file
+    \sed -i '' -e "s/  api_token = \"\"/  api_token = \"${cua_api_key}\"/" $cua_conf_file
     [[ $? -eq 0 ]] || fail "updating ${cua_conf_file} with api key"
 
     if [[ -n "${cua_api_app}" ]]; then
         log "\tSetting Circonus API app name in configuration"
-        \sed -i -e "s/  api_app = \"\"/  api_app = \"${cua_api_app}\"/" $cua_conf_file
+        \sed -i '' -e "s/  api_app = \"\"/  api_app = \"${cua_api_app}\"/" $cua_conf_file
         [[ $? -eq 0 ]] || fail "updating ${cua_conf_file} with api app"
     fi
 
     log "Starting circonus-unified-agent service"
 
     ${cua_service_file} start
-    [[ $? -eq 0 ]] || fail "${cua_service_file} enable"
+    [[ $? -eq 0 ]] || fail "${cua_service_file} start"
 }
 
 __configure_service() {
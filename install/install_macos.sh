@@ -82,7 +82,7 @@ __cua_init() {
     set +o errexit
     
     # trigger error if needed commands are not found...
-    local cmd_list="cat curl sed uname mkdir basename tar"
+    local cmd_list="cat curl sed uname mkdir basename tar launchctl"
     local cmd
     for cmd in $cmd_list; do
         type -P $cmd >/dev/null 2>&1 || fail "Unable to find '${cmd}' command. Ensure it is available in PATH '${PATH}' before continuing."
@@ -140,19 +140,14 @@ __configure_agent() {
     [[ -f $cua_conf_file ]] || fail "config file (${cua_conf_file}) not found"
 
     log "\tSetting Circonus API key in configuration"
-    \sed -i -e "s/  api_token = \"\"/  api_token = \"${cua_api_key}\"/" $cua_conf_file
+    \sed -i '' -e "s/  api_token = \"\"/  api_token = \"${cua_api_key}\"/" $cua_conf_file
     [[ $? -eq 0 ]] || fail "updating ${cua_conf_file} with api key"
 
     if [[ -n "${cua_api_app}" ]]; then
         log "\tSetting Circonus API app name in configuration"
-        \sed -i -e "s/  api_app = \"\"/  api_app = \"${cua_api_app}\"/" $cua_conf_file
+        \sed -i '' -e "s/  api_app = \"\"/  api_app = \"${cua_api_app}\"/" $cua_conf_file
         [[ $? -eq 0 ]] || fail "updating ${cua_conf_file} with api app"
     fi
-
-    log "Starting circonus-unified-agent service"
-
-    \launchctl start com.circonus.circonus-unified-agent
-    [[ $? -eq 0 ]] || fail "launchctl start com.circonus.circonus-unified-agent"
 }
 
 __configure_service() {
@@ -162,9 +157,14 @@ __configure_service() {
 
     [[ -f $cua_service_file ]] || fail "Service file (${cua_service_file}) not found"
 
-    \launchctl load -w ${cua_service_file}
+    # launchd ignores daemon plists not owned by root or writable by others
+    \chown root:wheel ${cua_service_file}
+    \chmod 644 ${cua_service_file}
 
-    log "Creating circonus-unified-agent service"
+    log "Starting circonus-unified-agent service"
+
+    \launchctl load -w ${cua_service_file}
+    [[ $? -eq 0 ]] || fail "launchctl load -w ${cua_service_file}"
 }
 
 __get_latest_release() {
@@ -195,8 +195,8 @@ cua_install() {
     __cua_init "$@"
     __make_circonus_dir
     __get_cua_package
-    __configure_service
     __configure_agent
+    __configure_service
 
     echo
     echo
@@ -0,0 +1,87 @@
+// Package capability drops the Linux capabilities of the agent process not
+// needed by its plugins, see capabilities(7).
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotSupported is returned by Drop on platforms without capabilities
+var ErrNotSupported = errors.New("capabilities are not supported on this platform")
+
+// names of the capabilities, indexed by their number
+var names = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// parse answers the numbers of the named capabilities, names are case
+// insensitive and the CAP_ prefix is optional, e.g. net_raw.
+func parse(capabilities []string) ([]int, error) {
+	nums := make([]int, 0, len(capabilities))
+	for _, c := range capabilities {
+		name := strings.ToUpper(c)
+		if !strings.HasPrefix(name, "CAP_") {
+			name = "CAP_" + name
+		}
+		found := false
+		for i, n := range names {
+			if n == name {
+				nums = append(nums, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown capability %q", c)
+		}
+	}
+	return nums, nil
+}
+
+// Validate checks the names of the capabilities
+func Validate(capabilities []string) error {
+	_, err := parse(capabilities)
+	return err
+}
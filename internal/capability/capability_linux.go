@@ -0,0 +1,55 @@
+package capability
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Drop removes all capabilities but the given ones from the effective,
+// permitted and inheritable sets of all threads of the process, for good.
+// Ambient capabilities not kept are cleared with them, so commands run by
+// plugins do not inherit them either.  It answers the kept capabilities the
+// process does not hold.
+//
+// Changing the capabilities of all threads requires a build without cgo.
+func Drop(keep []string) ([]string, error) {
+	nums, err := parse(keep)
+	if err != nil {
+		return nil, err
+	}
+	var mask [2]uint32
+	for _, n := range nums {
+		mask[n/32] |= 1 << (n % 32)
+	}
+
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return nil, fmt.Errorf("getting capabilities: %w", err)
+	}
+
+	var missing []string
+	for _, n := range nums {
+		if data[n/32].Permitted&(1<<(n%32)) == 0 {
+			missing = append(missing, names[n])
+		}
+	}
+
+	for i := range data {
+		data[i].Effective &= mask[i]
+		data[i].Permitted &= mask[i]
+		data[i].Inheritable &= mask[i]
+	}
+	_, _, errno := syscall.AllThreadsSyscall(unix.SYS_CAPSET,
+		uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0)
+	if errno != 0 {
+		if errno == syscall.ENOTSUP {
+			return nil, fmt.Errorf("setting capabilities: not supported in builds with cgo")
+		}
+		return nil, fmt.Errorf("setting capabilities: %w", errno)
+	}
+	return missing, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package capability
+
+// Drop is not supported on this platform
+func Drop(keep []string) ([]string, error) {
+	if _, err := parse(keep); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,16 @@
+package capability
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	nums, err := parse([]string{"CAP_NET_RAW", "net_bind_service", "cap_checkpoint_restore"})
+	require.NoError(t, err)
+	require.Equal(t, []int{13, 10, 40}, nums)
+
+	require.Error(t, Validate([]string{"CAP_NET_RAW", "CAP_UNKNOWN"}))
+	require.NoError(t, Validate(nil))
+}
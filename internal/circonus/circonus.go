@@ -7,6 +7,7 @@ import (
 	"os"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,6 +33,8 @@ type Circonus struct {
 	brokerCIDrx      string
 	globalTags       trapmetrics.Tags
 	ready            bool
+
+	instanceTags map[string]map[string]string // config tags of plugin instances by instance id
 }
 
 type MetricMeta struct {
@@ -48,6 +51,7 @@ type MetricDestConfig struct {
 	Broker       string  // allow override of broker for a specific plugin (dm input or circonus output)
 	Hostname     string  // allow override of hostname for a specific plugin (dm input or circonus output)
 	MetricMeta   MetricMeta
+	Submit       *SubmitConfig // optional: submission settings (circonus output)
 }
 
 // Logshim is for api and traps - it uses the info level and
@@ -187,6 +191,44 @@ func GetGlobalTags() trapmetrics.Tags {
 	return ch.globalTags
 }
 
+// AddInstanceTags records the tags of a plugin instance from its config, so
+// they are added to the check of the instance along with the global tags
+func AddInstanceTags(instanceID string, tags map[string]string) {
+	if ch == nil || instanceID == "" {
+		return
+	}
+	ch.Lock()
+	defer ch.Unlock()
+	if ch.instanceTags == nil {
+		ch.instanceTags = make(map[string]map[string]string)
+	}
+	itags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		if k != "" && v != "" {
+			itags[k] = v
+		}
+	}
+	ch.instanceTags[instanceID] = itags
+}
+
+// configCheckTags returns the global tags and the tags of a plugin instance
+// from the config as check tags, the instance tags win
+func configCheckTags(instanceID string) []string {
+	tags := make(map[string]string)
+	for _, t := range ch.globalTags {
+		tags[t.Category] = t.Value
+	}
+	for k, v := range ch.instanceTags[instanceID] {
+		tags[k] = v
+	}
+	checkTags := make([]string, 0, len(tags))
+	for k, v := range tags {
+		checkTags = append(checkTags, strings.ToLower(k+":"+v))
+	}
+	sort.Strings(checkTags)
+	return checkTags
+}
+
 // getAPIClient returns a Circonus API client or an error
 func getAPIClient(opts *MetricDestConfig) (*apiclient.API, error) {
 	if ch == nil {
@@ -364,6 +406,9 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 		checkTags = append(checkTags, getOSCheckTags()...)
 	}
 
+	cfgTags := configCheckTags(instanceID)
+	checkTags = append(checkTags, cfgTags...)
+
 	checkTarget := hostname
 
 	instanceLogger := &Logshim{
@@ -407,7 +452,10 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 				return nil, fmt.Errorf("circonus metric destination management module: unable to get broker tls config: %w", err)
 			}
 			if t != nil {
-				ch.brokerTLSConfigs[bundle.Brokers[0]] = t.Clone()
+				if t, err = fipsTLSConfig(t.Clone()); err != nil {
+					return nil, fmt.Errorf("circonus metric destination management module: %w", err)
+				}
+				ch.brokerTLSConfigs[bundle.Brokers[0]] = t
 			} else {
 				// note: err==nil and t==nil means public broker (api.circonus.com) or using http: as the schema
 				ch.brokerTLSConfigs[bundle.Brokers[0]] = t
@@ -474,7 +522,7 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 		saveCheckConfig(destKey, bundle)
 	}
 
-	if pluginID == "host" {
+	if pluginID == "host" || len(cfgTags) > 0 {
 		if b, err := updateCheckTags(circAPI, bundle, checkTags, logger); err != nil {
 			logger.Warnf("circonus metric destination management moudle: updating check %s", err)
 		} else if b != nil {
@@ -491,7 +539,10 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 			return nil, fmt.Errorf("circonus metric destination management module: unable to get broker tls config: %w", err)
 		}
 		if t != nil {
-			ch.brokerTLSConfigs[bundle.Brokers[0]] = t.Clone()
+			if t, err = fipsTLSConfig(t.Clone()); err != nil {
+				return nil, fmt.Errorf("circonus metric destination management module: %w", err)
+			}
+			ch.brokerTLSConfigs[bundle.Brokers[0]] = t
 		} else {
 			// note: err==nil and t==nil means public broker (api.circonus.com) or using http: as the schema
 			ch.brokerTLSConfigs[bundle.Brokers[0]] = t
@@ -503,6 +554,16 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 		Trap:   tch,
 		Logger: instanceLogger,
 	}
+
+	var sub *submitter
+	if opts.Submit != nil {
+		var err error
+		sub, err = newSubmitter(tch, *opts.Submit, traceMetrics, logger)
+		if err != nil {
+			return nil, fmt.Errorf("circonus metric destination management module: %w", err)
+		}
+		tm.Trap = sub
+	}
 	metrics, err := createMetrics(tm)
 	if err != nil {
 		return nil, err
@@ -527,6 +588,9 @@ func NewMetricDestination(opts *MetricDestConfig, logger cua.Logger) (*trapmetri
 			circAPI.Debug = debugAPI
 			instanceLogger.debugAPI = debugAPI
 			_, _ = tch.TraceMetrics(traceMetrics)
+			if sub != nil {
+				sub.setTrace(traceMetrics)
+			}
 			ch.logger.Infof("set debug:%t trace:%s on check %s", debugAPI, traceMetrics, checkUUID)
 		}
 	}
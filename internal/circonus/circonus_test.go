@@ -0,0 +1,25 @@
+package circonus
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/circonus-labs/go-trapmetrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCheckTags(t *testing.T) {
+	saved := ch
+	defer func() { ch = saved }()
+	ch = &Circonus{}
+
+	AddGlobalTags(map[string]string{"env": "prod", "team": "Ops", "empty": ""})
+	AddInstanceTags("web", map[string]string{"role": "frontend", "team": "web"})
+	AddInstanceTags("", map[string]string{"role": "ignored"})
+
+	global := GetGlobalTags()
+	sort.Slice(global, func(i, j int) bool { return global[i].Category < global[j].Category })
+	require.Equal(t, trapmetrics.Tags{{Category: "env", Value: "prod"}, {Category: "team", Value: "Ops"}}, global)
+	require.Equal(t, []string{"env:prod", "role:frontend", "team:web"}, configCheckTags("web"))
+	require.Equal(t, []string{"env:prod", "team:ops"}, configCheckTags("db"))
+}
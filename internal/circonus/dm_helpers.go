@@ -8,6 +8,24 @@ import (
 
 // Contains helpers for direct metric input plugins
 
+// MetricNaming holds the name_override, name_prefix and name_suffix options
+// of an input. Direct metrics bypass the agent, which applies them to the
+// metrics of other inputs, so direct metric input plugins embed it and apply
+// it to their measurement, the input_metric_group tag of their metrics.
+type MetricNaming struct {
+	NameOverride string `toml:"name_override"`
+	NamePrefix   string `toml:"name_prefix"`
+	NameSuffix   string `toml:"name_suffix"`
+}
+
+// Measurement returns the measurement name with the naming options applied
+func (n MetricNaming) Measurement(name string) string {
+	if n.NameOverride != "" {
+		name = n.NameOverride
+	}
+	return n.NamePrefix + name + n.NameSuffix
+}
+
 func AddMetricToDest(dest *trapmetrics.TrapMetrics, pluginID, metricGroup, metricName string, metricTags, staticInputTags map[string]string, value interface{}, ts time.Time) error {
 
 	tags := ConvertTags(pluginID, metricGroup, metricTags, staticInputTags)
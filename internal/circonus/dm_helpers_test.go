@@ -0,0 +1,24 @@
+package circonus
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/go-trapmetrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricNaming(t *testing.T) {
+	require.Equal(t, "ping", MetricNaming{}.Measurement("ping"))
+	require.Equal(t, "dc1_ping_lan", MetricNaming{NamePrefix: "dc1_", NameSuffix: "_lan"}.Measurement("ping"))
+	require.Equal(t, "dc1_gw", MetricNaming{NameOverride: "gw", NamePrefix: "dc1_"}.Measurement("ping"))
+
+	// the measurement is the input_metric_group tag, as for metrics sent
+	// through the circonus output
+	tags := ConvertTags("ping", MetricNaming{NamePrefix: "dc1_"}.Measurement("ping"), nil, nil)
+	require.Equal(t, trapmetrics.Tags{
+		{Category: "input_plugin", Value: "ping"},
+		{Category: "input_metric_group", Value: "dc1_ping"},
+	}, tags)
+	require.Equal(t, trapmetrics.Tags{{Category: "input_plugin", Value: "ping"}},
+		ConvertTags("ping", MetricNaming{}.Measurement("ping"), nil, nil))
+}
@@ -0,0 +1,398 @@
+package circonus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
+	"github.com/circonus-labs/circonus-unified-agent/internal/release"
+	ctls "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	apiconf "github.com/circonus-labs/go-apiclient/config"
+	"github.com/circonus-labs/go-trapcheck"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// payloads smaller than this are sent uncompressed, same as go-trapcheck
+	compressionThreshold = 1024
+
+	defaultSubmitRetries = 7
+	submitRetryWaitMin   = 50 * time.Millisecond
+	submitRetryWaitMax   = 2 * time.Second
+)
+
+// SubmitConfig tunes how a metric destination submits to its broker. When
+// set on a MetricDestConfig, submissions are made by the agent instead of
+// go-trapcheck, which has fixed settings.
+type SubmitConfig struct {
+	Compression string         // gzip (default), deflate or none
+	Timeout     time.Duration  // timeout of a submission, including retries, 0 for none
+	MaxRetries  *int           // retries of a failed request (default 7)
+	OnRetry     func()         // called for every retried request
+	ProxyURL    string         // proxy for submissions, default is the proxy environment variables
+	NoProxy     []string       // hosts, domains and networks not to proxy, NO_PROXY syntax
+	BrokerCAs   *x509.CertPool // pinned broker CA, replaces the CA provided by the API
+}
+
+// Validate checks the settings of the submit config
+func (sc *SubmitConfig) Validate() error {
+	switch sc.Compression {
+	case "", "gzip", "deflate", "none":
+	default:
+		return fmt.Errorf("invalid compression %q, must be gzip, deflate or none", sc.Compression)
+	}
+	if sc.MaxRetries != nil && *sc.MaxRetries < 0 {
+		return fmt.Errorf("invalid max retries %d", *sc.MaxRetries)
+	}
+	if sc.ProxyURL != "" {
+		u, err := url.Parse(sc.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy url %q, scheme must be http, https or socks5", sc.ProxyURL)
+		}
+	}
+	return nil
+}
+
+// proxy answers the proxy function of the submit transport
+func (sc *SubmitConfig) proxy() func(*http.Request) (*url.URL, error) {
+	if sc.ProxyURL == "" {
+		return http.ProxyFromEnvironment
+	}
+	pc := &httpproxy.Config{
+		HTTPProxy:  sc.ProxyURL,
+		HTTPSProxy: sc.ProxyURL,
+		NoProxy:    strings.Join(sc.NoProxy, ","),
+	}
+	proxyFunc := pc.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// submitter sends the metrics of a trap check to its broker. It keeps the
+// connection to the broker open between submissions and leaves finding the
+// check again, e.g. after it moved to another broker, to go-trapcheck.
+type submitter struct {
+	sync.Mutex
+	check      *trapcheck.TrapCheck
+	cfg        SubmitConfig
+	maxRetries int
+	logger     cua.Logger
+	trace      string
+	url        string
+	client     *http.Client
+}
+
+func newSubmitter(check *trapcheck.TrapCheck, cfg SubmitConfig, trace string, logger cua.Logger) (*submitter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	s := &submitter{
+		check:      check,
+		cfg:        cfg,
+		maxRetries: defaultSubmitRetries,
+		logger:     logger,
+		trace:      trace,
+	}
+	if cfg.MaxRetries != nil {
+		s.maxRetries = *cfg.MaxRetries
+	}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refresh takes the submission url and broker tls config from the check
+func (s *submitter) refresh() error {
+	bundle, err := s.check.GetCheckBundle()
+	if err != nil {
+		return fmt.Errorf("submitter: %w", err)
+	}
+	surl, ok := bundle.Config[apiconf.SubmissionURL]
+	if !ok {
+		return fmt.Errorf("submitter: no submission url in check bundle (%s)", bundle.CID)
+	}
+
+	var tlsConfig *tls.Config
+	if strings.HasPrefix(surl, "https:") {
+		if tlsConfig, err = s.check.GetBrokerTLSConfig(); err != nil {
+			return fmt.Errorf("submitter: broker tls config: %w", err)
+		}
+		if s.cfg.BrokerCAs != nil {
+			if tlsConfig, err = pinnedTLSConfig(tlsConfig, surl, s.cfg.BrokerCAs); err != nil {
+				return fmt.Errorf("submitter: %w", err)
+			}
+		}
+		if tlsConfig, err = fipsTLSConfig(tlsConfig); err != nil {
+			return fmt.Errorf("submitter: %w", err)
+		}
+	}
+
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+	s.url = surl
+	s.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy: s.cfg.proxy(),
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return nil
+}
+
+// fipsTLSConfig answers a copy of the tls config of a broker restricted to
+// the approved algorithms in fips mode, a new one for brokers without
+func fipsTLSConfig(config *tls.Config) (*tls.Config, error) {
+	if !fips.Enabled() {
+		return config, nil
+	}
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	} else {
+		config = config.Clone()
+	}
+	if err := ctls.ApplyFIPS(config); err != nil {
+		return nil, fmt.Errorf("broker tls config: %w", err)
+	}
+	return config, nil
+}
+
+// pinnedTLSConfig answers a tls config for the broker trusting only the
+// pinned CAs. Enterprise broker certificates carry the broker name in the
+// common name only, so those are verified the way go-trapcheck does, by
+// checking the chain and the common name against the expected server name.
+func pinnedTLSConfig(broker *tls.Config, surl string, roots *x509.CertPool) (*tls.Config, error) {
+	if broker == nil {
+		// public broker, its certificate has proper subject alternative names
+		u, err := url.Parse(surl)
+		if err != nil {
+			return nil, fmt.Errorf("parsing submission url: %w", err)
+		}
+		return &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: u.Hostname(),
+			RootCAs:    roots,
+		}, nil
+	}
+
+	serverName := broker.ServerName
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// the chain is verified in VerifyConnection, which runs regardless
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("broker sent no certificate")
+			}
+			cert := cs.PeerCertificates[0]
+			if serverName != "" && cert.Subject.CommonName != serverName {
+				if err := cert.VerifyHostname(serverName); err != nil {
+					return fmt.Errorf("broker certificate: %w", err)
+				}
+			}
+			opts := x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(c)
+			}
+			if _, err := cert.Verify(opts); err != nil {
+				return fmt.Errorf("broker certificate not signed by pinned CA: %w", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// setTrace changes metric tracing, submissions are handed to go-trapcheck
+// while tracing as it writes the traces
+func (s *submitter) setTrace(trace string) {
+	s.Lock()
+	s.trace = trace
+	s.Unlock()
+}
+
+// SendMetrics satisfies the trapmetrics.Trap interface
+func (s *submitter) SendMetrics(ctx context.Context, metrics bytes.Buffer) (*trapcheck.TrapResult, error) {
+	if metrics.Len() == 0 {
+		return nil, fmt.Errorf("no metrics to submit")
+	}
+
+	s.Lock()
+	trace, surl, client := s.trace, s.url, s.client
+	s.Unlock()
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	if trace != "" {
+		return s.check.SendMetrics(ctx, metrics)
+	}
+
+	start := time.Now()
+	payload, encoding, err := s.encode(metrics.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	var reqStart time.Time
+	wait := submitRetryWaitMin
+	for attempt := 0; ; attempt++ {
+		reqStart = time.Now()
+		resp, err = s.do(ctx, client, surl, payload, encoding)
+		if !retryable(resp, err) || attempt >= s.maxRetries || ctx.Err() != nil {
+			break
+		}
+		if resp != nil {
+			s.logger.Warnf("submitting to %s: %s, retrying", surl, resp.Status)
+			drain(resp)
+		} else {
+			s.logger.Warnf("submitting to %s: %s, retrying", surl, err)
+		}
+		if s.cfg.OnRetry != nil {
+			s.cfg.OnRetry()
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > submitRetryWaitMax {
+			wait = submitRetryWaitMax
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+	defer drain(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// the check moved or was deleted, go-trapcheck fetches it again and
+		// resubmits; the new submission url is used from then on
+		s.logger.Warnf("%s - %s: refreshing check", resp.Status, surl)
+		result, err := s.check.SendMetrics(ctx, metrics)
+		s.Lock()
+		if rerr := s.refresh(); rerr != nil {
+			s.logger.Warnf("refreshing submitter: %s", rerr)
+		}
+		s.Unlock()
+		return result, err //nolint:wrapcheck
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s - %s", resp.Status, surl)
+	}
+
+	var result trapcheck.TrapResult
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parsing response (%s): %w", string(body), err)
+	}
+
+	if bundle, err := s.check.GetCheckBundle(); err == nil && len(bundle.CheckUUIDs) > 0 {
+		result.CheckUUID = bundle.CheckUUIDs[0]
+	}
+	result.SubmitUUID = "n/a"
+	result.SubmitDuration = time.Since(start)
+	result.LastReqDuration = time.Since(reqStart)
+	result.BytesSent = len(payload)
+	if result.Error == "" {
+		result.Error = "none"
+	}
+
+	return &result, nil
+}
+
+func (s *submitter) do(ctx context.Context, client *http.Client, surl string, payload []byte, encoding string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, surl, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", release.NAME+"/"+release.GetInfo().Version)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Length", strconv.Itoa(len(payload)))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("submit: %w", err)
+	}
+	return resp, nil
+}
+
+// encode compresses the payload with the configured compression and
+// returns the content encoding to send, empty when not compressed
+func (s *submitter) encode(data []byte) ([]byte, string, error) {
+	if len(data) <= compressionThreshold || s.cfg.Compression == "none" {
+		return data, "", nil
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	encoding := "gzip"
+	if s.cfg.Compression == "deflate" {
+		// the deflate content encoding is the zlib format (RFC 9110 8.4.1.2)
+		w, encoding = zlib.NewWriter(&buf), "deflate"
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, "", fmt.Errorf("compressing metrics: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("compressing metrics: %w", err)
+	}
+	return buf.Bytes(), encoding, nil
+}
+
+// retryable reports whether a failed request is worth retrying: connection
+// errors, throttling and server errors are
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+}
@@ -0,0 +1,268 @@
+package circonus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/circonus-labs/go-trapcheck"
+	"github.com/stretchr/testify/require"
+)
+
+func testSubmitter(t *testing.T, url string, cfg SubmitConfig) *submitter {
+	t.Helper()
+	require.NoError(t, cfg.Validate())
+	s := &submitter{
+		check:      &trapcheck.TrapCheck{},
+		cfg:        cfg,
+		maxRetries: defaultSubmitRetries,
+		logger:     testutil.Logger{},
+		url:        url,
+		client:     &http.Client{},
+	}
+	if cfg.MaxRetries != nil {
+		s.maxRetries = *cfg.MaxRetries
+	}
+	return s
+}
+
+func TestSubmitConfigValidate(t *testing.T) {
+	require.NoError(t, (&SubmitConfig{}).Validate())
+	require.NoError(t, (&SubmitConfig{Compression: "deflate"}).Validate())
+	require.Error(t, (&SubmitConfig{Compression: "br"}).Validate())
+	retries := -1
+	require.Error(t, (&SubmitConfig{MaxRetries: &retries}).Validate())
+}
+
+func TestSubmitterEncode(t *testing.T) {
+	small := []byte(`{"a":{"_type":"n","_value":1}}`)
+	large := []byte(strings.Repeat(`{"a":{"_type":"n","_value":1}}`, 100))
+
+	tests := []struct {
+		compression string
+		data        []byte
+		encoding    string
+	}{
+		{compression: "", data: small, encoding: ""},
+		{compression: "", data: large, encoding: "gzip"},
+		{compression: "gzip", data: large, encoding: "gzip"},
+		{compression: "deflate", data: large, encoding: "deflate"},
+		{compression: "none", data: large, encoding: ""},
+	}
+	for _, tt := range tests {
+		s := &submitter{cfg: SubmitConfig{Compression: tt.compression}}
+		payload, encoding, err := s.encode(tt.data)
+		require.NoError(t, err)
+		require.Equal(t, tt.encoding, encoding)
+
+		var r io.Reader = bytes.NewReader(payload)
+		switch encoding {
+		case "gzip":
+			r, err = gzip.NewReader(r)
+			require.NoError(t, err)
+		case "deflate":
+			r, err = zlib.NewReader(r)
+			require.NoError(t, err)
+		}
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, tt.data, data)
+	}
+}
+
+func TestSubmitterRetries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"stats":2,"filtered":0}`))
+	}))
+	defer ts.Close()
+
+	var retries int32
+	s := testSubmitter(t, ts.URL, SubmitConfig{OnRetry: func() { atomic.AddInt32(&retries, 1) }})
+
+	result, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(`{"a":{"_type":"n","_value":1}}`))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), result.Stats)
+	require.Equal(t, "none", result.Error)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	require.Equal(t, int32(2), atomic.LoadInt32(&retries))
+}
+
+func TestSubmitterGivesUp(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	maxRetries := 2
+	s := testSubmitter(t, ts.URL, SubmitConfig{MaxRetries: &maxRetries})
+
+	_, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(`{}`))
+	require.Error(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestSubmitterNotRetried(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	s := testSubmitter(t, ts.URL, SubmitConfig{})
+
+	_, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(`{}`))
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestSubmitterTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	s := testSubmitter(t, ts.URL, SubmitConfig{Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	_, err := s.SendMetrics(context.Background(), *bytes.NewBufferString(`{}`))
+	require.Error(t, err)
+	require.Less(t, int64(time.Since(start)), int64(2*time.Second))
+}
+
+func TestSubmitConfigProxy(t *testing.T) {
+	sc := &SubmitConfig{
+		ProxyURL: "http://proxy.example.com:3128",
+		NoProxy:  []string{"10.0.0.0/8", ".internal"},
+	}
+	require.NoError(t, sc.Validate())
+	proxy := sc.proxy()
+
+	tests := []struct {
+		url   string
+		proxy string
+	}{
+		{url: "https://broker.example.com:43191/module/httptrap/x/y", proxy: "http://proxy.example.com:3128"},
+		{url: "http://broker.example.com/module/httptrap/x/y", proxy: "http://proxy.example.com:3128"},
+		{url: "https://10.1.2.3:43191/module/httptrap/x/y"},
+		{url: "https://broker.dc1.internal:43191/module/httptrap/x/y"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPut, tt.url, nil)
+		require.NoError(t, err)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		if tt.proxy == "" {
+			require.Nil(t, u, tt.url)
+		} else {
+			require.Equal(t, tt.proxy, u.String(), tt.url)
+		}
+	}
+
+	require.Error(t, (&SubmitConfig{ProxyURL: "ftp://proxy.example.com"}).Validate())
+}
+
+func TestPinnedTLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	pinned := x509.NewCertPool()
+	pinned.AddCert(ts.Certificate())
+	broker := &tls.Config{ServerName: "example.com"} //nolint:gosec
+
+	tests := []struct {
+		name  string
+		roots *x509.CertPool
+		ok    bool
+	}{
+		{name: "pinned ca", roots: pinned, ok: true},
+		{name: "other ca", roots: x509.NewCertPool(), ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := pinnedTLSConfig(broker, ts.URL, tt.roots)
+			require.NoError(t, err)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			resp, err := client.Get(ts.URL)
+			if !tt.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestFIPSTLSConfig(t *testing.T) {
+	broker := &tls.Config{ServerName: "example.com"} //nolint:gosec
+	cfg, err := fipsTLSConfig(broker)
+	require.NoError(t, err)
+	require.Same(t, broker, cfg)
+
+	fips.Set(true)
+	defer fips.Set(false)
+
+	// brokers without a tls config, e.g. public ones, get a restricted one
+	cfg, err = fipsTLSConfig(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	require.NotEmpty(t, cfg.CipherSuites)
+
+	_, err = fipsTLSConfig(&tls.Config{MinVersion: tls.VersionTLS10}) //nolint:gosec
+	require.Error(t, err)
+
+	submit := func(ts *httptest.Server) error {
+		pinned := x509.NewCertPool()
+		pinned.AddCert(ts.Certificate())
+		cfg, err := pinnedTLSConfig(broker, ts.URL, pinned)
+		require.NoError(t, err)
+		cfg, err = fipsTLSConfig(cfg)
+		require.NoError(t, err)
+
+		retries := 0
+		s := testSubmitter(t, ts.URL, SubmitConfig{MaxRetries: &retries})
+		s.client = &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+		_, err = s.SendMetrics(context.Background(), *bytes.NewBufferString(`{"a":{"_type":"n","_value":1}}`))
+		return err
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"stats":1,"filtered":0}`))
+	})
+
+	ts := httptest.NewTLSServer(handler)
+	defer ts.Close()
+	require.NoError(t, submit(ts))
+
+	// the submissions to a broker only offering unapproved cipher suites fail
+	unapproved := httptest.NewUnstartedServer(handler)
+	unapproved.TLS = &tls.Config{ //nolint:gosec
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305},
+	}
+	unapproved.StartTLS()
+	defer unapproved.Close()
+	require.Error(t, submit(unapproved))
+}
@@ -0,0 +1,6 @@
+//go:build fips
+// +build fips
+
+package fips
+
+const buildEnabled = true
@@ -0,0 +1,38 @@
+// Package fips restricts the agent to algorithms approved by FIPS 140-2, for
+// deployments required to use them only.  The mode is enabled by the
+// fips_mode agent setting or, for good, by building with the fips tag.
+package fips
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var enabled int32
+
+func init() {
+	if buildEnabled {
+		enabled = 1
+	}
+}
+
+// Enabled reports whether the agent is restricted to approved algorithms
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// Set enables or disables the restriction to approved algorithms, agents
+// built with the fips tag can not disable it.
+func Set(on bool) {
+	if on || buildEnabled {
+		atomic.StoreInt32(&enabled, 1)
+		return
+	}
+	atomic.StoreInt32(&enabled, 0)
+}
+
+// NotApproved returns the error for configuring an algorithm, e.g. the
+// "authentication protocol" MD5, that is not approved.
+func NotApproved(kind, name string) error {
+	return fmt.Errorf("%s %s is not FIPS approved, not allowed in fips mode", kind, name)
+}
@@ -0,0 +1,6 @@
+//go:build lite
+// +build lite
+
+package lite
+
+const buildEnabled = true
@@ -0,0 +1,89 @@
+// Package lite reduces the footprint of the agent for edge gateways and other
+// devices low on memory.  The mode is enabled by the lite_mode agent setting
+// or, for good, by building with the lite tag, which also builds in a reduced
+// set of plugins only.  Independent of the mode, the features running
+// commands and looking up MIBs can be disabled.
+package lite
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Defaults of the agent settings of the same names in lite mode, and the GC
+// percent, trading collection cycles for a smaller heap.
+const (
+	MetricBatchSize   = 250
+	MetricBufferLimit = 2500
+	GCPercent         = 50
+)
+
+var (
+	enabled      int32
+	execDisabled int32
+	mibsDisabled int32
+)
+
+func init() {
+	if buildEnabled {
+		enabled = 1
+	}
+}
+
+func store(v *int32, on bool) {
+	if on {
+		atomic.StoreInt32(v, 1)
+		return
+	}
+	atomic.StoreInt32(v, 0)
+}
+
+// Enabled reports whether the agent runs in lite mode
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// Built reports whether the agent is built with the lite tag
+func Built() bool {
+	return buildEnabled
+}
+
+// Set enables or disables lite mode, agents built with the lite tag can not
+// disable it.
+func Set(on bool) {
+	store(&enabled, on || buildEnabled)
+}
+
+// ExecDisabled reports whether plugins and settings running commands, e.g.
+// the exec input or global_tags_command, are disabled
+func ExecDisabled() bool {
+	return atomic.LoadInt32(&execDisabled) == 1
+}
+
+// SetExecDisabled disables or enables running commands
+func SetExecDisabled(on bool) {
+	store(&execDisabled, on)
+}
+
+// MIBsDisabled reports whether looking up the names of OIDs in MIBs, with
+// snmptranslate, is disabled; numeric OIDs are used as they are
+func MIBsDisabled() bool {
+	return atomic.LoadInt32(&mibsDisabled) == 1
+}
+
+// SetMIBsDisabled disables or enables MIB lookups
+func SetMIBsDisabled(on bool) {
+	store(&mibsDisabled, on)
+}
+
+// ExecNotAllowed returns the error for configuring a plugin or setting, e.g.
+// "input exec", running commands while they are disabled.
+func ExecNotAllowed(what string) error {
+	return fmt.Errorf("%s runs commands, not allowed with disable_exec", what)
+}
+
+// MIBNotAllowed returns the error for an OID, e.g. "IF-MIB::ifTable", that
+// needs a MIB lookup while lookups are disabled.
+func MIBNotAllowed(oid string) error {
+	return fmt.Errorf("OID %s needs a MIB lookup, not allowed with disable_mibs; use the numeric OID", oid)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"sync"
 	"sync/atomic"
@@ -26,12 +27,14 @@ type Process struct {
 	cancel       context.CancelFunc
 	name         string
 	args         []string
+	envs         []string
 	RestartDelay time.Duration
 	pid          int32
 }
 
-// New creates a new process wrapper
-func New(command []string) (*Process, error) {
+// New creates a new process wrapper, envs are "key=value" pairs added to the
+// environment of the process
+func New(command []string, envs []string) (*Process, error) {
 	if len(command) == 0 {
 		return nil, errors.New("no command")
 	}
@@ -40,6 +43,7 @@ func New(command []string) (*Process, error) {
 		RestartDelay: 5 * time.Second,
 		name:         command[0],
 		args:         []string{},
+		envs:         envs,
 	}
 
 	if len(command) > 1 {
@@ -83,6 +87,9 @@ func (p *Process) Stop() {
 
 func (p *Process) cmdStart() error {
 	p.Cmd = exec.Command(p.name, p.args...) //nolint:gosec // G204
+	if len(p.envs) > 0 {
+		p.Cmd.Env = append(os.Environ(), p.envs...)
+	}
 
 	var err error
 	p.Stdin, err = p.Cmd.StdinPipe()
@@ -23,7 +23,7 @@ func TestRestartingRebindsPipes(t *testing.T) {
 	exe, err := os.Executable()
 	require.NoError(t, err)
 
-	p, err := New([]string{exe, "-external"})
+	p, err := New([]string{exe, "-external"}, nil)
 	p.RestartDelay = 100 * time.Nanosecond
 	p.Log = testutil.Logger{}
 	require.NoError(t, err)
@@ -52,15 +52,46 @@ func TestRestartingRebindsPipes(t *testing.T) {
 	p.Stop()
 }
 
+// test that the environment of a process has the configured variables
+func TestEnvironment(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	p, err := New([]string{exe, "-external-env"}, []string{"PROCESS_TEST=42"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+
+	lines := make(chan string, 1)
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}
+
+	require.NoError(t, p.Start())
+	require.Equal(t, "PROCESS_TEST=42", <-lines)
+	p.Stop()
+}
+
 var external = flag.Bool("external", false,
 	"if true, run externalProcess instead of tests")
 
+var externalEnv = flag.Bool("external-env", false,
+	"if true, print the PROCESS_TEST environment variable instead of running tests")
+
 func TestMain(m *testing.M) {
 	flag.Parse()
 	if *external {
 		externalProcess()
 		os.Exit(0)
 	}
+	if *externalEnv {
+		fmt.Fprintf(os.Stdout, "PROCESS_TEST=%s\n", os.Getenv("PROCESS_TEST"))
+		// exit when stopped
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		os.Exit(0)
+	}
 	code := m.Run()
 	os.Exit(code)
 }
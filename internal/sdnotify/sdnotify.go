@@ -0,0 +1,68 @@
+// Package sdnotify implements the service notification protocol of systemd,
+// see sd_notify(3), for services of Type=notify.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// Ready tells the service manager that the service finished starting
+	Ready = "READY=1"
+	// Reloading tells the service manager that the service reloads its
+	// configuration, followed by Ready once done
+	Reloading = "RELOADING=1"
+	// Stopping tells the service manager that the service is shutting down
+	Stopping = "STOPPING=1"
+	// Watchdog keeps the watchdog of the service manager from restarting the
+	// service
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends the state to the service manager.  It answers false without
+// error when the service was not started by a service manager expecting
+// notifications.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// abstract sockets, e.g. @/org/freedesktop/systemd1/notify
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("connecting to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("notifying %s: %w", state, err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval answers the interval the service manager expects Watchdog
+// notifications at, 0 when the watchdog is disabled for the process.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// the watchdog is meant for another process
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
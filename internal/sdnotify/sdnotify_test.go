@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	sent, err := Notify(Ready)
+	require.NoError(t, err)
+	require.False(t, sent)
+
+	socket := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	os.Setenv("NOTIFY_SOCKET", socket)
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	sent, err = Notify(Ready)
+	require.NoError(t, err)
+	require.True(t, sent)
+
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, Ready, string(buf[:n]))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	os.Unsetenv("WATCHDOG_USEC")
+	interval, err := WatchdogInterval()
+	require.NoError(t, err)
+	require.Zero(t, interval)
+
+	os.Setenv("WATCHDOG_USEC", "30000000")
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	interval, err = WatchdogInterval()
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, interval)
+
+	os.Setenv("WATCHDOG_PID", "1")
+	interval, err = WatchdogInterval()
+	require.NoError(t, err)
+	require.Zero(t, interval)
+
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("WATCHDOG_USEC", "soon")
+	_, err = WatchdogInterval()
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
 	"github.com/gosnmp/gosnmp"
 )
 
@@ -120,6 +121,9 @@ func NewWrapper(s ClientConfig) (GosnmpWrapper, error) {
 
 		switch strings.ToLower(s.AuthProtocol) {
 		case "md5":
+			if fips.Enabled() {
+				return GosnmpWrapper{}, fips.NotApproved("authProtocol", s.AuthProtocol)
+			}
 			sp.AuthenticationProtocol = gosnmp.MD5
 		case "sha":
 			sp.AuthenticationProtocol = gosnmp.SHA
@@ -133,6 +137,9 @@ func NewWrapper(s ClientConfig) (GosnmpWrapper, error) {
 
 		switch strings.ToLower(s.PrivProtocol) {
 		case "des":
+			if fips.Enabled() {
+				return GosnmpWrapper{}, fips.NotApproved("privProtocol", s.PrivProtocol)
+			}
 			sp.PrivacyProtocol = gosnmp.DES
 		case "aes":
 			sp.PrivacyProtocol = gosnmp.AES
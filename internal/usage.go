@@ -17,6 +17,10 @@ The commands & flags are:
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
+  --config-poll-interval <dur>   interval to check configs fetched over http(s) for
+                                 changes, e.g. 5m, not checked if zero
+  --config-public-key <file>     ed25519 public key verifying the signatures of
+                                 configs fetched over http(s)
   --plugin-directory             directory containing *.so files, this directory will be
                                  searched recursively. Any Plugin found will be loaded
                                  and namespaced.
@@ -28,6 +32,8 @@ The commands & flags are:
   --pidfile <file>               file to write our pid to
   --pprof-addr <address>         pprof address to listen on, don't activate pprof if empty
   --processor-filter <filter>    filter the processors to enable, separator is :
+  --profile-dir <directory>      directory to dump goroutine stacks and heap profiles
+                                 to on SIGUSR1, defaults to the temp directory
   --quiet                        run in quiet mode
   --section-filter               filter config sections to output, separator is :
                                  Valid values are 'agent', 'global_tags', 'outputs',
@@ -48,9 +54,18 @@ Examples:
   # generate config with only cpu input & circonus output plugins defined
   circonus-unified-agent --input-filter cpu --output-filter circonus config
 
+  # print the sample config of the snmp_trap and mongodb inputs only
+  circonus-unified-agent config --section-filter inputs --input-filter snmp_trap:mongodb
+
   # run a single collection, outputting metrics to stdout
   circonus-unified-agent --config circonus-unified-agent.conf --test
 
+  # run a single collection of the mongodb inputs only, outputting metrics to stdout
+  circonus-unified-agent --config circonus-unified-agent.conf --test --input-filter mongodb
+
+  # run a single collection, writing the metrics to the outputs, e.g. from cron
+  circonus-unified-agent --config circonus-unified-agent.conf --once
+
   # run with all plugins defined in config file
   circonus-unified-agent --config circonus-unified-agent.conf
 
@@ -17,6 +17,10 @@ The commands & flags are:
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
+  --config-poll-interval <dur>   interval to check configs fetched over http(s) for
+                                 changes, e.g. 5m, not checked if zero
+  --config-public-key <file>     ed25519 public key verifying the signatures of
+                                 configs fetched over http(s)
   --debug                        turn on debug logging
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
@@ -41,6 +45,10 @@ The commands & flags are:
   --service <service>            operate on the service (windows only)
   --service-name                 service name (windows only)
   --service-display-name         service display name (windows only)
+  --service-delayed-start        start the installed service delayed after the other
+                                 automatic services (windows only)
+  --service-restart-delay        restart the installed service this long after it failed,
+                                 0 to not restart it, defaults to 1m (windows only)
 
 Examples:
 
@@ -50,8 +58,17 @@ Examples:
   # generate config with only cpu input & circonus output plugins defined
   circonus-unified-agentd.exe --input-filter cpu --output-filter circonus config
 
+  # print the sample config of the snmp_trap and mongodb inputs only
+  circonus-unified-agentd.exe config --section-filter inputs --input-filter snmp_trap:mongodb
+
   # run a single collection, outputting metrics to stdout
-  circonus-unified-agentd.exe --config circonus-unfied-agent.conf --test
+  circonus-unified-agentd.exe --config circonus-unified-agent.conf --test
+
+  # run a single collection of the mongodb inputs only, outputting metrics to stdout
+  circonus-unified-agentd.exe --config circonus-unified-agent.conf --test --input-filter mongodb
+
+  # run a single collection, writing the metrics to the outputs
+  circonus-unified-agentd.exe --config circonus-unified-agent.conf --once
 
   # run with all plugins defined in config file
   circonus-unified-agentd.exe --config circonus-unified-agent.conf
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/influxdata/wlog"
+)
+
+var levelNames = map[wlog.Level]string{
+	wlog.DEBUG: "debug",
+	wlog.INFO:  "info",
+	wlog.WARN:  "warn",
+	wlog.ERROR: "error",
+}
+
+// levelFilter drops the log lines below the level of their source, the
+// plugin or component in brackets after the level prefix, e.g.
+// "D! [inputs.snmp_trap] ...".  Sources without a level of their own log at
+// the global level.
+type levelFilter struct {
+	writer  io.Writer
+	level   wlog.Level
+	sources map[string]wlog.Level
+}
+
+func newLevelFilter(w io.Writer, level wlog.Level, sources map[string]wlog.Level) *levelFilter {
+	return &levelFilter{
+		writer:  w,
+		level:   level,
+		sources: sources,
+	}
+}
+
+func (f *levelFilter) Write(b []byte) (int, error) {
+	level, source, _ := parseLine(b)
+	threshold, ok := f.sources[source]
+	if !ok {
+		// an alias of a plugin, e.g. inputs.snmp_trap::edge
+		if i := strings.Index(source, "::"); i > 0 {
+			threshold, ok = f.sources[source[:i]]
+		}
+	}
+	if !ok {
+		threshold = f.level
+	}
+	if level < threshold {
+		return len(b), nil
+	}
+	return f.writer.Write(b) //nolint:wrapcheck
+}
+
+func (f *levelFilter) Close() error {
+	if closer, ok := f.writer.(io.Closer); ok {
+		return closer.Close() //nolint:wrapcheck
+	}
+	return nil
+}
+
+// parseLevels parses the log levels of sources, e.g. "debug" for
+// "inputs.snmp_trap", answering the lowest level as well
+func parseLevels(levels map[string]string, global wlog.Level) (map[string]wlog.Level, wlog.Level, error) {
+	sources := make(map[string]wlog.Level, len(levels))
+	lowest := global
+	for source, name := range levels {
+		level, ok := wlog.StringToLevel[strings.ToUpper(name)]
+		if !ok {
+			return nil, global, fmt.Errorf("invalid log level %q of %s", name, source)
+		}
+		sources[source] = level
+		if level < lowest {
+			lowest = level
+		}
+	}
+	return sources, lowest, nil
+}
+
+// parseLine splits a log line into its level, source and message.  Lines
+// without a level prefix are at info level.
+func parseLine(b []byte) (level wlog.Level, source string, msg []byte) {
+	level = wlog.INFO
+	msg = bytes.TrimRight(b, "\r\n")
+	if prefixRegex.Match(msg) {
+		level = wlog.Levels[msg[0]]
+		msg = bytes.TrimLeft(msg[2:], " ")
+	}
+	if len(msg) > 0 && msg[0] == '[' {
+		if i := bytes.IndexByte(msg, ']'); i > 0 {
+			source = string(msg[1:i])
+			msg = bytes.TrimLeft(msg[i+1:], " ")
+		}
+	}
+	return level, source, msg
+}
@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
@@ -18,6 +20,9 @@ var prefixRegex = regexp.MustCompile("^[DIWE]!")
 const (
 	LogTargetFile   = "file"
 	LogTargetStderr = "stderr"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
 // LogConfig contains the log configuration settings
@@ -38,6 +43,15 @@ type LogConfig struct {
 	Quiet bool
 	// will set the log level to DEBUG
 	Debug bool
+	// text (the default) or json lines, for stderr and file targets
+	LogFormat string
+	// levels of plugins or components overriding the global level, e.g.
+	// "debug" for "inputs.snmp_trap"
+	LogLevels map[string]string
+	// maximum similar log lines of a plugin each RateLimitInterval, further
+	// ones are summarized; 0 for no limit
+	RateLimit         int
+	RateLimitInterval internal.Duration
 }
 
 type Creator interface {
@@ -56,9 +70,22 @@ func registerLogger(name string, loggerCreator Creator) {
 type cuaLog struct {
 	writer         io.Writer
 	internalWriter io.Writer
+	json           bool
+}
+
+// jsonLine is a log line in json format
+type jsonLine struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"msg"`
 }
 
 func (t *cuaLog) Write(b []byte) (n int, err error) {
+	if t.json {
+		return t.writeJSON(b)
+	}
+
 	var line []byte
 	if !prefixRegex.Match(b) {
 		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
@@ -68,6 +95,26 @@ func (t *cuaLog) Write(b []byte) (n int, err error) {
 	return t.writer.Write(line)
 }
 
+func (t *cuaLog) writeJSON(b []byte) (int, error) {
+	level, source, msg := parseLine(b)
+	if level < wlog.LogLevel() {
+		return len(b), nil
+	}
+	line, err := json.Marshal(jsonLine{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Level:   levelNames[level],
+		Source:  source,
+		Message: string(msg),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("encoding log line: %w", err)
+	}
+	if _, err := t.internalWriter.Write(append(line, '\n')); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	return len(b), nil
+}
+
 func (t *cuaLog) Close() error {
 	stdErrWriter := os.Stderr
 	// avoid closing stderr
@@ -82,10 +129,11 @@ func (t *cuaLog) Close() error {
 }
 
 // newCUAWriter returns a logging-wrapped writer.
-func newCUAWriter(w io.Writer) io.Writer {
+func newCUAWriter(w io.Writer, format string) io.Writer {
 	return &cuaLog{
 		writer:         wlog.NewWriter(w),
 		internalWriter: w,
+		json:           format == LogFormatJSON,
 	}
 }
 
@@ -119,7 +167,7 @@ func (t *cuaLogCreator) CreateLogger(config LogConfig) (io.Writer, error) {
 		writer = defaultWriter
 	}
 
-	return newCUAWriter(writer), nil
+	return newCUAWriter(writer, config.LogFormat), nil
 }
 
 // Keep track what is actually set as a log output, because log package doesn't provide a getter.
@@ -137,6 +185,20 @@ func newLogWriter(config LogConfig) io.Writer {
 	if !config.Debug && !config.Quiet {
 		wlog.SetLevel(wlog.INFO)
 	}
+	// the lowest level of all sources passes the wlog writer, the filter
+	// drops lines below the level of their source
+	global := wlog.LogLevel()
+	var sources map[string]wlog.Level
+	if len(config.LogLevels) > 0 {
+		var lowest wlog.Level
+		var err error
+		sources, lowest, err = parseLevels(config.LogLevels, global)
+		if err != nil {
+			log.Printf("E! %v, ignoring log levels", err)
+		} else {
+			wlog.SetLevel(lowest)
+		}
+	}
 	var logWriter io.Writer
 	if logCreator, ok := loggerRegistry[config.LogTarget]; ok {
 		logWriter, _ = logCreator.CreateLogger(config)
@@ -145,6 +207,17 @@ func newLogWriter(config LogConfig) io.Writer {
 		logWriter, _ = (&cuaLogCreator{}).CreateLogger(config)
 	}
 
+	if len(sources) > 0 {
+		logWriter = newLevelFilter(logWriter, global, sources)
+	}
+	if config.RateLimit > 0 {
+		interval := config.RateLimitInterval.Duration
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		logWriter = newRateLimiter(logWriter, config.RateLimit, interval)
+	}
+
 	if closer, isCloser := actualLogger.(io.Closer); isCloser {
 		closer.Close()
 	}
@@ -2,10 +2,12 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/internal"
@@ -133,10 +135,69 @@ func TestLogTargetSettings(t *testing.T) {
 	assert.Equal(t, logger.internalWriter, os.Stderr)
 }
 
+func TestJSONWriteLogToFile(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.LogFormat = LogFormatJSON
+	SetupLogging(config)
+	log.Printf("W! [inputs.cpu] disk \"sda\" not found")
+	log.Printf("Starting")
+	log.Printf("D! [inputs.cpu] TEST") // <- should be ignored
+
+	f, err := os.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(f), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var line map[string]string
+	require.NoError(t, json.Unmarshal(lines[0], &line))
+	require.NotEmpty(t, line["time"])
+	delete(line, "time")
+	require.Equal(t, map[string]string{"level": "warn", "source": "inputs.cpu", "msg": `disk "sda" not found`}, line)
+
+	line = nil
+	require.NoError(t, json.Unmarshal(lines[1], &line))
+	delete(line, "time")
+	require.Equal(t, map[string]string{"level": "info", "msg": "Starting"}, line)
+}
+
+func TestLogLevels(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.LogLevels = map[string]string{
+		"inputs.snmp_trap": "debug",
+		"outputs.file":     "error",
+	}
+	SetupLogging(config)
+	log.Printf("D! [inputs.snmp_trap] TEST1")
+	log.Printf("D! [inputs.snmp_trap::edge] TEST2")
+	log.Printf("D! [inputs.cpu] TEST")   // <- should be ignored
+	log.Printf("D! TEST")                // <- should be ignored
+	log.Printf("W! [outputs.file] TEST") // <- should be ignored
+	log.Printf("I! [agent] TEST3")
+
+	f, err := os.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(f)), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "Z D! [inputs.snmp_trap] TEST1", lines[0][19:])
+	require.Equal(t, "Z D! [inputs.snmp_trap::edge] TEST2", lines[1][19:])
+	require.Equal(t, "Z I! [agent] TEST3", lines[2][19:])
+
+	// reset the global level raised for the plugin
+	SetupLogging(createBasicLogConfig(tmpfile.Name()))
+}
+
 func BenchmarkCUALogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
-	w := newCUAWriter(&buf)
+	w := newCUAWriter(&buf, LogFormatText)
 	for i := 0; i < b.N; i++ {
 		buf.Reset()
 		_, _ = w.Write(msg)
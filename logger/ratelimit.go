@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/influxdata/wlog"
+)
+
+// numberRegex matches the numbers of a message, e.g. of addresses, counts
+// or durations, which vary between otherwise similar messages
+var numberRegex = regexp.MustCompile(`[0-9]+`)
+
+// rateLimiter passes at most limit similar log lines of a source each
+// interval, lines with the same level, source and message apart from the
+// numbers in it.  The suppressed lines are summarized at the end of the
+// interval.
+type rateLimiter struct {
+	writer   io.Writer
+	limit    int
+	interval time.Duration
+
+	mu       sync.Mutex
+	messages map[string]*similar
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// similar counts the similar lines of an interval
+type similar struct {
+	level      wlog.Level
+	source     string
+	first      string
+	count      int
+	suppressed int
+}
+
+func newRateLimiter(w io.Writer, limit int, interval time.Duration) *rateLimiter {
+	r := &rateLimiter{
+		writer:   w,
+		limit:    limit,
+		interval: interval,
+		messages: make(map[string]*similar),
+		done:     make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.flush()
+			case <-r.done:
+				r.flush()
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func (r *rateLimiter) Write(b []byte) (int, error) {
+	level, source, msg := parseLine(b)
+	key := string(wlog.ReverseLevels[level]) + source + "\x00" + numberRegex.ReplaceAllString(string(msg), "0")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.messages[key]
+	if !ok {
+		m = &similar{level: level, source: source, first: string(msg)}
+		r.messages[key] = m
+	}
+	m.count++
+	if m.count > r.limit {
+		m.suppressed++
+		return len(b), nil
+	}
+	return r.writer.Write(b) //nolint:wrapcheck
+}
+
+// flush writes a summary of the suppressed lines of the interval and starts
+// the next one
+func (r *rateLimiter) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.messages {
+		if m.suppressed == 0 {
+			continue
+		}
+		source := ""
+		if m.source != "" {
+			source = "[" + m.source + "] "
+		}
+		line := fmt.Sprintf("%c! %sSuppressed %d similar messages in the last %s: %s\n",
+			wlog.ReverseLevels[m.level], source, m.suppressed, r.interval, m.first)
+		_, _ = r.writer.Write([]byte(line))
+	}
+	r.messages = make(map[string]*similar)
+}
+
+func (r *rateLimiter) Close() error {
+	close(r.done)
+	r.wg.Wait()
+	if closer, ok := r.writer.(io.Closer); ok {
+		return closer.Close() //nolint:wrapcheck
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var buf bytes.Buffer
+	r := newRateLimiter(&buf, 2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		_, err := r.Write([]byte("E! [inputs.snmp_trap] Reverse lookup of 10.0.0." + string(rune('1'+i)) + " failed\n"))
+		require.NoError(t, err)
+	}
+	_, err := r.Write([]byte("E! [inputs.cpu] Reverse lookup of 10.0.0.1 failed\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("W! [inputs.snmp_trap] Reverse lookup of 10.0.0.1 failed\n"))
+	require.NoError(t, err)
+
+	r.flush()
+	_, err = r.Write([]byte("E! [inputs.snmp_trap] Reverse lookup of 10.0.0.9 failed\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	require.Equal(t, []string{
+		"E! [inputs.snmp_trap] Reverse lookup of 10.0.0.1 failed",
+		"E! [inputs.snmp_trap] Reverse lookup of 10.0.0.2 failed",
+		"E! [inputs.cpu] Reverse lookup of 10.0.0.1 failed",
+		"W! [inputs.snmp_trap] Reverse lookup of 10.0.0.1 failed",
+		"E! [inputs.snmp_trap] Suppressed 3 similar messages in the last 1h0m0s: Reverse lookup of 10.0.0.1 failed",
+		"E! [inputs.snmp_trap] Reverse lookup of 10.0.0.9 failed",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
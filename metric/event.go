@@ -0,0 +1,19 @@
+package metric
+
+import "github.com/circonus-labs/circonus-unified-agent/cua"
+
+// EventName is the name of the "Event" metrics added with AddEvent.
+const EventName = "event"
+
+// EventFields answers the fields of an "Event" metric: the title, the body
+// when not empty, and the name of the severity.
+func EventFields(title, body string, severity cua.Severity) map[string]interface{} {
+	fields := map[string]interface{}{
+		"title":    title,
+		"severity": severity.String(),
+	}
+	if body != "" {
+		fields["body"] = body
+	}
+	return fields
+}
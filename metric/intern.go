@@ -0,0 +1,38 @@
+package metric
+
+import "sync"
+
+// maxInterned bounds the strings of the shared table, so tags of unbounded
+// cardinality, e.g. request ids, do not grow it forever
+const maxInterned = 65536
+
+// tagStrings is the table of the tag keys and values shared by the metrics
+var tagStrings = internTable{strings: make(map[string]string)}
+
+type internTable struct {
+	sync.RWMutex
+	strings map[string]string
+}
+
+// Intern answers the string of the shared table equal to s, so the metrics
+// repeating the same tag keys and values, e.g. of the same series, share
+// them instead of each retaining its own copy until written.  Once the
+// table is full, s is answered as is.
+func Intern(s string) string {
+	tagStrings.RLock()
+	is, ok := tagStrings.strings[s]
+	tagStrings.RUnlock()
+	if ok {
+		return is
+	}
+
+	tagStrings.Lock()
+	defer tagStrings.Unlock()
+	if is, ok := tagStrings.strings[s]; ok {
+		return is
+	}
+	if len(tagStrings.strings) < maxInterned {
+		tagStrings.strings[s] = s
+	}
+	return s
+}
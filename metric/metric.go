@@ -14,6 +14,7 @@ type metric struct {
 	name           string
 	originInstance string
 	origin         string
+	pipeline       string
 	fields         []*cua.Field
 	tags           []*cua.Tag
 	tp             cua.ValueType
@@ -46,7 +47,7 @@ func New(
 		m.tags = make([]*cua.Tag, 0, len(tags))
 		for k, v := range tags {
 			m.tags = append(m.tags,
-				&cua.Tag{Key: k, Value: v})
+				&cua.Tag{Key: Intern(k), Value: Intern(v)})
 		}
 		sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
 	}
@@ -77,6 +78,7 @@ func FromMetric(other cua.Metric) cua.Metric {
 		aggregate:      other.IsAggregate(),
 		origin:         other.Origin(),
 		originInstance: other.OriginInstance(),
+		pipeline:       other.Pipeline(),
 	}
 
 	for i, tag := range other.TagList() {
@@ -89,6 +91,26 @@ func FromMetric(other cua.Metric) cua.Metric {
 	return m
 }
 
+// FromLists returns a metric of the tags and fields, without copying them,
+// so parsers building the lists do not allocate them twice.  The tags must
+// be sorted by key and their keys unique, as well as the keys of the fields;
+// the values of the fields must be of the types the metrics hold.
+func FromLists(
+	name string,
+	tags []*cua.Tag,
+	fields []*cua.Field,
+	tm time.Time,
+	tp cua.ValueType,
+) cua.Metric {
+	return &metric{
+		name:   name,
+		tags:   tags,
+		fields: fields,
+		tm:     tm,
+		tp:     tp,
+	}
+}
+
 func (m *metric) String() string {
 	return fmt.Sprintf("%s %v %v %d", m.name, m.Tags(), m.Fields(), m.tm.UnixNano())
 }
@@ -235,23 +257,23 @@ func (m *metric) SetTime(t time.Time) {
 }
 
 func (m *metric) Copy() cua.Metric {
-	m2 := &metric{
-		name:           m.name,
-		tags:           make([]*cua.Tag, len(m.tags)),
-		fields:         make([]*cua.Field, len(m.fields)),
-		tm:             m.tm,
-		tp:             m.tp,
-		aggregate:      m.aggregate,
-		origin:         m.origin,
-		originInstance: m.originInstance,
-	}
+	// copies are recycled as well, e.g. the copies of the metrics written
+	// to several outputs
+	m2 := shared.get()
+	m2.name = m.name
+	m2.tm = m.tm
+	m2.tp = m.tp
+	m2.aggregate = m.aggregate
+	m2.origin = m.origin
+	m2.originInstance = m.originInstance
+	m2.pipeline = m.pipeline
 
-	for i, tag := range m.tags {
-		m2.tags[i] = &cua.Tag{Key: tag.Key, Value: tag.Value}
+	for _, tag := range m.tags {
+		m2.tags = append(m2.tags, &cua.Tag{Key: tag.Key, Value: tag.Value})
 	}
 
-	for i, field := range m.fields {
-		m2.fields[i] = &cua.Field{Key: field.Key, Value: field.Value}
+	for _, field := range m.fields {
+		m2.fields = append(m2.fields, &cua.Field{Key: field.Key, Value: field.Value})
 	}
 	return m2
 }
@@ -398,3 +420,10 @@ func (m *metric) OriginInstance() string {
 func (m *metric) SetOriginInstance(instanceID string) {
 	m.originInstance = instanceID
 }
+
+func (m *metric) Pipeline() string {
+	return m.pipeline
+}
+func (m *metric) SetPipeline(name string) {
+	m.pipeline = name
+}
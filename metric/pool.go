@@ -0,0 +1,103 @@
+package metric
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// Pool recycles metrics, so producers of many short lived metrics, e.g.
+// metrics most of which are dropped by filters, do not allocate a metric
+// for each of them.
+type Pool struct {
+	pool sync.Pool
+}
+
+// Get answers a metric like New, reusing a metric put back into the pool
+// when there is one.
+func (p *Pool) Get(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...cua.ValueType,
+) cua.Metric {
+	m := p.get()
+	m.name = name
+	m.tm = tm
+	m.tp = cua.Untyped
+	if len(tp) > 0 {
+		m.tp = tp[0]
+	}
+
+	for k, v := range tags {
+		m.tags = append(m.tags, &cua.Tag{Key: Intern(k), Value: Intern(v)})
+	}
+	sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
+
+	for k, v := range fields {
+		v := convertField(v)
+		if v == nil {
+			continue
+		}
+		m.fields = append(m.fields, &cua.Field{Key: k, Value: v})
+	}
+
+	return m
+}
+
+// get answers a metric of the pool, a new one when there is none
+func (p *Pool) get() *metric {
+	m, ok := p.pool.Get().(*metric)
+	if !ok {
+		m = &metric{}
+	}
+	return m
+}
+
+// Put returns a metric to the pool. The metric must not be used anymore,
+// it is handed out again by Get. Metrics not created by this package, e.g.
+// tracking metrics, are ignored.
+func (p *Pool) Put(m cua.Metric) {
+	pm, ok := m.(*metric)
+	if !ok {
+		return
+	}
+
+	// keep the slices but none of the tags and fields
+	tags := pm.tags[:cap(pm.tags)]
+	for i := range tags {
+		tags[i] = nil
+	}
+	fields := pm.fields[:cap(pm.fields)]
+	for i := range fields {
+		fields[i] = nil
+	}
+	*pm = metric{
+		tags:   tags[:0],
+		fields: fields[:0],
+	}
+	p.pool.Put(pm)
+}
+
+// shared recycles the metrics between the accumulators creating them and
+// the outputs done with them once written
+var shared Pool
+
+// Get answers a metric like New, from the pool shared by the agent.
+func Get(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...cua.ValueType,
+) cua.Metric {
+	return shared.Get(name, tags, fields, tm, tp...)
+}
+
+// Recycle returns a metric to the pool shared by the agent, like Pool.Put.
+func Recycle(m cua.Metric) {
+	shared.Put(m)
+}
@@ -0,0 +1,71 @@
+package metric
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	var pool Pool
+	now := time.Now()
+
+	m := pool.Get("cpu",
+		map[string]string{"host": "localhost", "cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(99), "count": 1},
+		now, cua.Gauge)
+	require.Equal(t, "cpu", m.Name())
+	require.Equal(t, map[string]string{"host": "localhost", "cpu": "cpu0"}, m.Tags())
+	require.Equal(t, "cpu", m.TagList()[0].Key)
+	require.Equal(t, map[string]interface{}{"usage_idle": float64(99), "count": int64(1)}, m.Fields())
+	require.Equal(t, now, m.Time())
+	require.Equal(t, cua.Gauge, m.Type())
+
+	tag := m.TagList()[0]
+	pool.Put(m)
+
+	m = pool.Get("mem", nil, map[string]interface{}{"free": int64(42)}, now)
+	require.Equal(t, "mem", m.Name())
+	require.Empty(t, m.TagList())
+	require.Equal(t, map[string]interface{}{"free": int64(42)}, m.Fields())
+	require.Equal(t, cua.Untyped, m.Type())
+	require.False(t, m.IsAggregate())
+	// tags of a recycled metric are never shared
+	require.Equal(t, "cpu", tag.Key)
+}
+
+func TestPoolIgnoresTrackingMetrics(t *testing.T) {
+	var pool Pool
+	m := pool.Get("cpu", nil, map[string]interface{}{"value": 42.0}, time.Now())
+	tm, _ := WithTracking(m, func(cua.DeliveryInfo) {})
+	pool.Put(tm)
+	require.Equal(t, "cpu", m.Name())
+}
+
+func TestIntern(t *testing.T) {
+	key := Intern(string([]byte("intern_test")))
+	require.Equal(t, "intern_test", key)
+
+	m := Get("cpu", map[string]string{string([]byte("intern_test")): "a"}, nil, time.Now())
+	// the strings of the first metric are shared by the ones created later
+	require.Equal(t, stringData(key), stringData(m.TagList()[0].Key))
+}
+
+func TestCopyRecycled(t *testing.T) {
+	m := Get("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.0}, time.Now())
+	c := m.Copy()
+	Recycle(m)
+	require.Equal(t, "cpu", c.Name())
+	require.Equal(t, map[string]string{"host": "a"}, c.Tags())
+	require.Equal(t, map[string]interface{}{"value": 42.0}, c.Fields())
+	Recycle(c)
+}
+
+// stringData answers the address of the bytes of a string
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
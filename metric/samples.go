@@ -0,0 +1,23 @@
+package metric
+
+import (
+	"math"
+	"strconv"
+)
+
+// SampleFields answers the fields of a "Histogram" metric holding the
+// samples, a field per distinct value, named after the value, with the
+// number of samples of the value.  Samples which are not a number or
+// infinite are dropped.
+func SampleFields(samples []float64) map[string]interface{} {
+	fields := make(map[string]interface{}, len(samples))
+	for _, v := range samples {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		key := strconv.FormatFloat(v, 'g', -1, 64)
+		n, _ := fields[key].(int64)
+		fields[key] = n + 1
+	}
+	return fields
+}
@@ -0,0 +1,18 @@
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleFields(t *testing.T) {
+	fields := SampleFields([]float64{0.25, 10, 0.25, math.NaN(), math.Inf(1), 1e-7})
+	require.Equal(t, map[string]interface{}{
+		"0.25":  int64(2),
+		"10":    int64(1),
+		"1e-07": int64(1),
+	}, fields)
+	require.Empty(t, SampleFields(nil))
+}
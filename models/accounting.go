@@ -0,0 +1,95 @@
+package models
+
+import (
+	"runtime/metrics"
+	"sync"
+)
+
+// heapAllocs is the runtime metric of the bytes allocated on the heap by the
+// process since it started
+const heapAllocs = "/gc/heap/allocs:bytes"
+
+// gatherAccounting apportions the resources used by the process to the
+// collections of the inputs
+var gatherAccounting = newAccountant(readUsage)
+
+// usage is the CPU time, in nanoseconds, and the bytes allocated on the heap
+// by the process, or charged to a collection
+type usage struct {
+	cpu   int64
+	alloc int64
+}
+
+// accountant estimates the resources used by each collection.  Go does not
+// account for the resources of goroutines, so the resources used by the
+// process between two starts or ends of collections are shared evenly by
+// the collections running meanwhile, including the ones used by goroutines
+// the inputs start.
+type accountant struct {
+	mu      sync.Mutex
+	read    func() usage
+	last    usage
+	running map[*usage]struct{}
+}
+
+func newAccountant(read func() usage) *accountant {
+	return &accountant{
+		read:    read,
+		running: make(map[*usage]struct{}),
+	}
+}
+
+// start records the start of a collection, answering its usage to stop
+func (a *accountant) start() *usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.charge()
+	u := &usage{}
+	a.running[u] = struct{}{}
+	return u
+}
+
+// stop records the end of a collection, answering the resources it used
+func (a *accountant) stop(u *usage) usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.charge()
+	delete(a.running, u)
+	return *u
+}
+
+// measure runs a collection, answering the resources it used; a collection
+// panicking is not charged anymore
+func (a *accountant) measure(collect func() error) (used usage, err error) {
+	u := a.start()
+	defer func() {
+		used = a.stop(u)
+	}()
+	return usage{}, collect()
+}
+
+// charge shares the resources used since the last start or end of a
+// collection by the collections running
+func (a *accountant) charge() {
+	now := a.read()
+	if n := int64(len(a.running)); n > 0 {
+		cpu := (now.cpu - a.last.cpu) / n
+		alloc := (now.alloc - a.last.alloc) / n
+		for u := range a.running {
+			u.cpu += cpu
+			u.alloc += alloc
+		}
+	}
+	a.last = now
+}
+
+// readUsage answers the resources used by the process so far
+func readUsage() usage {
+	sample := []metrics.Sample{{Name: heapAllocs}}
+	metrics.Read(sample)
+	u := usage{cpu: processCPUTime()}
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		u.alloc = int64(sample[0].Value.Uint64())
+	}
+	return u
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	defaultBreakerProbeInterval    = time.Minute
+	defaultBreakerMaxProbeInterval = 10 * time.Minute
+)
+
+// breaker skips the collections of an input after consecutive failed ones.
+// Once open, a single collection probes the input after the probe interval,
+// doubling up to the maximum probe interval while the probes keep failing.
+type breaker struct {
+	threshold int
+	probe     time.Duration
+	maxProbe  time.Duration
+
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+}
+
+func newBreaker(threshold int, probe, maxProbe time.Duration) *breaker {
+	if probe <= 0 {
+		probe = defaultBreakerProbeInterval
+	}
+	if maxProbe <= 0 {
+		maxProbe = defaultBreakerMaxProbeInterval
+	}
+	if maxProbe < probe {
+		maxProbe = probe
+	}
+	return &breaker{
+		threshold: threshold,
+		probe:     probe,
+		maxProbe:  maxProbe,
+	}
+}
+
+// open reports whether the breaker is open
+func (b *breaker) open() bool {
+	return b.failures >= b.threshold
+}
+
+// allow reports whether a collection is due at now
+func (b *breaker) allow(now time.Time) bool {
+	return !b.open() || !now.Before(b.openUntil)
+}
+
+// record records the outcome of a collection, answering the time until the
+// next probe when the breaker opens or a probe failed, and 0 otherwise
+func (b *breaker) record(now time.Time, failed bool) time.Duration {
+	if !failed {
+		b.failures = 0
+		b.backoff = 0
+		return 0
+	}
+
+	b.failures++
+	if !b.open() {
+		return 0
+	}
+	switch {
+	case b.backoff == 0:
+		b.backoff = b.probe
+	case b.backoff < b.maxProbe:
+		b.backoff *= 2
+		if b.backoff > b.maxProbe {
+			b.backoff = b.maxProbe
+		}
+	}
+	b.openUntil = now.Add(b.backoff)
+	return b.backoff
+}
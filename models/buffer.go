@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -12,6 +13,13 @@ var (
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
 )
 
+const (
+	// DropOldest makes room in a full buffer by dropping the oldest metrics
+	DropOldest = "drop_oldest"
+	// DropNewest keeps the metrics of a full buffer, dropping new metrics
+	DropNewest = "drop_newest"
+)
+
 // Buffer stores metrics in a circular buffer.
 type Buffer struct {
 	sync.Mutex
@@ -27,6 +35,8 @@ type Buffer struct {
 	last           int // one after the index of the last/newest metric
 	first          int // index of the first/oldest metric
 	batchFirst     int // index of the first metric in the batch
+
+	overflow string // DropOldest or DropNewest
 }
 
 // NewBuffer returns a new empty Buffer with the given capacity.
@@ -35,7 +45,11 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 	if alias != "" {
 		tags["alias"] = alias
 	}
+	return newBuffer(tags, capacity)
+}
 
+// newBuffer returns a new empty Buffer reporting its stats with tags
+func newBuffer(tags map[string]string, capacity int) *Buffer {
 	b := &Buffer{
 		buf:   make([]cua.Metric, capacity),
 		first: 0,
@@ -43,6 +57,8 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 		size:  0,
 		cap:   capacity,
 
+		overflow: DropOldest,
+
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
@@ -74,6 +90,30 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 	return b
 }
 
+// SetOverflow sets the policy for a full buffer, DropOldest (the default)
+// or DropNewest.
+func (b *Buffer) SetOverflow(policy string) error {
+	switch policy {
+	case "":
+		policy = DropOldest
+	case DropOldest, DropNewest:
+	default:
+		return fmt.Errorf("invalid buffer overflow policy %q, must be %s or %s", policy, DropOldest, DropNewest)
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.overflow = policy
+	return nil
+}
+
+// Overflow returns the policy for a full buffer.
+func (b *Buffer) Overflow() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.overflow
+}
+
 // Len returns the number of metrics currently in the buffer.
 func (b *Buffer) Len() int {
 	b.Lock()
@@ -104,6 +144,14 @@ func (b *Buffer) metricDropped(metric cua.Metric) {
 
 func (b *Buffer) add(m cua.Metric) int {
 	dropped := 0
+	// Keep the buffered metrics, including the batch being written, which
+	// is returned to the buffer when the write fails
+	if b.overflow == DropNewest && b.size+b.batchSize >= b.cap {
+		b.metricAdded()
+		b.metricDropped(m)
+		return 1
+	}
+
 	// Check if Buffer is full
 	if b.size == b.cap {
 		b.metricDropped(b.buf[b.last])
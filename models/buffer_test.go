@@ -726,3 +726,39 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_DropNewestKeepsOldest(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	require.NoError(t, b.SetOverflow(DropNewest))
+	dropped := b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4), MetricTime(5))
+	require.Equal(t, 2, dropped)
+	require.Equal(t, int64(5), b.MetricsAdded.Get())
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+
+	batch := b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{MetricTime(1), MetricTime(2), MetricTime(3)}, batch)
+}
+
+func TestBuffer_DropNewestKeepsBatch(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	require.NoError(t, b.SetOverflow(DropNewest))
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+	dropped := b.Add(MetricTime(3), MetricTime(4))
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 3, b.Len())
+
+	b.Reject(batch)
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{MetricTime(1), MetricTime(2), MetricTime(3)}, batch)
+}
+
+func TestBuffer_SetOverflowInvalid(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	require.Error(t, b.SetOverflow("drop_all"))
+	require.NoError(t, b.SetOverflow(""))
+	require.Equal(t, DropOldest, b.Overflow())
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package models
+
+import "syscall"
+
+// processCPUTime answers the user and system CPU time of the process in
+// nanoseconds, 0 when unknown
+func processCPUTime() int64 {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return ru.Utime.Nano() + ru.Stime.Nano()
+}
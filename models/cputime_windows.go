@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package models
+
+import "golang.org/x/sys/windows"
+
+// processCPUTime answers the user and kernel CPU time of the process in
+// nanoseconds, 0 when unknown
+func processCPUTime() int64 {
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(windows.CurrentProcess(), &creation, &exit, &kernel, &user); err != nil {
+		return 0
+	}
+	// durations in 100ns units
+	ticks := func(ft windows.Filetime) int64 {
+		return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime)
+	}
+	return (ticks(kernel) + ticks(user)) * 100
+}
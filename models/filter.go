@@ -137,11 +137,11 @@ func (f *Filter) shouldNamePass(key string) bool {
 
 	switch {
 	case f.namePass != nil && f.nameDrop != nil:
-		return pass(f) && drop(f)
+		return pass(f) && !drop(f)
 	case f.namePass != nil:
 		return pass(f)
 	case f.nameDrop != nil:
-		return drop(f)
+		return !drop(f)
 	}
 
 	return true
@@ -3,14 +3,15 @@ package models
 import "github.com/circonus-labs/circonus-unified-agent/cua"
 
 // Makemetric applies new metric plugin and agent measurement and tag
-// settings.
+// settings.  Of the global tags, e.g. the ones of the pipeline and the
+// agent, the first ones set take precedence.
 func makemetric(
 	metric cua.Metric,
 	nameOverride string,
 	namePrefix string,
 	nameSuffix string,
 	tags map[string]string,
-	globalTags map[string]string,
+	globalTags ...map[string]string,
 ) cua.Metric {
 	if len(nameOverride) != 0 {
 		metric.SetName(nameOverride)
@@ -30,9 +31,11 @@ func makemetric(
 		}
 	}
 	// Apply global tags
-	for k, v := range globalTags {
-		if _, ok := metric.GetTag(k); !ok {
-			metric.AddTag(k, v)
+	for _, gt := range globalTags {
+		for k, v := range gt {
+			if _, ok := metric.GetTag(k); !ok {
+				metric.AddTag(k, v)
+			}
 		}
 	}
 
@@ -0,0 +1,15 @@
+package models
+
+// DefaultPipeline is the pipeline of the plugins not configured for one.
+// Metrics are routed within their pipeline only: the processors, aggregators
+// and outputs of a pipeline handle the metrics of its inputs and aggregators.
+const DefaultPipeline = "default"
+
+// PipelineName answers the name of the pipeline, the default pipeline for an
+// empty name
+func PipelineName(name string) string {
+	if name == "" {
+		return DefaultPipeline
+	}
+	return name
+}
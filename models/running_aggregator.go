@@ -78,6 +78,10 @@ type AggregatorConfig struct {
 	Period            time.Duration
 	Delay             time.Duration
 	DropOriginal      bool
+
+	// Pipeline is the name of the pipeline of the aggregator, it aggregates
+	// the metrics of the pipeline only
+	Pipeline string
 }
 
 func (r *RunningAggregator) LogName() string {
@@ -119,6 +123,8 @@ func (r *RunningAggregator) MakeMetric(metric cua.Metric) cua.Metric {
 
 	if m != nil {
 		m.SetAggregate(true)
+		m.SetPipeline(PipelineName(r.Config.Pipeline))
+		trace(m, r.LogName(), "pushed")
 	}
 
 	r.MetricsPushed.Incr(1)
@@ -129,6 +135,9 @@ func (r *RunningAggregator) MakeMetric(metric cua.Metric) cua.Metric {
 // Add a metric to the aggregator and return true if the original metric
 // should be dropped.
 func (r *RunningAggregator) Add(m cua.Metric) bool {
+	if PipelineName(m.Pipeline()) != PipelineName(r.Config.Pipeline) {
+		return false
+	}
 	if ok := r.Config.Filter.Select(m); !ok {
 		return false
 	}
@@ -141,6 +150,7 @@ func (r *RunningAggregator) Add(m cua.Metric) bool {
 
 	r.Config.Filter.Modify(m)
 	if len(m.FieldList()) == 0 {
+		trace(m, r.LogName(), "not aggregated, no fields left after the filters")
 		r.MetricsFiltered.Incr(1)
 		return r.Config.DropOriginal
 	}
@@ -151,10 +161,16 @@ func (r *RunningAggregator) Add(m cua.Metric) bool {
 	if m.Time().Before(r.periodStart.Add(-r.Config.Grace)) || m.Time().After(r.periodEnd.Add(r.Config.Delay)) {
 		r.log.Debugf("Metric is outside aggregation window; discarding. %s: m: %s e: %s g: %s",
 			m.Time(), r.periodStart, r.periodEnd, r.Config.Grace)
+		trace(m, r.LogName(), "not aggregated, outside the aggregation window")
 		r.MetricsDropped.Incr(1)
 		return r.Config.DropOriginal
 	}
 
+	if r.Config.DropOriginal {
+		trace(m, r.LogName(), "aggregated, original dropped")
+	} else {
+		trace(m, r.LogName(), "aggregated")
+	}
 	r.Aggregator.Add(m)
 	return r.Config.DropOriginal
 }
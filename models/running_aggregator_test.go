@@ -38,6 +38,49 @@ func TestAdd(t *testing.T) {
 	require.Equal(t, int64(101), acc.Metrics[0].Fields["sum"])
 }
 
+func TestAddOtherPipeline(t *testing.T) {
+	a := &TestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name: "TestRunningAggregator",
+		Filter: Filter{
+			NamePass: []string{"*"},
+		},
+		Period:   time.Millisecond * 500,
+		Pipeline: "team_a",
+	})
+	require.NoError(t, ra.Config.Filter.Compile())
+	acc := testutil.Accumulator{}
+
+	now := time.Now()
+	ra.UpdateWindow(now, now.Add(ra.Config.Period))
+
+	other := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(1),
+		},
+		time.Now().Add(time.Millisecond*150),
+		cua.Untyped)
+	require.False(t, ra.Add(other))
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Now().Add(time.Millisecond*150),
+		cua.Untyped)
+	m.SetPipeline("team_a")
+	require.False(t, ra.Add(m))
+	ra.Push(&acc)
+
+	require.Equal(t, 1, len(acc.Metrics))
+	require.Equal(t, int64(101), acc.Metrics[0].Fields["sum"])
+
+	pushed := ra.MakeMetric(testutil.TestMetric(1))
+	require.Equal(t, "team_a", pushed.Pipeline())
+}
+
 func TestAddMetricsOutsideCurrentPeriod(t *testing.T) {
 	a := &TestAggregator{}
 	ra := NewRunningAggregator(a, &AggregatorConfig{
@@ -2,13 +2,20 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 )
 
+// budgetCollections is the number of collections in a row exceeding the
+// budgets an input with DisableOverBudget is disabled after
+const budgetCollections = 3
+
 var (
 	GlobalMetricsGathered = selfstat.Register("agent", "metrics_gathered", map[string]string{})
 	GlobalGatherErrors    = selfstat.Register("agent", "gather_errors", map[string]string{})
@@ -23,6 +30,44 @@ type RunningInput struct {
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+
+	// series of the current interval, when limited by MaxSeries
+	seriesMu      sync.Mutex
+	series        map[uint64]struct{}
+	seriesFull    bool
+	SeriesDropped selfstat.Stat
+
+	GatherTimeouts selfstat.Stat
+	Restarts       selfstat.Stat
+
+	// resources used by the collections, and the collections exceeding the
+	// budgets, in a row
+	GatherCPU         selfstat.Stat
+	GatherAlloc       selfstat.Stat
+	GathersOverBudget selfstat.Stat
+	Disabled          selfstat.Stat
+	overBudget        int
+	disabled          bool
+
+	// breaker of failing collections
+	breaker        *breaker
+	BreakerOpen    selfstat.Stat
+	GathersSkipped selfstat.Stat
+	now            func() time.Time
+
+	// leader of a cluster scope input
+	leader Leader
+
+	// last successful full collection of an incremental input, zero when
+	// the next collection must be full
+	lastFullGather     time.Time
+	GathersIncremental selfstat.Stat
+}
+
+// Leader answers whether the agent was elected to run the inputs with
+// ClusterScope
+type Leader interface {
+	Leader() bool
 }
 
 func NewRunningInput(input cua.Input, config *InputConfig) *RunningInput {
@@ -41,14 +86,10 @@ func NewRunningInput(input cua.Input, config *InputConfig) *RunningInput {
 
 	inputErrorsRegister := selfstat.Register("gather", "errors", tags)
 	logger := NewLogger("inputs", config.Name, alias)
-	logger.OnErr(func() {
-		inputErrorsRegister.Incr(1)
-		GlobalGatherErrors.Incr(1)
-	})
 	SetLoggerOnPlugin(input, logger)
 	// add for high performance (hp) plugins SetInstanceIDOnPlugin(input,config.InstanceID)
 
-	return &RunningInput{
+	r := &RunningInput{
 		Input:  input,
 		Config: config,
 		MetricsGathered: selfstat.Register(
@@ -61,8 +102,68 @@ func NewRunningInput(input cua.Input, config *InputConfig) *RunningInput {
 			"gather_time_ns",
 			tags,
 		),
-		log: logger,
+		SeriesDropped: selfstat.Register(
+			"gather",
+			"series_dropped",
+			tags,
+		),
+		GatherTimeouts: selfstat.Register(
+			"gather",
+			"gather_timeouts",
+			tags,
+		),
+		Restarts: selfstat.Register(
+			"gather",
+			"restarts",
+			tags,
+		),
+		GatherCPU: selfstat.RegisterTiming(
+			"gather",
+			"gather_cpu_ns",
+			tags,
+		),
+		GatherAlloc: selfstat.RegisterTiming(
+			"gather",
+			"gather_alloc_bytes",
+			tags,
+		),
+		GathersOverBudget: selfstat.Register(
+			"gather",
+			"gathers_over_budget",
+			tags,
+		),
+		Disabled: selfstat.Register(
+			"gather",
+			"disabled",
+			tags,
+		),
+		BreakerOpen: selfstat.Register(
+			"gather",
+			"breaker_open",
+			tags,
+		),
+		GathersSkipped: selfstat.Register(
+			"gather",
+			"gathers_skipped",
+			tags,
+		),
+		GathersIncremental: selfstat.Register(
+			"gather",
+			"gathers_incremental",
+			tags,
+		),
+		series: make(map[uint64]struct{}),
+		log:    logger,
+		now:    time.Now,
+	}
+	if config.BreakerThreshold > 0 {
+		r.breaker = newBreaker(config.BreakerThreshold, config.BreakerProbeInterval, config.BreakerMaxProbeInterval)
 	}
+	logger.OnErr(func() {
+		inputErrorsRegister.Incr(1)
+		GlobalGatherErrors.Incr(1)
+	})
+	return r
 }
 
 // InputConfig is the common config for all inputs.
@@ -78,6 +179,44 @@ type InputConfig struct {
 	Precision         time.Duration
 	Interval          time.Duration
 	CollectionJitter  time.Duration
+
+	// MaxSeries is the number of unique series per interval, metrics of
+	// further series are dropped; 0 for no limit
+	MaxSeries int
+
+	// GatherTimeout is the deadline of each Gather; 0 for the agent default
+	GatherTimeout time.Duration
+
+	// BreakerThreshold is the number of consecutive failed collections
+	// after which collections are skipped, probing the input with a backoff
+	// from BreakerProbeInterval up to BreakerMaxProbeInterval; 0 disables
+	// the breaker
+	BreakerThreshold        int
+	BreakerProbeInterval    time.Duration
+	BreakerMaxProbeInterval time.Duration
+
+	// CPUBudget and AllocBudget are the CPU time and heap bytes allocated
+	// each collection may use, compared to the usage apportioned to the
+	// collection; 0 for no budget.  Collections exceeding them are reported,
+	// with DisableOverBudget the input is disabled once it exceeds them in
+	// budgetCollections collections in a row.
+	CPUBudget         time.Duration
+	AllocBudget       int64
+	DisableOverBudget bool
+
+	// FullGatherInterval is the interval of the full collections of an
+	// IncrementalInput, collecting only the changes in between; 0 for full
+	// collections only
+	FullGatherInterval time.Duration
+
+	// Pipeline is the name of the pipeline of the input, PipelineTags its
+	// global tags, taking precedence over the ones of the agent
+	Pipeline     string
+	PipelineTags map[string]string
+
+	// ClusterScope inputs are only collected by the agent elected leader
+	// of the agents sharing the election lock
+	ClusterScope bool
 }
 
 func (r *RunningInput) metricFiltered(metric cua.Metric) {
@@ -88,6 +227,15 @@ func (r *RunningInput) LogName() string {
 	return logName("inputs", r.Config.Name, r.Config.Alias)
 }
 
+// StateID answers the ID of the state of the input in the statefile, the
+// LogName with the instance_id when the alias does not carry it
+func (r *RunningInput) StateID() string {
+	if r.Config.InstanceID == "" || r.Config.InstanceID == r.Config.Alias {
+		return r.LogName()
+	}
+	return r.LogName() + "#" + r.Config.InstanceID
+}
+
 func (r *RunningInput) Init() error {
 	if p, ok := r.Input.(cua.Initializer); ok {
 		err := p.Init()
@@ -100,6 +248,7 @@ func (r *RunningInput) Init() error {
 
 func (r *RunningInput) MakeMetric(metric cua.Metric) cua.Metric {
 	if ok := r.Config.Filter.Select(metric); !ok {
+		trace(metric, r.LogName(), "dropped by the filters")
 		r.metricFiltered(metric)
 		return nil
 	}
@@ -110,37 +259,201 @@ func (r *RunningInput) MakeMetric(metric cua.Metric) cua.Metric {
 		r.Config.MeasurementPrefix,
 		r.Config.MeasurementSuffix,
 		r.Config.Tags,
+		r.Config.PipelineTags,
 		r.defaultTags)
 
 	m.SetOrigin(r.Config.Name)
 	m.SetOriginInstance(r.Config.InstanceID)
+	m.SetPipeline(PipelineName(r.Config.Pipeline))
 
 	r.Config.Filter.Modify(metric)
 	if len(metric.FieldList()) == 0 {
+		trace(m, r.LogName(), "dropped, no fields left after the filters")
 		r.metricFiltered(metric)
 		return nil
 	}
 
+	if r.Config.MaxSeries > 0 && !r.addSeries(m) {
+		trace(m, r.LogName(), "dropped by max_series")
+		r.SeriesDropped.Incr(1)
+		m.Drop()
+		return nil
+	}
+
+	trace(m, r.LogName(), "gathered")
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
+// addSeries records the series of a metric, answering false when it is a
+// new series beyond the limit of the interval
+func (r *RunningInput) addSeries(m cua.Metric) bool {
+	id := m.HashID()
+
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+	if _, ok := r.series[id]; ok {
+		return true
+	}
+	if len(r.series) >= r.Config.MaxSeries {
+		if !r.seriesFull {
+			r.seriesFull = true
+			r.log.Warnf("Limit of %d series reached, dropping metrics of new series", r.Config.MaxSeries)
+		}
+		return false
+	}
+	r.series[id] = struct{}{}
+	return true
+}
+
 func (r *RunningInput) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if r.Config.MaxSeries > 0 {
+		r.seriesMu.Lock()
+		r.series = make(map[uint64]struct{}, len(r.series))
+		r.seriesFull = false
+		r.seriesMu.Unlock()
+	}
+
+	if r.disabled {
+		return nil
+	}
+	if r.leader != nil && !r.leader.Leader() {
+		return nil
+	}
+	if r.breaker != nil && !r.breaker.allow(r.now()) {
+		r.GathersSkipped.Incr(1)
+		return nil
+	}
+	acc, gacc := newGatherAccumulator(acc)
+
+	now := r.now()
 	start := time.Now()
-	err := r.Input.Gather(ctx, acc)
+	full := true
+	used, err := gatherAccounting.measure(func() error {
+		if input, ok := r.Input.(cua.IncrementalInput); ok && r.incremental(now) {
+			err := input.GatherChanges(ctx, acc)
+			if !errors.Is(err, cua.ErrFullGather) {
+				full = false
+				r.GathersIncremental.Incr(1)
+				return err
+			}
+			r.log.Debugf("Changes lost, gathering all objects")
+		}
+		return r.Input.Gather(ctx, acc)
+	})
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
+	r.GatherCPU.Incr(used.cpu)
+	r.GatherAlloc.Incr(used.alloc)
+
+	// errors added to the accumulator fail the collection as well
+	failed := err != nil || atomic.LoadInt64(&gacc.errors) > 0
+	switch {
+	case failed:
+		r.lastFullGather = time.Time{}
+	case full:
+		r.lastFullGather = now
+	}
+	if r.breaker != nil {
+		r.recordGather(failed)
+	}
+	r.checkBudget(used)
 	if err != nil {
 		return fmt.Errorf("gather (input %s): %w", r.Config.Name, err)
 	}
 	return nil
 }
 
+// gatherAccumulator counts the errors added by a collection, unlike the
+// errors logged meanwhile by the goroutines of service inputs
+type gatherAccumulator struct {
+	// Must be 64-bit aligned
+	errors int64
+
+	cua.Accumulator
+}
+
+// streamingGatherAccumulator is the gatherAccumulator of a
+// cua.StreamingAccumulator
+type streamingGatherAccumulator struct {
+	*gatherAccumulator
+	stream cua.StreamingAccumulator
+}
+
+// newGatherAccumulator answers the accumulator passed to a collection,
+// counting its errors in the gatherAccumulator
+func newGatherAccumulator(acc cua.Accumulator) (cua.Accumulator, *gatherAccumulator) {
+	gacc := &gatherAccumulator{Accumulator: acc}
+	if sacc, ok := acc.(cua.StreamingAccumulator); ok {
+		return &streamingGatherAccumulator{gatherAccumulator: gacc, stream: sacc}, gacc
+	}
+	return gacc, gacc
+}
+
+func (a *gatherAccumulator) AddError(err error) {
+	if err != nil {
+		atomic.AddInt64(&a.errors, 1)
+	}
+	a.Accumulator.AddError(err)
+}
+
+func (a *streamingGatherAccumulator) NewStream(size int) cua.MetricStream {
+	return a.stream.NewStream(size)
+}
+
+// incremental answers whether the collection at now only gathers the
+// changes since the previous collection, succeeding within the
+// FullGatherInterval of the last full one
+func (r *RunningInput) incremental(now time.Time) bool {
+	return r.Config.FullGatherInterval > 0 && !r.lastFullGather.IsZero() &&
+		now.Sub(r.lastFullGather) < r.Config.FullGatherInterval
+}
+
+// recordGather updates the breaker with the outcome of a collection
+func (r *RunningInput) recordGather(failed bool) {
+	wasOpen := r.breaker.open()
+	backoff := r.breaker.record(r.now(), failed)
+	switch {
+	case wasOpen && !failed:
+		r.BreakerOpen.Set(0)
+		r.log.Infof("Collection succeeded, resuming collections")
+	case backoff > 0:
+		r.BreakerOpen.Set(1)
+		r.log.Warnf("%d consecutive collections failed, skipping collections for %s", r.breaker.failures, backoff)
+	}
+}
+
+// checkBudget reports the collections exceeding the budgets, disabling the
+// input with DisableOverBudget once they did budgetCollections times in a row
+func (r *RunningInput) checkBudget(u usage) {
+	if (r.Config.CPUBudget <= 0 || u.cpu <= r.Config.CPUBudget.Nanoseconds()) &&
+		(r.Config.AllocBudget <= 0 || u.alloc <= r.Config.AllocBudget) {
+		r.overBudget = 0
+		return
+	}
+	r.overBudget++
+	r.GathersOverBudget.Incr(1)
+	r.log.Warnf("Collection exceeded its budget, using an estimated %s CPU time and %d bytes allocated",
+		time.Duration(u.cpu), u.alloc)
+	if !r.Config.DisableOverBudget || r.overBudget < budgetCollections {
+		return
+	}
+	r.disabled = true
+	r.Disabled.Set(1)
+	r.log.Errorf("%d collections in a row exceeded the budget, input disabled until the agent reloads", r.overBudget)
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
 
+// SetLeader skips the collections of a cluster scope input while the agent
+// is not the leader
+func (r *RunningInput) SetLeader(leader Leader) {
+	r.leader = leader
+}
+
 func (r *RunningInput) Log() cua.Logger {
 	return r.log
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -177,6 +178,33 @@ func TestMakeMetricWithDaemonTags(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricWithPipeline(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:         "TestRunningInput",
+		Tags:         map[string]string{"foo": "input"},
+		Pipeline:     "team_a",
+		PipelineTags: map[string]string{"foo": "pipeline", "team": "a", "dc": "pipeline"},
+	})
+	ri.SetDefaultTags(map[string]string{"dc": "agent", "host": "agent"})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Now(),
+		cua.Untyped)
+	m = ri.MakeMetric(m)
+
+	require.Equal(t, "team_a", m.Pipeline())
+	require.Equal(t, map[string]string{
+		"foo":  "input",
+		"team": "a",
+		"dc":   "pipeline",
+		"host": "agent",
+	}, m.Tags())
+}
+
 func TestMakeMetricNameOverride(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -287,8 +315,293 @@ func TestMetricErrorCounters(t *testing.T) {
 	require.GreaterOrEqual(t, int64(1), GlobalGatherErrors.Get())
 }
 
+func TestMakeMetricMaxSeries(t *testing.T) {
+	now := time.Now()
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:      "TestRunningInput",
+		MaxSeries: 2,
+	})
+
+	series := func(host string) cua.Metric {
+		return testutil.MustMetric("cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": 42},
+			now)
+	}
+
+	require.NotNil(t, ri.MakeMetric(series("a")))
+	require.NotNil(t, ri.MakeMetric(series("b")))
+	require.Nil(t, ri.MakeMetric(series("c")))
+	// known series pass
+	require.NotNil(t, ri.MakeMetric(series("a")))
+	require.Equal(t, int64(1), ri.SeriesDropped.Get())
+
+	// the series are counted per interval
+	require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	require.NotNil(t, ri.MakeMetric(series("c")))
+	require.Equal(t, int64(1), ri.SeriesDropped.Get())
+}
+
+func TestRunningInputStateID(t *testing.T) {
+	for _, tt := range []struct {
+		alias, instanceID, expected string
+	}{
+		{expected: "inputs.tail"},
+		{alias: "web", expected: "inputs.tail::web"},
+		// the alias is backfilled from the instance_id
+		{alias: "web", instanceID: "web", expected: "inputs.tail::web"},
+		{alias: "web", instanceID: "web-2", expected: "inputs.tail::web#web-2"},
+	} {
+		ri := NewRunningInput(&testInput{}, &InputConfig{
+			Name:       "tail",
+			Alias:      tt.alias,
+			InstanceID: tt.instanceID,
+		})
+		require.Equal(t, tt.expected, ri.StateID())
+	}
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                                   { return "" }
 func (t *testInput) SampleConfig() string                                  { return "" }
 func (t *testInput) Gather(ctx context.Context, acc cua.Accumulator) error { return nil }
+
+type failingInput struct {
+	gathers int
+	err     error
+}
+
+func (t *failingInput) Description() string  { return "" }
+func (t *failingInput) SampleConfig() string { return "" }
+func (t *failingInput) Gather(ctx context.Context, acc cua.Accumulator) error {
+	t.gathers++
+	return t.err
+}
+
+func TestRunningInputBreaker(t *testing.T) {
+	input := &failingInput{err: errors.New("connection refused")}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:                    "TestRunningInputBreaker",
+		BreakerThreshold:        2,
+		BreakerProbeInterval:    time.Minute,
+		BreakerMaxProbeInterval: 3 * time.Minute,
+	})
+	now := time.Unix(0, 0)
+	ri.now = func() time.Time { return now }
+	acc := testutil.Accumulator{}
+
+	gather := func(at time.Duration) {
+		now = time.Unix(0, 0).Add(at)
+		_ = ri.Gather(context.Background(), &acc)
+	}
+
+	// opens after 2 failures, probing after 1m, 2m, then 3m
+	for _, at := range []time.Duration{0, 10 * time.Second, 20 * time.Second, 70 * time.Second} {
+		gather(at)
+	}
+	require.Equal(t, 3, input.gathers)
+	require.Equal(t, int64(1), ri.BreakerOpen.Get())
+	gather(190 * time.Second)
+	require.Equal(t, 4, input.gathers)
+	gather(300 * time.Second)
+	require.Equal(t, 4, input.gathers)
+	gather(370 * time.Second)
+	require.Equal(t, 5, input.gathers)
+	require.Equal(t, 3*time.Minute, ri.breaker.backoff)
+
+	// a successful probe closes the breaker
+	input.err = nil
+	gather(550 * time.Second)
+	require.Equal(t, 6, input.gathers)
+	require.Equal(t, int64(0), ri.BreakerOpen.Get())
+	gather(560 * time.Second)
+	require.Equal(t, 7, input.gathers)
+	require.Equal(t, int64(2), ri.GathersSkipped.Get())
+}
+
+func TestRunningInputBreakerAccumulatorErrors(t *testing.T) {
+	input := &failingInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:             "TestRunningInputBreakerAccumulatorErrors",
+		BreakerThreshold: 1,
+	})
+
+	// errors logged by the goroutines of service inputs do not fail the
+	// collection, errors added to the accumulator do
+	ri.Input = &addErrorInput{log: ri.Log()}
+	require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	require.False(t, ri.breaker.open())
+	ri.Input = &addErrorInput{log: ri.Log(), add: true}
+	require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	require.True(t, ri.breaker.open())
+}
+
+type addErrorInput struct {
+	log cua.Logger
+	add bool
+}
+
+func (t *addErrorInput) Description() string  { return "" }
+func (t *addErrorInput) SampleConfig() string { return "" }
+func (t *addErrorInput) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if t.add {
+		acc.AddError(errors.New("server unreachable"))
+		return nil
+	}
+	t.log.Errorf("server unreachable")
+	return nil
+}
+
+type testLeader bool
+
+func (l *testLeader) Leader() bool {
+	return bool(*l)
+}
+
+func TestRunningInputClusterScope(t *testing.T) {
+	input := &failingInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:         "TestRunningInputClusterScope",
+		ClusterScope: true,
+	})
+	leader := testLeader(false)
+	ri.SetLeader(&leader)
+
+	// collected only while elected
+	require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	require.Equal(t, 0, input.gathers)
+	leader = true
+	require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	require.Equal(t, 1, input.gathers)
+}
+
+func TestAccountant(t *testing.T) {
+	var now usage
+	a := newAccountant(func() usage { return now })
+
+	// shared evenly while running concurrently
+	first := a.start()
+	now.cpu, now.alloc = 100, 1000
+	second := a.start()
+	now.cpu, now.alloc = 200, 2000
+	require.Equal(t, usage{cpu: 150, alloc: 1500}, a.stop(first))
+	now.cpu, now.alloc = 300, 3000
+	require.Equal(t, usage{cpu: 150, alloc: 1500}, a.stop(second))
+
+	// not charged while no collection runs
+	now.cpu = 1000
+	used, err := a.measure(func() error {
+		now.cpu += 10
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, usage{cpu: 10}, used)
+}
+
+func TestRunningInputBudget(t *testing.T) {
+	var now usage
+	defer func(a *accountant) { gatherAccounting = a }(gatherAccounting)
+	gatherAccounting = newAccountant(func() usage { return now })
+
+	input := &failingInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:        "TestRunningInputBudget",
+		CPUBudget:   time.Second,
+		AllocBudget: 1 << 20,
+	})
+	gather := func(cpu time.Duration, alloc int64) {
+		ri.Input = &usingInput{input: input, now: &now, cpu: cpu, alloc: alloc}
+		require.NoError(t, ri.Gather(context.Background(), &testutil.Accumulator{}))
+	}
+
+	// only reported by default
+	gather(2*time.Second, 0)
+	gather(0, 2<<20)
+	gather(time.Second, 1<<20)
+	require.Equal(t, int64(time.Second), ri.GatherCPU.Get())
+	for i := 0; i < budgetCollections; i++ {
+		gather(2*time.Second, 0)
+	}
+	require.Equal(t, 3+budgetCollections, input.gathers)
+	require.Equal(t, int64(2+budgetCollections), ri.GathersOverBudget.Get())
+	require.Equal(t, int64(0), ri.Disabled.Get())
+
+	// disabled after budgetCollections in a row over budget when enabled
+	ri.Config.DisableOverBudget = true
+	gather(time.Second, 1<<20)
+	for i := 0; i < budgetCollections; i++ {
+		gather(2*time.Second, 0)
+	}
+	require.Equal(t, 4+2*budgetCollections, input.gathers)
+	require.Equal(t, int64(1), ri.Disabled.Get())
+	gather(0, 0)
+	require.Equal(t, 4+2*budgetCollections, input.gathers)
+}
+
+// usingInput uses resources while gathering
+type usingInput struct {
+	input *failingInput
+	now   *usage
+	cpu   time.Duration
+	alloc int64
+}
+
+func (t *usingInput) Description() string  { return "" }
+func (t *usingInput) SampleConfig() string { return "" }
+func (t *usingInput) Gather(ctx context.Context, acc cua.Accumulator) error {
+	t.now.cpu += t.cpu.Nanoseconds()
+	t.now.alloc += t.alloc
+	return t.input.Gather(ctx, acc)
+}
+
+// incrementalInput counts its full and incremental collections
+type incrementalInput struct {
+	failingInput
+	changes    int
+	changesErr error
+}
+
+func (t *incrementalInput) GatherChanges(ctx context.Context, acc cua.Accumulator) error {
+	t.changes++
+	return t.changesErr
+}
+
+func TestRunningInputIncremental(t *testing.T) {
+	input := &incrementalInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:               "TestRunningInputIncremental",
+		FullGatherInterval: time.Minute,
+	})
+	now := time.Unix(0, 0)
+	ri.now = func() time.Time { return now }
+	gather := func(at time.Duration) {
+		now = time.Unix(0, 0).Add(at)
+		_ = ri.Gather(context.Background(), &testutil.Accumulator{})
+	}
+
+	// full on the first collection, then once per interval
+	gather(0)
+	gather(20 * time.Second)
+	gather(40 * time.Second)
+	require.Equal(t, 1, input.gathers)
+	require.Equal(t, 2, input.changes)
+	gather(60 * time.Second)
+	require.Equal(t, 2, input.gathers)
+	require.Equal(t, int64(2), ri.GathersIncremental.Get())
+
+	// full after a failed collection
+	input.changesErr = errors.New("connection refused")
+	gather(80 * time.Second)
+	input.changesErr = nil
+	gather(100 * time.Second)
+	require.Equal(t, 3, input.gathers)
+	require.Equal(t, 3, input.changes)
+
+	// full when the input lost track of the changes
+	input.changesErr = cua.ErrFullGather
+	gather(120 * time.Second)
+	require.Equal(t, 4, input.gathers)
+	require.Equal(t, 4, input.changes)
+	require.Equal(t, int64(3), ri.GathersIncremental.Get())
+}
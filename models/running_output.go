@@ -2,11 +2,13 @@ package models
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
 	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 )
 
@@ -30,6 +32,22 @@ type OutputConfig struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 	FlushInterval     time.Duration
+
+	// MetricBufferOverflow is the policy of a full buffer, DropOldest or
+	// DropNewest
+	MetricBufferOverflow string
+
+	// WriteWorkers is the number of goroutines writing to the output, each
+	// with its own buffer of the series hashed to it
+	WriteWorkers int
+
+	// MaxMetricsPerFlush is the number of metrics added to the output
+	// between flushes, metrics beyond it are dropped; 0 for no limit
+	MaxMetricsPerFlush int
+
+	// Pipeline is the name of the pipeline of the output, it is written
+	// the metrics of the pipeline only
+	Pipeline string
 }
 
 // RunningOutput contains the output configuration
@@ -41,9 +59,13 @@ type RunningOutput struct {
 	log               cua.Logger
 	Config            *OutputConfig
 	BatchReady        chan time.Time
-	buffer            *Buffer
+	buffers           []*Buffer
+	workerOutputs     []cua.Output
+	connected         int
+	MetricsLimited    selfstat.Stat
 	newMetricsCount   int64
 	droppedMetrics    int64
+	flushMetricsCount int64
 	MetricBufferLimit int
 	MetricBatchSize   int
 }
@@ -103,7 +125,7 @@ func NewRunningOutput(
 	}
 
 	ro := &RunningOutput{
-		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
+		buffers:           newBuffers(config, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
@@ -119,28 +141,99 @@ func NewRunningOutput(
 			"write_time_ns",
 			tags,
 		),
+		MetricsLimited: selfstat.Register(
+			"write",
+			"metrics_limited",
+			tags,
+		),
 		log: logger,
 	}
+	for _, b := range ro.buffers {
+		if err := b.SetOverflow(config.MetricBufferOverflow); err != nil {
+			logger.Errorf("%v, using %s", err, DropOldest)
+			break
+		}
+	}
 
 	return ro
 }
 
+// newBuffers returns the buffers of the write workers, the buffer limit is
+// shared by the workers
+func newBuffers(config *OutputConfig, bufferLimit int) []*Buffer {
+	if config.WriteWorkers <= 1 {
+		return []*Buffer{NewBuffer(config.Name, config.Alias, bufferLimit)}
+	}
+
+	capacity := (bufferLimit + config.WriteWorkers - 1) / config.WriteWorkers
+	buffers := make([]*Buffer, config.WriteWorkers)
+	for i := range buffers {
+		tags := map[string]string{"output": config.Name, "worker": strconv.Itoa(i)}
+		if config.Alias != "" {
+			tags["alias"] = config.Alias
+		}
+		buffers[i] = newBuffer(tags, capacity)
+	}
+	return buffers
+}
+
+// SetWorkerOutputs sets the instances of the output written by the write
+// workers but the first one, which writes Output.  Outputs not supporting
+// concurrent writes have an instance per write worker.
+func (ro *RunningOutput) SetWorkerOutputs(outputs []cua.Output) {
+	for _, output := range outputs {
+		SetLoggerOnPlugin(output, ro.log)
+	}
+	ro.workerOutputs = outputs
+}
+
+// outputs answers the instances of the output
+func (ro *RunningOutput) outputs() []cua.Output {
+	return append([]cua.Output{ro.Output}, ro.workerOutputs...)
+}
+
+// workerOutput answers the instance of the output written by the write
+// worker w
+func (ro *RunningOutput) workerOutput(w int) cua.Output {
+	if w == 0 || w > len(ro.workerOutputs) {
+		return ro.Output
+	}
+	return ro.workerOutputs[w-1]
+}
+
 func (ro *RunningOutput) LogName() string {
 	return logName("outputs", ro.Config.Name, ro.Config.Alias)
 }
 
+// Pipeline answers the name of the pipeline of the output
+func (ro *RunningOutput) Pipeline() string {
+	return PipelineName(ro.Config.Pipeline)
+}
+
 func (ro *RunningOutput) metricFiltered(metric cua.Metric) {
 	ro.MetricsFiltered.Incr(1)
 	metric.Drop()
 }
 
 func (ro *RunningOutput) Init() error {
-	if p, ok := ro.Output.(cua.Initializer); ok {
-		err := p.Init()
-		if err != nil {
-			return fmt.Errorf("init (output %s): %w", ro.Config.Name, err)
+	for _, output := range ro.outputs() {
+		if p, ok := output.(cua.Initializer); ok {
+			err := p.Init()
+			if err != nil {
+				return fmt.Errorf("init (output %s): %w", ro.Config.Name, err)
+			}
 		}
+	}
+	return nil
+}
 
+// Connect connects the instances of the output not connected yet
+func (ro *RunningOutput) Connect() error {
+	outputs := ro.outputs()
+	for ; ro.connected < len(outputs); ro.connected++ {
+		if err := outputs[ro.connected].Connect(); err != nil {
+			return err //nolint:wrapcheck
+		}
 	}
 	return nil
 }
@@ -150,17 +243,20 @@ func (ro *RunningOutput) Init() error {
 // Takes ownership of metric
 func (ro *RunningOutput) AddMetric(metric cua.Metric) {
 	if ok := ro.Config.Filter.Select(metric); !ok {
+		trace(metric, ro.LogName(), "dropped by the filters")
 		ro.metricFiltered(metric)
 		return
 	}
 
 	ro.Config.Filter.Modify(metric)
 	if len(metric.FieldList()) == 0 {
+		trace(metric, ro.LogName(), "dropped, no fields left after the filters")
 		ro.metricFiltered(metric)
 		return
 	}
 
 	if output, ok := ro.Output.(cua.AggregatingOutput); ok {
+		trace(metric, ro.LogName(), "aggregated by the output")
 		ro.aggMutex.Lock()
 		output.Add(metric)
 		ro.aggMutex.Unlock()
@@ -179,7 +275,20 @@ func (ro *RunningOutput) AddMetric(metric cua.Metric) {
 		metric.AddSuffix(ro.Config.NameSuffix)
 	}
 
-	dropped := ro.buffer.Add(metric)
+	if limit := ro.Config.MaxMetricsPerFlush; limit > 0 {
+		if n := atomic.AddInt64(&ro.flushMetricsCount, 1); n > int64(limit) {
+			if n == int64(limit)+1 {
+				ro.log.Warnf("Limit of %d metrics per flush reached, dropping metrics until the next flush", limit)
+			}
+			trace(metric, ro.LogName(), "dropped by max_metrics_per_flush")
+			ro.MetricsLimited.Incr(1)
+			metric.Drop()
+			return
+		}
+	}
+
+	trace(metric, ro.LogName(), "buffered")
+	dropped := ro.add(metric)
 	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
 
 	count := atomic.AddInt64(&ro.newMetricsCount, 1)
@@ -198,73 +307,152 @@ func (ro *RunningOutput) Write() error {
 	if output, ok := ro.Output.(cua.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
-		ro.buffer.Add(metrics...)
+		ro.add(metrics...)
 		output.Reset()
 		ro.aggMutex.Unlock()
 	}
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
+	atomic.StoreInt64(&ro.flushMetricsCount, 0)
+
+	return ro.eachBuffer(ro.writeBuffer)
+}
 
+// writeBuffer writes the metrics of the buffer of the write worker w,
+// stopping when all have been sent on or error.
+func (ro *RunningOutput) writeBuffer(w int, b *Buffer) error {
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
-	nBuffer := ro.buffer.Len()
+	nBuffer := b.Len()
 	nBatches := nBuffer/ro.MetricBatchSize + 1
 	for i := 0; i < nBatches; i++ {
-		batch := ro.buffer.Batch(ro.MetricBatchSize)
+		batch := b.Batch(ro.MetricBatchSize)
 		if len(batch) == 0 {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.write(w, batch)
 		if err != nil {
-			ro.buffer.Reject(batch)
+			b.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
+		b.Accept(batch)
+		ro.recycle(batch)
 	}
 	return nil
 }
 
-// WriteBatch writes a single batch of metrics to the output.
+// WriteBatch writes a single batch of metrics to the output, one batch per
+// write worker.
 func (ro *RunningOutput) WriteBatch() error {
-	batch := ro.buffer.Batch(ro.MetricBatchSize)
+	return ro.eachBuffer(ro.writeBatch)
+}
+
+func (ro *RunningOutput) writeBatch(w int, b *Buffer) error {
+	batch := b.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
+	err := ro.write(w, batch)
 	if err != nil {
-		ro.buffer.Reject(batch)
+		b.Reject(batch)
 		return err
 	}
-	ro.buffer.Accept(batch)
+	b.Accept(batch)
+	ro.recycle(batch)
+
+	return nil
+}
+
+// recycle returns the metrics of a batch written to the pool of the agent,
+// when the output is done with them
+func (ro *RunningOutput) recycle(batch []cua.Metric) {
+	if output, ok := ro.Output.(cua.RecyclingOutput); !ok || !output.RecyclesMetrics() {
+		return
+	}
+	for _, m := range batch {
+		metric.Recycle(m)
+	}
+}
+
+// add adds metrics to the buffers of their series and returns the number
+// of dropped metrics.
+func (ro *RunningOutput) add(metrics ...cua.Metric) int {
+	if len(ro.buffers) == 1 {
+		return ro.buffers[0].Add(metrics...)
+	}
+
+	// the metrics of a series always go to the same worker, so they are
+	// written in order
+	dropped := 0
+	for _, m := range metrics {
+		dropped += ro.buffers[m.HashID()%uint64(len(ro.buffers))].Add(m)
+	}
+	return dropped
+}
+
+// eachBuffer calls fn for each write worker and its buffer concurrently and
+// returns the first error.
+func (ro *RunningOutput) eachBuffer(fn func(int, *Buffer) error) error {
+	if len(ro.buffers) == 1 {
+		return fn(0, ro.buffers[0])
+	}
 
+	errs := make([]error, len(ro.buffers))
+	var wg sync.WaitGroup
+	for i, b := range ro.buffers {
+		wg.Add(1)
+		go func(i int, b *Buffer) {
+			defer wg.Done()
+			errs[i] = fn(i, b)
+		}(i, b)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Close closes the output
 func (ro *RunningOutput) Close() {
-	err := ro.Output.Close()
-	if err != nil {
-		ro.log.Errorf("Error closing output: %v", err)
+	for _, output := range ro.outputs() {
+		err := output.Close()
+		if err != nil {
+			ro.log.Errorf("Error closing output: %v", err)
+		}
 	}
 }
 
-func (ro *RunningOutput) write(metrics []cua.Metric) error {
+// write writes metrics to the instance of the output of the write worker w
+func (ro *RunningOutput) write(w int, metrics []cua.Metric) error {
 	dropped := atomic.LoadInt64(&ro.droppedMetrics)
 	if dropped > 0 {
-		ro.log.Warnf("Metric buffer overflow; %d batches have been dropped", dropped)
+		ro.log.Warnf("Metric buffer overflow; %d metrics have been dropped (%s)", dropped, ro.buffers[0].Overflow())
 		atomic.StoreInt64(&ro.droppedMetrics, 0)
 	}
 
 	start := time.Now()
-	_, err := ro.Output.Write(metrics)
+	_, err := ro.workerOutput(w).Write(metrics)
 	elapsed := time.Since(start)
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
 	if err == nil {
 		ro.log.Debugf("Wrote %d batches in %s", len(metrics), elapsed)
 	}
+	if metricTracer != nil {
+		for _, m := range metrics {
+			if err != nil {
+				trace(m, ro.LogName(), "write failed, kept in the buffer: %s", err)
+			} else {
+				trace(m, ro.LogName(), "written")
+			}
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("write (output %s): %w", ro.Config.Name, err)
 	}
@@ -272,7 +460,7 @@ func (ro *RunningOutput) write(metrics []cua.Metric) error {
 }
 
 func (ro *RunningOutput) LogBufferStatus() {
-	nBuffer := ro.buffer.Len()
+	nBuffer := ro.BufferLength()
 	ro.log.Debugf("Buffer fullness: %d / %d batches", nBuffer, ro.MetricBufferLimit)
 }
 
@@ -281,5 +469,9 @@ func (ro *RunningOutput) Log() cua.Logger {
 }
 
 func (ro *RunningOutput) BufferLength() int {
-	return ro.buffer.Len()
+	n := 0
+	for _, b := range ro.buffers {
+		n += b.Len()
+	}
+	return n
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
 	"github.com/circonus-labs/circonus-unified-agent/selfstat"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
@@ -494,6 +495,7 @@ func TestInternalMetrics(t *testing.T) {
 				"metrics_added":    0,
 				"metrics_dropped":  0,
 				"metrics_filtered": 0,
+				"metrics_limited":  0,
 				"metrics_written":  0,
 				"write_time_ns":    0,
 			},
@@ -512,6 +514,143 @@ func TestInternalMetrics(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
 }
 
+func TestRunningOutputWriteWorkers(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		WriteWorkers: 4,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+	require.Len(t, ro.buffers, 4)
+
+	// several samples of each series
+	var metrics []cua.Metric
+	for i := 0; i < 5; i++ {
+		for _, metric := range append(append([]cua.Metric{}, first5...), next5...) {
+			mm := metric.Copy()
+			mm.SetTime(time.Unix(int64(i), 0))
+			metrics = append(metrics, mm)
+			ro.AddMetric(mm)
+		}
+	}
+	require.Equal(t, 50, ro.BufferLength())
+
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 50)
+	require.Equal(t, 0, ro.BufferLength())
+
+	// the samples of a series are written in order
+	last := make(map[string]time.Time)
+	for _, metric := range m.Metrics() {
+		require.False(t, metric.Time().Before(last[metric.Name()]), metric.Name())
+		last[metric.Name()] = metric.Time()
+	}
+}
+
+func TestRunningOutputWorkerOutputs(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		WriteWorkers: 2,
+	}
+
+	m, m2 := &mockOutput{}, &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 100)
+	ro.SetWorkerOutputs([]cua.Output{m2})
+	require.NoError(t, ro.Connect())
+
+	for _, metric := range append(append([]cua.Metric{}, first5...), next5...) {
+		ro.AddMetric(metric.Copy())
+	}
+	require.NoError(t, ro.Write())
+
+	// each worker writes its series to its own instance
+	require.NotEmpty(t, m.Metrics())
+	require.NotEmpty(t, m2.Metrics())
+	require.Len(t, append(m.Metrics(), m2.Metrics()...), 10)
+	written := make(map[uint64]bool)
+	for _, metric := range m.Metrics() {
+		written[metric.HashID()] = true
+	}
+	for _, metric := range m2.Metrics() {
+		require.False(t, written[metric.HashID()], metric.Name())
+	}
+}
+
+func TestRunningOutputMaxMetricsPerFlush(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:             Filter{},
+		MaxMetricsPerFlush: 3,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Equal(t, 3, ro.BufferLength())
+	require.Equal(t, int64(2), ro.MetricsLimited.Get())
+
+	// the limit applies again after the flush
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 3)
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.Equal(t, 3, ro.BufferLength())
+	require.Equal(t, int64(4), ro.MetricsLimited.Get())
+}
+
+func TestRunningOutputWriteWorkersFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:       Filter{},
+		WriteWorkers: 2,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.Equal(t, 5, ro.BufferLength())
+
+	m.failWrite = false
+	require.NoError(t, ro.WriteBatch())
+	require.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputRecycle(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &recyclingOutput{perfOutput: perfOutput{failWrite: true}}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	written := metric.Get("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.0}, time.Now())
+	ro.AddMetric(written)
+
+	// metrics failing to be written are kept
+	require.Error(t, ro.Write())
+	require.Equal(t, "cpu", written.Name())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.Empty(t, written.Name())
+	require.Empty(t, written.TagList())
+
+	// unless the output keeps them
+	m.keep = true
+	kept := metric.Get("cpu", nil, map[string]interface{}{"value": 42.0}, time.Now())
+	ro.AddMetric(kept)
+	require.NoError(t, ro.Write())
+	require.Equal(t, "cpu", kept.Name())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -585,3 +724,12 @@ func (m *perfOutput) Write(metrics []cua.Metric) (int, error) {
 	}
 	return 0, nil
 }
+
+type recyclingOutput struct {
+	perfOutput
+	keep bool
+}
+
+func (m *recyclingOutput) RecyclesMetrics() bool {
+	return !m.keep
+}
@@ -27,6 +27,10 @@ type ProcessorConfig struct {
 	Alias  string
 	Filter Filter
 	Order  int64
+
+	// Pipeline is the name of the pipeline of the processor, it processes
+	// the metrics of the pipeline only
+	Pipeline string
 }
 
 func NewRunningProcessor(processor cua.StreamingProcessor, config *ProcessorConfig) *RunningProcessor {
@@ -72,6 +76,11 @@ func (rp *RunningProcessor) LogName() string {
 }
 
 func (rp *RunningProcessor) MakeMetric(metric cua.Metric) cua.Metric {
+	// metrics created by the processor are in its pipeline
+	if metric.Pipeline() == "" {
+		metric.SetPipeline(PipelineName(rp.Config.Pipeline))
+	}
+	trace(metric, rp.LogName(), "emitted")
 	return metric
 }
 
@@ -80,6 +89,11 @@ func (rp *RunningProcessor) Start(acc cua.Accumulator) error {
 }
 
 func (rp *RunningProcessor) Add(m cua.Metric, acc cua.Accumulator) error {
+	if PipelineName(m.Pipeline()) != PipelineName(rp.Config.Pipeline) {
+		// pass downstream
+		acc.AddMetric(m)
+		return nil
+	}
 	if ok := rp.Config.Filter.Select(m); !ok {
 		// pass downstream
 		acc.AddMetric(m)
@@ -89,10 +103,12 @@ func (rp *RunningProcessor) Add(m cua.Metric, acc cua.Accumulator) error {
 	rp.Config.Filter.Modify(m)
 	if len(m.FieldList()) == 0 {
 		// drop metric
+		trace(m, rp.LogName(), "dropped, no fields left after the filters")
 		rp.metricFiltered(m)
 		return nil
 	}
 
+	trace(m, rp.LogName(), "processing")
 	return rp.Processor.Add(m, acc)
 }
 
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// tracer logs the flow of the metrics selected by its filter through the
+// inputs, processors, aggregators and outputs
+type tracer struct {
+	filter Filter
+	logf   func(format string, args ...interface{})
+}
+
+// metricTracer is the tracer of the agent, nil when not tracing; it is set
+// while loading the config, before any plugin runs
+var metricTracer *tracer
+
+// EnableTracing traces the metrics with a name matching namePass and a tag
+// matching tagPass, like the namepass and tagpass filters of a plugin.
+// Tracing is disabled when both are empty.
+func EnableTracing(namePass []string, tagPass map[string][]string) error {
+	f := Filter{NamePass: namePass}
+	names := make([]string, 0, len(tagPass))
+	for name := range tagPass {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f.TagPass = append(f.TagPass, TagFilter{Name: name, Filter: tagPass[name]})
+	}
+	if err := f.Compile(); err != nil {
+		return err
+	}
+
+	if !f.IsActive() {
+		metricTracer = nil
+		return nil
+	}
+	metricTracer = &tracer{filter: f, logf: log.Printf}
+	return nil
+}
+
+// trace logs what happened to the metric at a stage of the pipeline, e.g.
+// "inputs.cpu", when the metric is traced. The metric is identified by its
+// series and time, so its log lines can be followed through the pipeline.
+func trace(m cua.Metric, stage, format string, args ...interface{}) {
+	t := metricTracer
+	if t == nil || !t.filter.Select(m) {
+		return
+	}
+	t.logf("I! [trace] %s [%s] %s: %s", traceID(m), stage, fmt.Sprintf(format, args...), series(m))
+}
+
+// traceID answers the ID of a traced metric, the hash of its series and
+// its time
+func traceID(m cua.Metric) string {
+	return fmt.Sprintf("%016x-%d", m.HashID(), m.Time().UnixNano())
+}
+
+// series answers the name and tags of a metric, e.g. "cpu,cpu=cpu0"
+func series(m cua.Metric) string {
+	var b strings.Builder
+	b.WriteString(m.Name())
+	for _, tag := range m.TagList() {
+		b.WriteByte(',')
+		b.WriteString(tag.Key)
+		b.WriteByte('=')
+		b.WriteString(tag.Value)
+	}
+	return b.String()
+}
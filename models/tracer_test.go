@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer(t *testing.T) {
+	require.NoError(t, EnableTracing([]string{"cpu"}, map[string][]string{"cpu": {"cpu-total"}}))
+	defer func() { metricTracer = nil }()
+	var lines []string
+	metricTracer.logf = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	ri := NewRunningInput(&testInput{}, &InputConfig{Name: "cpu", Alias: "host"})
+	out := &mockOutput{}
+	ro := NewRunningOutput("circonus", out, &OutputConfig{Name: "circonus"}, 10, 100)
+
+	now := time.Unix(1600000000, 0)
+	for _, cpu := range []string{"cpu-total", "cpu0"} {
+		m, err := metric.New("cpu", map[string]string{"cpu": cpu}, map[string]interface{}{"usage_idle": 99.5}, now)
+		require.NoError(t, err)
+		ro.AddMetric(ri.MakeMetric(m))
+	}
+	out.failWrite = true
+	require.Error(t, ro.Write())
+	out.failWrite = false
+	require.NoError(t, ro.Write())
+
+	m, err := metric.New("cpu", map[string]string{"cpu": "cpu-total"}, map[string]interface{}{}, now)
+	require.NoError(t, err)
+	id := traceID(m)
+	require.Equal(t, []string{
+		"I! [trace] " + id + " [inputs.cpu::host] gathered: cpu,cpu=cpu-total",
+		"I! [trace] " + id + " [outputs.circonus] buffered: cpu,cpu=cpu-total",
+		"I! [trace] " + id + " [outputs.circonus] write failed, kept in the buffer: failed write: cpu,cpu=cpu-total",
+		"I! [trace] " + id + " [outputs.circonus] written: cpu,cpu=cpu-total",
+	}, lines)
+
+	require.NoError(t, EnableTracing(nil, nil))
+	require.Nil(t, metricTracer)
+}
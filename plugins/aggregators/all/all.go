@@ -1,3 +1,6 @@
+//go:build !lite
+// +build !lite
+
 package all
 
 //nolint:golint
@@ -7,5 +10,6 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/histogram"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/merge"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/minmax"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/rollup"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/valuecounter"
 )
@@ -0,0 +1,12 @@
+//go:build lite
+// +build lite
+
+package all
+
+// reduced set of plugins of agents built with the lite tag for edge devices
+
+//nolint:golint
+import (
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/basicstats"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/aggregators/minmax"
+)
@@ -0,0 +1,229 @@
+package rollup
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## The period on which to push the completed rollups, at most the shortest
+  ## window.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Resolutions to roll the metrics up to.  Each window is aligned to the
+  ## clock, e.g. a 5m window starts at 12:00, 12:05, ...
+  windows = ["1m", "5m"]
+
+  ## Statistics of each field to emit, any of count, min, max, mean and sum.
+  # stats = ["mean", "max", "count"]
+
+  ## Tag set to the window of the rollup.
+  # tag = "rollup"
+`
+
+type Rollup struct {
+	Windows []config.Duration `toml:"windows"`
+	Stats   []string          `toml:"stats"`
+	Tag     string            `toml:"tag"`
+	Log     cua.Logger        `toml:"-"`
+
+	windows []window
+	buckets map[bucketKey]*bucket
+	now     func() time.Time
+}
+
+type window struct {
+	size time.Duration
+	name string
+}
+
+// bucketKey identifies the rollup of a series in a window
+type bucketKey struct {
+	window int
+	start  int64
+	id     uint64
+}
+
+type bucket struct {
+	name   string
+	tags   map[string]string
+	start  time.Time
+	fields map[string]*stats
+}
+
+type stats struct {
+	count int64
+	min   float64
+	max   float64
+	sum   float64
+}
+
+func NewRollup() *Rollup {
+	return &Rollup{
+		Windows: []config.Duration{config.Duration(time.Minute), config.Duration(5 * time.Minute)},
+		Stats:   []string{"mean", "max", "count"},
+		Tag:     "rollup",
+		buckets: make(map[bucketKey]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (r *Rollup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rollup) Description() string {
+	return "Roll metrics up to one or more coarser resolutions"
+}
+
+func (r *Rollup) Init() error {
+	if len(r.Windows) == 0 {
+		return fmt.Errorf("no windows configured")
+	}
+	for _, stat := range r.Stats {
+		switch stat {
+		case "count", "min", "max", "mean", "sum":
+		default:
+			return fmt.Errorf("unknown stat %q", stat)
+		}
+	}
+	if r.Tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+
+	r.windows = make([]window, 0, len(r.Windows))
+	for _, w := range r.Windows {
+		size := time.Duration(w)
+		if size <= 0 {
+			return fmt.Errorf("window %s must be positive", size)
+		}
+		r.windows = append(r.windows, window{size: size, name: windowName(size)})
+	}
+	return nil
+}
+
+// windowName formats a window as configured, e.g. 5m instead of 5m0s
+func windowName(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func (r *Rollup) Add(in cua.Metric) {
+	id := in.HashID()
+	for i, w := range r.windows {
+		start := in.Time().Truncate(w.size)
+		k := bucketKey{window: i, start: start.UnixNano(), id: id}
+		b, ok := r.buckets[k]
+		if !ok {
+			b = &bucket{
+				name:   in.Name(),
+				tags:   in.Tags(),
+				start:  start,
+				fields: make(map[string]*stats),
+			}
+			r.buckets[k] = b
+		}
+
+		for _, field := range in.FieldList() {
+			v, ok := convert(field.Value)
+			if !ok {
+				continue
+			}
+			s, ok := b.fields[field.Key]
+			if !ok {
+				s = &stats{min: math.Inf(1), max: math.Inf(-1)}
+				b.fields[field.Key] = s
+			}
+			s.count++
+			s.sum += v
+			s.min = math.Min(s.min, v)
+			s.max = math.Max(s.max, v)
+		}
+	}
+}
+
+// Push emits the rollups of the windows that have ended, stamped with the
+// start of the window.  Rollups of windows in progress are kept for later
+// pushes.
+func (r *Rollup) Push(acc cua.Accumulator) {
+	acc.SetPrecision(time.Nanosecond)
+
+	now := r.now()
+	for k, b := range r.buckets {
+		w := r.windows[k.window]
+		if now.Before(b.start.Add(w.size)) {
+			continue
+		}
+		delete(r.buckets, k)
+		if len(b.fields) == 0 {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(b.fields)*len(r.Stats))
+		for name, s := range b.fields {
+			for _, stat := range r.Stats {
+				switch stat {
+				case "count":
+					fields[name+"_count"] = s.count
+				case "min":
+					fields[name+"_min"] = s.min
+				case "max":
+					fields[name+"_max"] = s.max
+				case "mean":
+					fields[name+"_mean"] = s.sum / float64(s.count)
+				case "sum":
+					fields[name+"_sum"] = s.sum
+				}
+			}
+		}
+		tags := make(map[string]string, len(b.tags)+1)
+		for key, value := range b.tags {
+			tags[key] = value
+		}
+		tags[r.Tag] = w.name
+		acc.AddFields(b.name, fields, tags, b.start)
+	}
+}
+
+// Reset keeps the rollups, windows usually span several periods.
+func (r *Rollup) Reset() {
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("rollup", func() cua.Aggregator {
+		return NewRollup()
+	})
+}
@@ -0,0 +1,99 @@
+package rollup
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func load(v float64, sec int64) cua.Metric {
+	return testutil.MustMetric("system",
+		map[string]string{"host": "tars"},
+		map[string]interface{}{"load1": v, "uptime": int64(sec), "os": "linux"},
+		time.Unix(sec, 0))
+}
+
+func TestRollup(t *testing.T) {
+	r := NewRollup()
+	r.Stats = []string{"mean", "max", "min", "sum", "count"}
+	require.NoError(t, r.Init())
+
+	// 00:00:30 to 00:05:30 every minute
+	for i, v := range []float64{1, 2, 3, 4, 5, 6} {
+		r.Add(load(v, int64(30+60*i)))
+	}
+
+	acc := testutil.Accumulator{}
+	r.now = func() time.Time { return time.Unix(300, 0) }
+	r.Push(&acc)
+
+	expected := make([]cua.Metric, 0, 5)
+	for i, v := range []float64{1, 2, 3, 4, 5} {
+		uptime := float64(30 + 60*i)
+		expected = append(expected, testutil.MustMetric("system",
+			map[string]string{"host": "tars", "rollup": "1m"},
+			map[string]interface{}{
+				"load1_mean": v, "load1_max": v, "load1_min": v, "load1_sum": v, "load1_count": int64(1),
+				"uptime_mean": uptime, "uptime_max": uptime, "uptime_min": uptime, "uptime_sum": uptime, "uptime_count": int64(1),
+			},
+			time.Unix(int64(60*i), 0)))
+	}
+	expected = append(expected, testutil.MustMetric("system",
+		map[string]string{"host": "tars", "rollup": "5m"},
+		map[string]interface{}{
+			"load1_mean": 3.0, "load1_max": 5.0, "load1_min": 1.0, "load1_sum": 15.0, "load1_count": int64(5),
+			"uptime_mean": 150.0, "uptime_max": 270.0, "uptime_min": 30.0, "uptime_sum": 750.0, "uptime_count": int64(5),
+		},
+		time.Unix(0, 0)))
+	actual := acc.GetCUAMetrics()
+	sort.Slice(actual, func(i, j int) bool {
+		if actual[i].Tags()["rollup"] != actual[j].Tags()["rollup"] {
+			return actual[i].Tags()["rollup"] < actual[j].Tags()["rollup"]
+		}
+		return actual[i].Time().Before(actual[j].Time())
+	})
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// the windows in progress are pushed once they end
+	acc.ClearMetrics()
+	r.Reset()
+	r.Push(&acc)
+	require.Empty(t, acc.GetCUAMetrics())
+
+	r.now = func() time.Time { return time.Unix(600, 0) }
+	r.Push(&acc)
+	require.Len(t, acc.GetCUAMetrics(), 2)
+	require.Empty(t, r.buckets)
+}
+
+func TestWindowName(t *testing.T) {
+	r := NewRollup()
+	r.Windows = []config.Duration{
+		config.Duration(30 * time.Second),
+		config.Duration(time.Hour),
+		config.Duration(90 * time.Minute),
+	}
+	require.NoError(t, r.Init())
+	require.Equal(t, "30s", r.windows[0].name)
+	require.Equal(t, "1h", r.windows[1].name)
+	require.Equal(t, "1h30m", r.windows[2].name)
+}
+
+func TestInitErrors(t *testing.T) {
+	r := NewRollup()
+	r.Windows = nil
+	require.Error(t, r.Init())
+
+	r = NewRollup()
+	r.Stats = []string{"stdev"}
+	require.Error(t, r.Init())
+
+	r = NewRollup()
+	r.Windows = []config.Duration{0}
+	require.Error(t, r.Init())
+}
@@ -0,0 +1,71 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// consulProvider answers the instances of a service passing their health
+// checks, labelling the targets with the node and datacenter
+type consulProvider struct {
+	client     *api.Client
+	service    string
+	tag        string
+	datacenter string
+}
+
+func newConsulProvider(cfg *Config) (*consulProvider, error) {
+	if cfg.Service == "" {
+		return nil, fmt.Errorf("consul discovery without service")
+	}
+	consulConfig := api.DefaultConfig()
+	if cfg.ConsulAddress != "" {
+		consulConfig.Address = cfg.ConsulAddress
+	}
+	if cfg.ConsulToken != "" {
+		consulConfig.Token = cfg.ConsulToken
+	}
+	client, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, fmt.Errorf("consul client: %w", err)
+	}
+	return &consulProvider{
+		client:     client,
+		service:    cfg.Service,
+		tag:        cfg.Tag,
+		datacenter: cfg.ConsulDatacenter,
+	}, nil
+}
+
+func (p *consulProvider) targets(ctx context.Context) ([]Target, error) {
+	opts := (&api.QueryOptions{Datacenter: p.datacenter}).WithContext(ctx)
+	entries, _, err := p.client.Health().Service(p.service, p.tag, true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("consul service %s: %w", p.service, err)
+	}
+
+	targets := make([]Target, 0, len(entries))
+	for _, e := range entries {
+		if e.Service == nil || e.Node == nil {
+			continue
+		}
+		// the service address defaults to the address of the node
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		targets = append(targets, Target{
+			Address: net.JoinHostPort(host, strconv.Itoa(e.Service.Port)),
+			Labels: map[string]string{
+				"service":    p.service,
+				"node":       e.Node.Node,
+				"datacenter": e.Node.Datacenter,
+			},
+		})
+	}
+	return targets, nil
+}
@@ -0,0 +1,174 @@
+// Package discovery resolves the targets of inputs at runtime, e.g. the
+// servers of a redis input from the DNS SRV records or the Consul catalog,
+// so configurations do not have to be regenerated when backends scale.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+)
+
+const defaultInterval = time.Minute
+
+// Target is a discovered endpoint, the address as host:port and labels
+// describing it, e.g. the node a service instance runs on.
+type Target struct {
+	Address string
+	Labels  map[string]string
+}
+
+// Config selects where targets are discovered, configured as the discovery
+// table of an input.
+type Config struct {
+	// Type of the discovery, one of "dns_srv", "consul", "file" or "kubernetes"
+	Type string `toml:"type"`
+	// Interval between resolving the targets again
+	Interval internal.Duration `toml:"interval"`
+	// URL template turning a target into a server of the input
+	URL string `toml:"url"`
+
+	// dns_srv: SRV records to resolve, e.g. _redis._tcp.example.com
+	Names []string `toml:"names"`
+
+	// consul: healthy instances of the service, optionally with the tag
+	ConsulAddress    string `toml:"consul_address"`
+	ConsulToken      string `toml:"consul_token"`
+	ConsulDatacenter string `toml:"consul_datacenter"`
+	Service          string `toml:"service"`
+	Tag              string `toml:"tag"`
+
+	// file: files with a target per line or in the JSON format of the
+	// Prometheus file based discovery, read again when resolving
+	Files []string `toml:"files"`
+
+	// kubernetes: ready addresses of the endpoints of the service
+	KubeConfig string `toml:"kubeconfig"`
+	Namespace  string `toml:"namespace"`
+	Port       string `toml:"port"`
+}
+
+// provider resolves the current targets
+type provider interface {
+	targets(ctx context.Context) ([]Target, error)
+}
+
+// Discoverer answers the targets of a discovery configuration, resolving
+// them again once the interval elapsed.  When resolving fails the previous
+// targets are kept, so a flapping registry does not drop all servers.
+type Discoverer struct {
+	interval time.Duration
+	url      *template.Template
+	provider provider
+	log      cua.Logger
+
+	mu       sync.Mutex
+	resolved time.Time
+	current  []Target
+}
+
+// New creates the discoverer of the configuration.  The default URL template
+// is used when none is configured, e.g. "tcp://{{.Address}}" for redis.
+func New(cfg *Config, defaultURL string, log cua.Logger) (*Discoverer, error) {
+	d := &Discoverer{
+		interval: cfg.Interval.Duration,
+		log:      log,
+	}
+	if d.interval <= 0 {
+		d.interval = defaultInterval
+	}
+
+	urlTemplate := cfg.URL
+	if urlTemplate == "" {
+		urlTemplate = defaultURL
+	}
+	var err error
+	d.url, err = template.New("url").Option("missingkey=zero").Parse(urlTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("discovery url %q: %w", urlTemplate, err)
+	}
+
+	switch cfg.Type {
+	case "dns_srv":
+		d.provider, err = newDNSProvider(cfg)
+	case "consul":
+		d.provider, err = newConsulProvider(cfg)
+	case "file":
+		d.provider, err = newFileProvider(cfg)
+	case "kubernetes":
+		d.provider, err = newKubernetesProvider(cfg)
+	default:
+		err = fmt.Errorf("unknown discovery type %q", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Interval answers the interval between resolving the targets
+func (d *Discoverer) Interval() time.Duration {
+	return d.interval
+}
+
+// Targets answers the discovered targets sorted by address
+func (d *Discoverer) Targets(ctx context.Context) ([]Target, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.resolved.IsZero() && time.Since(d.resolved) < d.interval {
+		return d.current, nil
+	}
+
+	targets, err := d.provider.targets(ctx)
+	if err != nil {
+		if d.resolved.IsZero() {
+			return nil, err
+		}
+		d.log.Errorf("Discovering targets, keeping %d previous targets: %v", len(d.current), err)
+		// try again with the next interval rather than with every gather
+		d.resolved = time.Now()
+		return d.current, nil
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Address < targets[j].Address })
+	if len(targets) != len(d.current) {
+		d.log.Debugf("Discovered %d targets", len(targets))
+	}
+	d.current = targets
+	d.resolved = time.Now()
+	return d.current, nil
+}
+
+// URLs answers the discovered targets as servers of the input
+func (d *Discoverer) URLs(ctx context.Context) ([]string, error) {
+	targets, err := d.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(targets))
+	for _, t := range targets {
+		u, err := d.URL(t)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+// URL answers a target as a server of the input
+func (d *Discoverer) URL(t Target) (string, error) {
+	var buf bytes.Buffer
+	if err := d.url.Execute(&buf, t); err != nil {
+		return "", fmt.Errorf("discovery url of %s: %w", t.Address, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// dnsProvider resolves SRV records, labelling the targets with the record
+type dnsProvider struct {
+	names    []string
+	resolver *net.Resolver
+}
+
+func newDNSProvider(cfg *Config) (*dnsProvider, error) {
+	if len(cfg.Names) == 0 {
+		return nil, fmt.Errorf("dns_srv discovery without names")
+	}
+	return &dnsProvider{names: cfg.Names, resolver: net.DefaultResolver}, nil
+}
+
+func (p *dnsProvider) targets(ctx context.Context) ([]Target, error) {
+	var targets []Target
+	for _, name := range p.names {
+		_, records, err := p.resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", name, err)
+		}
+		for _, r := range records {
+			host := strings.TrimSuffix(r.Target, ".")
+			targets = append(targets, Target{
+				Address: net.JoinHostPort(host, strconv.Itoa(int(r.Port))),
+				Labels:  map[string]string{"srv": name},
+			})
+		}
+	}
+	return targets, nil
+}
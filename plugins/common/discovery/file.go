@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileProvider reads the targets from files, either in the JSON format of
+// the Prometheus file based discovery,
+//
+//	[{"targets": ["10.0.0.1:6379"], "labels": {"role": "cache"}}]
+//
+// or with a target per line, ignoring empty lines and lines starting with #.
+type fileProvider struct {
+	files []string
+}
+
+type fileGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func newFileProvider(cfg *Config) (*fileProvider, error) {
+	if len(cfg.Files) == 0 {
+		return nil, fmt.Errorf("file discovery without files")
+	}
+	return &fileProvider{files: cfg.Files}, nil
+}
+
+func (p *fileProvider) targets(_ context.Context) ([]Target, error) {
+	var targets []Target
+	for _, pattern := range p.files {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("file pattern %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			t, err := readTargets(file)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, t...)
+		}
+	}
+	return targets, nil
+}
+
+func readTargets(file string) ([]Target, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading targets: %w", err)
+	}
+
+	if filepath.Ext(file) == ".json" {
+		var groups []fileGroup
+		if err := json.Unmarshal(data, &groups); err != nil {
+			return nil, fmt.Errorf("parsing targets %s: %w", file, err)
+		}
+		var targets []Target
+		for _, g := range groups {
+			for _, address := range g.Targets {
+				labels := make(map[string]string, len(g.Labels))
+				for k, v := range g.Labels {
+					labels[k] = v
+				}
+				targets = append(targets, Target{Address: address, Labels: labels})
+			}
+		}
+		return targets, nil
+	}
+
+	var targets []Target
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, Target{Address: line, Labels: map[string]string{}})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading targets %s: %w", file, err)
+	}
+	return targets, nil
+}
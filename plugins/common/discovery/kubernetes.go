@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	"github.com/ghodss/yaml"
+)
+
+// kubernetesProvider answers the ready addresses of the endpoints of a
+// service, labelling the targets with the namespace, pod and node
+type kubernetesProvider struct {
+	client    *k8s.Client
+	namespace string
+	service   string
+	port      string
+}
+
+func newKubernetesProvider(cfg *Config) (*kubernetesProvider, error) {
+	if cfg.Service == "" {
+		return nil, fmt.Errorf("kubernetes discovery without service")
+	}
+	client, err := KubernetesClient(cfg.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	return &kubernetesProvider{
+		client:    client,
+		namespace: namespace,
+		service:   cfg.Service,
+		port:      cfg.Port,
+	}, nil
+}
+
+// KubernetesClient answers the in-cluster client or, outside a cluster, the
+// client of the kubeconfig, by default ~/.kube/config
+func KubernetesClient(kubeconfig string) (*k8s.Client, error) {
+	if kubeconfig == "" {
+		if client, err := k8s.NewInClusterClient(); err == nil {
+			return client, nil
+		}
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current user - %w", err)
+		}
+		kubeconfig = filepath.Join(u.HomeDir, ".kube/config")
+	}
+
+	data, err := os.ReadFile(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading '%s': %w", kubeconfig, err)
+	}
+	var config k8s.Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("yaml unmarshal: %w", err)
+	}
+	client, err := k8s.NewClient(&config)
+	if err != nil {
+		return nil, fmt.Errorf("new k8s client: %w", err)
+	}
+	return client, nil
+}
+
+func (p *kubernetesProvider) targets(ctx context.Context) ([]Target, error) {
+	var endpoints corev1.Endpoints
+	if err := p.client.Get(ctx, p.namespace, p.service, &endpoints); err != nil {
+		return nil, fmt.Errorf("endpoints %s/%s: %w", p.namespace, p.service, err)
+	}
+	return endpointTargets(&endpoints, p.namespace, p.service, p.port), nil
+}
+
+// endpointTargets answers the ready addresses with the named port, or the
+// first port of each subset when no port is named
+func endpointTargets(endpoints *corev1.Endpoints, namespace, service, portName string) []Target {
+	var targets []Target
+	for _, subset := range endpoints.GetSubsets() {
+		var port int32
+		for _, p := range subset.GetPorts() {
+			if portName == "" || p.GetName() == portName {
+				port = p.GetPort()
+				break
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, addr := range subset.GetAddresses() {
+			labels := map[string]string{
+				"namespace": namespace,
+				"service":   service,
+			}
+			if node := addr.GetNodeName(); node != "" {
+				labels["node"] = node
+			}
+			if pod := addr.GetTargetRef().GetName(); pod != "" {
+				labels["pod"] = pod
+			}
+			targets = append(targets, Target{
+				Address: net.JoinHostPort(addr.GetIp(), strconv.Itoa(int(port))),
+				Labels:  labels,
+			})
+		}
+	}
+	return targets
+}
@@ -0,0 +1,95 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const defaultConsulKey = "circonus-unified-agent/leader"
+
+// consulLock is a key acquired with a session renewed within its TTL; when
+// the agent stops renewing it, Consul invalidates the session and releases
+// the key after its lock delay
+type consulLock struct {
+	client  *api.Client
+	key     string
+	ttl     string
+	session string
+}
+
+func newConsulLock(cfg *Config, lease time.Duration) (*consulLock, error) {
+	if lease < 10*time.Second {
+		return nil, fmt.Errorf("consul election lease_duration %s, must be at least 10s", lease)
+	}
+	consulConfig := api.DefaultConfig()
+	if cfg.ConsulAddress != "" {
+		consulConfig.Address = cfg.ConsulAddress
+	}
+	if cfg.ConsulToken != "" {
+		consulConfig.Token = cfg.ConsulToken
+	}
+	client, err := api.NewClient(consulConfig)
+	if err != nil {
+		return nil, fmt.Errorf("consul client: %w", err)
+	}
+	key := cfg.Key
+	if key == "" {
+		key = defaultConsulKey
+	}
+	return &consulLock{
+		client: client,
+		key:    key,
+		ttl:    lease.String(),
+	}, nil
+}
+
+func (l *consulLock) acquire(ctx context.Context, id string, _ time.Duration) (bool, error) {
+	opts := (&api.WriteOptions{}).WithContext(ctx)
+	if l.session != "" {
+		entry, _, err := l.client.Session().Renew(l.session, opts)
+		if err != nil {
+			return false, fmt.Errorf("consul session renew: %w", err)
+		}
+		if entry == nil {
+			// invalidated, e.g. not renewed within the TTL
+			l.session = ""
+		}
+	}
+	if l.session == "" {
+		session, _, err := l.client.Session().CreateNoChecks(&api.SessionEntry{
+			Name:     "circonus-unified-agent " + id,
+			TTL:      l.ttl,
+			Behavior: api.SessionBehaviorRelease,
+		}, opts)
+		if err != nil {
+			return false, fmt.Errorf("consul session create: %w", err)
+		}
+		l.session = session
+	}
+
+	held, _, err := l.client.KV().Acquire(&api.KVPair{
+		Key:     l.key,
+		Value:   []byte(id),
+		Session: l.session,
+	}, opts)
+	if err != nil {
+		return false, fmt.Errorf("consul acquire %s: %w", l.key, err)
+	}
+	return held, nil
+}
+
+func (l *consulLock) release(ctx context.Context, _ string) error {
+	if l.session == "" {
+		return nil
+	}
+	opts := (&api.WriteOptions{}).WithContext(ctx)
+	// destroying the session releases the key
+	if _, err := l.client.Session().Destroy(l.session, opts); err != nil {
+		return fmt.Errorf("consul session destroy: %w", err)
+	}
+	l.session = ""
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package election elects one of the agents sharing a lock, a file, a Consul
+// key or a Kubernetes ConfigMap, to run the inputs with cluster_scope, e.g.
+// the vsphere input polling a vCenter every agent of a cluster can reach, so
+// exactly one agent polls it and another takes over when that agent fails.
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
+)
+
+const (
+	defaultLeaseDuration = 15 * time.Second
+	minLeaseDuration     = 3 * time.Second
+	releaseTimeout       = 5 * time.Second
+)
+
+// Config selects the lock the agents are elected with, configured as the
+// election table of the agent.
+type Config struct {
+	// Type of the lock, one of "file", "consul" or "kubernetes"
+	Type string `toml:"type"`
+	// ID identifies the agent holding the lock, by default the hostname
+	ID string `toml:"id"`
+	// LeaseDuration is how long the lock is held without being renewed; the
+	// lock is renewed at a third of it
+	LeaseDuration internal.Duration `toml:"lease_duration"`
+
+	// file: lease file on a filesystem shared by the agents
+	Path string `toml:"path"`
+
+	// consul: key of the lock, held with a session
+	ConsulAddress string `toml:"consul_address"`
+	ConsulToken   string `toml:"consul_token"`
+	Key           string `toml:"key"`
+
+	// kubernetes: ConfigMap holding the lock in an annotation
+	KubeConfig string `toml:"kubeconfig"`
+	Namespace  string `toml:"namespace"`
+	Name       string `toml:"name"`
+}
+
+// lock is a lease held by one agent at a time
+type lock interface {
+	// acquire acquires the lease for id, or renews it when id holds it, and
+	// answers whether id holds it
+	acquire(ctx context.Context, id string, lease time.Duration) (bool, error)
+	// release releases the lease when id holds it
+	release(ctx context.Context, id string) error
+}
+
+// Elector campaigns for the lease of a lock until stopped.  An agent leads
+// while it holds the lease; when the lock cannot be reached it keeps leading
+// until the lease expires, since no other agent acquires it before.
+type Elector struct {
+	id    string
+	lease time.Duration
+	lock  lock
+	log   cua.Logger
+	now   func() time.Time
+
+	leaderStat selfstat.Stat
+
+	mu      sync.Mutex
+	leader  bool
+	renewed time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates the elector of the configuration, identifying the agent by
+// the hostname when no ID is configured.
+func New(cfg *Config, hostname string, log cua.Logger) (*Elector, error) {
+	e := &Elector{
+		id:         cfg.ID,
+		lease:      cfg.LeaseDuration.Duration,
+		log:        log,
+		now:        time.Now,
+		leaderStat: selfstat.Register("agent", "election_leader", map[string]string{}),
+	}
+	if e.id == "" {
+		e.id = hostname
+	}
+	if e.id == "" {
+		return nil, fmt.Errorf("election without id")
+	}
+	if e.lease == 0 {
+		e.lease = defaultLeaseDuration
+	}
+	if e.lease < minLeaseDuration {
+		return nil, fmt.Errorf("election lease_duration %s, must be at least %s", e.lease, minLeaseDuration)
+	}
+
+	var err error
+	switch cfg.Type {
+	case "file":
+		e.lock, err = newFileLock(cfg)
+	case "consul":
+		e.lock, err = newConsulLock(cfg, e.lease)
+	case "kubernetes":
+		e.lock, err = newKubernetesLock(cfg)
+	default:
+		err = fmt.Errorf("unknown election type %q", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ID answers the identity the agent holds the lease with
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// Leader answers whether the agent holds the lease
+func (e *Elector) Leader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading()
+}
+
+func (e *Elector) leading() bool {
+	return e.leader && e.now().Sub(e.renewed) < e.lease
+}
+
+// Start campaigns for the lease once, then again at a third of the lease
+// duration until Stop.
+func (e *Elector) Start(ctx context.Context) {
+	ctx, e.cancel = context.WithCancel(ctx)
+	e.done = make(chan struct{})
+	e.campaign(ctx)
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.campaign(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops campaigning and releases the lease, so another agent takes over
+// without waiting for it to expire.
+func (e *Elector) Stop() {
+	e.cancel()
+	<-e.done
+
+	e.mu.Lock()
+	e.leader = false
+	e.mu.Unlock()
+	e.leaderStat.Set(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := e.lock.release(ctx, e.id); err != nil {
+		e.log.Errorf("Releasing the lease: %v", err)
+	}
+}
+
+// campaign acquires or renews the lease
+func (e *Elector) campaign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.lease/3)
+	defer cancel()
+
+	// the lease is held from before it was acquired, not after
+	start := e.now()
+	held, err := e.lock.acquire(ctx, e.id, e.lease)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	was := e.leading()
+	switch {
+	case err != nil:
+		if ctx.Err() == nil || was {
+			e.log.Errorf("Acquiring the lease: %v", err)
+		}
+	case held:
+		e.leader = true
+		e.renewed = start
+	default:
+		e.leader = false
+	}
+
+	is := e.leading()
+	switch {
+	case is && !was:
+		e.log.Infof("Elected leader as %s, running the cluster scope inputs", e.id)
+		e.leaderStat.Set(1)
+	case !is && was:
+		e.log.Infof("Not the leader anymore, skipping the cluster scope inputs")
+		e.leaderStat.Set(0)
+	}
+}
+
+// record is the lease as stored by the file and kubernetes locks
+type record struct {
+	Holder               string    `json:"holderIdentity"`
+	RenewTime            time.Time `json:"renewTime"`
+	LeaseDurationSeconds int       `json:"leaseDurationSeconds"`
+}
+
+func newRecord(id string, now time.Time, lease time.Duration) []byte {
+	data, _ := json.Marshal(&record{
+		Holder:               id,
+		RenewTime:            now,
+		LeaseDurationSeconds: int((lease + time.Second - 1) / time.Second),
+	})
+	return data
+}
+
+// parseRecord answers the record, an empty one when there is none or it
+// cannot be parsed, so the lock is taken over
+func parseRecord(data []byte) record {
+	var r record
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &r); err != nil {
+			return record{}
+		}
+	}
+	return r
+}
+
+// observer tracks when the record of a lock last changed by the local clock,
+// so the expiry of a lease held by another agent does not depend on the
+// clocks of the agents agreeing
+type observer struct {
+	now  func() time.Time
+	data string
+	at   time.Time
+}
+
+// expired answers whether the lease of the record held by another agent was
+// not renewed for its duration, by default the lease of this agent
+func (o *observer) expired(data []byte, r record, lease time.Duration) bool {
+	if r.Holder == "" {
+		return true
+	}
+	if r.LeaseDurationSeconds > 0 {
+		lease = time.Duration(r.LeaseDurationSeconds) * time.Second
+	}
+	now := o.now()
+	if string(data) != o.data {
+		o.data = string(data)
+		o.at = now
+		return false
+	}
+	return now.Sub(o.at) >= lease
+}
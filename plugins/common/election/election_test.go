@@ -0,0 +1,142 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestFileLock(path string, c *clock) *fileLock {
+	return &fileLock{
+		path: path,
+		now:  c.now,
+		obs:  observer{now: c.now},
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader")
+	ctx := context.Background()
+	lease := 15 * time.Second
+	ca := &clock{t: time.Unix(1600000000, 0)}
+	cb := &clock{t: time.Unix(1600000000, 0)}
+	a := newTestFileLock(path, ca)
+	b := newTestFileLock(path, cb)
+
+	// taken over, held once read back
+	held, err := a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	held, err = b.acquire(ctx, "b", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	ca.t = ca.t.Add(5 * time.Second)
+	held, err = a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// renewed by a, not expired for b whatever its clock
+	cb.t = cb.t.Add(time.Hour)
+	held, err = b.acquire(ctx, "b", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	ca.t = ca.t.Add(5 * time.Second)
+	held, err = a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// not renewed by a for the lease duration
+	cb.t = cb.t.Add(5 * time.Second)
+	held, err = b.acquire(ctx, "b", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	cb.t = cb.t.Add(lease)
+	held, err = b.acquire(ctx, "b", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	held, err = b.acquire(ctx, "b", lease)
+	require.NoError(t, err)
+	require.True(t, held)
+	held, err = a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+
+	// released, taken over right away
+	require.NoError(t, a.release(ctx, "a"))
+	require.FileExists(t, path)
+	require.NoError(t, b.release(ctx, "b"))
+	require.NoFileExists(t, path)
+	held, err = a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.False(t, held)
+	held, err = a.acquire(ctx, "a", lease)
+	require.NoError(t, err)
+	require.True(t, held)
+}
+
+type testLock struct {
+	held bool
+	err  error
+}
+
+func (l *testLock) acquire(context.Context, string, time.Duration) (bool, error) {
+	return l.held, l.err
+}
+
+func (l *testLock) release(context.Context, string) error {
+	l.held = false
+	return nil
+}
+
+func TestElector(t *testing.T) {
+	c := &clock{t: time.Unix(1600000000, 0)}
+	l := &testLock{held: true}
+	e, err := New(&Config{Type: "file", Path: filepath.Join(t.TempDir(), "leader")}, "host", testutil.Logger{})
+	require.NoError(t, err)
+	require.Equal(t, "host", e.ID())
+	e.lock = l
+	e.now = c.now
+
+	ctx := context.Background()
+	e.campaign(ctx)
+	require.True(t, e.Leader())
+
+	// leading while the lock cannot be reached, until the lease expires
+	l.err = errors.New("unreachable")
+	c.t = c.t.Add(10 * time.Second)
+	e.campaign(ctx)
+	require.True(t, e.Leader())
+	c.t = c.t.Add(5 * time.Second)
+	require.False(t, e.Leader())
+
+	l.err = nil
+	e.campaign(ctx)
+	require.True(t, e.Leader())
+	l.held = false
+	e.campaign(ctx)
+	require.False(t, e.Leader())
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&Config{Type: "file"}, "host", testutil.Logger{})
+	require.Error(t, err)
+	_, err = New(&Config{Type: "etcd"}, "host", testutil.Logger{})
+	require.Error(t, err)
+	_, err = New(&Config{Type: "file", Path: "leader"}, "", testutil.Logger{})
+	require.Error(t, err)
+	_, err = New(&Config{Type: "consul"}, "host", testutil.Logger{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,89 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileLock is a lease file on a filesystem shared by the agents, e.g. NFS,
+// replaced by renaming a temporary file.  The file cannot be replaced only
+// when unchanged, so a lease taken over is only held once the next renewal
+// reads it back: of the agents racing for an expired lease, the one which
+// wrote it last.
+type fileLock struct {
+	path string
+	now  func() time.Time
+	obs  observer
+}
+
+func newFileLock(cfg *Config) (*fileLock, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("file election without path")
+	}
+	return &fileLock{
+		path: cfg.Path,
+		now:  time.Now,
+		obs:  observer{now: time.Now},
+	}, nil
+}
+
+func (l *fileLock) acquire(_ context.Context, id string, lease time.Duration) (bool, error) {
+	data, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	r := parseRecord(data)
+	held := r.Holder == id
+	if !held && !l.obs.expired(data, r, lease) {
+		return false, nil
+	}
+	if err := l.write(newRecord(id, l.now(), lease)); err != nil {
+		return false, err
+	}
+	return held, nil
+}
+
+func (l *fileLock) release(_ context.Context, id string) error {
+	data, err := l.read()
+	if err != nil {
+		return err
+	}
+	if parseRecord(data).Holder != id {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing lease file: %w", err)
+	}
+	return nil
+}
+
+// read answers the content of the lease file, none when there is none
+func (l *fileLock) read() ([]byte, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading lease file: %w", err)
+	}
+	return data, nil
+}
+
+func (l *fileLock) write(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), "."+filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing lease file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing lease file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing lease file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("writing lease file: %w", err)
+	}
+	return nil
+}
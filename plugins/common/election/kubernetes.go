@@ -0,0 +1,128 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
+	"github.com/ericchiang/k8s"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+const (
+	defaultConfigMap = "circonus-unified-agent-leader"
+	// leaderAnnotation holds the lease record on the ConfigMap
+	leaderAnnotation = "circonus.com/leader"
+)
+
+// kubernetesLock is an annotation of a ConfigMap, updated with its resource
+// version so only one of the agents racing for the lease succeeds
+type kubernetesLock struct {
+	client    *k8s.Client
+	namespace string
+	name      string
+	now       func() time.Time
+	obs       observer
+}
+
+func newKubernetesLock(cfg *Config) (*kubernetesLock, error) {
+	client, err := discovery.KubernetesClient(cfg.KubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes election: %w", err)
+	}
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = client.Namespace
+	}
+	name := cfg.Name
+	if name == "" {
+		name = defaultConfigMap
+	}
+	return &kubernetesLock{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		now:       time.Now,
+		obs:       observer{now: time.Now},
+	}, nil
+}
+
+func (l *kubernetesLock) acquire(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	var cm corev1.ConfigMap
+	err := l.client.Get(ctx, l.namespace, l.name, &cm)
+	if apiErrorCode(err) == http.StatusNotFound {
+		cm = corev1.ConfigMap{
+			Metadata: &metav1.ObjectMeta{
+				Name:        k8s.String(l.name),
+				Namespace:   k8s.String(l.namespace),
+				Annotations: map[string]string{leaderAnnotation: string(newRecord(id, l.now(), lease))},
+			},
+		}
+		if err := l.client.Create(ctx, &cm); err != nil {
+			if apiErrorCode(err) == http.StatusConflict {
+				// created by another agent
+				return false, nil
+			}
+			return false, fmt.Errorf("configmap %s/%s: %w", l.namespace, l.name, err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("configmap %s/%s: %w", l.namespace, l.name, err)
+	}
+
+	if cm.Metadata == nil {
+		cm.Metadata = &metav1.ObjectMeta{}
+	}
+	data := []byte(cm.Metadata.GetAnnotations()[leaderAnnotation])
+	r := parseRecord(data)
+	if r.Holder != id && !l.obs.expired(data, r, lease) {
+		return false, nil
+	}
+	if cm.Metadata.Annotations == nil {
+		cm.Metadata.Annotations = make(map[string]string)
+	}
+	cm.Metadata.Annotations[leaderAnnotation] = string(newRecord(id, l.now(), lease))
+	if err := l.client.Update(ctx, &cm); err != nil {
+		if apiErrorCode(err) == http.StatusConflict {
+			// updated by another agent since read
+			return false, nil
+		}
+		return false, fmt.Errorf("configmap %s/%s: %w", l.namespace, l.name, err)
+	}
+	return true, nil
+}
+
+func (l *kubernetesLock) release(ctx context.Context, id string) error {
+	var cm corev1.ConfigMap
+	if err := l.client.Get(ctx, l.namespace, l.name, &cm); err != nil {
+		if apiErrorCode(err) == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("configmap %s/%s: %w", l.namespace, l.name, err)
+	}
+	annotations := cm.GetMetadata().GetAnnotations()
+	if parseRecord([]byte(annotations[leaderAnnotation])).Holder != id {
+		return nil
+	}
+	// an empty holder is taken over right away
+	annotations[leaderAnnotation] = string(newRecord("", l.now(), 0))
+	if err := l.client.Update(ctx, &cm); err != nil {
+		return fmt.Errorf("configmap %s/%s: %w", l.namespace, l.name, err)
+	}
+	return nil
+}
+
+// apiErrorCode answers the HTTP status code of a kubernetes API error, 0 for
+// none
+func apiErrorCode(err error) int {
+	var apiErr *k8s.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
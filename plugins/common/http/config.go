@@ -0,0 +1,183 @@
+// Package httpconfig is the common configuration of the HTTP clients of
+// plugins: timeouts, connection reuse, authentication, proxy and TLS.
+package httpconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/proxy"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	krbclient "gopkg.in/jcmturner/gokrb5.v7/client"
+	krbconfig "gopkg.in/jcmturner/gokrb5.v7/config"
+	"gopkg.in/jcmturner/gokrb5.v7/keytab"
+	"gopkg.in/jcmturner/gokrb5.v7/spnego"
+)
+
+const defaultKerberosConfig = "/etc/krb5.conf"
+
+// HTTPClientConfig is embedded by plugins to configure their HTTP client.
+type HTTPClientConfig struct {
+	// Timeout of a request, including reading the response body
+	Timeout internal.Duration `toml:"timeout"`
+	// ConnectTimeout of establishing a connection, including the TLS handshake
+	ConnectTimeout internal.Duration `toml:"connect_timeout"`
+	// ResponseTimeout of waiting for the response headers after sending the
+	// request
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+
+	// IdleConnTimeout closes idle keep-alive connections after this long
+	IdleConnTimeout     internal.Duration `toml:"idle_conn_timeout"`
+	MaxIdleConns        int               `toml:"max_idle_conn"`
+	MaxIdleConnsPerHost int               `toml:"max_idle_conn_per_host"`
+	DisableKeepAlives   bool              `toml:"disable_keep_alives"`
+
+	// HTTP Basic Auth credentials
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	// BearerToken is the path of a file with the token, read for each
+	// request so a rotated token is used right away
+	BearerToken string `toml:"bearer_token"`
+
+	// Kerberos (SPNEGO) authentication of the principal, user@REALM, with
+	// the keys of the keytab.  KerberosSPN is the service principal name of
+	// the servers, HTTP/<host of the url> by default.
+	KerberosPrincipal string `toml:"kerberos_principal"`
+	KerberosKeytab    string `toml:"kerberos_keytab"`
+	KerberosConfig    string `toml:"kerberos_config"`
+	KerberosSPN       string `toml:"kerberos_spn"`
+
+	// OAuth2 Client Credentials Grant
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	TokenURL     string   `toml:"token_url"`
+	Scopes       []string `toml:"scopes"`
+
+	proxy.HTTPProxy
+	tls.ClientConfig
+}
+
+// CreateClient returns a client with the configuration.  The context is used
+// for fetching OAuth2 tokens.
+func (c *HTTPClientConfig) CreateClient(ctx context.Context) (*http.Client, error) {
+	tlsCfg, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	proxy, err := c.HTTPProxy.Proxy()
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		TLSClientConfig:       tlsCfg,
+		TLSHandshakeTimeout:   c.ConnectTimeout.Duration,
+		ResponseHeaderTimeout: c.ResponseTimeout.Duration,
+		IdleConnTimeout:       c.IdleConnTimeout.Duration,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		DisableKeepAlives:     c.DisableKeepAlives,
+	}
+	if c.ConnectTimeout.Duration > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   c.ConnectTimeout.Duration,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+
+	auth := &authTransport{config: c, base: transport}
+	if c.KerberosKeytab != "" {
+		auth.kerberos, err = c.kerberosClient()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := &http.Client{
+		Transport: auth,
+		Timeout:   c.Timeout.Duration,
+	}
+
+	if c.ClientID != "" && c.ClientSecret != "" && c.TokenURL != "" {
+		oauthConfig := clientcredentials.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			TokenURL:     c.TokenURL,
+			Scopes:       c.Scopes,
+		}
+		// the token requests use the same transport
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+		client = oauthConfig.Client(ctx)
+	}
+
+	return client, nil
+}
+
+// kerberosClient returns the client logging in with the keytab, it logs in
+// with the first request, so the KDC need not be reachable on startup.
+func (c *HTTPClientConfig) kerberosClient() (*krbclient.Client, error) {
+	parts := strings.SplitN(c.KerberosPrincipal, "@", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid kerberos_principal %q, must be user@REALM", c.KerberosPrincipal)
+	}
+
+	configFile := c.KerberosConfig
+	if configFile == "" {
+		configFile = defaultKerberosConfig
+	}
+	krbConfig, err := krbconfig.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading kerberos config %s: %w", configFile, err)
+	}
+
+	kt, err := keytab.Load(c.KerberosKeytab)
+	if err != nil {
+		return nil, fmt.Errorf("loading keytab %s: %w", c.KerberosKeytab, err)
+	}
+
+	return krbclient.NewClientWithKeytab(parts[0], parts[1], kt, krbConfig,
+		krbclient.DisablePAFXFAST(true)), nil
+}
+
+// authTransport adds the configured credentials to requests without an
+// Authorization header.
+type authTransport struct {
+	config   *HTTPClientConfig
+	kerberos *krbclient.Client
+	base     http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req) //nolint:wrapcheck
+	}
+
+	switch {
+	case t.kerberos != nil:
+		req = req.Clone(req.Context())
+		if err := spnego.SetSPNEGOHeader(t.kerberos, req, t.config.KerberosSPN); err != nil {
+			return nil, fmt.Errorf("kerberos authentication: %w", err)
+		}
+	case t.config.BearerToken != "":
+		token, err := os.ReadFile(t.config.BearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("reading bearer token: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	case t.config.Username != "" || t.config.Password != "":
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	return t.base.RoundTrip(req) //nolint:wrapcheck
+}
@@ -0,0 +1,83 @@
+package httpconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateClientAuth(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	get := func(c *HTTPClientConfig, header string) string {
+		client, err := c.CreateClient(context.Background())
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return auth
+	}
+
+	require.Empty(t, get(&HTTPClientConfig{}, ""))
+	require.Equal(t, "Basic dXNlcjpzZWNyZXQ=", get(&HTTPClientConfig{Username: "user", Password: "secret"}, ""))
+	// headers set by the plugin take precedence
+	require.Equal(t, "Custom", get(&HTTPClientConfig{Username: "user", Password: "secret"}, "Custom"))
+
+	token := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(token, []byte("abc\n"), 0600))
+	c := &HTTPClientConfig{BearerToken: token}
+	require.Equal(t, "Bearer abc", get(c, ""))
+	// a rotated token is used right away
+	require.NoError(t, os.WriteFile(token, []byte("def\n"), 0600))
+	require.Equal(t, "Bearer def", get(c, ""))
+}
+
+func TestCreateClientResponseTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	c := &HTTPClientConfig{ResponseTimeout: internal.Duration{Duration: 10 * time.Millisecond}}
+	client, err := c.CreateClient(context.Background())
+	require.NoError(t, err)
+	_, err = client.Get(ts.URL) //nolint:bodyclose,noctx // the request fails
+	require.Error(t, err)
+}
+
+func TestCreateClientKerberos(t *testing.T) {
+	dir := t.TempDir()
+	krb5conf := filepath.Join(dir, "krb5.conf")
+	require.NoError(t, os.WriteFile(krb5conf, []byte("[libdefaults]\n  default_realm = EXAMPLE.COM\n"), 0600))
+
+	c := &HTTPClientConfig{
+		KerberosPrincipal: "cua",
+		KerberosKeytab:    filepath.Join(dir, "cua.keytab"),
+		KerberosConfig:    krb5conf,
+	}
+	_, err := c.CreateClient(context.Background())
+	require.Error(t, err)
+
+	c.KerberosPrincipal = "cua@EXAMPLE.COM"
+	_, err = c.CreateClient(context.Background())
+	require.Error(t, err, "missing keytab")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/Shopify/sarama"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/proxy"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 )
 
@@ -33,6 +34,10 @@ type WriteConfig struct {
 func (k *WriteConfig) SetConfig(config *sarama.Config) error {
 	config.Producer.Return.Successes = true
 	config.Producer.Idempotent = k.IdempotentWrites
+	if k.IdempotentWrites {
+		// sarama refuses idempotent producers with requests in flight
+		config.Net.MaxOpenRequests = 1
+	}
 	config.Producer.Retry.Max = k.MaxRetry
 	if k.MaxMessageBytes > 0 {
 		config.Producer.MaxMessageBytes = k.MaxMessageBytes
@@ -44,6 +49,7 @@ func (k *WriteConfig) SetConfig(config *sarama.Config) error {
 // Config common to all Kafka clients.
 type Config struct {
 	tls.ClientConfig
+	proxy.TCPProxy
 	Version  string `toml:"version"`
 	ClientID string `toml:"client_id"`
 	SASLAuth
@@ -79,6 +85,15 @@ func (k *Config) SetConfig(config *sarama.Config) error {
 		config.Net.TLS.Enable = true
 	}
 
+	if k.ProxyURL != "" {
+		dialer, err := k.TCPProxy.Dialer(config.Net.DialTimeout)
+		if err != nil {
+			return fmt.Errorf("proxy: %w", err)
+		}
+		config.Net.Proxy.Enable = true
+		config.Net.Proxy.Dialer = dialer
+	}
+
 	if err := k.SetSASLConfig(config); err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+// Package netns gathers from Linux network namespaces, e.g. of containers or
+// of VRFs, by running functions in them.
+package netns
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+)
+
+// TagKey is the tag holding the name of the namespace of a metric
+const TagKey = "namespace"
+
+// ThreadProcNet is the proc directory of the network namespace of the
+// calling thread, /proc/net shows the namespace of the main thread
+const ThreadProcNet = "/proc/thread-self/net"
+
+// DefaultDirs holds the named namespaces of "ip netns"
+var DefaultDirs = []string{"/var/run/netns"}
+
+// Config selects the network namespaces an input gathers from in addition
+// to the host namespace.
+type Config struct {
+	Namespaces    []string `toml:"namespaces"`
+	NamespaceDirs []string `toml:"namespace_dirs"`
+
+	filter filter.Filter
+}
+
+// Namespace is a named network namespace
+type Namespace struct {
+	Name string
+	Path string
+}
+
+// Enabled answers whether namespaces are configured
+func (c *Config) Enabled() bool {
+	return len(c.Namespaces) > 0
+}
+
+// List answers the configured namespaces present, sorted by name
+func (c *Config) List() ([]Namespace, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	if c.filter == nil {
+		f, err := filter.Compile(c.Namespaces)
+		if err != nil {
+			return nil, fmt.Errorf("namespaces: %w", err)
+		}
+		c.filter = f
+	}
+	dirs := c.NamespaceDirs
+	if len(dirs) == 0 {
+		dirs = DefaultDirs
+	}
+
+	var namespaces []Namespace
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("namespaces: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !c.filter.Match(entry.Name()) {
+				continue
+			}
+			namespaces = append(namespaces, Namespace{
+				Name: entry.Name(),
+				Path: filepath.Join(dir, entry.Name()),
+			})
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	return namespaces, nil
+}
@@ -0,0 +1,43 @@
+package netns
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// Do runs fn on a thread switched to the namespace, so reads of
+// ThreadProcNet, sysctls and netlink show the namespace
+func Do(ns Namespace, fn func() error) error {
+	runtime.LockOSThread()
+
+	origin, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("open current namespace: %w", err)
+	}
+	defer unix.Close(origin)
+
+	target, err := unix.Open(ns.Path, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("open namespace %s: %w", ns.Name, err)
+	}
+	defer unix.Close(target)
+
+	if err := unix.Setns(target, unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("enter namespace %s: %w", ns.Name, err)
+	}
+
+	fnErr := fn()
+
+	if err := unix.Setns(origin, unix.CLONE_NEWNET); err != nil {
+		// keep the thread locked, it is terminated with the goroutine
+		// rather than reused in the wrong namespace
+		return fmt.Errorf("leave namespace %s: %w", ns.Name, err)
+	}
+	runtime.UnlockOSThread()
+	return fnErr
+}
@@ -0,0 +1,28 @@
+package netns
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestDo(t *testing.T) {
+	ns := Namespace{Name: "self", Path: "/proc/self/ns/net"}
+
+	ran := false
+	err := Do(ns, func() error {
+		ran = true
+		_, err := os.Stat(ThreadProcNet + "/dev")
+		return err
+	})
+	if errors.Is(err, unix.EPERM) {
+		t.Skip("entering network namespaces requires CAP_SYS_ADMIN")
+	}
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	require.Error(t, Do(Namespace{Name: "missing", Path: "/nonexistent"}, func() error { return nil }))
+}
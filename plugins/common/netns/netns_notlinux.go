@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package netns
+
+import "errors"
+
+// Do is only supported on Linux
+func Do(ns Namespace, fn func() error) error {
+	return errors.New("network namespaces are only supported on Linux")
+}
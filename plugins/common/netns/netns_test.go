@@ -0,0 +1,40 @@
+package netns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	ipDir := t.TempDir()
+	dockerDir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(ipDir, "vrf-red"),
+		filepath.Join(ipDir, "vrf-blue"),
+		filepath.Join(ipDir, "mgmt"),
+		filepath.Join(dockerDir, "vrf-green"),
+	} {
+		require.NoError(t, os.WriteFile(path, nil, 0600))
+	}
+
+	c := &Config{}
+	require.False(t, c.Enabled())
+	namespaces, err := c.List()
+	require.NoError(t, err)
+	require.Empty(t, namespaces)
+
+	c = &Config{
+		Namespaces:    []string{"vrf-*"},
+		NamespaceDirs: []string{ipDir, dockerDir, filepath.Join(ipDir, "missing")},
+	}
+	namespaces, err = c.List()
+	require.NoError(t, err)
+	require.Equal(t, []Namespace{
+		{Name: "vrf-blue", Path: filepath.Join(ipDir, "vrf-blue")},
+		{Name: "vrf-green", Path: filepath.Join(dockerDir, "vrf-green")},
+		{Name: "vrf-red", Path: filepath.Join(ipDir, "vrf-red")},
+	}, namespaces)
+}
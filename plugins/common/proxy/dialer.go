@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// TCPProxy configures a proxy for plugins connecting with protocols other
+// than HTTP, e.g. redis or kafka.  The URL selects the kind of proxy:
+// socks5://[user:password@]host:port for a SOCKS5 proxy, resolving names on
+// the proxy, or http://[user:password@]host:port for an HTTP proxy
+// supporting the CONNECT method.
+type TCPProxy struct {
+	ProxyURL string `toml:"proxy_url"`
+}
+
+// Dialer makes connections, directly or through a proxy.  It satisfies the
+// Dialer and ContextDialer interfaces of golang.org/x/net/proxy.
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Dialer answers a dialer connecting through the configured proxy, or
+// directly when no proxy is configured.  The timeout applies to connecting
+// to the proxy or the address.
+func (p *TCPProxy) Dialer(timeout time.Duration) (Dialer, error) {
+	direct := &net.Dialer{Timeout: timeout}
+	if p.ProxyURL == "" {
+		return direct, nil
+	}
+
+	u, err := url.Parse(p.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing proxy url %q: %w", p.ProxyURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy url %q without host", p.ProxyURL)
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+		d, err := proxy.SOCKS5("tcp", u.Host, auth, direct)
+		if err != nil {
+			return nil, fmt.Errorf("socks5 proxy %q: %w", u.Host, err)
+		}
+		cd, ok := d.(Dialer)
+		if !ok {
+			return nil, fmt.Errorf("socks5 proxy %q: dialer without context support", u.Host)
+		}
+		return cd, nil
+	case "http":
+		return &connectDialer{proxy: u, forward: direct}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use socks5 or http", u.Scheme)
+	}
+}
+
+// connectDialer tunnels connections through an HTTP proxy using CONNECT
+type connectDialer struct {
+	proxy   *url.URL
+	forward *net.Dialer
+}
+
+func (d *connectDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+func (d *connectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("network %q not supported by http proxy", network)
+	}
+
+	conn, err := d.forward.DialContext(ctx, "tcp", d.proxy.Host)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to proxy %q: %w", d.proxy.Host, err)
+	}
+
+	// the handshake is bounded by the context as well as the dial
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else if d.forward.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(d.forward.Timeout))
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.proxy.User != nil {
+		password, _ := d.proxy.User.Password()
+		credentials := d.proxy.User.Username() + ":" + password
+		req.Header.Set("Proxy-Authorization",
+			"Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("writing CONNECT to proxy %q: %w", d.proxy.Host, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading CONNECT response from proxy %q: %w", d.proxy.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %q refused CONNECT to %s: %s", d.proxy.Host, addr, resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn hands out data the proxy sent along with its response
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
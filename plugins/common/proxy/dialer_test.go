@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listen serves each accepted connection with handle until the test ends
+func listen(t *testing.T, handle func(net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func echo(conn net.Conn) {
+	_, _ = io.Copy(conn, conn)
+}
+
+func tunnel(conn net.Conn, addr string) {
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+	go func() { _, _ = io.Copy(target, conn) }()
+	_, _ = io.Copy(conn, target)
+}
+
+func requireEcho(t *testing.T, d Dialer, addr string) {
+	conn, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+}
+
+func TestDialerDirect(t *testing.T) {
+	target := listen(t, echo)
+	d, err := (&TCPProxy{}).Dialer(time.Second)
+	require.NoError(t, err)
+	requireEcho(t, d, target)
+}
+
+func TestDialerHTTPConnect(t *testing.T) {
+	target := listen(t, echo)
+	proxyAddr := listen(t, func(conn net.Conn) {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		if req.Method != http.MethodConnect || req.Host != target {
+			_, _ = conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+			return
+		}
+		user, password, ok := (&http.Request{Header: http.Header{
+			"Authorization": req.Header["Proxy-Authorization"],
+		}}).BasicAuth()
+		if !ok || user != "user" || password != "secret" {
+			_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		tunnel(conn, target)
+	})
+
+	d, err := (&TCPProxy{ProxyURL: "http://user:secret@" + proxyAddr}).Dialer(time.Second)
+	require.NoError(t, err)
+	requireEcho(t, d, target)
+
+	d, err = (&TCPProxy{ProxyURL: "http://user:wrong@" + proxyAddr}).Dialer(time.Second)
+	require.NoError(t, err)
+	_, err = d.Dial("tcp", target)
+	require.Error(t, err)
+}
+
+func TestDialerSOCKS5(t *testing.T) {
+	target := listen(t, echo)
+	proxyAddr := listen(t, func(conn net.Conn) {
+		// greeting: version, methods; answer no authentication
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, make([]byte, buf[1])); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{5, 0})
+
+		// request: version, command, reserved, address type, address, port
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		var host string
+		switch req[3] {
+		case 1:
+			ip := make([]byte, 4)
+			if _, err := io.ReadFull(conn, ip); err != nil {
+				return
+			}
+			host = net.IP(ip).String()
+		case 3:
+			l := make([]byte, 1)
+			if _, err := io.ReadFull(conn, l); err != nil {
+				return
+			}
+			name := make([]byte, l[0])
+			if _, err := io.ReadFull(conn, name); err != nil {
+				return
+			}
+			host = string(name)
+		default:
+			return
+		}
+		port := make([]byte, 2)
+		if _, err := io.ReadFull(conn, port); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		tunnel(conn, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	})
+
+	d, err := (&TCPProxy{ProxyURL: "socks5://" + proxyAddr}).Dialer(time.Second)
+	require.NoError(t, err)
+	requireEcho(t, d, target)
+}
+
+func TestDialerInvalidURL(t *testing.T) {
+	_, err := (&TCPProxy{ProxyURL: "ftp://localhost:21"}).Dialer(time.Second)
+	require.Error(t, err)
+	_, err = (&TCPProxy{ProxyURL: "socks5://"}).Dialer(time.Second)
+	require.Error(t, err)
+}
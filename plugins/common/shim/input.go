@@ -47,6 +47,11 @@ func (s *Shim) RunInput(ctx context.Context, pollInterval time.Duration) error {
 		if serviceInput, ok := s.Input.(cua.ServiceInput); ok {
 			serviceInput.Stop()
 		}
+		if closingInput, ok := s.Input.(cua.ClosingInput); ok {
+			if err := closingInput.Close(); err != nil {
+				fmt.Fprintf(s.stderr, "failed to close input: %s\n", err)
+			}
+		}
 		// closing the metric channel gracefully stops writing to stdout
 		close(s.metricCh)
 	}()
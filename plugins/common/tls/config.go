@@ -10,10 +10,14 @@ import (
 
 // ClientConfig represents the standard client TLS config.
 type ClientConfig struct {
-	TLSCA              string `toml:"tls_ca"`
-	TLSCert            string `toml:"tls_cert"`
-	TLSKey             string `toml:"tls_key"`
-	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+	TLSCA              string   `toml:"tls_ca"`
+	TLSCert            string   `toml:"tls_cert"`
+	TLSKey             string   `toml:"tls_key"`
+	TLSServerName      string   `toml:"tls_server_name"`
+	TLSMinVersion      string   `toml:"tls_min_version"`
+	TLSMaxVersion      string   `toml:"tls_max_version"`
+	TLSCipherSuites    []string `toml:"tls_cipher_suites"`
+	InsecureSkipVerify bool     `toml:"insecure_skip_verify"`
 }
 
 // ServerConfig represents the standard server TLS config.
@@ -34,13 +38,15 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify &&
+		c.TLSServerName == "" && c.TLSMinVersion == "" && c.TLSMaxVersion == "" && len(c.TLSCipherSuites) == 0 {
 		return nil, nil
 	}
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // G402
 		Renegotiation:      tls.RenegotiateNever,
+		ServerName:         c.TLSServerName,
 	}
 
 	if c.TLSCA != "" {
@@ -52,10 +58,23 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	}
 
 	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
+		certs, err := newCertReloader(c.TLSCert, c.TLSKey)
 		if err != nil {
 			return nil, err
 		}
+		// the certificate is reloaded when the files change
+		tlsConfig.Certificates = []tls.Certificate{*certs.certificate()}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certs.certificate(), nil
+		}
+	}
+
+	if err := setVersionsAndCiphers(tlsConfig, c.TLSMinVersion, c.TLSMaxVersion, c.TLSCipherSuites); err != nil {
+		return nil, err
+	}
+
+	if err := ApplyFIPS(tlsConfig); err != nil {
+		return nil, err
 	}
 
 	return tlsConfig, nil
@@ -80,42 +99,22 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	}
 
 	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
+		certs, err := newCertReloader(c.TLSCert, c.TLSKey)
 		if err != nil {
 			return nil, err
 		}
-	}
-
-	if len(c.TLSCipherSuites) != 0 {
-		cipherSuites, err := ParseCiphers(c.TLSCipherSuites)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse server cipher suites %s: %w", strings.Join(c.TLSCipherSuites, ","), err)
+		// no static certificate, so the reloaded one is used for all clients
+		tlsConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certs.certificate(), nil
 		}
-		tlsConfig.CipherSuites = cipherSuites
 	}
 
-	if c.TLSMaxVersion != "" {
-		version, err := ParseTLSVersion(c.TLSMaxVersion)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse tls max version %q: %w", c.TLSMaxVersion, err)
-		}
-		tlsConfig.MaxVersion = version
+	if err := setVersionsAndCiphers(tlsConfig, c.TLSMinVersion, c.TLSMaxVersion, c.TLSCipherSuites); err != nil {
+		return nil, err
 	}
 
-	if c.TLSMinVersion != "" {
-		version, err := ParseTLSVersion(c.TLSMinVersion)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse tls min version %q: %w", c.TLSMinVersion, err)
-		}
-		tlsConfig.MinVersion = version
-	}
-
-	if tlsConfig.MinVersion != 0 && tlsConfig.MaxVersion != 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
-		return nil, fmt.Errorf(
-			"tls min version %q can't be greater than tls max version %q", tlsConfig.MinVersion, tlsConfig.MaxVersion)
+	if err := ApplyFIPS(tlsConfig); err != nil {
+		return nil, err
 	}
 
 	return tlsConfig, nil
@@ -138,14 +137,39 @@ func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 	return pool, nil
 }
 
-func loadCertificate(config *tls.Config, certFile, keyFile string) error {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
+// setVersionsAndCiphers sets the configured TLS versions and cipher suites
+func setVersionsAndCiphers(tlsConfig *tls.Config, minVersion, maxVersion string, ciphers []string) error {
+	if len(ciphers) != 0 {
+		cipherSuites, err := ParseCiphers(ciphers)
+		if err != nil {
+			return fmt.Errorf(
+				"could not parse cipher suites %s: %w", strings.Join(ciphers, ","), err)
+		}
+		tlsConfig.CipherSuites = cipherSuites
+	}
+
+	if maxVersion != "" {
+		version, err := ParseTLSVersion(maxVersion)
+		if err != nil {
+			return fmt.Errorf(
+				"could not parse tls max version %q: %w", maxVersion, err)
+		}
+		tlsConfig.MaxVersion = version
+	}
+
+	if minVersion != "" {
+		version, err := ParseTLSVersion(minVersion)
+		if err != nil {
+			return fmt.Errorf(
+				"could not parse tls min version %q: %w", minVersion, err)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if tlsConfig.MinVersion != 0 && tlsConfig.MaxVersion != 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
 		return fmt.Errorf(
-			"could not load keypair %s:%s: %w", certFile, keyFile, err)
+			"tls min version %q can't be greater than tls max version %q", minVersion, maxVersion)
 	}
 
-	config.Certificates = []tls.Certificate{cert}
-	config.BuildNameToCertificate()
 	return nil
 }
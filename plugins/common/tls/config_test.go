@@ -1,11 +1,16 @@
 package tls_test
 
 import (
+	cryptotls "crypto/tls"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/require"
@@ -271,6 +276,21 @@ func TestServerConfig(t *testing.T) {
 	}
 }
 
+// startTLSServer starts a test server using the TLS config as is, the server
+// certificate of StartTLS would take precedence over a reloaded one.
+func startTLSServer(config *cryptotls.Config) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.Listener = cryptotls.NewListener(ts.Listener, config)
+	ts.Start()
+	return ts
+}
+
+func tlsURL(ts *httptest.Server) string {
+	return strings.Replace(ts.URL, "http://", "https://", 1)
+}
+
 func TestConnect(t *testing.T) {
 	clientConfig := tls.ClientConfig{
 		TLSCA:   pki.CACertPath(),
@@ -287,12 +307,7 @@ func TestConnect(t *testing.T) {
 	serverTLSConfig, err := serverConfig.TLSConfig()
 	require.NoError(t, err)
 
-	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}))
-	ts.TLS = serverTLSConfig
-
-	ts.StartTLS()
+	ts := startTLSServer(serverTLSConfig)
 	defer ts.Close()
 
 	clientTLSConfig, err := clientConfig.TLSConfig()
@@ -305,7 +320,116 @@ func TestConnect(t *testing.T) {
 		Timeout: 10 * time.Second,
 	}
 
-	resp, err := client.Get(ts.URL)
+	resp, err := client.Get(tlsURL(ts))
 	require.NoError(t, err)
 	require.Equal(t, 200, resp.StatusCode)
 }
+
+func TestFIPSMode(t *testing.T) {
+	fips.Set(true)
+	defer fips.Set(false)
+
+	serverConfig := tls.ServerConfig{
+		TLSCert:           pki.ServerCertPath(),
+		TLSKey:            pki.ServerKeyPath(),
+		TLSAllowedCACerts: []string{pki.CACertPath()},
+		TLSCipherSuites:   []string{pki.CipherSuite()},
+	}
+	_, err := serverConfig.TLSConfig()
+	require.Error(t, err)
+
+	serverConfig.TLSCipherSuites = nil
+	serverConfig.TLSMinVersion = pki.TLSMinVersion()
+	_, err = serverConfig.TLSConfig()
+	require.Error(t, err)
+
+	serverConfig.TLSMinVersion = ""
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(cryptotls.VersionTLS12), serverTLSConfig.MinVersion)
+	require.NotEmpty(t, serverTLSConfig.CipherSuites)
+
+	clientConfig := tls.ClientConfig{
+		TLSCA:   pki.CACertPath(),
+		TLSCert: pki.ClientCertPath(),
+		TLSKey:  pki.ClientKeyPath(),
+	}
+	clientTLSConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, serverTLSConfig.CipherSuites, clientTLSConfig.CipherSuites)
+
+	ts := startTLSServer(serverTLSConfig)
+	defer ts.Close()
+
+	client := http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: clientTLSConfig,
+		},
+		Timeout: 10 * time.Second,
+	}
+	resp, err := client.Get(tlsURL(ts))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	copyKeyPair := func(cert, key string, mod time.Time) {
+		for src, dst := range map[string]string{cert: certFile, key: keyFile} {
+			data, err := os.ReadFile(src)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(dst, data, 0600))
+			require.NoError(t, os.Chtimes(dst, mod, mod))
+		}
+	}
+	now := time.Now()
+	copyKeyPair(pki.ClientCertPath(), pki.ClientKeyPath(), now)
+
+	clientConfig := tls.ClientConfig{TLSCert: certFile, TLSKey: keyFile}
+	tlsConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+
+	client, err := cryptotls.LoadX509KeyPair(pki.ClientCertPath(), pki.ClientKeyPath())
+	require.NoError(t, err)
+	cert, err := tlsConfig.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, client.Certificate, cert.Certificate)
+
+	// rotated
+	copyKeyPair(pki.ServerCertPath(), pki.ServerKeyPath(), now.Add(time.Hour))
+	server, err := cryptotls.LoadX509KeyPair(pki.ServerCertPath(), pki.ServerKeyPath())
+	require.NoError(t, err)
+	cert, err = tlsConfig.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, server.Certificate, cert.Certificate)
+
+	// invalid files keep the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0600))
+	require.NoError(t, os.Chtimes(keyFile, now.Add(2*time.Hour), now.Add(2*time.Hour)))
+	cert, err = tlsConfig.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, server.Certificate, cert.Certificate)
+}
+
+func TestClientConfigVersions(t *testing.T) {
+	clientConfig := tls.ClientConfig{
+		TLSServerName:   "example.org",
+		TLSMinVersion:   "TLS12",
+		TLSMaxVersion:   "TLS13",
+		TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	tlsConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, "example.org", tlsConfig.ServerName)
+	require.Equal(t, uint16(cryptotls.VersionTLS12), tlsConfig.MinVersion)
+	require.Equal(t, uint16(cryptotls.VersionTLS13), tlsConfig.MaxVersion)
+	require.Equal(t, []uint16{cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+
+	clientConfig.TLSMinVersion = "TLS13"
+	clientConfig.TLSMaxVersion = "TLS12"
+	_, err = clientConfig.TLSConfig()
+	require.Error(t, err)
+}
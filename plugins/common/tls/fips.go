@@ -0,0 +1,79 @@
+package tls
+
+import (
+	"crypto/tls"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
+)
+
+// fipsCiphers are the FIPS approved cipher suites, the ones with AES-GCM.
+// The TLS 1.3 suites are not configurable, they are accepted for
+// completeness.
+var fipsCiphers = []string{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_AES_128_GCM_SHA256",
+	"TLS_AES_256_GCM_SHA384",
+}
+
+// ApplyFIPS restricts a config to TLS 1.2 and later, the approved cipher
+// suites and the NIST curves in fips mode.  Configured versions or cipher
+// suites that are not approved are rejected.  Plugins building a tls.Config
+// themselves apply it as well.
+func ApplyFIPS(config *tls.Config) error {
+	if !fips.Enabled() {
+		return nil
+	}
+
+	if config.MinVersion != 0 && config.MinVersion < tls.VersionTLS12 {
+		return fips.NotApproved("tls min version", tlsVersionName(config.MinVersion))
+	}
+	if config.MaxVersion != 0 && config.MaxVersion < tls.VersionTLS12 {
+		return fips.NotApproved("tls max version", tlsVersionName(config.MaxVersion))
+	}
+	config.MinVersion = tls.VersionTLS12
+
+	approved, _ := ParseCiphers(fipsCiphers)
+	if len(config.CipherSuites) == 0 {
+		config.CipherSuites = approved
+	}
+	for _, id := range config.CipherSuites {
+		if !containsCipher(approved, id) {
+			return fips.NotApproved("tls cipher suite", cipherName(id))
+		}
+	}
+
+	config.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+	return nil
+}
+
+func containsCipher(ids []uint16, id uint16) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func cipherName(id uint16) string {
+	for name, v := range tlsCipherMap {
+		if v == id {
+			return name
+		}
+	}
+	return tls.CipherSuiteName(id)
+}
+
+func tlsVersionName(version uint16) string {
+	for name, v := range tlsVersionMap {
+		if v == version {
+			return name
+		}
+	}
+	return "unknown"
+}
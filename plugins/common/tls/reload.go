@@ -0,0 +1,83 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader holds a key pair and loads it again when its files change on
+// disk, e.g. when rotated by cert-manager, so connections made after the
+// rotation use the new certificate without restarting the agent.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(certMod, keyMod); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// certificate answers the current certificate, reloading it when the files
+// changed since it was loaded.  When reloading fails, e.g. while the files
+// are being replaced, the previous certificate is kept and reloading is
+// retried with the next connection.
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		log.Printf("E! [tls] Checking certificate %s: %v", r.certFile, err)
+		return r.cert
+	}
+	if certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert
+	}
+	if err := r.load(certMod, keyMod); err != nil {
+		log.Printf("E! [tls] Reloading certificate, keeping the previous one: %v", err)
+		return r.cert
+	}
+	log.Printf("I! [tls] Reloaded certificate %s", r.certFile)
+	return r.cert
+}
+
+func (r *certReloader) load(certMod, keyMod time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf(
+			"could not load keypair %s:%s: %w", r.certFile, r.keyFile, err)
+	}
+	r.cert = &cert
+	r.certMod = certMod
+	r.keyMod = keyMod
+	return nil
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not read certificate: %w", err)
+	}
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not read key: %w", err)
+	}
+	return cert.ModTime(), key.ModTime(), nil
+}
@@ -99,6 +99,9 @@ func (a *Aerospike) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 		if tlsConfig == nil && (a.EnableTLS || a.EnableSSL) {
 			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			if err := tlsint.ApplyFIPS(tlsConfig); err != nil {
+				return fmt.Errorf("TLSConfig: %w", err)
+			}
 		}
 		a.tlsConfig = tlsConfig
 		a.initialized = true
@@ -1,14 +1,20 @@
+//go:build !lite
+// +build !lite
+
 package all
 
 //nolint:golint
 import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/activemq"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/aerospike"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/amd_rocm_smi"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/amqp_consumer"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/apache"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/apcupsd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/arm_soc"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/aurora"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/azure_storage_queue"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bacnet"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bcache"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/beanstalkd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/bind"
@@ -23,11 +29,13 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloud_pubsub"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloud_pubsub_push"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cloudwatch"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cockroachdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/conntrack"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/consul"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/couchbase"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/couchdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cpu"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cpu_telemetry"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/dcos"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/disk"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/diskio"
@@ -37,6 +45,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/docker"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/docker_log"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/dovecot"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ebpf"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ecs"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/elasticsearch"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ethtool"
@@ -46,19 +55,25 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fail2ban"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fibaro"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/file"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/file_integrity"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/filecount"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/filestat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fireboard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fluentbit"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/fluentd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/github"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/gitlab"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/gnmi"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/gpfs"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/graylog"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/haproxy"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/hddtemp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http_listener_v2"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http_response"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/hyperv"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/icinga2"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/iis"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/infiniband"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/influxdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/influxdb_listener"
@@ -105,6 +120,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/neptune_apex"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/net"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/net_response"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/netflow"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx_plus"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/nginx_plus_api"
@@ -121,7 +137,9 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openldap"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openntpd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/opensmtpd"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openstack"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/openweathermap"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/osquery"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/passenger"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/pf"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/pgbouncer"
@@ -132,6 +150,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/postgresql_extensible"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/powerdns"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/powerdns_recursor"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/proc_accounting"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/processes"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/procstat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/prometheus"
@@ -141,11 +160,13 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/raindrops"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/redfish"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/redis"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/replay"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/rethinkdb"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/riak"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/salesforce"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sensors"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sflow"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/slurm"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/smart"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp_trap"
@@ -158,6 +179,7 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/suricata"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/swap"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/synproxy"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/synthetic"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/syslog"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sysstat"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/system"
@@ -170,10 +192,14 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/trig"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/twemproxy"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/unbound"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/upsd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/uwsgi"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/varnish"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/vsphere"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/webhooks"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_ad"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_cluster"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_dns"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_eventlog"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_services"
@@ -0,0 +1,28 @@
+//go:build lite
+// +build lite
+
+package all
+
+// reduced set of plugins of agents built with the lite tag for edge devices
+
+//nolint:golint
+import (
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/arm_soc"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/cpu"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/disk"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/diskio"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http_response"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/internal"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/kernel"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mem"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/modbus"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/mqtt_consumer"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/net"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/ping"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/processes"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/procstat"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/snmp"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/swap"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/system"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/temp"
+)
@@ -0,0 +1,219 @@
+package amdrocmsmi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	measurement        = "amd_rocm_smi"
+	processMeasurement = "amd_rocm_smi_process"
+	mebibyte           = 1024 * 1024
+)
+
+// ROCmSMI holds the methods for this plugin
+type ROCmSMI struct {
+	BinPath          string            `toml:"bin_path"`
+	Timeout          internal.Duration `toml:"timeout"`
+	CollectProcesses bool              `toml:"collect_processes"`
+}
+
+// Description returns the description of the ROCmSMI plugin
+func (*ROCmSMI) Description() string {
+	return "Pulls statistics from AMD GPUs attached to the host"
+}
+
+// SampleConfig returns the sample configuration for the ROCmSMI plugin
+func (*ROCmSMI) SampleConfig() string {
+	return `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Optional: path to rocm-smi binary
+  # bin_path = "/opt/rocm/bin/rocm-smi"
+
+  ## Optional: timeout for GPU polling
+  # timeout = "5s"
+
+  ## Optional: report the VRAM used by each process using a GPU in the
+  ## amd_rocm_smi_process measurement, tagged by pid
+  # collect_processes = false
+`
+}
+
+// Gather implements the Input interface
+func (r *ROCmSMI) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if _, err := os.Stat(r.BinPath); os.IsNotExist(err) {
+		return fmt.Errorf("rocm-smi binary not at path %s, cannot gather GPU data", r.BinPath)
+	}
+
+	data, err := r.pollROCmSMI()
+	if err != nil {
+		return err
+	}
+
+	return gatherROCmSMI(data, acc, r.CollectProcesses)
+}
+
+func (r *ROCmSMI) pollROCmSMI() ([]byte, error) {
+	args := []string{
+		"--showuniqueid",
+		"--showproductname",
+		"--showbus",
+		"--showtemp",
+		"--showuse",
+		"--showmemuse",
+		"--showmeminfo", "vram",
+		"--showpower",
+		"--showfan",
+		"--showdriverversion",
+	}
+	if r.CollectProcesses {
+		args = append(args, "--showpids")
+	}
+	args = append(args, "--json")
+
+	ret, err := internal.CombinedOutputTimeout(
+		exec.Command(r.BinPath, args...), //nolint:gosec // G204
+		r.Timeout.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("combined output timeout: %w", err)
+	}
+	return ret, nil
+}
+
+func gatherROCmSMI(ret []byte, acc cua.Accumulator, processes bool) error {
+	// rocm-smi answers an object keyed by card ("card0", ...) plus a
+	// "system" entry, each holding string values keyed by description
+	var smi map[string]map[string]string
+	if err := json.Unmarshal(ret, &smi); err != nil {
+		return fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	system := smi["system"]
+	cards := make([]string, 0, len(smi))
+	for name := range smi {
+		if strings.HasPrefix(name, "card") {
+			cards = append(cards, name)
+		}
+	}
+	sort.Strings(cards)
+
+	for _, name := range cards {
+		card := smi[name]
+		tags := map[string]string{
+			"gpu": name,
+		}
+		setTagIfUsed(tags, "uuid", card["Unique ID"])
+		setTagIfUsed(tags, "name", card["Card series"])
+		setTagIfUsed(tags, "pci_bus", card["PCI Bus"])
+
+		fields := map[string]interface{}{}
+		setIfUsed("str", fields, "driver_version", system["Driver version"])
+		setIfUsed("int", fields, "utilization_gpu", card["GPU use (%)"])
+		setIfUsed("int", fields, "utilization_memory", card["GPU memory use (%)"])
+		setIfUsed("int", fields, "fan_speed", card["Fan speed (%)"])
+		setIfUsed("float", fields, "temperature_gpu", card["Temperature (Sensor edge) (C)"])
+		setIfUsed("float", fields, "temperature_junction", card["Temperature (Sensor junction) (C)"])
+		setIfUsed("float", fields, "temperature_memory", card["Temperature (Sensor memory) (C)"])
+		setIfUsed("float", fields, "power_draw", card["Average Graphics Package Power (W)"])
+
+		// report memory in MiB like nvidia_smi so dashboards can span vendors
+		total, totalErr := strconv.ParseUint(card["VRAM Total Memory (B)"], 10, 64)
+		used, usedErr := strconv.ParseUint(card["VRAM Total Used Memory (B)"], 10, 64)
+		if totalErr == nil {
+			fields["memory_total"] = int(total / mebibyte)
+		}
+		if usedErr == nil {
+			fields["memory_used"] = int(used / mebibyte)
+		}
+		if totalErr == nil && usedErr == nil && total >= used {
+			fields["memory_free"] = int((total - used) / mebibyte)
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+		acc.AddFields(measurement, fields, tags)
+	}
+
+	if processes {
+		gatherProcesses(system, acc)
+	}
+
+	return nil
+}
+
+// gatherProcesses parses the "PID<n>" entries of the system section. Each
+// holds "name, gpu count, vram bytes, sdma bytes, cu occupancy".
+func gatherProcesses(system map[string]string, acc cua.Accumulator) {
+	for key, value := range system {
+		if !strings.HasPrefix(key, "PID") {
+			continue
+		}
+		parts := strings.Split(value, ",")
+		if len(parts) < 3 {
+			continue
+		}
+		vram, err := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		tags := map[string]string{
+			"pid": strings.TrimPrefix(key, "PID"),
+		}
+		setTagIfUsed(tags, "process_name", strings.TrimSpace(parts[0]))
+
+		fields := map[string]interface{}{
+			"memory_used": int(vram / mebibyte),
+		}
+		setIfUsed("int", fields, "gpus", strings.TrimSpace(parts[1]))
+		acc.AddFields(processMeasurement, fields, tags)
+	}
+}
+
+func setTagIfUsed(m map[string]string, k, v string) {
+	if v = strings.TrimSpace(v); v != "" && v != "N/A" {
+		m[k] = v
+	}
+}
+
+func setIfUsed(t string, m map[string]interface{}, k, v string) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "N/A" {
+		return
+	}
+
+	switch t {
+	case "float":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			m[k] = f
+		}
+	case "int":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			m[k] = int(f)
+		}
+	case "str":
+		m[k] = v
+	}
+}
+
+func init() {
+	inputs.Add("amd_rocm_smi", func() cua.Input {
+		return &ROCmSMI{
+			BinPath: "/opt/rocm/bin/rocm-smi",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package amdrocmsmi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherValidJSON(t *testing.T) {
+	octets, err := os.ReadFile(filepath.Join("testdata", "mi100.json"))
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, gatherROCmSMI(octets, &acc, true))
+
+	expected := []cua.Metric{
+		testutil.MustMetric(
+			"amd_rocm_smi",
+			map[string]string{
+				"gpu":     "card0",
+				"uuid":    "0x5c4b1a3d2f9e8b71",
+				"name":    "Arcturus GL-XL [Instinct MI100]",
+				"pci_bus": "0000:23:00.0",
+			},
+			map[string]interface{}{
+				"driver_version":       "5.11.32",
+				"utilization_gpu":      87,
+				"utilization_memory":   34,
+				"temperature_gpu":      41.0,
+				"temperature_junction": 44.0,
+				"temperature_memory":   39.0,
+				"power_draw":           221.0,
+				"memory_total":         32752,
+				"memory_used":          16376,
+				"memory_free":          16376,
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric(
+			"amd_rocm_smi",
+			map[string]string{
+				"gpu":     "card1",
+				"uuid":    "0x7d2e9c04b1a6f352",
+				"name":    "Arcturus GL-XL [Instinct MI100]",
+				"pci_bus": "0000:43:00.0",
+			},
+			map[string]interface{}{
+				"driver_version":       "5.11.32",
+				"utilization_gpu":      0,
+				"utilization_memory":   0,
+				"temperature_gpu":      35.0,
+				"temperature_junction": 36.0,
+				"temperature_memory":   33.0,
+				"power_draw":           34.0,
+				"memory_total":         32752,
+				"memory_used":          7,
+				"memory_free":          32745,
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric(
+			"amd_rocm_smi_process",
+			map[string]string{
+				"pid":          "28331",
+				"process_name": "python3",
+			},
+			map[string]interface{}{
+				"memory_used": 16368,
+				"gpus":        1,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherInvalidJSON(t *testing.T) {
+	var acc testutil.Accumulator
+	require.Error(t, gatherROCmSMI([]byte("ERROR: No AMD GPUs found"), &acc, false))
+}
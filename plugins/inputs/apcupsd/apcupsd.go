@@ -43,56 +43,74 @@ func (*ApcUpsd) SampleConfig() string {
 }
 
 func (h *ApcUpsd) Gather(ctx context.Context, acc cua.Accumulator) error {
-
+	// an unreachable server does not prevent the others from being
+	// gathered, the first error is returned and any further ones are added
+	// to the accumulator
+	var firstErr error
 	for _, addr := range h.Servers {
-		addrBits, err := url.Parse(addr)
-		if err != nil {
-			return fmt.Errorf("url parse (%s): %w", addr, err)
-		}
-		if addrBits.Scheme == "" {
-			addrBits.Scheme = "tcp"
+		err := h.gatherServer(ctx, acc, addr)
+		switch {
+		case err == nil:
+		case firstErr == nil:
+			firstErr = err
+		default:
+			acc.AddError(fmt.Errorf("server %s: %w", addr, err))
 		}
+	}
+	return firstErr
+}
 
-		gctx, cancel := context.WithTimeout(ctx, h.Timeout.Duration)
-		defer cancel()
+func (h *ApcUpsd) gatherServer(ctx context.Context, acc cua.Accumulator, addr string) error {
+	addrBits, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("url parse (%s): %w", addr, err)
+	}
+	if addrBits.Scheme == "" {
+		addrBits.Scheme = "tcp"
+	}
 
-		status, err := fetchStatus(gctx, addrBits)
-		if err != nil {
-			return err
-		}
+	gctx, cancel := context.WithTimeout(ctx, h.Timeout.Duration)
+	defer cancel()
 
-		tags := map[string]string{
-			"serial":   status.SerialNumber,
-			"ups_name": status.UPSName,
-			"status":   status.Status,
-			"model":    status.Model,
-		}
+	status, err := fetchStatus(gctx, addrBits)
+	if err != nil {
+		return err
+	}
 
-		flags, err := strconv.ParseUint(strings.Fields(status.StatusFlags)[0], 0, 64)
-		if err != nil {
-			return fmt.Errorf("parse uint (%s): %w", strings.Fields(status.StatusFlags)[0], err)
-		}
+	tags := map[string]string{
+		"serial":   status.SerialNumber,
+		"ups_name": status.UPSName,
+		"status":   status.Status,
+		"model":    status.Model,
+	}
 
-		fields := map[string]interface{}{
-			"status_flags":            flags,
-			"input_voltage":           status.LineVoltage,
-			"load_percent":            status.LoadPercent,
-			"battery_charge_percent":  status.BatteryChargePercent,
-			"time_left_ns":            status.TimeLeft.Nanoseconds(),
-			"output_voltage":          status.OutputVoltage,
-			"internal_temp":           status.InternalTemp,
-			"battery_voltage":         status.BatteryVoltage,
-			"input_frequency":         status.LineFrequency,
-			"time_on_battery_ns":      status.TimeOnBattery.Nanoseconds(),
-			"nominal_input_voltage":   status.NominalInputVoltage,
-			"nominal_battery_voltage": status.NominalBatteryVoltage,
-			"nominal_power":           status.NominalPower,
-			"firmware":                status.Firmware,
-			"battery_date":            status.BatteryDate,
+	var flags uint64
+	if f := strings.Fields(status.StatusFlags); len(f) > 0 {
+		flags, err = strconv.ParseUint(f[0], 0, 64)
+		if err != nil {
+			return fmt.Errorf("parse uint (%s): %w", f[0], err)
 		}
+	}
 
-		acc.AddFields("apcupsd", fields, tags)
+	fields := map[string]interface{}{
+		"status_flags":            flags,
+		"input_voltage":           status.LineVoltage,
+		"load_percent":            status.LoadPercent,
+		"battery_charge_percent":  status.BatteryChargePercent,
+		"time_left_ns":            status.TimeLeft.Nanoseconds(),
+		"output_voltage":          status.OutputVoltage,
+		"internal_temp":           status.InternalTemp,
+		"battery_voltage":         status.BatteryVoltage,
+		"input_frequency":         status.LineFrequency,
+		"time_on_battery_ns":      status.TimeOnBattery.Nanoseconds(),
+		"nominal_input_voltage":   status.NominalInputVoltage,
+		"nominal_battery_voltage": status.NominalBatteryVoltage,
+		"nominal_power":           status.NominalPower,
+		"firmware":                status.Firmware,
+		"battery_date":            status.BatteryDate,
 	}
+
+	acc.AddFields("apcupsd", fields, tags)
 	return nil
 }
 
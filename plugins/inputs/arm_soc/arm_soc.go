@@ -0,0 +1,298 @@
+//go:build linux
+// +build linux
+
+package armsoc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Sets to collect, available sets are:
+  ##   "thermal"   - temperature and trip points of the thermal zones, state
+  ##                 of the cooling devices, e.g. fans
+  ##   "regulator" - output voltage of the voltage regulators
+  ##   "firmware"  - Raspberry Pi throttling flags, voltages, temperature and
+  ##                 clocks of the VideoCore firmware, as vcgencmd reports them
+  # collect = ["thermal", "regulator", "firmware"]
+
+  ## Firmware mailbox device, readable by root and the video group
+  # vcio_device = "/dev/vcio"
+`
+
+const (
+	collectThermal   = "thermal"
+	collectRegulator = "regulator"
+	collectFirmware  = "firmware"
+)
+
+var defaultCollect = []string{collectThermal, collectRegulator, collectFirmware}
+
+// throttled flags of the firmware, as vcgencmd get_throttled answers them
+var throttledFlags = []struct {
+	bit  uint
+	name string
+}{
+	{0, "under_voltage"},
+	{1, "freq_capped"},
+	{2, "throttled"},
+	{3, "soft_temp_limit"},
+	{16, "under_voltage_occurred"},
+	{17, "freq_capped_occurred"},
+	{18, "throttled_occurred"},
+	{19, "soft_temp_limit_occurred"},
+}
+
+// firmware voltage and clock ids
+var (
+	firmwareVoltages = []struct {
+		id   uint32
+		name string
+	}{
+		{1, "core"},
+		{2, "sdram_c"},
+		{3, "sdram_i"},
+		{4, "sdram_p"},
+	}
+	firmwareClocks = []struct {
+		id   uint32
+		name string
+	}{
+		{3, "arm"},
+		{4, "core"},
+	}
+)
+
+type ArmSoC struct {
+	Collect    []string `toml:"collect"`
+	VcioDevice string   `toml:"vcio_device"`
+
+	Log cua.Logger `toml:"-"`
+
+	sysPath      string
+	openFirmware func(path string) (firmware, error)
+	thermal      bool
+	regulator    bool
+	firmware     bool
+}
+
+// Description answers a description of this input plugin
+func (*ArmSoC) Description() string {
+	return "Read the thermal zones, regulator voltages and Raspberry Pi firmware throttling of ARM SoCs"
+}
+
+// SampleConfig answers a sample configuration
+func (*ArmSoC) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *ArmSoC) Init() error {
+	if len(a.Collect) == 0 {
+		a.Collect = defaultCollect
+	}
+	for _, name := range a.Collect {
+		switch name {
+		case collectThermal:
+			a.thermal = true
+		case collectRegulator:
+			a.regulator = true
+		case collectFirmware:
+			a.firmware = true
+		default:
+			return fmt.Errorf("unknown collect %q", name)
+		}
+	}
+	if a.sysPath == "" {
+		a.sysPath = "/sys"
+		if v := os.Getenv("HOST_SYS"); v != "" {
+			a.sysPath = v
+		}
+	}
+	if a.openFirmware == nil {
+		a.openFirmware = openVcio
+	}
+	return nil
+}
+
+func (a *ArmSoC) Gather(_ context.Context, acc cua.Accumulator) error {
+	now := time.Now()
+	if a.thermal {
+		if err := a.gatherThermal(acc, now); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if a.regulator {
+		if err := a.gatherRegulators(acc, now); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if a.firmware {
+		if err := a.gatherFirmware(acc, now); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+// gatherThermal adds the temperature and the lowest passive and critical
+// trip points of each thermal zone, and the state of each cooling device
+func (a *ArmSoC) gatherThermal(acc cua.Accumulator, now time.Time) error {
+	dir := filepath.Join(a.sysPath, "class", "thermal")
+	zones, err := filepath.Glob(filepath.Join(dir, "thermal_zone*"))
+	if err != nil {
+		return fmt.Errorf("listing thermal zones: %w", err)
+	}
+	for _, zone := range zones {
+		temp, err := readInt(filepath.Join(zone, "temp"))
+		if err != nil {
+			// zones of sensors that are off answer EINVAL or ENODATA
+			continue
+		}
+		fields := map[string]interface{}{
+			"temp": float64(temp) / 1000,
+		}
+		for i := 0; ; i++ {
+			typ, err := readString(filepath.Join(zone, fmt.Sprintf("trip_point_%d_type", i)))
+			if err != nil {
+				break
+			}
+			if typ != "passive" && typ != "critical" {
+				continue
+			}
+			trip, err := readInt(filepath.Join(zone, fmt.Sprintf("trip_point_%d_temp", i)))
+			if err != nil {
+				continue
+			}
+			key := "trip_" + typ
+			if prev, ok := fields[key]; !ok || float64(trip)/1000 < prev.(float64) {
+				fields[key] = float64(trip) / 1000
+			}
+		}
+		typ, _ := readString(filepath.Join(zone, "type"))
+		acc.AddGauge("arm_soc_thermal", fields, map[string]string{
+			"zone": filepath.Base(zone),
+			"type": typ,
+		}, now)
+	}
+
+	devices, err := filepath.Glob(filepath.Join(dir, "cooling_device*"))
+	if err != nil {
+		return fmt.Errorf("listing cooling devices: %w", err)
+	}
+	for _, device := range devices {
+		cur, err := readInt(filepath.Join(device, "cur_state"))
+		if err != nil {
+			continue
+		}
+		fields := map[string]interface{}{
+			"cur_state": cur,
+		}
+		if max, err := readInt(filepath.Join(device, "max_state")); err == nil {
+			fields["max_state"] = max
+		}
+		typ, _ := readString(filepath.Join(device, "type"))
+		acc.AddGauge("arm_soc_cooling", fields, map[string]string{
+			"device": filepath.Base(device),
+			"type":   typ,
+		}, now)
+	}
+	return nil
+}
+
+// gatherRegulators adds the output voltage of the regulators reporting one
+func (a *ArmSoC) gatherRegulators(acc cua.Accumulator, now time.Time) error {
+	regulators, err := filepath.Glob(filepath.Join(a.sysPath, "class", "regulator", "regulator.*"))
+	if err != nil {
+		return fmt.Errorf("listing regulators: %w", err)
+	}
+	for _, regulator := range regulators {
+		uv, err := readInt(filepath.Join(regulator, "microvolts"))
+		if err != nil {
+			continue
+		}
+		name, _ := readString(filepath.Join(regulator, "name"))
+		if name == "" {
+			name = filepath.Base(regulator)
+		}
+		acc.AddGauge("arm_soc_regulator", map[string]interface{}{
+			"voltage": float64(uv) / 1e6,
+		}, map[string]string{"regulator": name}, now)
+	}
+	return nil
+}
+
+// gatherFirmware adds the throttled flags, voltages, temperature and clocks
+// of the firmware; devices without the firmware mailbox are skipped
+func (a *ArmSoC) gatherFirmware(acc cua.Accumulator, now time.Time) error {
+	fw, err := a.openFirmware(a.VcioDevice)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("opening firmware mailbox: %w", err)
+	}
+	defer fw.close()
+
+	flags, err := fw.property(tagGetThrottled, 0)
+	if err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"throttled_flags": int64(flags),
+	}
+	for _, f := range throttledFlags {
+		fields[f.name] = flags&(1<<f.bit) != 0
+	}
+	for _, v := range firmwareVoltages {
+		if uv, err := fw.property(tagGetVoltage, v.id); err == nil {
+			fields[v.name+"_voltage"] = float64(uv) / 1e6
+		}
+	}
+	if mc, err := fw.property(tagGetTemperature, 0); err == nil {
+		fields["temp"] = float64(mc) / 1000
+	}
+	for _, c := range firmwareClocks {
+		if hz, err := fw.property(tagGetClockMeasure, c.id); err == nil {
+			fields[c.name+"_freq"] = int64(hz)
+		}
+	}
+	acc.AddGauge("arm_soc_firmware", fields, nil, now)
+	return nil
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readInt(path string) (int64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("arm_soc", func() cua.Input {
+		return &ArmSoC{
+			VcioDevice: "/dev/vcio",
+		}
+	})
+}
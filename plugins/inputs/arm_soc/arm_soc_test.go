@@ -0,0 +1,126 @@
+//go:build linux
+// +build linux
+
+package armsoc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFirmware map[uint32]map[uint32]uint32
+
+func (f fakeFirmware) property(tag, arg uint32) (uint32, error) {
+	if v, ok := f[tag][arg]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("tag 0x%08x not supported", tag)
+}
+
+func (f fakeFirmware) close() error { return nil }
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content+"\n"), 0644))
+	}
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&ArmSoC{Collect: []string{"gpu"}}).Init())
+	a := &ArmSoC{}
+	require.NoError(t, a.Init())
+	require.True(t, a.thermal && a.regulator && a.firmware)
+}
+
+func TestGather(t *testing.T) {
+	sys := t.TempDir()
+	writeFiles(t, sys, map[string]string{
+		"class/thermal/thermal_zone0/type":              "cpu-thermal",
+		"class/thermal/thermal_zone0/temp":              "48312",
+		"class/thermal/thermal_zone0/trip_point_0_type": "critical",
+		"class/thermal/thermal_zone0/trip_point_0_temp": "110000",
+		"class/thermal/thermal_zone0/trip_point_1_type": "passive",
+		"class/thermal/thermal_zone0/trip_point_1_temp": "85000",
+		"class/thermal/thermal_zone0/trip_point_2_type": "passive",
+		"class/thermal/thermal_zone0/trip_point_2_temp": "80000",
+		"class/thermal/cooling_device0/type":            "pwm-fan",
+		"class/thermal/cooling_device0/cur_state":       "2",
+		"class/thermal/cooling_device0/max_state":       "4",
+		"class/regulator/regulator.1/name":              "vdd-core",
+		"class/regulator/regulator.1/microvolts":        "880000",
+		"class/regulator/regulator.2/name":              "fixed-5v",
+	})
+
+	a := &ArmSoC{
+		sysPath: sys,
+		openFirmware: func(string) (firmware, error) {
+			return fakeFirmware{
+				tagGetThrottled:    {0: 0x50005},
+				tagGetVoltage:      {1: 860000},
+				tagGetTemperature:  {0: 48300},
+				tagGetClockMeasure: {3: 1500000000},
+			}, nil
+		},
+	}
+	require.NoError(t, a.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "arm_soc_thermal", map[string]interface{}{
+		"temp":          48.312,
+		"trip_passive":  80.0,
+		"trip_critical": 110.0,
+	}, map[string]string{"zone": "thermal_zone0", "type": "cpu-thermal"})
+	acc.AssertContainsTaggedFields(t, "arm_soc_cooling", map[string]interface{}{
+		"cur_state": int64(2),
+		"max_state": int64(4),
+	}, map[string]string{"device": "cooling_device0", "type": "pwm-fan"})
+	acc.AssertContainsTaggedFields(t, "arm_soc_regulator", map[string]interface{}{
+		"voltage": 0.88,
+	}, map[string]string{"regulator": "vdd-core"})
+	// regulators without a voltage are skipped
+	var regulators int
+	for _, m := range acc.Metrics {
+		if m.Measurement == "arm_soc_regulator" {
+			regulators++
+		}
+	}
+	require.Equal(t, 1, regulators)
+
+	m, ok := acc.Get("arm_soc_firmware")
+	require.True(t, ok)
+	require.Equal(t, int64(0x50005), m.Fields["throttled_flags"])
+	require.Equal(t, true, m.Fields["under_voltage"])
+	require.Equal(t, false, m.Fields["freq_capped"])
+	require.Equal(t, true, m.Fields["throttled"])
+	require.Equal(t, true, m.Fields["under_voltage_occurred"])
+	require.Equal(t, true, m.Fields["throttled_occurred"])
+	require.Equal(t, 0.86, m.Fields["core_voltage"])
+	require.Equal(t, 48.3, m.Fields["temp"])
+	require.Equal(t, int64(1500000000), m.Fields["arm_freq"])
+	require.NotContains(t, m.Fields, "sdram_c_voltage")
+}
+
+func TestGatherNoFirmware(t *testing.T) {
+	a := &ArmSoC{
+		Collect:    []string{"firmware"},
+		VcioDevice: filepath.Join(t.TempDir(), "vcio"),
+	}
+	require.NoError(t, a.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.Metrics)
+}
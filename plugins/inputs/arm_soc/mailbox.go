@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package armsoc
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// VideoCore firmware property tags, the ones vcgencmd uses, see
+// https://github.com/raspberrypi/firmware/wiki/Mailbox-property-interface
+const (
+	tagGetVoltage      = 0x00030003
+	tagGetTemperature  = 0x00030006
+	tagGetThrottled    = 0x00030046
+	tagGetClockMeasure = 0x00030047
+
+	mboxRequest = 0x00000000
+	mboxSuccess = 0x80000000
+)
+
+// firmware answers the value of a property tag of the firmware
+type firmware interface {
+	property(tag uint32, arg uint32) (uint32, error)
+	close() error
+}
+
+// vcio is the firmware mailbox of /dev/vcio
+type vcio struct {
+	f *os.File
+}
+
+func openVcio(path string) (firmware, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &vcio{f: f}, nil
+}
+
+func (v *vcio) close() error {
+	return v.f.Close() //nolint:wrapcheck
+}
+
+// property sends a single tag request with one argument word, e.g. the id
+// of a voltage or clock, and answers the value word of the response
+func (v *vcio) property(tag uint32, arg uint32) (uint32, error) {
+	// size, request code, tag, value buffer size, tag request code,
+	// value buffer of id and value, end tag
+	buf := [8]uint32{8 * 4, mboxRequest, tag, 8, 0, arg, 0, 0}
+
+	// _IOWR(100, 0, char *)
+	req := uintptr(0xc0000000) | unsafe.Sizeof(uintptr(0))<<16 | 100<<8
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, v.f.Fd(), req, uintptr(unsafe.Pointer(&buf[0])))
+	if errno != 0 {
+		return 0, fmt.Errorf("mailbox tag 0x%08x: %w", tag, errno)
+	}
+	if buf[1] != mboxSuccess {
+		return 0, fmt.Errorf("mailbox tag 0x%08x: response code 0x%08x", tag, buf[1])
+	}
+	// single word responses, e.g. the throttled flags, are in the first
+	// value word, id and value responses in the second
+	if buf[4]&^mboxSuccess == 4 {
+		return buf[5], nil
+	}
+	return buf[6], nil
+}
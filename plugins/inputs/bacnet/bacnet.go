@@ -0,0 +1,295 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Time to wait for a device to answer a request and the number of
+  ## times an unanswered request is repeated.
+  # timeout = "2s"
+  # retries = 1
+
+  ## One table per BACnet/IP device. Routed devices behind a BACnet
+  ## router are not supported, use the address of a device reachable
+  ## over BACnet/IP directly.
+  [[inputs.bacnet.device]]
+    ## Device name, reported in the device tag
+    name = "ahu1"
+    ## Address of the device, the port defaults to 47808 (0xBAC0)
+    address = "192.168.1.50:47808"
+
+    ## Objects to read
+    ## name     - the field name
+    ## type     - object type, e.g. analog-input, analog-value,
+    ##            binary-input, multi-state-value, or the numeric type
+    ## instance - object instance number
+    ## property - property to read, defaults to present-value; the name of
+    ##            a standard property or its numeric identifier
+    objects = [
+      { name = "supply_air_temp", type = "analog-input",  instance = 1 },
+      { name = "fan_status",      type = "binary-input",  instance = 3 },
+      { name = "fan_flags",       type = "binary-input",  instance = 3, property = "status-flags" },
+    ]
+`
+
+const (
+	defaultPort  = "47808"
+	measurement  = "bacnet"
+	presentValue = 85
+)
+
+var defaultTimeout = internal.Duration{Duration: 2 * time.Second}
+
+// objectTypes maps the BACnet standard object type names to their ids
+var objectTypes = map[string]uint32{
+	"analog-input":       0,
+	"analog-output":      1,
+	"analog-value":       2,
+	"binary-input":       3,
+	"binary-output":      4,
+	"binary-value":       5,
+	"device":             8,
+	"loop":               12,
+	"multi-state-input":  13,
+	"multi-state-output": 14,
+	"multi-state-value":  19,
+	"accumulator":        23,
+	"pulse-converter":    24,
+	"integer-value":      45,
+	"large-analog-value": 46,
+	"positive-integer":   48,
+}
+
+// properties maps commonly read property names to their ids
+var properties = map[string]uint32{
+	"present-value":  presentValue,
+	"status-flags":   111,
+	"reliability":    103,
+	"out-of-service": 81,
+	"event-state":    36,
+	"priority-array": 87,
+	"units":          117,
+	"object-name":    77,
+	"description":    28,
+	"relinquish-def": 104,
+	"pulse-rate":     186,
+}
+
+type BACnet struct {
+	Timeout internal.Duration `toml:"timeout"`
+	Retries int               `toml:"retries"`
+	Devices []*device         `toml:"device"`
+
+	Log cua.Logger `toml:"-"`
+}
+
+type device struct {
+	Name    string   `toml:"name"`
+	Address string   `toml:"address"`
+	Objects []object `toml:"objects"`
+
+	addr *net.UDPAddr
+}
+
+type object struct {
+	Name     string `toml:"name"`
+	Type     string `toml:"type"`
+	Instance uint32 `toml:"instance"`
+	Property string `toml:"property"`
+
+	id       objectID
+	property uint32
+}
+
+// Description answers a description of this input plugin
+func (*BACnet) Description() string {
+	return "Read property values from BACnet/IP devices"
+}
+
+// SampleConfig answers a sample configuration
+func (*BACnet) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *BACnet) Init() error {
+	if b.Retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
+	if len(b.Devices) == 0 {
+		return fmt.Errorf("no devices configured")
+	}
+
+	for _, dev := range b.Devices {
+		if dev.Name == "" {
+			return fmt.Errorf("device name is empty")
+		}
+		address := dev.Address
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, defaultPort)
+		}
+		addr, err := net.ResolveUDPAddr("udp", address)
+		if err != nil {
+			return fmt.Errorf("device %s: resolve (%s): %w", dev.Name, dev.Address, err)
+		}
+		dev.addr = addr
+
+		if len(dev.Objects) == 0 {
+			return fmt.Errorf("device %s: no objects configured", dev.Name)
+		}
+		names := map[string]bool{}
+		for i := range dev.Objects {
+			obj := &dev.Objects[i]
+			if obj.Name == "" {
+				return fmt.Errorf("device %s: empty object name", dev.Name)
+			}
+			if names[obj.Name] {
+				return fmt.Errorf("device %s: object name '%s' is duplicated", dev.Name, obj.Name)
+			}
+			names[obj.Name] = true
+
+			typ, err := lookup(objectTypes, obj.Type, 1023)
+			if err != nil {
+				return fmt.Errorf("device %s: object %s: invalid type: %w", dev.Name, obj.Name, err)
+			}
+			if obj.Instance > maxInstance {
+				return fmt.Errorf("device %s: object %s: instance %d out of range", dev.Name, obj.Name, obj.Instance)
+			}
+			obj.id = objectID{typ: uint16(typ), instance: obj.Instance}
+
+			obj.property = presentValue
+			if obj.Property != "" {
+				prop, err := lookup(properties, obj.Property, 4194303)
+				if err != nil {
+					return fmt.Errorf("device %s: object %s: invalid property: %w", dev.Name, obj.Name, err)
+				}
+				obj.property = prop
+			}
+		}
+	}
+	return nil
+}
+
+// lookup resolves a name from the table or a numeric identifier up to max.
+func lookup(table map[string]uint32, name string, max uint64) (uint32, error) {
+	if v, ok := table[name]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || v > max {
+		return 0, fmt.Errorf("unknown '%s'", name)
+	}
+	return uint32(v), nil
+}
+
+// Gather reads the configured objects of all devices concurrently
+func (b *BACnet) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, dev := range b.Devices {
+		wg.Add(1)
+		go func(dev *device) {
+			defer wg.Done()
+			if err := b.gatherDevice(ctx, acc, dev); err != nil {
+				acc.AddError(fmt.Errorf("device %s: %w", dev.Name, err))
+			}
+		}(dev)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (b *BACnet) gatherDevice(ctx context.Context, acc cua.Accumulator, dev *device) error {
+	conn, err := net.DialUDP("udp", nil, dev.addr)
+	if err != nil {
+		return fmt.Errorf("dial (%s): %w", dev.addr, err)
+	}
+	defer conn.Close()
+
+	fields := make(map[string]interface{}, len(dev.Objects))
+	var invokeID byte
+	for _, obj := range dev.Objects {
+		if ctx.Err() != nil {
+			return ctx.Err() //nolint:wrapcheck
+		}
+		invokeID++
+		value, err := b.readProperty(conn, invokeID, obj)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				// an unresponsive device would otherwise cost a timeout
+				// per object
+				return fmt.Errorf("object %s: %w", obj.Name, err)
+			}
+			acc.AddError(fmt.Errorf("device %s: object %s: %w", dev.Name, obj.Name, err))
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		fields[obj.Name] = value
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields(measurement, fields, map[string]string{
+			"device":  dev.Name,
+			"address": dev.addr.String(),
+		})
+	}
+	return nil
+}
+
+// readProperty sends a ReadProperty request and waits for the matching
+// response, repeating the request on timeout.
+func (b *BACnet) readProperty(conn *net.UDPConn, invokeID byte, obj object) (interface{}, error) {
+	req := encodeReadProperty(invokeID, obj.id, obj.property, -1)
+	buf := make([]byte, 1500)
+
+	var err error
+	for attempt := 0; attempt <= b.Retries; attempt++ {
+		if _, err = conn.Write(req); err != nil {
+			return nil, fmt.Errorf("write: %w", err)
+		}
+		if err = conn.SetReadDeadline(time.Now().Add(b.Timeout.Duration)); err != nil {
+			return nil, fmt.Errorf("set deadline: %w", err)
+		}
+		for {
+			var n int
+			n, err = conn.Read(buf)
+			if err != nil {
+				break
+			}
+			value, ok, derr := decodeReadPropertyACK(buf[:n], invokeID)
+			if !ok {
+				// a late answer to an earlier request or unrelated traffic
+				continue
+			}
+			return value, derr
+		}
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("no response: %w", err)
+}
+
+func init() {
+	inputs.Add("bacnet", func() cua.Input {
+		return &BACnet{
+			Timeout: defaultTimeout,
+			Retries: 1,
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package bacnet
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice answers ReadProperty requests for the objects in values, keyed
+// by encoded object id and property, with the given application tagged value.
+func fakeDevice(t *testing.T, values map[[2]uint32][]byte) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			// BVLC(4) NPDU(2) APDU header(4) object id tag(5) property tag
+			if n < 17 || req[9] != serviceReadProperty {
+				continue
+			}
+			invokeID := req[8]
+			oid := binary.BigEndian.Uint32(req[11:15])
+			prop := uint32(decodeUnsigned(req[16 : 16+int(req[15]&0x07)]))
+
+			apdu := []byte{}
+			if value, ok := values[[2]uint32{oid, prop}]; ok {
+				apdu = append(apdu, pduComplexACK<<4, invokeID, serviceReadProperty)
+				apdu = append(apdu, req[10:16+int(req[15]&0x07)]...)
+				apdu = append(apdu, 0x3e)
+				apdu = append(apdu, value...)
+				apdu = append(apdu, 0x3f)
+			} else {
+				// error class object (1), code unknown-object (31)
+				apdu = append(apdu, pduError<<4, invokeID, serviceReadProperty, 0x91, 1, 0x91, 31)
+			}
+			resp := []byte{bvlcTypeBIP, bvlcOriginalUnicastNPDU, 0, 0, npduVersion, 0}
+			resp = append(resp, apdu...)
+			binary.BigEndian.PutUint16(resp[2:4], uint16(len(resp)))
+			_, _ = conn.WriteToUDP(resp, src)
+		}
+	}()
+	return conn
+}
+
+func TestGather(t *testing.T) {
+	analogInput1 := objectID{typ: 0, instance: 1}.encode()
+	binaryInput3 := objectID{typ: 3, instance: 3}.encode()
+	multiState7 := objectID{typ: 19, instance: 7}.encode()
+
+	dev := fakeDevice(t, map[[2]uint32][]byte{
+		{analogInput1, presentValue}: {0x44, 0x41, 0xb4, 0x00, 0x00}, // real 22.5
+		{binaryInput3, presentValue}: {0x91, 0x01},                   // enumerated active
+		{binaryInput3, 111}:          {0x82, 0x04, 0x40},             // status-flags fault
+		{multiState7, presentValue}:  {0x21, 0x03},                   // unsigned 3
+	})
+	defer dev.Close()
+
+	b := &BACnet{
+		Timeout: internal.Duration{Duration: time.Second},
+		Devices: []*device{
+			{
+				Name:    "ahu1",
+				Address: dev.LocalAddr().String(),
+				Objects: []object{
+					{Name: "supply_air_temp", Type: "analog-input", Instance: 1},
+					{Name: "fan_status", Type: "binary-input", Instance: 3},
+					{Name: "fan_flags", Type: "binary-input", Instance: 3, Property: "status-flags"},
+					{Name: "mode", Type: "19", Instance: 7, Property: "85"},
+					{Name: "missing", Type: "analog-value", Instance: 9},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, b.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, b.Gather(context.Background(), &acc))
+
+	acc.AssertContainsTaggedFields(t, "bacnet",
+		map[string]interface{}{
+			"supply_air_temp": float64(22.5),
+			"fan_status":      uint64(1),
+			"fan_flags":       uint64(2),
+			"mode":            uint64(3),
+		},
+		map[string]string{
+			"device":  "ahu1",
+			"address": dev.LocalAddr().String(),
+		})
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "missing")
+}
+
+func TestGatherUnresponsiveDevice(t *testing.T) {
+	// a bound socket that never answers
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	b := &BACnet{
+		Timeout: internal.Duration{Duration: 50 * time.Millisecond},
+		Retries: 1,
+		Devices: []*device{
+			{
+				Name:    "silent",
+				Address: conn.LocalAddr().String(),
+				Objects: []object{
+					{Name: "a", Type: "analog-input", Instance: 1},
+					{Name: "b", Type: "analog-input", Instance: 2},
+				},
+			},
+		},
+	}
+	require.NoError(t, b.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, b.Gather(context.Background(), &acc))
+	require.Empty(t, acc.GetCUAMetrics())
+	// the device is skipped after the first unanswered object
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestInitValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  object
+	}{
+		{"unknown type", object{Name: "x", Type: "thermostat", Instance: 1}},
+		{"instance out of range", object{Name: "x", Type: "analog-input", Instance: maxInstance + 1}},
+		{"unknown property", object{Name: "x", Type: "analog-input", Instance: 1, Property: "temperature"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BACnet{Devices: []*device{{Name: "d", Address: "127.0.0.1", Objects: []object{tt.obj}}}}
+			require.Error(t, b.Init())
+		})
+	}
+
+	b := &BACnet{Devices: []*device{{Name: "d", Address: "127.0.0.1", Objects: []object{{Name: "x", Type: "analog-input"}}}}}
+	require.NoError(t, b.Init())
+	require.Equal(t, 47808, b.Devices[0].addr.Port)
+}
+
+func TestDecodeValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  interface{}
+	}{
+		{"null", []byte{0x00}, nil},
+		{"boolean", []byte{0x11}, true},
+		{"signed", []byte{0x32, 0xff, 0x38}, int64(-200)},
+		{"double", []byte{0x55, 0x08, 0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}, 3.141592653589793},
+		{"string", []byte{0x75, 0x04, 0x00, 'a', 'h', 'u'}, "ahu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ack := []byte{0x0c, 0, 0, 0, 1, 0x19, presentValue, 0x3e}
+			ack = append(ack, tt.value...)
+			ack = append(ack, 0x3f)
+			v, err := decodePropertyValue(ack)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, v)
+		})
+	}
+}
+
+func TestDecodeRoutedResponse(t *testing.T) {
+	// response forwarded by a BBMD from a device on remote network 5
+	resp := []byte{bvlcTypeBIP, bvlcForwardedNPDU, 0, 0, 192, 0, 2, 1, 0xba, 0xc0,
+		npduVersion, npduSNETPresent, 0, 5, 1, 9,
+		pduComplexACK << 4, 7, serviceReadProperty,
+		0x0c, 0, 0, 0, 1, 0x19, presentValue, 0x3e, 0x21, 42, 0x3f}
+	v, ok, err := decodeReadPropertyACK(resp, 7)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(42), v)
+
+	_, ok, err = decodeReadPropertyACK(resp, 8)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,391 @@
+package bacnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// BACnet/IP (ASHRAE 135 Annex J) framing and the subset of the application
+// layer needed for confirmed ReadProperty requests.
+
+const (
+	bvlcTypeBIP               = 0x81
+	bvlcForwardedNPDU         = 0x04
+	bvlcOriginalUnicastNPDU   = 0x0a
+	bvlcOriginalBroadcastNPDU = 0x0b
+	bvlcHeaderLen             = 4
+
+	npduVersion        = 0x01
+	npduExpectingReply = 0x04
+	npduNetworkMessage = 0x80
+	npduDNETPresent    = 0x20
+	npduSNETPresent    = 0x08
+
+	pduConfirmedRequest = 0x0
+	pduComplexACK       = 0x3
+	pduError            = 0x5
+	pduReject           = 0x6
+	pduAbort            = 0x7
+
+	serviceReadProperty = 12
+
+	// max segments accepted: unspecified, max APDU: 1476 octets
+	maxAPDU = 0x05
+
+	// application tags
+	tagNull       = 0
+	tagBoolean    = 1
+	tagUnsigned   = 2
+	tagSigned     = 3
+	tagReal       = 4
+	tagDouble     = 5
+	tagCharString = 7
+	tagBitString  = 8
+	tagEnumerated = 9
+
+	// ReadProperty context tags
+	objectIDTag     = 0
+	propertyIDTag   = 1
+	arrayIndexTag   = 2
+	propertyListTag = 3
+
+	// tag length/value/type field
+	lvtExtended      = 5
+	lvtOpening       = 6
+	lvtClosing       = 7
+	extendedTagValue = 15
+
+	maxInstance = 0x3fffff
+	charsetUTF8 = 0
+)
+
+var errShortPacket = errors.New("packet too short")
+
+// objectID is a BACnet object identifier: a 10 bit object type and a 22 bit
+// instance number.
+type objectID struct {
+	typ      uint16
+	instance uint32
+}
+
+func (o objectID) encode() uint32 {
+	return uint32(o.typ)<<22 | o.instance&maxInstance
+}
+
+// encodeReadProperty builds a BACnet/IP datagram carrying a confirmed
+// ReadProperty request. arrayIndex is omitted when negative.
+func encodeReadProperty(invokeID byte, obj objectID, property uint32, arrayIndex int64) []byte {
+	apdu := []byte{pduConfirmedRequest << 4, maxAPDU, invokeID, serviceReadProperty}
+	apdu = appendContextUnsigned(appendContextObjectID(apdu, objectIDTag, obj), propertyIDTag, uint64(property))
+	if arrayIndex >= 0 {
+		apdu = appendContextUnsigned(apdu, arrayIndexTag, uint64(arrayIndex))
+	}
+
+	buf := make([]byte, bvlcHeaderLen, bvlcHeaderLen+2+len(apdu))
+	buf[0] = bvlcTypeBIP
+	buf[1] = bvlcOriginalUnicastNPDU
+	buf = append(buf, npduVersion, npduExpectingReply)
+	buf = append(buf, apdu...)
+	binary.BigEndian.PutUint16(buf[2:4], uint16(len(buf)))
+	return buf
+}
+
+func appendContextObjectID(buf []byte, tagNum byte, obj objectID) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], obj.encode())
+	return append(append(buf, tagNum<<4|0x08|4), b[:]...)
+}
+
+func appendContextUnsigned(buf []byte, tagNum byte, v uint64) []byte {
+	b := unsignedBytes(v)
+	buf = append(buf, tagNum<<4|0x08|byte(len(b)))
+	return append(buf, b...)
+}
+
+// unsignedBytes returns the minimal big endian encoding of v.
+func unsignedBytes(v uint64) []byte {
+	n := 1
+	for x := v >> 8; x > 0; x >>= 8 {
+		n++
+	}
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+// apdu extracts the application layer PDU from a BACnet/IP datagram.
+func apdu(buf []byte) ([]byte, error) {
+	if len(buf) < bvlcHeaderLen {
+		return nil, errShortPacket
+	}
+	if buf[0] != bvlcTypeBIP {
+		return nil, fmt.Errorf("not a BACnet/IP datagram (type 0x%02x)", buf[0])
+	}
+	switch buf[1] {
+	case bvlcOriginalUnicastNPDU, bvlcOriginalBroadcastNPDU:
+		buf = buf[bvlcHeaderLen:]
+	case bvlcForwardedNPDU:
+		// followed by the 6 byte B/IP address of the originating device
+		if len(buf) < bvlcHeaderLen+6 {
+			return nil, errShortPacket
+		}
+		buf = buf[bvlcHeaderLen+6:]
+	default:
+		return nil, fmt.Errorf("unsupported BVLC function 0x%02x", buf[1])
+	}
+
+	if len(buf) < 2 {
+		return nil, errShortPacket
+	}
+	if buf[0] != npduVersion {
+		return nil, fmt.Errorf("unsupported NPDU version %d", buf[0])
+	}
+	control := buf[1]
+	if control&npduNetworkMessage != 0 {
+		return nil, fmt.Errorf("unexpected network layer message")
+	}
+	buf = buf[2:]
+	if control&npduDNETPresent != 0 {
+		if len(buf) < 3 || len(buf) < 3+int(buf[2]) {
+			return nil, errShortPacket
+		}
+		buf = buf[3+int(buf[2]):]
+	}
+	if control&npduSNETPresent != 0 {
+		if len(buf) < 3 || len(buf) < 3+int(buf[2]) {
+			return nil, errShortPacket
+		}
+		buf = buf[3+int(buf[2]):]
+	}
+	if control&npduDNETPresent != 0 {
+		// hop count
+		if len(buf) < 1 {
+			return nil, errShortPacket
+		}
+		buf = buf[1:]
+	}
+	return buf, nil
+}
+
+// decodeReadPropertyACK decodes the response to a ReadProperty request with
+// the given invoke id and returns the first value of the property. ok is
+// false when the datagram answers a different request.
+func decodeReadPropertyACK(buf []byte, invokeID byte) (value interface{}, ok bool, err error) {
+	pdu, err := apdu(buf)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(pdu) < 3 {
+		return nil, false, errShortPacket
+	}
+	if pdu[1] != invokeID {
+		return nil, false, nil
+	}
+
+	switch pdu[0] >> 4 {
+	case pduComplexACK:
+		if pdu[0]&0x08 != 0 {
+			return nil, true, fmt.Errorf("segmented responses are not supported")
+		}
+		if pdu[2] != serviceReadProperty {
+			return nil, true, fmt.Errorf("unexpected service %d in response", pdu[2])
+		}
+		value, err := decodePropertyValue(pdu[3:])
+		return value, true, err
+	case pduError:
+		class, code := errorClassCode(pdu[3:])
+		return nil, true, fmt.Errorf("error response: class %d code %d", class, code)
+	case pduReject:
+		return nil, true, fmt.Errorf("request rejected: reason %d", pdu[2])
+	case pduAbort:
+		return nil, true, fmt.Errorf("request aborted: reason %d", pdu[2])
+	default:
+		return nil, false, nil
+	}
+}
+
+// errorClassCode decodes the enumerated error class and code of an Error PDU.
+func errorClassCode(buf []byte) (class, code uint64) {
+	var vals []uint64
+	for len(buf) > 0 && len(vals) < 2 {
+		t, n, err := decodeTag(buf)
+		if err != nil || t.context || len(buf) < n+int(t.length) {
+			break
+		}
+		vals = append(vals, decodeUnsigned(buf[n:n+int(t.length)]))
+		buf = buf[n+int(t.length):]
+	}
+	if len(vals) == 2 {
+		return vals[0], vals[1]
+	}
+	return 0, 0
+}
+
+// decodePropertyValue skips the object identifier, property identifier, and
+// optional array index of a ReadProperty-ACK and decodes the first
+// application tagged value of the property value list.
+func decodePropertyValue(buf []byte) (interface{}, error) {
+	for len(buf) > 0 {
+		t, n, err := decodeTag(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[n:]
+		if t.context && t.opening && t.number == propertyListTag {
+			break
+		}
+		if t.opening || t.closing || len(buf) < int(t.length) {
+			return nil, fmt.Errorf("malformed ReadProperty-ACK")
+		}
+		buf = buf[t.length:]
+	}
+
+	t, n, err := decodeTag(buf)
+	if err != nil {
+		return nil, err
+	}
+	if t.context {
+		if t.closing && t.number == propertyListTag {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported constructed property value")
+	}
+	buf = buf[n:]
+	if t.number != tagBoolean && len(buf) < int(t.length) {
+		return nil, errShortPacket
+	}
+
+	switch t.number {
+	case tagNull:
+		return nil, nil
+	case tagBoolean:
+		return t.length != 0, nil
+	case tagUnsigned, tagEnumerated:
+		return decodeUnsigned(buf[:t.length]), nil
+	case tagSigned:
+		return decodeSigned(buf[:t.length]), nil
+	case tagReal:
+		if t.length != 4 {
+			return nil, fmt.Errorf("invalid real length %d", t.length)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), nil
+	case tagDouble:
+		if t.length != 8 {
+			return nil, fmt.Errorf("invalid double length %d", t.length)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(buf)), nil
+	case tagCharString:
+		if t.length == 0 {
+			return "", nil
+		}
+		if buf[0] != charsetUTF8 {
+			return nil, fmt.Errorf("unsupported character set %d", buf[0])
+		}
+		return string(buf[1:t.length]), nil
+	case tagBitString:
+		return decodeBitString(buf[:t.length]), nil
+	default:
+		return nil, fmt.Errorf("unsupported application tag %d", t.number)
+	}
+}
+
+type tag struct {
+	number  byte
+	context bool
+	opening bool
+	closing bool
+	// length of the value, or the value itself for application booleans
+	length uint32
+}
+
+// decodeTag decodes a tag header and returns it with the header's length.
+func decodeTag(buf []byte) (tag, int, error) {
+	if len(buf) < 1 {
+		return tag{}, 0, errShortPacket
+	}
+	t := tag{
+		number:  buf[0] >> 4,
+		context: buf[0]&0x08 != 0,
+	}
+	lvt := buf[0] & 0x07
+	n := 1
+	if t.number == extendedTagValue {
+		if len(buf) < 2 {
+			return tag{}, 0, errShortPacket
+		}
+		t.number = buf[1]
+		n++
+	}
+
+	switch {
+	case t.context && lvt == lvtOpening:
+		t.opening = true
+	case t.context && lvt == lvtClosing:
+		t.closing = true
+	case lvt == lvtExtended:
+		if len(buf) < n+1 {
+			return tag{}, 0, errShortPacket
+		}
+		switch l := buf[n]; l {
+		case 254:
+			if len(buf) < n+3 {
+				return tag{}, 0, errShortPacket
+			}
+			t.length = uint32(binary.BigEndian.Uint16(buf[n+1 : n+3]))
+			n += 3
+		case 255:
+			if len(buf) < n+5 {
+				return tag{}, 0, errShortPacket
+			}
+			t.length = binary.BigEndian.Uint32(buf[n+1 : n+5])
+			n += 5
+		default:
+			t.length = uint32(l)
+			n++
+		}
+	default:
+		t.length = uint32(lvt)
+	}
+	return t, n, nil
+}
+
+func decodeUnsigned(b []byte) uint64 {
+	var v uint64
+	for _, x := range b {
+		v = v<<8 | uint64(x)
+	}
+	return v
+}
+
+func decodeSigned(b []byte) int64 {
+	if len(b) == 0 {
+		return 0
+	}
+	v := int64(int8(b[0]))
+	for _, x := range b[1:] {
+		v = v<<8 | int64(x)
+	}
+	return v
+}
+
+// decodeBitString returns the bits of a bit string as an integer with the
+// first bit (e.g. status-flags in-alarm) as bit 0.
+func decodeBitString(b []byte) uint64 {
+	if len(b) < 2 {
+		return 0
+	}
+	unused := int(b[0])
+	bits := (len(b)-1)*8 - unused
+	var v uint64
+	for i := 0; i < bits && i < 64; i++ {
+		if b[1+i/8]&(0x80>>(i%8)) != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
@@ -25,6 +25,10 @@ var sampleConfig = `
   ## cgroup stat fields, as file names, globs are supported.
   ## these file names are appended to each path from above.
   # files = ["memory.*usage*", "memory.limit_in_bytes"]
+  ##
+  ## On cgroup v2 (unified) hosts, e.g. per systemd slice:
+  # paths = ["/sys/fs/cgroup/system.slice/*.service"]
+  # files = ["cpu.stat", "memory.current", "memory.max", "io.stat", "*.pressure"]
 `
 
 func (g *CGroup) SampleConfig() string {
@@ -6,11 +6,13 @@ package cgroup
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 )
@@ -55,6 +57,11 @@ func (g *CGroup) gatherDir(dir string, acc cua.Accumulator) error {
 
 		fd := fileData{data: raw, path: file.path}
 		if err := fd.parse(fields); err != nil {
+			if file.glob {
+				// globs such as "*" also match control files which are not
+				// statistics (cgroup.procs, cgroup.controllers, ...)
+				continue
+			}
 			return err
 		}
 	}
@@ -71,6 +78,7 @@ func (g *CGroup) gatherDir(dir string, acc cua.Accumulator) error {
 type pathInfo struct {
 	path string
 	err  error
+	glob bool // matched by a pattern containing wildcards
 }
 
 func isDir(path string) (bool, error) {
@@ -115,6 +123,7 @@ func (g *CGroup) generateFiles(dir string, list chan<- pathInfo) {
 			list <- pathInfo{err: err}
 			return
 		}
+		glob := strings.ContainsAny(file, "*?[")
 
 		for _, item := range items {
 			ok, err := isDir(item)
@@ -124,7 +133,7 @@ func (g *CGroup) generateFiles(dir string, list chan<- pathInfo) {
 			}
 			// supply only files not dirs
 			if !ok {
-				list <- pathInfo{path: item}
+				list <- pathInfo{path: item, glob: glob}
 			}
 		}
 	}
@@ -169,8 +178,12 @@ type fileFormat struct {
 	parser  func(measurement string, fields map[string]interface{}, b []byte)
 }
 
-const keyPattern = "[[:alpha:]_]+"
-const valuePattern = "[\\d-]+"
+const keyPattern = "[[:alpha:]_][[:alnum:]_.]*"
+const valuePattern = "(?:max|[\\d.-]+)"
+
+// lineKeyPattern matches the leading key of nested key-value lines, e.g. the
+// "8:0" device of io.stat or "some" of cpu.pressure
+const lineKeyPattern = "[[:alnum:]_:]+"
 
 var fileFormats = [...]fileFormat{
 	// 	VAL\n
@@ -200,12 +213,10 @@ var fileFormats = [...]fileFormat{
 	// 	VAL0 VAL1 ...\n
 	{
 		name:    "Space separated values",
-		pattern: "^(" + valuePattern + " )+\n$",
+		pattern: "^(" + valuePattern + " )+(" + valuePattern + ")?\n$",
 		parser: func(measurement string, fields map[string]interface{}, b []byte) {
-			re := regexp.MustCompile("(" + valuePattern + ") ")
-			matches := re.FindAllStringSubmatch(string(b), -1)
-			for i, v := range matches {
-				fields[measurement+"."+strconv.Itoa(i)] = numberOrString(v[1])
+			for i, v := range strings.Fields(string(b)) {
+				fields[measurement+"."+strconv.Itoa(i)] = numberOrString(v)
 			}
 		},
 	},
@@ -223,14 +234,59 @@ var fileFormats = [...]fileFormat{
 			}
 		},
 	},
+	// 	KEY0 SUBKEY0=VAL0 SUBKEY1=VAL1 ...\n
+	// 	KEY1 SUBKEY0=VAL0 ...\n
+	// 	...
+	{
+		name:    "New line separated nested key-value's",
+		pattern: "^(" + lineKeyPattern + "( " + keyPattern + "=" + valuePattern + ")+\n)+$",
+		parser: func(measurement string, fields map[string]interface{}, b []byte) {
+			for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+				items := strings.Fields(line)
+				for _, item := range items[1:] {
+					kv := strings.SplitN(item, "=", 2)
+					fields[measurement+"."+items[0]+"."+kv[0]] = numberOrString(kv[1])
+				}
+			}
+		},
+	},
+	// 	KEY0=VAL0 SUBKEY0=VAL0 ...\n
+	// 	KEY1=VAL1 SUBKEY0=VAL0 ...\n
+	// 	...
+	{
+		name:    "New line separated key=value's",
+		pattern: "^(" + keyPattern + "=" + valuePattern + "( " + keyPattern + "=" + valuePattern + ")*\n)+$",
+		parser: func(measurement string, fields map[string]interface{}, b []byte) {
+			for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+				items := strings.Fields(line)
+				first := strings.SplitN(items[0], "=", 2)
+				fields[measurement+"."+first[0]] = numberOrString(first[1])
+				for _, item := range items[1:] {
+					kv := strings.SplitN(item, "=", 2)
+					fields[measurement+"."+first[0]+"."+kv[0]] = numberOrString(kv[1])
+				}
+			}
+		},
+	},
 }
 
 func numberOrString(s string) interface{} {
+	// cgroup v2 limits read "max" when unlimited
+	if s == "max" {
+		return int64(math.MaxInt64)
+	}
+
 	i, err := strconv.ParseInt(s, 10, 64)
 	if err == nil {
 		return i
 	}
 
+	if strings.Contains(s, ".") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
 	return s
 }
 
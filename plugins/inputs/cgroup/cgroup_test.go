@@ -4,6 +4,7 @@
 package cgroup
 
 import (
+	"math"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
@@ -181,3 +182,90 @@ func TestCgroupStatistics_6(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
 }
+
+// ======================================================================
+
+var cg7 = &CGroup{
+	Paths: []string{"testdata/v2/system.slice"},
+	Files: []string{"*"},
+}
+
+func TestCgroupStatistics_7(t *testing.T) {
+	var acc testutil.Accumulator
+
+	err := acc.GatherError(cg7.Gather)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"path": "testdata/v2/system.slice",
+	}
+	fields := map[string]interface{}{
+		"cpu.stat.usage_usec":      int64(2331521000),
+		"cpu.stat.user_usec":       int64(1622340000),
+		"cpu.stat.system_usec":     int64(709181000),
+		"cpu.stat.nr_periods":      int64(0),
+		"cpu.stat.nr_throttled":    int64(0),
+		"cpu.stat.throttled_usec":  int64(0),
+		"cpu.max.0":                int64(math.MaxInt64),
+		"cpu.max.1":                int64(100000),
+		"cpu.pressure.some.avg10":  0.12,
+		"cpu.pressure.some.avg60":  0.05,
+		"cpu.pressure.some.avg300": 0.01,
+		"cpu.pressure.some.total":  int64(7331221),
+		"cpu.pressure.full.avg10":  0.0,
+		"cpu.pressure.full.avg60":  0.0,
+		"cpu.pressure.full.avg300": 0.0,
+		"cpu.pressure.full.total":  int64(1204410),
+		"memory.current":           int64(1331200000),
+		"memory.max":               int64(math.MaxInt64),
+		"io.stat.8:0.rbytes":       int64(1171456000),
+		"io.stat.8:0.wbytes":       int64(2231042048),
+		"io.stat.8:0.rios":         int64(31273),
+		"io.stat.8:0.wios":         int64(190364),
+		"io.stat.8:0.dbytes":       int64(0),
+		"io.stat.8:0.dios":         int64(0),
+		"io.stat.253:0.rbytes":     int64(1100156928),
+		"io.stat.253:0.wbytes":     int64(2231042048),
+		"io.stat.253:0.rios":       int64(29120),
+		"io.stat.253:0.wios":       int64(251004),
+		"io.stat.253:0.dbytes":     int64(0),
+		"io.stat.253:0.dios":       int64(0),
+		"cgroup.procs.0":           int64(812),
+		"cgroup.procs.1":           int64(1102),
+	}
+	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
+}
+
+// ======================================================================
+
+var cg8 = &CGroup{
+	Paths: []string{"testdata/memory"},
+	Files: []string{"memory.numa_stat"},
+}
+
+func TestCgroupStatistics_8(t *testing.T) {
+	var acc testutil.Accumulator
+
+	err := acc.GatherError(cg8.Gather)
+	require.NoError(t, err)
+
+	require.True(t, acc.HasPoint("cgroup", map[string]string{"path": "testdata/memory"},
+		"memory.numa_stat.total", int64(858067)))
+	require.True(t, acc.HasPoint("cgroup", map[string]string{"path": "testdata/memory"},
+		"memory.numa_stat.hierarchical_anon.N0", int64(451792)))
+}
+
+// ======================================================================
+
+var cg9 = &CGroup{
+	Paths: []string{"testdata/v2/system.slice"},
+	Files: []string{"cgroup.controllers"},
+}
+
+func TestCgroupStatistics_unknownFormat(t *testing.T) {
+	var acc testutil.Accumulator
+
+	// explicitly named files must be in a known format
+	err := acc.GatherError(cg9.Gather)
+	require.Error(t, err)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
 	"github.com/circonus-labs/circonus-unified-agent/internal/release"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/go-trapmetrics"
 	"github.com/hashicorp/go-retryablehttp"
@@ -253,7 +254,7 @@ func (chj *CHJ) loadTLSCACert() error {
 		chj.tlsCfg.ServerName = chj.TLSCN
 	}
 
-	return nil
+	return tlsint.ApplyFIPS(chj.tlsCfg) //nolint:wrapcheck
 }
 
 func init() {
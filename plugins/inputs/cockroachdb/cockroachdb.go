@@ -0,0 +1,340 @@
+package cockroachdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## HTTP address of any node of the cluster; node status is reported for
+  ## every node of the cluster.
+  url = "http://localhost:8080"
+
+  ## SQL user to log in with on secure clusters. The user needs the admin
+  ## role or the VIEWACTIVITY privilege.
+  # username = ""
+  # password = ""
+
+  ## Per node metrics, from the node status, to report. Globs are allowed.
+  # node_metrics = [
+  #   "capacity*", "ranges*", "replicas*", "sql.conns",
+  #   "sql.query.count", "sql.failure.count", "sql.service.latency-p99",
+  #   "sys.cpu.combined.percent-normalized", "sys.rss", "sys.uptime",
+  #   "liveness.heartbeatfailures", "livebytes",
+  # ]
+
+  ## Timeout for HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const sessionHeader = "X-Cockroach-API-Session"
+
+var defaultNodeMetrics = []string{
+	"capacity*", "ranges*", "replicas*", "sql.conns",
+	"sql.query.count", "sql.failure.count", "sql.service.latency-p99",
+	"sys.cpu.combined.percent-normalized", "sys.rss", "sys.uptime",
+	"liveness.heartbeatfailures", "livebytes",
+}
+
+// livenessStatus names the values of the NodeLivenessStatus enum.
+var livenessStatus = map[int]string{
+	0: "unknown",
+	1: "dead",
+	2: "unavailable",
+	3: "live",
+	4: "decommissioning",
+	5: "decommissioned",
+	6: "draining",
+}
+
+type CockroachDB struct {
+	URL         string            `toml:"url"`
+	Username    string            `toml:"username"`
+	Password    string            `toml:"password"`
+	NodeMetrics []string          `toml:"node_metrics"`
+	Timeout     internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client      *http.Client
+	metricsFilt filter.Filter
+
+	mu      sync.Mutex
+	session string
+}
+
+type nodeStatus struct {
+	NodeID  int `json:"node_id"`
+	Address struct {
+		AddressField string `json:"address_field"`
+	} `json:"address"`
+	Locality struct {
+		Tiers []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"tiers"`
+	} `json:"locality"`
+	BuildTag          string             `json:"build_tag"`
+	StartedAt         int64              `json:"started_at"`
+	UpdatedAt         int64              `json:"updated_at"`
+	NumCPUs           int64              `json:"num_cpus"`
+	TotalSystemMemory int64              `json:"total_system_memory"`
+	LivenessStatus    int                `json:"liveness_status"`
+	Metrics           map[string]float64 `json:"metrics"`
+}
+
+type nodesResponse struct {
+	Nodes []nodeStatus `json:"nodes"`
+	Next  int          `json:"next"`
+}
+
+// Description answers a description of this input plugin
+func (*CockroachDB) Description() string {
+	return "Read node readiness and status from a CockroachDB cluster"
+}
+
+// SampleConfig answers a sample configuration
+func (*CockroachDB) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CockroachDB) Init() error {
+	if _, err := url.Parse(c.URL); err != nil {
+		return fmt.Errorf("parsing url (%s): %w", c.URL, err)
+	}
+	if c.NodeMetrics == nil {
+		c.NodeMetrics = defaultNodeMetrics
+	}
+	f, err := filter.Compile(c.NodeMetrics)
+	if err != nil {
+		return fmt.Errorf("compiling node_metrics: %w", err)
+	}
+	c.metricsFilt = f
+
+	tlsCfg, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("tls config: %w", err)
+	}
+	c.client = &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		Timeout:   c.Timeout.Duration,
+	}
+	return nil
+}
+
+func (c *CockroachDB) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if err := c.gatherHealth(ctx, acc); err != nil {
+		acc.AddError(err)
+	}
+	if err := c.gatherNodes(ctx, acc); err != nil {
+		acc.AddError(err)
+	}
+	return nil
+}
+
+// gatherHealth reports whether the node behind the url accepts SQL clients;
+// a node that is draining or cannot reach the cluster answers 503.
+func (c *CockroachDB) gatherHealth(ctx context.Context, acc cua.Accumulator) error {
+	u := strings.TrimSuffix(c.URL, "/") + "/health?ready=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	resp.Body.Close()
+
+	ready := 0
+	if resp.StatusCode == http.StatusOK {
+		ready = 1
+	}
+	acc.AddFields("cockroachdb_health", map[string]interface{}{"ready": ready},
+		map[string]string{"url": c.URL})
+	return nil
+}
+
+func (c *CockroachDB) gatherNodes(ctx context.Context, acc cua.Accumulator) error {
+	var nodes []nodeStatus
+	for offset := 0; ; {
+		var page nodesResponse
+		if err := c.get(ctx, "/api/v2/nodes/?offset="+strconv.Itoa(offset), &page); err != nil {
+			return err
+		}
+		nodes = append(nodes, page.Nodes...)
+		if page.Next == 0 || page.Next <= offset {
+			break
+		}
+		offset = page.Next
+	}
+
+	now := time.Now()
+	for _, n := range nodes {
+		tags := map[string]string{
+			"node_id": strconv.Itoa(n.NodeID),
+			"address": n.Address.AddressField,
+		}
+		if n.BuildTag != "" {
+			tags["version"] = n.BuildTag
+		}
+		for _, tier := range n.Locality.Tiers {
+			tags["locality_"+tier.Key] = tier.Value
+		}
+
+		status, ok := livenessStatus[n.LivenessStatus]
+		if !ok {
+			status = livenessStatus[0]
+		}
+		fields := map[string]interface{}{
+			"liveness_status":     status,
+			"live":                0,
+			"num_cpus":            n.NumCPUs,
+			"total_system_memory": n.TotalSystemMemory,
+		}
+		if status == "live" {
+			fields["live"] = 1
+		}
+		if n.StartedAt > 0 && n.UpdatedAt >= n.StartedAt {
+			fields["uptime_seconds"] = (n.UpdatedAt - n.StartedAt) / int64(time.Second)
+		}
+		if n.UpdatedAt > 0 {
+			// seconds since the node last wrote its status, which it
+			// does every 10s while running
+			fields["status_age_seconds"] = now.Sub(time.Unix(0, n.UpdatedAt)).Seconds()
+		}
+		for name, v := range n.Metrics {
+			if c.metricsFilt.Match(name) {
+				fields[fieldName(name)] = v
+			}
+		}
+		acc.AddFields("cockroachdb_node", fields, tags, now)
+	}
+	return nil
+}
+
+func fieldName(metric string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(metric)
+}
+
+// get requests an API v2 path and decodes the answer into v, logging in
+// first on secure clusters and again when the session expired.
+func (c *CockroachDB) get(ctx context.Context, path string, v interface{}) error {
+	resp, err := c.request(ctx, path)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.Username != "" {
+		resp.Body.Close()
+		c.mu.Lock()
+		c.session = ""
+		c.mu.Unlock()
+		if resp, err = c.request(ctx, path); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	u := strings.TrimSuffix(c.URL, "/") + path
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", u, err)
+	}
+	return nil
+}
+
+func (c *CockroachDB) request(ctx context.Context, path string) (*http.Response, error) {
+	session, err := c.currentSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u := strings.TrimSuffix(c.URL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", u, err)
+	}
+	return resp, nil
+}
+
+func (c *CockroachDB) currentSession(ctx context.Context) (string, error) {
+	if c.Username == "" {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != "" {
+		return c.session, nil
+	}
+
+	u := strings.TrimSuffix(c.URL, "/") + "/api/v2/login/"
+	form := url.Values{"username": {c.Username}, "password": {c.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return "", fmt.Errorf("logging in as %s: %s: %s", c.Username, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var login struct {
+		Session string `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return "", fmt.Errorf("decoding login: %w", err)
+	}
+	c.session = login.Session
+	return c.session, nil
+}
+
+func init() {
+	inputs.Add("cockroachdb", func() cua.Input {
+		return &CockroachDB{
+			URL:     "http://localhost:8080",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
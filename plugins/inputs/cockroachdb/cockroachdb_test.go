@@ -0,0 +1,108 @@
+package cockroachdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	var logins int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			require.Equal(t, "1", r.URL.Query().Get("ready"))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/api/v2/login/":
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "monitor", r.FormValue("username"))
+			require.Equal(t, "secret", r.FormValue("password"))
+			logins++
+			_, _ = w.Write([]byte(`{"session": "abc"}`))
+		case "/api/v2/nodes/":
+			if r.Header.Get(sessionHeader) != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.ServeFile(w, r, "testdata/nodes.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &CockroachDB{
+		URL:      ts.URL,
+		Username: "monitor",
+		Password: "secret",
+	}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "cockroachdb_health",
+		map[string]interface{}{"ready": 0},
+		map[string]string{"url": ts.URL})
+
+	node1 := map[string]string{
+		"node_id":         "1",
+		"address":         "crdb-1:26257",
+		"version":         "v21.1.7",
+		"locality_region": "us-east1",
+		"locality_zone":   "us-east1-b",
+	}
+	m, ok := acc.Get("cockroachdb_node")
+	require.True(t, ok)
+	require.Equal(t, node1, m.Tags)
+	require.Equal(t, "live", m.Fields["liveness_status"])
+	require.Equal(t, 1, m.Fields["live"])
+	require.Equal(t, int64(4), m.Fields["num_cpus"])
+	require.Equal(t, int64(3600), m.Fields["uptime_seconds"])
+	require.Equal(t, float64(107374182400), m.Fields["capacity"])
+	require.Equal(t, float64(85899345920), m.Fields["capacity_available"])
+	require.Equal(t, float64(2), m.Fields["ranges_underreplicated"])
+	require.Equal(t, float64(8912896), m.Fields["sql_service_latency_p99"])
+	require.NotContains(t, m.Fields, "sql_mem_internal_current")
+	require.Contains(t, m.Fields, "status_age_seconds")
+
+	require.True(t, acc.HasPoint("cockroachdb_node",
+		map[string]string{
+			"node_id":         "2",
+			"address":         "crdb-2:26257",
+			"version":         "v21.1.7",
+			"locality_region": "us-east1",
+			"locality_zone":   "us-east1-c",
+		}, "liveness_status", "draining"))
+
+	// the session is kept between gathers
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Equal(t, 1, logins)
+}
+
+func TestGatherInsecure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+		case "/api/v2/nodes/":
+			require.Empty(t, r.Header.Get(sessionHeader))
+			_, _ = w.Write([]byte(`{"nodes": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &CockroachDB{URL: ts.URL}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.True(t, acc.HasPoint("cockroachdb_health", map[string]string{"url": ts.URL}, "ready", 1))
+}
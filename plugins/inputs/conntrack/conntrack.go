@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/netns"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 )
 
@@ -19,6 +20,7 @@ type Conntrack struct {
 	Path  string
 	Dirs  []string
 	Files []string
+	netns.Config
 }
 
 const (
@@ -66,6 +68,12 @@ var sampleConfig = `
    ## Directories to search within for the conntrack files above.
    ## Missing directories will be ignored.
    dirs = ["/proc/sys/net/ipv4/netfilter","/proc/sys/net/netfilter"]
+
+   ## Network namespaces to gather in addition to the host namespace, e.g.
+   ## of containers or VRFs, tagged with the namespace.
+   ## Names of the namespaces in namespace_dirs, globs accepted.
+   # namespaces = []
+   # namespace_dirs = ["/var/run/netns"]
 `
 
 func (c *Conntrack) SampleConfig() string {
@@ -75,6 +83,36 @@ func (c *Conntrack) SampleConfig() string {
 func (c *Conntrack) Gather(ctx context.Context, acc cua.Accumulator) error {
 	c.setDefaults()
 
+	fields := c.gatherFields(acc)
+	if len(fields) == 0 {
+		return fmt.Errorf("Conntrack input failed to collect metrics. " +
+			"Is the conntrack kernel module loaded?")
+	}
+	acc.AddFields(inputName, fields, nil)
+
+	namespaces, err := c.List()
+	if err != nil {
+		return fmt.Errorf("error listing network namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		err := netns.Do(ns, func() error {
+			// the netfilter sysctls show the namespace of the thread
+			fields := c.gatherFields(acc)
+			if len(fields) == 0 {
+				return fmt.Errorf("no conntrack stats")
+			}
+			acc.AddFields(inputName, fields, map[string]string{netns.TagKey: ns.Name})
+			return nil
+		})
+		if err != nil {
+			acc.AddError(fmt.Errorf("error gathering namespace %s: %w", ns.Name, err))
+		}
+	}
+	return nil
+}
+
+// gatherFields reads the conntrack files present
+func (c *Conntrack) gatherFields(acc cua.Accumulator) map[string]interface{} {
 	var metricKey string
 	fields := make(map[string]interface{})
 
@@ -108,13 +146,7 @@ func (c *Conntrack) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 	}
 
-	if len(fields) == 0 {
-		return fmt.Errorf("Conntrack input failed to collect metrics. " +
-			"Is the conntrack kernel module loaded?")
-	}
-
-	acc.AddFields(inputName, fields, nil)
-	return nil
+	return fields
 }
 
 func init() {
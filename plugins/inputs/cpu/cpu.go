@@ -14,11 +14,14 @@ import (
 type Stats struct {
 	ps        system.PS
 	lastStats map[string]cpu.TimesStat
+	nodes     map[string]string
 
 	PerCPU         bool `toml:"percpu"`
 	TotalCPU       bool `toml:"totalcpu"`
+	PerNode        bool `toml:"pernode"`
 	CollectCPUTime bool `toml:"collect_cpu_time"`
 	ReportActive   bool `toml:"report_active"`
+	ReportPressure bool `toml:"report_pressure"`
 }
 
 func NewCPUStats(ps system.PS) *Stats {
@@ -40,10 +43,14 @@ var sampleConfig = `
   percpu = true
   ## Whether to report total system cpu stats or not
   totalcpu = true
+  ## Whether to report per NUMA node cpu stats, tagged cpu-node0 etc. (Linux)
+  # pernode = false
   ## If true, collect raw CPU time metrics.
   collect_cpu_time = false
   ## If true, compute and report the sum of all non-idle CPU states.
   report_active = false
+  ## If true, report the cpu pressure stall information (Linux 4.20+).
+  # report_pressure = false
 `
 
 func (*Stats) SampleConfig() string {
@@ -51,12 +58,24 @@ func (*Stats) SampleConfig() string {
 }
 
 func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
-	times, err := s.ps.CPUTimes(s.PerCPU, s.TotalCPU)
+	times, err := s.ps.CPUTimes(s.PerCPU || s.PerNode, s.TotalCPU)
 	if err != nil {
 		return fmt.Errorf("error getting CPU info: %w", err)
 	}
+	if s.PerNode {
+		times, err = s.withNodeTimes(times)
+		if err != nil {
+			return fmt.Errorf("error getting NUMA nodes: %w", err)
+		}
+	}
 	now := time.Now()
 
+	if s.ReportPressure {
+		if err := system.AddPressure(acc, "cpu"); err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	for _, cts := range times {
 		tags := map[string]string{
 			"cpu": cts.CPU,
@@ -134,6 +153,27 @@ func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return err
 }
 
+// withNodeTimes adds the times of the NUMA nodes to the times, without the
+// per-cpu times unless they are reported
+func (s *Stats) withNodeTimes(times []cpu.TimesStat) ([]cpu.TimesStat, error) {
+	if s.nodes == nil {
+		nodes, err := numaNodes()
+		if err != nil {
+			return nil, err
+		}
+		s.nodes = nodes
+	}
+
+	result := make([]cpu.TimesStat, 0, len(times))
+	for _, t := range times {
+		if !s.PerCPU && t.CPU != "cpu-total" {
+			continue
+		}
+		result = append(result, t)
+	}
+	return append(result, nodeTimes(times, s.nodes)...), nil
+}
+
 func totalCPUTime(t cpu.TimesStat) float64 {
 	total := t.User + t.System + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal +
 		t.Idle
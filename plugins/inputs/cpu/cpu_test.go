@@ -3,6 +3,8 @@ package cpu
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/system"
@@ -255,3 +257,47 @@ func TestCPUTimesDecrease(t *testing.T) {
 	assertContainsTaggedFloat(t, &acc, "cpu", "usage_idle", 80, 0.0005, cputags)
 	assertContainsTaggedFloat(t, &acc, "cpu", "usage_iowait", 2, 0.0005, cputags)
 }
+
+func TestCPUPerNode(t *testing.T) {
+	sys := t.TempDir()
+	for node, list := range map[string]string{"node0": "0-1", "node1": "2,3"} {
+		dir := filepath.Join(sys, nodePath, node)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cpulist"), []byte(list+"\n"), 0600))
+	}
+	t.Setenv("HOST_SYS", sys)
+
+	var mps system.MockPS
+	mps.On("CPUTimes").Return([]cpu.TimesStat{
+		{CPU: "cpu0", User: 1, Idle: 9},
+		{CPU: "cpu1", User: 2, Idle: 8},
+		{CPU: "cpu2", User: 3, Idle: 7},
+		{CPU: "cpu3", User: 4, Idle: 6},
+		{CPU: "cpu-total", User: 10, Idle: 30},
+	}, nil)
+
+	cs := NewCPUStats(&mps)
+	cs.TotalCPU = true
+	cs.PerNode = true
+
+	var acc testutil.Accumulator
+	require.NoError(t, cs.Gather(context.Background(), &acc))
+
+	for _, m := range acc.Metrics {
+		require.NotEqual(t, "cpu0", m.Tags["cpu"], "per-cpu stats reported")
+	}
+	assertContainsTaggedFloat(t, &acc, "cpu", "time_user", 10, 0, map[string]string{"cpu": "cpu-total"})
+	assertContainsTaggedFloat(t, &acc, "cpu", "time_user", 3, 0, map[string]string{"cpu": "cpu-node0"})
+	assertContainsTaggedFloat(t, &acc, "cpu", "time_idle", 17, 0, map[string]string{"cpu": "cpu-node0"})
+	assertContainsTaggedFloat(t, &acc, "cpu", "time_user", 7, 0, map[string]string{"cpu": "cpu-node1"})
+	assertContainsTaggedFloat(t, &acc, "cpu", "time_idle", 13, 0, map[string]string{"cpu": "cpu-node1"})
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8,10-11")
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3, 8, 10, 11}, cpus)
+
+	_, err = parseCPUList("0-x")
+	require.Error(t, err)
+}
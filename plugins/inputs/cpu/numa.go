@@ -0,0 +1,110 @@
+package cpu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// nodePath is the sysfs directory of the NUMA nodes, relative to /sys
+var nodePath = "devices/system/node"
+
+// numaNodes answers the NUMA node of each cpu, e.g. "node0" of "cpu3", read
+// from sysfs (or $HOST_SYS); empty on systems without NUMA information
+func numaNodes() (map[string]string, error) {
+	sysPath := "/sys"
+	if v := os.Getenv("HOST_SYS"); v != "" {
+		sysPath = v
+	}
+	lists, err := filepath.Glob(filepath.Join(sysPath, nodePath, "node*", "cpulist"))
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	nodes := make(map[string]string)
+	for _, list := range lists {
+		node := filepath.Base(filepath.Dir(list))
+		data, err := os.ReadFile(list)
+		if err != nil {
+			return nil, fmt.Errorf("readfile: %w", err)
+		}
+		cpus, err := parseCPUList(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", list, err)
+		}
+		for _, c := range cpus {
+			nodes["cpu"+strconv.Itoa(c)] = node
+		}
+	}
+	return nodes, nil
+}
+
+// parseCPUList parses a sysfs cpu list like "0-3,8-11"
+func parseCPUList(s string) ([]int, error) {
+	var cpus []int
+	if s == "" {
+		return cpus, nil
+	}
+	for _, r := range strings.Split(s, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("cpu list %q: %w", s, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("cpu list %q: %w", s, err)
+			}
+		}
+		for c := first; c <= last; c++ {
+			cpus = append(cpus, c)
+		}
+	}
+	return cpus, nil
+}
+
+// nodeTimes answers the summed times of the cpus of each NUMA node, named
+// like cpu-node0 after cpu-total
+func nodeTimes(times []cpu.TimesStat, nodes map[string]string) []cpu.TimesStat {
+	sums := make(map[string]*cpu.TimesStat)
+	for _, t := range times {
+		node, ok := nodes[t.CPU]
+		if !ok {
+			continue
+		}
+		sum, ok := sums[node]
+		if !ok {
+			sum = &cpu.TimesStat{CPU: "cpu-" + node}
+			sums[node] = sum
+		}
+		sum.User += t.User
+		sum.System += t.System
+		sum.Idle += t.Idle
+		sum.Nice += t.Nice
+		sum.Iowait += t.Iowait
+		sum.Irq += t.Irq
+		sum.Softirq += t.Softirq
+		sum.Steal += t.Steal
+		sum.Guest += t.Guest
+		sum.GuestNice += t.GuestNice
+	}
+
+	names := make([]string, 0, len(sums))
+	for node := range sums {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+
+	result := make([]cpu.TimesStat, 0, len(names))
+	for _, node := range names {
+		result = append(result, *sums[node])
+	}
+	return result
+}
@@ -0,0 +1,210 @@
+//go:build linux
+// +build linux
+
+package cputelemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Collect last level cache occupancy and memory bandwidth of the resctrl
+  ## monitoring groups (requires a kernel with resctrl mounted and a CPU with
+  ## RDT monitoring, e.g. "mount -t resctrl resctrl /sys/fs/resctrl").
+  # resctrl = true
+  # resctrl_path = "/sys/fs/resctrl"
+
+  ## Collect per-CPU instructions, cycles and IPC with perf_event_open.
+  ## Requires CAP_PERFMON (or CAP_SYS_ADMIN) or kernel.perf_event_paranoid <= 0.
+  # perf = false
+
+  ## CPUs to count with perf, in the kernel list format. Defaults to all
+  ## online CPUs.
+  ##   example: cpus = ["0-3", "8"]
+  # cpus = []
+`
+
+type CPUTelemetry struct {
+	Resctrl     bool     `toml:"resctrl"`
+	ResctrlPath string   `toml:"resctrl_path"`
+	Perf        bool     `toml:"perf"`
+	CPUs        []string `toml:"cpus"`
+
+	Log cua.Logger `toml:"-"`
+
+	cpus     []int
+	counters []*cpuCounters
+
+	// previous mbm counters per group and domain, for bandwidth rates
+	mbm     map[string]mbmSample
+	mbmLock sync.Mutex
+}
+
+type mbmSample struct {
+	total uint64
+	local uint64
+	ts    time.Time
+}
+
+// Description answers a description of this input plugin
+func (*CPUTelemetry) Description() string {
+	return "Hardware CPU telemetry: LLC occupancy, memory bandwidth and IPC"
+}
+
+// SampleConfig answers a sample configuration
+func (*CPUTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CPUTelemetry) Init() error {
+	if !c.Resctrl && !c.Perf {
+		return fmt.Errorf("at least one of resctrl or perf must be enabled")
+	}
+	if c.ResctrlPath == "" {
+		c.ResctrlPath = "/sys/fs/resctrl"
+	}
+	if c.Perf {
+		var err error
+		if len(c.CPUs) > 0 {
+			c.cpus, err = parseCPUList(c.CPUs)
+		} else {
+			c.cpus, err = onlineCPUs()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	c.mbm = make(map[string]mbmSample)
+	return nil
+}
+
+// Start opens the perf counters so they count continuously between gathers
+func (c *CPUTelemetry) Start(_ context.Context, _ cua.Accumulator) error {
+	if !c.Perf {
+		return nil
+	}
+	for _, cpu := range c.cpus {
+		counters, err := openCPUCounters(cpu)
+		if err != nil {
+			c.Stop()
+			return err
+		}
+		c.counters = append(c.counters, counters)
+	}
+	return nil
+}
+
+func (c *CPUTelemetry) Stop() {
+	for _, counters := range c.counters {
+		counters.close()
+	}
+	c.counters = nil
+}
+
+func (c *CPUTelemetry) Gather(_ context.Context, acc cua.Accumulator) error {
+	if c.Resctrl {
+		if err := c.gatherResctrl(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	for _, counters := range c.counters {
+		c.gatherPerf(acc, counters)
+	}
+	return nil
+}
+
+func (c *CPUTelemetry) gatherResctrl(acc cua.Accumulator) error {
+	groups, err := monitoringGroups(c.ResctrlPath)
+	if err != nil {
+		return err
+	}
+
+	c.mbmLock.Lock()
+	defer c.mbmLock.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	for name, dir := range groups {
+		samples, err := readGroup(name, dir)
+		if err != nil {
+			acc.AddError(fmt.Errorf("resctrl group %s: %w", name, err))
+			continue
+		}
+		for _, s := range samples {
+			fields := map[string]interface{}{
+				"llc_occupancy_bytes": s.occupancy,
+			}
+			if s.hasMBM {
+				fields["mbm_total_bytes"] = s.total
+				fields["mbm_local_bytes"] = s.local
+
+				key := s.group + ":" + s.domain
+				seen[key] = true
+				if prev, ok := c.mbm[key]; ok && s.total >= prev.total && s.local >= prev.local {
+					if elapsed := now.Sub(prev.ts).Seconds(); elapsed > 0 {
+						total := float64(s.total-prev.total) / elapsed
+						local := float64(s.local-prev.local) / elapsed
+						fields["mbm_total_bytes_per_sec"] = total
+						fields["mbm_local_bytes_per_sec"] = local
+						fields["mbm_remote_bytes_per_sec"] = total - local
+					}
+				}
+				c.mbm[key] = mbmSample{total: s.total, local: s.local, ts: now}
+			}
+			acc.AddFields("cpu_telemetry_rdt", fields, map[string]string{
+				"group":     s.group,
+				"l3_domain": s.domain,
+			}, now)
+		}
+	}
+
+	// forget groups which have been removed
+	for key := range c.mbm {
+		if !seen[key] {
+			delete(c.mbm, key)
+		}
+	}
+	return nil
+}
+
+func (c *CPUTelemetry) gatherPerf(acc cua.Accumulator, counters *cpuCounters) {
+	instructions, cycles, ok, err := counters.read()
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	if !ok {
+		// the first read only establishes the baseline
+		return
+	}
+
+	fields := map[string]interface{}{
+		"instructions": instructions,
+		"cycles":       cycles,
+	}
+	if cycles > 0 {
+		fields["ipc"] = float64(instructions) / float64(cycles)
+	}
+	tags := map[string]string{"cpu": fmt.Sprintf("cpu%d", counters.cpu)}
+	if counters.node != "" {
+		tags["numa_node"] = counters.node
+	}
+	acc.AddFields("cpu_telemetry_perf", fields, tags)
+}
+
+func init() {
+	inputs.Add("cpu_telemetry", func() cua.Input {
+		return &CPUTelemetry{
+			Resctrl:     true,
+			ResctrlPath: "/sys/fs/resctrl",
+		}
+	})
+}
@@ -0,0 +1,117 @@
+//go:build linux
+// +build linux
+
+package cputelemetry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMonData(t *testing.T, dir string, domain string, occupancy, total, local string) {
+	d := filepath.Join(dir, "mon_data", "mon_L3_"+domain)
+	require.NoError(t, os.MkdirAll(d, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(d, "llc_occupancy"), []byte(occupancy+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(d, "mbm_total_bytes"), []byte(total+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(d, "mbm_local_bytes"), []byte(local+"\n"), 0644))
+}
+
+func TestGatherResctrl(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "info"), 0755))
+	writeMonData(t, root, "00", "1024", "1000", "600")
+	writeMonData(t, root, "01", "2048", "3000", "3000")
+	writeMonData(t, filepath.Join(root, "batch"), "00", "4096", "500", "100")
+	writeMonData(t, filepath.Join(root, "batch", "mon_groups", "vm1"), "00", "Unavailable", "0", "0")
+	writeMonData(t, filepath.Join(root, "mon_groups", "web"), "00", "512", "10", "5")
+
+	c := &CPUTelemetry{Resctrl: true, ResctrlPath: root, Log: testutil.Logger{}}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, 4, int(acc.NMetrics()))
+
+	acc.AssertContainsTaggedFields(t, "cpu_telemetry_rdt",
+		map[string]interface{}{
+			"llc_occupancy_bytes": uint64(1024),
+			"mbm_total_bytes":     uint64(1000),
+			"mbm_local_bytes":     uint64(600),
+		},
+		map[string]string{"group": "/", "l3_domain": "0"})
+	acc.AssertContainsTaggedFields(t, "cpu_telemetry_rdt",
+		map[string]interface{}{
+			"llc_occupancy_bytes": uint64(4096),
+			"mbm_total_bytes":     uint64(500),
+			"mbm_local_bytes":     uint64(100),
+		},
+		map[string]string{"group": "batch", "l3_domain": "0"})
+	require.True(t, acc.HasPoint("cpu_telemetry_rdt",
+		map[string]string{"group": "mon_groups/web", "l3_domain": "0"},
+		"llc_occupancy_bytes", uint64(512)))
+
+	// rates are reported from the second gather on
+	writeMonData(t, root, "00", "1024", "5000", "1600")
+	acc.ClearMetrics()
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	var m *testutil.Metric
+	for _, metric := range acc.Metrics {
+		if metric.Tags["group"] == "/" && metric.Tags["l3_domain"] == "0" {
+			m = metric
+		}
+	}
+	require.NotNil(t, m)
+	total := m.Fields["mbm_total_bytes_per_sec"].(float64)
+	local := m.Fields["mbm_local_bytes_per_sec"].(float64)
+	require.Greater(t, total, 0.0)
+	require.InDelta(t, total-local, m.Fields["mbm_remote_bytes_per_sec"].(float64), 1e-6)
+	require.InDelta(t, 4.0, total/local, 1e-6)
+}
+
+func TestGatherResctrlNotMounted(t *testing.T) {
+	c := &CPUTelemetry{Resctrl: true, ResctrlPath: t.TempDir(), Log: testutil.Logger{}}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := parseCPUList([]string{"0-3,8", "2", "10-11"})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3, 8, 10, 11}, cpus)
+
+	_, err = parseCPUList([]string{"3-1"})
+	require.Error(t, err)
+	_, err = parseCPUList([]string{"a"})
+	require.Error(t, err)
+}
+
+func TestGatherPerf(t *testing.T) {
+	c := &CPUTelemetry{Perf: true, CPUs: []string{"0"}, Log: testutil.Logger{}}
+	require.NoError(t, c.Init())
+
+	var acc testutil.Accumulator
+	if err := c.Start(context.Background(), &acc); err != nil {
+		t.Skipf("perf events unavailable: %v", err)
+	}
+	defer c.Stop()
+
+	// the first gather primes the counters
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Zero(t, acc.NMetrics())
+
+	require.NoError(t, c.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.True(t, acc.HasTag("cpu_telemetry_perf", "cpu"))
+	require.True(t, acc.HasField("cpu_telemetry_perf", "instructions"))
+}
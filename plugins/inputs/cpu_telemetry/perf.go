@@ -0,0 +1,142 @@
+//go:build linux
+// +build linux
+
+package cputelemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// cpuCounters is a perf event group counting instructions and cycles on
+// one CPU
+type cpuCounters struct {
+	cpu  int
+	node string
+	fds  [2]int // instructions (group leader), cycles
+	buf  []byte
+
+	prevInstructions uint64
+	prevCycles       uint64
+	primed           bool
+}
+
+// group read format: nr, time_enabled, time_running, value per event
+const groupReadFormat = unix.PERF_FORMAT_GROUP | unix.PERF_FORMAT_TOTAL_TIME_ENABLED | unix.PERF_FORMAT_TOTAL_TIME_RUNNING
+
+func openCPUCounters(cpu int) (*cpuCounters, error) {
+	c := &cpuCounters{cpu: cpu, fds: [2]int{-1, -1}, buf: make([]byte, 8*5)}
+	for i, config := range []uint64{unix.PERF_COUNT_HW_INSTRUCTIONS, unix.PERF_COUNT_HW_CPU_CYCLES} {
+		attr := unix.PerfEventAttr{
+			Type:        unix.PERF_TYPE_HARDWARE,
+			Config:      config,
+			Read_format: groupReadFormat,
+		}
+		attr.Size = uint32(unsafe.Sizeof(attr))
+
+		fd, err := unix.PerfEventOpen(&attr, -1, cpu, c.fds[0], unix.PERF_FLAG_FD_CLOEXEC)
+		if err != nil {
+			c.close()
+			return nil, fmt.Errorf("perf_event_open on cpu %d: %w", cpu, err)
+		}
+		c.fds[i] = fd
+	}
+	c.node = numaNode(cpu)
+	return c, nil
+}
+
+// read returns the instructions and cycles counted since the previous read,
+// scaled for the time the group was not scheduled when the PMU is
+// multiplexed. ok is false on the first read.
+func (c *cpuCounters) read() (instructions, cycles uint64, ok bool, err error) {
+	n, err := unix.Read(c.fds[0], c.buf)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("reading counters of cpu %d: %w", c.cpu, err)
+	}
+	if n != len(c.buf) || binary.LittleEndian.Uint64(c.buf[0:8]) != 2 {
+		return 0, 0, false, fmt.Errorf("unexpected read of %d bytes from counters of cpu %d", n, c.cpu)
+	}
+
+	enabled := binary.LittleEndian.Uint64(c.buf[8:16])
+	running := binary.LittleEndian.Uint64(c.buf[16:24])
+	instructions = scale(binary.LittleEndian.Uint64(c.buf[24:32]), enabled, running)
+	cycles = scale(binary.LittleEndian.Uint64(c.buf[32:40]), enabled, running)
+
+	dInstructions, dCycles := instructions-c.prevInstructions, cycles-c.prevCycles
+	ok = c.primed
+	c.prevInstructions, c.prevCycles, c.primed = instructions, cycles, true
+	return dInstructions, dCycles, ok, nil
+}
+
+func scale(value, enabled, running uint64) uint64 {
+	if running == 0 || running == enabled {
+		return value
+	}
+	return uint64(float64(value) * float64(enabled) / float64(running))
+}
+
+func (c *cpuCounters) close() {
+	for i, fd := range c.fds {
+		if fd >= 0 {
+			_ = unix.Close(fd)
+			c.fds[i] = -1
+		}
+	}
+}
+
+// numaNode returns the NUMA node of a CPU, or "" when it cannot be determined
+func numaNode(cpu int) string {
+	matches, _ := filepath.Glob(fmt.Sprintf("/sys/devices/system/cpu/cpu%d/node*", cpu))
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(filepath.Base(matches[0]), "node")
+}
+
+func onlineCPUs() ([]int, error) {
+	buf, err := ioutil.ReadFile("/sys/devices/system/cpu/online")
+	if err != nil {
+		return nil, fmt.Errorf("reading online cpus: %w", err)
+	}
+	return parseCPUList([]string{strings.TrimSpace(string(buf))})
+}
+
+// parseCPUList parses CPU lists in the kernel format, e.g. "0-3,8"
+func parseCPUList(lists []string) ([]int, error) {
+	var cpus []int
+	seen := map[int]bool{}
+	for _, list := range lists {
+		for _, part := range strings.Split(list, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			first, last := part, part
+			if i := strings.IndexByte(part, '-'); i >= 0 {
+				first, last = part[:i], part[i+1:]
+			}
+			from, err := strconv.Atoi(first)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpu range %q", part)
+			}
+			to, err := strconv.Atoi(last)
+			if err != nil || to < from {
+				return nil, fmt.Errorf("invalid cpu range %q", part)
+			}
+			for cpu := from; cpu <= to; cpu++ {
+				if !seen[cpu] {
+					seen[cpu] = true
+					cpus = append(cpus, cpu)
+				}
+			}
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,119 @@
+//go:build linux
+// +build linux
+
+package cputelemetry
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resctrlSample holds the monitoring data of one group in one L3 domain
+type resctrlSample struct {
+	group     string
+	domain    string
+	occupancy uint64
+	total     uint64
+	local     uint64
+	hasMBM    bool
+}
+
+// monitoringGroups returns the directories of the resctrl monitoring groups
+// keyed by group name: the root group ("/"), control groups, and the
+// monitoring groups of each (e.g. "ctrl1/mon_groups/vm1").
+func monitoringGroups(root string) (map[string]string, error) {
+	if _, err := os.Stat(filepath.Join(root, "mon_data")); err != nil {
+		return nil, fmt.Errorf("resctrl monitoring not available at %s: %w", root, err)
+	}
+
+	groups := map[string]string{"/": root}
+	addMonGroups := func(ctrlName, ctrlDir string) error {
+		entries, err := ioutil.ReadDir(filepath.Join(ctrlDir, "mon_groups"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return fmt.Errorf("reading monitoring groups: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				groups[filepath.Join(ctrlName, "mon_groups", e.Name())] = filepath.Join(ctrlDir, "mon_groups", e.Name())
+			}
+		}
+		return nil
+	}
+
+	if err := addMonGroups("", root); err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("reading resctrl: %w", err)
+	}
+	for _, e := range entries {
+		switch e.Name() {
+		case "info", "mon_groups", "mon_data":
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if _, err := os.Stat(filepath.Join(dir, "mon_data")); err != nil || !e.IsDir() {
+			continue
+		}
+		groups[e.Name()] = dir
+		if err := addMonGroups(e.Name(), dir); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// readGroup reads the monitoring data of every L3 domain of a group.
+func readGroup(name, dir string) ([]resctrlSample, error) {
+	domains, err := filepath.Glob(filepath.Join(dir, "mon_data", "mon_L3_*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing L3 domains: %w", err)
+	}
+
+	samples := make([]resctrlSample, 0, len(domains))
+	for _, d := range domains {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(d), "mon_L3_"))
+		if err != nil {
+			continue
+		}
+		s := resctrlSample{group: name, domain: strconv.Itoa(id)}
+
+		// files read "Unavailable" while the hardware has no data for a
+		// newly created group; such domains are skipped until it does
+		occupancy, err := readCounter(filepath.Join(d, "llc_occupancy"))
+		if err != nil {
+			continue
+		}
+		s.occupancy = occupancy
+
+		if total, err := readCounter(filepath.Join(d, "mbm_total_bytes")); err == nil {
+			if local, err := readCounter(filepath.Join(d, "mbm_local_bytes")); err == nil {
+				s.total, s.local, s.hasMBM = total, local, true
+			}
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func readCounter(path string) (uint64, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return v, nil
+}
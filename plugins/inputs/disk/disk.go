@@ -18,6 +18,7 @@ type Stats struct {
 
 	MountPoints []string `toml:"mount_points"`
 	IgnoreFS    []string `toml:"ignore_fs"`
+	IgnoreOpts  []string `toml:"ignore_mount_opts"`
 }
 
 func (*Stats) Description() string {
@@ -33,6 +34,11 @@ var diskSampleConfig = `
 
   ## Ignore mount points by filesystem type.
   ignore_fs = ["tmpfs", "devtmpfs", "devfs", "iso9660", "overlay", "aufs", "squashfs"]
+
+  ## Ignore mount points by mount option, e.g. "nobrowse" on macOS to skip
+  ## the APFS system volumes (VM, Preboot, Update) sharing the container of
+  ## the data volume.
+  # ignore_mount_opts = []
 `
 
 func (*Stats) SampleConfig() string {
@@ -56,6 +62,9 @@ func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
 			continue
 		}
 		mountOpts := parseOptions(partitions[i].Opts)
+		if mountOpts.existsAny(s.IgnoreOpts) {
+			continue
+		}
 		tags := map[string]string{
 			"path":   du.Path,
 			"device": strings.ReplaceAll(partitions[i].Device, "/dev/", ""),
@@ -105,6 +114,15 @@ func (opts MountOptions) exists(opt string) bool {
 	return false
 }
 
+func (opts MountOptions) existsAny(names []string) bool {
+	for _, name := range names {
+		if opts.exists(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func parseOptions(opts []string) MountOptions {
 	return opts
 }
@@ -118,6 +118,10 @@ func TestDiskUsage(t *testing.T) {
 	// / and /home
 	_ = (&Stats{ps: &mps, MountPoints: []string{"/", "/home"}}).Gather(context.Background(), &acc)
 	assert.Equal(t, 2*expectedAllDiskMetrics+7, acc.NFields())
+
+	// Only / as /home is ignored by its errors option
+	_ = (&Stats{ps: &mps, IgnoreOpts: []string{"errors=remount-ro"}}).Gather(context.Background(), &acc)
+	assert.Equal(t, 2*expectedAllDiskMetrics+14, acc.NFields())
 }
 
 func TestDiskUsageHostMountPrefix(t *testing.T) {
@@ -25,6 +25,8 @@ type DiskIO struct {
 	NameTemplates    []string
 	Devices          []string
 	SkipSerialNumber bool
+	DiscardFlush     bool
+	ReportPressure   bool
 	initialized      bool
 }
 
@@ -60,6 +62,12 @@ var diskIOsampleConfig = `
   ## The typical use case is for LVM volumes, to get the VG/LV name instead of
   ## the near-meaningless DM-0 name.
   # name_templates = ["$ID_FS_LABEL","$DM_VG_NAME/$DM_LV_NAME"]
+  #
+  ## If true, report the discard and flush counters of the devices on Linux
+  ## kernels providing them (4.18+ and 5.5+).
+  # discard_flush = false
+  ## If true, report the io pressure stall information (Linux 4.20+).
+  # report_pressure = false
 `
 
 func (*DiskIO) SampleConfig() string {
@@ -103,6 +111,14 @@ func (s *DiskIO) Gather(ctx context.Context, acc cua.Accumulator) error {
 		return fmt.Errorf("error getting disk io info: %w", err)
 	}
 
+	var discardFlush map[string]map[string]interface{}
+	if s.DiscardFlush {
+		discardFlush, err = discardFlushStats()
+		if err != nil {
+			acc.AddError(fmt.Errorf("error getting discard and flush stats: %w", err))
+		}
+	}
+
 	for _, io := range diskio {
 
 		match := false
@@ -151,9 +167,18 @@ func (s *DiskIO) Gather(ctx context.Context, acc cua.Accumulator) error {
 			"merged_reads":     io.MergedReadCount,
 			"merged_writes":    io.MergedWriteCount,
 		}
+		for k, v := range discardFlush[io.Name] {
+			fields[k] = v
+		}
 		acc.AddCounter("diskio", fields, tags)
 	}
 
+	if s.ReportPressure {
+		if err := system.AddPressure(acc, "io"); err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	return nil
 }
 
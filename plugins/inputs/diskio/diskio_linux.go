@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"golang.org/x/sys/unix"
@@ -86,3 +88,51 @@ func (s *DiskIO) diskInfo(devName string) (map[string]string, error) {
 
 	return di, nil
 }
+
+// discardFlushStats answers the discard and flush counters by device name
+// read from /proc/diskstats (or $HOST_PROC/diskstats), the kernel adds the
+// discard columns as of 4.18 and the flush columns as of 5.5
+func discardFlushStats() (map[string]map[string]interface{}, error) {
+	procPath := "/proc"
+	if v := os.Getenv("HOST_PROC"); v != "" {
+		procPath = v
+	}
+	data, err := os.ReadFile(filepath.Join(procPath, "diskstats"))
+	if err != nil {
+		return nil, fmt.Errorf("readfile: %w", err)
+	}
+	return parseDiscardFlush(data)
+}
+
+func parseDiscardFlush(data []byte) (map[string]map[string]interface{}, error) {
+	stats := make(map[string]map[string]interface{})
+	scnr := bufio.NewScanner(bytes.NewReader(data))
+	for scnr.Scan() {
+		f := strings.Fields(scnr.Text())
+		// major, minor, name and at least the 15 values including discards
+		if len(f) < 18 {
+			continue
+		}
+		values := make([]uint64, 0, len(f)-3)
+		for _, v := range f[3:] {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("diskstats %s: %w", f[2], err)
+			}
+			values = append(values, n)
+		}
+
+		fields := map[string]interface{}{
+			"discards":        values[11],
+			"merged_discards": values[12],
+			"discard_bytes":   values[13] * 512,
+			"discard_time":    values[14],
+		}
+		if len(values) >= 17 {
+			fields["flushes"] = values[15]
+			fields["flush_time"] = values[16]
+		}
+		stats[f[2]] = fields
+	}
+	return stats, nil
+}
@@ -110,3 +110,27 @@ func TestDiskIOStats_diskTags(t *testing.T) {
 	dt := s.diskTags("null")
 	assert.Equal(t, map[string]string{"MY_PARAM_2": "myval2"}, dt)
 }
+
+func TestParseDiscardFlush(t *testing.T) {
+	stats, err := parseDiscardFlush([]byte(`   8       0 sda 100 2 300 4 500 6 700 8 0 10 12 13 1 14 8 15 16 17
+   8       1 sda1 100 2 300 4 500 6 700 8 0 10 12 13 1 14 8 15
+   7       0 loop0 0 0 0 0 0 0 0 0 0 0 0
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]interface{}{
+		"sda": {
+			"discards":        uint64(13),
+			"merged_discards": uint64(1),
+			"discard_bytes":   uint64(14 * 512),
+			"discard_time":    uint64(8),
+			"flushes":         uint64(15),
+			"flush_time":      uint64(16),
+		},
+		"sda1": {
+			"discards":        uint64(13),
+			"merged_discards": uint64(1),
+			"discard_bytes":   uint64(14 * 512),
+			"discard_time":    uint64(8),
+		},
+	}, stats)
+}
@@ -54,6 +54,17 @@ type Docker struct {
 	filtersCreated        bool
 	GatherServices        bool `toml:"gather_services"`
 	IncludeSourceTag      bool `toml:"source_tag"`
+	ContainerEvents       bool `toml:"container_events"`
+	containers            map[string]containerState // listed containers by ID, for container events
+	listed                map[string]types.Container // listed containers by ID, for incremental gathers
+	listedAt              time.Time
+}
+
+// containerState is the state of a container at the last gather
+type containerState struct {
+	name  string
+	image string
+	state string
 }
 
 // KB, MB, GB, TB, PB...human friendly
@@ -71,6 +82,10 @@ var (
 	sizeRegex       = regexp.MustCompile(`^(\d+(\.\d+)*) ?([kKmMgGtTpP])?[bB]?$`)
 	containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
 	now             = time.Now
+
+	// containerChanges are the events of the containers changing how they
+	// are listed
+	containerChanges = []string{"create", "start", "restart", "pause", "unpause", "die", "destroy", "rename", "update"}
 )
 
 var sampleConfig = `
@@ -102,6 +117,10 @@ var sampleConfig = `
   ## Timeout for docker list, info, and stats commands
   timeout = "5s"
 
+  ## Add an event when a container is listed for the first time, changes
+  ## state, or is no longer listed, e.g. when it is started or stopped.
+  # container_events = false
+
   ## Whether to report for each container per-device blkio (8:0, 8:1...) and
   ## network (eth0, eth1, ...) stats or not
   perdevice = true
@@ -135,6 +154,19 @@ func (d *Docker) Description() string {
 
 // Gather metrics from the docker server.
 func (d *Docker) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return d.gather(ctx, acc, d.listContainers)
+}
+
+// GatherChanges gathers the containers listed by the previous gathers, only
+// listing again the ones with events since.
+func (d *Docker) GatherChanges(ctx context.Context, acc cua.Accumulator) error {
+	if d.listed == nil {
+		return cua.ErrFullGather
+	}
+	return d.gather(ctx, acc, d.listChanges)
+}
+
+func (d *Docker) gather(ctx context.Context, acc cua.Accumulator, list func(context.Context, filters.Args) ([]types.Container, error)) error {
 	if d.client == nil {
 		c, err := d.getNewClient()
 		if err != nil {
@@ -186,13 +218,10 @@ func (d *Docker) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 
 	// List containers
-	opts := types.ContainerListOptions{
-		Filters: filterArgs,
-	}
 	gctx, cancel := context.WithTimeout(ctx, d.Timeout.Duration)
 	defer cancel()
 
-	containers, err := d.client.ContainerList(gctx, opts)
+	containers, err := list(gctx, filterArgs)
 	if errors.Is(err, context.DeadlineExceeded) {
 		return errListTimeout
 	}
@@ -200,6 +229,10 @@ func (d *Docker) Gather(ctx context.Context, acc cua.Accumulator) error {
 		return fmt.Errorf("container list: %w", err)
 	}
 
+	if d.ContainerEvents {
+		d.addContainerEvents(containers, acc)
+	}
+
 	// Get container data
 	var wg sync.WaitGroup
 	wg.Add(len(containers))
@@ -216,6 +249,87 @@ func (d *Docker) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// listContainers lists the containers in the states of filterArgs,
+// recording them for the incremental gathers
+func (d *Docker) listContainers(ctx context.Context, filterArgs filters.Args) ([]types.Container, error) {
+	at := now()
+	containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{Filters: filterArgs})
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	d.listed = make(map[string]types.Container, len(containers))
+	for _, container := range containers {
+		d.listed[container.ID] = container
+	}
+	d.listedAt = at
+	return containers, nil
+}
+
+// listChanges answers the containers listed before, updated with the ones
+// with events since, listing only these
+func (d *Docker) listChanges(ctx context.Context, filterArgs filters.Args) ([]types.Container, error) {
+	at := now()
+	changed, err := d.changedContainers(ctx, d.listedAt, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changed) > 0 {
+		args := filterArgs.Clone()
+		for id := range changed {
+			args.Add("id", id)
+		}
+		containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{Filters: args})
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		// changed containers no longer listed were removed, or changed to
+		// excluded states
+		for id := range changed {
+			delete(d.listed, id)
+		}
+		for _, container := range containers {
+			d.listed[container.ID] = container
+		}
+	}
+	d.listedAt = at
+
+	containers := make([]types.Container, 0, len(d.listed))
+	for _, container := range d.listed {
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+// changedContainers answers the IDs of the containers created, changing
+// state, renamed or removed between since and until
+func (d *Docker) changedContainers(ctx context.Context, since, until time.Time) (map[string]bool, error) {
+	args := filters.NewArgs(filters.Arg("type", "container"))
+	for _, action := range containerChanges {
+		args.Add("event", action)
+	}
+	messages, errs := d.client.Events(ctx, types.EventsOptions{
+		Since:   strconv.FormatInt(since.Unix(), 10),
+		Until:   strconv.FormatInt(until.Unix(), 10),
+		Filters: args,
+	})
+
+	changed := make(map[string]bool)
+	for {
+		select {
+		case msg := <-messages:
+			changed[msg.Actor.ID] = true
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return changed, nil
+			}
+			return nil, fmt.Errorf("container events: %w", err)
+		}
+	}
+}
+
 func (d *Docker) gatherSwarmInfo(ctx context.Context, acc cua.Accumulator) error {
 	gctx, cancel := context.WithTimeout(ctx, d.Timeout.Duration)
 	defer cancel()
@@ -418,6 +532,89 @@ func hostnameFromID(id string) string {
 	return id
 }
 
+// containerName answers the first name of the container matching the
+// container name filters, or "" when no name matches
+func (d *Docker) containerName(container types.Container) string {
+	for _, name := range container.Names {
+		trimmedName := strings.TrimPrefix(name, "/")
+		if d.containerFilter.Match(trimmedName) {
+			return trimmedName
+		}
+	}
+	return ""
+}
+
+// addContainerEvents adds an event for each container listed for the first
+// time or in another state than at the last gather, and for each container
+// no longer listed. The first gather only records the listed containers.
+func (d *Docker) addContainerEvents(containers []types.Container, acc cua.Accumulator) {
+	listed := make(map[string]containerState, len(containers))
+	for _, container := range containers {
+		cname := d.containerName(container)
+		if cname == "" {
+			continue
+		}
+		cs := containerState{name: cname, image: container.Image, state: container.State}
+		listed[container.ID] = cs
+
+		if d.containers == nil {
+			continue
+		}
+		if last, ok := d.containers[container.ID]; ok && last.state == cs.state {
+			continue
+		}
+		body := fmt.Sprintf("id: %s\nimage: %s\nstatus: %s", hostnameFromID(container.ID), container.Image, container.Status)
+		d.addContainerEvent(acc, cs, containerAction(cs.state), body)
+	}
+
+	for id, last := range d.containers {
+		if _, ok := listed[id]; ok {
+			continue
+		}
+		body := fmt.Sprintf("id: %s\nimage: %s", hostnameFromID(id), last.image)
+		last.state = "exited"
+		d.addContainerEvent(acc, last, "stopped", body)
+	}
+
+	d.containers = listed
+}
+
+func (d *Docker) addContainerEvent(acc cua.Accumulator, cs containerState, action, body string) {
+	imageName, imageVersion := docker.ParseImage(cs.image)
+	tags := map[string]string{
+		"engine_host":       d.engineHost,
+		"server_version":    d.serverVersion,
+		"container_name":    cs.name,
+		"container_image":   imageName,
+		"container_version": imageVersion,
+		"container_status":  cs.state,
+	}
+
+	severity := cua.Info
+	switch cs.state {
+	case "exited":
+		severity = cua.Warning
+	case "dead":
+		severity = cua.Error
+	}
+
+	acc.AddEvent(fmt.Sprintf("container %s %s", cs.name, action), body, severity, tags)
+}
+
+// containerAction answers how a container came to be in the state
+func containerAction(state string) string {
+	switch state {
+	case "running":
+		return "started"
+	case "exited":
+		return "stopped"
+	case "dead":
+		return "died"
+	default:
+		return state
+	}
+}
+
 func (d *Docker) gatherContainer(
 	ctx context.Context,
 	container types.Container,
@@ -425,17 +622,7 @@ func (d *Docker) gatherContainer(
 ) error {
 	var v *types.StatsJSON
 
-	// Parse container name
-	var cname string
-	for _, name := range container.Names {
-		trimmedName := strings.TrimPrefix(name, "/")
-		match := d.containerFilter.Match(trimmedName)
-		if match {
-			cname = trimmedName
-			break
-		}
-	}
-
+	cname := d.containerName(container)
 	if cname == "" {
 		return nil
 	}
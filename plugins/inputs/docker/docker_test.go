@@ -12,6 +12,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/stretchr/testify/require"
 )
@@ -24,6 +25,7 @@ type MockClient struct {
 	ServiceListF      func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
 	TaskListF         func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
 	NodeListF         func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
+	EventsF           func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
 }
 
 func (c *MockClient) Info(ctx context.Context) (types.Info, error) {
@@ -73,6 +75,13 @@ func (c *MockClient) NodeList(
 	return c.NodeListF(ctx, options)
 }
 
+func (c *MockClient) Events(
+	ctx context.Context,
+	options types.EventsOptions,
+) (<-chan events.Message, <-chan error) {
+	return c.EventsF(ctx, options)
+}
+
 var baseClient = MockClient{
 	InfoF: func(context.Context) (types.Info, error) {
 		return info, nil
@@ -1123,3 +1132,93 @@ func TestHostnameFromID(t *testing.T) {
 	}
 
 }
+
+func TestContainerEvents(t *testing.T) {
+	d := Docker{
+		Log:             testutil.Logger{},
+		ContainerEvents: true,
+		engineHost:      "absol",
+	}
+	require.NoError(t, d.createContainerFilters())
+
+	var acc testutil.Accumulator
+	running := types.Container{ID: "e2173b9478a6", Names: []string{"/etcd"}, Image: "quay.io/coreos/etcd:v2.2.2", State: "running", Status: "Up 5 seconds"}
+	d.addContainerEvents([]types.Container{running}, &acc)
+	require.Empty(t, acc.GetCUAMetrics())
+
+	exited := running
+	exited.State = "exited"
+	exited.Status = "Exited (0) 1 second ago"
+	other := types.Container{ID: "b7dfbb9478a6", Names: []string{"/etcd2"}, Image: "quay.io/coreos/etcd:v2.2.2", State: "running"}
+	d.addContainerEvents([]types.Container{exited, other}, &acc)
+	d.addContainerEvents([]types.Container{exited}, &acc)
+
+	var titles []string
+	for _, m := range acc.GetCUAMetrics() {
+		require.Equal(t, cua.Event, m.Type())
+		title, _ := m.GetField("title")
+		titles = append(titles, title.(string))
+	}
+	require.Equal(t, []string{"container etcd stopped", "container etcd2 started", "container etcd2 stopped"}, titles)
+
+	m, ok := acc.Get("event")
+	require.True(t, ok)
+	require.Equal(t, "warning", m.Fields["severity"])
+	require.Equal(t, "id: e2173b9478a6\nimage: quay.io/coreos/etcd:v2.2.2\nstatus: Exited (0) 1 second ago", m.Fields["body"])
+	require.Equal(t, "etcd", m.Tags["container_name"])
+	require.Equal(t, "exited", m.Tags["container_status"])
+}
+
+func TestGatherChanges(t *testing.T) {
+	etcd, etcd2, acme := containerList[0], containerList[1], containerList[2]
+	client := baseClient
+	listed := []types.Container{etcd, etcd2}
+	var listIDs []string
+	client.ContainerListF = func(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+		require.Equal(t, []string{"running"}, options.Filters.Get("status"))
+		listIDs = options.Filters.Get("id")
+		return listed, nil
+	}
+	var since string
+	client.EventsF = func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+		since = options.Since
+		require.True(t, options.Filters.ExactMatch("type", "container"))
+		messages := make(chan events.Message)
+		errs := make(chan error, 1)
+		go func() {
+			messages <- events.Message{Action: "die", Actor: events.Actor{ID: etcd2.ID}}
+			messages <- events.Message{Action: "start", Actor: events.Actor{ID: acme.ID}}
+			errs <- io.EOF
+		}()
+		return messages, errs
+	}
+
+	d := Docker{
+		Log:       testutil.Logger{},
+		newClient: func(string, *tls.Config) (Client, error) { return &client, nil },
+	}
+	gathered := func(acc *testutil.Accumulator) []string {
+		var names []string
+		for _, m := range acc.Metrics {
+			if m.Measurement == "docker_container_mem" {
+				names = append(names, m.Tags["container_name"])
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// changes are only gathered after a full gather
+	var acc testutil.Accumulator
+	require.ErrorIs(t, d.GatherChanges(context.Background(), &acc), cua.ErrFullGather)
+	require.NoError(t, d.Gather(context.Background(), &acc))
+	require.Equal(t, []string{"etcd", "etcd2"}, gathered(&acc))
+
+	// only the containers with events are listed again
+	listed = []types.Container{acme}
+	acc = testutil.Accumulator{}
+	require.NoError(t, d.GatherChanges(context.Background(), &acc))
+	require.NotEmpty(t, since)
+	require.ElementsMatch(t, []string{etcd2.ID, acme.ID}, listIDs)
+	require.Equal(t, []string{"acme", "etcd"}, gathered(&acc))
+}
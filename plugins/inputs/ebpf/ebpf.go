@@ -0,0 +1,233 @@
+//go:build linux
+// +build linux
+
+package ebpf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	bpf "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"golang.org/x/sys/unix"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Events to trace, any of:
+  ##   tcp_retransmit - retransmitted tcp segments
+  ##   tcp_connect    - latency histogram of tcp connects and failed connects
+  ##   exec           - process execs, including short lived processes
+  ## Requires CAP_BPF and CAP_PERFMON (or CAP_SYS_ADMIN) and Linux 4.16+.
+  # collect = ["tcp_retransmit", "tcp_connect", "exec"]
+
+  ## Maximum number of tcp connects in progress tracked at a time.
+  # max_connects = 10240
+`
+
+const (
+	collectRetransmit = "tcp_retransmit"
+	collectConnect    = "tcp_connect"
+	collectExec       = "exec"
+)
+
+type EBPF struct {
+	Collect     []string `toml:"collect"`
+	MaxConnects uint32   `toml:"max_connects"`
+
+	Log cua.Logger `toml:"-"`
+
+	collect  map[string]bool
+	counters *bpf.Map
+	latency  *bpf.Map
+	closers  []io.Closer
+	events   []int
+}
+
+// Description answers a description of this input plugin
+func (*EBPF) Description() string {
+	return "Trace tcp retransmits, tcp connect latency and process execs with eBPF"
+}
+
+// SampleConfig answers a sample configuration
+func (*EBPF) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EBPF) Init() error {
+	e.collect = make(map[string]bool)
+	for _, c := range e.Collect {
+		switch c {
+		case collectRetransmit, collectConnect, collectExec:
+			e.collect[c] = true
+		default:
+			return fmt.Errorf("invalid collect %q", c)
+		}
+	}
+	if len(e.collect) == 0 {
+		return fmt.Errorf("nothing to collect")
+	}
+	if e.MaxConnects == 0 {
+		e.MaxConnects = 10240
+	}
+	return nil
+}
+
+// Start loads the programs and attaches them to their tracepoints, they
+// count in kernel maps which are read on gather
+func (e *EBPF) Start(_ context.Context, _ cua.Accumulator) error {
+	if err := e.start(); err != nil {
+		e.Stop()
+		return err
+	}
+	return nil
+}
+
+func (e *EBPF) start() error {
+	// locked memory is only accounted to the rlimit before Linux 5.11, so
+	// loading may well succeed without lifting it
+	if err := rlimit.RemoveMemlock(); err != nil {
+		e.Log.Warnf("Removing memlock limit: %v", err)
+	}
+	dir, err := tracefs()
+	if err != nil {
+		return err
+	}
+
+	if e.counters, err = newCounters(); err != nil {
+		return err
+	}
+	e.closers = append(e.closers, e.counters)
+
+	if e.collect[collectRetransmit] {
+		if err := e.attach(dir, "tcp", "tcp_retransmit_skb", func(*format) (asm.Instructions, error) {
+			return counterProgram(e.counters, counterRetransmits), nil
+		}); err != nil {
+			return err
+		}
+	}
+	if e.collect[collectExec] {
+		if err := e.attach(dir, "sched", "sched_process_exec", func(*format) (asm.Instructions, error) {
+			return counterProgram(e.counters, counterExecs), nil
+		}); err != nil {
+			return err
+		}
+	}
+	if e.collect[collectConnect] {
+		if e.latency, err = newLatency(); err != nil {
+			return err
+		}
+		e.closers = append(e.closers, e.latency)
+		start, err := newConnectStart(e.MaxConnects)
+		if err != nil {
+			return err
+		}
+		e.closers = append(e.closers, start)
+		if err := e.attach(dir, "sock", "inet_sock_set_state", func(f *format) (asm.Instructions, error) {
+			return connectProgram(f, start, e.latency, e.counters)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attach loads the program built for the format of the tracepoint
+// group/name and attaches it
+func (e *EBPF) attach(dir, group, name string, build func(*format) (asm.Instructions, error)) error {
+	f, err := readFormat(dir, group, name)
+	if err != nil {
+		return err
+	}
+	insns, err := build(f)
+	if err != nil {
+		return fmt.Errorf("tracepoint %s/%s: %w", group, name, err)
+	}
+	prog, err := newProgram(name, insns)
+	if err != nil {
+		return err
+	}
+	e.closers = append(e.closers, prog)
+
+	fd, err := attachTracepoint(f.id, prog.FD())
+	if err != nil {
+		return fmt.Errorf("tracepoint %s/%s: %w", group, name, err)
+	}
+	e.events = append(e.events, fd)
+	e.Log.Debugf("Attached %s/%s", group, name)
+	return nil
+}
+
+func (e *EBPF) Stop() {
+	for _, fd := range e.events {
+		_ = unix.Close(fd)
+	}
+	e.events = nil
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		_ = e.closers[i].Close()
+	}
+	e.closers = nil
+	e.counters = nil
+	e.latency = nil
+}
+
+func (e *EBPF) Gather(_ context.Context, acc cua.Accumulator) error {
+	if e.counters == nil {
+		return nil
+	}
+	counters := make([]uint64, numCounters)
+	for i := range counters {
+		if err := e.counters.Lookup(uint32(i), &counters[i]); err != nil {
+			return fmt.Errorf("reading counters: %w", err)
+		}
+	}
+
+	tcp := make(map[string]interface{})
+	if e.collect[collectRetransmit] {
+		tcp["retransmits"] = counters[counterRetransmits]
+	}
+	if e.collect[collectConnect] {
+		tcp["connects"] = counters[counterConnects]
+		tcp["connect_failures"] = counters[counterConnectFailures]
+	}
+	if len(tcp) > 0 {
+		acc.AddCounter("ebpf_tcp", tcp, nil)
+	}
+	if e.collect[collectExec] {
+		acc.AddCounter("ebpf_process", map[string]interface{}{
+			"execs": counters[counterExecs],
+		}, nil)
+	}
+
+	if e.latency != nil {
+		buckets := make(map[string]interface{})
+		for i := 0; i < numBuckets; i++ {
+			var n uint64
+			if err := e.latency.Lookup(uint32(i), &n); err != nil {
+				return fmt.Errorf("reading connect latency: %w", err)
+			}
+			if n > 0 {
+				buckets[strconv.FormatFloat(bucketValue(i), 'g', -1, 64)] = int64(n)
+			}
+		}
+		if len(buckets) > 0 {
+			acc.AddCumulativeHistogram("ebpf_tcp_connect_latency", buckets, nil)
+		}
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("ebpf", func() cua.Input {
+		return &EBPF{
+			Collect:     []string{collectRetransmit, collectConnect, collectExec},
+			MaxConnects: 10240,
+		}
+	})
+}
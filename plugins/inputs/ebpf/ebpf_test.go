@@ -0,0 +1,126 @@
+//go:build linux
+// +build linux
+
+package ebpf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const inetSockSetStateFormat = `name: inet_sock_set_state
+ID: 2187
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:const void * skaddr;	offset:8;	size:8;	signed:0;
+	field:int oldstate;	offset:16;	size:4;	signed:1;
+	field:int newstate;	offset:20;	size:4;	signed:1;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u16 family;	offset:28;	size:2;	signed:0;
+	field:__u16 protocol;	offset:30;	size:2;	signed:0;
+	field:__u8 saddr[4];	offset:32;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:36;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:40;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:56;	size:16;	signed:0;
+
+print fmt: "family=%s protocol=%s", REC->family, REC->protocol
+`
+
+func TestParseFormat(t *testing.T) {
+	f, err := parseFormat([]byte(inetSockSetStateFormat))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2187), f.id)
+	require.Equal(t, field{offset: 8, size: 8}, f.fields["skaddr"])
+	require.Equal(t, field{offset: 30, size: 2}, f.fields["protocol"])
+	require.Equal(t, field{offset: 56, size: 16}, f.fields["daddr_v6"])
+
+	_, err = f.field("newstate", 4)
+	require.NoError(t, err)
+	_, err = f.field("newstate", 8)
+	require.Error(t, err)
+	_, err = f.field("missing", 4)
+	require.Error(t, err)
+
+	_, err = parseFormat([]byte("format:\n"))
+	require.Error(t, err)
+}
+
+func TestBucketValue(t *testing.T) {
+	require.Equal(t, 0.000002, bucketValue(0))
+	require.Equal(t, 0.001024, bucketValue(9))
+	require.Equal(t, 1.048576, bucketValue(19))
+}
+
+func TestInit(t *testing.T) {
+	e := &EBPF{Collect: []string{"exec", "bogus"}}
+	require.Error(t, e.Init())
+
+	e = &EBPF{}
+	require.Error(t, e.Init())
+
+	e = &EBPF{Collect: []string{"exec"}}
+	require.NoError(t, e.Init())
+	require.Equal(t, uint32(10240), e.MaxConnects)
+}
+
+func TestGather(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping test loading eBPF programs in short mode")
+	}
+	e := &EBPF{
+		Collect: []string{collectRetransmit, collectConnect, collectExec},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	if err := e.Start(context.Background(), &acc); err != nil {
+		if errors.Is(err, os.ErrPermission) || errors.Is(err, os.ErrNotExist) {
+			t.Skipf("Cannot load eBPF programs: %v", err)
+		}
+		require.NoError(t, err)
+	}
+	defer e.Stop()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed.Close()
+	_, err = net.Dial("tcp", closed.Addr().String())
+	require.Error(t, err)
+	require.NoError(t, exec.Command("true").Run())
+
+	require.NoError(t, e.Gather(context.Background(), &acc))
+
+	connects, ok := acc.Get("ebpf_tcp")
+	require.True(t, ok)
+	require.GreaterOrEqual(t, connects.Fields["connects"], uint64(1))
+	require.GreaterOrEqual(t, connects.Fields["connect_failures"], uint64(1))
+	require.Contains(t, connects.Fields, "retransmits")
+	execs, ok := acc.Get("ebpf_process")
+	require.True(t, ok)
+	require.GreaterOrEqual(t, execs.Fields["execs"], uint64(1))
+
+	latency, ok := acc.Get("ebpf_tcp_connect_latency")
+	require.True(t, ok)
+	require.Equal(t, cua.CumulativeHistogram, latency.Type)
+	require.NotEmpty(t, latency.Fields)
+}
@@ -0,0 +1,223 @@
+//go:build linux
+// +build linux
+
+package ebpf
+
+import (
+	"fmt"
+	"math"
+
+	bpf "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+)
+
+// indexes of the counters map
+const (
+	counterRetransmits = iota
+	counterConnects
+	counterConnectFailures
+	counterExecs
+	numCounters
+)
+
+// log2 buckets of the connect latency histogram, in microseconds
+const numBuckets = 64
+
+// tcp states of the kernel, include/net/tcp_states.h
+const (
+	tcpEstablished = 1
+	tcpSynSent     = 2
+)
+
+const ipprotoTCP = 6
+
+func newCounters() (*bpf.Map, error) {
+	m, err := bpf.NewMap(&bpf.MapSpec{
+		Name:       "cua_counters",
+		Type:       bpf.Array,
+		KeySize:    4,
+		ValueSize:  8,
+		MaxEntries: numCounters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating counters map: %w", err)
+	}
+	return m, nil
+}
+
+func newLatency() (*bpf.Map, error) {
+	m, err := bpf.NewMap(&bpf.MapSpec{
+		Name:       "cua_latency",
+		Type:       bpf.Array,
+		KeySize:    4,
+		ValueSize:  8,
+		MaxEntries: numBuckets,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating latency map: %w", err)
+	}
+	return m, nil
+}
+
+// newConnectStart answers the map of the connect start times by socket, an
+// LRU so sockets which never leave SYN_SENT do not fill it up
+func newConnectStart(maxEntries uint32) (*bpf.Map, error) {
+	m, err := bpf.NewMap(&bpf.MapSpec{
+		Name:       "cua_connect",
+		Type:       bpf.LRUHash,
+		KeySize:    8,
+		ValueSize:  8,
+		MaxEntries: maxEntries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating connect map: %w", err)
+	}
+	return m, nil
+}
+
+func newProgram(name string, insns asm.Instructions) (*bpf.Program, error) {
+	prog, err := bpf.NewProgram(&bpf.ProgramSpec{
+		Name:         name,
+		Type:         bpf.TracePoint,
+		Instructions: insns,
+		License:      "MIT",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading program %s: %w", name, err)
+	}
+	return prog, nil
+}
+
+// increment atomically adds one to the counter at index of the array map m,
+// jumping to exit when the lookup fails. It clobbers r0 to r2.
+func increment(m *bpf.Map, index int32, exit string) asm.Instructions {
+	return asm.Instructions{
+		asm.StoreImm(asm.RFP, -4, int64(index), asm.Word),
+		asm.LoadMapPtr(asm.R1, m.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, exit),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+	}
+}
+
+func exit() asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Imm(asm.R0, 0).Sym("exit"),
+		asm.Return(),
+	}
+}
+
+// counterProgram counts the hits of a tracepoint
+func counterProgram(counters *bpf.Map, index int32) asm.Instructions {
+	return append(increment(counters, index, "exit"), exit()...)
+}
+
+// connectProgram measures the latency of tcp connects on the
+// sock/inet_sock_set_state tracepoint, from the transition of a socket to
+// SYN_SENT until it leaves SYN_SENT, counting the failed connects which do
+// not go on to ESTABLISHED
+func connectProgram(f *format, start, latency, counters *bpf.Map) (asm.Instructions, error) {
+	skaddr, err := f.field("skaddr", 8)
+	if err != nil {
+		return nil, err
+	}
+	oldstate, err := f.field("oldstate", 4)
+	if err != nil {
+		return nil, err
+	}
+	newstate, err := f.field("newstate", 4)
+	if err != nil {
+		return nil, err
+	}
+	protocol, err := f.field("protocol", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	// r6 context, r7 new state, r8 old state then bucket, r9 start then
+	// latency; the socket key is at fp-16 and the start time at fp-24
+	insns := asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.LoadMem(asm.R7, asm.R6, protocol.offset, asm.Half),
+		asm.JNE.Imm(asm.R7, ipprotoTCP, "exit"),
+		asm.LoadMem(asm.R7, asm.R6, skaddr.offset, asm.DWord),
+		asm.StoreMem(asm.RFP, -16, asm.R7, asm.DWord),
+		asm.LoadMem(asm.R7, asm.R6, newstate.offset, asm.Word),
+		asm.LoadMem(asm.R8, asm.R6, oldstate.offset, asm.Word),
+		asm.JNE.Imm(asm.R7, tcpSynSent, "leave"),
+
+		// connect started
+		asm.FnKtimeGetNs.Call(),
+		asm.StoreMem(asm.RFP, -24, asm.R0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, start.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -24),
+		asm.Mov.Imm(asm.R4, 0), // BPF_ANY
+		asm.FnMapUpdateElem.Call(),
+		asm.Ja.Label("exit"),
+
+		// connect done, when the socket was seen entering SYN_SENT
+		asm.JNE.Imm(asm.R8, tcpSynSent, "exit").Sym("leave"),
+		asm.LoadMapPtr(asm.R1, start.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "exit"),
+		asm.LoadMem(asm.R9, asm.R0, 0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, start.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.FnMapDeleteElem.Call(),
+		asm.JEq.Imm(asm.R7, tcpEstablished, "established"),
+	}
+	insns = append(insns, increment(counters, counterConnectFailures, "exit")...)
+	insns = append(insns,
+		asm.Ja.Label("exit"),
+
+		// latency in microseconds, bucket floor(log2(latency))
+		asm.FnKtimeGetNs.Call().Sym("established"),
+		asm.Sub.Reg(asm.R0, asm.R9),
+		asm.Mov.Reg(asm.R9, asm.R0),
+		asm.Div.Imm(asm.R9, 1000),
+		asm.Mov.Imm(asm.R8, 0),
+	)
+	shifts := []int32{32, 16, 8, 4, 2, 1}
+	for i, shift := range shifts {
+		next := "bucket"
+		if i+1 < len(shifts) {
+			next = fmt.Sprintf("log2_%d", shifts[i+1])
+		}
+		insns = append(insns,
+			asm.Mov.Reg(asm.R1, asm.R9).Sym(fmt.Sprintf("log2_%d", shift)),
+			asm.RSh.Imm(asm.R1, shift),
+			asm.JEq.Imm(asm.R1, 0, next),
+			asm.Mov.Reg(asm.R9, asm.R1),
+			asm.Add.Imm(asm.R8, shift),
+		)
+	}
+	insns = append(insns,
+		asm.StoreMem(asm.RFP, -4, asm.R8, asm.Word).Sym("bucket"),
+		asm.LoadMapPtr(asm.R1, latency.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "count"),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+	)
+	count := increment(counters, counterConnects, "exit")
+	count[0] = count[0].Sym("count")
+	insns = append(insns, count...)
+	return append(insns, exit()...), nil
+}
+
+// bucketValue answers the upper bound of the latency bucket in seconds,
+// bucket i holding the latencies from 2^i up to 2^(i+1) microseconds
+func bucketValue(bucket int) float64 {
+	return math.Ldexp(1, bucket+1) / 1e6
+}
@@ -0,0 +1,161 @@
+//go:build linux
+// +build linux
+
+package ebpf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// tracefs mount points, the debugfs one for kernels before 4.1
+var tracefsDirs = []string{"/sys/kernel/tracing", "/sys/kernel/debug/tracing"}
+
+// field of a tracepoint record
+type field struct {
+	offset int16
+	size   int
+}
+
+// format of a tracepoint, the id and fields of its records
+type format struct {
+	id     uint64
+	fields map[string]field
+}
+
+func tracefs() (string, error) {
+	for _, dir := range tracefsDirs {
+		if _, err := os.Stat(filepath.Join(dir, "events")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("tracefs not mounted at %s", strings.Join(tracefsDirs, " or "))
+}
+
+// readFormat reads the format of the tracepoint group/name, so the programs
+// use the record layout of the running kernel
+func readFormat(dir, group, name string) (*format, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "events", group, name, "format"))
+	if err != nil {
+		return nil, fmt.Errorf("tracepoint %s/%s: %w", group, name, err)
+	}
+	f, err := parseFormat(data)
+	if err != nil {
+		return nil, fmt.Errorf("tracepoint %s/%s: %w", group, name, err)
+	}
+	return f, nil
+}
+
+// parseFormat parses a tracepoint format file, e.g.
+//
+//	ID: 2187
+//	format:
+//		field:const void * skaddr;	offset:8;	size:8;	signed:0;
+func parseFormat(data []byte) (*format, error) {
+	f := &format{fields: make(map[string]field)}
+	hasID := false
+	scnr := bufio.NewScanner(bytes.NewReader(data))
+	for scnr.Scan() {
+		line := strings.TrimSpace(scnr.Text())
+		if strings.HasPrefix(line, "ID:") {
+			id, err := strconv.ParseUint(strings.TrimSpace(line[3:]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid id: %w", err)
+			}
+			f.id, hasID = id, true
+			continue
+		}
+		if !strings.HasPrefix(line, "field:") {
+			continue
+		}
+		var decl string
+		var fld field
+		for _, part := range strings.Split(line, ";") {
+			kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "field":
+				decl = kv[1]
+			case "offset":
+				v, err := strconv.ParseInt(kv[1], 10, 16)
+				if err != nil {
+					return nil, fmt.Errorf("invalid offset of %q: %w", decl, err)
+				}
+				fld.offset = int16(v)
+			case "size":
+				v, err := strconv.Atoi(kv[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid size of %q: %w", decl, err)
+				}
+				fld.size = v
+			}
+		}
+		// the name is the last word of the declaration, without array bounds
+		words := strings.Fields(decl)
+		if len(words) == 0 {
+			continue
+		}
+		fname := strings.TrimLeft(words[len(words)-1], "*")
+		if i := strings.IndexByte(fname, '['); i >= 0 {
+			fname = fname[:i]
+		}
+		f.fields[fname] = fld
+	}
+	if err := scnr.Err(); err != nil {
+		return nil, fmt.Errorf("scanning format: %w", err)
+	}
+	if !hasID {
+		return nil, fmt.Errorf("no id in format")
+	}
+	return f, nil
+}
+
+// field answers the field name, which must have the size
+func (f *format) field(name string, size int) (field, error) {
+	fld, ok := f.fields[name]
+	if !ok {
+		return field{}, fmt.Errorf("no field %s", name)
+	}
+	if fld.size != size {
+		return field{}, fmt.Errorf("field %s has size %d, expected %d", name, fld.size, size)
+	}
+	return fld, nil
+}
+
+// attachTracepoint attaches the program to the tracepoint with the id, the
+// program runs on all CPUs until the returned perf event is closed
+func attachTracepoint(id uint64, progFD int) (int, error) {
+	attr := unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_TRACEPOINT,
+		Config:      id,
+		Sample_type: unix.PERF_SAMPLE_RAW,
+		Sample:      1,
+		Wakeup:      1,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+
+	fd, err := unix.PerfEventOpen(&attr, -1, 0, -1, unix.PERF_FLAG_FD_CLOEXEC)
+	if err != nil {
+		return -1, fmt.Errorf("perf_event_open: %w", err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_SET_BPF, progFD); err != nil {
+		_ = unix.Close(fd)
+		return -1, fmt.Errorf("attaching program: %w", err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+		_ = unix.Close(fd)
+		return -1, fmt.Errorf("enabling tracepoint: %w", err)
+	}
+	return fd, nil
+}
@@ -36,6 +36,11 @@ const sampleConfig = `
   ## Delay before the process is restarted after an unexpected termination
   restart_delay = "10s"
 
+  ## Environment variables passed to the program, as "key=value" pairs in
+  ## addition to the environment of the agent.
+  ## eg: environment = ["API_TOKEN=secret", "LD_LIBRARY_PATH=/opt/lib"]
+  # environment = []
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -51,6 +56,7 @@ type Execd struct {
 	Signal       string          `toml:"signal"`
 	Command      []string        `toml:"command"`
 	RestartDelay config.Duration `toml:"restart_delay"`
+	Environment  []string        `toml:"environment"`
 }
 
 func (e *Execd) SampleConfig() string {
@@ -68,7 +74,7 @@ func (e *Execd) SetParser(parser parsers.Parser) {
 func (e *Execd) Start(ctx context.Context, acc cua.Accumulator) error {
 	e.acc = acc
 	var err error
-	e.process, err = process.New(e.Command)
+	e.process, err = process.New(e.Command, e.Environment)
 	if err != nil {
 		return fmt.Errorf("error creating new process: %w", err)
 	}
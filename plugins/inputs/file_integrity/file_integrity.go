@@ -0,0 +1,450 @@
+package fileintegrity
+
+import (
+	"context"
+	"crypto/md5"  //nolint:gosec // G501: offered for comparison with existing baselines
+	"crypto/sha1" //nolint:gosec // G505: offered for comparison with existing baselines
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/fsnotify/fsnotify"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Files and directories to watch. The files in a directory are watched,
+  ## and those of its subdirectories with recursive = true.
+  paths = ["/etc"]
+  # recursive = false
+
+  ## Paths to leave out, globs accepted.
+  # exclude = ["/etc/mtab", "/etc/*.swp"]
+
+  ## Hash of the file contents to report; one of "sha256", "sha512",
+  ## "sha1", "md5" or "" for none. A write leaving the hash unchanged is
+  ## not reported.
+  # hash = "sha256"
+  ## Files larger than this are not hashed.
+  # max_hash_size = "100MB"
+
+  ## Add each change as an event titled after the path and operation, e.g.
+  ## "/etc/passwd write", rather than as a metric.
+  # events = false
+  ## Severity of the events; one of "info", "warning", "error" or "critical".
+  # event_severity = "warning"
+
+  ## Time a file has to be left alone before its change is reported, so a
+  ## file written in several steps is reported once, with its final hash.
+  # settle_time = "100ms"
+`
+
+// state of a file, to report only actual changes
+type state struct {
+	hash string
+	mode os.FileMode
+	uid  uint32
+	gid  uint32
+}
+
+type FileIntegrity struct {
+	Paths         []string        `toml:"paths"`
+	Recursive     bool            `toml:"recursive"`
+	Exclude       []string        `toml:"exclude"`
+	Hash          string          `toml:"hash"`
+	MaxHashSize   config.Size     `toml:"max_hash_size"`
+	Events        bool            `toml:"events"`
+	EventSeverity string          `toml:"event_severity"`
+	SettleTime    config.Duration `toml:"settle_time"`
+
+	Log cua.Logger `toml:"-"`
+
+	exclude  filter.Filter
+	newHash  func() hash.Hash
+	severity cua.Severity
+
+	acc     cua.Accumulator
+	watcher *fsnotify.Watcher
+	wg      sync.WaitGroup
+
+	// watched directories, true when all their files are reported rather
+	// than only the configured ones
+	dirs   map[string]bool
+	files  map[string]bool
+	states map[string]state
+
+	// changes waiting for their files to settle, by path
+	pending map[string]*change
+}
+
+// change of a file waiting to be reported
+type change struct {
+	operation string
+	last      time.Time
+}
+
+// Description answers a description of this input plugin
+func (*FileIntegrity) Description() string {
+	return "Report changes to files, with their hash and owner, for file integrity monitoring"
+}
+
+// SampleConfig answers a sample configuration
+func (*FileIntegrity) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *FileIntegrity) Init() error {
+	if len(f.Paths) == 0 {
+		return fmt.Errorf("no paths configured")
+	}
+
+	var err error
+	f.exclude, err = filter.Compile(f.Exclude)
+	if err != nil {
+		return fmt.Errorf("exclude: %w", err)
+	}
+
+	switch f.Hash {
+	case "":
+	case "sha256":
+		f.newHash = sha256.New
+	case "sha512":
+		f.newHash = sha512.New
+	case "sha1":
+		f.newHash = sha1.New
+	case "md5":
+		f.newHash = md5.New
+	default:
+		return fmt.Errorf("invalid hash %q", f.Hash)
+	}
+
+	if f.Events {
+		severity, err := cua.ParseSeverity(f.EventSeverity)
+		if err != nil {
+			return fmt.Errorf("event_severity: %w", err)
+		}
+		f.severity = severity
+	}
+	return nil
+}
+
+// Start watches the paths, taking the current state of their files as the
+// baseline changes are reported against
+func (f *FileIntegrity) Start(_ context.Context, acc cua.Accumulator) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	f.acc = acc
+	f.watcher = watcher
+	f.dirs = make(map[string]bool)
+	f.files = make(map[string]bool)
+	f.states = make(map[string]state)
+	f.pending = make(map[string]*change)
+
+	for _, path := range f.Paths {
+		path = filepath.Clean(path)
+		info, err := os.Stat(path)
+		if err != nil {
+			f.Log.Warnf("Watching %s: %v", path, err)
+			continue
+		}
+		if info.IsDir() {
+			if err := f.watchDir(path, false); err != nil {
+				_ = watcher.Close()
+				return err
+			}
+			continue
+		}
+		// watch the directory, so the file is still watched when it is
+		// replaced, e.g. by an editor renaming a new copy over it
+		f.files[path] = true
+		dir := filepath.Dir(path)
+		if _, ok := f.dirs[dir]; !ok {
+			if err := watcher.Add(dir); err != nil {
+				_ = watcher.Close()
+				return fmt.Errorf("watching %s: %w", dir, err)
+			}
+			f.dirs[dir] = false
+		}
+		if st, _, err := f.stat(path); err == nil {
+			f.states[path] = st
+		}
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.run()
+	}()
+	return nil
+}
+
+// watchDir watches the directory and, when recursive, its subdirectories,
+// recording the state of their files. With report the files are reported
+// as created, for directories created while watching.
+func (f *FileIntegrity) watchDir(dir string, report bool) error {
+	if err := f.watcher.Add(dir); err != nil {
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+	f.dirs[dir] = true
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if f.exclude != nil && f.exclude.Match(path) {
+			continue
+		}
+		if entry.IsDir() {
+			if f.Recursive {
+				if err := f.watchDir(path, report); err != nil {
+					f.Log.Warnf("Watching %s: %v", path, err)
+				}
+			}
+			continue
+		}
+		if report {
+			f.pending[path] = &change{operation: "create", last: time.Now()}
+			continue
+		}
+		if st, _, err := f.stat(path); err == nil {
+			f.states[path] = st
+		}
+	}
+	return nil
+}
+
+func (f *FileIntegrity) run() {
+	settle := time.Duration(f.SettleTime)
+	tick := settle / 2
+	if tick <= 0 {
+		tick = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-f.watcher.Events:
+			if !ok {
+				f.flush(time.Now())
+				return
+			}
+			f.handle(event)
+		case now := <-ticker.C:
+			f.flush(now.Add(-settle))
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+			f.acc.AddError(fmt.Errorf("watching: %w", err))
+		}
+	}
+}
+
+func (f *FileIntegrity) handle(event fsnotify.Event) {
+	path := filepath.Clean(event.Name)
+	all, ok := f.dirs[filepath.Dir(path)]
+	if !ok || (!all && !f.files[path]) {
+		return
+	}
+	if f.exclude != nil && f.exclude.Match(path) {
+		return
+	}
+
+	var operation string
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if all && f.Recursive {
+				if err := f.watchDir(path, true); err != nil {
+					f.acc.AddError(err)
+				}
+			}
+			return
+		}
+		operation = "create"
+	case event.Op&fsnotify.Write != 0:
+		operation = "write"
+	case event.Op&fsnotify.Chmod != 0:
+		operation = "chmod"
+	case event.Op&fsnotify.Remove != 0:
+		operation = "remove"
+	case event.Op&fsnotify.Rename != 0:
+		operation = "rename"
+	default:
+		return
+	}
+
+	c, ok := f.pending[path]
+	if !ok {
+		f.pending[path] = &change{operation: operation, last: time.Now()}
+		return
+	}
+	c.last = time.Now()
+	// writes and mode changes of a new file are part of its creation
+	if (operation == "write" || operation == "chmod") && c.operation == "create" {
+		return
+	}
+	if operation == "chmod" && c.operation == "write" {
+		return
+	}
+	c.operation = operation
+}
+
+// flush reports the pending changes of the files left alone since before
+func (f *FileIntegrity) flush(before time.Time) {
+	for path, c := range f.pending {
+		if c.last.After(before) {
+			continue
+		}
+		delete(f.pending, path)
+		if c.operation == "remove" || c.operation == "rename" {
+			f.removed(path, c.operation, c.last)
+			continue
+		}
+		f.change(path, c.operation, c.last)
+	}
+}
+
+// change reports the file as changed by the operation, unless its hash,
+// mode and owner are unchanged
+func (f *FileIntegrity) change(path, operation string, now time.Time) {
+	st, size, err := f.stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			f.acc.AddError(err)
+		}
+		return
+	}
+	prev, known := f.states[path]
+	f.states[path] = st
+	if known && prev == st && operation != "create" {
+		return
+	}
+
+	fields := map[string]interface{}{
+		"size": size,
+		"mode": fmt.Sprintf("%04o", uint32(st.mode.Perm())),
+		"uid":  int64(st.uid),
+		"gid":  int64(st.gid),
+	}
+	if st.hash != "" {
+		fields["hash"] = st.hash
+		if known && prev.hash != "" && prev.hash != st.hash {
+			fields["previous_hash"] = prev.hash
+		}
+	}
+	f.add(path, operation, fields, now)
+}
+
+func (f *FileIntegrity) removed(path, operation string, now time.Time) {
+	fields := map[string]interface{}{}
+	if prev, ok := f.states[path]; ok && prev.hash != "" {
+		fields["previous_hash"] = prev.hash
+	}
+	delete(f.states, path)
+	f.add(path, operation, fields, now)
+}
+
+func (f *FileIntegrity) add(path, operation string, fields map[string]interface{}, now time.Time) {
+	tags := map[string]string{
+		"path":      path,
+		"operation": operation,
+	}
+	if f.Events {
+		f.acc.AddEvent(path+" "+operation, eventBody(fields), f.severity, tags, now)
+		return
+	}
+	if len(fields) == 0 {
+		fields["changed"] = true
+	}
+	f.acc.AddFields("file_integrity", fields, tags, now)
+}
+
+// stat answers the state and size of a regular file, hashing its contents
+func (f *FileIntegrity) stat(path string) (state, int64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return state{}, 0, err
+	}
+	st := state{mode: info.Mode()}
+	st.uid, st.gid = owner(info)
+	if !info.Mode().IsRegular() || f.newHash == nil {
+		return st, info.Size(), nil
+	}
+	if f.MaxHashSize > 0 && info.Size() > int64(f.MaxHashSize) {
+		return st, info.Size(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return state{}, 0, fmt.Errorf("hashing %s: %w", path, err)
+	}
+	defer file.Close()
+	h := f.newHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return state{}, 0, fmt.Errorf("hashing %s: %w", path, err)
+	}
+	st.hash = hex.EncodeToString(h.Sum(nil))
+	return st, info.Size(), nil
+}
+
+// eventBody answers the body of the event of a change, a line per field
+// in name order
+func eventBody(fields map[string]interface{}) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%v\n", name, fields[name])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (f *FileIntegrity) Stop() {
+	if f.watcher == nil {
+		return
+	}
+	if err := f.watcher.Close(); err != nil {
+		f.Log.Errorf("Closing watcher: %v", err)
+	}
+	f.wg.Wait()
+}
+
+func (f *FileIntegrity) Gather(_ context.Context, _ cua.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("file_integrity", func() cua.Input {
+		return &FileIntegrity{
+			SettleTime:    config.Duration(100 * time.Millisecond),
+			Hash:          "sha256",
+			MaxHashSize:   100 * 1024 * 1024,
+			EventSeverity: "warning",
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package fileintegrity
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	worldSHA256 = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7"
+)
+
+func start(t *testing.T, f *FileIntegrity) *testutil.Accumulator {
+	f.Log = testutil.Logger{}
+	require.NoError(t, f.Init())
+	acc := &testutil.Accumulator{}
+	require.NoError(t, f.Start(context.Background(), acc))
+	t.Cleanup(f.Stop)
+	return acc
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&FileIntegrity{}).Init())
+	require.Error(t, (&FileIntegrity{Paths: []string{"/etc"}, Hash: "crc"}).Init())
+	require.Error(t, (&FileIntegrity{Paths: []string{"/etc"}, Events: true, EventSeverity: "bad"}).Init())
+	require.NoError(t, (&FileIntegrity{Paths: []string{"/etc"}, Hash: "md5"}).Init())
+}
+
+func TestChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "passwd")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+
+	f := &FileIntegrity{
+		Paths:   []string{dir},
+		Exclude: []string{"*.swp"},
+		Hash:    "sha256",
+	}
+	acc := start(t, f)
+
+	// excluded and unchanged files are not reported
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "passwd.swp"), []byte("x"), 0600))
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+	require.NoError(t, ioutil.WriteFile(path, []byte("world"), 0600))
+	acc.Wait(1)
+
+	m, ok := acc.Get("file_integrity")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"path": path, "operation": "write"}, m.Tags)
+	require.Equal(t, worldSHA256, m.Fields["hash"])
+	require.Equal(t, helloSHA256, m.Fields["previous_hash"])
+	require.Equal(t, int64(5), m.Fields["size"])
+	require.Equal(t, "0600", m.Fields["mode"])
+	require.Equal(t, int64(os.Getuid()), m.Fields["uid"])
+
+	acc.ClearMetrics()
+	require.NoError(t, os.Remove(path))
+	acc.Wait(1)
+	require.True(t, acc.HasPoint("file_integrity",
+		map[string]string{"path": path, "operation": "remove"},
+		"previous_hash", worldSHA256))
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+
+	f := &FileIntegrity{
+		Paths:         []string{path},
+		Hash:          "sha256",
+		Events:        true,
+		EventSeverity: "critical",
+	}
+	acc := start(t, f)
+
+	// other files of the directory are not reported
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0600))
+	tmp := filepath.Join(dir, "hosts.new")
+	require.NoError(t, ioutil.WriteFile(tmp, []byte("world"), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+	acc.Wait(1)
+
+	m, ok := acc.Get("event")
+	require.True(t, ok)
+	require.Equal(t, cua.Event, m.Type)
+	require.Equal(t, path+" create", m.Fields["title"])
+	require.Equal(t, "critical", m.Fields["severity"])
+	require.Contains(t, m.Fields["body"], "hash="+worldSHA256)
+}
+
+func TestRecursive(t *testing.T) {
+	dir := t.TempDir()
+
+	f := &FileIntegrity{
+		Paths:     []string{dir},
+		Recursive: true,
+		Hash:      "sha256",
+	}
+	acc := start(t, f)
+
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0700))
+	acc.ClearMetrics()
+	path := filepath.Join(sub, "file")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello"), 0600))
+	acc.Wait(1)
+
+	require.True(t, acc.HasPoint("file_integrity",
+		map[string]string{"path": path, "operation": "create"},
+		"hash", helloSHA256))
+}
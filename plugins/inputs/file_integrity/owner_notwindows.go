@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package fileintegrity
+
+import (
+	"os"
+	"syscall"
+)
+
+// owner answers the user and group owning the file
+func owner(info os.FileInfo) (uid, gid uint32) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return stat.Uid, stat.Gid
+}
@@ -0,0 +1,203 @@
+package fluentbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Address of the fluent-bit built-in HTTP server (HTTP_Server On)
+  url = "http://127.0.0.1:2020"
+
+  ## Report the chunks buffered by the storage layer. Requires
+  ## storage.metrics On in the fluent-bit service section.
+  # storage_metrics = false
+
+  ## Timeout for HTTP requests
+  # timeout = "5s"
+`
+
+type FluentBit struct {
+	URL            string            `toml:"url"`
+	StorageMetrics bool              `toml:"storage_metrics"`
+	Timeout        internal.Duration `toml:"timeout"`
+
+	Log cua.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+// metrics is the /api/v1/metrics answer: counters per plugin instance,
+// keyed by the instance name or alias, per plugin kind.
+type metrics map[string]map[string]map[string]uint64
+
+type storage struct {
+	StorageLayer struct {
+		Chunks map[string]uint64 `json:"chunks"`
+	} `json:"storage_layer"`
+	InputChunks map[string]struct {
+		Status struct {
+			Overlimit bool   `json:"overlimit"`
+			MemSize   string `json:"mem_size"`
+			MemLimit  string `json:"mem_limit"`
+		} `json:"status"`
+		Chunks struct {
+			Total    uint64 `json:"total"`
+			Up       uint64 `json:"up"`
+			Down     uint64 `json:"down"`
+			Busy     uint64 `json:"busy"`
+			BusySize string `json:"busy_size"`
+		} `json:"chunks"`
+	} `json:"input_chunks"`
+}
+
+// Description answers a description of this input plugin
+func (*FluentBit) Description() string {
+	return "Read pipeline and storage metrics from the fluent-bit HTTP server"
+}
+
+// SampleConfig answers a sample configuration
+func (*FluentBit) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *FluentBit) Init() error {
+	if _, err := url.Parse(f.URL); err != nil {
+		return fmt.Errorf("parsing url (%s): %w", f.URL, err)
+	}
+	f.client = &http.Client{Timeout: f.Timeout.Duration}
+	return nil
+}
+
+func (f *FluentBit) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var m metrics
+	if err := f.get(ctx, "/api/v1/metrics", &m); err != nil {
+		acc.AddError(err)
+	} else {
+		now := time.Now()
+		// input, filter and output
+		for kind, instances := range m {
+			for name, counters := range instances {
+				fields := make(map[string]interface{}, len(counters))
+				for k, v := range counters {
+					fields[k] = v
+				}
+				acc.AddFields("fluentbit_"+kind, fields, map[string]string{"name": name}, now)
+			}
+		}
+	}
+
+	if f.StorageMetrics {
+		var s storage
+		if err := f.get(ctx, "/api/v1/storage", &s); err != nil {
+			acc.AddError(err)
+		} else {
+			gatherStorage(acc, &s)
+		}
+	}
+	return nil
+}
+
+func gatherStorage(acc cua.Accumulator, s *storage) {
+	now := time.Now()
+	if len(s.StorageLayer.Chunks) > 0 {
+		fields := make(map[string]interface{}, len(s.StorageLayer.Chunks))
+		for k, v := range s.StorageLayer.Chunks {
+			fields[k] = v
+		}
+		acc.AddFields("fluentbit_storage", fields, nil, now)
+	}
+
+	for name, in := range s.InputChunks {
+		overlimit := 0
+		if in.Status.Overlimit {
+			overlimit = 1
+		}
+		fields := map[string]interface{}{
+			"overlimit":   overlimit,
+			"chunks":      in.Chunks.Total,
+			"chunks_up":   in.Chunks.Up,
+			"chunks_down": in.Chunks.Down,
+			"chunks_busy": in.Chunks.Busy,
+		}
+		for field, v := range map[string]string{
+			"mem_size":   in.Status.MemSize,
+			"mem_limit":  in.Status.MemLimit,
+			"busy_bytes": in.Chunks.BusySize,
+		} {
+			if b, err := parseSize(v); err == nil {
+				fields[field] = b
+			}
+		}
+		acc.AddFields("fluentbit_input_storage", fields, map[string]string{"name": name}, now)
+	}
+}
+
+// parseSize converts the human readable sizes of the storage report, such
+// as "0b", "12.5K" or "1.1M", to bytes. fluent-bit uses binary multiples.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+	mult := float64(1)
+	switch s[len(s)-1] {
+	case 'b', 'B':
+		s = s[:len(s)-1]
+	case 'K':
+		mult, s = 1<<10, s[:len(s)-1]
+	case 'M':
+		mult, s = 1<<20, s[:len(s)-1]
+	case 'G':
+		mult, s = 1<<30, s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing size (%s): %w", s, err)
+	}
+	return int64(v * mult), nil
+}
+
+func (f *FluentBit) get(ctx context.Context, path string, v interface{}) error {
+	u := strings.TrimSuffix(f.URL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", u, err)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("fluentbit", func() cua.Input {
+		return &FluentBit{
+			URL:     "http://127.0.0.1:2020",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package fluentbit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/metrics":
+			http.ServeFile(w, r, "testdata/metrics.json")
+		case "/api/v1/storage":
+			http.ServeFile(w, r, "testdata/storage.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	f := &FluentBit{URL: ts.URL, StorageMetrics: true}
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "fluentbit_input",
+		map[string]interface{}{"records": uint64(18300), "bytes": uint64(2746201)},
+		map[string]string{"name": "tail.0"})
+	acc.AssertContainsTaggedFields(t, "fluentbit_filter",
+		map[string]interface{}{"drop_records": uint64(120), "add_records": uint64(0)},
+		map[string]string{"name": "grep.0"})
+	acc.AssertContainsTaggedFields(t, "fluentbit_output",
+		map[string]interface{}{
+			"proc_records":    uint64(18100),
+			"proc_bytes":      uint64(2710300),
+			"errors":          uint64(2),
+			"retries":         uint64(9),
+			"retries_failed":  uint64(1),
+			"dropped_records": uint64(40),
+			"retried_records": uint64(230),
+		},
+		map[string]string{"name": "es.0"})
+
+	acc.AssertContainsFields(t, "fluentbit_storage",
+		map[string]interface{}{
+			"total_chunks":   uint64(14),
+			"mem_chunks":     uint64(2),
+			"fs_chunks":      uint64(12),
+			"fs_chunks_up":   uint64(4),
+			"fs_chunks_down": uint64(8),
+		})
+	acc.AssertContainsTaggedFields(t, "fluentbit_input_storage",
+		map[string]interface{}{
+			"overlimit":   1,
+			"mem_size":    int64(5 << 20),
+			"mem_limit":   int64(5 << 20),
+			"chunks":      uint64(14),
+			"chunks_up":   uint64(6),
+			"chunks_down": uint64(8),
+			"chunks_busy": uint64(3),
+			"busy_bytes":  int64(1536),
+		},
+		map[string]string{"name": "tail.0"})
+}
+
+func TestGatherStorageDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"input": {}, "output": {}}`))
+	}))
+	defer ts.Close()
+
+	f := &FluentBit{URL: ts.URL}
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.False(t, acc.HasMeasurement("fluentbit_storage"))
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"0b":   0,
+		"512b": 512,
+		"1.5K": 1536,
+		"2.0M": 2 << 20,
+		"1G":   1 << 30,
+		"42":   42,
+	} {
+		got, err := parseSize(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+	_, err := parseSize("lots")
+	require.Error(t, err)
+}
@@ -52,6 +52,57 @@ type pluginData struct {
 	RetryCount            *float64 `json:"retry_count"`
 	BufferQueueLength     *float64 `json:"buffer_queue_length"`
 	BufferTotalQueuedSize *float64 `json:"buffer_total_queued_size"`
+
+	// reported by fluentd v1 output plugins
+	BufferStageLength    *float64   `json:"buffer_stage_length"`
+	BufferStageByteSize  *float64   `json:"buffer_stage_byte_size"`
+	BufferQueueByteSize  *float64   `json:"buffer_queue_byte_size"`
+	BufferAvailableSpace *float64   `json:"buffer_available_buffer_space_ratios"`
+	EmitRecords          *float64   `json:"emit_records"`
+	EmitCount            *float64   `json:"emit_count"`
+	WriteCount           *float64   `json:"write_count"`
+	RollbackCount        *float64   `json:"rollback_count"`
+	SlowFlushCount       *float64   `json:"slow_flush_count"`
+	FlushTimeCount       *float64   `json:"flush_time_count"`
+	Retry                *retryInfo `json:"retry"`
+}
+
+// retryInfo describes the retry state of an output failing to flush; it is
+// empty while the output is healthy.
+type retryInfo struct {
+	Steps *float64 `json:"steps"`
+}
+
+// fields answers the metrics reported for the plugin, keyed by field name.
+func (p *pluginData) fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	for name, v := range map[string]*float64{
+		"retry_count":                  p.RetryCount,
+		"buffer_queue_length":          p.BufferQueueLength,
+		"buffer_total_queued_size":     p.BufferTotalQueuedSize,
+		"buffer_stage_length":          p.BufferStageLength,
+		"buffer_stage_byte_size":       p.BufferStageByteSize,
+		"buffer_queue_byte_size":       p.BufferQueueByteSize,
+		"buffer_available_space_ratio": p.BufferAvailableSpace,
+		"emit_records":                 p.EmitRecords,
+		"emit_count":                   p.EmitCount,
+		"write_count":                  p.WriteCount,
+		"rollback_count":               p.RollbackCount,
+		"slow_flush_count":             p.SlowFlushCount,
+		"flush_time_count":             p.FlushTimeCount,
+	} {
+		if v != nil {
+			fields[name] = *v
+		}
+	}
+	if p.Retry != nil {
+		steps := float64(0)
+		if p.Retry.Steps != nil {
+			steps = *p.Retry.Steps
+		}
+		fields["retry_steps"] = steps
+	}
+	return fields
 }
 
 // parse JSON from fluentd Endpoint
@@ -140,27 +191,13 @@ func (h *Fluentd) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 		// If not, create new metric and add it to Accumulator
 		if !skip {
-			tmpFields := make(map[string]interface{})
-
 			tmpTags := map[string]string{
 				"plugin_id":       p.PluginID,
 				"plugin_category": p.PluginCategory,
 				"plugin_type":     p.PluginType,
 			}
 
-			if p.BufferQueueLength != nil {
-				tmpFields["buffer_queue_length"] = *p.BufferQueueLength
-
-			}
-			if p.RetryCount != nil {
-				tmpFields["retry_count"] = *p.RetryCount
-			}
-
-			if p.BufferTotalQueuedSize != nil {
-				tmpFields["buffer_total_queued_size"] = *p.BufferTotalQueuedSize
-			}
-
-			if !((p.BufferQueueLength == nil) && (p.RetryCount == nil) && (p.BufferTotalQueuedSize == nil)) {
+			if tmpFields := p.fields(); len(tmpFields) > 0 {
 				acc.AddFields(measurement, tmpFields, tmpTags)
 			}
 		}
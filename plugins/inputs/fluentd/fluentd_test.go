@@ -11,6 +11,7 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // sampleJSON from fluentd version '0.14.9'
@@ -103,8 +104,8 @@ var (
 		// 		{"object:f48698", "dummy", "input", nil, nil, nil},
 		// 		{"object:e27138", "dummy", "input", nil, nil, nil},
 		// 		{"object:d74060", "monitor_agent", "input", nil, nil, nil},
-		{"object:11a5e2c", "stdout", "output", &zero, nil, nil},
-		{"object:11237ec", "s3", "output", &zero, &zero, &zero},
+		{PluginID: "object:11a5e2c", PluginType: "stdout", PluginCategory: "output", RetryCount: &zero},
+		{PluginID: "object:11237ec", PluginType: "s3", PluginCategory: "output", RetryCount: &zero, BufferQueueLength: &zero, BufferTotalQueuedSize: &zero},
 	}
 	fluentdTest = &Fluentd{
 		Endpoint: "http://localhost:8081",
@@ -162,3 +163,73 @@ func Test_Gather(t *testing.T) {
 	assert.Equal(t, *expectedOutput[1].BufferTotalQueuedSize, acc.Metrics[1].Fields["buffer_total_queued_size"])
 
 }
+
+// sampleJSONv1 from fluentd version '1.14.0', an output failing to flush
+const sampleJSONv1 = `
+{
+  "plugins": [
+    {
+      "plugin_id": "es_output",
+      "plugin_category": "output",
+      "type": "elasticsearch",
+      "output_plugin": true,
+      "buffer_queue_length": 4,
+      "buffer_timekeys": [],
+      "buffer_total_queued_size": 52428800,
+      "retry_count": 12,
+      "emit_records": 120455,
+      "emit_size": 0,
+      "emit_count": 3310,
+      "write_count": 1205,
+      "rollback_count": 12,
+      "slow_flush_count": 3,
+      "flush_time_count": 905321,
+      "buffer_stage_length": 2,
+      "buffer_stage_byte_size": 1048576,
+      "buffer_queue_byte_size": 51380224,
+      "buffer_available_buffer_space_ratios": 93.5,
+      "retry": {
+        "start": "2021-09-20 10:12:01 +0000",
+        "steps": 5,
+        "next_time": "2021-09-20 10:14:09 +0000"
+      }
+    }
+  ]
+}
+`
+
+func Test_GatherV1(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, sampleJSONv1)
+	}))
+	defer ts.Close()
+
+	f := &Fluentd{Endpoint: ts.URL}
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(context.Background(), &acc))
+
+	acc.AssertContainsTaggedFields(t, "fluentd",
+		map[string]interface{}{
+			"retry_count":                  float64(12),
+			"retry_steps":                  float64(5),
+			"buffer_queue_length":          float64(4),
+			"buffer_total_queued_size":     float64(52428800),
+			"buffer_stage_length":          float64(2),
+			"buffer_stage_byte_size":       float64(1048576),
+			"buffer_queue_byte_size":       float64(51380224),
+			"buffer_available_space_ratio": float64(93.5),
+			"emit_records":                 float64(120455),
+			"emit_count":                   float64(3310),
+			"write_count":                  float64(1205),
+			"rollback_count":               float64(12),
+			"slow_flush_count":             float64(3),
+			"flush_time_count":             float64(905321),
+		},
+		map[string]string{
+			"plugin_id":       "es_output",
+			"plugin_category": "output",
+			"plugin_type":     "elasticsearch",
+		})
+}
@@ -0,0 +1,134 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/google/go-github/v32/github"
+)
+
+// actionsRunner is a self-hosted runner as listed by the actions API. The
+// go-github Runner type predates the busy flag and labels, so the listing
+// is decoded here.
+type actionsRunner struct {
+	Name   string `json:"name"`
+	OS     string `json:"os"`
+	Status string `json:"status"`
+	Busy   bool   `json:"busy"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+type actionsRunners struct {
+	TotalCount int             `json:"total_count"`
+	Runners    []actionsRunner `json:"runners"`
+}
+
+func (r *actionsRunner) hasLabel(label string) bool {
+	for _, l := range r.Labels {
+		if l.Name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// gatherRunners counts the self-hosted runners registered to an organization
+// by state, overall and for each configured runner label.
+func (g *GitHub) gatherRunners(ctx context.Context, acc cua.Accumulator, org string) error {
+	runners, err := g.listOrganizationRunners(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	acc.AddFields("github_actions_runners", runnerFields(runners), map[string]string{"owner": org}, now)
+
+	for _, label := range g.RunnerLabels {
+		var labeled []actionsRunner
+		for i := range runners {
+			if runners[i].hasLabel(label) {
+				labeled = append(labeled, runners[i])
+			}
+		}
+		acc.AddFields("github_actions_runners", runnerFields(labeled), map[string]string{
+			"owner": org,
+			"label": label,
+		}, now)
+	}
+	return nil
+}
+
+func (g *GitHub) listOrganizationRunners(ctx context.Context, org string) ([]actionsRunner, error) {
+	var runners []actionsRunner
+	page := 1
+	for page != 0 {
+		u := fmt.Sprintf("orgs/%s/actions/runners?per_page=100&page=%d", org, page)
+		req, err := g.githubClient.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating runners request (%s): %w", org, err)
+		}
+
+		var list actionsRunners
+		response, err := g.githubClient.Do(ctx, req, &list)
+		if err = g.trackRateLimit(response, err); err != nil {
+			return nil, fmt.Errorf("listing runners (%s): %w", org, err)
+		}
+
+		runners = append(runners, list.Runners...)
+		page = response.NextPage
+	}
+	return runners, nil
+}
+
+func runnerFields(runners []actionsRunner) map[string]interface{} {
+	var online, busy int
+	for _, r := range runners {
+		if r.Status != "online" {
+			continue
+		}
+		online++
+		if r.Busy {
+			busy++
+		}
+	}
+
+	fields := map[string]interface{}{
+		"total":   len(runners),
+		"online":  online,
+		"offline": len(runners) - online,
+		"busy":    busy,
+		"idle":    online - busy,
+	}
+	if online > 0 {
+		fields["utilization"] = float64(busy) / float64(online) * 100
+	}
+	return fields
+}
+
+// gatherWorkflowRuns reports how many workflow runs of a repository are
+// waiting for a runner and how many are executing.
+func (g *GitHub) gatherWorkflowRuns(ctx context.Context, acc cua.Accumulator, owner, repository string) error {
+	fields := make(map[string]interface{}, 2)
+	for _, status := range []string{"queued", "in_progress"} {
+		// only the total count is needed, not the runs themselves
+		opts := &github.ListWorkflowRunsOptions{
+			Status:      status,
+			ListOptions: github.ListOptions{PerPage: 1},
+		}
+		runs, response, err := g.githubClient.Actions.ListRepositoryWorkflowRuns(ctx, owner, repository, opts)
+		if err = g.trackRateLimit(response, err); err != nil {
+			return fmt.Errorf("listing %s workflow runs (%s/%s): %w", status, owner, repository, err)
+		}
+		fields[status] = runs.GetTotalCount()
+	}
+
+	acc.AddFields("github_actions_workflow_runs", fields, map[string]string{
+		"owner": owner,
+		"name":  repository,
+	})
+	return nil
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const runnersPage1 = `{
+  "total_count": 3,
+  "runners": [
+    {"id": 1, "name": "build-1", "os": "linux", "status": "online", "busy": true,
+     "labels": [{"name": "self-hosted"}, {"name": "linux"}, {"name": "gpu"}]},
+    {"id": 2, "name": "build-2", "os": "linux", "status": "online", "busy": false,
+     "labels": [{"name": "self-hosted"}, {"name": "linux"}]}
+  ]
+}`
+
+const runnersPage2 = `{
+  "total_count": 3,
+  "runners": [
+    {"id": 3, "name": "build-3", "os": "linux", "status": "offline", "busy": false,
+     "labels": [{"name": "self-hosted"}, {"name": "gpu"}]}
+  ]
+}`
+
+func TestGatherActions(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/orgs/acme/actions/runners":
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, runnersPage2)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/orgs/acme/actions/runners?per_page=100&page=2>; rel="next"`, ts.URL))
+			fmt.Fprint(w, runnersPage1)
+		case "/api/v3/repos/acme/widgets":
+			fmt.Fprint(w, `{"name": "widgets", "owner": {"login": "acme"}, "stargazers_count": 3}`)
+		case "/api/v3/repos/acme/widgets/actions/runs":
+			switch r.URL.Query().Get("status") {
+			case "queued":
+				fmt.Fprint(w, `{"total_count": 4, "workflow_runs": []}`)
+			case "in_progress":
+				fmt.Fprint(w, `{"total_count": 2, "workflow_runs": []}`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	g := &GitHub{
+		Repositories:        []string{"acme/widgets"},
+		EnterpriseBaseURL:   ts.URL + "/",
+		RunnerOrganizations: []string{"acme"},
+		RunnerLabels:        []string{"gpu"},
+		GatherWorkflowRuns:  true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, g.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "github_actions_runners",
+		map[string]interface{}{
+			"total":       3,
+			"online":      2,
+			"offline":     1,
+			"busy":        1,
+			"idle":        1,
+			"utilization": float64(50),
+		},
+		map[string]string{"owner": "acme"})
+	acc.AssertContainsTaggedFields(t, "github_actions_runners",
+		map[string]interface{}{
+			"total":       2,
+			"online":      1,
+			"offline":     1,
+			"busy":        1,
+			"idle":        0,
+			"utilization": float64(100),
+		},
+		map[string]string{"owner": "acme", "label": "gpu"})
+	acc.AssertContainsTaggedFields(t, "github_actions_workflow_runs",
+		map[string]interface{}{
+			"queued":      4,
+			"in_progress": 2,
+		},
+		map[string]string{"owner": "acme", "name": "widgets"})
+}
+
+func TestRunnerFieldsNoneOnline(t *testing.T) {
+	fields := runnerFields([]actionsRunner{{Status: "offline"}})
+	require.Equal(t, 0, fields["online"])
+	require.Equal(t, 1, fields["offline"])
+	require.NotContains(t, fields, "utilization")
+}
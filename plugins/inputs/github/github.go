@@ -23,7 +23,12 @@ type GitHub struct {
 	AccessToken       string            `toml:"access_token"`
 	EnterpriseBaseURL string            `toml:"enterprise_base_url"`
 	HTTPTimeout       internal.Duration `toml:"http_timeout"`
-	githubClient      *github.Client
+
+	RunnerOrganizations []string `toml:"runner_organizations"`
+	RunnerLabels        []string `toml:"runner_labels"`
+	GatherWorkflowRuns  bool     `toml:"gather_workflow_runs"`
+
+	githubClient *github.Client
 
 	obfuscatedToken string
 
@@ -49,6 +54,16 @@ const sampleConfig = `
 
   ## Timeout for HTTP requests.
   # http_timeout = "5s"
+
+  ## Organizations whose self-hosted GitHub Actions runners are counted.
+  ## Listing organization runners requires a token with the admin:org scope.
+  # runner_organizations = []
+
+  ## Additionally break the runner counts down by these runner labels.
+  # runner_labels = ["self-hosted", "linux", "gpu"]
+
+  ## Count the queued and in progress workflow runs of the repositories above.
+  # gather_workflow_runs = false
 `
 
 // SampleConfig returns sample configuration for this plugin.
@@ -116,7 +131,16 @@ func (g *GitHub) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 
 	var wg sync.WaitGroup
-	wg.Add(len(g.Repositories))
+	wg.Add(len(g.Repositories) + len(g.RunnerOrganizations))
+
+	for _, org := range g.RunnerOrganizations {
+		go func(org string) {
+			defer wg.Done()
+			if err := g.gatherRunners(ctx, acc, org); err != nil {
+				acc.AddError(err)
+			}
+		}(org)
+	}
 
 	for _, repository := range g.Repositories {
 		go func(repositoryName string, acc cua.Accumulator) {
@@ -129,25 +153,22 @@ func (g *GitHub) Gather(ctx context.Context, acc cua.Accumulator) error {
 			}
 
 			repositoryInfo, response, err := g.githubClient.Repositories.Get(ctx, owner, repository)
-
-			var rlerr *github.RateLimitError
-			if errors.As(err, &rlerr) {
-				g.RateLimitErrors.Incr(1)
-			}
-
-			if err != nil {
+			if err = g.trackRateLimit(response, err); err != nil {
 				acc.AddError(err)
 				return
 			}
 
-			g.RateLimit.Set(int64(response.Rate.Limit))
-			g.RateRemaining.Set(int64(response.Rate.Remaining))
-
 			now := time.Now()
 			tags := getTags(repositoryInfo)
 			fields := getFields(repositoryInfo)
 
 			acc.AddFields("github_repository", fields, tags, now)
+
+			if g.GatherWorkflowRuns {
+				if err := g.gatherWorkflowRuns(ctx, acc, owner, repository); err != nil {
+					acc.AddError(err)
+				}
+			}
 		}(repository, acc)
 	}
 
@@ -155,6 +176,22 @@ func (g *GitHub) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// trackRateLimit records the rate limit state reported with an API response
+// and passes the request error through.
+func (g *GitHub) trackRateLimit(response *github.Response, err error) error {
+	var rlerr *github.RateLimitError
+	if errors.As(err, &rlerr) {
+		g.RateLimitErrors.Incr(1)
+	}
+	if err != nil {
+		return err
+	}
+
+	g.RateLimit.Set(int64(response.Rate.Limit))
+	g.RateRemaining.Set(int64(response.Rate.Remaining))
+	return nil
+}
+
 func splitRepositoryName(repositoryName string) (string, string, error) {
 	splits := strings.SplitN(repositoryName, "/", 2)
 
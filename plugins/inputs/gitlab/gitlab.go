@@ -0,0 +1,295 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## GitLab instance address
+  url = "https://gitlab.example.com"
+
+  ## Access token sent as PRIVATE-TOKEN. Counting the runners of the whole
+  ## instance requires a token belonging to an administrator.
+  # access_token = ""
+
+  ## Report the readiness checks of the instance. The agent's address must
+  ## be in the GitLab monitoring IP allowlist.
+  # gather_readiness = true
+
+  ## Count the registered runners by type and status.
+  # gather_runners = true
+
+  ## Projects, by path or id, whose pending and running jobs are counted.
+  # projects = ["group/project"]
+
+  ## Timeout for HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type GitLab struct {
+	URL             string            `toml:"url"`
+	AccessToken     string            `toml:"access_token"`
+	GatherReadiness bool              `toml:"gather_readiness"`
+	GatherRunners   bool              `toml:"gather_runners"`
+	Projects        []string          `toml:"projects"`
+	Timeout         internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+// readinessCheck is the result of one probe of the readiness endpoint; the
+// gitaly check reports one result per storage shard.
+type readinessCheck struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Labels  map[string]string `json:"labels"`
+}
+
+type runner struct {
+	ID         int64  `json:"id"`
+	Active     bool   `json:"active"`
+	Paused     bool   `json:"paused"`
+	Online     bool   `json:"online"`
+	Status     string `json:"status"`
+	RunnerType string `json:"runner_type"`
+}
+
+// Description answers a description of this input plugin
+func (*GitLab) Description() string {
+	return "Read readiness, runner and job queue metrics from a GitLab instance"
+}
+
+// SampleConfig answers a sample configuration
+func (*GitLab) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GitLab) Init() error {
+	if g.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if _, err := url.Parse(g.URL); err != nil {
+		return fmt.Errorf("parsing url (%s): %w", g.URL, err)
+	}
+	tlsCfg, err := g.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("tls config: %w", err)
+	}
+	g.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: g.Timeout.Duration,
+	}
+	return nil
+}
+
+func (g *GitLab) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+
+	if g.GatherReadiness {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.gatherReadiness(ctx, acc); err != nil {
+				acc.AddError(err)
+			}
+		}()
+	}
+
+	if g.GatherRunners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.gatherRunners(ctx, acc); err != nil {
+				acc.AddError(err)
+			}
+		}()
+	}
+
+	for _, project := range g.Projects {
+		wg.Add(1)
+		go func(project string) {
+			defer wg.Done()
+			if err := g.gatherJobs(ctx, acc, project); err != nil {
+				acc.AddError(err)
+			}
+		}(project)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (g *GitLab) gatherReadiness(ctx context.Context, acc cua.Accumulator) error {
+	// a failing check answers 503 with the same body, so both are decoded
+	resp, err := g.get(ctx, "/-/readiness?all=1", http.StatusOK, http.StatusServiceUnavailable)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var checks map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&checks); err != nil {
+		return fmt.Errorf("decoding readiness: %w", err)
+	}
+
+	now := time.Now()
+	for name, raw := range checks {
+		if !strings.HasSuffix(name, "_check") {
+			// the overall status and message
+			continue
+		}
+		var results []readinessCheck
+		if err := json.Unmarshal(raw, &results); err != nil {
+			return fmt.Errorf("decoding readiness check %s: %w", name, err)
+		}
+		for _, r := range results {
+			tags := map[string]string{"check": strings.TrimSuffix(name, "_check")}
+			if shard, ok := r.Labels["shard"]; ok {
+				tags["shard"] = shard
+			}
+			fields := map[string]interface{}{"ok": 0}
+			if r.Status == "ok" {
+				fields["ok"] = 1
+			} else if r.Message != "" {
+				fields["message"] = r.Message
+			}
+			acc.AddFields("gitlab_readiness", fields, tags, now)
+		}
+	}
+	return nil
+}
+
+func (g *GitLab) gatherRunners(ctx context.Context, acc cua.Accumulator) error {
+	type key struct{ runnerType, status string }
+	type counts struct{ runners, paused int64 }
+
+	byState := make(map[key]*counts)
+	for page := "1"; page != ""; {
+		resp, err := g.get(ctx, "/api/v4/runners/all?per_page=100&page="+page, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		var runners []runner
+		err = json.NewDecoder(resp.Body).Decode(&runners)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decoding runners: %w", err)
+		}
+
+		for _, r := range runners {
+			k := key{runnerType: r.RunnerType, status: r.Status}
+			c, ok := byState[k]
+			if !ok {
+				c = &counts{}
+				byState[k] = c
+			}
+			c.runners++
+			// paused replaced active in GitLab 14.8
+			if r.Paused || !r.Active {
+				c.paused++
+			}
+		}
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	now := time.Now()
+	for k, c := range byState {
+		acc.AddFields("gitlab_runners", map[string]interface{}{
+			"count":  c.runners,
+			"paused": c.paused,
+		}, map[string]string{
+			"runner_type": k.runnerType,
+			"status":      k.status,
+		}, now)
+	}
+	return nil
+}
+
+// gatherJobs reports the pending and running jobs of a project. Only the
+// X-Total header of the job listing is used.
+func (g *GitLab) gatherJobs(ctx context.Context, acc cua.Accumulator, project string) error {
+	fields := make(map[string]interface{}, 2)
+	for _, scope := range []string{"pending", "running"} {
+		path := "/api/v4/projects/" + url.PathEscape(project) + "/jobs?per_page=1&scope[]=" + scope
+		resp, err := g.get(ctx, path, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		total, err := strconv.ParseInt(resp.Header.Get("X-Total"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing %s job count (%s): %w", scope, project, err)
+		}
+		fields[scope] = total
+	}
+
+	acc.AddFields("gitlab_jobs", fields, map[string]string{"project": project})
+	return nil
+}
+
+// get requests a path of the instance and answers the response when its
+// status is one of the expected ones; the caller closes the body
+func (g *GitLab) get(ctx context.Context, path string, expected ...int) (*http.Response, error) {
+	u := strings.TrimSuffix(g.URL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	if g.AccessToken != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.AccessToken)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", u, err)
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	resp.Body.Close()
+	return nil, fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func init() {
+	inputs.Add("gitlab", func() cua.Input {
+		return &GitLab{
+			GatherReadiness: true,
+			GatherRunners:   true,
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
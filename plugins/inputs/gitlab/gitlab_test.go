@@ -0,0 +1,100 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/-/readiness":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			http.ServeFile(w, r, "testdata/readiness.json")
+		case "/api/v4/runners/all":
+			if r.URL.Query().Get("page") == "2" {
+				http.ServeFile(w, r, "testdata/runners-2.json")
+				return
+			}
+			w.Header().Set("X-Next-Page", "2")
+			http.ServeFile(w, r, "testdata/runners-1.json")
+		case "/api/v4/projects/group/app/jobs":
+			require.Equal(t, "/api/v4/projects/group%2Fapp/jobs", r.URL.EscapedPath())
+			switch r.URL.Query().Get("scope[]") {
+			case "pending":
+				w.Header().Set("X-Total", "7")
+			case "running":
+				w.Header().Set("X-Total", "3")
+			}
+			_, _ = w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	g := &GitLab{
+		URL:             ts.URL,
+		AccessToken:     "secret",
+		GatherReadiness: true,
+		GatherRunners:   true,
+		Projects:        []string{"group/app"},
+	}
+	require.NoError(t, g.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, g.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "gitlab_readiness",
+		map[string]interface{}{"ok": 1},
+		map[string]string{"check": "db"})
+	acc.AssertContainsTaggedFields(t, "gitlab_readiness",
+		map[string]interface{}{"ok": 1},
+		map[string]string{"check": "gitaly", "shard": "default"})
+	acc.AssertContainsTaggedFields(t, "gitlab_readiness",
+		map[string]interface{}{
+			"ok":      0,
+			"message": "14:connections to all backends failing",
+		},
+		map[string]string{"check": "gitaly", "shard": "archive"})
+
+	acc.AssertContainsTaggedFields(t, "gitlab_runners",
+		map[string]interface{}{"count": int64(2), "paused": int64(1)},
+		map[string]string{"runner_type": "instance_type", "status": "online"})
+	acc.AssertContainsTaggedFields(t, "gitlab_runners",
+		map[string]interface{}{"count": int64(1), "paused": int64(0)},
+		map[string]string{"runner_type": "instance_type", "status": "offline"})
+	acc.AssertContainsTaggedFields(t, "gitlab_runners",
+		map[string]interface{}{"count": int64(1), "paused": int64(0)},
+		map[string]string{"runner_type": "project_type", "status": "online"})
+
+	acc.AssertContainsTaggedFields(t, "gitlab_jobs",
+		map[string]interface{}{"pending": int64(7), "running": int64(3)},
+		map[string]string{"project": "group/app"})
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"403 Forbidden"}`))
+	}))
+	defer ts.Close()
+
+	g := &GitLab{URL: ts.URL, GatherRunners: true}
+	require.NoError(t, g.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, g.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "403 Forbidden")
+}
@@ -0,0 +1,158 @@
+package gpfs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Path to the mmpmon binary
+  # mmpmon_path = "/usr/lpp/mmfs/bin/mmpmon"
+
+  ## mmpmon must run as root. Setting 'use_sudo' to true runs it with sudo,
+  ## which must be configured to allow the agent's user to run mmpmon
+  ## without a password.
+  # use_sudo = false
+
+  ## Timeout for mmpmon
+  # timeout = "5s"
+`
+
+// mmpmon requests: per file system and node wide I/O statistics
+const requests = "fs_io_s\nio_s\n"
+
+// ioFields maps the keys of the fs_io_s and io_s responses to field names
+var ioFields = map[string]string{
+	"_br_":  "bytes_read",
+	"_bw_":  "bytes_written",
+	"_oc_":  "opens",
+	"_cc_":  "closes",
+	"_rdc_": "reads",
+	"_wc_":  "writes",
+	"_dir_": "readdirs",
+	"_iu_":  "inode_updates",
+}
+
+type GPFS struct {
+	MmpmonPath string            `toml:"mmpmon_path"`
+	UseSudo    bool              `toml:"use_sudo"`
+	Timeout    internal.Duration `toml:"timeout"`
+
+	Log cua.Logger `toml:"-"`
+}
+
+// Description answers a description of this input plugin
+func (*GPFS) Description() string {
+	return "Read IBM Spectrum Scale (GPFS) file system I/O statistics with mmpmon"
+}
+
+// SampleConfig answers a sample configuration
+func (*GPFS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GPFS) Gather(ctx context.Context, acc cua.Accumulator) error {
+	cmd := exec.Command(g.MmpmonPath, "-p", "-s") //nolint:gosec // G204
+	if g.UseSudo {
+		cmd = exec.Command("sudo", "-n", g.MmpmonPath, "-p", "-s") //nolint:gosec // G204
+	}
+	cmd.Stdin = strings.NewReader(requests)
+
+	out, err := internal.CombinedOutputTimeout(cmd, g.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("running %s: %w - %s", g.MmpmonPath, err, strings.TrimSpace(string(out)))
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if err := gatherLine(acc, line); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+// gatherLine parses a response of mmpmon in parseable (-p) output format,
+// a keyword followed by _key_ value pairs, e.g.
+//
+//	_fs_io_s_ _n_ 10.0.0.1 _nn_ node1 _rc_ 0 _t_ 1634043000 _tu_ 52133 _cl_ c1 _fs_ gpfs01 _d_ 4 _br_ 3072 ...
+func gatherLine(acc cua.Accumulator, line string) error {
+	items := strings.Fields(line)
+	if len(items) < 3 || len(items)%2 != 1 {
+		return nil
+	}
+
+	var measurement string
+	switch items[0] {
+	case "_fs_io_s_":
+		measurement = "gpfs_fs"
+	case "_io_s_":
+		measurement = "gpfs_node"
+	default:
+		return nil
+	}
+
+	values := make(map[string]string, len(items)/2)
+	for i := 1; i < len(items); i += 2 {
+		values[items[i]] = items[i+1]
+	}
+
+	if rc := values["_rc_"]; rc != "0" {
+		// e.g. rc 1 when no file system is mounted
+		return fmt.Errorf("mmpmon %s: return code %s", strings.Trim(items[0], "_"), rc)
+	}
+
+	tags := map[string]string{}
+	if v, ok := values["_nn_"]; ok {
+		tags["node"] = v
+	}
+	if v, ok := values["_cl_"]; ok {
+		tags["cluster"] = v
+	}
+	if v, ok := values["_fs_"]; ok {
+		tags["filesystem"] = v
+	}
+
+	fields := make(map[string]interface{}, len(ioFields)+1)
+	for key, name := range ioFields {
+		v, err := strconv.ParseUint(values[key], 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[name] = v
+	}
+	if v, err := strconv.ParseUint(values["_d_"], 10, 64); err == nil {
+		fields["disks"] = v
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// use the time of the sample as reported by mmpmon
+	ts := time.Now()
+	if sec, err := strconv.ParseInt(values["_t_"], 10, 64); err == nil {
+		usec, _ := strconv.ParseInt(values["_tu_"], 10, 64)
+		ts = time.Unix(sec, usec*int64(time.Microsecond))
+	}
+
+	acc.AddFields(measurement, fields, tags, ts)
+	return nil
+}
+
+func init() {
+	inputs.Add("gpfs", func() cua.Input {
+		return &GPFS{
+			MmpmonPath: "/usr/lpp/mmfs/bin/mmpmon",
+			Timeout:    internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package gpfs
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherLine(t *testing.T) {
+	out, err := os.ReadFile("testdata/mmpmon.txt")
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	for _, line := range strings.Split(string(out), "\n") {
+		require.NoError(t, gatherLine(&acc, line))
+	}
+
+	ts := time.Unix(1634043000, 521330000)
+	expected := []cua.Metric{
+		testutil.MustMetric("gpfs_fs",
+			map[string]string{"node": "nsd01", "cluster": "hpc.example.com", "filesystem": "scratch"},
+			map[string]interface{}{
+				"disks":         uint64(12),
+				"bytes_read":    uint64(98334127104),
+				"bytes_written": uint64(40612093952),
+				"opens":         uint64(1822041),
+				"closes":        uint64(1821987),
+				"reads":         uint64(2201551),
+				"writes":        uint64(931120),
+				"readdirs":      uint64(40211),
+				"inode_updates": uint64(612004),
+			}, ts),
+		testutil.MustMetric("gpfs_fs",
+			map[string]string{"node": "nsd01", "cluster": "hpc.example.com", "filesystem": "home"},
+			map[string]interface{}{
+				"disks":         uint64(4),
+				"bytes_read":    uint64(1201332224),
+				"bytes_written": uint64(402653184),
+				"opens":         uint64(33102),
+				"closes":        uint64(33100),
+				"reads":         uint64(80113),
+				"writes":        uint64(21044),
+				"readdirs":      uint64(9981),
+				"inode_updates": uint64(14022),
+			}, ts),
+		testutil.MustMetric("gpfs_node",
+			map[string]string{"node": "nsd01"},
+			map[string]interface{}{
+				"bytes_read":    uint64(99535459328),
+				"bytes_written": uint64(41014747136),
+				"opens":         uint64(1855143),
+				"closes":        uint64(1855087),
+				"reads":         uint64(2281664),
+				"writes":        uint64(952164),
+				"readdirs":      uint64(50192),
+				"inode_updates": uint64(626026),
+			}, ts),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics())
+}
+
+func TestGatherLineReturnCode(t *testing.T) {
+	var acc testutil.Accumulator
+	err := gatherLine(&acc, "_fs_io_s_ _n_ 10.10.0.21 _nn_ nsd01 _rc_ 1 _t_ 1634043000 _tu_ 521330 _cl_ - _fs_ -")
+	require.Error(t, err)
+	require.Zero(t, acc.NMetrics())
+
+	// unrelated responses are ignored
+	require.NoError(t, gatherLine(&acc, "_reset_ _n_ 10.10.0.21 _nn_ nsd01 _rc_ 0 _t_ 1634043000 _tu_ 521330"))
+	require.Zero(t, acc.NMetrics())
+}
@@ -5,15 +5,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
-	"github.com/circonus-labs/circonus-unified-agent/plugins/common/proxy"
-	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
+	httpconfig "github.com/circonus-labs/circonus-unified-agent/plugins/common/http"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
 )
@@ -26,21 +25,15 @@ type HTTP struct {
 
 	Headers map[string]string `toml:"headers"`
 
-	// HTTP Basic Auth Credentials
-	Username string `toml:"username"`
-	Password string `toml:"password"`
-	tls.ClientConfig
-
-	proxy.HTTPProxy
-
-	// Absolute path to file with Bearer token
-	BearerToken string `toml:"bearer_token"`
-
 	SuccessStatusCodes []int `toml:"success_status_codes"`
 
-	Timeout internal.Duration `toml:"timeout"`
+	httpconfig.HTTPClientConfig
 
-	client *http.Client
+	Discovery *discovery.Config `toml:"discovery"`
+	Log       cua.Logger        `toml:"-"`
+
+	client     *http.Client
+	discoverer *discovery.Discoverer
 
 	// The parser will automatically be set by cua core code because
 	// this plugin implements the ParserInput interface (i.e. the SetParser method)
@@ -69,6 +62,14 @@ var sampleConfig = `
   # username = "username"
   # password = "pa$$word"
 
+  ## Kerberos (SPNEGO) authentication with a keytab, e.g. for endpoints in an
+  ## Active Directory domain.  The service principal name defaults to
+  ## HTTP/<host of the url>.
+  # kerberos_principal = "cua@EXAMPLE.COM"
+  # kerberos_keytab = "/opt/circonus/unified-agent/etc/cua.keytab"
+  # kerberos_config = "/etc/krb5.conf"
+  # kerberos_spn = ""
+
   ## HTTP entity-body to send with POST/PUT requests.
   # body = ""
 
@@ -88,10 +89,56 @@ var sampleConfig = `
 
   ## Amount of time allowed to complete the HTTP request
   # timeout = "5s"
+  ## Timeouts of connecting, including the TLS handshake, and of waiting for
+  ## the response headers; 0s for no timeout beyond the one of the request.
+  # connect_timeout = "0s"
+  # response_timeout = "0s"
+
+  ## Idle keep-alive connections kept for reuse, and for how long.
+  # max_idle_conn = 0
+  # max_idle_conn_per_host = 0
+  # idle_conn_timeout = "0s"
+  # disable_keep_alives = false
+
+  ## OAuth2 Client Credentials Grant
+  # client_id = "clientid"
+  # client_secret = "secret"
+  # token_url = "https://indentityprovider/oauth2/v1/token"
+  # scopes = ["urn:opc:idm:__myscopes__"]
 
   ## List of success status codes
   # success_status_codes = [200]
 
+  ## Optional discovery of endpoints, resolved again every interval and
+  ## gathered in addition to the urls above.
+  # [inputs.http.discovery]
+  #   ## One of "dns_srv", "consul", "file" or "kubernetes"
+  #   type = "consul"
+  #   # interval = "1m"
+  #   ## Template of the endpoint URL; .Address is the host:port of a target
+  #   ## and .Labels the labels of the target, e.g. the consul node.
+  #   # url = "http://{{.Address}}/metrics"
+  #
+  #   ## dns_srv: the SRV records to resolve
+  #   # names = ["_metrics._tcp.example.com"]
+  #
+  #   ## consul: healthy instances of the service, optionally with the tag
+  #   # consul_address = "localhost:8500"
+  #   # consul_token = ""
+  #   # consul_datacenter = ""
+  #   service = "api"
+  #   # tag = ""
+  #
+  #   ## file: files, or glob patterns, with a host:port per line or in
+  #   ## the JSON format of the Prometheus file based discovery
+  #   # files = ["/etc/circonus-unified-agent/http-targets.json"]
+  #
+  #   ## kubernetes: ready endpoints of the service, the port named port or
+  #   ## the first port, in cluster or using the kubeconfig
+  #   # kubeconfig = ""
+  #   # namespace = ""
+  #   # port = ""
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -110,24 +157,18 @@ func (*HTTP) Description() string {
 }
 
 func (h *HTTP) Init() error {
-	tlsCfg, err := h.ClientConfig.TLSConfig()
+	client, err := h.HTTPClientConfig.CreateClient(context.Background())
 	if err != nil {
-		return fmt.Errorf("TLSConfig: %w", err)
+		return fmt.Errorf("creating client: %w", err)
 	}
+	h.client = client
 
-	proxy, err := h.HTTPProxy.Proxy()
-	if err != nil {
-		return fmt.Errorf("proxy: %w", err)
-	}
-
-	transport := &http.Transport{
-		TLSClientConfig: tlsCfg,
-		Proxy:           proxy,
-	}
-
-	h.client = &http.Client{
-		Transport: transport,
-		Timeout:   h.Timeout.Duration,
+	if h.Discovery != nil {
+		d, err := discovery.New(h.Discovery, "http://{{.Address}}/", h.Log)
+		if err != nil {
+			return err
+		}
+		h.discoverer = d
 	}
 
 	// Set default as [200]
@@ -140,8 +181,17 @@ func (h *HTTP) Init() error {
 // Gather takes in an accumulator and adds the metrics that the Input
 // gathers. This is called every "interval"
 func (h *HTTP) Gather(ctx context.Context, acc cua.Accumulator) error {
+	urls := h.URLs
+	if h.discoverer != nil {
+		discovered, err := h.discoverer.URLs(ctx)
+		if err != nil {
+			acc.AddError(fmt.Errorf("discovering urls: %w", err))
+		}
+		urls = append(urls[:len(urls):len(urls)], discovered...)
+	}
+
 	var wg sync.WaitGroup
-	for _, u := range h.URLs {
+	for _, u := range urls {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
@@ -183,15 +233,6 @@ func (h *HTTP) gatherURL(
 		return fmt.Errorf("http new req (%s): %w", url, err)
 	}
 
-	if h.BearerToken != "" {
-		token, err := os.ReadFile(h.BearerToken)
-		if err != nil {
-			return fmt.Errorf("readfile: %w", err)
-		}
-		bearer := "Bearer " + strings.Trim(string(token), "\n")
-		request.Header.Set("Authorization", bearer)
-	}
-
 	if h.ContentEncoding == "gzip" {
 		request.Header.Set("Content-Encoding", "gzip")
 	}
@@ -204,10 +245,6 @@ func (h *HTTP) gatherURL(
 		}
 	}
 
-	if h.Username != "" || h.Password != "" {
-		request.SetBasicAuth(h.Username, h.Password)
-	}
-
 	resp, err := h.client.Do(request)
 	if err != nil {
 		return fmt.Errorf("http do: %w", err)
@@ -264,8 +301,10 @@ func makeRequestBodyReader(contentEncoding, body string) (io.ReadCloser, error)
 func init() {
 	inputs.Add("http", func() cua.Input {
 		return &HTTP{
-			Timeout: internal.Duration{Duration: time.Second * 5},
-			Method:  "GET",
+			Method: "GET",
+			HTTPClientConfig: httpconfig.HTTPClientConfig{
+				Timeout: internal.Duration{Duration: time.Second * 5},
+			},
 		}
 	})
 }
@@ -6,8 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
 	plugin "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/http"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
@@ -51,6 +55,41 @@ func TestHTTPwithJSONFormat(t *testing.T) {
 	require.Equal(t, acc.Metrics[0].Tags["url"], url)
 }
 
+func TestHTTPDiscovery(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/endpoint" {
+			_, _ = w.Write([]byte(simpleJSON))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	address := strings.TrimPrefix(fakeServer.URL, "http://")
+	targets := filepath.Join(t.TempDir(), "targets.txt")
+	require.NoError(t, os.WriteFile(targets, []byte(address+"\n"), 0600))
+
+	plugin := &plugin.HTTP{
+		Discovery: &discovery.Config{
+			Type:  "file",
+			Files: []string{targets},
+			URL:   "http://{{.Address}}/endpoint",
+		},
+		Log: testutil.Logger{},
+	}
+	p, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: metricName,
+	})
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, fakeServer.URL+"/endpoint", acc.Metrics[0].Tags["url"])
+}
+
 func TestHTTPHeaders(t *testing.T) {
 	header := "X-Special-Header"
 	headerValue := "Special-Value"
@@ -0,0 +1,141 @@
+//go:build windows
+// +build windows
+
+package hyperv
+
+import (
+	"context"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	winperfcounters "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Counter sets to collect, available sets are:
+  ##   "vcpu"    - guest and hypervisor run time per virtual processor
+  ##   "memory"  - dynamic memory assigned to and pressure of each VM
+  ##   "vswitch" - traffic and drops per virtual switch
+  ##   "vhd"     - throughput, latency and queue length per virtual disk
+  ##   "health"  - number of VMs in ok and critical health
+  # collect = ["vcpu", "memory", "vswitch", "vhd", "health"]
+
+  ## Period after which virtual machines, switches and disks are rediscovered
+  # refresh_interval = "1m"
+`
+
+// counterSets maps the collect options to the Hyper-V performance objects
+// gathered for them. Objects that do not exist on the host are skipped.
+var counterSets = map[string][]winperfcounters.CounterSet{
+	"vcpu": {
+		{
+			Object:      "Hyper-V Hypervisor Virtual Processor",
+			Measurement: "hyperv_vcpu",
+			Counters:    []string{"% Guest Run Time", "% Hypervisor Run Time", "% Total Run Time"},
+		},
+		{
+			Object:      "Hyper-V Hypervisor Logical Processor",
+			Measurement: "hyperv_host_cpu",
+			Counters:    []string{"% Guest Run Time", "% Hypervisor Run Time", "% Idle Time", "% Total Run Time"},
+		},
+	},
+	"memory": {
+		{
+			Object:      "Hyper-V Dynamic Memory VM",
+			Measurement: "hyperv_vm_memory",
+			Counters: []string{
+				"Physical Memory",
+				"Guest Visible Physical Memory",
+				"Guest Available Memory",
+				"Current Pressure",
+				"Average Pressure",
+				"Maximum Pressure",
+				"Minimum Pressure",
+				"Added Memory",
+				"Removed Memory",
+			},
+		},
+	},
+	"vswitch": {
+		{
+			Object:      "Hyper-V Virtual Switch",
+			Measurement: "hyperv_vswitch",
+			Counters: []string{
+				"Bytes Received/sec",
+				"Bytes Sent/sec",
+				"Packets Received/sec",
+				"Packets Sent/sec",
+				"Dropped Packets Incoming/sec",
+				"Dropped Packets Outgoing/sec",
+			},
+		},
+	},
+	"vhd": {
+		{
+			Object:      "Hyper-V Virtual Storage Device",
+			Measurement: "hyperv_vhd",
+			Counters: []string{
+				"Read Bytes/sec",
+				"Write Bytes/sec",
+				"Read Operations/Sec",
+				"Write Operations/Sec",
+				"Latency",
+				"Queue Length",
+				"Error Count",
+			},
+		},
+	},
+	"health": {
+		{
+			Object:      "Hyper-V Virtual Machine Health Summary",
+			Measurement: "hyperv_health",
+			Counters:    []string{"Health Ok", "Health Critical"},
+			Instances:   []string{"------"}, // single instance object
+		},
+	},
+}
+
+var defaultCollect = []string{"vcpu", "memory", "vswitch", "vhd", "health"}
+
+type HyperV struct {
+	Collect         []string          `toml:"collect"`
+	RefreshInterval internal.Duration `toml:"refresh_interval"`
+
+	Log cua.Logger `toml:"-"`
+
+	perf *winperfcounters.WinPerfCounters
+}
+
+func (h *HyperV) Description() string {
+	return "Hyper-V host and virtual machine performance counters"
+}
+
+func (h *HyperV) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *HyperV) Init() error {
+	if len(h.Collect) == 0 {
+		h.Collect = defaultCollect
+	}
+
+	var err error
+	h.perf, err = winperfcounters.NewPreset(counterSets, h.Collect, h.RefreshInterval, h.Log)
+	return err //nolint:wrapcheck
+}
+
+func (h *HyperV) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return h.perf.Gather(ctx, acc) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("hyperv", func() cua.Input {
+		return &HyperV{
+			RefreshInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
@@ -0,0 +1,28 @@
+//go:build windows
+// +build windows
+
+package hyperv
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCounterSets(t *testing.T) {
+	h := &HyperV{Log: testutil.Logger{}}
+	require.NoError(t, h.Init())
+	require.Len(t, h.perf.Object, 6)
+	require.True(t, h.perf.UseWildcardsExpansion)
+	require.False(t, h.perf.LocalizeWildcardsExpansion)
+
+	h = &HyperV{Collect: []string{"vhd"}, Log: testutil.Logger{}}
+	require.NoError(t, h.Init())
+	require.Len(t, h.perf.Object, 1)
+	require.Equal(t, "Hyper-V Virtual Storage Device", h.perf.Object[0].ObjectName)
+	require.Equal(t, []string{"*"}, h.perf.Object[0].Instances)
+
+	h = &HyperV{Collect: []string{"gpu"}, Log: testutil.Logger{}}
+	require.Error(t, h.Init())
+}
@@ -0,0 +1,135 @@
+//go:build windows
+// +build windows
+
+package iis
+
+import (
+	"context"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	winperfcounters "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Counter sets to collect, available sets are:
+  ##   "site"     - requests, connections and errors per web site
+  ##   "queue"    - HTTP.sys request queue length and rejections per application pool
+  ##   "app_pool" - application pool state, worker processes and recycles
+  ##   "worker"   - requests and cache usage per worker process
+  # collect = ["site", "queue", "app_pool", "worker"]
+
+  ## Period after which sites, application pools and worker processes are rediscovered
+  # refresh_interval = "1m"
+`
+
+// counterSets maps the collect options to the IIS performance objects
+// gathered for them. Objects that do not exist on the host are skipped.
+var counterSets = map[string][]winperfcounters.CounterSet{
+	"site": {
+		{
+			Object:      "Web Service",
+			Measurement: "iis_site",
+			Counters: []string{
+				"Current Connections",
+				"Total Method Requests/sec",
+				"Get Requests/sec",
+				"Post Requests/sec",
+				"Bytes Received/sec",
+				"Bytes Sent/sec",
+				"Not Found Errors/sec",
+				"Locked Errors/sec",
+				"Current Anonymous Users",
+				"Current NonAnonymous Users",
+				"Service Uptime",
+			},
+		},
+	},
+	"queue": {
+		{
+			Object:      "HTTP Service Request Queues",
+			Measurement: "iis_request_queue",
+			Counters: []string{
+				"CurrentQueueSize",
+				"MaxQueueItemAge",
+				"ArrivalRate",
+				"RejectionRate",
+				"RejectedRequests",
+			},
+		},
+	},
+	"app_pool": {
+		{
+			Object:      "APP_POOL_WAS",
+			Measurement: "iis_app_pool",
+			Counters: []string{
+				"Current Application Pool State",
+				"Current Application Pool Uptime",
+				"Current Worker Processes",
+				"Maximum Worker Processes",
+				"Recent Worker Process Failures",
+				"Total Worker Process Failures",
+				"Total Application Pool Recycles",
+			},
+		},
+	},
+	"worker": {
+		{
+			Object:      "W3SVC_W3WP",
+			Measurement: "iis_worker",
+			Counters: []string{
+				"Requests / Sec",
+				"Active Requests",
+				"Active Threads Count",
+				"Current File Cache Memory Usage",
+				"File Cache Hits %",
+				"Output Cache Current Memory Usage",
+			},
+		},
+	},
+}
+
+var defaultCollect = []string{"site", "queue", "app_pool", "worker"}
+
+type IIS struct {
+	Collect         []string          `toml:"collect"`
+	RefreshInterval internal.Duration `toml:"refresh_interval"`
+
+	Log cua.Logger `toml:"-"`
+
+	perf *winperfcounters.WinPerfCounters
+}
+
+func (i *IIS) Description() string {
+	return "IIS web site, request queue and application pool performance counters"
+}
+
+func (i *IIS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (i *IIS) Init() error {
+	if len(i.Collect) == 0 {
+		i.Collect = defaultCollect
+	}
+
+	var err error
+	i.perf, err = winperfcounters.NewPreset(counterSets, i.Collect, i.RefreshInterval, i.Log)
+	return err //nolint:wrapcheck
+}
+
+func (i *IIS) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return i.perf.Gather(ctx, acc) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("iis", func() cua.Input {
+		return &IIS{
+			RefreshInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package iis
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCounterSets(t *testing.T) {
+	i := &IIS{Log: testutil.Logger{}}
+	require.NoError(t, i.Init())
+	require.Len(t, i.perf.Object, 4)
+
+	i = &IIS{Collect: []string{"app_pool"}, Log: testutil.Logger{}}
+	require.NoError(t, i.Init())
+	require.Len(t, i.perf.Object, 1)
+	require.Equal(t, "APP_POOL_WAS", i.perf.Object[0].ObjectName)
+	require.Equal(t, "iis_app_pool", i.perf.Object[0].Measurement)
+
+	i = &IIS{Collect: []string{"ftp"}, Log: testutil.Logger{}}
+	require.Error(t, i.Init())
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -40,6 +41,8 @@ type InfluxDBListener struct {
 	RetentionPolicyTag string            `toml:"retention_policy_tag"`
 
 	timeFunc influx.TimeFunc
+	// handlers of the requests, reused with the strings they interned
+	handlers sync.Pool
 
 	listener net.Listener
 	server   http.Server
@@ -279,14 +282,20 @@ func (h *InfluxDBListener) handleWrite() http.HandlerFunc {
 			defer body.Close()
 		}
 
-		parser := influx.NewStreamParser(body)
+		handler, ok := h.handlers.Get().(*influx.MetricHandler)
+		if !ok {
+			handler = influx.NewMetricHandler()
+		}
+		defer h.handlers.Put(handler)
+		parser := influx.NewStreamParserWithHandler(body, handler)
 		parser.SetTimeFunc(h.timeFunc)
 
+		precision := time.Nanosecond
 		precisionStr := req.URL.Query().Get("precision")
 		if precisionStr != "" {
-			precision := getPrecisionMultiplier(precisionStr)
-			parser.SetTimePrecision(precision)
+			precision = getPrecisionMultiplier(precisionStr)
 		}
+		parser.SetTimePrecision(precision)
 
 		var m cua.Metric
 		var err error
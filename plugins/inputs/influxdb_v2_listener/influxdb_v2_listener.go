@@ -10,6 +10,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -45,6 +46,8 @@ type InfluxDBV2Listener struct {
 	BucketTag   string        `toml:"bucket_tag"`
 
 	timeFunc influx.TimeFunc
+	// handlers of the requests, reused with the strings they interned
+	handlers sync.Pool
 
 	listener net.Listener
 	server   http.Server
@@ -258,15 +261,20 @@ func (h *InfluxDBV2Listener) handleWrite() http.HandlerFunc {
 			badRequest(res, InternalError, readErr.Error())
 			return
 		}
-		metricHandler := influx.NewMetricHandler()
+		metricHandler, ok := h.handlers.Get().(*influx.MetricHandler)
+		if !ok {
+			metricHandler = influx.NewMetricHandler()
+		}
+		defer h.handlers.Put(metricHandler)
 		parser := influx.NewParser(metricHandler)
 		parser.SetTimeFunc(h.timeFunc)
 
+		precision := time.Nanosecond
 		precisionStr := req.URL.Query().Get("precision")
 		if precisionStr != "" {
-			precision := getPrecisionMultiplier(precisionStr)
-			metricHandler.SetTimePrecision(precision)
+			precision = getPrecisionMultiplier(precisionStr)
 		}
+		metricHandler.SetTimePrecision(precision)
 
 		var metrics []cua.Metric
 		var err error
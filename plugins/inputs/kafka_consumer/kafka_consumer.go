@@ -43,6 +43,12 @@ const sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional proxy for the connections to the brokers, either a SOCKS5
+  ## proxy, socks5://[user:password@]host:port, or an HTTP proxy supporting
+  ## CONNECT, http://[user:password@]host:port.  The addresses advertised by
+  ## the brokers must be reachable from the proxy.
+  # proxy_url = "socks5://proxy.example.com:1080"
+
   ## SASL authentication credentials.  These settings should typically be used
   ## with TLS encryption enabled
   # sasl_username = "kafka"
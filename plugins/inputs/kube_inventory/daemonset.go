@@ -14,13 +14,16 @@ func collectDaemonSets(ctx context.Context, acc cua.Accumulator, ki *KubernetesI
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, d := range list.Items {
+		inv.add(d)
 		ki.gatherDaemonSet(*d, acc)
 		// if err = ki.gatherDaemonSet(*d, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("daemonsets", inv)
 }
 
 func (ki *KubernetesInventory) gatherDaemonSet(d v1.DaemonSet, acc cua.Accumulator) {
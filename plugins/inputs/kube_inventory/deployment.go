@@ -14,13 +14,16 @@ func collectDeployments(ctx context.Context, acc cua.Accumulator, ki *Kubernetes
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, d := range list.Items {
+		inv.add(d)
 		ki.gatherDeployment(*d, acc)
 		// if err = ki.gatherDeployment(*d, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("deployments", inv)
 }
 
 func (ki *KubernetesInventory) gatherDeployment(d v1.Deployment, acc cua.Accumulator) {
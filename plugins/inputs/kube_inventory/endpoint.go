@@ -15,13 +15,16 @@ func collectEndpoints(ctx context.Context, acc cua.Accumulator, ki *KubernetesIn
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, i := range list.Items {
+		inv.add(i)
 		ki.gatherEndpoint(*i, acc)
 		// if err = ki.gatherEndpoint(*i, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("endpoints", inv)
 }
 
 func (ki *KubernetesInventory) gatherEndpoint(e v1.Endpoints, acc cua.Accumulator) {
@@ -14,13 +14,16 @@ func collectIngress(ctx context.Context, acc cua.Accumulator, ki *KubernetesInve
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, i := range list.Items {
+		inv.add(i)
 		ki.gatherIngress(*i, acc)
 		// if err = ki.gatherIngress(*i, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("ingress", inv)
 }
 
 func (ki *KubernetesInventory) gatherIngress(i v1beta1EXT.Ingress, acc cua.Accumulator) {
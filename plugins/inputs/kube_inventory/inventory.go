@@ -0,0 +1,208 @@
+package kubeinventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/ericchiang/k8s"
+	v1APPS "github.com/ericchiang/k8s/apis/apps/v1"
+	v1 "github.com/ericchiang/k8s/apis/core/v1"
+	v1beta1EXT "github.com/ericchiang/k8s/apis/extensions/v1beta1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+)
+
+// watchTimeout is how long the API server streams the changes of a resource
+// to an incremental gather, once the pending ones are sent
+const watchTimeout = time.Second
+
+// inventory is the objects of a resource listed by the last full gather,
+// updated with the changes watched since
+type inventory struct {
+	version string
+	objects map[string]k8s.Resource
+}
+
+func newInventory(meta *metav1.ListMeta) *inventory {
+	return &inventory{
+		version: meta.GetResourceVersion(),
+		objects: make(map[string]k8s.Resource),
+	}
+}
+
+func (inv *inventory) add(r k8s.Resource) {
+	inv.objects[objectKey(r)] = r
+}
+
+func objectKey(r k8s.Resource) string {
+	return r.GetMetadata().GetNamespace() + "/" + r.GetMetadata().GetName()
+}
+
+// watcher is the stream of the changes of a resource
+type watcher interface {
+	Next(k8s.Resource) (string, error)
+}
+
+// update applies the changes of w, decoded in the objects answered by
+// object, until the end of the stream
+func (inv *inventory) update(w watcher, object func() k8s.Resource) error {
+	for {
+		r := object()
+		eventType, err := w.Next(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("next: %w", err)
+		}
+
+		switch eventType {
+		case k8s.EventAdded, k8s.EventModified:
+			inv.add(r)
+		case k8s.EventDeleted:
+			delete(inv.objects, objectKey(r))
+		default:
+			// e.g. the version expired, the changes since are lost
+			return fmt.Errorf("unexpected %s event", eventType)
+		}
+		inv.version = r.GetMetadata().GetResourceVersion()
+	}
+}
+
+func (ki *KubernetesInventory) setInventory(resource string, inv *inventory) {
+	ki.inventoriesMu.Lock()
+	defer ki.inventoriesMu.Unlock()
+	if ki.inventories == nil {
+		ki.inventories = make(map[string]*inventory)
+	}
+	ki.inventories[resource] = inv
+}
+
+func (ki *KubernetesInventory) inventory(resource string) *inventory {
+	ki.inventoriesMu.Lock()
+	defer ki.inventoriesMu.Unlock()
+	return ki.inventories[resource]
+}
+
+// GatherChanges gathers the objects listed by the previous gathers, updated
+// with the changes watched since.
+func (ki *KubernetesInventory) GatherChanges(ctx context.Context, acc cua.Accumulator) error {
+	collectors, err := ki.collectors()
+	if err != nil {
+		return err
+	}
+	for _, resource := range collectors {
+		if ki.inventory(resource) == nil {
+			return cua.ErrFullGather
+		}
+	}
+
+	ki.gather(collectors, func(resource string) {
+		ki.collectChanges(ctx, acc, resource)
+	})
+	return nil
+}
+
+// collectChanges gathers the objects of a resource updated with the changes
+// since the last gather, listing them again when the changes are lost
+func (ki *KubernetesInventory) collectChanges(ctx context.Context, acc cua.Accumulator, resource string) {
+	inv := ki.inventory(resource)
+	res := watchedResources[resource]
+	namespace := ki.client.namespace
+	if res.clusterWide {
+		namespace = ""
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, ki.client.timeout)
+	defer cancel()
+	w, err := ki.client.Watch(wctx, namespace, res.object(), k8s.ResourceVersion(inv.version), k8s.Timeout(watchTimeout))
+	if err == nil {
+		err = inv.update(w, res.object)
+		_ = w.Close()
+	}
+	if err != nil {
+		log.Printf("D! [inputs.kube_inventory] watching %s failed, listing them: %s", resource, err)
+		availableCollectors[resource](ctx, acc, ki)
+		return
+	}
+
+	for _, r := range inv.objects {
+		res.gather(ki, r, acc)
+	}
+}
+
+// watchedResource is how to decode and gather the objects of a resource
+type watchedResource struct {
+	object      func() k8s.Resource
+	gather      func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator)
+	clusterWide bool
+}
+
+var watchedResources = map[string]watchedResource{
+	"daemonsets": {
+		object: func() k8s.Resource { return new(v1APPS.DaemonSet) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherDaemonSet(*r.(*v1APPS.DaemonSet), acc)
+		},
+	},
+	"deployments": {
+		object: func() k8s.Resource { return new(v1APPS.Deployment) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherDeployment(*r.(*v1APPS.Deployment), acc)
+		},
+	},
+	"endpoints": {
+		object: func() k8s.Resource { return new(v1.Endpoints) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherEndpoint(*r.(*v1.Endpoints), acc)
+		},
+	},
+	"ingress": {
+		object: func() k8s.Resource { return new(v1beta1EXT.Ingress) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherIngress(*r.(*v1beta1EXT.Ingress), acc)
+		},
+	},
+	"nodes": {
+		object: func() k8s.Resource { return new(v1.Node) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherNode(*r.(*v1.Node), acc)
+		},
+		clusterWide: true,
+	},
+	"pods": {
+		object: func() k8s.Resource { return new(v1.Pod) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherPod(*r.(*v1.Pod), acc)
+		},
+	},
+	"services": {
+		object: func() k8s.Resource { return new(v1.Service) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherService(*r.(*v1.Service), acc)
+		},
+	},
+	"statefulsets": {
+		object: func() k8s.Resource { return new(v1APPS.StatefulSet) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherStatefulSet(*r.(*v1APPS.StatefulSet), acc)
+		},
+	},
+	"persistentvolumes": {
+		object: func() k8s.Resource { return new(v1.PersistentVolume) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherPersistentVolume(*r.(*v1.PersistentVolume), acc)
+		},
+		clusterWide: true,
+	},
+	"persistentvolumeclaims": {
+		object: func() k8s.Resource { return new(v1.PersistentVolumeClaim) },
+		gather: func(ki *KubernetesInventory, r k8s.Resource, acc cua.Accumulator) {
+			ki.gatherPersistentVolumeClaim(*r.(*v1.PersistentVolumeClaim), acc)
+		},
+	},
+}
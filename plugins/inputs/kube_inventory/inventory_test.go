@@ -0,0 +1,66 @@
+package kubeinventory
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/ericchiang/k8s"
+	v1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type watchEvent struct {
+	eventType string
+	object    k8s.Resource
+}
+
+// mockWatcher streams events, then ends
+type mockWatcher []watchEvent
+
+func (w *mockWatcher) Next(r k8s.Resource) (string, error) {
+	if len(*w) == 0 {
+		return "", io.EOF
+	}
+	e := (*w)[0]
+	*w = (*w)[1:]
+	proto.Merge(r.(proto.Message), e.object.(proto.Message))
+	return e.eventType, nil
+}
+
+func node(name, version string) *v1.Node {
+	return &v1.Node{Metadata: &metav1.ObjectMeta{Name: toStrPtr(name), ResourceVersion: toStrPtr(version)}}
+}
+
+func TestInventoryUpdate(t *testing.T) {
+	inv := newInventory(&metav1.ListMeta{ResourceVersion: toStrPtr("10")})
+	inv.add(node("node1", "8"))
+	inv.add(node("node2", "9"))
+	require.Equal(t, "10", inv.version)
+
+	w := mockWatcher{
+		{k8s.EventModified, node("node1", "11")},
+		{k8s.EventDeleted, node("node2", "12")},
+		{k8s.EventAdded, node("node3", "13")},
+	}
+	object := func() k8s.Resource { return new(v1.Node) }
+	require.NoError(t, inv.update(&w, object))
+	require.Equal(t, "13", inv.version)
+	require.Len(t, inv.objects, 2)
+	require.Equal(t, "11", inv.objects["/node1"].GetMetadata().GetResourceVersion())
+	require.Contains(t, inv.objects, "/node3")
+
+	// the changes since an expired version are lost
+	w = mockWatcher{{"ERROR", node("", "")}}
+	require.Error(t, inv.update(&w, object))
+}
+
+func TestGatherChangesBeforeGather(t *testing.T) {
+	ki := &KubernetesInventory{client: &client{}, ResourceInclude: []string{"nodes"}}
+	var acc testutil.Accumulator
+	require.ErrorIs(t, ki.GatherChanges(context.Background(), &acc), cua.ErrFullGather)
+}
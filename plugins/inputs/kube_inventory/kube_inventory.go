@@ -40,6 +40,10 @@ type KubernetesInventory struct {
 	client *client
 
 	selectorFilter filter.Filter
+
+	// objects by resource, for incremental gathers
+	inventories   map[string]*inventory
+	inventoriesMu sync.Mutex
 }
 
 var sampleConfig = `
@@ -121,31 +125,51 @@ func (ki *KubernetesInventory) Init() error {
 
 // Gather collects kubernetes metrics from a given URL.
 func (ki *KubernetesInventory) Gather(ctx context.Context, acc cua.Accumulator) (err error) {
+	collectors, err := ki.collectors()
+	if err != nil {
+		return err
+	}
+
+	ki.gather(collectors, func(resource string) {
+		availableCollectors[resource](ctx, acc, ki)
+	})
+	return nil
+}
+
+// collectors answers the resources to collect, creating the filters
+func (ki *KubernetesInventory) collectors() ([]string, error) {
 	resourceFilter, err := filter.NewIncludeExcludeFilter(ki.ResourceInclude, ki.ResourceExclude)
 	if err != nil {
-		return fmt.Errorf("resource filters: %w", err)
+		return nil, fmt.Errorf("resource filters: %w", err)
 	}
 
 	ki.selectorFilter, err = filter.NewIncludeExcludeFilter(ki.SelectorInclude, ki.SelectorExclude)
 	if err != nil {
-		return fmt.Errorf("selector filters: %w", err)
+		return nil, fmt.Errorf("selector filters: %w", err)
 	}
 
-	wg := sync.WaitGroup{}
-
-	for collector, f := range availableCollectors {
+	var collectors []string
+	for collector := range availableCollectors {
 		if resourceFilter.Match(collector) {
-			wg.Add(1)
-			go func(f func(ctx context.Context, acc cua.Accumulator, k *KubernetesInventory)) {
-				defer wg.Done()
-				f(ctx, acc, ki)
-			}(f)
+			collectors = append(collectors, collector)
 		}
 	}
+	return collectors, nil
+}
 
-	wg.Wait()
+// gather runs collect for each of the resources concurrently
+func (ki *KubernetesInventory) gather(resources []string, collect func(resource string)) {
+	wg := sync.WaitGroup{}
 
-	return nil
+	for _, resource := range resources {
+		wg.Add(1)
+		go func(resource string) {
+			defer wg.Done()
+			collect(resource)
+		}(resource)
+	}
+
+	wg.Wait()
 }
 
 var availableCollectors = map[string]func(ctx context.Context, acc cua.Accumulator, ki *KubernetesInventory){
@@ -19,13 +19,16 @@ func collectNodes(ctx context.Context, acc cua.Accumulator, ki *KubernetesInvent
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, n := range list.Items {
+		inv.add(n)
 		ki.gatherNode(*n, acc)
 		// if err = ki.gatherNode(*n, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("nodes", inv)
 }
 
 func (ki *KubernetesInventory) gatherNode(n v1.Node, acc cua.Accumulator) {
@@ -14,13 +14,16 @@ func collectPersistentVolumes(ctx context.Context, acc cua.Accumulator, ki *Kube
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, pv := range list.Items {
+		inv.add(pv)
 		ki.gatherPersistentVolume(*pv, acc)
 		// if err = ki.gatherPersistentVolume(*pv, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("persistentvolumes", inv)
 }
 
 func (ki *KubernetesInventory) gatherPersistentVolume(pv v1.PersistentVolume, acc cua.Accumulator) {
@@ -14,13 +14,16 @@ func collectPersistentVolumeClaims(ctx context.Context, acc cua.Accumulator, ki
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, pvc := range list.Items {
+		inv.add(pvc)
 		ki.gatherPersistentVolumeClaim(*pvc, acc)
 		// if err = ki.gatherPersistentVolumeClaim(*pvc, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("persistentvolumeclaims", inv)
 }
 
 func (ki *KubernetesInventory) gatherPersistentVolumeClaim(pvc v1.PersistentVolumeClaim, acc cua.Accumulator) {
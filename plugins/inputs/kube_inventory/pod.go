@@ -13,13 +13,16 @@ func collectPods(ctx context.Context, acc cua.Accumulator, ki *KubernetesInvento
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, p := range list.Items {
+		inv.add(p)
 		ki.gatherPod(*p, acc)
 		// if err = ki.gatherPod(*p, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("pods", inv)
 }
 
 func (ki *KubernetesInventory) gatherPod(p v1.Pod, acc cua.Accumulator) {
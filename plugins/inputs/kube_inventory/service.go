@@ -14,13 +14,16 @@ func collectServices(ctx context.Context, acc cua.Accumulator, ki *KubernetesInv
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, i := range list.Items {
+		inv.add(i)
 		ki.gatherService(*i, acc)
 		// if err = ki.gatherService(*i, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("services", inv)
 }
 
 func (ki *KubernetesInventory) gatherService(s v1.Service, acc cua.Accumulator) {
@@ -14,13 +14,16 @@ func collectStatefulSets(ctx context.Context, acc cua.Accumulator, ki *Kubernete
 		acc.AddError(err)
 		return
 	}
+	inv := newInventory(list.Metadata)
 	for _, s := range list.Items {
+		inv.add(s)
 		ki.gatherStatefulSet(*s, acc)
 		// if err = ki.gatherStatefulSet(*s, acc); err != nil {
 		// 	acc.AddError(err)
 		// 	return
 		// }
 	}
+	ki.setInventory("statefulsets", inv)
 }
 
 func (ki *KubernetesInventory) gatherStatefulSet(s v1.StatefulSet, acc cua.Accumulator) {
@@ -418,6 +418,10 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wantedFields []*mapping, a
 						if wantedField == 0 {
 							wantedField = 1
 						}
+						if int(wantedField) >= len(parts) {
+							// truncated line, e.g. a stat without samples
+							continue
+						}
 						s := strings.TrimSuffix((parts[wantedField]), ",")
 						data, err = strconv.ParseUint(s, 10, 64)
 						if err != nil {
@@ -206,6 +206,32 @@ func TestLustre2GeneratesMetrics(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestLustre2TruncatedStats(t *testing.T) {
+	obddir := t.TempDir()
+	require.NoError(t, os.MkdirAll(obddir+"/OST0002", 0755))
+
+	// stats which have no samples yet lack the min/max/sum columns
+	contents := `snapshot_time             1438693064.430544 secs.usecs
+read_bytes                0 samples [bytes]
+write_bytes               12 samples [bytes] 4096 4096 49152
+`
+	require.NoError(t, os.WriteFile(obddir+"/OST0002/stats", []byte(contents), 0600))
+
+	m := &Lustre2{
+		OstProcfiles: []string{obddir + "/*/stats"},
+		MdsProcfiles: []string{obddir + "/*/md_stats"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, m.Gather(context.Background(), &acc))
+
+	acc.AssertContainsTaggedFields(t, "lustre2", map[string]interface{}{
+		"read_calls":  uint64(0),
+		"write_calls": uint64(12),
+		"write_bytes": uint64(49152),
+	}, map[string]string{"name": "OST0002"})
+}
+
 func TestLustre2GeneratesJobstatsMetrics(t *testing.T) {
 
 	tempdir := os.TempDir() + "/circonus/proc/fs/lustre/"
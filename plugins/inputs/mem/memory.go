@@ -13,6 +13,8 @@ import (
 type Stats struct {
 	ps       system.PS
 	platform string
+
+	ReportPressure bool `toml:"report_pressure"`
 }
 
 func (*Stats) Description() string {
@@ -22,6 +24,9 @@ func (*Stats) Description() string {
 func (*Stats) SampleConfig() string {
 	return `
   instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## If true, report the memory pressure stall information (Linux 4.20+).
+  # report_pressure = false
 `
 }
 
@@ -98,6 +103,12 @@ func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 	acc.AddGauge("mem", fields, nil)
 
+	if s.ReportPressure {
+		if err := system.AddPressure(acc, "memory"); err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	return nil
 }
 
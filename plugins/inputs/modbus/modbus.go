@@ -1,3 +1,6 @@
+//go:build !openbsd
+// +build !openbsd
+
 package modbus
 
 import (
@@ -10,6 +13,7 @@ import (
 	"net"
 	"net/url"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
@@ -36,7 +40,9 @@ type Modbus struct {
 	Coils            []fieldContainer  `toml:"coils"`
 	HoldingRegisters []fieldContainer  `toml:"holding_registers"`
 	InputRegisters   []fieldContainer  `toml:"input_registers"`
+	Slaves           []slaveDevice     `toml:"slave"`
 	registers        []register
+	devices          []device
 	isConnected      bool
 	tcpHandler       *mb.TCPClientHandler
 	rtuHandler       *mb.RTUClientHandler
@@ -44,6 +50,24 @@ type Modbus struct {
 	client           mb.Client
 }
 
+// slaveDevice is an additional device polled over the same controller,
+// e.g. several power meters sharing an RS485 bus or a TCP gateway
+type slaveDevice struct {
+	Name             string           `toml:"name"`
+	SlaveID          int              `toml:"slave_id"`
+	DiscreteInputs   []fieldContainer `toml:"discrete_inputs"`
+	Coils            []fieldContainer `toml:"coils"`
+	HoldingRegisters []fieldContainer `toml:"holding_registers"`
+	InputRegisters   []fieldContainer `toml:"input_registers"`
+}
+
+// device is the set of registers read from a single slave
+type device struct {
+	slaveID   int
+	tags      map[string]string
+	registers []register
+}
+
 type register struct {
 	Type           string
 	RegistersRange []registerRange
@@ -154,6 +178,16 @@ const sampleConfig = `
     { name = "tank_ph",      byte_order = "AB",   data_type = "INT16",   scale=1.0,     address = [1]},
     { name = "pump1_speed",  byte_order = "ABCD", data_type = "INT32",   scale=1.0,     address = [3,4]},
   ]
+
+  ## Additional slaves on the same controller, each with its own register
+  ## map. Metrics are tagged with the slave's name (defaults to the device
+  ## name above) and slave_id.
+  # [[inputs.modbus.slave]]
+  #   name = "meter2"
+  #   slave_id = 2
+  #   holding_registers = [
+  #     { name = "voltage", byte_order = "AB", data_type = "FIXED", scale=0.1, address = [0]},
+  #   ]
 `
 
 // SampleConfig returns a basic configuration for the plugin
@@ -196,17 +230,83 @@ func (m *Modbus) Init() error {
 		return err
 	}
 
+	m.devices = nil
+	if len(m.registers) > 0 {
+		m.devices = append(m.devices, device{
+			slaveID:   m.SlaveID,
+			tags:      map[string]string{"name": m.Name},
+			registers: m.registers,
+		})
+	}
+
+	seen := map[int]bool{m.SlaveID: len(m.registers) > 0}
+	for _, slave := range m.Slaves {
+		if slave.SlaveID < 0 || slave.SlaveID > 255 {
+			return fmt.Errorf("invalid slave id %d", slave.SlaveID)
+		}
+		if seen[slave.SlaveID] {
+			return fmt.Errorf("slave id %d is configured more than once", slave.SlaveID)
+		}
+		seen[slave.SlaveID] = true
+
+		name := slave.Name
+		if name == "" {
+			name = m.Name
+		}
+		dev := device{
+			slaveID: slave.SlaveID,
+			tags: map[string]string{
+				"name":     name,
+				"slave_id": strconv.Itoa(slave.SlaveID),
+			},
+		}
+		for _, rt := range []struct {
+			fields []fieldContainer
+			name   string
+		}{
+			{slave.DiscreteInputs, cDiscreteInputs},
+			{slave.Coils, cCoils},
+			{slave.HoldingRegisters, cHoldingRegisters},
+			{slave.InputRegisters, cInputRegisters},
+		} {
+			reg, err := buildRegister(rt.fields, rt.name)
+			if err != nil {
+				return fmt.Errorf("slave %d: %w", slave.SlaveID, err)
+			}
+			if reg != nil {
+				dev.registers = append(dev.registers, *reg)
+			}
+		}
+		if len(dev.registers) == 0 {
+			return fmt.Errorf("slave %d has no registers configured", slave.SlaveID)
+		}
+		m.devices = append(m.devices, dev)
+	}
+
 	return nil
 }
 
 func (m *Modbus) InitRegister(fields []fieldContainer, name string) error {
+	reg, err := buildRegister(fields, name)
+	if err != nil {
+		return err
+	}
+	if reg != nil {
+		m.registers = append(m.registers, *reg)
+	}
+	return nil
+}
+
+// buildRegister validates the fields of one register type and groups their
+// addresses into ranges that can be read with a single request
+func buildRegister(fields []fieldContainer, name string) (*register, error) {
 	if len(fields) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	err := validateFieldContainers(fields, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	addrs := []uint16{}
@@ -237,9 +337,7 @@ func (m *Modbus) InitRegister(fields []fieldContainer, name string) error {
 		}
 	}
 
-	m.registers = append(m.registers, register{name, registersRange, fields})
-
-	return nil
+	return &register{name, registersRange, fields}, nil
 }
 
 // Connect to a MODBUS Slave device via Modbus/[TCP|RTU|ASCII]
@@ -306,6 +404,18 @@ func connect(m *Modbus) error {
 	}
 }
 
+// setSlaveID addresses subsequent requests to the given slave
+func setSlaveID(m *Modbus, id int) {
+	switch {
+	case m.tcpHandler != nil:
+		m.tcpHandler.SlaveId = byte(id)
+	case m.rtuHandler != nil:
+		m.rtuHandler.SlaveId = byte(id)
+	case m.asciiHandler != nil:
+		m.asciiHandler.SlaveId = byte(id)
+	}
+}
+
 func disconnect(m *Modbus) error {
 	u, err := url.Parse(m.Controller)
 	if err != nil {
@@ -421,8 +531,8 @@ func readRegisterValues(m *Modbus, rt string, rr registerRange) ([]byte, error)
 	}
 }
 
-func (m *Modbus) getFields() error {
-	for _, register := range m.registers {
+func (m *Modbus) getFields(registers []register) error {
+	for _, register := range registers {
 		rawValues := make(map[uint16][]byte)
 		bitRawValues := make(map[uint16]uint16)
 		for _, rr := range register.RegistersRange {
@@ -694,10 +804,73 @@ func (m *Modbus) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 	}
 
+	var errs []error
+	for _, dev := range m.devices {
+		timestamp, err := m.gatherDevice(dev)
+		if err != nil {
+			var mberr *mb.ModbusError
+			if !errors.As(err, &mberr) {
+				// transport errors leave the connection in an unknown
+				// state, reconnect on the next gather
+				_ = disconnect(m)
+				m.isConnected = false
+				return err
+			}
+			// an exception response only concerns this slave, keep
+			// polling the remaining ones
+			errs = append(errs, fmt.Errorf("slave %d: %w", dev.slaveID, err))
+			continue
+		}
+
+		grouper := metric.NewSeriesGrouper()
+		for _, reg := range dev.registers {
+			tags := map[string]string{
+				"type": reg.Type,
+			}
+			for k, v := range dev.tags {
+				tags[k] = v
+			}
+
+			for _, field := range reg.Fields {
+				// In case no measurement was specified we use "modbus" as default
+				measurement := "modbus"
+				if field.Measurement != "" {
+					measurement = field.Measurement
+				}
+
+				// Group the data by series
+				_ = grouper.Add(measurement, tags, timestamp, field.Name, field.value)
+			}
+		}
+
+		// Add the metrics grouped by series to the accumulator
+		for _, metric := range grouper.Metrics() {
+			acc.AddMetric(metric)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		for _, err := range errs[1:] {
+			acc.AddError(err)
+		}
+		return errs[0]
+	}
+}
+
+// gatherDevice reads all registers of a single slave, retrying while the
+// device reports being busy
+func (m *Modbus) gatherDevice(dev device) (time.Time, error) {
+	setSlaveID(m, dev.slaveID)
+
 	timestamp := time.Now()
 	for retry := 0; retry <= m.Retries; retry++ {
 		timestamp = time.Now()
-		err := m.getFields()
+		err := m.getFields(dev.registers)
 		if err != nil {
 			var mberr *mb.ModbusError
 			if errors.As(err, &mberr) {
@@ -707,39 +880,12 @@ func (m *Modbus) Gather(ctx context.Context, acc cua.Accumulator) error {
 					continue
 				}
 			}
-			_ = disconnect(m)
-			m.isConnected = false
-			return err
+			return timestamp, err
 		}
 		// Reading was successful, leave the retry loop
 		break
 	}
-
-	grouper := metric.NewSeriesGrouper()
-	for _, reg := range m.registers {
-		tags := map[string]string{
-			"name": m.Name,
-			"type": reg.Type,
-		}
-
-		for _, field := range reg.Fields {
-			// In case no measurement was specified we use "modbus" as default
-			measurement := "modbus"
-			if field.Measurement != "" {
-				measurement = field.Measurement
-			}
-
-			// Group the data by series
-			_ = grouper.Add(measurement, tags, timestamp, field.Name, field.value)
-		}
-
-		// Add the metrics grouped by series to the accumulator
-		for _, metric := range grouper.Metrics() {
-			acc.AddMetric(metric)
-		}
-	}
-
-	return nil
+	return timestamp, nil
 }
 
 // Add this plugin to agent
@@ -0,0 +1,4 @@
+//go:build openbsd
+// +build openbsd
+
+package modbus
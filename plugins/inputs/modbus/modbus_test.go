@@ -1,3 +1,6 @@
+//go:build !openbsd
+// +build !openbsd
+
 package modbus
 
 import (
@@ -785,3 +788,71 @@ func TestRetryFail(t *testing.T) {
 		assert.Equal(t, counter, 1)
 	})
 }
+
+func TestMultipleSlaves(t *testing.T) {
+	serv := mbserver.NewServer()
+	err := serv.ListenTCP("localhost:1502")
+	assert.NoError(t, err)
+	defer serv.Close()
+
+	handler := m.NewTCPClientHandler("localhost:1502")
+	err = handler.Connect()
+	assert.NoError(t, err)
+	defer handler.Close()
+	client := m.NewClient(handler)
+
+	_, err = client.WriteMultipleRegisters(0, 2, []byte{0x04, 0xD2, 0x16, 0x2E})
+	assert.NoError(t, err)
+
+	modbus := Modbus{
+		Name:       "TestMultipleSlaves",
+		Controller: "tcp://localhost:1502",
+		SlaveID:    1,
+		HoldingRegisters: []fieldContainer{
+			{Name: "voltage", ByteOrder: "AB", DataType: "UINT16", Scale: 1.0, Address: []uint16{0}},
+		},
+		Slaves: []slaveDevice{
+			{
+				Name:    "meter2",
+				SlaveID: 2,
+				HoldingRegisters: []fieldContainer{
+					{Name: "current", ByteOrder: "AB", DataType: "FIXED", Scale: 1.0, Address: []uint16{1}},
+				},
+			},
+		},
+	}
+
+	err = modbus.Init()
+	assert.NoError(t, err)
+	assert.Len(t, modbus.devices, 2)
+
+	var acc testutil.Accumulator
+	err = modbus.Gather(context.Background(), &acc)
+	assert.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"voltage": uint64(1234)},
+		map[string]string{"name": "TestMultipleSlaves", "type": cHoldingRegisters})
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{"current": float64(5678)},
+		map[string]string{"name": "meter2", "slave_id": "2", "type": cHoldingRegisters})
+}
+
+func TestSlaveValidation(t *testing.T) {
+	coils := []fieldContainer{{Name: "run", Address: []uint16{0}}}
+
+	modbus := Modbus{
+		Name:       "TestSlaveValidation",
+		Controller: "tcp://localhost:1502",
+		SlaveID:    1,
+		Coils:      coils,
+		Slaves:     []slaveDevice{{SlaveID: 1, Coils: coils}},
+	}
+	assert.Error(t, modbus.Init())
+
+	modbus.Slaves = []slaveDevice{{SlaveID: 2}}
+	assert.Error(t, modbus.Init())
+
+	modbus.Slaves = []slaveDevice{{SlaveID: 2, Coils: coils}}
+	assert.NoError(t, modbus.Init())
+}
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/proxy"
 	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"gopkg.in/mgo.v2"
@@ -26,8 +28,13 @@ type MongoDB struct {
 	GatherColStats      bool
 	ColStatsDbs         []string
 	tlsint.ClientConfig
+	proxy.TCPProxy
+	Discovery *discovery.Config `toml:"discovery"`
 
 	Log cua.Logger
+
+	discoverer *discovery.Discoverer
+	discovered map[string]bool
 }
 
 type Ssl struct {
@@ -66,6 +73,41 @@ var sampleConfig = `
   # tls_key = "/etc/circonus-unified-agent/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## Optional proxy, either a SOCKS5 proxy, socks5://[user:password@]host:port,
+  ## or an HTTP proxy supporting CONNECT, http://[user:password@]host:port.
+  # proxy_url = "socks5://proxy.example.com:1080"
+
+  ## Optional discovery of servers, resolved again every interval and
+  ## gathered in addition to the servers above.
+  # [inputs.mongodb.discovery]
+  #   ## One of "dns_srv", "consul", "file" or "kubernetes"
+  #   type = "dns_srv"
+  #   # interval = "1m"
+  #   ## Template of the server URL; .Address is the host:port of a target
+  #   ## and .Labels the labels of the target, e.g. the consul node.
+  #   # url = "mongodb://user:auth_key@{{.Address}}"
+  #
+  #   ## dns_srv: the SRV records to resolve
+  #   names = ["_mongodb._tcp.example.com"]
+  #
+  #   ## consul: healthy instances of the service, optionally with the tag
+  #   # consul_address = "localhost:8500"
+  #   # consul_token = ""
+  #   # consul_datacenter = ""
+  #   # service = "mongodb"
+  #   # tag = ""
+  #
+  #   ## file: files, or glob patterns, with a host:port per line or in
+  #   ## the JSON format of the Prometheus file based discovery
+  #   # files = ["/etc/circonus-unified-agent/mongodb-targets.json"]
+  #
+  #   ## kubernetes: ready endpoints of the service, the port named port or
+  #   ## the first port, in cluster or using the kubeconfig
+  #   # kubeconfig = ""
+  #   # namespace = ""
+  #   # service = "mongodb"
+  #   # port = ""
 `
 
 func (m *MongoDB) SampleConfig() string {
@@ -78,22 +120,46 @@ func (*MongoDB) Description() string {
 
 var localhost = &url.URL{Host: "mongodb://127.0.0.1:27017"}
 
+func (m *MongoDB) Init() error {
+	if m.Discovery != nil {
+		d, err := discovery.New(m.Discovery, "mongodb://{{.Address}}", m.Log)
+		if err != nil {
+			return err
+		}
+		m.discoverer = d
+	}
+	return nil
+}
+
 // Reads stats from all configured servers accumulates stats.
 // Returns one of the errors encountered while gather stats (if any).
 func (m *MongoDB) Gather(ctx context.Context, acc cua.Accumulator) error {
-	if len(m.Servers) == 0 {
+	servers := m.Servers
+	if m.discoverer != nil {
+		discovered, err := m.discoverer.URLs(ctx)
+		if err != nil {
+			acc.AddError(fmt.Errorf("discovering servers: %w", err))
+		} else {
+			m.closeRemoved(discovered)
+		}
+		servers = append(servers[:len(servers):len(servers)], discovered...)
+	}
+
+	if len(servers) == 0 {
 		_ = m.gatherServer(m.getMongoServer(localhost), acc)
 		return nil
 	}
 
 	var wg sync.WaitGroup
-	for i, serv := range m.Servers {
+	for i, serv := range servers {
 		if !strings.HasPrefix(serv, "mongodb://") {
 			// Preserve backwards compatibility for hostnames without a
 			// scheme, broken in go 1.8. Remove in agent v2.0
 			serv = "mongodb://" + serv
 			m.Log.Warnf("Using %q as connection URL; please update your configuration to use an URL", serv)
-			m.Servers[i] = serv
+			if i < len(m.Servers) {
+				m.Servers[i] = serv
+			}
 		}
 
 		u, err := url.Parse(serv)
@@ -120,6 +186,28 @@ func (m *MongoDB) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// closeRemoved closes the sessions of discovered servers no longer found
+func (m *MongoDB) closeRemoved(servers []string) {
+	current := make(map[string]bool, len(servers))
+	for _, serv := range servers {
+		if u, err := url.Parse(serv); err == nil {
+			current[u.Host] = true
+		}
+	}
+	for host := range m.discovered {
+		if current[host] {
+			continue
+		}
+		if srv, ok := m.mongos[host]; ok {
+			if srv.Session != nil {
+				srv.Session.Close()
+			}
+			delete(m.mongos, host)
+		}
+	}
+	m.discovered = current
+}
+
 func (m *MongoDB) getMongoServer(url *url.URL) *Server {
 	if _, ok := m.mongos[url.Host]; !ok {
 		m.mongos[url.Host] = &Server{
@@ -162,6 +250,9 @@ func (m *MongoDB) gatherServer(server *Server, acc cua.Accumulator) error {
 			} else {
 				tlsConfig.InsecureSkipVerify = true
 			}
+			if err := tlsint.ApplyFIPS(tlsConfig); err != nil {
+				return fmt.Errorf("TLSConfig: %w", err)
+			}
 		} else {
 			tlsConfig, err = m.ClientConfig.TLSConfig()
 			if err != nil {
@@ -169,14 +260,32 @@ func (m *MongoDB) gatherServer(server *Server, acc cua.Accumulator) error {
 			}
 		}
 
-		// If configured to use TLS, add a dial function
-		if tlsConfig != nil {
+		// If configured to use TLS or a proxy, add a dial function
+		if tlsConfig != nil || m.ProxyURL != "" {
+			dialer, err := m.TCPProxy.Dialer(dialInfo.Timeout)
+			if err != nil {
+				return fmt.Errorf("proxy: %w", err)
+			}
 			dialInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
-				conn, err := tls.Dial("tcp", addr.String(), tlsConfig)
+				conn, err := dialer.Dial("tcp", addr.String())
 				if err != nil {
-					fmt.Printf("error in Dial, %s\n", err.Error())
+					return nil, fmt.Errorf("dial (%s): %w", addr.String(), err)
+				}
+				if tlsConfig == nil {
+					return conn, nil
+				}
+				cfg := tlsConfig.Clone()
+				if cfg.ServerName == "" && !cfg.InsecureSkipVerify {
+					if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+						cfg.ServerName = host
+					}
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err := tlsConn.Handshake(); err != nil {
+					conn.Close()
+					return nil, fmt.Errorf("tls dial (%s): %w", addr.String(), err)
 				}
-				return conn, fmt.Errorf("tls dial (%s): %w", addr.String(), err)
+				return tlsConn, nil
 			}
 		}
 
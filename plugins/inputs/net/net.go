@@ -8,8 +8,10 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/netns"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/system"
+	psnet "github.com/shirou/gopsutil/v3/net"
 )
 
 type IOStats struct {
@@ -19,6 +21,7 @@ type IOStats struct {
 	skipChecks          bool
 	IgnoreProtocolStats bool
 	Interfaces          []string
+	netns.Config
 }
 
 func (*IOStats) Description() string {
@@ -34,11 +37,18 @@ var netSampleConfig = `
   ##
   # interfaces = ["eth0"]
   ##
-  ## On linux systems agent also collects protocol stats.
+  ## On linux, macOS and FreeBSD systems agent also collects protocol stats.
   ## Setting ignore_protocol_stats to true will skip reporting of protocol metrics.
   ##
   # ignore_protocol_stats = false
   ##
+  ## On linux systems agent can also gather the interfaces of network
+  ## namespaces, e.g. of containers or VRFs, tagged with the namespace.
+  ## Names of the namespaces in namespace_dirs, globs accepted.
+  ##
+  # namespaces = []
+  # namespace_dirs = ["/var/run/netns"]
+  ##
 `
 
 func (*IOStats) SampleConfig() string {
@@ -61,6 +71,53 @@ func (s *IOStats) Gather(ctx context.Context, acc cua.Accumulator) error {
 	if err != nil {
 		return fmt.Errorf("error getting list of interfaces: %w", err)
 	}
+	s.addInterfaces(acc, netio, interfaces, map[string]string{})
+
+	// Get system wide stats for different network protocols
+	// (ignore these stats if the call fails)
+	if !s.IgnoreProtocolStats {
+		netprotos, _ := s.ps.NetProto()
+		fields := make(map[string]interface{})
+		for _, proto := range netprotos {
+			for stat, value := range proto.Stats {
+				name := fmt.Sprintf("%s_%s", strings.ToLower(proto.Protocol),
+					strings.ToLower(stat))
+				fields[name] = value
+			}
+		}
+		tags := map[string]string{
+			"interface": "all",
+		}
+		acc.AddFields("net", fields, tags)
+	}
+
+	namespaces, err := s.List()
+	if err != nil {
+		return fmt.Errorf("error listing network namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		err := netns.Do(ns, func() error {
+			netio, err := psnet.IOCountersByFile(true, netns.ThreadProcNet+"/dev")
+			if err != nil {
+				return fmt.Errorf("net io info: %w", err)
+			}
+			interfaces, err := net.Interfaces()
+			if err != nil {
+				return fmt.Errorf("list of interfaces: %w", err)
+			}
+			s.addInterfaces(acc, netio, interfaces, map[string]string{netns.TagKey: ns.Name})
+			return nil
+		})
+		if err != nil {
+			acc.AddError(fmt.Errorf("error gathering namespace %s: %w", ns.Name, err))
+		}
+	}
+
+	return nil
+}
+
+// addInterfaces adds the counters of the selected interfaces
+func (s *IOStats) addInterfaces(acc cua.Accumulator, netio []psnet.IOCountersStat, interfaces []net.Interface, extraTags map[string]string) {
 	interfacesByName := map[string]net.Interface{}
 	for _, iface := range interfaces {
 		interfacesByName[iface.Name] = iface
@@ -95,6 +152,9 @@ func (s *IOStats) Gather(ctx context.Context, acc cua.Accumulator) error {
 		tags := map[string]string{
 			"interface": io.Name,
 		}
+		for k, v := range extraTags {
+			tags[k] = v
+		}
 
 		fields := map[string]interface{}{
 			"bytes_sent":   io.BytesSent,
@@ -108,26 +168,6 @@ func (s *IOStats) Gather(ctx context.Context, acc cua.Accumulator) error {
 		}
 		acc.AddCounter("net", fields, tags)
 	}
-
-	// Get system wide stats for different network protocols
-	// (ignore these stats if the call fails)
-	if !s.IgnoreProtocolStats {
-		netprotos, _ := s.ps.NetProto()
-		fields := make(map[string]interface{})
-		for _, proto := range netprotos {
-			for stat, value := range proto.Stats {
-				name := fmt.Sprintf("%s_%s", strings.ToLower(proto.Protocol),
-					strings.ToLower(stat))
-				fields[name] = value
-			}
-		}
-		tags := map[string]string{
-			"interface": "all",
-		}
-		acc.AddFields("net", fields, tags)
-	}
-
-	return nil
 }
 
 func init() {
@@ -2,6 +2,8 @@ package net
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 
@@ -89,7 +91,7 @@ func TestNetStats(t *testing.T) {
 
 	acc.Metrics = nil
 
-	err = (&Stats{&mps}).Gather(context.Background(), &acc)
+	err = (&Stats{ps: &mps}).Gather(context.Background(), &acc)
 	require.NoError(t, err)
 
 	fields3 := map[string]interface{}{
@@ -115,3 +117,24 @@ func TestNetStats(t *testing.T) {
 
 	acc.AssertDoesNotContainsTaggedFields(t, "netstat", fields3, make(map[string]string))
 }
+
+func TestSocketStates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tcp"), []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1000 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0016 0100007F:A000 01 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:0016 0100007F:A002 06 00000000:00000000 03:00000000 00000000     0        0 0 3 0000000000000000
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "udp"), []byte(`   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  0: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1002 2 0000000000000000 0
+`), 0600))
+
+	counts, err := socketStates(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"LISTEN":      1,
+		"ESTABLISHED": 1,
+		"TIME_WAIT":   1,
+		"UDP":         1,
+	}, counts)
+}
@@ -1,17 +1,38 @@
 package net
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/netns"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/system"
 )
 
+// tcpStates are the TCP states of the st column of /proc/net/tcp
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
 type Stats struct {
 	ps system.PS
+	netns.Config
 }
 
 func (*Stats) Description() string {
@@ -20,6 +41,12 @@ func (*Stats) Description() string {
 
 var tcpstatSampleConfig = `
   instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## On linux systems agent can also gather the sockets of network
+  ## namespaces, e.g. of containers or VRFs, tagged with the namespace.
+  ## Names of the namespaces in namespace_dirs, globs accepted.
+  # namespaces = []
+  # namespace_dirs = ["/var/run/netns"]
 `
 
 func (*Stats) SampleConfig() string {
@@ -48,7 +75,31 @@ func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
 		counts[netcon.Status] = c + 1
 	}
 
-	fields := map[string]interface{}{
+	acc.AddFields("netstat", netstatFields(counts), tags)
+
+	namespaces, err := s.List()
+	if err != nil {
+		return fmt.Errorf("error listing network namespaces: %w", err)
+	}
+	for _, ns := range namespaces {
+		err := netns.Do(ns, func() error {
+			counts, err := socketStates(netns.ThreadProcNet)
+			if err != nil {
+				return err
+			}
+			acc.AddFields("netstat", netstatFields(counts), map[string]string{netns.TagKey: ns.Name})
+			return nil
+		})
+		if err != nil {
+			acc.AddError(fmt.Errorf("error gathering namespace %s: %w", ns.Name, err))
+		}
+	}
+
+	return nil
+}
+
+func netstatFields(counts map[string]int) map[string]interface{} {
+	return map[string]interface{}{
 		"tcp_established": counts["ESTABLISHED"],
 		"tcp_syn_sent":    counts["SYN_SENT"],
 		"tcp_syn_recv":    counts["SYN_RECV"],
@@ -63,9 +114,42 @@ func (s *Stats) Gather(ctx context.Context, acc cua.Accumulator) error {
 		"tcp_none":        counts["NONE"],
 		"udp_socket":      counts["UDP"],
 	}
-	acc.AddFields("netstat", fields, tags)
+}
 
-	return nil
+// socketStates counts the TCP sockets by state and the UDP sockets (UDP)
+// of the tcp, tcp6, udp and udp6 files of the proc net directory
+func socketStates(procNet string) (map[string]int, error) {
+	counts := map[string]int{"UDP": 0}
+	for _, file := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		f, err := os.Open(filepath.Join(procNet, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // e.g. without IPv6
+			}
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 4 {
+				continue
+			}
+			if strings.HasPrefix(file, "udp") {
+				counts["UDP"]++
+				continue
+			}
+			if state, ok := tcpStates[fields[3]]; ok {
+				counts[state]++
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+	}
+	return counts, nil
 }
 
 func init() {
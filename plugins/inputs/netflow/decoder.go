@@ -0,0 +1,313 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+const (
+	netflowV5 = 5
+	netflowV9 = 9
+	ipfix     = 10
+
+	v5HeaderLen    = 24
+	v5RecordLen    = 48
+	v9HeaderLen    = 20
+	ipfixHeaderLen = 16
+
+	v9TemplateSetID           = 0
+	v9OptionsTemplateSetID    = 1
+	ipfixTemplateSetID        = 2
+	ipfixOptionsTemplateSetID = 3
+	minDataSetID              = 256
+
+	ipfixVariableLength = 65535
+	enterpriseBit       = 0x8000
+)
+
+var errShortPacket = errors.New("packet too short")
+
+// flowRecord is a single decoded flow, keyed by normalized field name.
+type flowRecord map[string]interface{}
+
+// templateField describes one field of a v9/IPFIX template.
+type templateField struct {
+	id         uint16
+	length     uint16
+	enterprise uint32
+}
+
+type template struct {
+	fields []templateField
+	// scoped templates describe options records, which are parsed to keep
+	// the data set aligned but are not reported as flows
+	options bool
+}
+
+// templateKey identifies a template; template IDs are only unique per
+// exporter and observation domain (v9 source id).
+type templateKey struct {
+	exporter string
+	domain   uint32
+	id       uint16
+}
+
+// decoder decodes NetFlow v5, v9, and IPFIX datagrams. Templates learned
+// from v9 and IPFIX template sets are cached per exporter.
+type decoder struct {
+	sync.Mutex
+	templates map[templateKey]*template
+}
+
+func newDecoder() *decoder {
+	return &decoder{templates: make(map[templateKey]*template)}
+}
+
+// decode parses a single NetFlow datagram from the given exporter.
+func (d *decoder) decode(exporter string, buf []byte) ([]flowRecord, error) {
+	if len(buf) < 2 {
+		return nil, errShortPacket
+	}
+	switch version := binary.BigEndian.Uint16(buf[0:2]); version {
+	case netflowV5:
+		return decodeV5(buf)
+	case netflowV9:
+		return d.decodeV9(exporter, buf)
+	case ipfix:
+		return d.decodeIPFIX(exporter, buf)
+	default:
+		return nil, fmt.Errorf("unsupported netflow version %d", version)
+	}
+}
+
+func decodeV5(buf []byte) ([]flowRecord, error) {
+	if len(buf) < v5HeaderLen {
+		return nil, errShortPacket
+	}
+	count := int(binary.BigEndian.Uint16(buf[2:4]))
+	samplingInterval := binary.BigEndian.Uint16(buf[22:24]) & 0x3fff
+	if len(buf) < v5HeaderLen+count*v5RecordLen {
+		return nil, fmt.Errorf("v5 header declares %d records, datagram holds %d bytes: %w",
+			count, len(buf), errShortPacket)
+	}
+
+	records := make([]flowRecord, 0, count)
+	for i := 0; i < count; i++ {
+		r := buf[v5HeaderLen+i*v5RecordLen : v5HeaderLen+(i+1)*v5RecordLen]
+		rec := flowRecord{
+			"src_ip":         net.IP(r[0:4]).String(),
+			"dst_ip":         net.IP(r[4:8]).String(),
+			"next_hop":       net.IP(r[8:12]).String(),
+			"input_ifindex":  uint64(binary.BigEndian.Uint16(r[12:14])),
+			"output_ifindex": uint64(binary.BigEndian.Uint16(r[14:16])),
+			"packets":        uint64(binary.BigEndian.Uint32(r[16:20])),
+			"bytes":          uint64(binary.BigEndian.Uint32(r[20:24])),
+			"src_port":       uint64(binary.BigEndian.Uint16(r[32:34])),
+			"dst_port":       uint64(binary.BigEndian.Uint16(r[34:36])),
+			"tcp_flags":      uint64(r[37]),
+			"protocol":       uint64(r[38]),
+			"tos":            uint64(r[39]),
+			"src_as":         uint64(binary.BigEndian.Uint16(r[40:42])),
+			"dst_as":         uint64(binary.BigEndian.Uint16(r[42:44])),
+			"src_mask":       uint64(r[44]),
+			"dst_mask":       uint64(r[45]),
+		}
+		if samplingInterval > 1 {
+			rec["sampling_rate"] = uint64(samplingInterval)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (d *decoder) decodeV9(exporter string, buf []byte) ([]flowRecord, error) {
+	if len(buf) < v9HeaderLen {
+		return nil, errShortPacket
+	}
+	sourceID := binary.BigEndian.Uint32(buf[16:20])
+	return d.decodeSets(exporter, sourceID, buf[v9HeaderLen:], false)
+}
+
+func (d *decoder) decodeIPFIX(exporter string, buf []byte) ([]flowRecord, error) {
+	if len(buf) < ipfixHeaderLen {
+		return nil, errShortPacket
+	}
+	length := int(binary.BigEndian.Uint16(buf[2:4]))
+	if length < ipfixHeaderLen || length > len(buf) {
+		return nil, fmt.Errorf("ipfix message length %d invalid for %d byte datagram", length, len(buf))
+	}
+	domain := binary.BigEndian.Uint32(buf[12:16])
+	return d.decodeSets(exporter, domain, buf[ipfixHeaderLen:length], true)
+}
+
+// decodeSets walks the flowsets (v9) or sets (IPFIX) following the header.
+func (d *decoder) decodeSets(exporter string, domain uint32, buf []byte, isIPFIX bool) ([]flowRecord, error) {
+	var records []flowRecord
+	for len(buf) >= 4 {
+		setID := binary.BigEndian.Uint16(buf[0:2])
+		setLen := int(binary.BigEndian.Uint16(buf[2:4]))
+		if setLen < 4 || setLen > len(buf) {
+			return records, fmt.Errorf("set %d has invalid length %d", setID, setLen)
+		}
+		body := buf[4:setLen]
+		buf = buf[setLen:]
+
+		var err error
+		switch {
+		case !isIPFIX && setID == v9TemplateSetID,
+			isIPFIX && setID == ipfixTemplateSetID:
+			err = d.parseTemplates(exporter, domain, body, isIPFIX, false)
+		case !isIPFIX && setID == v9OptionsTemplateSetID,
+			isIPFIX && setID == ipfixOptionsTemplateSetID:
+			err = d.parseTemplates(exporter, domain, body, isIPFIX, true)
+		case setID >= minDataSetID:
+			var recs []flowRecord
+			recs, err = d.parseData(exporter, domain, setID, body)
+			records = append(records, recs...)
+		default:
+			// reserved set ids are skipped
+		}
+		if err != nil {
+			return records, err
+		}
+	}
+	return records, nil
+}
+
+func (d *decoder) parseTemplates(exporter string, domain uint32, body []byte, isIPFIX, options bool) error {
+	// templates are padded to a 4 byte boundary, anything shorter than a
+	// template header is padding
+	for len(body) >= 4 {
+		id := binary.BigEndian.Uint16(body[0:2])
+		var fieldCount int
+		switch {
+		case options && isIPFIX:
+			if len(body) < 6 {
+				return errShortPacket
+			}
+			fieldCount = int(binary.BigEndian.Uint16(body[2:4]))
+			body = body[6:]
+		case options:
+			if len(body) < 6 {
+				return errShortPacket
+			}
+			// v9 options templates declare byte lengths of the scope and
+			// option field specifiers rather than counts
+			scopeLen := int(binary.BigEndian.Uint16(body[2:4]))
+			optLen := int(binary.BigEndian.Uint16(body[4:6]))
+			fieldCount = (scopeLen + optLen) / 4
+			body = body[6:]
+		default:
+			fieldCount = int(binary.BigEndian.Uint16(body[2:4]))
+			body = body[4:]
+		}
+		if id < minDataSetID {
+			// IPFIX template withdrawal or padding
+			return nil
+		}
+
+		tmpl := &template{options: options, fields: make([]templateField, 0, fieldCount)}
+		for i := 0; i < fieldCount; i++ {
+			if len(body) < 4 {
+				return fmt.Errorf("template %d truncated: %w", id, errShortPacket)
+			}
+			f := templateField{
+				id:     binary.BigEndian.Uint16(body[0:2]),
+				length: binary.BigEndian.Uint16(body[2:4]),
+			}
+			body = body[4:]
+			if isIPFIX && f.id&enterpriseBit != 0 {
+				if len(body) < 4 {
+					return fmt.Errorf("template %d truncated: %w", id, errShortPacket)
+				}
+				f.id &^= enterpriseBit
+				f.enterprise = binary.BigEndian.Uint32(body[0:4])
+				body = body[4:]
+			}
+			tmpl.fields = append(tmpl.fields, f)
+		}
+
+		d.Lock()
+		d.templates[templateKey{exporter: exporter, domain: domain, id: id}] = tmpl
+		d.Unlock()
+	}
+	return nil
+}
+
+func (d *decoder) parseData(exporter string, domain uint32, setID uint16, body []byte) ([]flowRecord, error) {
+	d.Lock()
+	tmpl, ok := d.templates[templateKey{exporter: exporter, domain: domain, id: setID}]
+	d.Unlock()
+	if !ok {
+		// data arrived before its template; exporters resend templates
+		// periodically so these records are dropped rather than reported
+		return nil, nil
+	}
+
+	var records []flowRecord
+	for len(body) > 0 {
+		rec := flowRecord{}
+		consumed := 0
+		for _, f := range tmpl.fields {
+			length := int(f.length)
+			if f.length == ipfixVariableLength {
+				if len(body) < consumed+1 {
+					return records, nil
+				}
+				length = int(body[consumed])
+				consumed++
+				if length == 255 {
+					if len(body) < consumed+2 {
+						return records, nil
+					}
+					length = int(binary.BigEndian.Uint16(body[consumed : consumed+2]))
+					consumed += 2
+				}
+			}
+			if len(body) < consumed+length {
+				// remaining bytes are set padding
+				return records, nil
+			}
+			if f.enterprise == 0 {
+				setField(rec, f.id, body[consumed:consumed+length])
+			}
+			consumed += length
+		}
+		if consumed == 0 {
+			return records, nil
+		}
+		body = body[consumed:]
+		if !tmpl.options {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+func setField(rec flowRecord, id uint16, value []byte) {
+	ie, ok := informationElements[id]
+	if !ok {
+		return
+	}
+	switch ie.kind {
+	case kindUnsigned:
+		if len(value) > 8 {
+			return
+		}
+		var v uint64
+		for _, b := range value {
+			v = v<<8 | uint64(b)
+		}
+		rec[ie.name] = v
+	case kindIP:
+		if len(value) != net.IPv4len && len(value) != net.IPv6len {
+			return
+		}
+		rec[ie.name] = net.IP(value).String()
+	case kindMAC:
+		rec[ie.name] = net.HardwareAddr(value).String()
+	}
+}
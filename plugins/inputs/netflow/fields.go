@@ -0,0 +1,69 @@
+package netflow
+
+type fieldKind int
+
+const (
+	kindUnsigned fieldKind = iota
+	kindIP
+	kindMAC
+)
+
+type informationElement struct {
+	name string
+	kind fieldKind
+}
+
+// informationElements maps the NetFlow v9 field types and IANA IPFIX
+// information element ids (which share a numbering) to the normalized names
+// used for NetFlow v5 records. Elements not listed here are skipped.
+var informationElements = map[uint16]informationElement{
+	1:   {"bytes", kindUnsigned},             // octetDeltaCount
+	2:   {"packets", kindUnsigned},           // packetDeltaCount
+	4:   {"protocol", kindUnsigned},          // protocolIdentifier
+	5:   {"tos", kindUnsigned},               // ipClassOfService
+	6:   {"tcp_flags", kindUnsigned},         // tcpControlBits
+	7:   {"src_port", kindUnsigned},          // sourceTransportPort
+	8:   {"src_ip", kindIP},                  // sourceIPv4Address
+	9:   {"src_mask", kindUnsigned},          // sourceIPv4PrefixLength
+	10:  {"input_ifindex", kindUnsigned},     // ingressInterface
+	11:  {"dst_port", kindUnsigned},          // destinationTransportPort
+	12:  {"dst_ip", kindIP},                  // destinationIPv4Address
+	13:  {"dst_mask", kindUnsigned},          // destinationIPv4PrefixLength
+	14:  {"output_ifindex", kindUnsigned},    // egressInterface
+	15:  {"next_hop", kindIP},                // ipNextHopIPv4Address
+	16:  {"src_as", kindUnsigned},            // bgpSourceAsNumber
+	17:  {"dst_as", kindUnsigned},            // bgpDestinationAsNumber
+	27:  {"src_ip", kindIP},                  // sourceIPv6Address
+	28:  {"dst_ip", kindIP},                  // destinationIPv6Address
+	29:  {"src_mask", kindUnsigned},          // sourceIPv6PrefixLength
+	30:  {"dst_mask", kindUnsigned},          // destinationIPv6PrefixLength
+	32:  {"icmp_type_code", kindUnsigned},    // icmpTypeCodeIPv4
+	34:  {"sampling_rate", kindUnsigned},     // samplingInterval
+	56:  {"src_mac", kindMAC},                // sourceMacAddress
+	58:  {"vlan", kindUnsigned},              // vlanId
+	61:  {"direction", kindUnsigned},         // flowDirection
+	62:  {"next_hop", kindIP},                // ipNextHopIPv6Address
+	80:  {"dst_mac", kindMAC},                // destinationMacAddress
+	85:  {"bytes", kindUnsigned},             // octetTotalCount
+	86:  {"packets", kindUnsigned},           // packetTotalCount
+	136: {"end_reason", kindUnsigned},        // flowEndReason
+	139: {"icmp_type_code", kindUnsigned},    // icmpTypeCodeIPv6
+	225: {"post_nat_src_ip", kindIP},         // postNATSourceIPv4Address
+	226: {"post_nat_dst_ip", kindIP},         // postNATDestinationIPv4Address
+	227: {"post_nat_src_port", kindUnsigned}, // postNAPTSourceTransportPort
+	228: {"post_nat_dst_port", kindUnsigned}, // postNAPTDestinationTransportPort
+}
+
+// protocolNames translates common IP protocol numbers for the protocol tag.
+var protocolNames = map[uint64]string{
+	1:   "icmp",
+	2:   "igmp",
+	6:   "tcp",
+	17:  "udp",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "ipv6-icmp",
+	89:  "ospf",
+	132: "sctp",
+}
@@ -0,0 +1,380 @@
+package netflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/sflow"
+	"github.com/circonus-labs/circonus-unified-agent/selfstat"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Address to listen for NetFlow v5/v9, IPFIX, and sFlow v5 datagrams.
+  ## The protocol is detected from each datagram's version field.
+  ##   example: service_address = "udp://:2055"
+  ##            service_address = "udp4://:4739"
+  ##            service_address = "udp6://:6343"
+  service_address = "udp://:2055"
+
+  ## Set the size of the operating system's receive buffer.
+  ##   example: read_buffer_size = "64KiB"
+  # read_buffer_size = ""
+
+  ## Flow record fields used to group flows. Each distinct combination is
+  ## emitted as a separate series every interval, tagged with these fields,
+  ## with the summed bytes, packets, and flow count.
+  ## Available: exporter, flow_version, src_ip, dst_ip, src_port, dst_port,
+  ##   protocol, tos, tcp_flags, input_ifindex, output_ifindex, next_hop,
+  ##   src_as, dst_as, src_mask, dst_mask, src_mac, dst_mac, vlan, direction
+  # aggregate_by = ["exporter", "src_ip", "dst_ip", "protocol", "dst_port"]
+
+  ## Scale byte and packet counts by the exporter's sampling rate when
+  ## the exporter reports one.
+  # apply_sampling_rate = true
+
+  ## Maximum number of aggregated flows per interval. Records of new flows
+  ## beyond it are dropped until the next gather.
+  # max_flows = 100000
+`
+
+const (
+	maxPacketSize   = 64 * 1024
+	measurement     = "netflow"
+	defaultMaxFlows = 100000
+)
+
+var defaultAggregateBy = []string{"exporter", "src_ip", "dst_ip", "protocol", "dst_port"}
+
+type NetFlow struct {
+	ServiceAddress    string        `toml:"service_address"`
+	ReadBufferSize    internal.Size `toml:"read_buffer_size"`
+	AggregateBy       []string      `toml:"aggregate_by"`
+	ApplySamplingRate bool          `toml:"apply_sampling_rate"`
+	MaxFlows          int           `toml:"max_flows"`
+
+	Log cua.Logger `toml:"-"`
+
+	addr   net.Addr
+	closer io.Closer
+	wg     sync.WaitGroup
+
+	decoder      *decoder
+	sflowDecoder *sflow.PacketDecoder
+
+	flowsDropped selfstat.Stat
+
+	sync.Mutex
+	flows   map[string]*flowAggregate
+	dropped int64
+}
+
+// flowAggregate accumulates the counters of all flows sharing a key.
+type flowAggregate struct {
+	tags    map[string]string
+	bytes   uint64
+	packets uint64
+	flows   uint64
+}
+
+// Description answers a description of this input plugin
+func (n *NetFlow) Description() string {
+	return "NetFlow v5/v9, IPFIX, and sFlow v5 collector"
+}
+
+// SampleConfig answers a sample configuration
+func (n *NetFlow) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NetFlow) Init() error {
+	if len(n.AggregateBy) == 0 {
+		n.AggregateBy = defaultAggregateBy
+	}
+	n.decoder = newDecoder()
+	n.sflowDecoder = sflow.NewDecoder()
+	n.sflowDecoder.Log = n.Log
+	if n.MaxFlows <= 0 {
+		n.MaxFlows = defaultMaxFlows
+	}
+	n.flows = make(map[string]*flowAggregate)
+	n.flowsDropped = selfstat.Register("netflow", "flows_dropped", map[string]string{
+		"address": n.ServiceAddress,
+	})
+	return nil
+}
+
+// Start starts listening on the configured network for flow datagrams
+func (n *NetFlow) Start(_ context.Context, acc cua.Accumulator) error {
+	u, err := url.Parse(n.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("url parse (%s): %w", n.ServiceAddress, err)
+	}
+
+	conn, err := listenUDP(u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	n.closer = conn
+	n.addr = conn.LocalAddr()
+
+	if n.ReadBufferSize.Size > 0 {
+		_ = conn.SetReadBuffer(int(n.ReadBufferSize.Size))
+	}
+
+	n.Log.Infof("Listening on %s://%s", n.addr.Network(), n.addr.String())
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.read(acc, conn)
+	}()
+
+	return nil
+}
+
+// Gather emits the flows aggregated since the previous call
+func (n *NetFlow) Gather(_ context.Context, acc cua.Accumulator) error {
+	n.Lock()
+	flows, dropped := n.flows, n.dropped
+	n.flows = make(map[string]*flowAggregate)
+	n.dropped = 0
+	n.Unlock()
+
+	if dropped > 0 {
+		n.flowsDropped.Incr(dropped)
+		n.Log.Warnf("Dropped %d flow records over max_flows %d", dropped, n.MaxFlows)
+	}
+
+	now := time.Now()
+	for _, agg := range flows {
+		acc.AddFields(measurement, map[string]interface{}{
+			"bytes":   agg.bytes,
+			"packets": agg.packets,
+			"flows":   agg.flows,
+		}, agg.tags, now)
+	}
+	return nil
+}
+
+func (n *NetFlow) Stop() {
+	if n.closer != nil {
+		n.closer.Close()
+	}
+	n.wg.Wait()
+}
+
+func (n *NetFlow) Address() net.Addr {
+	return n.addr
+}
+
+func (n *NetFlow) read(acc cua.Accumulator, conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		sz, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				acc.AddError(err)
+			}
+			break
+		}
+		exporter := src.String()
+		if ua, ok := src.(*net.UDPAddr); ok {
+			exporter = ua.IP.String()
+		}
+		if err := n.process(exporter, buf[:sz]); err != nil {
+			acc.AddError(fmt.Errorf("unable to parse datagram from %s: %w", exporter, err))
+		}
+	}
+}
+
+func (n *NetFlow) process(exporter string, buf []byte) error {
+	if len(buf) < 4 {
+		return errShortPacket
+	}
+
+	// sFlow carries a 32 bit version, NetFlow and IPFIX a 16 bit one, so a
+	// leading zero word followed by 5 is an sFlow v5 datagram.
+	if binary.BigEndian.Uint32(buf[0:4]) == 5 {
+		p, err := n.sflowDecoder.DecodeOnePacket(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("sflow: %w", err)
+		}
+		n.add(exporter, "sflow", sflowRecords(p))
+		return nil
+	}
+
+	records, err := n.decoder.decode(exporter, buf)
+	n.add(exporter, flowVersion(buf), records)
+	return err
+}
+
+// add folds decoded records into the per-key aggregates.
+func (n *NetFlow) add(exporter, version string, records []flowRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	n.Lock()
+	defer n.Unlock()
+	for _, rec := range records {
+		rec["exporter"] = exporter
+		rec["flow_version"] = version
+
+		tags := make(map[string]string, len(n.AggregateBy))
+		for _, k := range n.AggregateBy {
+			if v, ok := rec[k]; ok {
+				tags[k] = formatTag(k, v)
+			}
+		}
+		key := seriesKey(tags)
+
+		agg, ok := n.flows[key]
+		if !ok {
+			if len(n.flows) >= n.MaxFlows {
+				n.dropped++
+				continue
+			}
+			agg = &flowAggregate{tags: tags}
+			n.flows[key] = agg
+		}
+
+		b, _ := rec["bytes"].(uint64)
+		p, _ := rec["packets"].(uint64)
+		if rate, ok := rec["sampling_rate"].(uint64); ok && rate > 1 && n.ApplySamplingRate {
+			b *= rate
+			p *= rate
+		}
+		agg.bytes += b
+		agg.packets += p
+		agg.flows++
+	}
+}
+
+func flowVersion(buf []byte) string {
+	switch binary.BigEndian.Uint16(buf[0:2]) {
+	case netflowV5:
+		return "netflow5"
+	case netflowV9:
+		return "netflow9"
+	case ipfix:
+		return "ipfix"
+	default:
+		return "unknown"
+	}
+}
+
+// sflowRecords converts the flow samples of an sFlow datagram into flow
+// records. Counter samples are not flows and are ignored.
+func sflowRecords(p *sflow.V5Format) []flowRecord {
+	var records []flowRecord
+	for _, sample := range p.Samples {
+		for _, fr := range sample.SampleData.FlowRecords {
+			if fr.FlowData == nil {
+				continue
+			}
+			rec := flowRecord{
+				"input_ifindex":  uint64(sample.SampleData.InputIfIndex),
+				"output_ifindex": uint64(sample.SampleData.OutputIfIndex),
+				"direction":      sample.SampleData.SampleDirection,
+				// each flow sample is one sampled packet, scaled by its
+				// sampling rate like the other protocols
+				"packets":       uint64(1),
+				"sampling_rate": uint64(sample.SampleData.SamplingRate),
+			}
+			for k, v := range fr.FlowData.GetTags() {
+				switch k {
+				case "src_port", "dst_port":
+					if port, err := strconv.ParseUint(v, 10, 16); err == nil {
+						rec[k] = port
+					}
+				case "src_ip", "dst_ip", "src_mac", "dst_mac":
+					rec[k] = v
+				}
+			}
+			// the sFlow decoder scales bytes by the sampling rate, the frame
+			// length is the unscaled count
+			if b, ok := fr.FlowData.GetFields()["frame_length"].(uint32); ok {
+				rec["bytes"] = uint64(b)
+			}
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func formatTag(key string, v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case uint64:
+		switch key {
+		case "protocol":
+			if name, ok := protocolNames[val]; ok {
+				return name
+			}
+		case "direction":
+			// flowDirection: 0 ingress, 1 egress (matches sFlow naming)
+			if val == 0 {
+				return "ingress"
+			}
+			return "egress"
+		}
+		return strconv.FormatUint(val, 10)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func seriesKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(tags[k])
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+func listenUDP(network string, address string) (*net.UDPConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+		addr, err := net.ResolveUDPAddr(network, address)
+		if err != nil {
+			return nil, fmt.Errorf("resolve udp addr (%s): %w", address, err)
+		}
+		return net.ListenUDP(network, addr)
+	default:
+		return nil, fmt.Errorf("unsupported network type: %s", network)
+	}
+}
+
+func init() {
+	inputs.Add("netflow", func() cua.Input {
+		return &NetFlow{
+			ApplySamplingRate: true,
+			MaxFlows:          defaultMaxFlows,
+		}
+	})
+}
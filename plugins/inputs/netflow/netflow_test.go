@@ -0,0 +1,265 @@
+package netflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func v5Packet(t *testing.T) []byte {
+	var buf bytes.Buffer
+	hdr := []interface{}{
+		uint16(5), uint16(2), uint32(1000), uint32(1600000000), uint32(0),
+		uint32(1), uint8(0), uint8(0), uint16(0),
+	}
+	for _, v := range hdr {
+		require.NoError(t, binary.Write(&buf, binary.BigEndian, v))
+	}
+	for i := 0; i < 2; i++ {
+		rec := []interface{}{
+			[4]byte{10, 0, 0, 1}, [4]byte{10, 0, 0, 2}, [4]byte{10, 0, 0, 254},
+			uint16(1), uint16(2), uint32(10), uint32(1500), uint32(0), uint32(0),
+			uint16(40000 + i), uint16(443), uint8(0), uint8(0x18), uint8(6), uint8(0),
+			uint16(64512), uint16(64513), uint8(24), uint8(24), uint16(0),
+		}
+		for _, v := range rec {
+			require.NoError(t, binary.Write(&buf, binary.BigEndian, v))
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeV5(t *testing.T) {
+	records, err := newDecoder().decode("192.0.2.1", v5Packet(t))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "10.0.0.1", records[0]["src_ip"])
+	require.Equal(t, "10.0.0.2", records[0]["dst_ip"])
+	require.Equal(t, uint64(443), records[0]["dst_port"])
+	require.Equal(t, uint64(40001), records[1]["src_port"])
+	require.Equal(t, uint64(1500), records[0]["bytes"])
+	require.Equal(t, uint64(10), records[0]["packets"])
+	require.Equal(t, uint64(6), records[0]["protocol"])
+}
+
+func writeSet(t *testing.T, buf *bytes.Buffer, id uint16, body []byte) {
+	require.NoError(t, binary.Write(buf, binary.BigEndian, id))
+	require.NoError(t, binary.Write(buf, binary.BigEndian, uint16(len(body)+4)))
+	buf.Write(body)
+}
+
+func TestDecodeV9TemplateThenData(t *testing.T) {
+	var tmpl bytes.Buffer
+	for _, v := range []uint16{
+		256, 5, // template id, field count
+		8, 4, // src ip
+		12, 4, // dst ip
+		4, 1, // protocol
+		1, 4, // bytes
+		2, 4, // packets
+	} {
+		require.NoError(t, binary.Write(&tmpl, binary.BigEndian, v))
+	}
+
+	var data bytes.Buffer
+	data.Write([]byte{192, 168, 1, 1, 192, 168, 1, 2, 17})
+	require.NoError(t, binary.Write(&data, binary.BigEndian, uint32(900)))
+	require.NoError(t, binary.Write(&data, binary.BigEndian, uint32(3)))
+	data.Write([]byte{0, 0, 0}) // padding
+
+	header := func(buf *bytes.Buffer) {
+		for _, v := range []interface{}{uint16(9), uint16(1), uint32(0), uint32(0), uint32(1), uint32(42)} {
+			require.NoError(t, binary.Write(buf, binary.BigEndian, v))
+		}
+	}
+
+	d := newDecoder()
+
+	// data before the template is dropped
+	var early bytes.Buffer
+	header(&early)
+	writeSet(t, &early, 256, data.Bytes())
+	records, err := d.decode("192.0.2.1", early.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	var pkt bytes.Buffer
+	header(&pkt)
+	writeSet(t, &pkt, 0, tmpl.Bytes())
+	writeSet(t, &pkt, 256, data.Bytes())
+	records, err = d.decode("192.0.2.1", pkt.Bytes())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "192.168.1.1", records[0]["src_ip"])
+	require.Equal(t, uint64(17), records[0]["protocol"])
+	require.Equal(t, uint64(900), records[0]["bytes"])
+
+	// templates are scoped to the exporter
+	records, err = d.decode("192.0.2.2", early.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
+
+func TestDecodeIPFIXEnterpriseAndVariableLength(t *testing.T) {
+	var tmpl bytes.Buffer
+	for _, v := range []interface{}{
+		uint16(300), uint16(4),
+		uint16(27), uint16(16), // src ipv6
+		uint16(0x8000 | 100), uint16(ipfixVariableLength), uint32(9), // enterprise, variable
+		uint16(11), uint16(2), // dst port
+		uint16(85), uint16(8), // octetTotalCount
+	} {
+		require.NoError(t, binary.Write(&tmpl, binary.BigEndian, v))
+	}
+
+	var data bytes.Buffer
+	data.Write(net.ParseIP("2001:db8::1").To16())
+	data.Write([]byte{3, 'a', 'b', 'c'})
+	require.NoError(t, binary.Write(&data, binary.BigEndian, uint16(53)))
+	require.NoError(t, binary.Write(&data, binary.BigEndian, uint64(123456)))
+
+	var sets bytes.Buffer
+	writeSet(t, &sets, 2, tmpl.Bytes())
+	writeSet(t, &sets, 300, data.Bytes())
+
+	var pkt bytes.Buffer
+	for _, v := range []interface{}{uint16(10), uint16(16 + sets.Len()), uint32(0), uint32(0), uint32(7)} {
+		require.NoError(t, binary.Write(&pkt, binary.BigEndian, v))
+	}
+	pkt.Write(sets.Bytes())
+
+	records, err := newDecoder().decode("192.0.2.1", pkt.Bytes())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "2001:db8::1", records[0]["src_ip"])
+	require.Equal(t, uint64(53), records[0]["dst_port"])
+	require.Equal(t, uint64(123456), records[0]["bytes"])
+}
+
+func TestAggregation(t *testing.T) {
+	n := &NetFlow{
+		AggregateBy: []string{"dst_ip", "protocol"},
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, n.Init())
+	require.NoError(t, n.process("192.0.2.1", v5Packet(t)))
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+
+	acc.AssertContainsTaggedFields(t, "netflow",
+		map[string]interface{}{
+			"bytes":   uint64(3000),
+			"packets": uint64(20),
+			"flows":   uint64(2),
+		},
+		map[string]string{
+			"dst_ip":   "10.0.0.2",
+			"protocol": "tcp",
+		})
+
+	// aggregates reset after each gather
+	acc.ClearMetrics()
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Empty(t, acc.GetCUAMetrics())
+}
+
+func TestMaxFlows(t *testing.T) {
+	n := &NetFlow{
+		AggregateBy: []string{"src_port"},
+		MaxFlows:    1,
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, n.Init())
+	dropped := n.flowsDropped.Get()
+	require.NoError(t, n.process("192.0.2.1", v5Packet(t)))
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(context.Background(), &acc))
+	require.Len(t, acc.GetCUAMetrics(), 1)
+	acc.AssertContainsTaggedFields(t, "netflow",
+		map[string]interface{}{
+			"bytes":   uint64(1500),
+			"packets": uint64(10),
+			"flows":   uint64(1),
+		},
+		map[string]string{"src_port": "40000"})
+	require.Equal(t, dropped+1, n.flowsDropped.Get())
+}
+
+// sflowPacket is an sFlow v5 datagram of two flow samples, the first one
+// of a 267 bytes packet sampled 1 in 1024
+const sflowPacket = "0000000500000001c0a80102000000100000f3d40bfa047f0000000200000001000000d00001210a000001fe000004000484240000000000000001fe00000200000000020000000100000090000000010000010b0000000400000080000c2936d3d694c691aa97600800450000f9f19040004011b4f5c0a80913c0a8090a00a1ba0500e5641f3081da02010104066d6f746f6770a281cc02047b46462e0201000201003081bd3012060d2b06010201190501010281dc710201003013060d2b06010201190501010281e66802025acc3012060d2b0601020119050101000003e9000000100000000900000000000000090000000000000001000000d00000e3cc000002100000400048eb740000000000000002100000020000000002000000010000009000000001000000970000000400000080000c2936d3d6fcecda44008f81000009080045000081186440003f119098c0a80815c0a8090a9a690202006d23083c33303e4170722031312030393a33333a3031206b6e6f64653120736e6d70645b313039385d3a20436f6e6e656374696f6e2066726f6d205544503a205b3139322e3136382e392e31305d3a34393233362d000003e90000001000000009000000000000000900000000"
+
+func TestSFlowSamplingRate(t *testing.T) {
+	packetBytes, err := hex.DecodeString(sflowPacket)
+	require.NoError(t, err)
+
+	for _, apply := range []bool{true, false} {
+		n := &NetFlow{
+			AggregateBy:       []string{"dst_port"},
+			ApplySamplingRate: apply,
+			Log:               testutil.Logger{},
+		}
+		require.NoError(t, n.Init())
+		require.NoError(t, n.process("192.0.2.1", packetBytes))
+
+		var acc testutil.Accumulator
+		require.NoError(t, n.Gather(context.Background(), &acc))
+
+		fields := map[string]interface{}{
+			"bytes":   uint64(267),
+			"packets": uint64(1),
+			"flows":   uint64(1),
+		}
+		if apply {
+			fields["bytes"] = uint64(273408)
+			fields["packets"] = uint64(1024)
+		}
+		acc.AssertContainsTaggedFields(t, "netflow", fields, map[string]string{"dst_port": "47621"})
+	}
+}
+
+func TestNetFlowListenerSFlow(t *testing.T) {
+	n := &NetFlow{
+		ServiceAddress: "udp://127.0.0.1:0",
+		AggregateBy:    []string{"flow_version", "dst_port"},
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, n.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Start(context.Background(), &acc))
+	defer n.Stop()
+
+	client, err := net.Dial(n.Address().Network(), n.Address().String())
+	require.NoError(t, err)
+
+	packetBytes, err := hex.DecodeString(sflowPacket)
+	require.NoError(t, err)
+	_, err = client.Write(packetBytes)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, n.Gather(context.Background(), &acc))
+		return acc.NMetrics() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	acc.AssertContainsTaggedFields(t, "netflow",
+		map[string]interface{}{
+			"bytes":   uint64(267),
+			"packets": uint64(1),
+			"flows":   uint64(1),
+		},
+		map[string]string{
+			"flow_version": "sflow",
+			"dst_port":     "47621",
+		})
+}
@@ -15,12 +15,16 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 )
 
-const measurement = "nvidia_smi"
+const (
+	measurement        = "nvidia_smi"
+	processMeasurement = "nvidia_smi_process"
+)
 
 // NvidiaSMI holds the methods for this plugin
 type NvidiaSMI struct {
-	BinPath string
-	Timeout internal.Duration
+	BinPath          string
+	Timeout          internal.Duration
+	CollectProcesses bool `toml:"collect_processes"`
 }
 
 // Description returns the description of the NvidiaSMI plugin
@@ -38,6 +42,10 @@ func (smi *NvidiaSMI) SampleConfig() string {
 
   ## Optional: timeout for GPU polling
   # timeout = "5s"
+
+  ## Optional: report the GPU memory used by each compute and graphics
+  ## process in the nvidia_smi_process measurement, tagged by pid
+  # collect_processes = false
 `
 }
 
@@ -52,7 +60,7 @@ func (smi *NvidiaSMI) Gather(ctx context.Context, acc cua.Accumulator) error {
 		return err
 	}
 
-	err = gatherNvidiaSMI(data, acc)
+	err = gatherNvidiaSMI(data, acc, smi.CollectProcesses)
 	if err != nil {
 		return err
 	}
@@ -80,7 +88,7 @@ func (smi *NvidiaSMI) pollSMI() ([]byte, error) {
 	return ret, nil
 }
 
-func gatherNvidiaSMI(ret []byte, acc cua.Accumulator) error {
+func gatherNvidiaSMI(ret []byte, acc cua.Accumulator, processes bool) error {
 	smi := &SMI{}
 	err := xml.Unmarshal(ret, smi)
 	if err != nil {
@@ -93,6 +101,12 @@ func gatherNvidiaSMI(ret []byte, acc cua.Accumulator) error {
 		acc.AddFields(measurement, metric.fields, metric.tags)
 	}
 
+	if processes {
+		for _, metric := range smi.genProcessTagsFields() {
+			acc.AddFields(processMeasurement, metric.fields, metric.tags)
+		}
+	}
+
 	return nil
 }
 
@@ -144,6 +158,31 @@ func (s *SMI) genTagsFields() []metric {
 	return metrics
 }
 
+// genProcessTagsFields answers one metric per process running on a GPU,
+// tagged with the index and uuid of the GPU it runs on.
+func (s *SMI) genProcessTagsFields() []metric {
+	metrics := []metric{}
+	for i, gpu := range s.GPU {
+		for _, proc := range gpu.Processes {
+			tags := map[string]string{
+				"index": strconv.Itoa(i),
+			}
+			setTagIfUsed(tags, "uuid", gpu.UUID)
+			setTagIfUsed(tags, "pid", proc.PID)
+			setTagIfUsed(tags, "process_name", proc.Name)
+			setTagIfUsed(tags, "type", proc.Type)
+
+			fields := map[string]interface{}{}
+			setIfUsed("int", fields, "memory_used", proc.UsedMemory)
+			if len(fields) == 0 {
+				continue
+			}
+			metrics = append(metrics, metric{tags, fields})
+		}
+	}
+	return metrics
+}
+
 func setTagIfUsed(m map[string]string, k, v string) {
 	if v != "" {
 		m[k] = v
@@ -187,7 +226,7 @@ func setIfUsed(t string, m map[string]interface{}, k, v string) {
 type SMI struct {
 	GPU           GPU    `xml:"gpu"`
 	DriverVersion string `xml:"driver_version"`
-	CUDAVersion   string `xml:"cuda_versoion"`
+	CUDAVersion   string `xml:"cuda_version"`
 }
 
 // GPU defines the structure of the GPU portion of the smi output.
@@ -205,6 +244,7 @@ type GPU []struct {
 	Encoder     EncoderStats     `xml:"encoder_stats"`
 	FBC         FBCStats         `xml:"fbc_stats"`
 	Clocks      ClockStats       `xml:"clocks"`
+	Processes   []ProcessInfo    `xml:"processes>process_info"`
 }
 
 // MemoryStats defines the structure of the memory portions in the smi output.
@@ -265,3 +305,12 @@ type ClockStats struct {
 	Memory   string `xml:"mem_clock"`      // int
 	Video    string `xml:"video_clock"`    // int
 }
+
+// ProcessInfo defines the structure of a process_info entry in the processes
+// portion of the smi output.
+type ProcessInfo struct {
+	PID        string `xml:"pid"`
+	Type       string `xml:"type"` // C (compute), G (graphics) or C+G
+	Name       string `xml:"process_name"`
+	UsedMemory string `xml:"used_memory"` // int
+}
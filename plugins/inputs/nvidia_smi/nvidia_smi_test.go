@@ -143,10 +143,71 @@ func TestGatherValidXML(t *testing.T) {
 			octets, err := os.ReadFile(filepath.Join("testdata", tt.filename))
 			require.NoError(t, err)
 
-			err = gatherNvidiaSMI(octets, &acc)
+			err = gatherNvidiaSMI(octets, &acc, false)
 			require.NoError(t, err)
 
 			testutil.RequireMetricsEqual(t, tt.expected, acc.GetCUAMetrics(), testutil.IgnoreTime())
 		})
 	}
 }
+
+func TestGatherProcesses(t *testing.T) {
+	octets, err := os.ReadFile(filepath.Join("testdata", "a100-processes.xml"))
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, gatherNvidiaSMI(octets, &acc, true))
+
+	expected := []cua.Metric{
+		testutil.MustMetric(
+			"nvidia_smi",
+			map[string]string{
+				"compute_mode": "Default",
+				"index":        "0",
+				"name":         "NVIDIA A100-SXM4-40GB",
+				"pstate":       "P0",
+				"uuid":         "GPU-6ee2a9e1-1c8b-1b9c-0e3c-3f6f0b2c5d0a",
+			},
+			map[string]interface{}{
+				"cuda_version":        "11.4",
+				"driver_version":      "470.57.02",
+				"memory_free":         8782,
+				"memory_total":        40536,
+				"memory_used":         31754,
+				"power_draw":          312.45,
+				"temperature_gpu":     61,
+				"utilization_gpu":     97,
+				"utilization_memory":  61,
+				"utilization_encoder": 0,
+				"utilization_decoder": 0,
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric(
+			"nvidia_smi_process",
+			map[string]string{
+				"index":        "0",
+				"uuid":         "GPU-6ee2a9e1-1c8b-1b9c-0e3c-3f6f0b2c5d0a",
+				"pid":          "48211",
+				"process_name": "/usr/bin/python3",
+				"type":         "C",
+			},
+			map[string]interface{}{
+				"memory_used": 30720,
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric(
+			"nvidia_smi_process",
+			map[string]string{
+				"index":        "0",
+				"uuid":         "GPU-6ee2a9e1-1c8b-1b9c-0e3c-3f6f0b2c5d0a",
+				"pid":          "48390",
+				"process_name": "/usr/bin/python3",
+				"type":         "C",
+			},
+			map[string]interface{}{
+				"memory_used": 1031,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics(), testutil.IgnoreTime())
+}
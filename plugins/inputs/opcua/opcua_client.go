@@ -3,9 +3,9 @@ package opcuaclient
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
@@ -35,6 +35,8 @@ type OpcUA struct {
 	RequestTimeout config.Duration `toml:"request_timeout"`
 	NodeList       []OPCTag        `toml:"nodes"`
 
+	SubscriptionInterval config.Duration `toml:"subscription_interval"`
+
 	Nodes       []string     `toml:"-"`
 	NodeData    []OPCData    `toml:"-"`
 	NodeIDs     []*ua.NodeID `toml:"-"`
@@ -50,6 +52,12 @@ type OpcUA struct {
 	client *opcua.Client
 	req    *ua.ReadRequest
 	opts   []opcua.Option
+
+	// subscription mode, mu guards NodeData and subErr which are updated
+	// by the notification receiver
+	mu        sync.Mutex
+	cancelSub context.CancelFunc
+	subErr    error
 }
 
 // OPCTag type
@@ -86,7 +94,6 @@ const (
 
 const description = `Retrieve data from OPCUA devices`
 const sampleConfig = `
-[[inputs.opcua]]
   instance_id = "" # unique instance identifier (REQUIRED)
 
   ## Device name
@@ -101,6 +108,11 @@ const sampleConfig = `
   ## Maximum time allowed for a request over the estabilished connection.
   # request_timeout = "5s"
   #
+  ## When set, the nodes are monitored through a subscription with this
+  ## publishing interval instead of being read on every gather. Each gather
+  ## then reports the most recent value received for every node.
+  # subscription_interval = "1s"
+  #
   ## Security policy, one of "None", "Basic128Rsa15", "Basic256",
   ## "Basic256Sha256", or "auto"
   # security_policy = "auto"
@@ -166,10 +178,7 @@ func (o *OpcUA) Init() error {
 	}
 	o.NumberOfTags = len(o.NodeList)
 
-	o.setupOptions()
-
 	return nil
-
 }
 
 func (o *OpcUA) validateEndpoint() error {
@@ -279,6 +288,13 @@ func Connect(o *OpcUA) error {
 			_ = o.client.CloseSession()
 		}
 
+		// the server's endpoints are queried on every connect so that a
+		// server which is unreachable at startup or changed its security
+		// configuration is picked up on the next gather
+		if err := o.setupOptions(); err != nil {
+			return err
+		}
+
 		o.client = opcua.NewClient(o.Endpoint, o.opts...)
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.ConnectTimeout))
 		defer cancel()
@@ -286,6 +302,10 @@ func Connect(o *OpcUA) error {
 			return fmt.Errorf("Error in Client Connection: %w", err)
 		}
 
+		if o.SubscriptionInterval > 0 {
+			return o.subscribe()
+		}
+
 		regResp, err := o.client.RegisterNodes(&ua.RegisterNodesRequest{
 			NodesToRegister: o.NodeIDs,
 		})
@@ -310,21 +330,24 @@ func Connect(o *OpcUA) error {
 	return nil
 }
 
-func (o *OpcUA) setupOptions() {
-
+func (o *OpcUA) setupOptions() error {
 	// Get a list of the endpoints for our target server
 	endpoints, err := opcua.GetEndpoints(o.Endpoint)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("GetEndpoints (%s): %w", o.Endpoint, err)
 	}
 
 	if o.Certificate == "" && o.PrivateKey == "" {
 		if o.SecurityPolicy != none || o.SecurityMode != none {
-			o.Certificate, o.PrivateKey = generateCert("urn:circonus:gopcua:client", 2048, o.Certificate, o.PrivateKey, (365 * 24 * time.Hour))
+			o.Certificate, o.PrivateKey, err = generateCert("urn:circonus:gopcua:client", 2048, o.Certificate, o.PrivateKey, (365 * 24 * time.Hour))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	o.opts = generateClientOpts(endpoints, o.Certificate, o.PrivateKey, o.SecurityPolicy, o.SecurityMode, o.AuthMethod, o.Username, o.Password, time.Duration(o.RequestTimeout))
+	o.opts, err = generateClientOpts(endpoints, o.Certificate, o.PrivateKey, o.SecurityPolicy, o.SecurityMode, o.AuthMethod, o.Username, o.Password, time.Duration(o.RequestTimeout))
+	return err
 }
 
 func (o *OpcUA) getData() error {
@@ -338,18 +361,87 @@ func (o *OpcUA) getData() error {
 		if d.Status != ua.StatusOK {
 			return fmt.Errorf("Status not OK: %v", d.Status) //nolint:errorlint
 		}
-		o.NodeData[i].TagName = o.NodeList[i].Name
-		if d.Value != nil {
-			o.NodeData[i].Value = d.Value.Value()
-			o.NodeData[i].DataType = d.Value.Type()
+		o.setNodeData(i, d)
+	}
+	return nil
+}
+
+func (o *OpcUA) setNodeData(i int, d *ua.DataValue) {
+	if i < 0 || i >= len(o.NodeData) || d == nil {
+		return
+	}
+	o.NodeData[i].TagName = o.NodeList[i].Name
+	if d.Value != nil {
+		o.NodeData[i].Value = d.Value.Value()
+		o.NodeData[i].DataType = d.Value.Type()
+	}
+	o.NodeData[i].Quality = d.Status
+	o.NodeData[i].TimeStamp = d.ServerTimestamp.String()
+	o.NodeData[i].Time = d.SourceTimestamp.String()
+}
+
+// subscribe creates a subscription monitoring the value of every node, the
+// node's index is used as the client handle of its monitored item
+func (o *OpcUA) subscribe() error {
+	notifyCh := make(chan *opcua.PublishNotificationData, 16)
+	sub, err := o.client.Subscribe(&opcua.SubscriptionParameters{
+		Interval: time.Duration(o.SubscriptionInterval),
+	}, notifyCh)
+	if err != nil {
+		return fmt.Errorf("Subscribe failed: %w", err)
+	}
+
+	items := make([]*ua.MonitoredItemCreateRequest, len(o.NodeIDs))
+	for i, id := range o.NodeIDs {
+		items[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i))
+	}
+	resp, err := sub.Monitor(ua.TimestampsToReturnBoth, items...)
+	if err != nil {
+		_ = sub.Cancel()
+		return fmt.Errorf("Monitor failed: %w", err)
+	}
+	for i, res := range resp.Results {
+		if res.StatusCode != ua.StatusOK {
+			_ = sub.Cancel()
+			return fmt.Errorf("Monitor %s failed: %v", o.Nodes[i], res.StatusCode) //nolint:errorlint
 		}
-		o.NodeData[i].Quality = d.Status
-		o.NodeData[i].TimeStamp = d.ServerTimestamp.String()
-		o.NodeData[i].Time = d.SourceTimestamp.String()
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancelSub = cancel
+	go sub.Run(ctx)
+	go o.receive(ctx, notifyCh)
 	return nil
 }
 
+// receive applies data change notifications until the subscription is
+// cancelled. A publish error ends the subscription's run loop, it is kept so
+// the next gather reconnects.
+func (o *OpcUA) receive(ctx context.Context, notifyCh <-chan *opcua.PublishNotificationData) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-notifyCh:
+			if msg.Error != nil {
+				o.mu.Lock()
+				o.subErr = msg.Error
+				o.mu.Unlock()
+				continue
+			}
+			dcn, ok := msg.Value.(*ua.DataChangeNotification)
+			if !ok {
+				continue
+			}
+			o.mu.Lock()
+			for _, item := range dcn.MonitoredItems {
+				o.setNodeData(int(item.ClientHandle), item.Value)
+			}
+			o.mu.Unlock()
+		}
+	}
+}
+
 func readvalues(ids []*ua.NodeID) []*ua.ReadValueID {
 	rvids := make([]*ua.ReadValueID, len(ids))
 	for i, v := range ids {
@@ -367,10 +459,26 @@ func disconnect(o *OpcUA) error {
 	o.ReadError = 0
 	o.ReadSuccess = 0
 
+	if o.cancelSub != nil {
+		o.cancelSub()
+		o.cancelSub = nil
+	}
+	o.mu.Lock()
+	o.subErr = nil
+	// drop the last values so nothing stale is reported until the node
+	// has been read again
+	for i := range o.NodeData {
+		o.NodeData[i] = OPCData{}
+	}
+	o.mu.Unlock()
+
 	switch u.Scheme {
 	case "opc.tcp":
 		o.state = Disconnected
-		o.client.Close()
+		if o.client != nil {
+			o.client.Close()
+			o.client = nil
+		}
 		return nil
 	default:
 		return fmt.Errorf("invalid controller")
@@ -383,21 +491,39 @@ func (o *OpcUA) Gather(ctx context.Context, acc cua.Accumulator) error {
 		o.state = Connecting
 		err := Connect(o)
 		if err != nil {
-			o.state = Disconnected
+			// close whatever part of the connection was established, it
+			// is retried on the next gather
+			_ = disconnect(o)
 			return err
 		}
 	}
 
 	o.state = Connected
 
-	err := o.getData()
-	if err != nil && o.state == Connected {
-		o.state = Disconnected
-		_ = disconnect(o)
-		return err
+	if o.SubscriptionInterval > 0 {
+		o.mu.Lock()
+		err := o.subErr
+		o.mu.Unlock()
+		if err != nil {
+			_ = disconnect(o)
+			return fmt.Errorf("subscription failed: %w", err)
+		}
+	} else {
+		err := o.getData()
+		if err != nil && o.state == Connected {
+			o.state = Disconnected
+			_ = disconnect(o)
+			return err
+		}
 	}
 
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	for i, n := range o.NodeList {
+		if o.NodeData[i].TagName == "" {
+			// no notification received yet
+			continue
+		}
 		fields := make(map[string]interface{})
 		tags := map[string]string{
 			"name": n.Name,
@@ -1,12 +1,16 @@
 package opcuaclient
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
 	"github.com/stretchr/testify/require"
 )
 
@@ -78,10 +82,12 @@ func MapOPCTag(tags OPCTags) (out OPCTag) {
 func TestConfig(t *testing.T) {
 	toml := `
 [[inputs.opcua]]
+instance_id = "opcua_test"
 name = "localhost"
 endpoint = "opc.tcp://localhost:4840"
 connect_timeout = "10s"
 request_timeout = "5s"
+subscription_interval = "500ms"
 security_policy = "auto"
 security_mode = "auto"
 certificate = "/etc/circonus-unified-agent/cert.pem"
@@ -107,4 +113,67 @@ nodes = [
 	require.Len(t, o.NodeList, 2)
 	require.Equal(t, o.NodeList[0].Name, "name")
 	require.Equal(t, o.NodeList[1].Name, "name2")
+	require.Equal(t, config.Duration(500*time.Millisecond), o.SubscriptionInterval)
+}
+
+func TestGatherUnreachableEndpoint(t *testing.T) {
+	o := OpcUA{
+		Name:           "unreachable",
+		Endpoint:       "opc.tcp://127.0.0.1:1",
+		SecurityPolicy: "None",
+		SecurityMode:   "None",
+		AuthMethod:     "Anonymous",
+		ConnectTimeout: config.Duration(time.Second),
+		RequestTimeout: config.Duration(time.Second),
+		NodeList: []OPCTag{
+			{Name: "ProductName", Namespace: "0", IdentifierType: "i", Identifier: "2261", DataType: "string"},
+		},
+	}
+	require.NoError(t, o.Init())
+
+	// a server that is down must not stop the agent, the connection is
+	// retried on every gather
+	var acc testutil.Accumulator
+	require.Error(t, o.Gather(context.Background(), &acc))
+	require.Equal(t, Disconnected, o.state)
+	require.Error(t, o.Gather(context.Background(), &acc))
+	require.Empty(t, acc.GetCUAMetrics())
+}
+
+func TestSubscriptionReceive(t *testing.T) {
+	o := OpcUA{
+		Name:           "subscribed",
+		Endpoint:       "opc.tcp://localhost:4840",
+		SecurityPolicy: "None",
+		SecurityMode:   "None",
+		NodeList: []OPCTag{
+			{Name: "temp", Namespace: "3", IdentifierType: "s", Identifier: "Temperature", DataType: "float"},
+			{Name: "pressure", Namespace: "3", IdentifierType: "s", Identifier: "Pressure", DataType: "float"},
+		},
+	}
+	require.NoError(t, o.Init())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifyCh := make(chan *opcua.PublishNotificationData)
+	done := make(chan struct{})
+	go func() {
+		o.receive(ctx, notifyCh)
+		close(done)
+	}()
+
+	notifyCh <- &opcua.PublishNotificationData{
+		Value: &ua.DataChangeNotification{
+			MonitoredItems: []*ua.MonitoredItemNotification{
+				{ClientHandle: 1, Value: &ua.DataValue{Value: ua.MustVariant(float64(1.5)), Status: ua.StatusOK}},
+			},
+		},
+	}
+	notifyCh <- &opcua.PublishNotificationData{Error: ua.StatusBadConnectionClosed}
+	cancel()
+	<-done
+
+	require.Equal(t, "", o.NodeData[0].TagName)
+	require.Equal(t, "pressure", o.NodeData[1].TagName)
+	require.Equal(t, 1.5, o.NodeData[1].Value)
+	require.Error(t, o.subErr)
 }
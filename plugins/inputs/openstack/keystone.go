@@ -0,0 +1,160 @@
+package openstack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// tokenRefreshMargin renews a token shortly before keystone expires it so a
+// gather does not start with a token that lapses midway.
+const tokenRefreshMargin = time.Minute
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods               []string               `json:"methods"`
+			Password              *passwordAuth          `json:"password,omitempty"`
+			ApplicationCredential *applicationCredential `json:"application_credential,omitempty"`
+		} `json:"identity"`
+		Scope *projectScope `json:"scope,omitempty"`
+	} `json:"auth"`
+}
+
+type domain struct {
+	Name string `json:"name"`
+}
+
+type passwordAuth struct {
+	User struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Domain   domain `json:"domain"`
+	} `json:"user"`
+}
+
+type applicationCredential struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type projectScope struct {
+	Project struct {
+		Name   string `json:"name"`
+		Domain domain `json:"domain"`
+	} `json:"project"`
+}
+
+type tokenResponse struct {
+	Token struct {
+		ExpiresAt time.Time      `json:"expires_at"`
+		Catalog   []catalogEntry `json:"catalog"`
+	} `json:"token"`
+}
+
+type catalogEntry struct {
+	Type      string `json:"type"`
+	Endpoints []struct {
+		Interface string `json:"interface"`
+		Region    string `json:"region"`
+		RegionID  string `json:"region_id"`
+		URL       string `json:"url"`
+	} `json:"endpoints"`
+}
+
+// session is an authenticated keystone token together with the service
+// catalog issued with it.
+type session struct {
+	token     string
+	expiresAt time.Time
+	catalog   []catalogEntry
+}
+
+func (s *session) valid(now time.Time) bool {
+	return s != nil && now.Add(tokenRefreshMargin).Before(s.expiresAt)
+}
+
+// endpoint looks up the URL of a service type in the catalog for the
+// configured region and interface.
+func (s *session) endpoint(serviceType, region, iface string) (string, error) {
+	for _, entry := range s.catalog {
+		if entry.Type != serviceType {
+			continue
+		}
+		for _, ep := range entry.Endpoints {
+			if ep.Interface != iface {
+				continue
+			}
+			if region != "" && ep.Region != region && ep.RegionID != region {
+				continue
+			}
+			return strings.TrimSuffix(ep.URL, "/"), nil
+		}
+	}
+	return "", fmt.Errorf("no %s endpoint for service type %s in region %q", iface, serviceType, region)
+}
+
+// authenticate requests a project scoped token from keystone with either
+// password or application credential authentication.
+func (o *OpenStack) authenticate(ctx context.Context) (*session, error) {
+	var ar authRequest
+	if o.ApplicationCredentialID != "" {
+		ar.Auth.Identity.Methods = []string{"application_credential"}
+		ar.Auth.Identity.ApplicationCredential = &applicationCredential{
+			ID:     o.ApplicationCredentialID,
+			Secret: o.ApplicationCredentialSecret,
+		}
+	} else {
+		pw := &passwordAuth{}
+		pw.User.Name = o.Username
+		pw.User.Password = o.Password
+		pw.User.Domain.Name = o.UserDomainName
+		ar.Auth.Identity.Methods = []string{"password"}
+		ar.Auth.Identity.Password = pw
+
+		// application credentials are bound to a project already
+		scope := &projectScope{}
+		scope.Project.Name = o.ProjectName
+		scope.Project.Domain.Name = o.ProjectDomainName
+		ar.Auth.Scope = scope
+	}
+
+	body, err := json.Marshal(ar)
+	if err != nil {
+		return nil, fmt.Errorf("encoding auth request: %w", err)
+	}
+
+	u := strings.TrimSuffix(o.IdentityEndpoint, "/") + "/auth/tokens"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return nil, fmt.Errorf("authenticating with %s: %s: %s", u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("decoding token: %w", err)
+	}
+
+	return &session{
+		token:     resp.Header.Get("X-Subject-Token"),
+		expiresAt: tr.Token.ExpiresAt,
+		catalog:   tr.Token.Catalog,
+	}, nil
+}
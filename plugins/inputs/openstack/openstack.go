@@ -0,0 +1,403 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Keystone v3 identity endpoint
+  identity_endpoint = "https://keystone.example.com:5000/v3"
+
+  ## Password authentication, scoped to a project. Hypervisor and pool
+  ## statistics are admin only, so the user needs the admin role.
+  # username = "admin"
+  # password = ""
+  # user_domain_name = "Default"
+  # project_name = "admin"
+  # project_domain_name = "Default"
+
+  ## Application credential authentication, used instead of the password
+  ## when set.
+  # application_credential_id = ""
+  # application_credential_secret = ""
+
+  ## Region and interface of the service catalog endpoints to query.
+  # region = ""
+  # interface = "public"
+
+  ## Statistics to collect:
+  ##   hypervisors    - nova hypervisor capacity and usage
+  ##   network_agents - neutron agent liveness
+  ##   volume_pools   - cinder backend pool capacity
+  # collect = ["hypervisors", "network_agents", "volume_pools"]
+
+  ## Timeout for HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const (
+	collectHypervisors   = "hypervisors"
+	collectNetworkAgents = "network_agents"
+	collectVolumePools   = "volume_pools"
+)
+
+var errUnauthorized = errors.New("token rejected")
+
+type OpenStack struct {
+	IdentityEndpoint            string            `toml:"identity_endpoint"`
+	Username                    string            `toml:"username"`
+	Password                    string            `toml:"password"`
+	UserDomainName              string            `toml:"user_domain_name"`
+	ProjectName                 string            `toml:"project_name"`
+	ProjectDomainName           string            `toml:"project_domain_name"`
+	ApplicationCredentialID     string            `toml:"application_credential_id"`
+	ApplicationCredentialSecret string            `toml:"application_credential_secret"`
+	Region                      string            `toml:"region"`
+	Interface                   string            `toml:"interface"`
+	Collect                     []string          `toml:"collect"`
+	Timeout                     internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client *http.Client
+
+	mu      sync.Mutex
+	session *session
+}
+
+type hypervisor struct {
+	HypervisorHostname string `json:"hypervisor_hostname"`
+	HypervisorType     string `json:"hypervisor_type"`
+	State              string `json:"state"`
+	Status             string `json:"status"`
+	VCPUs              int64  `json:"vcpus"`
+	VCPUsUsed          int64  `json:"vcpus_used"`
+	MemoryMB           int64  `json:"memory_mb"`
+	MemoryMBUsed       int64  `json:"memory_mb_used"`
+	LocalGB            int64  `json:"local_gb"`
+	LocalGBUsed        int64  `json:"local_gb_used"`
+	DiskAvailableLeast int64  `json:"disk_available_least"`
+	RunningVMs         int64  `json:"running_vms"`
+	CurrentWorkload    int64  `json:"current_workload"`
+}
+
+type networkAgent struct {
+	AgentType        string `json:"agent_type"`
+	Binary           string `json:"binary"`
+	Host             string `json:"host"`
+	AvailabilityZone string `json:"availability_zone"`
+	Alive            bool   `json:"alive"`
+	AdminStateUp     bool   `json:"admin_state_up"`
+}
+
+type volumePool struct {
+	Name string `json:"name"`
+	// capacities are reported as numbers, or as "infinite" and "unknown"
+	// by drivers that cannot tell
+	Capabilities map[string]interface{} `json:"capabilities"`
+}
+
+// Description answers a description of this input plugin
+func (*OpenStack) Description() string {
+	return "Read hypervisor, network agent and volume pool statistics from OpenStack APIs"
+}
+
+// SampleConfig answers a sample configuration
+func (*OpenStack) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenStack) Init() error {
+	if o.IdentityEndpoint == "" {
+		return fmt.Errorf("identity_endpoint is required")
+	}
+	if _, err := url.Parse(o.IdentityEndpoint); err != nil {
+		return fmt.Errorf("parsing identity_endpoint (%s): %w", o.IdentityEndpoint, err)
+	}
+	if o.ApplicationCredentialID == "" && o.Username == "" {
+		return fmt.Errorf("username or application_credential_id is required")
+	}
+	for _, c := range o.Collect {
+		switch c {
+		case collectHypervisors, collectNetworkAgents, collectVolumePools:
+		default:
+			return fmt.Errorf("unknown collect option %q", c)
+		}
+	}
+
+	tlsCfg, err := o.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("tls config: %w", err)
+	}
+	o.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: o.Timeout.Duration,
+	}
+	return nil
+}
+
+func (o *OpenStack) Gather(ctx context.Context, acc cua.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, c := range o.Collect {
+		var gather func(context.Context, cua.Accumulator) error
+		switch c {
+		case collectHypervisors:
+			gather = o.gatherHypervisors
+		case collectNetworkAgents:
+			gather = o.gatherNetworkAgents
+		case collectVolumePools:
+			gather = o.gatherVolumePools
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := gather(ctx, acc); err != nil {
+				acc.AddError(err)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (o *OpenStack) gatherHypervisors(ctx context.Context, acc cua.Accumulator) error {
+	var resp struct {
+		Hypervisors []hypervisor `json:"hypervisors"`
+	}
+	if err := o.get(ctx, []string{"compute"}, "/os-hypervisors/detail", &resp); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, h := range resp.Hypervisors {
+		fields := map[string]interface{}{
+			"vcpus":                h.VCPUs,
+			"vcpus_used":           h.VCPUsUsed,
+			"memory_mb":            h.MemoryMB,
+			"memory_mb_used":       h.MemoryMBUsed,
+			"local_gb":             h.LocalGB,
+			"local_gb_used":        h.LocalGBUsed,
+			"disk_available_least": h.DiskAvailableLeast,
+			"running_vms":          h.RunningVMs,
+			"current_workload":     h.CurrentWorkload,
+		}
+		if h.VCPUs > 0 {
+			fields["vcpus_utilization"] = float64(h.VCPUsUsed) / float64(h.VCPUs) * 100
+		}
+		if h.MemoryMB > 0 {
+			fields["memory_utilization"] = float64(h.MemoryMBUsed) / float64(h.MemoryMB) * 100
+		}
+		acc.AddFields("openstack_hypervisor", fields, map[string]string{
+			"hypervisor":      h.HypervisorHostname,
+			"hypervisor_type": h.HypervisorType,
+			"state":           h.State,
+			"status":          h.Status,
+		}, now)
+	}
+	return nil
+}
+
+func (o *OpenStack) gatherNetworkAgents(ctx context.Context, acc cua.Accumulator) error {
+	var resp struct {
+		Agents []networkAgent `json:"agents"`
+	}
+	if err := o.get(ctx, []string{"network"}, "/v2.0/agents", &resp); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, a := range resp.Agents {
+		tags := map[string]string{
+			"agent_type": a.AgentType,
+			"binary":     a.Binary,
+			"host":       a.Host,
+		}
+		if a.AvailabilityZone != "" {
+			tags["availability_zone"] = a.AvailabilityZone
+		}
+		acc.AddFields("openstack_network_agent", map[string]interface{}{
+			"alive":          boolToInt(a.Alive),
+			"admin_state_up": boolToInt(a.AdminStateUp),
+		}, tags, now)
+	}
+	return nil
+}
+
+func (o *OpenStack) gatherVolumePools(ctx context.Context, acc cua.Accumulator) error {
+	var resp struct {
+		Pools []volumePool `json:"pools"`
+	}
+	// the block storage service type was renamed along the API versions
+	if err := o.get(ctx, []string{"volumev3", "block-storage", "volumev2"}, "/scheduler-stats/get_pools?detail=true", &resp); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, p := range resp.Pools {
+		fields := make(map[string]interface{})
+		for _, name := range []string{
+			"total_capacity_gb",
+			"free_capacity_gb",
+			"allocated_capacity_gb",
+			"provisioned_capacity_gb",
+			"max_over_subscription_ratio",
+			"reserved_percentage",
+		} {
+			if v, ok := capacity(p.Capabilities[name]); ok {
+				fields[name] = v
+			}
+		}
+		total, okTotal := fields["total_capacity_gb"].(float64)
+		free, okFree := fields["free_capacity_gb"].(float64)
+		if okTotal && okFree && total > 0 {
+			fields["utilization"] = (total - free) / total * 100
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		tags := map[string]string{"pool": p.Name}
+		if backend, ok := p.Capabilities["volume_backend_name"].(string); ok {
+			tags["backend"] = backend
+		}
+		acc.AddFields("openstack_volume_pool", fields, tags, now)
+	}
+	return nil
+}
+
+// capacity converts a pool capability to a number; some drivers answer
+// numeric capabilities as strings
+func capacity(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case string:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// get requests a path of the first service type found in the catalog and
+// decodes the answer into v. A rejected token is renewed once.
+func (o *OpenStack) get(ctx context.Context, serviceTypes []string, path string, v interface{}) error {
+	err := o.getOnce(ctx, serviceTypes, path, v)
+	if errors.Is(err, errUnauthorized) {
+		o.mu.Lock()
+		o.session = nil
+		o.mu.Unlock()
+		err = o.getOnce(ctx, serviceTypes, path, v)
+	}
+	return err
+}
+
+func (o *OpenStack) getOnce(ctx context.Context, serviceTypes []string, path string, v interface{}) error {
+	s, err := o.currentSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	var base string
+	for _, st := range serviceTypes {
+		if base, err = s.endpoint(st, o.Region, o.Interface); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	u := base + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	req.Header.Set("X-Auth-Token", s.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return fmt.Errorf("requesting %s: %w", u, errUnauthorized)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", u, err)
+	}
+	return nil
+}
+
+// currentSession answers the cached keystone session, authenticating again
+// when it is missing or about to expire.
+func (o *OpenStack) currentSession(ctx context.Context) (*session, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.session.valid(time.Now()) {
+		return o.session, nil
+	}
+	s, err := o.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.session = s
+	return s, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	inputs.Add("openstack", func() cua.Input {
+		return &OpenStack{
+			UserDomainName:    "Default",
+			ProjectDomainName: "Default",
+			Interface:         "public",
+			Collect:           []string{collectHypervisors, collectNetworkAgents, collectVolumePools},
+			Timeout:           internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
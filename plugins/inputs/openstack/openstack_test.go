@@ -0,0 +1,150 @@
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, tokens *int) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identity/v3/auth/tokens" {
+			require.Equal(t, http.MethodPost, r.Method)
+			var ar authRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ar))
+			require.Equal(t, []string{"password"}, ar.Auth.Identity.Methods)
+			require.Equal(t, "admin", ar.Auth.Identity.Password.User.Name)
+			require.Equal(t, "admin", ar.Auth.Scope.Project.Name)
+
+			*tokens++
+			w.Header().Set("X-Subject-Token", fmt.Sprintf("token-%d", *tokens))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": {"expires_at": %q, "catalog": [
+				{"type": "compute", "endpoints": [
+					{"interface": "public", "region": "RegionOne", "url": "%[2]s/compute/v2.1"},
+					{"interface": "public", "region": "RegionTwo", "url": "%[2]s/other/v2.1"}]},
+				{"type": "network", "endpoints": [
+					{"interface": "internal", "region": "RegionOne", "url": "%[2]s/internal"},
+					{"interface": "public", "region": "RegionOne", "url": "%[2]s/network/"}]},
+				{"type": "block-storage", "endpoints": [
+					{"interface": "public", "region": "RegionOne", "url": "%[2]s/volume/v3/abc123"}]}
+			]}}`, time.Now().Add(time.Hour).Format(time.RFC3339), ts.URL)
+			return
+		}
+
+		// the first token is revoked to exercise reauthentication
+		if r.Header.Get("X-Auth-Token") != "token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/compute/v2.1/os-hypervisors/detail":
+			http.ServeFile(w, r, "testdata/hypervisors.json")
+		case "/network/v2.0/agents":
+			http.ServeFile(w, r, "testdata/agents.json")
+		case "/volume/v3/abc123/scheduler-stats/get_pools":
+			require.Equal(t, "true", r.URL.Query().Get("detail"))
+			http.ServeFile(w, r, "testdata/pools.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func TestGather(t *testing.T) {
+	var tokens int
+	ts := newTestServer(t, &tokens)
+	defer ts.Close()
+
+	o := &OpenStack{
+		IdentityEndpoint:  ts.URL + "/identity/v3",
+		Username:          "admin",
+		Password:          "secret",
+		UserDomainName:    "Default",
+		ProjectName:       "admin",
+		ProjectDomainName: "Default",
+		Region:            "RegionOne",
+		Interface:         "public",
+		// gathered one after another so the revoked token is renewed once
+		Collect: []string{collectHypervisors},
+	}
+	require.NoError(t, o.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, 2, tokens)
+
+	acc.AssertContainsTaggedFields(t, "openstack_hypervisor",
+		map[string]interface{}{
+			"vcpus":                int64(64),
+			"vcpus_used":           int64(48),
+			"memory_mb":            int64(262144),
+			"memory_mb_used":       int64(131072),
+			"local_gb":             int64(1800),
+			"local_gb_used":        int64(400),
+			"disk_available_least": int64(1350),
+			"running_vms":          int64(12),
+			"current_workload":     int64(0),
+			"vcpus_utilization":    float64(75),
+			"memory_utilization":   float64(50),
+		},
+		map[string]string{
+			"hypervisor":      "compute-01",
+			"hypervisor_type": "QEMU",
+			"state":           "up",
+			"status":          "enabled",
+		})
+
+	o.Collect = []string{collectNetworkAgents, collectVolumePools}
+	acc.ClearMetrics()
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, 2, tokens, "cached token is reused")
+
+	acc.AssertContainsTaggedFields(t, "openstack_network_agent",
+		map[string]interface{}{"alive": 1, "admin_state_up": 1},
+		map[string]string{
+			"agent_type": "Open vSwitch agent",
+			"binary":     "neutron-openvswitch-agent",
+			"host":       "compute-01",
+		})
+	acc.AssertContainsTaggedFields(t, "openstack_network_agent",
+		map[string]interface{}{"alive": 0, "admin_state_up": 1},
+		map[string]string{
+			"agent_type":        "L3 agent",
+			"binary":            "neutron-l3-agent",
+			"host":              "network-01",
+			"availability_zone": "nova",
+		})
+
+	acc.AssertContainsTaggedFields(t, "openstack_volume_pool",
+		map[string]interface{}{
+			"total_capacity_gb":           float64(1000),
+			"free_capacity_gb":            float64(250),
+			"allocated_capacity_gb":       float64(700),
+			"provisioned_capacity_gb":     float64(900),
+			"max_over_subscription_ratio": float64(20),
+			"reserved_percentage":         float64(0),
+			"utilization":                 float64(75),
+		},
+		map[string]string{"pool": "storage-01@ceph#ceph", "backend": "ceph"})
+	acc.AssertContainsTaggedFields(t, "openstack_volume_pool",
+		map[string]interface{}{"allocated_capacity_gb": float64(12)},
+		map[string]string{"pool": "storage-02@nfs#nfs", "backend": "nfs"})
+}
+
+func TestEndpointNotInCatalog(t *testing.T) {
+	s := &session{catalog: []catalogEntry{{Type: "compute"}}}
+	_, err := s.endpoint("network", "RegionOne", "public")
+	require.Error(t, err)
+}
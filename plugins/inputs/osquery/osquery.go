@@ -0,0 +1,169 @@
+package osquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Path of the osqueryi shell, e.g. /usr/local/bin/osqueryi on macOS
+  # binary = "osqueryi"
+
+  ## Timeout for each query to complete
+  # timeout = "10s"
+
+  ## Queries to run, each row of the result is added as a metric
+  [[inputs.osquery.query]]
+    ## Measurement of the rows
+    measurement = "osquery_uptime"
+    ## SQL query, see https://osquery.io/schema
+    query = "SELECT total_seconds FROM uptime"
+    ## Columns added as tags, all other columns are added as fields; numeric
+    ## values are added as numbers
+    # tag_columns = []
+
+  # [[inputs.osquery.query]]
+  #   measurement = "osquery_listening_ports"
+  #   query = "SELECT p.name, l.port, l.protocol FROM listening_ports l JOIN processes p USING (pid) WHERE l.port != 0"
+  #   tag_columns = ["name", "protocol"]
+`
+
+type Query struct {
+	Measurement string   `toml:"measurement"`
+	Query       string   `toml:"query"`
+	TagColumns  []string `toml:"tag_columns"`
+}
+
+type Osquery struct {
+	Binary  string          `toml:"binary"`
+	Timeout config.Duration `toml:"timeout"`
+	Queries []Query         `toml:"query"`
+
+	Log cua.Logger `toml:"-"`
+
+	run func(binary string, args []string, timeout time.Duration) ([]byte, error)
+}
+
+// Description answers a description of this input plugin
+func (*Osquery) Description() string {
+	return "Run osquery SQL queries and add the resulting rows as metrics"
+}
+
+// SampleConfig answers a sample configuration
+func (*Osquery) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *Osquery) Init() error {
+	if len(o.Queries) == 0 {
+		return fmt.Errorf("no queries configured")
+	}
+	for i, q := range o.Queries {
+		if q.Query == "" {
+			return fmt.Errorf("query %d: no query", i)
+		}
+		if q.Measurement == "" {
+			return fmt.Errorf("query %d: no measurement", i)
+		}
+	}
+	if o.run == nil {
+		o.run = runOsqueryi
+	}
+	return nil
+}
+
+func (o *Osquery) Gather(_ context.Context, acc cua.Accumulator) error {
+	for _, q := range o.Queries {
+		if err := o.gatherQuery(acc, q); err != nil {
+			acc.AddError(fmt.Errorf("%s: %w", q.Measurement, err))
+		}
+	}
+	return nil
+}
+
+func (o *Osquery) gatherQuery(acc cua.Accumulator, q Query) error {
+	out, err := o.run(o.Binary, []string{"--json", q.Query}, time.Duration(o.Timeout))
+	if err != nil {
+		return err
+	}
+	// osqueryi answers all values as strings
+	var rows []map[string]string
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return fmt.Errorf("parsing result: %w", err)
+	}
+
+	tagColumns := make(map[string]bool, len(q.TagColumns))
+	for _, c := range q.TagColumns {
+		tagColumns[c] = true
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+		for column, value := range row {
+			if tagColumns[column] {
+				if value != "" {
+					tags[column] = value
+				}
+				continue
+			}
+			fields[column] = fieldValue(value)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		acc.AddFields(q.Measurement, fields, tags, now)
+	}
+	return nil
+}
+
+// fieldValue answers the value as an integer or float when numeric
+func fieldValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+func runOsqueryi(binary string, args []string, timeout time.Duration) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %w", binary, err)
+	}
+	if err := internal.WaitTimeout(cmd, timeout); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", binary, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", binary, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func init() {
+	inputs.Add("osquery", func() cua.Input {
+		return &Osquery{
+			Binary:  "osqueryi",
+			Timeout: config.Duration(10 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package osquery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&Osquery{}).Init())
+	require.Error(t, (&Osquery{Queries: []Query{{Measurement: "m"}}}).Init())
+	require.Error(t, (&Osquery{Queries: []Query{{Query: "SELECT 1"}}}).Init())
+}
+
+func TestGather(t *testing.T) {
+	results := map[string]string{
+		"SELECT total_seconds FROM uptime": `[{"total_seconds":"86400"}]`,
+		"SELECT name, port, load FROM listening": `[
+			{"name":"sshd","port":"22","load":"0.5"},
+			{"name":"launchd","port":"","load":"idle"}
+		]`,
+	}
+	o := &Osquery{
+		Binary: "osqueryi",
+		Queries: []Query{
+			{Measurement: "osquery_uptime", Query: "SELECT total_seconds FROM uptime"},
+			{Measurement: "osquery_listening", Query: "SELECT name, port, load FROM listening", TagColumns: []string{"name", "port"}},
+			{Measurement: "osquery_bad", Query: "SELECT * FROM nope"},
+		},
+		run: func(binary string, args []string, _ time.Duration) ([]byte, error) {
+			require.Equal(t, "osqueryi", binary)
+			require.Equal(t, "--json", args[0])
+			if out, ok := results[args[1]]; ok {
+				return []byte(out), nil
+			}
+			return nil, fmt.Errorf("no such table")
+		},
+	}
+	require.NoError(t, o.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, o.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.True(t, acc.HasPoint("osquery_uptime", map[string]string{}, "total_seconds", int64(86400)))
+	require.True(t, acc.HasPoint("osquery_listening", map[string]string{"name": "sshd", "port": "22"}, "load", 0.5))
+	require.True(t, acc.HasPoint("osquery_listening", map[string]string{"name": "launchd"}, "load", "idle"))
+}
@@ -33,6 +33,13 @@ const (
 	pfMaxActiveProcesses = "max active processes"
 	pfMaxChildrenReached = "max children reached"
 	pfSlowRequests       = "slow requests"
+
+	// per process entries of the full status (?full)
+	pfPid             = "pid"
+	pfState           = "state"
+	pfRequestDuration = "request duration"
+
+	pfMaxRequestDuration = "max request duration"
 )
 
 type metric map[string]int64
@@ -177,9 +184,16 @@ func (p *phpfpm) gatherServer(addr string, acc cua.Accumulator) error {
 
 // Gather stat using fcgi protocol
 func (p *phpfpm) gatherFcgi(fcgi *conn, statusPath string, acc cua.Accumulator, addr string) error {
+	// "status?full" requests the per process status
+	var query string
+	if i := strings.IndexByte(statusPath, '?'); i >= 0 {
+		statusPath, query = statusPath[:i], statusPath[i+1:]
+	}
+
 	fpmOutput, fpmErr, err := fcgi.Request(map[string]string{
 		"SCRIPT_NAME":     "/" + statusPath,
 		"SCRIPT_FILENAME": statusPath,
+		"QUERY_STRING":    query,
 		"REQUEST_METHOD":  "GET",
 		"CONTENT_LENGTH":  "0",
 		"SERVER_PROTOCOL": "HTTP/1.0",
@@ -213,7 +227,7 @@ func (p *phpfpm) gatherHTTP(addr string, acc cua.Accumulator) error {
 	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
-		return fmt.Errorf("unable to get valid stat result from '%s': %w", addr, err)
+		return fmt.Errorf("unable to get valid stat result from '%s': %s", addr, res.Status)
 	}
 
 	importMetric(res.Body, acc, addr)
@@ -223,12 +237,13 @@ func (p *phpfpm) gatherHTTP(addr string, acc cua.Accumulator) error {
 // Import stat data into system
 func importMetric(r io.Reader, acc cua.Accumulator, addr string) {
 	stats := make(poolStat)
-	var currentPool string
+	var currentPool, procState string
+	var inProcess bool
 
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		statLine := scanner.Text()
-		keyvalue := strings.Split(statLine, ":")
+		keyvalue := strings.SplitN(statLine, ":", 2)
 
 		if len(keyvalue) < 2 {
 			continue
@@ -238,6 +253,39 @@ func importMetric(r io.Reader, acc cua.Accumulator, addr string) {
 		if fieldName == pfPool {
 			currentPool = strings.Trim(keyvalue[1], " ")
 			stats[currentPool] = make(metric)
+			inProcess = false
+			continue
+		}
+		if stats[currentPool] == nil {
+			continue
+		}
+
+		// The full status lists each process after the pool; their
+		// entries (e.g. "start since") must not overwrite the pool's
+		if fieldName == pfPid {
+			inProcess = true
+			procState = ""
+			if _, ok := stats[currentPool][pfMaxRequestDuration]; !ok {
+				stats[currentPool][pfMaxRequestDuration] = 0
+			}
+			continue
+		}
+		if inProcess {
+			value := strings.Trim(keyvalue[1], " ")
+			switch fieldName {
+			case pfState:
+				procState = strings.ToLower(strings.ReplaceAll(value, " ", "_"))
+				stats[currentPool]["state "+procState]++
+			case pfRequestDuration:
+				// idle processes report the duration of their last request
+				if procState == "idle" {
+					continue
+				}
+				d, err := strconv.ParseInt(value, 10, 64)
+				if err == nil && d > stats[currentPool][pfMaxRequestDuration] {
+					stats[currentPool][pfMaxRequestDuration] = d
+				}
+			}
 			continue
 		}
 
@@ -331,6 +331,94 @@ func TestPhpFpmGeneratesMetrics_Throw_Error_When_Socket_Path_Is_Invalid(t *testi
 
 }
 
+func TestPhpFpmGeneratesMetrics_From_Full_Status(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, outputSample+fullOutputSample)
+	}))
+	defer ts.Close()
+
+	url := ts.URL + "/status?full"
+	r := &phpfpm{
+		Urls: []string{url},
+	}
+	require.NoError(t, r.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	tags := map[string]string{
+		"pool": "www",
+		"url":  url,
+	}
+	fields := map[string]interface{}{
+		"start_since":           int64(1991),
+		"accepted_conn":         int64(3),
+		"listen_queue":          int64(1),
+		"max_listen_queue":      int64(0),
+		"listen_queue_len":      int64(0),
+		"idle_processes":        int64(1),
+		"active_processes":      int64(1),
+		"total_processes":       int64(2),
+		"max_active_processes":  int64(1),
+		"max_children_reached":  int64(2),
+		"slow_requests":         int64(1),
+		"state_idle":            int64(1),
+		"state_reading_headers": int64(1),
+		"max_request_duration":  int64(5210),
+	}
+	acc.AssertContainsTaggedFields(t, "phpfpm", fields, tags)
+}
+
+func TestPhpFpmGeneratesMetrics_Throw_Error_When_Status_Not_OK(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	r := &phpfpm{
+		Urls: []string{ts.URL},
+	}
+	require.NoError(t, r.Init())
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(r.Gather)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
+
+const fullOutputSample = `
+************************
+pid:                  31
+state:                Idle
+start time:           11/Oct/2015:23:38:51 +0000
+start since:          120
+requests:             2
+request duration:     98210
+request method:       GET
+request URI:          /index.php
+content length:       0
+user:                 -
+script:               /var/www/html/index.php
+last request cpu:     0.00
+last request memory:  2097152
+
+************************
+pid:                  32
+state:                Reading headers
+start time:           11/Oct/2015:23:38:51 +0000
+start since:          80
+requests:             1
+request duration:     5210
+request method:       -
+request URI:          -
+content length:       0
+user:                 -
+script:               -
+last request cpu:     0.00
+last request memory:  0
+`
+
 const outputSample = `
 pool:                 www
 process manager:      dynamic
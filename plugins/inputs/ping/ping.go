@@ -55,7 +55,10 @@ type Ping struct {
 	Timeout           float64           // Per-ping timeout, in seconds. 0 means no timeout (ping -W <TIMEOUT>)
 	calcInterval      time.Duration     // Pre-calculated interval
 	DirectMetrics     bool              `toml:"direct_metrics"` // enable direct metrics
+	RTTHistogram      bool              `toml:"rtt_histogram"`  // add the round trip times as histogram samples
 	IPv6              bool              // Whether to resolve addresses using ipv6 or not.
+
+	circmgr.MetricNaming // direct metrics: name_override, name_prefix, name_suffix
 }
 
 func (*Ping) Description() string {
@@ -111,12 +114,26 @@ const sampleConfig = `
   ## Number of data bytes to be sent. Corresponds to the "-s"
   ## option of the ping command. This only works with the native method.
   # size = 56
+
+  ## Add the round trip time of each packet as a sample of the rtt histogram,
+  ## in milliseconds, when sending more than one packet.  Direct metrics
+  ## always include the histogram.
+  # rtt_histogram = false
 `
 
 func (*Ping) SampleConfig() string {
 	return sampleConfig
 }
 
+// Capabilities answers CAP_NET_RAW for the raw ICMP sockets of the native
+// method in privileged mode
+func (p *Ping) Capabilities() []string {
+	if p.Method != "native" || (p.Privileged != nil && !*p.Privileged) {
+		return nil
+	}
+	return []string{"CAP_NET_RAW"}
+}
+
 func (p *Ping) Gather(ctx context.Context, acc cua.Accumulator) error {
 	for _, host := range p.Urls {
 		p.wg.Add(1)
@@ -211,11 +228,14 @@ func (p *Ping) nativePing(ctx context.Context, destination string) (*pingStats,
 func (p *Ping) addStats(acc cua.Accumulator, fields map[string]interface{}, tags map[string]string, stats *pingStats, rtts *[]float64) { //nolint:unparam
 	if !p.DirectMetrics || p.metricDestination == nil {
 		acc.AddFields("ping", fields, tags)
+		if p.RTTHistogram && p.Count > 1 {
+			p.addRTTSamples(acc, tags, stats, rtts)
+		}
 		return
 	}
 
 	ts := time.Now()
-	mtags := circmgr.ConvertTags("ping", "", tags, p.Tags)
+	mtags := circmgr.ConvertTags("ping", p.Measurement("ping"), tags, p.Tags)
 	for metricName, val := range fields {
 		switch v := val.(type) {
 		case string:
@@ -249,6 +269,30 @@ func (p *Ping) addStats(acc cua.Accumulator, fields map[string]interface{}, tags
 	}
 }
 
+// addRTTSamples adds the round trip times in milliseconds as samples of the
+// rtt histogram, like the rtt histogram of direct metrics
+func (p *Ping) addRTTSamples(acc cua.Accumulator, tags map[string]string, stats *pingStats, rtts *[]float64) {
+	var samples []float64
+	if stats != nil {
+		samples = make([]float64, 0, len(stats.Rtts))
+		for _, rtt := range stats.Rtts {
+			samples = append(samples, float64(rtt)/float64(time.Millisecond))
+		}
+	} else if rtts != nil {
+		samples = *rtts
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	htags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		htags[k] = v
+	}
+	htags["units"] = "milliseconds"
+	acc.AddSamples("rtt", samples, htags)
+}
+
 func (p *Ping) pingToURLNative(ctx context.Context, destination string, acc cua.Accumulator) {
 	tags := map[string]string{"url": destination}
 	fields := map[string]interface{}{}
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -267,6 +268,28 @@ func TestPingGather(t *testing.T) {
 	acc.AssertContainsTaggedFields(t, "ping", fields, tags)
 }
 
+func TestPingGatherRTTHistogram(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:         []string{"www.google.com"},
+		Count:        3,
+		RTTHistogram: true,
+		pingHost:     mockLossyHostPinger,
+	}
+
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.True(t, acc.HasMeasurement("ping"))
+	m, ok := acc.Get("rtt")
+	require.True(t, ok)
+	require.Equal(t, cua.Histogram, m.Type)
+	require.Equal(t, map[string]string{"url": "www.google.com", "units": "milliseconds"}, m.Tags)
+	// a sample per received packet
+	require.Len(t, m.Fields, 3)
+	for _, n := range m.Fields {
+		require.Equal(t, int64(1), n)
+	}
+}
+
 var lossyPingOutput = `
 PING www.google.com (216.58.218.164) 56(84) bytes of data.
 64 bytes from host.net (216.58.218.164): icmp_seq=1 ttl=63 time=35.2 ms
@@ -439,3 +462,15 @@ func TestDNSLookupError(t *testing.T) {
 	_ = acc.GatherError(p.Gather)
 	assert.True(t, len(acc.Errors) > 0)
 }
+
+func TestCapabilities(t *testing.T) {
+	p := Ping{Method: "exec"}
+	require.Empty(t, p.Capabilities())
+
+	p.Method = "native"
+	require.Equal(t, []string{"CAP_NET_RAW"}, p.Capabilities())
+
+	unprivileged := false
+	p.Privileged = &unprivileged
+	require.Empty(t, p.Capabilities())
+}
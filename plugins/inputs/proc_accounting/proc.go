@@ -0,0 +1,183 @@
+//go:build linux
+// +build linux
+
+package procaccounting
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// process is the accounting of a process read from /proc/<pid>
+type process struct {
+	pid        int
+	uid        string
+	slice      string
+	cpu        float64 // user and system time, seconds
+	threads    int64
+	rss        int64  // bytes
+	start      uint64 // clock ticks after boot
+	readBytes  uint64
+	writeBytes uint64
+	hasIO      bool
+}
+
+// readProcess reads the stat, status, io and cgroup of the process; io is
+// only readable for processes of the same user without CAP_SYS_PTRACE
+func readProcess(dir string, pid int, clockTicks float64, pageSize int64, slices bool) (*process, error) {
+	p := &process{pid: pid}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parseStat(data, clockTicks, pageSize); err != nil {
+		return nil, fmt.Errorf("stat of %d: %w", pid, err)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+	p.uid = parseUID(data)
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "io")); err == nil {
+		p.readBytes, p.writeBytes, p.hasIO = parseIO(data)
+	}
+
+	if slices {
+		if data, err := ioutil.ReadFile(filepath.Join(dir, "cgroup")); err == nil {
+			p.slice = parseSlice(data)
+		}
+		if p.slice == "" {
+			p.slice = rootSlice
+		}
+	}
+	return p, nil
+}
+
+// parseStat parses /proc/<pid>/stat, the command may hold spaces and
+// parentheses so the fields are counted from the last ')'
+func (p *process) parseStat(data []byte, clockTicks float64, pageSize int64) error {
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return fmt.Errorf("no command")
+	}
+	// fields from state (3), so utime (14) is at 11
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return fmt.Errorf("%d fields", len(fields)+2)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return fmt.Errorf("utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return fmt.Errorf("stime: %w", err)
+	}
+	p.threads, err = strconv.ParseInt(fields[17], 10, 64)
+	if err != nil {
+		return fmt.Errorf("num_threads: %w", err)
+	}
+	p.start, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return fmt.Errorf("starttime: %w", err)
+	}
+	rss, err := strconv.ParseInt(fields[21], 10, 64)
+	if err != nil {
+		return fmt.Errorf("rss: %w", err)
+	}
+	p.cpu = float64(utime+stime) / clockTicks
+	p.rss = rss * pageSize
+	return nil
+}
+
+// parseUID answers the real user id of /proc/<pid>/status
+func parseUID(data []byte) string {
+	scnr := bufio.NewScanner(bytes.NewReader(data))
+	for scnr.Scan() {
+		line := scnr.Text()
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		if ids := strings.Fields(line[4:]); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
+
+// parseIO answers the bytes read from and written to storage of
+// /proc/<pid>/io
+func parseIO(data []byte) (read, written uint64, ok bool) {
+	var hasRead, hasWritten bool
+	scnr := bufio.NewScanner(bytes.NewReader(data))
+	for scnr.Scan() {
+		kv := strings.SplitN(scnr.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch kv[0] {
+		case "read_bytes":
+			read, hasRead = v, true
+		case "write_bytes":
+			written, hasWritten = v, true
+		}
+	}
+	return read, written, hasRead && hasWritten
+}
+
+// the systemd root slice, of processes outside any slice
+const rootSlice = "-.slice"
+
+// parseSlice answers the innermost systemd slice of /proc/<pid>/cgroup,
+// e.g. user-1000.slice for 0::/user.slice/user-1000.slice/session-3.scope,
+// from the unified hierarchy or the name=systemd one of cgroup v1
+func parseSlice(data []byte) string {
+	var path string
+	scnr := bufio.NewScanner(bytes.NewReader(data))
+	for scnr.Scan() {
+		parts := strings.SplitN(scnr.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "name=systemd" || (parts[0] == "0" && parts[1] == "") {
+			path = parts[2]
+			break
+		}
+	}
+	elems := strings.Split(path, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if strings.HasSuffix(elems[i], ".slice") {
+			return elems[i]
+		}
+	}
+	return ""
+}
+
+// readUptime answers the uptime of /proc/uptime in clock ticks
+func readUptime(procPath string, clockTicks float64) (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procPath, "uptime"))
+	if err != nil {
+		return 0, fmt.Errorf("reading uptime: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty uptime")
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing uptime: %w", err)
+	}
+	return uint64(uptime * clockTicks), nil
+}
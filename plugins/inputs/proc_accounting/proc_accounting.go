@@ -0,0 +1,244 @@
+//go:build linux
+// +build linux
+
+package procaccounting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Aggregate the processes by any of:
+  ##   user  - the real user of the processes, tagged user
+  ##   slice - the innermost systemd slice of the processes, tagged slice
+  # group_by = ["user", "slice"]
+`
+
+const (
+	groupUser  = "user"
+	groupSlice = "slice"
+)
+
+// key of a process, the start time tells a reused pid apart
+type key struct {
+	pid   int
+	start uint64
+}
+
+// sample of the counters of a process at the previous gather
+type sample struct {
+	cpu        float64
+	readBytes  uint64
+	writeBytes uint64
+	hasIO      bool
+}
+
+// group of processes aggregated by user or slice
+type group struct {
+	processes  int64
+	threads    int64
+	rss        int64
+	cpu        float64
+	readBytes  uint64
+	writeBytes uint64
+}
+
+type groupKey struct {
+	by    string
+	value string
+}
+
+type ProcAccounting struct {
+	GroupBy []string `toml:"group_by"`
+
+	Log cua.Logger `toml:"-"`
+
+	procPath   string
+	clockTicks float64
+	pageSize   int64
+	lookupUser func(uid string) string
+	users      map[string]string
+
+	prev       map[key]sample
+	prevTime   time.Time
+	prevUptime uint64
+}
+
+// Description answers a description of this input plugin
+func (*ProcAccounting) Description() string {
+	return "Aggregate the cpu, memory and io of the processes by user and systemd slice"
+}
+
+// SampleConfig answers a sample configuration
+func (*ProcAccounting) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *ProcAccounting) Init() error {
+	if len(p.GroupBy) == 0 {
+		return fmt.Errorf("no group_by configured")
+	}
+	for _, by := range p.GroupBy {
+		if by != groupUser && by != groupSlice {
+			return fmt.Errorf("invalid group_by %q", by)
+		}
+	}
+	if p.procPath == "" {
+		p.procPath = "/proc"
+		if v := os.Getenv("HOST_PROC"); v != "" {
+			p.procPath = v
+		}
+	}
+	if p.clockTicks == 0 {
+		p.clockTicks = cpu.ClocksPerSec
+	}
+	if p.pageSize == 0 {
+		p.pageSize = int64(os.Getpagesize())
+	}
+	if p.lookupUser == nil {
+		p.lookupUser = lookupUser
+	}
+	p.users = make(map[string]string)
+	return nil
+}
+
+func (p *ProcAccounting) Gather(_ context.Context, acc cua.Accumulator) error {
+	now := time.Now()
+	uptime, err := readUptime(p.procPath, p.clockTicks)
+	if err != nil {
+		return err
+	}
+	pids, err := p.pids()
+	if err != nil {
+		return err
+	}
+
+	slices := false
+	for _, by := range p.GroupBy {
+		slices = slices || by == groupSlice
+	}
+
+	primed := !p.prevTime.IsZero()
+	samples := make(map[key]sample, len(pids))
+	groups := make(map[groupKey]*group)
+	for _, pid := range pids {
+		proc, err := readProcess(filepath.Join(p.procPath, strconv.Itoa(pid)), pid, p.clockTicks, p.pageSize, slices)
+		if err != nil {
+			// gone since listed, or a kernel thread without status
+			continue
+		}
+		k := key{pid: pid, start: proc.start}
+		s := sample{cpu: proc.cpu, readBytes: proc.readBytes, writeBytes: proc.writeBytes, hasIO: proc.hasIO}
+		samples[k] = s
+
+		// usage since the previous gather, all of it for processes
+		// started since then
+		var d sample
+		if prev, ok := p.prev[k]; ok {
+			d.cpu = s.cpu - prev.cpu
+			if s.hasIO && prev.hasIO && s.readBytes >= prev.readBytes && s.writeBytes >= prev.writeBytes {
+				d.readBytes = s.readBytes - prev.readBytes
+				d.writeBytes = s.writeBytes - prev.writeBytes
+			}
+		} else if primed && proc.start >= p.prevUptime {
+			d = s
+		}
+
+		for _, by := range p.GroupBy {
+			gk := groupKey{by: by, value: proc.slice}
+			if by == groupUser {
+				gk.value = p.userName(proc.uid)
+			}
+			g, ok := groups[gk]
+			if !ok {
+				g = &group{}
+				groups[gk] = g
+			}
+			g.processes++
+			g.threads += proc.threads
+			g.rss += proc.rss
+			g.cpu += d.cpu
+			g.readBytes += d.readBytes
+			g.writeBytes += d.writeBytes
+		}
+	}
+
+	elapsed := now.Sub(p.prevTime).Seconds()
+	for gk, g := range groups {
+		fields := map[string]interface{}{
+			"processes":  g.processes,
+			"threads":    g.threads,
+			"memory_rss": g.rss,
+		}
+		if primed && elapsed > 0 {
+			fields["cpu_usage"] = 100 * g.cpu / elapsed
+			fields["io_read_bytes_per_sec"] = float64(g.readBytes) / elapsed
+			fields["io_write_bytes_per_sec"] = float64(g.writeBytes) / elapsed
+		}
+		acc.AddGauge("proc_accounting", fields, map[string]string{gk.by: gk.value}, now)
+	}
+
+	p.prev = samples
+	p.prevTime = now
+	p.prevUptime = uptime
+	return nil
+}
+
+// pids answers the ids of the processes in /proc
+func (p *ProcAccounting) pids() ([]int, error) {
+	dir, err := os.Open(p.procPath)
+	if err != nil {
+		return nil, fmt.Errorf("listing processes: %w", err)
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("listing processes: %w", err)
+	}
+	pids := make([]int, 0, len(names))
+	for _, name := range names {
+		if pid, err := strconv.Atoi(name); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// userName answers the name of the user id, the id itself when it has no
+// name, e.g. for a container user
+func (p *ProcAccounting) userName(uid string) string {
+	if name, ok := p.users[uid]; ok {
+		return name
+	}
+	name := p.lookupUser(uid)
+	p.users[uid] = name
+	return name
+}
+
+func lookupUser(uid string) string {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return uid
+	}
+	return u.Username
+}
+
+func init() {
+	inputs.Add("proc_accounting", func() cua.Input {
+		return &ProcAccounting{
+			GroupBy: []string{groupUser, groupSlice},
+		}
+	})
+}
@@ -0,0 +1,97 @@
+//go:build linux
+// +build linux
+
+package procaccounting
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProc(t *testing.T, root string, pid int, uid string, utime, stime, start uint64, rss int64, read, written uint64, cgroup string) {
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	stat := fmt.Sprintf("%d (a (weird) cmd) S 1 %d %d 0 -1 4194560 1000 0 0 0 %d %d 0 0 20 0 3 0 %d 12345678 %d 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0\n",
+		pid, pid, pid, utime, stime, start, rss)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+	status := fmt.Sprintf("Name:\tcmd\nState:\tS (sleeping)\nUid:\t%s\t%s\t%s\t%s\nGid:\t0\t0\t0\t0\n", uid, uid, uid, uid)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644))
+	io := fmt.Sprintf("rchar: 1\nwchar: 2\nsyscr: 3\nsyscw: 4\nread_bytes: %d\nwrite_bytes: %d\ncancelled_write_bytes: 0\n", read, written)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "io"), []byte(io), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0644))
+}
+
+func TestParseSlice(t *testing.T) {
+	require.Equal(t, "user-1000.slice", parseSlice([]byte("0::/user.slice/user-1000.slice/session-3.scope\n")))
+	require.Equal(t, "system-getty.slice", parseSlice([]byte(
+		"12:cpu,cpuacct:/system.slice\n1:name=systemd:/system.slice/system-getty.slice/getty@tty1.service\n0::/system.slice/system-getty.slice/getty@tty1.service\n")))
+	require.Equal(t, "", parseSlice([]byte("0::/init.scope\n")))
+}
+
+func TestGather(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "uptime"), []byte("100.00 350.00\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "self"), 0755))
+	writeProc(t, root, 1, "0", 100, 50, 1, 1000, 0, 0, "0::/init.scope\n")
+	writeProc(t, root, 200, "1000", 300, 100, 5000, 2000, 4096, 8192, "0::/user.slice/user-1000.slice/session-3.scope\n")
+	writeProc(t, root, 201, "1000", 10, 10, 6000, 500, 0, 0, "0::/user.slice/user-1000.slice/session-3.scope\n")
+
+	p := &ProcAccounting{
+		GroupBy:    []string{"user", "slice"},
+		procPath:   root,
+		clockTicks: 100,
+		pageSize:   4096,
+		lookupUser: func(uid string) string {
+			if uid == "0" {
+				return "root"
+			}
+			return uid
+		},
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	acc.AssertContainsTaggedFields(t, "proc_accounting", map[string]interface{}{
+		"processes":  int64(2),
+		"threads":    int64(6),
+		"memory_rss": int64(2500 * 4096),
+	}, map[string]string{"user": "1000"})
+	acc.AssertContainsTaggedFields(t, "proc_accounting", map[string]interface{}{
+		"processes":  int64(1),
+		"threads":    int64(3),
+		"memory_rss": int64(1000 * 4096),
+	}, map[string]string{"slice": "-.slice"})
+	require.True(t, acc.HasPoint("proc_accounting", map[string]string{"user": "root"}, "processes", int64(1)))
+	require.True(t, acc.HasPoint("proc_accounting", map[string]string{"slice": "user-1000.slice"}, "processes", int64(2)))
+
+	// 201 exits, 202 starts after the first gather at 100s
+	p.prevTime = p.prevTime.Add(-10 * time.Second)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "201")))
+	writeProc(t, root, 200, "1000", 800, 100, 5000, 2000, 4096+1024*1024, 8192, "0::/user.slice/user-1000.slice/session-3.scope\n")
+	writeProc(t, root, 202, "1000", 400, 100, 10500, 100, 0, 0, "0::/user.slice/user-1000.slice/session-4.scope\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "uptime"), []byte("110.00 350.00\n"), 0644))
+
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(context.Background(), &acc))
+	var m *testutil.Metric
+	for _, m = range acc.Metrics {
+		if m.Tags["user"] == "1000" {
+			break
+		}
+	}
+	require.Equal(t, map[string]string{"user": "1000"}, m.Tags)
+	require.Equal(t, int64(2), m.Fields["processes"])
+	// 5s of 200 and 5s of 202 in 10s
+	require.InDelta(t, 100.0, m.Fields["cpu_usage"], 1)
+	require.InDelta(t, 1024*1024/10.0, m.Fields["io_read_bytes_per_sec"], 1024)
+	require.InDelta(t, 0.0, m.Fields["io_write_bytes_per_sec"], 1)
+}
@@ -21,8 +21,9 @@ import (
 )
 
 type Processes struct {
-	execPS       func() ([]byte, error)
-	readProcFile func(filename string) ([]byte, error)
+	execPS        func() ([]byte, error)
+	execPSThreads func() ([]byte, error)
+	readProcFile  func(filename string) ([]byte, error)
 
 	Log cua.Logger
 
@@ -50,6 +51,11 @@ func (p *Processes) Gather(ctx context.Context, acc cua.Accumulator) error {
 		if err := p.gatherFromPS(fields); err != nil {
 			return err
 		}
+		if p.execPSThreads != nil {
+			if err := p.gatherThreadsFromPS(fields); err != nil {
+				return err
+			}
+		}
 	} else {
 		if err := p.gatherFromProc(fields); err != nil {
 			return err
@@ -75,10 +81,12 @@ func getEmptyFields() map[string]interface{} {
 	case "freebsd":
 		fields["idle"] = int64(0)
 		fields["wait"] = int64(0)
+		fields["total_threads"] = int64(0)
 	case "darwin":
 		fields["idle"] = int64(0)
 	case "openbsd":
 		fields["idle"] = int64(0)
+		fields["total_threads"] = int64(0)
 	case "linux":
 		fields["dead"] = int64(0)
 		fields["paging"] = int64(0)
@@ -128,6 +136,24 @@ func (p *Processes) gatherFromPS(fields map[string]interface{}) error {
 	return nil
 }
 
+// exec `ps` listing each thread to count the threads of all processes
+func (p *Processes) gatherThreadsFromPS(fields map[string]interface{}) error {
+	out, err := p.execPSThreads()
+	if err != nil {
+		return err
+	}
+
+	var threads int64
+	for i, status := range bytes.Fields(out) {
+		if i == 0 && string(status) == "STAT" {
+			continue
+		}
+		threads++
+	}
+	fields["total_threads"] = threads
+	return nil
+}
+
 // get process states from /proc/(pid)/stat files
 func (p *Processes) gatherFromProc(fields map[string]interface{}) error {
 	filenames, err := filepath.Glob(linuxsysctlfs.GetHostProc() + "/[0-9]*/stat")
@@ -226,11 +252,31 @@ func execPS() ([]byte, error) {
 	return out, nil
 }
 
+// execPSThreads lists the state of each thread, supported by the ps of
+// FreeBSD and OpenBSD
+func execPSThreads() ([]byte, error) {
+	bin, err := exec.LookPath("ps")
+	if err != nil {
+		return nil, fmt.Errorf("lookpath (ps): %w", err)
+	}
+
+	out, err := exec.Command(bin, "-axH", "-o", "state").Output()
+	if err != nil {
+		return nil, fmt.Errorf("exec cmd (%s): %w", bin, err)
+	}
+
+	return out, nil
+}
+
 func init() {
 	inputs.Add("processes", func() cua.Input {
-		return &Processes{
+		p := &Processes{
 			execPS:       execPS,
 			readProcFile: readProcFile,
 		}
+		if runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd" {
+			p.execPSThreads = execPSThreads
+		}
+		return p
 	})
 }
@@ -59,6 +59,21 @@ func TestFromPS(t *testing.T) {
 	acc.AssertContainsTaggedFields(t, "processes", fields, map[string]string{})
 }
 
+func TestThreadsFromPS(t *testing.T) {
+	processes := &Processes{
+		Log:           testutil.Logger{},
+		execPS:        testExecPS("STAT\nR\nS\n"),
+		execPSThreads: testExecPS("STAT\nR\nS\nS\nI\n"),
+		forcePS:       true,
+	}
+
+	var acc testutil.Accumulator
+	err := processes.Gather(context.Background(), &acc)
+	require.NoError(t, err)
+	require.True(t, acc.HasPoint("processes", map[string]string{}, "total_threads", int64(4)))
+	require.True(t, acc.HasPoint("processes", map[string]string{}, "total", int64(2)))
+}
+
 func TestFromPSError(t *testing.T) {
 	processes := &Processes{
 		Log:     testutil.Logger{},
@@ -21,45 +21,35 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// metricFunc creates the metrics of a parse, see newMetric and
+// cua.MetricStream
+type metricFunc func(name string, tags map[string]string, fields map[string]interface{}, t time.Time, tp cua.ValueType) cua.Metric
+
+func newMetric(name string, tags map[string]string, fields map[string]interface{}, t time.Time, tp cua.ValueType) cua.Metric {
+	m, _ := metric.New(name, tags, fields, t, tp)
+	return m
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
 func ParseV2(buf []byte, header http.Header) ([]cua.Metric, error) {
 	var metrics []cua.Metric
-	var parser expfmt.TextParser
-	// parse even if the buffer begins with a newline
-	buf = bytes.TrimPrefix(buf, []byte("\n"))
-	// Read raw data
-	buffer := bytes.NewBuffer(buf)
-	reader := bufio.NewReader(buffer)
-
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
-	// Prepare output
-	metricFamilies := make(map[string]*dto.MetricFamily)
-
-	if err == nil && mediatype == "application/vnd.google.protobuf" &&
-		params["encoding"] == "delimited" &&
-		params["proto"] == "io.prometheus.client.MetricFamily" {
-		for {
-			mf := &dto.MetricFamily{}
-			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
-				if errors.Is(ierr, io.EOF) {
-					break
-				}
-				return nil, fmt.Errorf("reading metric family protocol buffer failed: %w", ierr)
-			}
-			metricFamilies[mf.GetName()] = mf
-		}
-	} else {
-		metricFamilies, err = parser.TextToMetricFamilies(reader)
-		if err != nil {
-			return nil, fmt.Errorf("reading text format failed: %w", err)
-		}
+	err := parseV2(buf, header, newMetric, func(m cua.Metric) {
+		metrics = append(metrics, m)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return metrics, nil
+}
 
+// parseV2 passes the metrics of buf to emit as they are parsed, metrics
+// are created by newMetric
+func parseV2(buf []byte, header http.Header, newMetric metricFunc, emit func(cua.Metric)) error {
 	// make sure all metrics have a consistent timestamp so that metrics don't straddle two different seconds
 	now := time.Now()
-	// read metrics
-	for metricName, mf := range metricFamilies {
+	return parseFamilies(buf, header, func(mf *dto.MetricFamily) {
+		metricName := mf.GetName()
 		for _, m := range mf.Metric {
 			// reading tags
 			tags := makeLabels(m)
@@ -67,54 +57,75 @@ func ParseV2(buf []byte, header http.Header) ([]cua.Metric, error) {
 			switch mf.GetType() {
 			case dto.MetricType_SUMMARY:
 				// summary metric
-				agentMetrics := makeQuantilesV2(m, tags, metricName, mf.GetType(), now)
-				metrics = append(metrics, agentMetrics...)
+				makeQuantilesV2(m, tags, metricName, mf.GetType(), now, newMetric, emit)
 			case dto.MetricType_HISTOGRAM:
 				// histogram metric
-				agentMetrics := makeBucketsV2(m, tags, metricName, mf.GetType(), now)
-				metrics = append(metrics, agentMetrics...)
+				makeBucketsV2(m, tags, metricName, mf.GetType(), now, newMetric, emit)
 			default:
 				// standard metric
 				// reading fields
 				fields := getNameAndValueV2(m, metricName)
 				// converting to circonus metric
 				if len(fields) > 0 {
-					var t time.Time
-					if m.TimestampMs != nil && *m.TimestampMs > 0 {
-						t = time.Unix(0, *m.TimestampMs*1000000)
-					} else {
-						t = now
-					}
-					metric, err := metric.New("prometheus", tags, fields, t, valueType(mf.GetType()))
-					if err == nil {
-						metrics = append(metrics, metric)
-					} else {
-						return nil, fmt.Errorf("metric new: %w", err)
-					}
+					emit(newMetric("prometheus", tags, fields, metricTime(m, now), valueType(mf.GetType())))
+				}
+			}
+		}
+	})
+}
+
+// parseFamilies passes the metric families of buf to fn, in the protobuf
+// format each family as soon as it is read
+func parseFamilies(buf []byte, header http.Header, fn func(*dto.MetricFamily)) error {
+	var parser expfmt.TextParser
+	// parse even if the buffer begins with a newline
+	buf = bytes.TrimPrefix(buf, []byte("\n"))
+	// Read raw data
+	buffer := bytes.NewBuffer(buf)
+	reader := bufio.NewReader(buffer)
+
+	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if err == nil && mediatype == "application/vnd.google.protobuf" &&
+		params["encoding"] == "delimited" &&
+		params["proto"] == "io.prometheus.client.MetricFamily" {
+		for {
+			mf := &dto.MetricFamily{}
+			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
+				if errors.Is(ierr, io.EOF) {
+					break
 				}
+				return fmt.Errorf("reading metric family protocol buffer failed: %w", ierr)
 			}
+			fn(mf)
 		}
+		return nil
 	}
 
-	return metrics, nil
+	metricFamilies, err := parser.TextToMetricFamilies(reader)
+	if err != nil {
+		return fmt.Errorf("reading text format failed: %w", err)
+	}
+	for _, mf := range metricFamilies {
+		fn(mf)
+	}
+	return nil
 }
 
-// Get Quantiles for summary metric & Buckets for histogram
-func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, now time.Time) []cua.Metric {
-	var metrics []cua.Metric
-	fields := make(map[string]interface{})
-	var t time.Time
+func metricTime(m *dto.Metric, now time.Time) time.Time {
 	if m.TimestampMs != nil && *m.TimestampMs > 0 {
-		t = time.Unix(0, *m.TimestampMs*1000000)
-	} else {
-		t = now
+		return time.Unix(0, *m.TimestampMs*1000000)
 	}
+	return now
+}
+
+// Get Quantiles for summary metric & Buckets for histogram
+func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, now time.Time, newMetric metricFunc, emit func(cua.Metric)) {
+	fields := make(map[string]interface{})
+	t := metricTime(m, now)
 	fields[metricName+"_count"] = float64(m.GetSummary().GetSampleCount())
 	fields[metricName+"_sum"] = m.GetSummary().GetSampleSum()
-	met, err := metric.New("prometheus", tags, fields, t, valueType(metricType))
-	if err == nil {
-		metrics = append(metrics, met)
-	}
+	emit(newMetric("prometheus", tags, fields, t, valueType(metricType)))
 
 	for _, q := range m.GetSummary().Quantile {
 		newTags := tags
@@ -123,31 +134,17 @@ func makeQuantilesV2(m *dto.Metric, tags map[string]string, metricName string, m
 		newTags["quantile"] = fmt.Sprint(q.GetQuantile())
 		fields[metricName] = q.GetValue()
 
-		quantileMetric, err := metric.New("prometheus", newTags, fields, t, valueType(metricType))
-		if err == nil {
-			metrics = append(metrics, quantileMetric)
-		}
+		emit(newMetric("prometheus", newTags, fields, t, valueType(metricType)))
 	}
-	return metrics
 }
 
 // Get Buckets  from histogram metric
-func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, now time.Time) []cua.Metric {
-	var metrics []cua.Metric
+func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, metricType dto.MetricType, now time.Time, newMetric metricFunc, emit func(cua.Metric)) {
 	fields := make(map[string]interface{})
-	var t time.Time
-	if m.TimestampMs != nil && *m.TimestampMs > 0 {
-		t = time.Unix(0, *m.TimestampMs*1000000)
-	} else {
-		t = now
-	}
+	t := metricTime(m, now)
 	fields[metricName+"_count"] = float64(m.GetHistogram().GetSampleCount())
 	fields[metricName+"_sum"] = m.GetHistogram().GetSampleSum()
-
-	met, err := metric.New("prometheus", tags, fields, t, valueType(metricType))
-	if err == nil {
-		metrics = append(metrics, met)
-	}
+	emit(newMetric("prometheus", tags, fields, t, valueType(metricType)))
 
 	for _, b := range m.GetHistogram().Bucket {
 		newTags := tags
@@ -155,53 +152,30 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 		newTags["le"] = fmt.Sprint(b.GetUpperBound())
 		fields[metricName+"_bucket"] = float64(b.GetCumulativeCount())
 
-		histogramMetric, err := metric.New("prometheus", newTags, fields, t, valueType(metricType))
-		if err == nil {
-			metrics = append(metrics, histogramMetric)
-		}
+		emit(newMetric("prometheus", newTags, fields, t, valueType(metricType)))
 	}
-	return metrics
 }
 
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
 func Parse(buf []byte, header http.Header) ([]cua.Metric, error) {
 	var metrics []cua.Metric
-	var parser expfmt.TextParser
-	// parse even if the buffer begins with a newline
-	buf = bytes.TrimPrefix(buf, []byte("\n"))
-	// Read raw data
-	buffer := bytes.NewBuffer(buf)
-	reader := bufio.NewReader(buffer)
-
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
-	// Prepare output
-	metricFamilies := make(map[string]*dto.MetricFamily)
-
-	if err == nil && mediatype == "application/vnd.google.protobuf" &&
-		params["encoding"] == "delimited" &&
-		params["proto"] == "io.prometheus.client.MetricFamily" {
-		for {
-			mf := &dto.MetricFamily{}
-			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
-				if errors.Is(ierr, io.EOF) {
-					break
-				}
-				return nil, fmt.Errorf("reading metric family protocol buffer failed: %w", ierr)
-			}
-			metricFamilies[mf.GetName()] = mf
-		}
-	} else {
-		metricFamilies, err = parser.TextToMetricFamilies(reader)
-		if err != nil {
-			return nil, fmt.Errorf("reading text format failed: %w", err)
-		}
+	err := parse(buf, header, newMetric, func(m cua.Metric) {
+		metrics = append(metrics, m)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return metrics, nil
+}
 
+// parse passes the metrics of buf to emit as they are parsed, metrics are
+// created by newMetric
+func parse(buf []byte, header http.Header, newMetric metricFunc, emit func(cua.Metric)) error {
 	// make sure all metrics have a consistent timestamp so that metrics don't straddle two different seconds
 	now := time.Now()
-	// read metrics
-	for metricName, mf := range metricFamilies {
+	return parseFamilies(buf, header, func(mf *dto.MetricFamily) {
+		metricName := mf.GetName()
 		for _, m := range mf.Metric {
 			// reading tags
 			tags := makeLabels(m)
@@ -224,23 +198,10 @@ func Parse(buf []byte, header http.Header) ([]cua.Metric, error) {
 			}
 			// converting to circonus metric
 			if len(fields) > 0 {
-				var t time.Time
-				if m.TimestampMs != nil && *m.TimestampMs > 0 {
-					t = time.Unix(0, *m.TimestampMs*1000000)
-				} else {
-					t = now
-				}
-				metric, err := metric.New(metricName, tags, fields, t, valueType(mf.GetType()))
-				if err == nil {
-					metrics = append(metrics, metric)
-				} else {
-					return nil, fmt.Errorf("metric new: %w", err)
-				}
+				emit(newMetric(metricName, tags, fields, metricTime(m, now), valueType(mf.GetType())))
 			}
 		}
-	}
-
-	return metrics, nil
+	})
 }
 
 func valueType(mt dto.MetricType) cua.ValueType {
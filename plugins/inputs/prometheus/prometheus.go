@@ -20,6 +20,10 @@ import (
 
 const acceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
 
+// streamSize is the number of scraped metrics pending in the accumulator
+// at most, larger scrapes block parsing until the agent takes them
+const streamSize = 1000
+
 type Prometheus struct {
 	// An array of urls to scrape metrics from.
 	URLs []string `toml:"urls"`
@@ -332,6 +336,29 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc cua.Accumulator) error {
 		return fmt.Errorf("error reading body: %w", err)
 	}
 
+	// strip user and password from URL
+	u.OriginalURL.User = nil
+
+	if sacc, ok := acc.(cua.StreamingAccumulator); ok {
+		// add the metrics as they are parsed, the tags are added to the
+		// parsed metrics instead of copying them
+		stream := sacc.NewStream(streamSize)
+		defer stream.Close()
+		emit := func(metric cua.Metric) {
+			p.addURLTags(metric, u)
+			stream.AddMetric(metric)
+		}
+		if p.MetricVersion == 2 {
+			err = parseV2(body, resp.Header, stream.NewMetric, emit)
+		} else {
+			err = parse(body, resp.Header, stream.NewMetric, emit)
+		}
+		if err != nil {
+			return fmt.Errorf("error reading metrics for %s: %w", u.URL, err)
+		}
+		return nil
+	}
+
 	if p.MetricVersion == 2 {
 		metrics, err = ParseV2(body, resp.Header)
 	} else {
@@ -343,18 +370,8 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc cua.Accumulator) error {
 	}
 
 	for _, metric := range metrics {
+		p.addURLTags(metric, u)
 		tags := metric.Tags()
-		// strip user and password from URL
-		u.OriginalURL.User = nil
-		if p.URLTag != "" {
-			tags[p.URLTag] = u.OriginalURL.String()
-		}
-		if u.Address != "" {
-			tags["address"] = u.Address
-		}
-		for k, v := range u.Tags {
-			tags[k] = v
-		}
 
 		switch metric.Type() {
 		case cua.Counter:
@@ -373,6 +390,20 @@ func (p *Prometheus) gatherURL(u URLAndAddress, acc cua.Accumulator) error {
 	return nil
 }
 
+// addURLTags adds the url, address and service discovery tags of the
+// target to a scraped metric
+func (p *Prometheus) addURLTags(metric cua.Metric, u URLAndAddress) {
+	if p.URLTag != "" {
+		metric.AddTag(p.URLTag, u.OriginalURL.String())
+	}
+	if u.Address != "" {
+		metric.AddTag("address", u.Address)
+	}
+	for k, v := range u.Tags {
+		metric.AddTag(k, v)
+	}
+}
+
 // Start will start the Kubernetes scraping if enabled in the configuration
 func (p *Prometheus) Start(ctx context.Context, a cua.Accumulator) error {
 	if p.MonitorPods {
@@ -3,8 +3,10 @@ package redis
 import (
 	"bufio"
 	"context"
+	ctls "crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -13,6 +15,8 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/proxy"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/go-redis/redis"
@@ -29,10 +33,14 @@ type Redis struct {
 	Servers  []string
 	Password string
 	tls.ClientConfig
+	proxy.TCPProxy
+	Discovery *discovery.Config `toml:"discovery"`
 
 	Log cua.Logger
 
 	clients     []Client
+	discoverer  *discovery.Discoverer
+	discovered  map[string]*redisClient
 	initialized bool
 }
 
@@ -105,6 +113,42 @@ var sampleConfig = `
   # tls_key = "/etc/circonus-unified-agent/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = true
+
+  ## Optional proxy for tcp servers, either a SOCKS5 proxy,
+  ## socks5://[user:password@]host:port, or an HTTP proxy supporting
+  ## CONNECT, http://[user:password@]host:port.
+  # proxy_url = "socks5://proxy.example.com:1080"
+
+  ## Optional discovery of servers, resolved again every interval and
+  ## gathered in addition to the servers above.
+  # [inputs.redis.discovery]
+  #   ## One of "dns_srv", "consul", "file" or "kubernetes"
+  #   type = "dns_srv"
+  #   # interval = "1m"
+  #   ## Template of the server URL; .Address is the host:port of a target
+  #   ## and .Labels the labels of the target, e.g. the consul node.
+  #   # url = "tcp://:password@{{.Address}}"
+  #
+  #   ## dns_srv: the SRV records to resolve
+  #   names = ["_redis._tcp.example.com"]
+  #
+  #   ## consul: healthy instances of the service, optionally with the tag
+  #   # consul_address = "localhost:8500"
+  #   # consul_token = ""
+  #   # consul_datacenter = ""
+  #   # service = "redis"
+  #   # tag = ""
+  #
+  #   ## file: files, or glob patterns, with a host:port per line or in
+  #   ## the JSON format of the Prometheus file based discovery
+  #   # files = ["/etc/circonus-unified-agent/redis-targets.json"]
+  #
+  #   ## kubernetes: ready endpoints of the service, the port named port or
+  #   ## the first port, in cluster or using the kubeconfig
+  #   # kubeconfig = ""
+  #   # namespace = ""
+  #   # service = "redis"
+  #   # port = ""
 `
 
 func (r *Redis) SampleConfig() string {
@@ -121,77 +165,151 @@ var Tracking = map[string]string{
 	"role":              "replication_role",
 }
 
+func (r *Redis) Init() error {
+	if r.Discovery != nil {
+		d, err := discovery.New(r.Discovery, "tcp://{{.Address}}", r.Log)
+		if err != nil {
+			return err
+		}
+		r.discoverer = d
+	}
+	return nil
+}
+
 func (r *Redis) init(acc cua.Accumulator) error {
 	if r.initialized {
 		return nil
 	}
 
-	if len(r.Servers) == 0 {
+	if len(r.Servers) == 0 && r.discoverer == nil {
 		r.Servers = []string{"tcp://localhost:6379"}
 	}
 
 	r.clients = make([]Client, len(r.Servers))
 
 	for i, serv := range r.Servers {
-		if !strings.HasPrefix(serv, "tcp://") && !strings.HasPrefix(serv, "unix://") {
-			r.Log.Warn("Server URL found without scheme; please update your configuration file")
-			serv = "tcp://" + serv
+		client, err := r.newClient(serv)
+		if err != nil {
+			return err
 		}
+		r.clients[i] = client
+	}
 
-		u, err := url.Parse(serv)
+	r.initialized = true
+	return nil
+}
+
+func (r *Redis) newClient(serv string) (*redisClient, error) {
+	if !strings.HasPrefix(serv, "tcp://") && !strings.HasPrefix(serv, "unix://") {
+		r.Log.Warn("Server URL found without scheme; please update your configuration file")
+		serv = "tcp://" + serv
+	}
+
+	u, err := url.Parse(serv)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse to address %q: %w", serv, err)
+	}
+
+	password := ""
+	if u.User != nil {
+		pw, ok := u.User.Password()
+		if ok {
+			password = pw
+		}
+	}
+	if len(r.Password) > 0 {
+		password = r.Password
+	}
+
+	var address string
+	if u.Scheme == "unix" {
+		address = u.Path
+	} else {
+		address = u.Host
+	}
+
+	tlsConfig, err := r.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	options := &redis.Options{
+		Addr:      address,
+		Password:  password,
+		Network:   u.Scheme,
+		PoolSize:  1,
+		TLSConfig: tlsConfig,
+	}
+	if r.ProxyURL != "" && u.Scheme == "tcp" {
+		dialer, err := r.TCPProxy.Dialer(5 * time.Second)
 		if err != nil {
-			return fmt.Errorf("unable to parse to address %q: %w", serv, err)
+			return nil, fmt.Errorf("proxy: %w", err)
 		}
+		options.Dialer = proxyDialer(dialer, address, tlsConfig)
+	}
 
-		password := ""
-		if u.User != nil {
-			pw, ok := u.User.Password()
-			if ok {
-				password = pw
+	tags := map[string]string{}
+	if u.Scheme == "unix" {
+		tags["socket"] = u.Path
+	} else {
+		tags["server"] = u.Hostname()
+		tags["port"] = u.Port()
+	}
+
+	return &redisClient{
+		client: redis.NewClient(options),
+		tags:   tags,
+	}, nil
+}
+
+// discoveredClients answers the clients of the discovered servers, creating
+// clients for new servers and closing the ones of servers no longer found
+func (r *Redis) discoveredClients(ctx context.Context) ([]Client, error) {
+	servers, err := r.discoverer.URLs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovering servers: %w", err)
+	}
+
+	clients := make(map[string]*redisClient, len(servers))
+	list := make([]Client, 0, len(servers))
+	for _, serv := range servers {
+		client, ok := r.discovered[serv]
+		if !ok {
+			client, err = r.newClient(serv)
+			if err != nil {
+				return nil, err
 			}
 		}
-		if len(r.Password) > 0 {
-			password = r.Password
-		}
-
-		var address string
-		if u.Scheme == "unix" {
-			address = u.Path
-		} else {
-			address = u.Host
+		clients[serv] = client
+		list = append(list, client)
+	}
+	for serv, client := range r.discovered {
+		if _, ok := clients[serv]; !ok {
+			client.client.Close()
 		}
+	}
+	r.discovered = clients
+	return list, nil
+}
 
-		tlsConfig, err := r.ClientConfig.TLSConfig()
+// proxyDialer connects to the server through the proxy, the redis client
+// only sets up TLS with its default dialer.
+func proxyDialer(dialer proxy.Dialer, address string, tlsConfig *ctls.Config) func() (net.Conn, error) {
+	return func() (net.Conn, error) {
+		conn, err := dialer.Dial("tcp", address)
 		if err != nil {
-			return fmt.Errorf("TLSConfig: %w", err)
+			return nil, err
 		}
-
-		client := redis.NewClient(
-			&redis.Options{
-				Addr:      address,
-				Password:  password,
-				Network:   u.Scheme,
-				PoolSize:  1,
-				TLSConfig: tlsConfig,
-			},
-		)
-
-		tags := map[string]string{}
-		if u.Scheme == "unix" {
-			tags["socket"] = u.Path
-		} else {
-			tags["server"] = u.Hostname()
-			tags["port"] = u.Port()
+		if tlsConfig == nil {
+			return conn, nil
 		}
-
-		r.clients[i] = &redisClient{
-			client: client,
-			tags:   tags,
+		cfg := tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			host, _, _ := net.SplitHostPort(address)
+			cfg.ServerName = host
 		}
+		return ctls.Client(conn, cfg), nil
 	}
-
-	r.initialized = true
-	return nil
 }
 
 // Reads stats from all configured servers accumulates stats.
@@ -206,7 +324,16 @@ func (r *Redis) Gather(ctx context.Context, acc cua.Accumulator) error {
 
 	var wg sync.WaitGroup
 
-	for _, client := range r.clients {
+	clients := r.clients
+	if r.discoverer != nil {
+		discovered, err := r.discoveredClients(ctx)
+		if err != nil {
+			acc.AddError(err)
+		}
+		clients = append(clients[:len(clients):len(clients)], discovered...)
+	}
+
+	for _, client := range clients {
 		wg.Add(1)
 		go func(client Client) {
 			defer wg.Done()
@@ -2,11 +2,16 @@ package redis
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/discovery"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/go-redis/redis"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +51,35 @@ func TestRedisConnect(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRedis_Discovery(t *testing.T) {
+	targets := filepath.Join(t.TempDir(), "redis.txt")
+	require.NoError(t, os.WriteFile(targets, []byte("10.0.0.1:6379\n10.0.0.2:6379\n"), 0600))
+
+	r := &Redis{
+		Log: testutil.Logger{},
+		Discovery: &discovery.Config{
+			Type:     "file",
+			Files:    []string{targets},
+			Interval: internal.Duration{Duration: time.Nanosecond},
+		},
+	}
+	require.NoError(t, r.Init())
+
+	clients, err := r.discoveredClients(context.Background())
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	require.Equal(t, map[string]string{"server": "10.0.0.1", "port": "6379"}, clients[0].BaseTags())
+	kept := r.discovered["tcp://10.0.0.2:6379"]
+
+	require.NoError(t, os.WriteFile(targets, []byte("10.0.0.2:6379\n10.0.0.3:6379\n"), 0600))
+	clients, err = r.discoveredClients(context.Background())
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	require.Same(t, kept, r.discovered["tcp://10.0.0.2:6379"])
+	require.Contains(t, r.discovered, "tcp://10.0.0.3:6379")
+	require.NotContains(t, r.discovered, "tcp://10.0.0.1:6379")
+}
+
 func TestRedis_Commands(t *testing.T) {
 	const redisListKey = "test-list-length"
 	var acc testutil.Accumulator
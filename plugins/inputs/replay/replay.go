@@ -0,0 +1,380 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal/globpath"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Archives to replay, e.g. the rotated files of the file output; files are
+  ## replayed in the order of their names, once each.  Match only archives
+  ## complete or still being appended to, lines are replayed once complete.
+  files = ["/var/lib/circonus-unified-agent/archive/metrics.*.out"]
+
+  ## Maximum lines read but not yet written by the outputs; replaying
+  ## proceeds as fast as the outputs write the metrics.
+  # max_undelivered_lines = 1000
+
+  ## What to do with an archive once all its lines were written, "keep" it
+  ## or "delete" it.  Kept archives are not replayed again while they are
+  ## recorded in the agent statefile.
+  # after_replay = "keep"
+
+  ## Data format of the archives, a line based one as the file output
+  ## writes without use_batch_format.
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+const (
+	afterReplayKeep   = "keep"
+	afterReplayDelete = "delete"
+)
+
+type Replay struct {
+	Files               []string `toml:"files"`
+	MaxUndeliveredLines int      `toml:"max_undelivered_lines"`
+	AfterReplay         string   `toml:"after_replay"`
+
+	Log cua.Logger `toml:"-"`
+
+	parserFunc parsers.ParserFunc
+	globs      []*globpath.GlobPath
+
+	acc    cua.TrackingAccumulator
+	sem    chan struct{}
+	scan   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	offsets map[string]int64 // offset up to which the lines were delivered, by file
+	files   map[string]*replayFile
+	lines   map[cua.TrackingID]*line
+}
+
+// replayFile is an archive being replayed, with its lines not yet delivered
+// in the order they were read
+type replayFile struct {
+	name        string
+	pending     []*line
+	eof         int64 // offset of the end of the file once all read, else -1
+	undelivered int   // metrics rejected by the outputs, e.g. when filtered
+}
+
+type line struct {
+	file      *replayFile
+	end       int64
+	metrics   int
+	delivered bool
+}
+
+// Description answers a description of this input plugin
+func (*Replay) Description() string {
+	return "Replay the metrics of archived files, e.g. of the file output, with their timestamps"
+}
+
+// SampleConfig answers a sample configuration
+func (*Replay) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Replay) SetParserFunc(fn parsers.ParserFunc) {
+	r.parserFunc = fn
+}
+
+func (r *Replay) Init() error {
+	if len(r.Files) == 0 {
+		return fmt.Errorf("no files configured")
+	}
+	if r.MaxUndeliveredLines <= 0 {
+		return fmt.Errorf("invalid max_undelivered_lines %d, must be 1 or more", r.MaxUndeliveredLines)
+	}
+	switch r.AfterReplay {
+	case afterReplayKeep, afterReplayDelete:
+	default:
+		return fmt.Errorf("invalid after_replay %q, must be keep or delete", r.AfterReplay)
+	}
+	for _, file := range r.Files {
+		g, err := globpath.Compile(file)
+		if err != nil {
+			return fmt.Errorf("invalid files pattern %q: %w", file, err)
+		}
+		r.globs = append(r.globs, g)
+	}
+	return nil
+}
+
+func (r *Replay) Start(ctx context.Context, acc cua.Accumulator) error {
+	r.acc = acc.WithTracking(r.MaxUndeliveredLines)
+	r.sem = make(chan struct{}, r.MaxUndeliveredLines)
+	r.scan = make(chan struct{}, 1)
+	r.files = make(map[string]*replayFile)
+	r.lines = make(map[cua.TrackingID]*line)
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case info := <-r.acc.Delivered():
+				r.delivered(info)
+				<-r.sem
+			}
+		}
+	}()
+	go func() {
+		defer r.wg.Done()
+		for {
+			r.replayFiles(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.scan:
+			}
+		}
+	}()
+	return nil
+}
+
+// Gather looks for archives not yet replayed, e.g. rotated since
+func (r *Replay) Gather(_ context.Context, _ cua.Accumulator) error {
+	select {
+	case r.scan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (r *Replay) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// GetState answers the offsets up to which the archives were delivered
+func (r *Replay) GetState() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	offsets := make(map[string]int64, len(r.offsets))
+	for k, v := range r.offsets {
+		offsets[k] = v
+	}
+	return offsets
+}
+
+// SetState restores the offsets to resume replaying at
+func (r *Replay) SetState(state interface{}) error {
+	offsets, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, v := range offsets {
+		r.offsets[k] = v
+	}
+	return nil
+}
+
+// replayFiles replays the lines of the archives not delivered yet, in the
+// order of their names
+func (r *Replay) replayFiles(ctx context.Context) {
+	matched := make(map[string]bool)
+	var names []string
+	for _, g := range r.globs {
+		for _, name := range g.Match() {
+			if matched[name] {
+				continue
+			}
+			if info, err := os.Stat(name); err != nil || info.IsDir() {
+				continue
+			}
+			matched[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	r.mu.Lock()
+	// forget the offsets of archives gone, e.g. deleted once replayed
+	for name := range r.offsets {
+		if !matched[name] && r.files[name] == nil {
+			delete(r.offsets, name)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := r.replayFile(ctx, name); err != nil {
+			r.Log.Errorf("Replaying %s: %v", name, err)
+		}
+	}
+}
+
+func (r *Replay) replayFile(ctx context.Context, name string) error {
+	r.mu.Lock()
+	offset := r.offsets[name]
+	if f := r.files[name]; f != nil {
+		// still being delivered, continue after the lines read
+		if len(f.pending) > 0 {
+			offset = f.pending[len(f.pending)-1].end
+		}
+	}
+	r.mu.Unlock()
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	if offset > info.Size() {
+		r.Log.Warnf("%s is smaller than replayed, replaying it from the start", name)
+		offset = 0
+	}
+	if offset == info.Size() {
+		return nil
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	parser, err := r.parserFunc()
+	if err != nil {
+		return fmt.Errorf("creating parser: %w", err)
+	}
+
+	r.mu.Lock()
+	f := r.files[name]
+	if f == nil {
+		f = &replayFile{name: name}
+		r.files[name] = f
+		r.Log.Debugf("Replaying %s from offset %d", name, offset)
+	}
+	f.eof = -1
+	r.mu.Unlock()
+
+	reader := bufio.NewReader(file)
+	for {
+		text, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a partial line is replayed once complete
+			break
+		}
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		offset += int64(len(text))
+
+		metrics, err := parser.Parse(text)
+		if err != nil {
+			r.Log.Errorf("Malformed line in %s at offset %d: %v", name, offset-int64(len(text)), err)
+		}
+
+		l := &line{file: f, end: offset, metrics: len(metrics)}
+		r.mu.Lock()
+		f.pending = append(f.pending, l)
+		r.mu.Unlock()
+		if len(metrics) == 0 {
+			r.markDelivered(f, l)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case r.sem <- struct{}{}:
+		}
+		r.mu.Lock()
+		r.lines[r.acc.AddTrackingMetricGroup(metrics)] = l
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	f.eof = offset
+	r.mu.Unlock()
+	r.markDelivered(f, nil)
+	return nil
+}
+
+// delivered records the delivery of the metrics of a line
+func (r *Replay) delivered(info cua.DeliveryInfo) {
+	r.mu.Lock()
+	l, ok := r.lines[info.ID()]
+	delete(r.lines, info.ID())
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	if !info.Delivered() {
+		r.mu.Lock()
+		l.file.undelivered += l.metrics
+		r.mu.Unlock()
+	}
+	r.markDelivered(l.file, l)
+}
+
+// markDelivered marks the line delivered, when not nil, and advances the
+// offset of the file past the delivered lines read first; a file delivered
+// up to its end is done
+func (r *Replay) markDelivered(f *replayFile, l *line) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l != nil {
+		l.delivered = true
+	}
+	n := 0
+	for n < len(f.pending) && f.pending[n].delivered {
+		r.offsets[f.name] = f.pending[n].end
+		n++
+	}
+	f.pending = f.pending[n:]
+	if len(f.pending) > 0 || f.eof < 0 {
+		return
+	}
+
+	delete(r.files, f.name)
+	if f.undelivered > 0 {
+		r.Log.Warnf("Replayed %s, %d metrics were not written", f.name, f.undelivered)
+	} else {
+		r.Log.Infof("Replayed %s", f.name)
+	}
+	if r.AfterReplay == afterReplayDelete {
+		if err := os.Remove(f.name); err != nil {
+			r.Log.Errorf("Deleting replayed %s: %v", f.name, err)
+			return
+		}
+		delete(r.offsets, f.name)
+	}
+}
+
+func init() {
+	inputs.Add("replay", func() cua.Input {
+		return &Replay{
+			MaxUndeliveredLines: 1000,
+			AfterReplay:         afterReplayKeep,
+			offsets:             make(map[string]int64),
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/agent"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/models"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/parsers"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetricMaker struct{}
+
+func (tm *testMetricMaker) Name() string {
+	return "TestPlugin"
+}
+
+func (tm *testMetricMaker) LogName() string {
+	return tm.Name()
+}
+
+func (tm *testMetricMaker) MakeMetric(metric cua.Metric) cua.Metric {
+	return metric
+}
+
+func (tm *testMetricMaker) Log() cua.Logger {
+	return models.NewLogger("test", "test", "")
+}
+
+func newTestReplay(files ...string) *Replay {
+	r := inputs.Inputs["replay"]().(*Replay)
+	r.Log = testutil.Logger{}
+	r.Files = files
+	r.MaxUndeliveredLines = 2
+	r.SetParserFunc(parsers.NewInfluxParser)
+	return r
+}
+
+// receive answers the next metric replayed, accepted or rejected
+func receive(t *testing.T, metrics chan cua.Metric, accept bool) cua.Metric {
+	select {
+	case m := <-metrics:
+		if accept {
+			m.Accept()
+		} else {
+			m.Reject()
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no metric replayed")
+		return nil
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "metrics.1.out")
+	second := filepath.Join(dir, "metrics.2.out")
+	require.NoError(t, os.WriteFile(second, []byte("cpu value=3 1600000002000000000\n"), 0600))
+	require.NoError(t, os.WriteFile(first, []byte(
+		"cpu value=1 1600000000000000000\n"+
+			"malformed\n"+
+			"cpu value=2 1600000001000000000\n"+
+			"cpu value=4"), 0600))
+
+	r := newTestReplay(filepath.Join(dir, "metrics.*.out"))
+	require.NoError(t, r.Init())
+	metrics := make(chan cua.Metric, 10)
+	require.NoError(t, r.Start(context.Background(), agent.NewAccumulator(&testMetricMaker{}, metrics)))
+
+	// replayed in order with their timestamps, up to the partial line
+	m := receive(t, metrics, true)
+	require.Equal(t, map[string]interface{}{"value": 1.0}, m.Fields())
+	require.Equal(t, time.Unix(1600000000, 0), m.Time())
+	m = receive(t, metrics, false)
+	require.Equal(t, time.Unix(1600000001, 0), m.Time())
+	m = receive(t, metrics, true)
+	require.Equal(t, time.Unix(1600000002, 0), m.Time())
+
+	require.Eventually(t, func() bool {
+		state := r.GetState().(map[string]int64)
+		return state[second] == 32 && state[first] == 74
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the completed line is replayed on the next gather
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(" 1600000003000000000\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, r.Gather(context.Background(), nil))
+	m = receive(t, metrics, true)
+	require.Equal(t, time.Unix(1600000003, 0), m.Time())
+	r.Stop()
+
+	// resumed from the state, nothing left to replay
+	state := r.GetState()
+	r = newTestReplay(filepath.Join(dir, "metrics.*.out"))
+	require.NoError(t, r.SetState(state))
+	require.NoError(t, r.Init())
+	require.NoError(t, r.Start(context.Background(), agent.NewAccumulator(&testMetricMaker{}, metrics)))
+	require.NoError(t, r.Gather(context.Background(), nil))
+	select {
+	case m := <-metrics:
+		require.FailNow(t, "replayed again", "%v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+	r.Stop()
+}
+
+func TestReplayDelete(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "metrics.out")
+	require.NoError(t, os.WriteFile(name, []byte(
+		"cpu value=1 1600000000000000000\ncpu value=2 1600000001000000000\n"), 0600))
+
+	r := newTestReplay(name)
+	r.AfterReplay = afterReplayDelete
+	require.NoError(t, r.Init())
+	metrics := make(chan cua.Metric, 10)
+	require.NoError(t, r.Start(context.Background(), agent.NewAccumulator(&testMetricMaker{}, metrics)))
+	defer r.Stop()
+
+	receive(t, metrics, true)
+	require.FileExists(t, name)
+	receive(t, metrics, true)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(name)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, r.GetState())
+}
+
+func TestReplayInit(t *testing.T) {
+	r := newTestReplay()
+	require.Error(t, r.Init())
+	r = newTestReplay("metrics.out")
+	r.AfterReplay = "move"
+	require.Error(t, r.Init())
+	r = newTestReplay("metrics.out")
+	r.MaxUndeliveredLines = 0
+	require.Error(t, r.Init())
+}
@@ -26,15 +26,16 @@ func MinReader(r io.Reader, minNumberOfBytesToRead int64) *MinimumReader {
 func (r *MinimumReader) Read(p []byte) (n int, err error) {
 	n, err = r.R.Read(p)
 	r.MinNumberOfBytesToRead -= int64(n)
-	return n, fmt.Errorf("read: %w", err)
+	return n, err //nolint:wrapcheck // io.Reader callers compare against io.EOF
 }
 
 // Close does not close the underlying reader, only the MinimumReader
 func (r *MinimumReader) Close() error {
 	if r.MinNumberOfBytesToRead > 0 {
 		b := make([]byte, r.MinNumberOfBytesToRead)
-		_, err := r.R.Read(b)
-		return fmt.Errorf("close/read: %w", err)
+		if _, err := r.R.Read(b); err != nil {
+			return fmt.Errorf("close/read: %w", err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,183 @@
+package slurm
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// apiErrors is the error list every slurmrestd answer carries
+type apiErrors struct {
+	Errors []struct {
+		Error       string `json:"error"`
+		Description string `json:"description"`
+		ErrorNumber int    `json:"error_number"`
+	} `json:"errors"`
+}
+
+func (e apiErrors) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	msgs := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		msg := item.Error
+		if msg == "" {
+			msg = item.Description
+		}
+		msgs = append(msgs, msg)
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+type diagResponse struct {
+	apiErrors
+	Statistics diagStatistics `json:"statistics"`
+}
+
+type diagStatistics struct {
+	ServerThreadCount   int64 `json:"server_thread_count"`
+	AgentQueueSize      int64 `json:"agent_queue_size"`
+	AgentCount          int64 `json:"agent_count"`
+	DBDAgentQueueSize   int64 `json:"dbd_agent_queue_size"`
+	JobsSubmitted       int64 `json:"jobs_submitted"`
+	JobsStarted         int64 `json:"jobs_started"`
+	JobsCompleted       int64 `json:"jobs_completed"`
+	JobsCanceled        int64 `json:"jobs_canceled"`
+	JobsFailed          int64 `json:"jobs_failed"`
+	JobsPending         int64 `json:"jobs_pending"`
+	JobsRunning         int64 `json:"jobs_running"`
+	ScheduleCycleLast   int64 `json:"schedule_cycle_last"`
+	ScheduleCycleMax    int64 `json:"schedule_cycle_max"`
+	ScheduleCycleMean   int64 `json:"schedule_cycle_mean"`
+	ScheduleQueueLength int64 `json:"schedule_queue_length"`
+	BFBackfilledJobs    int64 `json:"bf_backfilled_jobs"`
+	BFCycleLast         int64 `json:"bf_cycle_last"`
+	BFCycleMax          int64 `json:"bf_cycle_max"`
+	BFQueueLen          int64 `json:"bf_queue_len"`
+}
+
+func (d diagStatistics) fields() map[string]interface{} {
+	return map[string]interface{}{
+		"server_thread_count":   d.ServerThreadCount,
+		"agent_queue_size":      d.AgentQueueSize,
+		"agent_count":           d.AgentCount,
+		"dbd_agent_queue_size":  d.DBDAgentQueueSize,
+		"jobs_submitted":        d.JobsSubmitted,
+		"jobs_started":          d.JobsStarted,
+		"jobs_completed":        d.JobsCompleted,
+		"jobs_canceled":         d.JobsCanceled,
+		"jobs_failed":           d.JobsFailed,
+		"jobs_pending":          d.JobsPending,
+		"jobs_running":          d.JobsRunning,
+		"schedule_cycle_last":   d.ScheduleCycleLast,
+		"schedule_cycle_max":    d.ScheduleCycleMax,
+		"schedule_cycle_mean":   d.ScheduleCycleMean,
+		"schedule_queue_length": d.ScheduleQueueLength,
+		"bf_backfilled_jobs":    d.BFBackfilledJobs,
+		"bf_cycle_last":         d.BFCycleLast,
+		"bf_cycle_max":          d.BFCycleMax,
+		"bf_queue_len":          d.BFQueueLen,
+	}
+}
+
+type jobsResponse struct {
+	apiErrors
+	Jobs []job `json:"jobs"`
+}
+
+type job struct {
+	JobID     int64      `json:"job_id"`
+	Partition string     `json:"partition"`
+	State     stateNames `json:"job_state"`
+	CPUs      int64      `json:"cpus"`
+	NodeCount int64      `json:"node_count"`
+}
+
+type nodesResponse struct {
+	apiErrors
+	Nodes []node `json:"nodes"`
+}
+
+type node struct {
+	Name       string     `json:"name"`
+	State      stateNames `json:"state"`
+	StateFlags []string   `json:"state_flags"`
+	CPUs       int64      `json:"cpus"`
+	AllocCPUs  int64      `json:"alloc_cpus"`
+	Partitions []string   `json:"partitions"`
+}
+
+// stateName answers the node state including the drain flag, which is what
+// operators act on (e.g. "idle+drain")
+func (n node) stateName() string {
+	state := n.State.base()
+	for _, flag := range n.flags() {
+		if f := strings.ToLower(flag); f == "drain" {
+			state += "+" + f
+		}
+	}
+	return state
+}
+
+// flags answers the state flags, which newer OpenAPI plugin versions list
+// after the base state
+func (n node) flags() []string {
+	flags := make([]string, 0, len(n.StateFlags)+len(n.State))
+	flags = append(flags, n.StateFlags...)
+	if len(n.State) > 1 {
+		flags = append(flags, n.State[1:]...)
+	}
+	return flags
+}
+
+// unavailable reports whether a node cannot run new jobs
+func (n node) unavailable() bool {
+	switch n.State.base() {
+	case "down", "drain", "fail", "future", "unknown":
+		return true
+	}
+	for _, flag := range n.flags() {
+		switch strings.ToLower(flag) {
+		case "drain", "fail", "maintenance", "not_responding":
+			return true
+		}
+	}
+	return false
+}
+
+type partitionsResponse struct {
+	apiErrors
+	Partitions []partition `json:"partitions"`
+}
+
+type partition struct {
+	Name string `json:"name"`
+}
+
+// stateNames decodes job and node states, which older OpenAPI plugin
+// versions answer as a string ("RUNNING") and newer ones as a list of the
+// base state followed by flags (["IDLE", "DRAIN"])
+type stateNames []string
+
+func (s *stateNames) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = stateNames{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err //nolint:wrapcheck
+	}
+	*s = list
+	return nil
+}
+
+// base answers the lower case base state
+func (s stateNames) base() string {
+	if len(s) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(s[0])
+}
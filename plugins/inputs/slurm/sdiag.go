@@ -0,0 +1,71 @@
+package slurm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sdiagFields maps sdiag output lines to the field names used by the
+// slurmrestd diag endpoint, per section of the output
+var sdiagFields = map[string]map[string]string{
+	"": {
+		"Server thread count":  "server_thread_count",
+		"Agent queue size":     "agent_queue_size",
+		"Agent count":          "agent_count",
+		"DBD Agent queue size": "dbd_agent_queue_size",
+		"Jobs submitted":       "jobs_submitted",
+		"Jobs started":         "jobs_started",
+		"Jobs completed":       "jobs_completed",
+		"Jobs canceled":        "jobs_canceled",
+		"Jobs failed":          "jobs_failed",
+		"Jobs pending":         "jobs_pending",
+		"Jobs running":         "jobs_running",
+	},
+	"main": {
+		"Last cycle":        "schedule_cycle_last",
+		"Max cycle":         "schedule_cycle_max",
+		"Mean cycle":        "schedule_cycle_mean",
+		"Last queue length": "schedule_queue_length",
+	},
+	"backfill": {
+		"Total backfilled jobs (since last slurm start)": "bf_backfilled_jobs",
+		"Last cycle":        "bf_cycle_last",
+		"Max cycle":         "bf_cycle_max",
+		"Last queue length": "bf_queue_len",
+	},
+}
+
+// parseSdiag parses the output of sdiag into slurm_diag fields
+func parseSdiag(out string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	section := ""
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Main schedule statistics"):
+			section = "main"
+			continue
+		case strings.HasPrefix(trimmed, "Backfilling stats"):
+			section = "backfill"
+			continue
+		case strings.HasPrefix(trimmed, "Remote Procedure Call statistics"):
+			// per message type and per user RPC counts follow
+			return fields
+		}
+
+		i := strings.Index(trimmed, ":")
+		if i < 0 {
+			continue
+		}
+		name, ok := sdiagFields[section][strings.TrimSpace(trimmed[:i])]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(trimmed[i+1:]), 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[name] = v
+	}
+	return fields
+}
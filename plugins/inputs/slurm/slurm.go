@@ -0,0 +1,314 @@
+package slurm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Where to read scheduler statistics from:
+  ##   slurmrestd - query the REST API for scheduler diagnostics, jobs,
+  ##                nodes and partitions
+  ##   sdiag      - run sdiag on the controller and report scheduler
+  ##                diagnostics only
+  # source = "slurmrestd"
+
+  ## slurmrestd address and the OpenAPI plugin version it serves
+  url = "http://localhost:6820"
+  # api_version = "v0.0.37"
+
+  ## JWT authentication, sent as X-SLURM-USER-NAME and X-SLURM-USER-TOKEN
+  # username = ""
+  # token = ""
+
+  ## Path to the sdiag binary
+  # sdiag_path = "/usr/bin/sdiag"
+
+  ## Timeout for requests and sdiag
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const (
+	sourceREST  = "slurmrestd"
+	sourceSdiag = "sdiag"
+)
+
+type Slurm struct {
+	Source     string            `toml:"source"`
+	URL        string            `toml:"url"`
+	APIVersion string            `toml:"api_version"`
+	Username   string            `toml:"username"`
+	Token      string            `toml:"token"`
+	SdiagPath  string            `toml:"sdiag_path"`
+	Timeout    internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+// Description answers a description of this input plugin
+func (*Slurm) Description() string {
+	return "Read scheduler, job queue, node and partition statistics from Slurm"
+}
+
+// SampleConfig answers a sample configuration
+func (*Slurm) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Slurm) Init() error {
+	switch s.Source {
+	case "":
+		s.Source = sourceREST
+	case sourceREST, sourceSdiag:
+	default:
+		return fmt.Errorf("unknown source %q", s.Source)
+	}
+
+	if s.Source == sourceREST {
+		if _, err := url.Parse(s.URL); err != nil {
+			return fmt.Errorf("parsing url (%s): %w", s.URL, err)
+		}
+		tlsCfg, err := s.ClientConfig.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("tls config: %w", err)
+		}
+		s.client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+			Timeout:   s.Timeout.Duration,
+		}
+	}
+	return nil
+}
+
+func (s *Slurm) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if s.Source == sourceSdiag {
+		out, err := internal.CombinedOutputTimeout(exec.Command(s.SdiagPath), s.Timeout.Duration) //nolint:gosec // G204
+		if err != nil {
+			return fmt.Errorf("running %s: %w", s.SdiagPath, err)
+		}
+		acc.AddFields("slurm_diag", parseSdiag(string(out)), nil)
+		return nil
+	}
+
+	var diag diagResponse
+	if err := s.get(ctx, "diag", &diag); err != nil {
+		acc.AddError(err)
+	} else {
+		acc.AddFields("slurm_diag", diag.Statistics.fields(), nil)
+	}
+
+	var jobs jobsResponse
+	if err := s.get(ctx, "jobs", &jobs); err != nil {
+		acc.AddError(err)
+		jobs.Jobs = nil
+	}
+	var nodes nodesResponse
+	if err := s.get(ctx, "nodes", &nodes); err != nil {
+		acc.AddError(err)
+		nodes.Nodes = nil
+	}
+	var partitions partitionsResponse
+	if err := s.get(ctx, "partitions", &partitions); err != nil {
+		acc.AddError(err)
+		partitions.Partitions = nil
+	}
+
+	now := time.Now()
+	gatherJobs(acc, jobs.Jobs, now)
+	gatherNodes(acc, nodes.Nodes, now)
+	gatherPartitions(acc, partitions.Partitions, nodes.Nodes, jobs.Jobs, now)
+	return nil
+}
+
+// get requests an endpoint of the slurm OpenAPI plugin and decodes the
+// answer into v, one of the response types embedding apiErrors
+func (s *Slurm) get(ctx context.Context, endpoint string, v interface{ err() error }) error {
+	u := strings.TrimSuffix(s.URL, "/") + "/slurm/" + s.APIVersion + "/" + endpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("creating request (%s): %w", u, err)
+	}
+	if s.Username != "" {
+		req.Header.Set("X-SLURM-USER-NAME", s.Username)
+	}
+	if s.Token != "" {
+		req.Header.Set("X-SLURM-USER-TOKEN", s.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("requesting %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s: %w", u, err)
+	}
+	if err := v.err(); err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	return nil
+}
+
+func gatherJobs(acc cua.Accumulator, jobs []job, now time.Time) {
+	type key struct{ partition, state string }
+	type counts struct{ jobs, cpus, nodes int64 }
+
+	byState := make(map[key]*counts)
+	for _, j := range jobs {
+		k := key{partition: j.Partition, state: j.State.base()}
+		c, ok := byState[k]
+		if !ok {
+			c = &counts{}
+			byState[k] = c
+		}
+		c.jobs++
+		c.cpus += j.CPUs
+		c.nodes += j.NodeCount
+	}
+
+	for k, c := range byState {
+		acc.AddFields("slurm_jobs", map[string]interface{}{
+			"count": c.jobs,
+			"cpus":  c.cpus,
+			"nodes": c.nodes,
+		}, map[string]string{
+			"partition": k.partition,
+			"state":     k.state,
+		}, now)
+	}
+}
+
+func gatherNodes(acc cua.Accumulator, nodes []node, now time.Time) {
+	type counts struct{ nodes, cpus, allocCPUs int64 }
+
+	byState := make(map[string]*counts)
+	for _, n := range nodes {
+		state := n.stateName()
+		c, ok := byState[state]
+		if !ok {
+			c = &counts{}
+			byState[state] = c
+		}
+		c.nodes++
+		c.cpus += n.CPUs
+		c.allocCPUs += n.AllocCPUs
+	}
+
+	for state, c := range byState {
+		acc.AddFields("slurm_nodes", map[string]interface{}{
+			"count":      c.nodes,
+			"cpus":       c.cpus,
+			"alloc_cpus": c.allocCPUs,
+		}, map[string]string{
+			"state": state,
+		}, now)
+	}
+}
+
+func gatherPartitions(acc cua.Accumulator, partitions []partition, nodes []node, jobs []job, now time.Time) {
+	type usage struct {
+		nodes, allocNodes, idleNodes, downNodes int64
+		cpus, allocCPUs                         int64
+		jobsPending, jobsRunning                int64
+	}
+
+	byPartition := make(map[string]*usage, len(partitions))
+	for _, p := range partitions {
+		byPartition[p.Name] = &usage{}
+	}
+	for _, n := range nodes {
+		for _, name := range n.Partitions {
+			u, ok := byPartition[name]
+			if !ok {
+				continue
+			}
+			u.nodes++
+			u.cpus += n.CPUs
+			u.allocCPUs += n.AllocCPUs
+			switch {
+			case n.unavailable():
+				u.downNodes++
+			case n.State.base() == "idle":
+				u.idleNodes++
+			default:
+				u.allocNodes++
+			}
+		}
+	}
+	for _, j := range jobs {
+		// jobs submitted to several partitions list them comma separated
+		for _, name := range strings.Split(j.Partition, ",") {
+			u, ok := byPartition[name]
+			if !ok {
+				continue
+			}
+			switch j.State.base() {
+			case "pending":
+				u.jobsPending++
+			case "running":
+				u.jobsRunning++
+			}
+		}
+	}
+
+	for _, p := range partitions {
+		u := byPartition[p.Name]
+		fields := map[string]interface{}{
+			"nodes":        u.nodes,
+			"nodes_alloc":  u.allocNodes,
+			"nodes_idle":   u.idleNodes,
+			"nodes_down":   u.downNodes,
+			"cpus":         u.cpus,
+			"cpus_alloc":   u.allocCPUs,
+			"jobs_pending": u.jobsPending,
+			"jobs_running": u.jobsRunning,
+		}
+		if u.cpus > 0 {
+			fields["cpu_utilization"] = float64(u.allocCPUs) / float64(u.cpus) * 100
+		}
+		acc.AddFields("slurm_partition", fields, map[string]string{
+			"partition": p.Name,
+		}, now)
+	}
+}
+
+func init() {
+	inputs.Add("slurm", func() cua.Input {
+		return &Slurm{
+			Source:     sourceREST,
+			URL:        "http://localhost:6820",
+			APIVersion: "v0.0.37",
+			SdiagPath:  "/usr/bin/sdiag",
+			Timeout:    internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package slurm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-SLURM-USER-NAME") != "cua" || r.Header.Get("X-SLURM-USER-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name := filepath.Base(r.URL.Path)
+		if r.URL.Path != "/slurm/v0.0.37/"+name {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+}
+
+func newSlurm(url string) *Slurm {
+	return &Slurm{
+		URL:        url,
+		APIVersion: "v0.0.37",
+		Username:   "cua",
+		Token:      "secret",
+		Timeout:    internal.Duration{Duration: 5 * time.Second},
+		Log:        testutil.Logger{},
+	}
+}
+
+func TestGatherREST(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	s := newSlurm(ts.URL)
+	require.NoError(t, s.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"partition": "gpu", "state": "running"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "nodes": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"partition": "gpu", "state": "pending"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(64), "nodes": int64(2)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"partition": "batch", "state": "running"},
+			map[string]interface{}{"count": int64(2), "cpus": int64(24), "nodes": int64(2)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"partition": "batch,gpu", "state": "pending"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(4), "nodes": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"state": "mixed"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "alloc_cpus": int64(24)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"state": "idle"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "alloc_cpus": int64(0)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"state": "idle+drain"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "alloc_cpus": int64(0)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"state": "allocated"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "alloc_cpus": int64(32)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"state": "down"},
+			map[string]interface{}{"count": int64(1), "cpus": int64(32), "alloc_cpus": int64(0)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_partition",
+			map[string]string{"partition": "batch"},
+			map[string]interface{}{
+				"nodes":           int64(3),
+				"nodes_alloc":     int64(1),
+				"nodes_idle":      int64(1),
+				"nodes_down":      int64(1),
+				"cpus":            int64(96),
+				"cpus_alloc":      int64(24),
+				"cpu_utilization": float64(25),
+				"jobs_pending":    int64(1),
+				"jobs_running":    int64(2),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("slurm_partition",
+			map[string]string{"partition": "gpu"},
+			map[string]interface{}{
+				"nodes":           int64(2),
+				"nodes_alloc":     int64(1),
+				"nodes_idle":      int64(0),
+				"nodes_down":      int64(1),
+				"cpus":            int64(64),
+				"cpus_alloc":      int64(32),
+				"cpu_utilization": float64(50),
+				"jobs_pending":    int64(2),
+				"jobs_running":    int64(1),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics()[1:], testutil.IgnoreTime(), testutil.SortMetrics())
+
+	diag, ok := acc.Get("slurm_diag")
+	require.True(t, ok)
+	require.Equal(t, int64(17), diag.Fields["jobs_pending"])
+	require.Equal(t, int64(4412), diag.Fields["bf_cycle_last"])
+}
+
+func TestGatherRESTUnauthorized(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	s := newSlurm(ts.URL)
+	s.Token = "wrong"
+	require.NoError(t, s.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 4)
+	require.Zero(t, acc.NMetrics())
+}
+
+func TestAPIErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors": [{"error": "Unable to query jobs", "error_number": 2}], "jobs": []}`))
+	}))
+	defer ts.Close()
+
+	s := newSlurm(ts.URL)
+	require.NoError(t, s.Init())
+
+	var jobs jobsResponse
+	err := s.get(context.Background(), "jobs", &jobs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Unable to query jobs")
+}
+
+func TestStateNames(t *testing.T) {
+	var n node
+	require.NoError(t, json.Unmarshal([]byte(`{"state": ["IDLE", "DRAIN"], "cpus": 4}`), &n))
+	require.Equal(t, "idle+drain", n.stateName())
+	require.True(t, n.unavailable())
+
+	require.NoError(t, json.Unmarshal([]byte(`{"state": "mixed", "state_flags": []}`), &n))
+	require.Equal(t, "mixed", n.stateName())
+	require.False(t, n.unavailable())
+}
+
+func TestParseSdiag(t *testing.T) {
+	out, err := os.ReadFile(filepath.Join("testdata", "sdiag.txt"))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]interface{}{
+		"server_thread_count":   int64(3),
+		"agent_queue_size":      int64(0),
+		"agent_count":           int64(0),
+		"dbd_agent_queue_size":  int64(0),
+		"jobs_submitted":        int64(2210),
+		"jobs_started":          int64(2187),
+		"jobs_completed":        int64(2150),
+		"jobs_canceled":         int64(11),
+		"jobs_failed":           int64(4),
+		"jobs_pending":          int64(17),
+		"jobs_running":          int64(26),
+		"schedule_cycle_last":   int64(812),
+		"schedule_cycle_max":    int64(41236),
+		"schedule_cycle_mean":   int64(1093),
+		"schedule_queue_length": int64(17),
+		"bf_backfilled_jobs":    int64(934),
+		"bf_cycle_last":         int64(4412),
+		"bf_cycle_max":          int64(98231),
+		"bf_queue_len":          int64(17),
+	}, parseSdiag(string(out)))
+}
+
+func TestInitUnknownSource(t *testing.T) {
+	s := newSlurm("http://localhost:6820")
+	s.Source = "squeue"
+	require.Error(t, s.Init())
+}
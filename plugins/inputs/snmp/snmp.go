@@ -20,6 +20,7 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/internal/snmp"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/circonus-labs/go-trapmetrics"
@@ -50,9 +51,20 @@ const sampleConfig = `
   ## Number of retries to attempt.
   # retries = 3
 
-  ## The GETBULK max-repetitions parameter.
+  ## The GETBULK max-repetitions parameter: the number of rows requested
+  ## per round trip when walking tables with version 2 or 3. Larger values
+  ## reduce the round trips needed for large tables such as ifTable.
   # max_repetitions = 10
 
+  ## Keep the session to each agent open between gathers rather than
+  ## opening a new one every interval. Saves the connection set up and the
+  ## SNMPv3 engine discovery at the cost of one open socket per agent.
+  # reuse_connections = false
+
+  ## Maximum number of agents polled concurrently; 0 polls all agents at
+  ## once. Bounds memory and socket use when polling thousands of agents.
+  # agent_concurrency = 0
+
   ## SNMPv3 authentication and encryption options.
   ##
   ## Security Name.
@@ -124,6 +136,7 @@ type Snmp struct {
 	Tables            []Table                  `toml:"table"`
 	Fields            []Field                  `toml:"field"` // Name & Fields are the elements of a Table. agent chokes if we try to embed a Table. So instead we have to embed the fields of a Table, and construct a Table during runtime.
 	connectionCache   []snmpConnection
+	connMu            sync.Mutex // guards connectionCache
 	Agents            []string `toml:"agents"`
 	Tags              map[string]string
 	snmp.ClientConfig
@@ -133,6 +146,11 @@ type Snmp struct {
 	DirectMetrics  bool          `toml:"direct_metrics"` // direct metrics mode - send directly to circonus (bypassing output)
 	DebugSNMP      bool          `toml:"debug_snmp"`     // debug gosnmp
 	initialized    bool
+
+	ReuseConnections bool `toml:"reuse_connections"` // keep agent sessions open between gathers
+	AgentConcurrency int  `toml:"agent_concurrency"` // max agents polled concurrently, 0 = all
+
+	circmgr.MetricNaming // direct metrics: name_override, name_prefix, name_suffix
 }
 
 func (s *Snmp) init() error {
@@ -176,6 +194,13 @@ func (s *Snmp) init() error {
 
 	s.connectionCache = make([]snmpConnection, len(s.Agents))
 
+	if s.MaxRepetitions == 0 {
+		s.MaxRepetitions = 10
+	}
+	if s.AgentConcurrency < 0 {
+		return fmt.Errorf("agent_concurrency cannot be negative")
+	}
+
 	for i := range s.Tables {
 		if err := s.Tables[i].Init(); err != nil {
 			return fmt.Errorf("initializing table %s: %w", s.Tables[i].Name, err)
@@ -234,6 +259,9 @@ func (t *Table) initBuild() error {
 	if t.Oid == "" {
 		return nil
 	}
+	if lite.MIBsDisabled() {
+		return fmt.Errorf("table oid %s needs MIB lookups, not allowed with disable_mibs; list the fields of the table instead", t.Oid)
+	}
 
 	_, _, oidText, fields, err := snmpTable(t.Oid)
 	if err != nil {
@@ -425,59 +453,39 @@ func (s *Snmp) Gather(ctx context.Context, acc cua.Accumulator) error {
 		return err
 	}
 
+	// agents are polled by a pool of workers, each agent is handled by a
+	// single worker as a connection must not be shared between goroutines
+	concurrency := s.AgentConcurrency
+	if concurrency <= 0 || concurrency > len(s.Agents) {
+		concurrency = len(s.Agents)
+	}
+
 	var wg sync.WaitGroup
+	var topDMTagsMu sync.Mutex
 	topDMTags := make(map[string]string)
-	for i, agent := range s.Agents {
+	agents := make(chan int)
+	for w := 0; w < concurrency; w++ {
 		wg.Add(1)
-		go func(i int, agent string) {
+		go func() {
 			defer wg.Done()
-			gs, err := s.getConnection(i)
-			if err != nil {
-				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
-				return
-			}
-
-			// not re-using connections (memory footprint for large scale)
-			defer func(agent string) {
-				if gs != nil {
-					err := gs.Close()
-					if err != nil {
-						s.Log.Errorf("closing snmp conn: %s (%s)", err, agent)
-					}
+			for i := range agents {
+				if topTags := s.gatherAgent(ctx, acc, i); topTags != nil {
+					topDMTagsMu.Lock()
+					topDMTags = topTags
+					topDMTagsMu.Unlock()
 				}
-			}(agent)
-
-			if isDone(ctx) {
-				return
-			}
-
-			// First is the top-level fields. We treat the fields as table prefixes with an empty index.
-			t := Table{
-				Name:   s.Name,
-				Fields: s.Fields,
-			}
-			topTags := map[string]string{}
-
-			if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
-				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
-			}
-			topDMTags = topTags
-
-			if isDone(ctx) {
-				return
 			}
-
-			// Now is the real tables.
-			for _, t := range s.Tables {
-				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
-					acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
-				}
-				if isDone(ctx) {
-					return
-				}
-			}
-		}(i, agent)
+		}()
+	}
+queue:
+	for i := range s.Agents {
+		select {
+		case agents <- i:
+		case <-ctx.Done():
+			break queue
+		}
 	}
+	close(agents)
 	wg.Wait()
 
 	stats := map[string]interface{}{"dur_snmp_get": time.Since(gstart).Seconds()}
@@ -527,6 +535,52 @@ func (s *Snmp) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
+// gatherAgent gathers the top-level fields and the tables of a single agent
+// and returns the tags of the top-level fields.
+func (s *Snmp) gatherAgent(ctx context.Context, acc cua.Accumulator, i int) map[string]string {
+	agent := s.Agents[i]
+	gs, err := s.getConnection(i)
+	if err != nil {
+		acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
+		return nil
+	}
+
+	if !s.ReuseConnections {
+		// not re-using connections (memory footprint for large scale)
+		defer func() {
+			if err := gs.Close(); err != nil {
+				s.Log.Errorf("closing snmp conn: %s (%s)", err, agent)
+			}
+		}()
+	}
+
+	if isDone(ctx) {
+		return nil
+	}
+
+	// First is the top-level fields. We treat the fields as table prefixes with an empty index.
+	t := Table{
+		Name:   s.Name,
+		Fields: s.Fields,
+	}
+	topTags := map[string]string{}
+
+	if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
+		acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
+	}
+
+	// Now is the real tables.
+	for _, t := range s.Tables {
+		if isDone(ctx) {
+			break
+		}
+		if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
+			acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
+		}
+	}
+	return topTags
+}
+
 func (s *Snmp) gatherTable(acc cua.Accumulator, gs snmpConnection, t Table, topTags map[string]string, walk bool) error {
 	rt, err := t.Build(gs, walk)
 	if err != nil {
@@ -553,7 +607,7 @@ func (s *Snmp) gatherTable(acc cua.Accumulator, gs snmpConnection, t Table, topT
 
 		if s.DirectMetrics && s.metricDestination != nil {
 			for metricName, val := range tr.Fields {
-				if err := circmgr.AddMetricToDest(s.metricDestination, "snmp_dm", rt.Name, metricName, tr.Tags, s.Tags, val, rt.Time); err != nil {
+				if err := circmgr.AddMetricToDest(s.metricDestination, "snmp_dm", s.Measurement(rt.Name), metricName, tr.Tags, s.Tags, val, rt.Time); err != nil {
 					s.Log.Warnf("adding %s: %s", metricName, err)
 				}
 			}
@@ -566,9 +620,75 @@ func (s *Snmp) gatherTable(acc cua.Accumulator, gs snmpConnection, t Table, topT
 }
 
 // Build retrieves all the fields specified in the table and constructs the RTable.
+// getFields fetches the OIDs of the given fields in batches of up to
+// gosnmp.MaxOids and returns the results keyed by OID. An agent answering a
+// batch with an error status (SNMPv1 fails the whole request when a single
+// OID is missing) is retried one OID at a time.
+func getFields(gs snmpConnection, fields []Field) (map[string]gosnmp.SnmpPDU, error) {
+	oids := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if len(f.Oid) == 0 {
+			return nil, fmt.Errorf("cannot have empty OID on field %s", f.Name)
+		}
+		oid := f.Oid
+		if oid[0] != '.' {
+			oid = "." + oid
+		}
+		if !seen[oid] {
+			seen[oid] = true
+			oids = append(oids, oid)
+		}
+	}
+
+	pdus := make(map[string]gosnmp.SnmpPDU, len(oids))
+	for len(oids) > 0 {
+		n := len(oids)
+		if n > gosnmp.MaxOids {
+			n = gosnmp.MaxOids
+		}
+		batch := oids[:n]
+		oids = oids[n:]
+
+		pkt, err := gs.Get(batch)
+		if err != nil {
+			return nil, fmt.Errorf("performing get (oids:%s): %w", strings.Join(batch, ","), err)
+		}
+		if pkt == nil {
+			continue
+		}
+		if pkt.Error != gosnmp.NoError && len(batch) > 1 {
+			for _, oid := range batch {
+				pkt, err := gs.Get([]string{oid})
+				if err != nil {
+					return nil, fmt.Errorf("performing get (oid:%s): %w", oid, err)
+				}
+				if pkt != nil && pkt.Error == gosnmp.NoError && len(pkt.Variables) > 0 {
+					pdus[oid] = pkt.Variables[0]
+				}
+			}
+			continue
+		}
+		for _, ent := range pkt.Variables {
+			pdus[ent.Name] = ent
+		}
+	}
+	return pdus, nil
+}
+
 func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 	rows := map[string]RTableRow{}
 
+	// non-table fields are fetched up front, packing as many OIDs into each
+	// GET as the protocol allows rather than a round trip per field
+	var pdus map[string]gosnmp.SnmpPDU
+	if !walk {
+		var err error
+		if pdus, err = getFields(gs, t.Fields); err != nil {
+			return nil, err
+		}
+	}
+
 	tagCount := 0
 	for _, f := range t.Fields {
 		f := f
@@ -597,10 +717,7 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 			// We fetch the fields directly, and add them to ifv as if the index were an
 			// empty string. This results in all the non-table fields sharing the same
 			// index, and being added on the same row.
-			if pkt, err := gs.Get([]string{oid}); err != nil {
-				return nil, fmt.Errorf("performing get on field %s (oid:%s): %w", f.Name, oid, err)
-			} else if pkt != nil && len(pkt.Variables) > 0 && pkt.Variables[0].Type != gosnmp.NoSuchObject && pkt.Variables[0].Type != gosnmp.NoSuchInstance {
-				ent := pkt.Variables[0]
+			if ent, ok := pdus[oid]; ok && ent.Type != gosnmp.NoSuchObject && ent.Type != gosnmp.NoSuchInstance {
 				fv, err := fieldConvert(f, ent)
 				if err != nil {
 					return nil, fmt.Errorf("converting %q (OID %s) for field %s: %w", ent.Value, ent.Name, f.Name, err)
@@ -754,13 +871,16 @@ func (ls logShim) Printf(fmt string, args ...interface{}) {
 	ls.log.Debugf(fmt, args)
 }
 
-// getConnection creates a snmpConnection (*gosnmp.GoSNMP) object and caches the
-// result using `agentIndex` as the cache key.  This is done to allow multiple
-// connections to a single address.  It is an error to use a connection in
-// more than one goroutine.
+// getConnection creates a snmpConnection (*gosnmp.GoSNMP) object and, with
+// reuse_connections, caches the result using `agentIndex` as the cache key.
+// This is done to allow multiple connections to a single address.  It is an
+// error to use a connection in more than one goroutine.
 func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
-	if gs := s.connectionCache[idx]; gs != nil {
-		return gs, nil
+	s.connMu.Lock()
+	cached := s.connectionCache[idx]
+	s.connMu.Unlock()
+	if cached != nil {
+		return cached, nil
 	}
 
 	agent := s.Agents[idx]
@@ -776,9 +896,6 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		return nil, fmt.Errorf("set agent: %w", err)
 	}
 
-	// cache connection for reuse
-	// s.connectionCache[idx] = gs
-
 	if err := gs.Connect(); err != nil {
 		return nil, fmt.Errorf("setting up connection: %w", err)
 	}
@@ -788,9 +905,34 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		gs.Logger = gosnmp.NewLogger(ls)
 	}
 
+	if s.ReuseConnections {
+		// the wrapper reconnects on error, so a cached connection
+		// recovers from agents restarting
+		s.connMu.Lock()
+		s.connectionCache[idx] = gs
+		s.connMu.Unlock()
+	}
+
 	return gs, nil
 }
 
+// Close closes the connections kept open between gathers with
+// reuse_connections.
+func (s *Snmp) Close() error {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	for i, gs := range s.connectionCache {
+		if gs == nil {
+			continue
+		}
+		if err := gs.Close(); err != nil {
+			s.Log.Errorf("closing snmp conn: %s (%s)", err, gs.Host())
+		}
+		s.connectionCache[i] = nil
+	}
+	return nil
+}
+
 // fieldConvert converts from any type according to the conv specification
 //  "float"/"float(0)" will convert the value into a float.
 //  "float(X)" will convert the value into a float, and then move the decimal before Xth right-most digit.
@@ -1174,6 +1316,12 @@ func snmpTranslateCall(oid string) *TranslateItem {
 
 	var err error
 	var out []byte
+	if lite.MIBsDisabled() {
+		if strings.ContainsAny(oid, ":abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			return &TranslateItem{err: lite.MIBNotAllowed(oid)}
+		}
+		return stc
+	}
 	if strings.ContainsAny(oid, ":abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
 		out, err = execCmd("snmptranslate", "-Td", "-Ob", oid)
 	} else {
@@ -25,7 +25,7 @@ func mockExecCommand(arg0 string, args ...string) *exec.Cmd {
 //
 // Idea based on https://github.com/golang/go/blob/7c31043/src/os/exec/exec_test.go#L568
 func TestMockExecCommand(t *testing.T) {
-	cmd := make([]string, 0, len(os.Args))
+	var cmd []string
 	for _, arg := range os.Args {
 		if arg == "--" {
 			cmd = []string{}
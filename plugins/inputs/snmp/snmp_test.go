@@ -11,7 +11,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/internal/snmp"
 	config "github.com/circonus-labs/circonus-unified-agent/internal/snmp"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
@@ -25,6 +27,7 @@ import (
 type testSNMPConnection struct {
 	values map[string]interface{}
 	host   string
+	closed int
 }
 
 func (tsc *testSNMPConnection) Host() string {
@@ -32,6 +35,7 @@ func (tsc *testSNMPConnection) Host() string {
 }
 
 func (tsc *testSNMPConnection) Close() error {
+	tsc.closed++
 	return nil
 }
 
@@ -353,7 +357,8 @@ func TestGetSNMPConnection_v3(t *testing.T) {
 
 func TestGetSNMPConnection_caching(t *testing.T) {
 	s := &Snmp{
-		Agents: []string{"1.2.3.4", "1.2.3.5", "1.2.3.5"},
+		Agents:           []string{"1.2.3.4", "1.2.3.5", "1.2.3.5"},
+		ReuseConnections: true,
 	}
 	err := s.init()
 	require.NoError(t, err)
@@ -370,6 +375,39 @@ func TestGetSNMPConnection_caching(t *testing.T) {
 	assert.False(t, gs3 == gs4)
 }
 
+func TestGetSNMPConnection_noCaching(t *testing.T) {
+	s := &Snmp{
+		Agents: []string{"1.2.3.4"},
+	}
+	require.NoError(t, s.init())
+	gs1, err := s.getConnection(0)
+	require.NoError(t, err)
+	gs2, err := s.getConnection(0)
+	require.NoError(t, err)
+	assert.False(t, gs1 == gs2)
+}
+
+func TestClose(t *testing.T) {
+	var _ cua.ClosingInput = &Snmp{}
+
+	tsc1 := &testSNMPConnection{host: "1.2.3.4"}
+	tsc2 := &testSNMPConnection{host: "1.2.3.5"}
+	s := &Snmp{
+		Agents:           []string{"1.2.3.4", "1.2.3.5", "1.2.3.6"},
+		ReuseConnections: true,
+		connectionCache:  []snmpConnection{tsc1, tsc2, nil},
+		Log:              testutil.Logger{},
+	}
+	require.NoError(t, s.Close())
+	assert.Equal(t, 1, tsc1.closed)
+	assert.Equal(t, 1, tsc2.closed)
+	assert.Equal(t, []snmpConnection{nil, nil, nil}, s.connectionCache)
+
+	// closed connections are not closed again
+	require.NoError(t, s.Close())
+	assert.Equal(t, 1, tsc1.closed)
+}
+
 func TestGosnmpWrapper_walk_retry(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping test due to random failures.")
@@ -650,7 +688,8 @@ func TestGather(t *testing.T) {
 	_ = s.Gather(context.Background(), acc)
 	tstop := time.Now()
 
-	require.Len(t, acc.Metrics, 2)
+	// the two tables plus the gather statistics
+	require.Len(t, acc.Metrics, 3)
 
 	m := acc.Metrics[0]
 	assert.Equal(t, "mytable", m.Measurement)
@@ -659,8 +698,8 @@ func TestGather(t *testing.T) {
 	assert.Len(t, m.Fields, 2)
 	assert.Equal(t, 234, m.Fields["myfield2"])
 	assert.Equal(t, "baz", m.Fields["myfield3"])
-	assert.True(t, !tstart.Before(m.Time))
-	assert.True(t, !tstop.After(m.Time))
+	assert.True(t, !tstart.After(m.Time))
+	assert.True(t, !tstop.Before(m.Time))
 
 	m2 := acc.Metrics[1]
 	assert.Equal(t, "myOtherTable", m2.Measurement)
@@ -696,11 +735,72 @@ func TestGather_host(t *testing.T) {
 
 	_ = s.Gather(context.Background(), acc)
 
-	require.Len(t, acc.Metrics, 1)
+	require.Len(t, acc.Metrics, 2)
 	m := acc.Metrics[0]
 	assert.Equal(t, "baz", m.Tags["host"])
 }
 
+type countingSNMPConnection struct {
+	*testSNMPConnection
+	gets    int
+	maxOids int
+}
+
+func (csc *countingSNMPConnection) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	csc.gets++
+	if len(oids) > csc.maxOids {
+		csc.maxOids = len(oids)
+	}
+	return csc.testSNMPConnection.Get(oids)
+}
+
+func TestTableBuild_batchedGet(t *testing.T) {
+	tbl := Table{Name: "mytable"}
+	for i := 0; i < 2*gosnmp.MaxOids+10; i++ {
+		tbl.Fields = append(tbl.Fields, Field{
+			Name: fmt.Sprintf("f%d", i),
+			Oid:  fmt.Sprintf(".1.0.0.1.2.%d", i),
+		})
+	}
+	tbl.Fields = append(tbl.Fields, Field{Name: "myfield2", Oid: ".1.0.0.1.2"})
+
+	csc := &countingSNMPConnection{testSNMPConnection: tsc}
+	tb, err := tbl.Build(csc, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, csc.gets)
+	assert.Equal(t, gosnmp.MaxOids, csc.maxOids)
+	require.Len(t, tb.Rows, 1)
+	assert.Equal(t, map[string]interface{}{"myfield2": 234}, tb.Rows[0].Fields)
+}
+
+func TestGather_agentConcurrency(t *testing.T) {
+	s := &Snmp{
+		Agents: []string{"a1", "a2", "a3"},
+		Name:   "mytable",
+		Fields: []Field{
+			{
+				Name: "myfield2",
+				Oid:  ".1.0.0.1.2",
+			},
+		},
+		AgentConcurrency: 2,
+
+		connectionCache: []snmpConnection{tsc, tsc, tsc},
+		initialized:     true,
+	}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Gather(context.Background(), acc))
+
+	// one metric per agent plus the gather statistics
+	require.Len(t, acc.Metrics, 4)
+	for _, m := range acc.Metrics[:3] {
+		assert.Equal(t, "mytable", m.Measurement)
+		assert.Equal(t, 234, m.Fields["myfield2"])
+	}
+}
+
 func TestFieldConvert(t *testing.T) {
 	testTable := []struct {
 		expected interface{}
@@ -870,6 +970,20 @@ func TestSnmpTranslateCache_miss(t *testing.T) {
 	assert.Equal(t, stc1.err, stc2.err)
 }
 
+func TestSnmpTranslateCall_mibsDisabled(t *testing.T) {
+	lite.SetMIBsDisabled(true)
+	defer lite.SetMIBsDisabled(false)
+
+	stc := snmpTranslateCall(".1.3.6.1.2.1.2.2.1.6")
+	require.NoError(t, stc.err)
+	assert.Equal(t, ".1.3.6.1.2.1.2.2.1.6", stc.oidNum)
+	assert.Equal(t, ".1.3.6.1.2.1.2.2.1.6", stc.oidText)
+
+	stc = snmpTranslateCall("IF-MIB::ifPhysAddress")
+	require.Error(t, stc.err)
+	assert.Contains(t, stc.err.Error(), "disable_mibs")
+}
+
 func TestSnmpTranslateCache_hit(t *testing.T) {
 	snmpTranslateCache = map[string]TranslateItem{
 		"foo": {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
+	"github.com/circonus-labs/circonus-unified-agent/internal/lite"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"github.com/gosnmp/gosnmp"
 )
@@ -43,6 +46,10 @@ type SnmpTrap struct {
 	PrivProtocol string `toml:"priv_protocol"`
 	PrivPassword string `toml:"priv_password"`
 
+	// Add the traps as events rather than metrics
+	Events        bool   `toml:"events"`
+	EventSeverity string `toml:"event_severity"`
+
 	acc      cua.Accumulator
 	listener *gosnmp.TrapListener
 	timeFunc func() time.Time
@@ -55,6 +62,8 @@ type SnmpTrap struct {
 	cacheLock sync.Mutex
 	cache     map[string]mibEntry
 
+	severity cua.Severity
+
 	execCmd execer
 }
 
@@ -87,6 +96,13 @@ var sampleConfig = `
   # priv_protocol = ""
   ## Privacy password used for encrypted messages.
   # priv_password = ""
+
+  ## Add each trap as an event titled after the trap, e.g.
+  ## "SNMPv2-MIB::coldStart", with the trap variables as its body, rather
+  ## than as a metric.
+  # events = false
+  ## Severity of the events; one of "info", "warning", "error" or "critical".
+  # event_severity = "warning"
 `
 
 func (s *SnmpTrap) SampleConfig() string {
@@ -108,6 +124,7 @@ func init() {
 			ServiceAddress: "udp://:162",
 			Timeout:        defaultTimeout,
 			Version:        "2c",
+			EventSeverity:  "warning",
 		}
 	})
 }
@@ -124,11 +141,45 @@ func realExecCmd(timeout internal.Duration, arg0 string, args ...string) ([]byte
 }
 
 func (s *SnmpTrap) Init() error {
+	if fips.Enabled() && s.Version == "3" {
+		if strings.EqualFold(s.AuthProtocol, "md5") {
+			return fips.NotApproved("authentication protocol", s.AuthProtocol)
+		}
+		if strings.EqualFold(s.PrivProtocol, "des") {
+			return fips.NotApproved("privacy protocol", s.PrivProtocol)
+		}
+	}
+
+	if s.Events {
+		severity, err := cua.ParseSeverity(s.EventSeverity)
+		if err != nil {
+			return fmt.Errorf("event_severity: %w", err)
+		}
+		s.severity = severity
+	}
+
 	s.cache = map[string]mibEntry{}
 	s.execCmd = realExecCmd
 	return nil
 }
 
+// Capabilities answers CAP_NET_BIND_SERVICE when listening on a privileged
+// port, e.g. the default 162
+func (s *SnmpTrap) Capabilities() []string {
+	split := strings.SplitN(s.ServiceAddress, "://", 2)
+	if len(split) != 2 {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(split[1])
+	if err != nil {
+		return nil
+	}
+	if p, err := strconv.Atoi(port); err != nil || p == 0 || p >= 1024 {
+		return nil
+	}
+	return []string{"CAP_NET_BIND_SERVICE"}
+}
+
 func (s *SnmpTrap) Start(ctx context.Context, acc cua.Accumulator) error {
 	s.acc = acc
 	s.listener = gosnmp.NewTrapListener()
@@ -358,14 +409,47 @@ func makeTrapHandler(s *SnmpTrap) gosnmp.TrapHandlerFunc {
 			s.Log.Errorf("parsing packet: %+v", packet)
 			return
 		}
+		if s.Events {
+			s.acc.AddEvent(trapTitle(tags, metricName), trapBody(fields), s.severity, tags, tm)
+			return
+		}
 		fields[metricName] = 1
 		s.acc.AddFields("snmp_trap", fields, tags, tm)
 	}
 }
 
+// trapTitle answers the title of the event of a trap, the trap name
+// qualified by its MIB
+func trapTitle(tags map[string]string, name string) string {
+	if mib := tags["mib"]; mib != "" {
+		return mib + "::" + name
+	}
+	return name
+}
+
+// trapBody answers the body of the event of a trap, a line per trap
+// variable in name order
+func trapBody(fields map[string]interface{}) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%v\n", name, fields[name])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 func (s *SnmpTrap) lookup(oid string) (e mibEntry, err error) {
 	s.cacheLock.Lock()
 	defer s.cacheLock.Unlock()
+	if lite.MIBsDisabled() {
+		// numeric OIDs only
+		return mibEntry{oidText: oid}, nil
+	}
 	var ok bool
 	if e, ok = s.cache[oid]; !ok {
 		// cache miss.  exec snmptranslate
@@ -11,6 +11,7 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/internal/fips"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/gosnmp/gosnmp"
 	"github.com/stretchr/testify/require"
@@ -1318,3 +1319,75 @@ func TestReceiveTrap(t *testing.T) {
 	}
 
 }
+
+func TestCapabilities(t *testing.T) {
+	s := &SnmpTrap{ServiceAddress: "udp://:162"}
+	require.Equal(t, []string{"CAP_NET_BIND_SERVICE"}, s.Capabilities())
+
+	s.ServiceAddress = "udp://127.0.0.1:1162"
+	require.Empty(t, s.Capabilities())
+}
+
+func TestFIPSMode(t *testing.T) {
+	fips.Set(true)
+	defer fips.Set(false)
+
+	s := &SnmpTrap{Version: "3", AuthProtocol: "MD5", PrivProtocol: "AES"}
+	require.Error(t, s.Init())
+	s = &SnmpTrap{Version: "3", AuthProtocol: "SHA", PrivProtocol: "DES"}
+	require.Error(t, s.Init())
+	s = &SnmpTrap{Version: "3", AuthProtocol: "SHA", PrivProtocol: "AES"}
+	require.NoError(t, s.Init())
+}
+
+func TestTrapEvents(t *testing.T) {
+	fakeTime := time.Unix(456456456, 456)
+
+	s := &SnmpTrap{
+		Events:        true,
+		EventSeverity: "critical",
+		timeFunc: func() time.Time {
+			return fakeTime
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, s.Init())
+	s.execCmd = fakeExecCmd
+	s.load(".1.3.6.1.6.3.1.1.5.1", mibEntry{"SNMPv2-MIB", "coldStart"})
+
+	var acc testutil.Accumulator
+	s.acc = &acc
+	makeTrapHandler(s)(&gosnmp.SnmpPacket{
+		Version: gosnmp.Version2c,
+		Variables: []gosnmp.SnmpPDU{
+			{
+				Name:  ".1.3.6.1.6.3.1.1.4.1.0", // SNMPv2-MIB::snmpTrapOID.0
+				Type:  gosnmp.ObjectIdentifier,
+				Value: ".1.3.6.1.6.3.1.1.5.1", // coldStart
+			},
+		},
+	}, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+
+	testutil.RequireMetricsEqual(t,
+		[]cua.Metric{
+			testutil.MustMetric(
+				"event",
+				map[string]string{
+					"oid":     ".1.3.6.1.6.3.1.1.5.1",
+					"mib":     "SNMPv2-MIB",
+					"version": "2c",
+					"source":  "127.0.0.1",
+				},
+				map[string]interface{}{
+					"title":    "SNMPv2-MIB::coldStart",
+					"severity": "critical",
+				},
+				fakeTime,
+				cua.Event,
+			),
+		},
+		acc.GetCUAMetrics())
+
+	s.EventSeverity = "loud"
+	require.Error(t, s.Init())
+}
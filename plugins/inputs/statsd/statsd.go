@@ -190,6 +190,8 @@ type Statsd struct {
 	ReadBufferSize         int  `toml:"read_buffer_size"`
 	DataDogExtensions      bool `toml:"datadog_extensions"`
 	TCPKeepAlive           bool `toml:"tcp_keep_alive"`
+
+	circmgr.MetricNaming // direct metrics: name_override, name_prefix, name_suffix
 }
 
 type input struct {
@@ -737,6 +739,9 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		for k, v := range m.tags {
 			m.mtags = append(m.mtags, trapmetrics.Tag{Category: k, Value: v})
 		}
+		if group := s.Measurement("statsd"); group != "statsd" {
+			m.mtags = append(m.mtags, trapmetrics.Tag{Category: "input_metric_group", Value: group})
+		}
 
 		// // Make a unique key for the measurement name/tags
 		// var tg []string
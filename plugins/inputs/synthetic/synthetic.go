@@ -0,0 +1,152 @@
+package synthetic
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Measurements generated, named synthetic_0, synthetic_1, ...
+  # measurements = 1
+
+  ## Series per measurement, told apart by their "series" tag; each gather
+  ## generates measurements * series metrics.
+  # series = 100
+
+  ## Tags per series besides "series", tag_0, tag_1, ... each with up to
+  ## tag_values distinct values
+  # tags = 2
+  # tag_values = 10
+
+  ## Fields per metric, field_0, field_1, ...
+  # fields = 5
+
+  ## Type of the metrics, "gauge" with random float values or "counter" with
+  ## increasing integer values
+  # metric_type = "gauge"
+`
+
+const (
+	metricTypeGauge   = "gauge"
+	metricTypeCounter = "counter"
+)
+
+// Synthetic generates metrics of a configurable volume and cardinality, e.g.
+// to size the buffers, CPU and brokers of the agent before adding plugins
+// that gather many metrics
+type Synthetic struct {
+	Measurements int    `toml:"measurements"`
+	Series       int    `toml:"series"`
+	Tags         int    `toml:"tags"`
+	TagValues    int    `toml:"tag_values"`
+	Fields       int    `toml:"fields"`
+	MetricType   string `toml:"metric_type"`
+
+	Log cua.Logger `toml:"-"`
+
+	names     []string
+	tagKeys   []string
+	fieldKeys []string
+	rand      *rand.Rand
+	gathers   int64
+}
+
+// Description answers a description of this input plugin
+func (*Synthetic) Description() string {
+	return "Generate metrics of a configurable volume and cardinality for capacity testing"
+}
+
+// SampleConfig answers a sample configuration
+func (*Synthetic) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Synthetic) Init() error {
+	for name, n := range map[string]int{
+		"measurements": s.Measurements,
+		"series":       s.Series,
+		"tag_values":   s.TagValues,
+		"fields":       s.Fields,
+	} {
+		if n <= 0 {
+			return fmt.Errorf("invalid %s %d, must be 1 or more", name, n)
+		}
+	}
+	if s.Tags < 0 {
+		return fmt.Errorf("invalid tags %d, must be 0 or more", s.Tags)
+	}
+	switch s.MetricType {
+	case metricTypeGauge, metricTypeCounter:
+	default:
+		return fmt.Errorf("invalid metric_type %q, must be gauge or counter", s.MetricType)
+	}
+
+	s.names = keys("synthetic_", s.Measurements)
+	s.tagKeys = keys("tag_", s.Tags)
+	s.fieldKeys = keys("field_", s.Fields)
+	s.rand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	s.Log.Debugf("Generating %d metrics each gather", s.Measurements*s.Series)
+	return nil
+}
+
+func (s *Synthetic) Gather(ctx context.Context, acc cua.Accumulator) error {
+	s.gathers++
+	now := time.Now()
+	for _, name := range s.names {
+		for i := 0; i < s.Series; i++ {
+			if ctx.Err() != nil {
+				return nil
+			}
+			tags := make(map[string]string, len(s.tagKeys)+1)
+			tags["series"] = strconv.Itoa(i)
+			for k, key := range s.tagKeys {
+				// spread the series differently over the values of each tag
+				tags[key] = "value_" + strconv.Itoa((i/(k+1))%s.TagValues)
+			}
+			fields := make(map[string]interface{}, len(s.fieldKeys))
+			for k, key := range s.fieldKeys {
+				if s.MetricType == metricTypeCounter {
+					fields[key] = s.gathers * int64(k+1)
+				} else {
+					fields[key] = s.rand.Float64() * 100
+				}
+			}
+			if s.MetricType == metricTypeCounter {
+				acc.AddCounter(name, fields, tags, now)
+			} else {
+				acc.AddGauge(name, fields, tags, now)
+			}
+		}
+	}
+	return nil
+}
+
+// keys answers n keys with the prefix, numbered from 0
+func keys(prefix string, n int) []string {
+	k := make([]string, n)
+	for i := range k {
+		k[i] = prefix + strconv.Itoa(i)
+	}
+	return k
+}
+
+func init() {
+	inputs.Add("synthetic", func() cua.Input {
+		return &Synthetic{
+			Measurements: 1,
+			Series:       100,
+			Tags:         2,
+			TagValues:    10,
+			Fields:       5,
+			MetricType:   metricTypeGauge,
+		}
+	})
+}
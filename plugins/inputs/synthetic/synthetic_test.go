@@ -0,0 +1,80 @@
+package synthetic
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSynthetic() *Synthetic {
+	s := inputs.Inputs["synthetic"]().(*Synthetic)
+	s.Log = testutil.Logger{}
+	return s
+}
+
+func TestGather(t *testing.T) {
+	s := newTestSynthetic()
+	s.Measurements = 2
+	s.Series = 50
+	s.Tags = 3
+	s.TagValues = 4
+	s.Fields = 2
+	require.NoError(t, s.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.Len(t, acc.Metrics, 100)
+
+	series := make(map[string]bool)
+	values := make(map[string]map[string]bool)
+	for _, m := range acc.Metrics {
+		require.Equal(t, cua.Gauge, m.Type)
+		require.Len(t, m.Tags, 4)
+		require.Len(t, m.Fields, 2)
+		series[m.Measurement+"/"+m.Tags["series"]] = true
+		for k, v := range m.Tags {
+			if values[k] == nil {
+				values[k] = make(map[string]bool)
+			}
+			values[k][v] = true
+		}
+	}
+	require.Len(t, series, 100)
+	require.Len(t, values["series"], 50)
+	for _, k := range []string{"tag_0", "tag_1", "tag_2"} {
+		require.Len(t, values[k], 4)
+	}
+}
+
+func TestGatherCounter(t *testing.T) {
+	s := newTestSynthetic()
+	s.Series = 1
+	s.Fields = 2
+	s.MetricType = metricTypeCounter
+	require.NoError(t, s.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.Len(t, acc.Metrics, 2)
+	require.Equal(t, cua.Counter, acc.Metrics[1].Type)
+	require.Equal(t, map[string]interface{}{"field_0": int64(2), "field_1": int64(4)}, acc.Metrics[1].Fields)
+}
+
+func TestInit(t *testing.T) {
+	s := newTestSynthetic()
+	s.Series = 0
+	require.Error(t, s.Init())
+	s = newTestSynthetic()
+	s.Tags = -1
+	require.Error(t, s.Init())
+	s = newTestSynthetic()
+	s.MetricType = "histogram"
+	require.Error(t, s.Init())
+	s = newTestSynthetic()
+	s.Tags = 0
+	require.NoError(t, s.Init())
+}
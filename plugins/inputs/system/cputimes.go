@@ -0,0 +1,10 @@
+//go:build !darwin || cgo
+// +build !darwin cgo
+
+package system
+
+import "github.com/shirou/gopsutil/v3/cpu"
+
+func readCPUTimes(perCPU bool) ([]cpu.TimesStat, error) {
+	return cpu.Times(perCPU)
+}
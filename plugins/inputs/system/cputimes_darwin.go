@@ -0,0 +1,92 @@
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package system
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// gopsutil only reads the cpu times of darwin with cgo, which the release
+// builds are without; the mach calls are made through libSystem directly,
+// the way golang.org/x/sys/unix calls libc on darwin
+
+//go:linkname syscall_syscall syscall.syscall
+func syscall_syscall(fn, a1, a2, a3 uintptr) (r1, r2 uintptr, err uintptr)
+
+//go:linkname syscall_syscall6 syscall.syscall6
+func syscall_syscall6(fn, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2 uintptr, err uintptr)
+
+var (
+	libc_mach_host_self_trampoline_addr       uintptr
+	libc_mach_task_self_trampoline_addr       uintptr
+	libc_host_processor_info_trampoline_addr  uintptr
+	libc_vm_deallocate_trampoline_addr        uintptr
+	libc_mach_port_deallocate_trampoline_addr uintptr
+)
+
+//go:cgo_import_dynamic libc_mach_host_self mach_host_self "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_mach_task_self mach_task_self "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_host_processor_info host_processor_info "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_vm_deallocate vm_deallocate "/usr/lib/libSystem.B.dylib"
+//go:cgo_import_dynamic libc_mach_port_deallocate mach_port_deallocate "/usr/lib/libSystem.B.dylib"
+
+const (
+	processorCPULoadInfo = 2 // PROCESSOR_CPU_LOAD_INFO
+	cpuStateMax          = 4 // CPU_STATE_MAX
+	cpuStateUser         = 0
+	cpuStateSystem       = 1
+	cpuStateIdle         = 2
+	cpuStateNice         = 3
+	clocksPerSec         = 100 // CLK_TCK
+)
+
+// readCPUTimes answers the times of each processor with host_processor_info,
+// or their sum as cpu-total
+func readCPUTimes(perCPU bool) ([]cpu.TimesStat, error) {
+	host, _, _ := syscall_syscall(libc_mach_host_self_trampoline_addr, 0, 0, 0)
+	task, _, _ := syscall_syscall(libc_mach_task_self_trampoline_addr, 0, 0, 0)
+	defer func() { _, _, _ = syscall_syscall(libc_mach_port_deallocate_trampoline_addr, task, host, 0) }()
+
+	var (
+		count   uint32
+		info    unsafe.Pointer
+		infoCnt uint32
+	)
+	kr, _, _ := syscall_syscall6(libc_host_processor_info_trampoline_addr,
+		host, processorCPULoadInfo,
+		uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&infoCnt)), 0)
+	if int32(kr) != 0 {
+		return nil, fmt.Errorf("host_processor_info: kern_return_t %d", int32(kr))
+	}
+	defer func() {
+		_, _, _ = syscall_syscall(libc_vm_deallocate_trampoline_addr, task, uintptr(info), uintptr(infoCnt)*4)
+	}()
+
+	n := count * cpuStateMax
+	ticks := (*[1 << 20]uint32)(info)[:n:n] //nolint:gosec
+	times := make([]cpu.TimesStat, 0, count)
+	total := cpu.TimesStat{CPU: "cpu-total"}
+	for i := uint32(0); i < count; i++ {
+		t := ticks[i*cpuStateMax : (i+1)*cpuStateMax]
+		ts := cpu.TimesStat{
+			CPU:    fmt.Sprintf("cpu%d", i),
+			User:   float64(t[cpuStateUser]) / clocksPerSec,
+			System: float64(t[cpuStateSystem]) / clocksPerSec,
+			Idle:   float64(t[cpuStateIdle]) / clocksPerSec,
+			Nice:   float64(t[cpuStateNice]) / clocksPerSec,
+		}
+		times = append(times, ts)
+		total.User += ts.User
+		total.System += ts.System
+		total.Idle += ts.Idle
+		total.Nice += ts.Nice
+	}
+	if !perCPU {
+		return []cpu.TimesStat{total}, nil
+	}
+	return times, nil
+}
@@ -0,0 +1,10 @@
+//go:build !darwin || cgo
+// +build !darwin cgo
+
+package system
+
+import "github.com/shirou/gopsutil/v3/disk"
+
+func readDiskIO(names []string) (map[string]disk.IOCountersStat, error) {
+	return disk.IOCounters(names...)
+}
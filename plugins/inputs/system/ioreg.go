@@ -0,0 +1,163 @@
+package system
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// parseIORegDiskIO answers the io counters of the whole disks in the plist
+// output of `ioreg -a -r -d 2 -c IOBlockStorageDriver`: each driver holds
+// the statistics, its IOMedia child the BSD name of the disk
+func parseIORegDiskIO(data []byte, names []string) (map[string]disk.IOCountersStat, error) {
+	v, err := parsePlist(data)
+	if err != nil {
+		return nil, err
+	}
+	drivers, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ioreg: no array of drivers")
+	}
+
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+	}
+
+	ret := make(map[string]disk.IOCountersStat)
+	for _, d := range drivers {
+		driver, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stats, ok := driver["Statistics"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		children, _ := driver["IORegistryEntryChildren"].([]interface{})
+		var name string
+		for _, c := range children {
+			if media, ok := c.(map[string]interface{}); ok {
+				if name, ok = media["BSD Name"].(string); ok {
+					break
+				}
+			}
+		}
+		if name == "" || (len(want) > 0 && !want[name]) {
+			continue
+		}
+		stat := func(key string) uint64 {
+			n, _ := stats[key].(uint64)
+			return n
+		}
+		ret[name] = disk.IOCountersStat{
+			Name:       name,
+			ReadCount:  stat("Operations (Read)"),
+			WriteCount: stat("Operations (Write)"),
+			ReadBytes:  stat("Bytes (Read)"),
+			WriteBytes: stat("Bytes (Write)"),
+			ReadTime:   stat("Total Time (Read)") / 1e6, // ns to ms
+			WriteTime:  stat("Total Time (Write)") / 1e6,
+		}
+	}
+	return ret, nil
+}
+
+// parsePlist parses an XML property list into maps, slices, strings,
+// uint64 or int64 integers, float64 reals and bools
+func parsePlist(data []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local != "plist" {
+			return plistValue(dec, se)
+		}
+	}
+}
+
+func plistValue(dec *xml.Decoder, se xml.StartElement) (interface{}, error) {
+	switch se.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("plist dict: %w", err)
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := dec.DecodeElement(&key, &t); err != nil {
+						return nil, fmt.Errorf("plist key: %w", err)
+					}
+					continue
+				}
+				v, err := plistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []interface{}
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("plist array: %w", err)
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				v, err := plistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, v)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		return se.Name.Local == "true", nil
+	}
+
+	var s string
+	if err := dec.DecodeElement(&s, &se); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("plist %s: %w", se.Name.Local, err)
+	}
+	s = strings.TrimSpace(s)
+	switch se.Name.Local {
+	case "integer":
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist integer: %w", err)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist real: %w", err)
+		}
+		return f, nil
+	}
+	// string, data and date are kept as text
+	return s, nil
+}
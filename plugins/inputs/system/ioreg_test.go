@@ -0,0 +1,81 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/stretchr/testify/require"
+)
+
+const ioregDiskIO = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<array>
+	<dict>
+		<key>IOClass</key>
+		<string>IOBlockStorageDriver</string>
+		<key>IORegistryEntryChildren</key>
+		<array>
+			<dict>
+				<key>BSD Name</key>
+				<string>disk0</string>
+				<key>Size</key>
+				<integer>500277790720</integer>
+				<key>Whole</key>
+				<true/>
+			</dict>
+		</array>
+		<key>Statistics</key>
+		<dict>
+			<key>Bytes (Read)</key>
+			<integer>106397892608</integer>
+			<key>Bytes (Write)</key>
+			<integer>78412406784</integer>
+			<key>Operations (Read)</key>
+			<integer>4187551</integer>
+			<key>Operations (Write)</key>
+			<integer>2066193</integer>
+			<key>Total Time (Read)</key>
+			<integer>1423957370000</integer>
+			<key>Total Time (Write)</key>
+			<integer>711390256000</integer>
+		</dict>
+	</dict>
+	<dict>
+		<key>IOClass</key>
+		<string>IOBlockStorageDriver</string>
+		<key>IORegistryEntryChildren</key>
+		<array>
+			<dict>
+				<key>BSD Name</key>
+				<string>disk4</string>
+			</dict>
+		</array>
+		<key>Statistics</key>
+		<dict>
+			<key>Bytes (Read)</key>
+			<integer>1024</integer>
+		</dict>
+	</dict>
+</array>
+</plist>
+`
+
+func TestParseIORegDiskIO(t *testing.T) {
+	m, err := parseIORegDiskIO([]byte(ioregDiskIO), nil)
+	require.NoError(t, err)
+	require.Len(t, m, 2)
+	require.Equal(t, disk.IOCountersStat{
+		Name:       "disk0",
+		ReadCount:  4187551,
+		WriteCount: 2066193,
+		ReadBytes:  106397892608,
+		WriteBytes: 78412406784,
+		ReadTime:   1423957,
+		WriteTime:  711390,
+	}, m["disk0"])
+
+	m, err = parseIORegDiskIO([]byte(ioregDiskIO), []string{"disk4"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]disk.IOCountersStat{"disk4": {Name: "disk4", ReadBytes: 1024}}, m)
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !freebsd
+// +build !darwin,!freebsd
+
+package system
+
+import "github.com/shirou/gopsutil/v3/net"
+
+func readNetProto() ([]net.ProtoCountersStat, error) {
+	return net.ProtoCounters(nil)
+}
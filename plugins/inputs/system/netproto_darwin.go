@@ -0,0 +1,44 @@
+//go:build darwin
+// +build darwin
+
+package system
+
+import "github.com/shirou/gopsutil/v3/net"
+
+// readNetProto answers the tcp and udp counters of the struct tcpstat and
+// udpstat sysctls, named like the linux snmp counters
+func readNetProto() ([]net.ProtoCountersStat, error) {
+	tcp, err := sysctlCounters("net.inet.tcp.stats", 4, 30)
+	if err != nil {
+		return nil, err
+	}
+	udp, err := sysctlCounters("net.inet.udp.stats", 4, 10)
+	if err != nil {
+		return nil, err
+	}
+	return []net.ProtoCountersStat{
+		{
+			Protocol: "Tcp",
+			Stats: map[string]int64{
+				"ActiveOpens":  tcp[0],            // tcps_connattempt
+				"PassiveOpens": tcp[1],            // tcps_accepts
+				"EstabResets":  tcp[3],            // tcps_drops
+				"AttemptFails": tcp[4],            // tcps_conndrops
+				"OutSegs":      tcp[15],           // tcps_sndtotal
+				"RetransSegs":  tcp[18],           // tcps_sndrexmitpack
+				"InSegs":       tcp[25],           // tcps_rcvtotal
+				"InErrs":       tcp[28] + tcp[29], // tcps_rcvbadsum, tcps_rcvbadoff
+			},
+		},
+		{
+			Protocol: "Udp",
+			Stats: map[string]int64{
+				"InDatagrams":  udp[0],                   // udps_ipackets
+				"InErrors":     udp[1] + udp[2] + udp[3], // udps_hdrops, udps_badsum, udps_badlen
+				"NoPorts":      udp[4],                   // udps_noport
+				"RcvbufErrors": udp[6],                   // udps_fullsock
+				"OutDatagrams": udp[9],                   // udps_opackets
+			},
+		},
+	}, nil
+}
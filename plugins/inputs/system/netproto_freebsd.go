@@ -0,0 +1,45 @@
+//go:build freebsd
+// +build freebsd
+
+package system
+
+import "github.com/shirou/gopsutil/v3/net"
+
+// readNetProto answers the tcp and udp counters of the struct tcpstat and
+// udpstat sysctls, uint64_t since FreeBSD 11, named like the linux snmp
+// counters
+func readNetProto() ([]net.ProtoCountersStat, error) {
+	tcp, err := sysctlCounters("net.inet.tcp.stats", 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	udp, err := sysctlCounters("net.inet.udp.stats", 8, 11)
+	if err != nil {
+		return nil, err
+	}
+	return []net.ProtoCountersStat{
+		{
+			Protocol: "Tcp",
+			Stats: map[string]int64{
+				"ActiveOpens":  tcp[0],            // tcps_connattempt
+				"PassiveOpens": tcp[1],            // tcps_accepts
+				"EstabResets":  tcp[3],            // tcps_drops
+				"AttemptFails": tcp[4],            // tcps_conndrops
+				"OutSegs":      tcp[16],           // tcps_sndtotal
+				"RetransSegs":  tcp[19],           // tcps_sndrexmitpack
+				"InSegs":       tcp[27],           // tcps_rcvtotal
+				"InErrs":       tcp[30] + tcp[31], // tcps_rcvbadsum, tcps_rcvbadoff
+			},
+		},
+		{
+			Protocol: "Udp",
+			Stats: map[string]int64{
+				"InDatagrams":  udp[0],                   // udps_ipackets
+				"InErrors":     udp[1] + udp[2] + udp[4], // udps_hdrops, udps_badsum, udps_badlen
+				"NoPorts":      udp[5],                   // udps_noport
+				"RcvbufErrors": udp[7],                   // udps_fullsock
+				"OutDatagrams": udp[10],                  // udps_opackets
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,97 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// PressureStat is a line of a Linux pressure stall information (PSI) file,
+// the share of time some or all (full) tasks stalled on a resource
+type PressureStat struct {
+	Type   string // some or full
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64 // total stall time in microseconds
+}
+
+// ReadPressure reads the pressure stall information of the resource, one of
+// cpu, io or memory, from /proc/pressure (or $HOST_PROC/pressure)
+func ReadPressure(resource string) ([]PressureStat, error) {
+	procPath := "/proc"
+	if v := os.Getenv("HOST_PROC"); v != "" {
+		procPath = v
+	}
+	data, err := os.ReadFile(filepath.Join(procPath, "pressure", resource))
+	if err != nil {
+		return nil, fmt.Errorf("pressure: %w", err)
+	}
+	return parsePressure(data)
+}
+
+// parsePressure parses lines like
+// "some avg10=0.12 avg60=0.05 avg300=0.01 total=123456"
+func parsePressure(data []byte) ([]PressureStat, error) {
+	var stats []PressureStat
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) == 0 {
+			continue
+		}
+		stat := PressureStat{Type: f[0]}
+		for _, kv := range f[1:] {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("pressure: invalid value %q", kv)
+			}
+			var err error
+			switch parts[0] {
+			case "avg10":
+				stat.Avg10, err = strconv.ParseFloat(parts[1], 64)
+			case "avg60":
+				stat.Avg60, err = strconv.ParseFloat(parts[1], 64)
+			case "avg300":
+				stat.Avg300, err = strconv.ParseFloat(parts[1], 64)
+			case "total":
+				stat.Total, err = strconv.ParseUint(parts[1], 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pressure %s: %w", parts[0], err)
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// AddPressure adds the pressure stall information of the resource as the
+// pressure measurement tagged with the resource and type
+func AddPressure(acc cua.Accumulator, resource string) error {
+	stats, err := ReadPressure(resource)
+	if err != nil {
+		return err
+	}
+	for _, stat := range stats {
+		tags := map[string]string{
+			"resource": resource,
+			"type":     stat.Type,
+		}
+		acc.AddGauge("pressure", map[string]interface{}{
+			"avg10":  stat.Avg10,
+			"avg60":  stat.Avg60,
+			"avg300": stat.Avg300,
+		}, tags)
+		acc.AddCounter("pressure", map[string]interface{}{
+			"total": stat.Total,
+		}, tags)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePressure(t *testing.T) {
+	stats, err := parsePressure([]byte(`some avg10=1.25 avg60=0.50 avg300=0.10 total=123456
+full avg10=0.00 avg60=0.00 avg300=0.00 total=789
+`))
+	require.NoError(t, err)
+	require.Equal(t, []PressureStat{
+		{Type: "some", Avg10: 1.25, Avg60: 0.5, Avg300: 0.1, Total: 123456},
+		{Type: "full", Total: 789},
+	}, stats)
+
+	_, err = parsePressure([]byte("some avg10=x\n"))
+	require.Error(t, err)
+}
@@ -54,14 +54,14 @@ type SysPSDisk struct{}
 func (s *SysPS) CPUTimes(perCPU, totalCPU bool) ([]cpu.TimesStat, error) {
 	var cpuTimes []cpu.TimesStat
 	if perCPU {
-		if perCPUTimes, err := cpu.Times(true); err == nil {
+		if perCPUTimes, err := readCPUTimes(true); err == nil {
 			cpuTimes = append(cpuTimes, perCPUTimes...)
 		} else {
 			return nil, fmt.Errorf("cpu times (percpu): %w", err)
 		}
 	}
 	if totalCPU {
-		if totalCPUTimes, err := cpu.Times(false); err == nil {
+		if totalCPUTimes, err := readCPUTimes(false); err == nil {
 			cpuTimes = append(cpuTimes, totalCPUTimes...)
 		} else {
 			return nil, fmt.Errorf("cpu times (tot): %w", err)
@@ -142,7 +142,7 @@ func (s *SysPS) DiskUsage(
 }
 
 func (s *SysPS) NetProto() ([]net.ProtoCountersStat, error) {
-	return net.ProtoCounters(nil)
+	return readNetProto()
 }
 
 func (s *SysPS) NetIO() ([]net.IOCountersStat, error) {
@@ -154,7 +154,7 @@ func (s *SysPS) NetConnections() ([]net.ConnectionStat, error) {
 }
 
 func (s *SysPS) DiskIO(names []string) (map[string]disk.IOCountersStat, error) {
-	m, err := disk.IOCounters(names...)
+	m, err := readDiskIO(names)
 	if err != nil {
 		if errors.Is(err, internal.ErrNotImplemented) {
 			return nil, nil
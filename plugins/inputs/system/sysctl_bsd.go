@@ -0,0 +1,32 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package system
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysctlCounters answers the first n counters of a stats struct sysctl,
+// e.g. net.inet.tcp.stats, of size byte counters
+func sysctlCounters(name string, size, n int) ([]int64, error) {
+	buf, err := unix.SysctlRaw(name)
+	if err != nil {
+		return nil, fmt.Errorf("sysctl %s: %w", name, err)
+	}
+	if len(buf) < n*size {
+		return nil, fmt.Errorf("sysctl %s: %d bytes", name, len(buf))
+	}
+	counters := make([]int64, n)
+	for i := range counters {
+		if size == 8 {
+			counters[i] = int64(binary.LittleEndian.Uint64(buf[i*8:]))
+		} else {
+			counters[i] = int64(binary.LittleEndian.Uint32(buf[i*4:]))
+		}
+	}
+	return counters, nil
+}
@@ -420,6 +420,7 @@ func (t *Tail) Stop() {
 			offset, err := tailer.Tell()
 			if err == nil {
 				t.Log.Debugf("Recording offset %d for %q", offset, tailer.Filename)
+				t.offsets[tailer.Filename] = offset
 			} else {
 				t.Log.Errorf("Recording offset for %q: %s", tailer.Filename, err.Error())
 			}
@@ -441,6 +442,23 @@ func (t *Tail) Stop() {
 	offsetsMutex.Unlock()
 }
 
+// GetState answers the offsets of the files, recorded when stopped
+func (t *Tail) GetState() interface{} {
+	return t.offsets
+}
+
+// SetState restores the offsets of the files to resume tailing at
+func (t *Tail) SetState(state interface{}) error {
+	offsetsState, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	for k, v := range offsetsState {
+		t.offsets[k] = v
+	}
+	return nil
+}
+
 func (t *Tail) SetParserFunc(fn parsers.ParserFunc) {
 	t.parserFunc = fn
 }
@@ -574,3 +574,33 @@ func getTestdataDir() string {
 
 	return filepath.Join(dir, "testdata")
 }
+
+func TestTailState(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	first := "cpu usage_idle=100\n"
+	_, err = tmpfile.WriteString(first + "cpu2 usage_idle=200\n")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.Init())
+
+	// resume after the first line
+	require.NoError(t, tt.SetState(map[string]int64{tmpfile.Name(): int64(len(first))}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(context.Background(), &acc))
+	require.NoError(t, acc.GatherError(tt.Gather))
+	acc.Wait(1)
+	tt.Stop()
+
+	require.Equal(t, "cpu2", acc.GetCUAMetrics()[0].Name())
+	info, err := os.Stat(tmpfile.Name())
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{tmpfile.Name(): info.Size()}, tt.GetState())
+}
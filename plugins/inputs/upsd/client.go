@@ -0,0 +1,173 @@
+package upsd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// client speaks the NUT network protocol (docs/net-protocol.txt in the NUT
+// sources) to a upsd server.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(ctx context.Context, address string, timeout time.Duration) (*client, error) {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("dial (%s): %w", address, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("set deadline: %w", err)
+	}
+	return &client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *client) Close() error {
+	// best effort, the server closes the connection after LOGOUT
+	_, _ = c.conn.Write([]byte("LOGOUT\n"))
+	return c.conn.Close() //nolint:wrapcheck
+}
+
+func (c *client) login(username, password string) error {
+	if username != "" {
+		if _, err := c.command("USERNAME " + quote(username)); err != nil {
+			return err
+		}
+	}
+	if password != "" {
+		if _, err := c.command("PASSWORD " + quote(password)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// command sends a single line command and returns its single line response.
+func (c *client) command(cmd string) (string, error) {
+	if _, err := c.conn.Write([]byte(cmd + "\n")); err != nil {
+		return "", fmt.Errorf("write: %w", err)
+	}
+	return c.readLine()
+}
+
+func (c *client) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "ERR ") {
+		return "", errors.New(strings.ToLower(strings.TrimPrefix(line, "ERR ")))
+	}
+	return line, nil
+}
+
+// list sends LIST <query> and returns the tokens of every item in the
+// response, without the leading item type.
+func (c *client) list(query string) ([][]string, error) {
+	begin, err := c.command("LIST " + query)
+	if err != nil {
+		return nil, fmt.Errorf("LIST %s: %w", query, err)
+	}
+	if begin != "BEGIN LIST "+query {
+		return nil, fmt.Errorf("LIST %s: unexpected response %q", query, begin)
+	}
+
+	var items [][]string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("LIST %s: %w", query, err)
+		}
+		if line == "END LIST "+query {
+			return items, nil
+		}
+		tokens, err := split(line)
+		if err != nil {
+			return nil, fmt.Errorf("LIST %s: %w", query, err)
+		}
+		if len(tokens) < 2 {
+			continue
+		}
+		items = append(items, tokens[1:])
+	}
+}
+
+// upsNames lists the UPSes known to the server.
+func (c *client) upsNames() ([]string, error) {
+	items, err := c.list("UPS")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item[0])
+	}
+	return names, nil
+}
+
+// variables returns all variables of a UPS.
+func (c *client) variables(ups string) (map[string]string, error) {
+	items, err := c.list("VAR " + ups)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(items))
+	for _, item := range items {
+		// VAR <upsname> <varname> "<value>"
+		if len(item) < 3 {
+			continue
+		}
+		vars[item[1]] = item[2]
+	}
+	return vars, nil
+}
+
+// split tokenizes a response line; values are double quoted with
+// backslash escapes.
+func split(line string) ([]string, error) {
+	var tokens []string
+	var sb strings.Builder
+	inQuote, escaped, inToken := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+			inToken = true
+		case r == ' ' && !inQuote:
+			if inToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				inToken = false
+			}
+		default:
+			sb.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inToken {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens, nil
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
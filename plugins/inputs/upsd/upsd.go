@@ -0,0 +1,210 @@
+package upsd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+)
+
+const (
+	defaultServer = "127.0.0.1"
+	defaultPort   = 3493
+)
+
+var defaultTimeout = internal.Duration{Duration: time.Second * 5}
+
+// numericFields maps NUT variables to the field names used by the apcupsd
+// input, so both report UPS health the same way
+var numericFields = map[string]string{
+	"battery.charge":          "battery_charge_percent",
+	"ups.load":                "load_percent",
+	"input.voltage":           "input_voltage",
+	"output.voltage":          "output_voltage",
+	"battery.voltage":         "battery_voltage",
+	"input.frequency":         "input_frequency",
+	"ups.temperature":         "internal_temp",
+	"ups.realpower.nominal":   "nominal_power",
+	"input.voltage.nominal":   "nominal_input_voltage",
+	"battery.voltage.nominal": "nominal_battery_voltage",
+}
+
+// statusFlags translates ups.status tokens to the apcupsd status bits
+var statusFlags = map[string]uint64{
+	"CAL":   0x01,
+	"TRIM":  0x02,
+	"BOOST": 0x04,
+	"OL":    0x08,
+	"OB":    0x10,
+	"OVER":  0x20,
+	"LB":    0x40,
+	"RB":    0x80,
+}
+
+type Upsd struct {
+	Server           string            `toml:"server"`
+	Port             int               `toml:"port"`
+	Username         string            `toml:"username"`
+	Password         string            `toml:"password"`
+	Timeout          internal.Duration `toml:"timeout"`
+	UpsNames         []string          `toml:"ups_names"`
+	AdditionalFields []string          `toml:"additional_fields"`
+
+	additional filter.Filter
+}
+
+func (*Upsd) Description() string {
+	return "Monitor UPSes connected to a Network UPS Tools (NUT) upsd server"
+}
+
+var sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## upsd server to connect to
+  # server = "127.0.0.1"
+  # port = 3493
+
+  ## Credentials, only needed when upsd restricts access to the UPS
+  # username = ""
+  # password = ""
+
+  ## Timeout for connecting to and reading from the server
+  # timeout = "5s"
+
+  ## UPSes to gather, by default all UPSes known to the server
+  # ups_names = []
+
+  ## Additional NUT variables to report, glob patterns matched against the
+  ## variable names (e.g. "battery.temperature", "ups.power*"). Dots in the
+  ## names are replaced by underscores to form the field name.
+  # additional_fields = []
+`
+
+func (*Upsd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (u *Upsd) Init() error {
+	if u.Server == "" {
+		u.Server = defaultServer
+	}
+	if u.Port == 0 {
+		u.Port = defaultPort
+	}
+	f, err := filter.Compile(u.AdditionalFields)
+	if err != nil {
+		return fmt.Errorf("additional_fields: %w", err)
+	}
+	u.additional = f
+	return nil
+}
+
+func (u *Upsd) Gather(ctx context.Context, acc cua.Accumulator) error {
+	address := net.JoinHostPort(u.Server, strconv.Itoa(u.Port))
+	c, err := dial(ctx, address, u.Timeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.login(u.Username, u.Password); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	names := u.UpsNames
+	if len(names) == 0 {
+		names, err = c.upsNames()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		vars, err := c.variables(name)
+		if err != nil {
+			acc.AddError(fmt.Errorf("ups %s: %w", name, err))
+			continue
+		}
+		fields, tags := u.metric(name, vars)
+		acc.AddFields("upsd", fields, tags)
+	}
+	return nil
+}
+
+func (u *Upsd) metric(name string, vars map[string]string) (map[string]interface{}, map[string]string) {
+	status := vars["ups.status"]
+	tags := map[string]string{
+		"ups_name": name,
+		"status":   status,
+	}
+	if model := firstOf(vars, "device.model", "ups.model"); model != "" {
+		tags["model"] = model
+	}
+	if serial := firstOf(vars, "device.serial", "ups.serial"); serial != "" {
+		tags["serial"] = serial
+	}
+
+	var flags uint64
+	for _, s := range strings.Fields(status) {
+		flags |= statusFlags[s]
+	}
+	fields := map[string]interface{}{
+		"status_flags": flags,
+	}
+
+	for v, field := range numericFields {
+		if f, err := strconv.ParseFloat(vars[v], 64); err == nil {
+			fields[field] = f
+		}
+	}
+	if runtime, err := strconv.ParseFloat(vars["battery.runtime"], 64); err == nil {
+		fields["time_left_ns"] = int64(runtime * float64(time.Second))
+	}
+	if v, ok := vars["ups.firmware"]; ok {
+		fields["firmware"] = v
+	}
+	if v := firstOf(vars, "battery.date", "battery.mfr.date"); v != "" {
+		fields["battery_date"] = v
+	}
+
+	if u.additional != nil {
+		for v, value := range vars {
+			if !u.additional.Match(v) {
+				continue
+			}
+			field := strings.ReplaceAll(v, ".", "_")
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				fields[field] = f
+			} else {
+				fields[field] = value
+			}
+		}
+	}
+	return fields, tags
+}
+
+func firstOf(vars map[string]string, names ...string) string {
+	for _, n := range names {
+		if v, ok := vars[n]; ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func init() {
+	inputs.Add("upsd", func() cua.Input {
+		return &Upsd{
+			Server:  defaultServer,
+			Port:    defaultPort,
+			Timeout: defaultTimeout,
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package upsd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpsd serves a single connection with the given UPS variables and
+// records the commands it received.
+func fakeUpsd(t *testing.T, password string, upses map[string]map[string]string) (string, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cmds := make(chan []string, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		defer func() { cmds <- received }()
+
+		authed := password == ""
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			received = append(received, line)
+			switch {
+			case strings.HasPrefix(line, "USERNAME "):
+				fmt.Fprint(conn, "OK\n")
+			case strings.HasPrefix(line, "PASSWORD "):
+				authed = line == "PASSWORD "+quote(password)
+				fmt.Fprint(conn, "OK\n")
+			case !authed:
+				fmt.Fprint(conn, "ERR ACCESS-DENIED\n")
+			case line == "LIST UPS":
+				fmt.Fprint(conn, "BEGIN LIST UPS\n")
+				for name := range upses {
+					fmt.Fprintf(conn, "UPS %s \"Test UPS\"\n", name)
+				}
+				fmt.Fprint(conn, "END LIST UPS\n")
+			case strings.HasPrefix(line, "LIST VAR "):
+				name := strings.TrimPrefix(line, "LIST VAR ")
+				vars, ok := upses[name]
+				if !ok {
+					fmt.Fprint(conn, "ERR UNKNOWN-UPS\n")
+					continue
+				}
+				fmt.Fprintf(conn, "BEGIN LIST VAR %s\n", name)
+				for k, v := range vars {
+					fmt.Fprintf(conn, "VAR %s %s %s\n", name, k, quote(v))
+				}
+				fmt.Fprintf(conn, "END LIST VAR %s\n", name)
+			case line == "LOGOUT":
+				fmt.Fprint(conn, "OK Goodbye\n")
+				return
+			default:
+				fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), cmds
+}
+
+func newUpsd(t *testing.T, address string) *Upsd {
+	host, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	u := &Upsd{
+		Server:  host,
+		Timeout: internal.Duration{Duration: time.Second},
+	}
+	_, err = fmt.Sscan(port, &u.Port)
+	require.NoError(t, err)
+	return u
+}
+
+func TestGather(t *testing.T) {
+	addr, cmds := fakeUpsd(t, "secret", map[string]map[string]string{
+		"rack1": {
+			"battery.charge":    "96",
+			"battery.runtime":   "1830",
+			"battery.voltage":   "27.1",
+			"device.model":      "Smart-UPS 1500",
+			"device.serial":     "AS1234",
+			"input.voltage":     "231.5",
+			"ups.load":          "23",
+			"ups.status":        "OL CHRG",
+			"ups.beeper.status": "enabled",
+			"ups.power":         "312",
+		},
+	})
+
+	u := newUpsd(t, addr)
+	u.Username = "monitor"
+	u.Password = "secret"
+	u.AdditionalFields = []string{"ups.beeper.*", "ups.power"}
+	require.NoError(t, u.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, u.Gather(context.Background(), &acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "upsd",
+		map[string]interface{}{
+			"status_flags":           uint64(0x08),
+			"battery_charge_percent": float64(96),
+			"time_left_ns":           int64(1830 * time.Second),
+			"battery_voltage":        27.1,
+			"input_voltage":          231.5,
+			"load_percent":           float64(23),
+			"ups_beeper_status":      "enabled",
+			"ups_power":              float64(312),
+		},
+		map[string]string{
+			"ups_name": "rack1",
+			"status":   "OL CHRG",
+			"model":    "Smart-UPS 1500",
+			"serial":   "AS1234",
+		})
+
+	require.Equal(t, []string{
+		`USERNAME "monitor"`,
+		`PASSWORD "secret"`,
+		"LIST UPS",
+		"LIST VAR rack1",
+		"LOGOUT",
+	}, <-cmds)
+}
+
+func TestGatherAccessDenied(t *testing.T) {
+	addr, _ := fakeUpsd(t, "secret", map[string]map[string]string{"rack1": {}})
+
+	u := newUpsd(t, addr)
+	require.NoError(t, u.Init())
+
+	var acc testutil.Accumulator
+	err := u.Gather(context.Background(), &acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access-denied")
+}
+
+func TestGatherUnknownUps(t *testing.T) {
+	addr, _ := fakeUpsd(t, "", map[string]map[string]string{
+		"rack1": {"ups.status": "OB LB"},
+	})
+
+	u := newUpsd(t, addr)
+	u.UpsNames = []string{"missing", "rack1"}
+	require.NoError(t, u.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, u.Gather(context.Background(), &acc))
+	require.Len(t, acc.Errors, 1)
+	acc.AssertContainsTaggedFields(t, "upsd",
+		map[string]interface{}{"status_flags": uint64(0x10 | 0x40)},
+		map[string]string{"ups_name": "rack1", "status": "OB LB"})
+}
+
+func TestSplit(t *testing.T) {
+	tokens, err := split(`VAR ups ups.mfr "American \"Power\" Conversion"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"VAR", "ups", "ups.mfr", `American "Power" Conversion`}, tokens)
+
+	tokens, err = split(`VAR ups ups.id ""`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"VAR", "ups", "ups.id", ""}, tokens)
+
+	_, err = split(`VAR ups ups.id "open`)
+	require.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -109,6 +110,10 @@ func (u *Uwsgi) gatherServer(acc cua.Accumulator, surl *url.URL) error {
 		}
 		r = resp.Body
 		s.source = surl.Host
+		if resp.StatusCode != http.StatusOK {
+			r.Close()
+			return fmt.Errorf("http get (%s): %s", surl.String(), resp.Status)
+		}
 	default:
 		return fmt.Errorf("'%s' is not a supported scheme", surl.Scheme)
 	}
@@ -133,6 +138,23 @@ func (u *Uwsgi) gatherStatServer(acc cua.Accumulator, s *StatsServer) {
 		"pid":                 s.PID,
 	}
 
+	// summarize worker states, a pool without idle workers queues requests
+	var harakiri int
+	for _, status := range []string{"idle", "busy", "cheap", "pause", "sig"} {
+		fields["workers_"+status] = 0
+	}
+	for _, w := range s.Workers {
+		status := w.Status
+		if strings.HasPrefix(status, "sig") {
+			status = "sig"
+		}
+		if n, ok := fields["workers_"+status].(int); ok {
+			fields["workers_"+status] = n + 1
+		}
+		harakiri += w.HarakiriCount
+	}
+	fields["harakiri_count"] = harakiri
+
 	tags := map[string]string{
 		"source":  s.source,
 		"uid":     strconv.Itoa(s.UID),
@@ -144,6 +166,22 @@ func (u *Uwsgi) gatherStatServer(acc cua.Accumulator, s *StatsServer) {
 	u.gatherWorkers(acc, s)
 	u.gatherApps(acc, s)
 	u.gatherCores(acc, s)
+	u.gatherSockets(acc, s)
+}
+
+func (u *Uwsgi) gatherSockets(acc cua.Accumulator, s *StatsServer) {
+	for _, sock := range s.Sockets {
+		fields := map[string]interface{}{
+			"queue":     sock.Queue,
+			"max_queue": sock.MaxQueue,
+		}
+		tags := map[string]string{
+			"name":   sock.Name,
+			"proto":  sock.Proto,
+			"source": s.source,
+		}
+		acc.AddFields("uwsgi_sockets", fields, tags)
+	}
 }
 
 func (u *Uwsgi) gatherWorkers(acc cua.Accumulator, s *StatsServer) {
@@ -241,6 +279,18 @@ type StatsServer struct {
 	Load              int `json:"load"`
 
 	Workers []*Worker `json:"workers"`
+	Sockets []*Socket `json:"sockets"`
+}
+
+// Socket defines the socket metric structure.
+type Socket struct {
+	// Tags
+	Name  string `json:"name"`
+	Proto string `json:"proto"`
+
+	// Fields
+	Queue    int `json:"queue"`
+	MaxQueue int `json:"max_queue"`
 }
 
 // Worker defines the worker metric structure.
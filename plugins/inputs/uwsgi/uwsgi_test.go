@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs/uwsgi"
@@ -125,6 +126,23 @@ func TestBasic(t *testing.T) {
 	var acc testutil.Accumulator
 	_ = plugin.Gather(context.Background(), &acc)
 	require.Equal(t, 0, len(acc.Errors))
+
+	overview, ok := acc.Get("uwsgi_overview")
+	require.True(t, ok)
+	require.Equal(t, 1, overview.Fields["workers_idle"])
+	require.Equal(t, 0, overview.Fields["workers_busy"])
+	require.Equal(t, 0, overview.Fields["harakiri_count"])
+
+	acc.AssertContainsTaggedFields(t, "uwsgi_sockets",
+		map[string]interface{}{
+			"queue":     0,
+			"max_queue": 100,
+		},
+		map[string]string{
+			"name":   "127.0.0.1:47430",
+			"proto":  "uwsgi",
+			"source": strings.TrimPrefix(fakeServer.URL, "http://"),
+		})
 }
 
 func TestInvalidJSON(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/performance"
@@ -109,6 +110,9 @@ func NewClient(ctx context.Context, u *url.URL, vs *VSphere) (*Client, error) {
 	// Use a default TLS config if it's missing
 	if tlsCfg == nil {
 		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		if err := tlsint.ApplyFIPS(tlsCfg); err != nil {
+			return nil, fmt.Errorf("TLSConfig: %w", err)
+		}
 	}
 	if vs.Username != "" {
 		u.User = url.UserPassword(vs.Username, vs.Password)
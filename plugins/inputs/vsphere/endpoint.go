@@ -14,8 +14,10 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/vmware/govmomi/event"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 
@@ -36,8 +38,17 @@ const (
 	maxMetadataSamples = 100  // Number of resources to sample for metric metadata
 	maxRealtimeMetrics = 5000 // Absolute maximum metrics per realtime query
 	hwMarkTTL          = 4 * time.Hour
+	maxQueryEvents     = 1000 // Maximum events answered by a query, more may have been missed
+	streamSize         = 1000 // Maximum metrics of a chunk pending in a streaming accumulator
 )
 
+// vmChangeEvents are the events of the virtual machines changing how they
+// are discovered
+var vmChangeEvents = []string{
+	"VmPoweredOnEvent", "VmPoweredOffEvent", "VmSuspendedEvent", "VmRemovedEvent",
+	"VmRenamedEvent", "VmReconfiguredEvent", "VmMigratedEvent", "DrsVmMigratedEvent", "VmRelocatedEvent",
+}
+
 type queryChunk []types.PerfQuerySpec
 
 type queryJob func(queryChunk)
@@ -61,6 +72,7 @@ type Endpoint struct {
 	metricNameLookup  map[int32]string
 	metricNameMux     sync.RWMutex
 	log               cua.Logger
+	discoveredAt      time.Time // server time of the last discovery
 }
 
 type resourceKind struct {
@@ -410,6 +422,10 @@ func (e *Endpoint) discover(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	now, err := client.GetServerTime(ctx)
+	if err != nil {
+		return err
+	}
 
 	e.log.Debugf("Discover new objects for %s", e.URL.Host)
 	dcNameCache := make(map[string]string)
@@ -492,6 +508,7 @@ func (e *Endpoint) discover(ctx context.Context) error {
 		e.resourceKinds[k].objects = v
 	}
 	e.lun2ds = l2d
+	e.discoveredAt = now
 
 	if fields != nil {
 		e.customFields = fields
@@ -502,6 +519,132 @@ func (e *Endpoint) discover(ctx context.Context) error {
 	return nil
 }
 
+// discoverChanges updates the virtual machines discovered last with the
+// ones with events since, discovering all the objects again when the
+// changes were lost
+func (e *Endpoint) discoverChanges(ctx context.Context) error {
+	lost, err := e.discoverVMChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if lost {
+		e.log.Debugf("Changes of %s lost, discovering all objects", e.URL.Host)
+		return e.discover(ctx)
+	}
+	return nil
+}
+
+// discoverVMChanges refreshes the virtual machines with events since the last
+// discovery, answering true when these may not all be known.  Virtual machines
+// not discovered before are only added when all of them are included.
+func (e *Endpoint) discoverVMChanges(ctx context.Context) (bool, error) {
+	e.busy.Lock()
+	defer e.busy.Unlock()
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if e.discoveredAt.IsZero() {
+		return true, nil
+	}
+	res := e.resourceKinds["vm"]
+	if !res.enabled {
+		return false, nil
+	}
+
+	client, err := e.clientFactory.GetClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	now, err := client.GetServerTime(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ctx1, cancel1 := context.WithTimeout(ctx, e.Parent.Timeout.Duration)
+	defer cancel1()
+	events, err := event.NewManager(client.Client.Client).QueryEvents(ctx1, types.EventFilterSpec{
+		Time:        &types.EventFilterSpecByTime{BeginTime: &e.discoveredAt, EndTime: &now},
+		EventTypeId: vmChangeEvents,
+	})
+	if err != nil {
+		return false, fmt.Errorf("query events: %w", err)
+	}
+	if len(events) >= maxQueryEvents {
+		return true, nil
+	}
+
+	changed := make(map[string]types.ManagedObjectReference)
+	removed := make(map[string]bool)
+	for _, ev := range events {
+		vm := ev.GetEvent().Vm
+		if vm == nil {
+			continue
+		}
+		if _, ok := ev.(*types.VmRemovedEvent); ok {
+			removed[vm.Vm.Value] = true
+		}
+		changed[vm.Vm.Value] = vm.Vm
+	}
+	refs := make([]types.ManagedObjectReference, 0, len(changed))
+	for id, ref := range changed {
+		if !removed[id] {
+			refs = append(refs, ref)
+		}
+	}
+	var vms []mo.VirtualMachine
+	if len(refs) > 0 {
+		pc := property.DefaultCollector(client.Client.Client)
+		err = pc.Retrieve(ctx1, refs, append([]string{"name"}, addFields["VirtualMachine"]...), &vms)
+		if err != nil {
+			return false, fmt.Errorf("retrieve: %w", err)
+		}
+	}
+
+	objects := make(objectMap, len(res.objects))
+	for id, obj := range res.objects {
+		objects[id] = obj
+	}
+	for id := range removed {
+		delete(objects, id)
+	}
+	dcNameCache := make(map[string]string)
+	for _, r := range vms {
+		id := r.ExtensibleManagedObject.Reference().Value
+		_, known := objects[id]
+		delete(objects, id)
+		if r.Runtime.PowerState != "poweredOn" || !known && !includesAllVMs(res) {
+			continue
+		}
+		obj := e.vmObject(r)
+		if obj.parentRef != nil {
+			obj.dcname, _ = e.getDatacenterName(ctx, client, dcNameCache, *obj.parentRef)
+		}
+		objects[id] = obj
+	}
+	e.log.Debugf("Refreshed %d changed virtual machines of %s", len(changed), e.URL.Host)
+
+	// Atomically swap maps
+	e.collectMux.Lock()
+	defer e.collectMux.Unlock()
+	res.objects = objects
+	e.discoveredAt = now
+	return false, nil
+}
+
+// includesAllVMs answers whether the paths of the virtual machines include
+// all of them
+func includesAllVMs(res *resourceKind) bool {
+	if len(res.excludePaths) > 0 {
+		return false
+	}
+	for _, path := range res.paths {
+		if path == "/**" || path == "/*/vm/**" {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Endpoint) simpleMetadataSelect(ctx context.Context, client *Client, res *resourceKind) {
 	e.log.Debugf("Using fast metric metadata selection for %s", res.name)
 	m, err := client.CounterInfoByName(ctx)
@@ -690,81 +833,86 @@ func getVMs(ctx context.Context, e *Endpoint, filter *ResourceFilter) (objectMap
 		if r.Runtime.PowerState != "poweredOn" {
 			continue
 		}
-		guest := "unknown"
-		uuid := ""
-		lookup := make(map[string]string)
+		m[r.ExtensibleManagedObject.Reference().Value] = e.vmObject(r)
+	}
+	return m, nil
+}
 
-		// Extract host name
-		if r.Guest != nil && r.Guest.HostName != "" {
-			lookup["guesthostname"] = r.Guest.HostName
-		}
+// vmObject answers the object of a virtual machine
+func (e *Endpoint) vmObject(r mo.VirtualMachine) *objectRef {
+	guest := "unknown"
+	uuid := ""
+	lookup := make(map[string]string)
 
-		// Collect network information
-		for _, net := range r.Guest.Net {
-			if net.DeviceConfigId == -1 {
-				continue
-			}
-			if net.IpConfig == nil || net.IpConfig.IpAddress == nil {
-				continue
-			}
-			ips := make(map[string][]string)
-			for _, ip := range net.IpConfig.IpAddress {
-				addr := ip.IpAddress
-				for _, ipType := range e.Parent.IPAddresses {
-					if !(ipType == "ipv4" && isIPv4.MatchString(addr) ||
-						ipType == "ipv6" && isIPv6.MatchString(addr)) {
-						continue
-					}
+	// Extract host name
+	if r.Guest != nil && r.Guest.HostName != "" {
+		lookup["guesthostname"] = r.Guest.HostName
+	}
 
-					// By convention, we want the preferred addresses to appear first in the array.
-					if _, ok := ips[ipType]; !ok {
-						ips[ipType] = make([]string, 0)
-					}
-					if ip.State == "preferred" {
-						ips[ipType] = append([]string{addr}, ips[ipType]...)
-					} else {
-						ips[ipType] = append(ips[ipType], addr)
-					}
-				}
-			}
-			for ipType, ipList := range ips {
-				lookup["nic/"+strconv.Itoa(int(net.DeviceConfigId))+"/"+ipType] = strings.Join(ipList, ",")
-			}
+	// Collect network information
+	for _, net := range r.Guest.Net {
+		if net.DeviceConfigId == -1 {
+			continue
 		}
-
-		// Sometimes Config is unknown and returns a nil pointer
-		if r.Config != nil {
-			guest = cleanGuestID(r.Config.GuestId)
-			uuid = r.Config.Uuid
+		if net.IpConfig == nil || net.IpConfig.IpAddress == nil {
+			continue
 		}
-		cvs := make(map[string]string)
-		if e.customAttrEnabled {
-			for _, cv := range r.Summary.CustomValue {
-				val := cv.(*types.CustomFieldStringValue)
-				if val.Value == "" {
+		ips := make(map[string][]string)
+		for _, ip := range net.IpConfig.IpAddress {
+			addr := ip.IpAddress
+			for _, ipType := range e.Parent.IPAddresses {
+				if !(ipType == "ipv4" && isIPv4.MatchString(addr) ||
+					ipType == "ipv6" && isIPv6.MatchString(addr)) {
 					continue
 				}
-				key, ok := e.customFields[val.Key]
-				if !ok {
-					e.log.Warnf("Metadata for custom field %d not found. Skipping", val.Key)
-					continue
+
+				// By convention, we want the preferred addresses to appear first in the array.
+				if _, ok := ips[ipType]; !ok {
+					ips[ipType] = make([]string, 0)
 				}
-				if e.customAttrFilter.Match(key) {
-					cvs[key] = val.Value
+				if ip.State == "preferred" {
+					ips[ipType] = append([]string{addr}, ips[ipType]...)
+				} else {
+					ips[ipType] = append(ips[ipType], addr)
 				}
 			}
 		}
-		m[r.ExtensibleManagedObject.Reference().Value] = &objectRef{
-			name:         r.Name,
-			ref:          r.ExtensibleManagedObject.Reference(),
-			parentRef:    r.Runtime.Host,
-			guest:        guest,
-			altID:        uuid,
-			customValues: e.loadCustomAttributes(&r.ManagedEntity),
-			lookup:       lookup,
+		for ipType, ipList := range ips {
+			lookup["nic/"+strconv.Itoa(int(net.DeviceConfigId))+"/"+ipType] = strings.Join(ipList, ",")
 		}
 	}
-	return m, nil
+
+	// Sometimes Config is unknown and returns a nil pointer
+	if r.Config != nil {
+		guest = cleanGuestID(r.Config.GuestId)
+		uuid = r.Config.Uuid
+	}
+	cvs := make(map[string]string)
+	if e.customAttrEnabled {
+		for _, cv := range r.Summary.CustomValue {
+			val := cv.(*types.CustomFieldStringValue)
+			if val.Value == "" {
+				continue
+			}
+			key, ok := e.customFields[val.Key]
+			if !ok {
+				e.log.Warnf("Metadata for custom field %d not found. Skipping", val.Key)
+				continue
+			}
+			if e.customAttrFilter.Match(key) {
+				cvs[key] = val.Value
+			}
+		}
+	}
+	return &objectRef{
+		name:         r.Name,
+		ref:          r.ExtensibleManagedObject.Reference(),
+		parentRef:    r.Runtime.Host,
+		guest:        guest,
+		altID:        uuid,
+		customValues: e.loadCustomAttributes(&r.ManagedEntity),
+		lookup:       lookup,
+	}
 }
 
 func getDatastores(ctx context.Context, e *Endpoint, filter *ResourceFilter) (objectMap, error) {
@@ -825,6 +973,16 @@ func (e *Endpoint) Close() {
 
 // Collect runs a round of data collections as specified in the configuration.
 func (e *Endpoint) Collect(ctx context.Context, acc cua.Accumulator) error {
+	return e.collect(ctx, acc, e.discover)
+}
+
+// CollectChanges runs a round of data collections, only discovering the
+// changes since the last discovery when discovering on each collection.
+func (e *Endpoint) CollectChanges(ctx context.Context, acc cua.Accumulator) error {
+	return e.collect(ctx, acc, e.discoverChanges)
+}
+
+func (e *Endpoint) collect(ctx context.Context, acc cua.Accumulator, discover func(context.Context) error) error {
 
 	// If we never managed to do a discovery, collection will be a no-op. Therefore,
 	// we need to check that a connection is available, or the collection will
@@ -833,6 +991,15 @@ func (e *Endpoint) Collect(ctx context.Context, acc cua.Accumulator) error {
 		return err
 	}
 
+	// If discovery interval is disabled (0), discover on each collection cycle,
+	// before holding the objects swapped by the discovery
+	if e.Parent.ObjectDiscoveryInterval.Duration == 0 {
+		err := discover(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	e.collectMux.RLock()
 	defer e.collectMux.RUnlock()
 
@@ -840,13 +1007,6 @@ func (e *Endpoint) Collect(ctx context.Context, acc cua.Accumulator) error {
 		return ctx.Err()
 	}
 
-	// If discovery interval is disabled (0), discover on each collection cycle
-	if e.Parent.ObjectDiscoveryInterval.Duration == 0 {
-		err := e.discover(ctx)
-		if err != nil {
-			return err
-		}
-	}
 	var wg sync.WaitGroup
 	for k, res := range e.resourceKinds {
 		if res.enabled {
@@ -1104,6 +1264,19 @@ func (e *Endpoint) collectChunk(ctx context.Context, pqs queryChunk, res *resour
 
 	e.log.Debugf("Query for %s returned metrics for %d objects", resourceType, len(ems))
 
+	// Stream the metrics of the chunk to the accumulator as they are built
+	// when possible, each chunk with its own stream
+	emit := func(b metricEntry) {
+		acc.AddFields(b.name, b.fields, b.tags, b.ts)
+	}
+	if sacc, ok := acc.(cua.StreamingAccumulator); ok {
+		stream := sacc.NewStream(streamSize)
+		defer stream.Close()
+		emit = func(b metricEntry) {
+			stream.AddMetric(stream.NewMetric(b.name, b.tags, b.fields, b.ts, cua.Untyped))
+		}
+	}
+
 	// Iterate through results
 	for _, em := range ems {
 		moid := em.Entity.Reference().Value
@@ -1183,7 +1356,7 @@ func (e *Endpoint) collectChunk(ctx context.Context, pqs queryChunk, res *resour
 		// We've iterated through all the metrics and collected buckets for each
 		// measurement name. Now emit them!
 		for _, bucket := range buckets {
-			acc.AddFields(bucket.name, bucket.fields, bucket.tags, bucket.ts)
+			emit(bucket)
 		}
 	}
 	return count, latestSample, nil
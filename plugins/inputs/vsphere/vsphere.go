@@ -311,12 +311,22 @@ func (v *VSphere) Stop() {
 // Gather is the main data collection function called by the agent core. It performs all
 // the data collection and writes all metrics into the Accumulator passed as an argument.
 func (v *VSphere) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return v.gather(acc, (*Endpoint).Collect)
+}
+
+// GatherChanges collects like Gather, only discovering the virtual machines
+// changed since the last discovery when object_discovery_interval is 0.
+func (v *VSphere) GatherChanges(ctx context.Context, acc cua.Accumulator) error {
+	return v.gather(acc, (*Endpoint).CollectChanges)
+}
+
+func (v *VSphere) gather(acc cua.Accumulator, collect func(*Endpoint, context.Context, cua.Accumulator) error) error {
 	var wg sync.WaitGroup
 	for _, ep := range v.endpoints {
 		wg.Add(1)
 		go func(endpoint *Endpoint) {
 			defer wg.Done()
-			err := endpoint.Collect(context.Background(), acc)
+			err := collect(endpoint, context.Background(), acc)
 			if errors.Is(err, context.Canceled) {
 
 				// No need to signal errors if we were merely canceled.
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -552,3 +553,58 @@ func mustContainAll(t *testing.T, tagMap map[string]string, mustHave []string) {
 		require.Contains(t, tagMap, tag)
 	}
 }
+
+func TestCollectionChanges(t *testing.T) {
+	// Don't run test on 32-bit machines due to bug in simulator.
+	// https://github.com/vmware/govmomi/issues/1330
+	var i int
+	if unsafe.Sizeof(i) < 8 {
+		return
+	}
+
+	m, s, err := createSim(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Remove()
+	defer s.Close()
+
+	v := defaultVSphere()
+	v.Vcenters = []string{s.URL.String()}
+	v.ObjectDiscoveryInterval = internal.Duration{Duration: 0}
+
+	var acc testutil.Accumulator
+	ctx := context.Background()
+	require.NoError(t, v.Start(ctx, &acc))
+	defer v.Stop()
+	require.NoError(t, v.Gather(ctx, &acc))
+	require.Empty(t, acc.Errors)
+
+	e := v.endpoints[0]
+	vms := e.resourceKinds["vm"].objects
+	hosts := e.resourceKinds["host"].objects
+	require.NotEmpty(t, vms)
+	var powerOff *objectRef
+	for _, obj := range vms {
+		powerOff = obj
+		break
+	}
+
+	// powered off virtual machines are no longer collected
+	client, err := e.clientFactory.GetClient(ctx)
+	require.NoError(t, err)
+	task, err := object.NewVirtualMachine(client.Client.Client, powerOff.ref).PowerOff(ctx)
+	require.NoError(t, err)
+	require.NoError(t, task.Wait(ctx))
+
+	require.NoError(t, v.GatherChanges(ctx, &acc))
+	require.Empty(t, acc.Errors)
+	// only the virtual machines were discovered again
+	require.Equal(t, reflect.ValueOf(hosts).Pointer(), reflect.ValueOf(e.resourceKinds["host"].objects).Pointer())
+	require.Len(t, e.resourceKinds["vm"].objects, len(vms)-1)
+	require.NotContains(t, e.resourceKinds["vm"].objects, powerOff.ref.Value)
+	for id, obj := range e.resourceKinds["vm"].objects {
+		require.Equal(t, vms[id].name, obj.name)
+		require.Equal(t, vms[id].dcname, obj.dcname)
+	}
+}
@@ -0,0 +1,134 @@
+//go:build windows
+// +build windows
+
+package winad
+
+import (
+	"context"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	winperfcounters "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Counter sets to collect, available sets are:
+  ##   "replication" - directory replication agent traffic and pending operations
+  ##   "ldap"        - LDAP sessions, binds, searches and bind time
+  ##   "auth"        - Kerberos and NTLM authentications and KDC requests
+  ##   "directory"   - directory reads, writes and searches
+  # collect = ["replication", "ldap", "auth", "directory"]
+`
+
+// single instance objects
+var noInstance = []string{"------"}
+
+// counterSets maps the collect options to the AD DS performance objects
+// gathered for them. Objects that do not exist on the host are skipped.
+var counterSets = map[string][]winperfcounters.CounterSet{
+	"replication": {
+		{
+			Object:      "NTDS",
+			Measurement: "win_ad_replication",
+			Instances:   noInstance,
+			Counters: []string{
+				"DRA Inbound Objects/sec",
+				"DRA Outbound Objects/sec",
+				"DRA Inbound Bytes Total/sec",
+				"DRA Outbound Bytes Total/sec",
+				"DRA Pending Replication Operations",
+				"DRA Pending Replication Synchronizations",
+				"DRA Sync Requests Made",
+				"DRA Sync Requests Successful",
+				"DRA Sync Failures on Schema Mismatch",
+			},
+		},
+	},
+	"ldap": {
+		{
+			Object:      "NTDS",
+			Measurement: "win_ad_ldap",
+			Instances:   noInstance,
+			Counters: []string{
+				"LDAP Client Sessions",
+				"LDAP Active Threads",
+				"LDAP Bind Time",
+				"LDAP Successful Binds/sec",
+				"LDAP Searches/sec",
+				"LDAP Writes/sec",
+				"Simple Binds/sec",
+				"Digest Binds/sec",
+				"Negotiated Binds/sec",
+			},
+		},
+	},
+	"auth": {
+		{
+			Object:      "Security System-Wide Statistics",
+			Measurement: "win_ad_auth",
+			Instances:   noInstance,
+			Counters: []string{
+				"Kerberos Authentications",
+				"NTLM Authentications",
+				"KDC AS Requests",
+				"KDC TGS Requests",
+			},
+		},
+	},
+	"directory": {
+		{
+			Object:      "NTDS",
+			Measurement: "win_ad_directory",
+			Instances:   noInstance,
+			Counters: []string{
+				"DS Directory Reads/sec",
+				"DS Directory Writes/sec",
+				"DS Directory Searches/sec",
+				"DS Threads in Use",
+				"DS Notify Queue Size",
+			},
+		},
+	},
+}
+
+var defaultCollect = []string{"replication", "ldap", "auth", "directory"}
+
+type ActiveDirectory struct {
+	Collect []string `toml:"collect"`
+
+	Log cua.Logger `toml:"-"`
+
+	perf *winperfcounters.WinPerfCounters
+}
+
+func (a *ActiveDirectory) Description() string {
+	return "Active Directory Domain Services replication, LDAP and authentication counters"
+}
+
+func (a *ActiveDirectory) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *ActiveDirectory) Init() error {
+	if len(a.Collect) == 0 {
+		a.Collect = defaultCollect
+	}
+
+	var err error
+	// the objects are single instance, there is nothing to rediscover
+	a.perf, err = winperfcounters.NewPreset(counterSets, a.Collect, internal.Duration{}, a.Log)
+	return err //nolint:wrapcheck
+}
+
+func (a *ActiveDirectory) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return a.perf.Gather(ctx, acc) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("win_ad", func() cua.Input {
+		return &ActiveDirectory{}
+	})
+}
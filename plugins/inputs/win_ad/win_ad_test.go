@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package winad
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCounterSets(t *testing.T) {
+	a := &ActiveDirectory{Log: testutil.Logger{}}
+	require.NoError(t, a.Init())
+	require.Len(t, a.perf.Object, 4)
+	for _, o := range a.perf.Object {
+		require.Equal(t, []string{"------"}, o.Instances)
+	}
+
+	a = &ActiveDirectory{Collect: []string{"dhcp"}, Log: testutil.Logger{}}
+	require.Error(t, a.Init())
+}
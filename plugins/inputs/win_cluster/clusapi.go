@@ -0,0 +1,142 @@
+//go:build windows
+// +build windows
+
+package wincluster
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// failover cluster API, https://docs.microsoft.com/en-us/windows/win32/api/clusapi/
+var (
+	modclusapi = windows.NewLazySystemDLL("clusapi.dll")
+
+	procOpenCluster          = modclusapi.NewProc("OpenCluster")
+	procCloseCluster         = modclusapi.NewProc("CloseCluster")
+	procClusterOpenEnum      = modclusapi.NewProc("ClusterOpenEnum")
+	procClusterEnum          = modclusapi.NewProc("ClusterEnum")
+	procClusterCloseEnum     = modclusapi.NewProc("ClusterCloseEnum")
+	procOpenClusterNode      = modclusapi.NewProc("OpenClusterNode")
+	procGetClusterNodeState  = modclusapi.NewProc("GetClusterNodeState")
+	procCloseClusterNode     = modclusapi.NewProc("CloseClusterNode")
+	procOpenClusterGroup     = modclusapi.NewProc("OpenClusterGroup")
+	procGetClusterGroupState = modclusapi.NewProc("GetClusterGroupState")
+	procCloseClusterGroup    = modclusapi.NewProc("CloseClusterGroup")
+)
+
+// CLUSTER_ENUM types
+const (
+	clusterEnumNode  = 0x1
+	clusterEnumGroup = 0x8
+)
+
+// clusAPI is a cluster opened with the failover cluster API
+type clusAPI struct {
+	handle uintptr
+}
+
+func openClusAPI(name string) (cluster, error) {
+	if err := modclusapi.Load(); err != nil {
+		return nil, fmt.Errorf("failover clustering not installed: %w", err)
+	}
+	var namep *uint16
+	if name != "" {
+		var err error
+		namep, err = syscall.UTF16PtrFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("cluster name: %w", err)
+		}
+	}
+	h, _, err := procOpenCluster.Call(uintptr(unsafe.Pointer(namep)))
+	if h == 0 {
+		return nil, fmt.Errorf("opening cluster: %w", err)
+	}
+	return &clusAPI{handle: h}, nil
+}
+
+func (c *clusAPI) close() {
+	_, _, _ = procCloseCluster.Call(c.handle)
+}
+
+// enum answers the names of the cluster objects of the type
+func (c *clusAPI) enum(typ uint32) ([]string, error) {
+	e, _, err := procClusterOpenEnum.Call(c.handle, uintptr(typ))
+	if e == 0 {
+		return nil, fmt.Errorf("enumerating cluster: %w", err)
+	}
+	defer func() { _, _, _ = procClusterCloseEnum.Call(e) }()
+
+	var names []string
+	buf := make([]uint16, 256)
+	for i := uint32(0); ; {
+		var t uint32
+		n := uint32(len(buf))
+		r, _, _ := procClusterEnum.Call(e, uintptr(i),
+			uintptr(unsafe.Pointer(&t)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n)))
+		switch syscall.Errno(r) {
+		case windows.ERROR_SUCCESS:
+			names = append(names, windows.UTF16ToString(buf[:n]))
+			i++
+		case windows.ERROR_MORE_DATA:
+			buf = make([]uint16, n+1)
+		case windows.ERROR_NO_MORE_ITEMS:
+			return names, nil
+		default:
+			return nil, fmt.Errorf("enumerating cluster: %w", syscall.Errno(r))
+		}
+	}
+}
+
+func (c *clusAPI) nodes() ([]nodeState, error) {
+	names, err := c.enum(clusterEnumNode)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]nodeState, 0, len(names))
+	for _, name := range names {
+		namep, err := syscall.UTF16PtrFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("node name: %w", err)
+		}
+		h, _, err := procOpenClusterNode.Call(c.handle, uintptr(unsafe.Pointer(namep)))
+		if h == 0 {
+			return nil, fmt.Errorf("opening node %s: %w", name, err)
+		}
+		state, _, _ := procGetClusterNodeState.Call(h)
+		_, _, _ = procCloseClusterNode.Call(h)
+		nodes = append(nodes, nodeState{name: name, state: int32(state)})
+	}
+	return nodes, nil
+}
+
+func (c *clusAPI) groups() ([]groupState, error) {
+	names, err := c.enum(clusterEnumGroup)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]groupState, 0, len(names))
+	for _, name := range names {
+		namep, err := syscall.UTF16PtrFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("group name: %w", err)
+		}
+		h, _, err := procOpenClusterGroup.Call(c.handle, uintptr(unsafe.Pointer(namep)))
+		if h == 0 {
+			return nil, fmt.Errorf("opening group %s: %w", name, err)
+		}
+		owner := make([]uint16, 256)
+		n := uint32(len(owner))
+		state, _, _ := procGetClusterGroupState.Call(h, uintptr(unsafe.Pointer(&owner[0])), uintptr(unsafe.Pointer(&n)))
+		_, _, _ = procCloseClusterGroup.Call(h)
+		groups = append(groups, groupState{
+			name:  name,
+			owner: windows.UTF16ToString(owner[:n]),
+			state: int32(state),
+		})
+	}
+	return groups, nil
+}
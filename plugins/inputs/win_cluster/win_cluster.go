@@ -0,0 +1,259 @@
+//go:build windows
+// +build windows
+
+package wincluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	winperfcounters "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Cluster to query, the cluster of this node when empty
+  # cluster = ""
+
+  ## Sets to collect, available sets are:
+  ##   "nodes"  - state of each cluster node
+  ##   "groups" - state and owner node of each cluster group (role)
+  ##   "csv"    - io of the cluster shared volumes, including redirected io
+  # collect = ["nodes", "groups", "csv"]
+
+  ## Add an event when a group moves to another node, e.g. on a failover
+  # events = false
+  # event_severity = "warning"
+
+  ## Period after which the cluster shared volumes are rediscovered
+  # refresh_interval = "1m"
+`
+
+// counterSets maps the collect options to the cluster performance objects
+// gathered for them. Objects that do not exist on the host are skipped.
+var counterSets = map[string][]winperfcounters.CounterSet{
+	"csv": {
+		{
+			Object:      "Cluster CSVFS",
+			Measurement: "win_cluster_csv",
+			Counters: []string{
+				"Read Bytes/sec",
+				"Write Bytes/sec",
+				"Reads/sec",
+				"Writes/sec",
+				"Avg. sec/Read",
+				"Avg. sec/Write",
+				"Current Queue Length",
+				"Redirected Read Bytes/sec",
+				"Redirected Write Bytes/sec",
+				"Redirected Reads/sec",
+				"Redirected Writes/sec",
+			},
+		},
+		{
+			Object:      "Cluster CSV Volume Manager",
+			Measurement: "win_cluster_csv_volume",
+			Counters: []string{
+				"IO Read Bytes/sec - Redirected",
+				"IO Write Bytes/sec - Redirected",
+				"IO Reads/sec - Redirected",
+				"IO Writes/sec - Redirected",
+			},
+		},
+	},
+}
+
+const (
+	collectNodes  = "nodes"
+	collectGroups = "groups"
+)
+
+var defaultCollect = []string{collectNodes, collectGroups, "csv"}
+
+// CLUSTER_NODE_STATE
+var nodeStates = map[int32]string{
+	-1: "unknown",
+	0:  "up",
+	1:  "down",
+	2:  "paused",
+	3:  "joining",
+}
+
+// CLUSTER_GROUP_STATE
+var groupStates = map[int32]string{
+	-1: "unknown",
+	0:  "online",
+	1:  "offline",
+	2:  "failed",
+	3:  "partial_online",
+	4:  "pending",
+}
+
+type nodeState struct {
+	name  string
+	state int32
+}
+
+type groupState struct {
+	name  string
+	owner string
+	state int32
+}
+
+// cluster is the cluster state queried by the plugin
+type cluster interface {
+	nodes() ([]nodeState, error)
+	groups() ([]groupState, error)
+	close()
+}
+
+type WinCluster struct {
+	Cluster         string            `toml:"cluster"`
+	Collect         []string          `toml:"collect"`
+	Events          bool              `toml:"events"`
+	EventSeverity   string            `toml:"event_severity"`
+	RefreshInterval internal.Duration `toml:"refresh_interval"`
+
+	Log cua.Logger `toml:"-"`
+
+	open     func(name string) (cluster, error)
+	nodes    bool
+	groups   bool
+	severity cua.Severity
+	owners   map[string]string
+	perf     *winperfcounters.WinPerfCounters
+}
+
+func (w *WinCluster) Description() string {
+	return "Windows failover cluster node and group state and cluster shared volume io"
+}
+
+func (w *WinCluster) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WinCluster) Init() error {
+	if len(w.Collect) == 0 {
+		w.Collect = defaultCollect
+	}
+
+	var perfSets []string
+	for _, name := range w.Collect {
+		switch name {
+		case collectNodes:
+			w.nodes = true
+		case collectGroups:
+			w.groups = true
+		default:
+			perfSets = append(perfSets, name)
+		}
+	}
+
+	if w.Events {
+		severity, err := cua.ParseSeverity(w.EventSeverity)
+		if err != nil {
+			return fmt.Errorf("event_severity: %w", err)
+		}
+		w.severity = severity
+	}
+
+	if w.open == nil {
+		w.open = openClusAPI
+	}
+	w.owners = make(map[string]string)
+
+	if len(perfSets) > 0 {
+		var err error
+		w.perf, err = winperfcounters.NewPreset(counterSets, perfSets, w.RefreshInterval, w.Log)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+	return nil
+}
+
+func (w *WinCluster) Gather(ctx context.Context, acc cua.Accumulator) error {
+	if w.nodes || w.groups {
+		// opened each gather, the cluster service may have restarted or
+		// moved the cluster name since the last one
+		c, err := w.open(w.Cluster)
+		if err != nil {
+			acc.AddError(err)
+		} else {
+			w.gatherCluster(c, acc)
+			c.close()
+		}
+	}
+
+	if w.perf != nil {
+		return w.perf.Gather(ctx, acc) //nolint:wrapcheck
+	}
+	return nil
+}
+
+func (w *WinCluster) gatherCluster(c cluster, acc cua.Accumulator) {
+	now := time.Now()
+
+	if w.nodes {
+		nodes, err := c.nodes()
+		if err != nil {
+			acc.AddError(err)
+		}
+		for _, n := range nodes {
+			acc.AddFields("win_cluster_node", map[string]interface{}{
+				"state":      stateName(nodeStates, n.state),
+				"state_code": int64(n.state),
+			}, map[string]string{"node": n.name}, now)
+		}
+	}
+
+	if w.groups {
+		groups, err := c.groups()
+		if err != nil {
+			acc.AddError(err)
+			return
+		}
+		owners := make(map[string]string, len(groups))
+		for _, g := range groups {
+			owners[g.name] = g.owner
+			acc.AddFields("win_cluster_group", map[string]interface{}{
+				"state":      stateName(groupStates, g.state),
+				"state_code": int64(g.state),
+				"owner_node": g.owner,
+			}, map[string]string{"group": g.name}, now)
+
+			prev, ok := w.owners[g.name]
+			if !w.Events || !ok || prev == g.owner || g.owner == "" {
+				continue
+			}
+			acc.AddEvent(
+				fmt.Sprintf("cluster group %s moved to %s", g.name, g.owner),
+				fmt.Sprintf("group=%s from=%s to=%s state=%s", g.name, prev, g.owner, stateName(groupStates, g.state)),
+				w.severity,
+				map[string]string{"group": g.name},
+				now)
+		}
+		w.owners = owners
+	}
+}
+
+func stateName(states map[int32]string, state int32) string {
+	if name, ok := states[state]; ok {
+		return name
+	}
+	return states[-1]
+}
+
+func init() {
+	inputs.Add("win_cluster", func() cua.Input {
+		return &WinCluster{
+			EventSeverity:   "warning",
+			RefreshInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
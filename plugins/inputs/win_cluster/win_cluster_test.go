@@ -0,0 +1,79 @@
+//go:build windows
+// +build windows
+
+package wincluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCluster struct {
+	nodeStates  []nodeState
+	groupStates []groupState
+}
+
+func (f *fakeCluster) nodes() ([]nodeState, error)   { return f.nodeStates, nil }
+func (f *fakeCluster) groups() ([]groupState, error) { return f.groupStates, nil }
+func (f *fakeCluster) close()                        {}
+
+func TestInitCounterSets(t *testing.T) {
+	w := &WinCluster{Log: testutil.Logger{}}
+	require.NoError(t, w.Init())
+	require.True(t, w.nodes)
+	require.True(t, w.groups)
+	require.Len(t, w.perf.Object, 2)
+	require.Equal(t, "Cluster CSVFS", w.perf.Object[0].ObjectName)
+
+	w = &WinCluster{Collect: []string{"groups"}, Log: testutil.Logger{}}
+	require.NoError(t, w.Init())
+	require.False(t, w.nodes)
+	require.Nil(t, w.perf)
+
+	w = &WinCluster{Collect: []string{"disks"}, Log: testutil.Logger{}}
+	require.Error(t, w.Init())
+
+	w = &WinCluster{Collect: []string{"groups"}, Events: true, EventSeverity: "bad", Log: testutil.Logger{}}
+	require.Error(t, w.Init())
+}
+
+func TestGatherFailover(t *testing.T) {
+	c := &fakeCluster{
+		nodeStates: []nodeState{{name: "node1", state: 0}, {name: "node2", state: 2}},
+		groupStates: []groupState{
+			{name: "SQL Server (MSSQLSERVER)", owner: "node1", state: 0},
+			{name: "Available Storage", owner: "node2", state: 1},
+		},
+	}
+	w := &WinCluster{
+		Collect:       []string{"nodes", "groups"},
+		Events:        true,
+		EventSeverity: "warning",
+		Log:           testutil.Logger{},
+		open:          func(string) (cluster, error) { return c, nil },
+	}
+	require.NoError(t, w.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, w.Gather(context.Background(), &acc))
+	require.True(t, acc.HasPoint("win_cluster_node", map[string]string{"node": "node2"}, "state", "paused"))
+	acc.AssertContainsTaggedFields(t, "win_cluster_group", map[string]interface{}{
+		"state":      "online",
+		"state_code": int64(0),
+		"owner_node": "node1",
+	}, map[string]string{"group": "SQL Server (MSSQLSERVER)"})
+	require.False(t, acc.HasMeasurement("event"))
+
+	// the sql role fails over to node2
+	c.groupStates[0] = groupState{name: "SQL Server (MSSQLSERVER)", owner: "node2", state: 4}
+	acc.ClearMetrics()
+	require.NoError(t, w.Gather(context.Background(), &acc))
+	require.True(t, acc.HasPoint("win_cluster_group", map[string]string{"group": "SQL Server (MSSQLSERVER)"}, "state", "pending"))
+	m, ok := acc.Get("event")
+	require.True(t, ok)
+	require.Equal(t, "cluster group SQL Server (MSSQLSERVER) moved to node2", m.Fields["title"])
+	require.Equal(t, "warning", m.Fields["severity"])
+}
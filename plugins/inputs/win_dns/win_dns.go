@@ -0,0 +1,143 @@
+//go:build windows
+// +build windows
+
+package windns
+
+import (
+	"context"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
+	winperfcounters "github.com/circonus-labs/circonus-unified-agent/plugins/inputs/win_perf_counters"
+)
+
+const sampleConfig = `
+  instance_id = "" # unique instance identifier (REQUIRED)
+
+  ## Counter sets to collect, available sets are:
+  ##   "queries"       - queries received and responses sent, by transport
+  ##   "recursion"     - recursive queries, failures and timeouts
+  ##   "zone_transfer" - zone transfer requests, successes and failures
+  ##   "updates"       - dynamic and secure updates, and notifications
+  ##   "memory"        - caching and database memory
+  # collect = ["queries", "recursion", "zone_transfer", "updates", "memory"]
+`
+
+// single instance objects
+var noInstance = []string{"------"}
+
+// counterSets maps the collect options to the DNS server counters gathered
+// for them. The DNS object only exists with the DNS Server role installed.
+var counterSets = map[string][]winperfcounters.CounterSet{
+	"queries": {
+		{
+			Object:      "DNS",
+			Measurement: "win_dns_queries",
+			Instances:   noInstance,
+			Counters: []string{
+				"Total Query Received/sec",
+				"Total Response Sent/sec",
+				"UDP Query Received/sec",
+				"UDP Response Sent/sec",
+				"TCP Query Received/sec",
+				"TCP Response Sent/sec",
+				"Total Query Received",
+				"Total Response Sent",
+			},
+		},
+	},
+	"recursion": {
+		{
+			Object:      "DNS",
+			Measurement: "win_dns_recursion",
+			Instances:   noInstance,
+			Counters: []string{
+				"Recursive Queries/sec",
+				"Recursive Send TimeOuts/sec",
+				"Recursive Query Failure/sec",
+				"Recursive TimeOut/sec",
+			},
+		},
+	},
+	"zone_transfer": {
+		{
+			Object:      "DNS",
+			Measurement: "win_dns_zone_transfer",
+			Instances:   noInstance,
+			Counters: []string{
+				"Zone Transfer Request Received",
+				"Zone Transfer Success",
+				"Zone Transfer Failure",
+				"Zone Transfer SOA Request Sent",
+			},
+		},
+	},
+	"updates": {
+		{
+			Object:      "DNS",
+			Measurement: "win_dns_updates",
+			Instances:   noInstance,
+			Counters: []string{
+				"Dynamic Update Received/sec",
+				"Dynamic Update Rejected",
+				"Dynamic Update TimeOuts",
+				"Secure Update Received/sec",
+				"Secure Update Failure",
+				"Notify Received/sec",
+				"Notify Sent/sec",
+			},
+		},
+	},
+	"memory": {
+		{
+			Object:      "DNS",
+			Measurement: "win_dns_memory",
+			Instances:   noInstance,
+			Counters: []string{
+				"Caching Memory",
+				"Database Node Memory",
+				"Record Flow Memory",
+			},
+		},
+	},
+}
+
+var defaultCollect = []string{"queries", "recursion", "zone_transfer", "updates", "memory"}
+
+type DNSServer struct {
+	Collect []string `toml:"collect"`
+
+	Log cua.Logger `toml:"-"`
+
+	perf *winperfcounters.WinPerfCounters
+}
+
+func (d *DNSServer) Description() string {
+	return "Windows DNS Server query, recursion, zone transfer and update statistics"
+}
+
+func (d *DNSServer) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DNSServer) Init() error {
+	if len(d.Collect) == 0 {
+		d.Collect = defaultCollect
+	}
+
+	var err error
+	// the DNS object is single instance, there is nothing to rediscover
+	d.perf, err = winperfcounters.NewPreset(counterSets, d.Collect, internal.Duration{}, d.Log)
+	return err //nolint:wrapcheck
+}
+
+func (d *DNSServer) Gather(ctx context.Context, acc cua.Accumulator) error {
+	return d.perf.Gather(ctx, acc) //nolint:wrapcheck
+}
+
+func init() {
+	inputs.Add("win_dns", func() cua.Input {
+		return &DNSServer{}
+	})
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package windns
+
+import (
+	"testing"
+
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCounterSets(t *testing.T) {
+	d := &DNSServer{Collect: []string{"queries", "recursion"}, Log: testutil.Logger{}}
+	require.NoError(t, d.Init())
+	require.Len(t, d.perf.Object, 2)
+	require.Equal(t, "win_dns_queries", d.perf.Object[0].Measurement)
+	require.Equal(t, "DNS", d.perf.Object[1].ObjectName)
+
+	d = &DNSServer{Collect: []string{"dnssec"}, Log: testutil.Logger{}}
+	require.Error(t, d.Init())
+}
@@ -11,11 +11,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"unicode/utf16"
 	"unicode/utf8"
 	"unsafe"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"golang.org/x/sys/windows"
 )
 
@@ -155,3 +157,61 @@ func UniqueFieldNames(fields []EventField, fieldsUsage map[string]int, separator
 	}
 	return fieldsUnique
 }
+
+// eventCounts tallies events by their tag set when count_events is enabled
+type eventCounts map[string]*eventCount
+
+type eventCount struct {
+	tags  map[string]string
+	count uint64
+}
+
+func (c eventCounts) add(tags map[string]string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(tags[k])
+		sb.WriteByte(0)
+	}
+	key := sb.String()
+
+	if ec, ok := c[key]; ok {
+		ec.count++
+		return
+	}
+	c[key] = &eventCount{tags: tags, count: 1}
+}
+
+func (c eventCounts) emit(acc cua.Accumulator) {
+	for _, ec := range c {
+		acc.AddFields("win_eventlog_count", map[string]interface{}{"count": ec.count}, ec.tags)
+	}
+}
+
+// eventTitle answers the title of an event added with events enabled, the
+// event source and id, e.g. "Microsoft-Windows-Security-Auditing 4625"
+func eventTitle(event Event) string {
+	return fmt.Sprintf("%s %d", event.Source.Name, event.EventID)
+}
+
+// eventSeverity answers the severity of an event level, levels above 3
+// (verbose and custom levels) are info
+func eventSeverity(level int) cua.Severity {
+	switch level {
+	case 1:
+		return cua.Critical
+	case 2:
+		return cua.Error
+	case 3:
+		return cua.Warning
+	default:
+		return cua.Info
+	}
+}
@@ -13,6 +13,10 @@ import (
 	"reflect"
 	"testing"
 	"unicode/utf16"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecodeUTF16(t *testing.T) {
@@ -202,3 +206,30 @@ func TestUniqueFieldNames(t *testing.T) {
 		})
 	}
 }
+
+func TestEventCounts(t *testing.T) {
+	counts := eventCounts{}
+	counts.add(map[string]string{"EventID": "4625", "Channel": "Security"})
+	counts.add(map[string]string{"Channel": "Security", "EventID": "4625"})
+	counts.add(map[string]string{"EventID": "4624", "Channel": "Security"})
+
+	var acc testutil.Accumulator
+	counts.emit(&acc)
+
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(2)},
+		map[string]string{"EventID": "4625", "Channel": "Security"})
+	acc.AssertContainsTaggedFields(t, "win_eventlog_count",
+		map[string]interface{}{"count": uint64(1)},
+		map[string]string{"EventID": "4624", "Channel": "Security"})
+}
+
+func TestEventSeverity(t *testing.T) {
+	require.Equal(t, cua.Critical, eventSeverity(1))
+	require.Equal(t, cua.Error, eventSeverity(2))
+	require.Equal(t, cua.Warning, eventSeverity(3))
+	require.Equal(t, cua.Info, eventSeverity(4))
+	require.Equal(t, cua.Info, eventSeverity(0))
+	require.Equal(t, "Microsoft-Windows-Security-Auditing 4625",
+		eventTitle(Event{Source: Provider{Name: "Microsoft-Windows-Security-Auditing"}, EventID: 4625}))
+}
@@ -101,6 +101,20 @@ var sampleConfig = `
 
   ## Skip those tags or fields if their value is empty or equals to zero. Globbing supported
   exclude_empty = ["*ActivityID", "UserID"]
+
+  ## Read the events already in the log when the agent starts, rather than
+  ## only the events logged after the subscription is made
+  # from_beginning = false
+
+  ## Instead of one metric per event, emit a win_eventlog_count metric per
+  ## distinct set of event_tags with the number of matching events since the
+  ## last gather. Useful for alerting on e.g. failed logon events (4625)
+  ## without sending every event.
+  # count_events = false
+
+  ## Instead of a metric, add each event as an event titled after its Source
+  ## and EventID, with the Message as its body and a severity from its Level.
+  # events = false
 `
 
 // WinEventLog config
@@ -117,6 +131,9 @@ type WinEventLog struct {
 	EventFields            []string `toml:"event_fields"`
 	ExcludeFields          []string `toml:"exclude_fields"`
 	ExcludeEmpty           []string `toml:"exclude_empty"`
+	FromBeginning          bool     `toml:"from_beginning"`
+	CountEvents            bool     `toml:"count_events"`
+	Events                 bool     `toml:"events"`
 	subscription           EvtHandle
 	buf                    []byte
 	Log                    cua.Logger
@@ -148,6 +165,9 @@ func (w *WinEventLog) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 	w.Log.Debug("Subscription handle id:", w.subscription)
 
+	counts := eventCounts{}
+	defer counts.emit(acc)
+
 loop:
 	for {
 		events, err := w.fetchEvents(w.subscription)
@@ -182,12 +202,13 @@ loop:
 					fieldValue = event.Source.Name
 					fieldType = reflect.TypeOf(fieldValue).String()
 				case "Execution":
-					fieldValue := event.Execution.ProcessID
+					pid := event.Execution.ProcessID
+					fieldValue = pid
 					fieldType = reflect.TypeOf(fieldValue).String()
 					fieldName = "ProcessID"
 					// Look up Process Name from pid
 					if should, _ := w.shouldProcessField("ProcessName"); should {
-						_, _, processName, err := GetFromSnapProcess(fieldValue)
+						_, _, processName, err := GetFromSnapProcess(pid)
 						if err == nil {
 							computedValues["ProcessName"] = processName
 						}
@@ -272,11 +293,25 @@ loop:
 			}
 			uniqueXMLFields := UniqueFieldNames(xmlFields, fieldsUsage, w.Separator)
 			for _, xmlField := range uniqueXMLFields {
+				if _, where := w.shouldProcessField(xmlField.Name); where == "tags" {
+					tags[xmlField.Name] = xmlField.Value
+					continue
+				}
 				if !w.shouldExclude(xmlField.Name) {
 					fields[xmlField.Name] = xmlField.Value
 				}
 			}
 
+			if w.CountEvents {
+				counts.add(tags)
+				continue
+			}
+
+			if w.Events {
+				acc.AddEvent(eventTitle(event), event.Message, eventSeverity(event.Level), tags, timeStamp)
+				continue
+			}
+
 			// Pass collected metrics
 			acc.AddFields("win_eventlog", fields, tags, timeStamp)
 		}
@@ -349,8 +384,13 @@ func (w *WinEventLog) evtSubscribe(logName, xquery string) (EvtHandle, error) {
 		return 0, fmt.Errorf("win_eventlog evtSubscribe ptrfromstr: %w", err)
 	}
 
+	flags := EvtSubscribeToFutureEvents
+	if w.FromBeginning {
+		flags = EvtSubscribeStartAtOldestRecord
+	}
+
 	subsHandle, err := _EvtSubscribe(0, uintptr(sigEvent), logNamePtr, xqueryPtr,
-		0, 0, 0, EvtSubscribeToFutureEvents)
+		0, 0, 0, flags)
 	if err != nil {
 		return 0, err
 	}
@@ -31,6 +31,11 @@ var sampleConfig = `
   # and in case of localized Windows, counter paths will be also localized. It also returns instance indexes in instance names.
   # If false, wildcards (not partial) in instance names will still be expanded, but instance indexes will not be returned in instance names.
   #UseWildcardsExpansion = false
+  # When running on a localized version of Windows and with UseWildcardsExpansion = true, Windows will
+  # localize object and counter names. When LocalizeWildcardsExpansion = false, the English object and
+  # counter names from the configuration are kept in metric and field names, only instance names are
+  # taken from the expansion. Wildcards in counter names are still reported localized.
+  #LocalizeWildcardsExpansion = true
   # Period after which counters will be reread from configuration and wildcards in counter paths expanded
   CountersRefreshInterval="1m"
 
@@ -149,6 +154,9 @@ type WinPerfCounters struct {
 	Object                  []perfobject
 	CountersRefreshInterval internal.Duration
 	UseWildcardsExpansion   bool
+	// LocalizeWildcardsExpansion keeps localized object and counter names
+	// returned by wildcard expansion
+	LocalizeWildcardsExpansion bool
 
 	Log cua.Logger
 
@@ -183,6 +191,9 @@ type instanceGrouping struct {
 	objectname string
 }
 
+// emptyInstance is the configured instance of single instance objects
+const emptyInstance = "------"
+
 var sanitizedChars = strings.NewReplacer("/sec", "_persec", "/Sec", "_persec",
 	" ", "_", "%", "Percent", `\`, "")
 
@@ -240,6 +251,15 @@ func extractCounterInfoFromCounterPath(counterPath string) (object string, insta
 	return
 }
 
+// formatPath builds a counter path from its parts, the instance is omitted
+// for single instance objects
+func formatPath(objectName, instance, counterName string) string {
+	if instance == "" || instance == emptyInstance {
+		return "\\" + objectName + "\\" + counterName
+	}
+	return "\\" + objectName + "(" + instance + ")\\" + counterName
+}
+
 func (m *WinPerfCounters) Description() string {
 	return "Input plugin to counterPath Performance Counters on Windows operating systems"
 }
@@ -267,6 +287,7 @@ func (m *WinPerfCounters) AddItem(counterPath string, objectName string, instanc
 
 	if m.UseWildcardsExpansion {
 		origInstance := instance
+		origObjectName, origCounterName := objectName, counterName
 		counterPath, err = m.query.GetCounterPath(counterHandle)
 		if err != nil {
 			return fmt.Errorf("win_perf_counters add getCounterPath: %w", err)
@@ -278,8 +299,6 @@ func (m *WinPerfCounters) AddItem(counterPath string, objectName string, instanc
 
 		for _, counterPath := range counters {
 			var err error
-			counterHandle, _ := m.query.AddCounterToQuery(counterPath)
-
 			objectName, instance, counterName, err = extractCounterInfoFromCounterPath(counterPath)
 			if err != nil {
 				return err
@@ -289,6 +308,21 @@ func (m *WinPerfCounters) AddItem(counterPath string, objectName string, instanc
 				continue
 			}
 
+			var counterHandle PdhHCounter
+			if !m.LocalizeWildcardsExpansion && m.query.IsVistaOrNewer() && !strings.Contains(origCounterName, "*") {
+				// the expansion returns localized paths, re-add the counter by
+				// its configured English names with the expanded instance
+				objectName, counterName = origObjectName, origCounterName
+				counterPath = formatPath(objectName, instance, counterName)
+				counterHandle, err = m.query.AddEnglishCounterToQuery(counterPath)
+			} else {
+				counterHandle, err = m.query.AddCounterToQuery(counterPath)
+			}
+			if err != nil {
+				m.Log.Warnf("unable to add expanded counter %q, skipping: %s", counterPath, err)
+				continue
+			}
+
 			newItem := &counter{counterPath, objectName, counterName, instance, measurement,
 				includeTotal, counterHandle}
 			m.counters = append(m.counters, newItem)
@@ -318,11 +352,7 @@ func (m *WinPerfCounters) ParseConfig() error {
 				for _, instance := range PerfObject.Instances {
 					objectname := PerfObject.ObjectName
 
-					if instance == "------" {
-						counterPath = "\\" + objectname + "\\" + counter
-					} else {
-						counterPath = "\\" + objectname + "(" + instance + ")\\" + counter
-					}
+					counterPath = formatPath(objectname, instance, counter)
 
 					err := m.AddItem(counterPath, objectname, instance, counter, PerfObject.Measurement, PerfObject.IncludeTotal)
 
@@ -448,7 +478,7 @@ func shouldIncludeMetric(metric *counter, cValue CounterValue) bool {
 		// Catch if we set it to total or some form of it
 		return true
 	}
-	if metric.instance == "------" {
+	if metric.instance == emptyInstance {
 		return true
 	}
 	return false
@@ -478,8 +508,65 @@ func isKnownCounterDataError(err error) bool {
 	return false
 }
 
+// New returns a WinPerfCounters with the default settings, for inputs that
+// collect a predefined set of performance objects
+func New() *WinPerfCounters {
+	return &WinPerfCounters{
+		query:                      &PerformanceQueryImpl{},
+		CountersRefreshInterval:    internal.Duration{Duration: time.Second * 60},
+		LocalizeWildcardsExpansion: true,
+	}
+}
+
+// AddObject adds a performance object to collect
+func (m *WinPerfCounters) AddObject(objectName, measurement string, instances, counters []string, includeTotal bool) {
+	m.Object = append(m.Object, perfobject{
+		ObjectName:   objectName,
+		Instances:    instances,
+		Counters:     counters,
+		Measurement:  measurement,
+		IncludeTotal: includeTotal,
+	})
+}
+
+// CounterSet is a performance object collected by the inputs built on this
+// plugin, e.g. hyperv and iis
+type CounterSet struct {
+	Object      string
+	Measurement string
+	Counters    []string
+	// Instances defaults to all instances
+	Instances []string
+}
+
+// NewPreset returns a WinPerfCounters collecting the named counter sets.
+// Wildcards are expanded but object and counter names are kept in English,
+// so metric names do not depend on the language of the host.
+func NewPreset(sets map[string][]CounterSet, collect []string, refresh internal.Duration, log cua.Logger) (*WinPerfCounters, error) {
+	m := New()
+	m.Log = log
+	m.UseWildcardsExpansion = true
+	m.LocalizeWildcardsExpansion = false
+	m.CountersRefreshInterval = refresh
+
+	for _, name := range collect {
+		objects, ok := sets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown counter set %q", name)
+		}
+		for _, cs := range objects {
+			instances := cs.Instances
+			if len(instances) == 0 {
+				instances = []string{"*"}
+			}
+			m.AddObject(cs.Object, cs.Measurement, instances, cs.Counters, false)
+		}
+	}
+	return m, nil
+}
+
 func init() {
 	inputs.Add("win_perf_counters", func() cua.Input {
-		return &WinPerfCounters{query: &PerformanceQueryImpl{}, CountersRefreshInterval: internal.Duration{Duration: time.Second * 60}}
+		return New()
 	})
 }
@@ -506,6 +506,48 @@ func TestParseConfigExpand(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestParseConfigLocalizedExpansion(t *testing.T) {
+	// the English path resolves to a localized path, which the wildcard
+	// expansion is performed on
+	counters := map[string]testCounter{
+		"\\O(*)\\C":   {handle: 0, path: "\\L(*)\\LC"},
+		"\\L(I1)\\LC": {handle: 1, path: "\\L(I1)\\LC"},
+		"\\L(I2)\\LC": {handle: 2, path: "\\L(I2)\\LC"},
+		"\\O(I1)\\C":  {handle: 3, path: "\\O(I1)\\C"},
+		"\\O(I2)\\C":  {handle: 4, path: "\\O(I2)\\C"},
+	}
+	expandPaths := map[string][]string{
+		"\\L(*)\\LC": {"\\L(I1)\\LC", "\\L(I2)\\LC"},
+	}
+
+	for _, localize := range []bool{true, false} {
+		m := WinPerfCounters{
+			Log:                        testutil.Logger{},
+			UseWildcardsExpansion:      true,
+			LocalizeWildcardsExpansion: localize,
+			Object:                     createPerfObject("m", "O", []string{"*"}, []string{"C"}, true, false),
+			query: &FakePerformanceQuery{
+				counters:      counters,
+				expandPaths:   expandPaths,
+				vistaAndNewer: true,
+			}}
+		require.NoError(t, m.query.Open())
+		require.NoError(t, m.ParseConfig())
+		require.Len(t, m.counters, 2)
+
+		object, counter, path := "L", "LC", "\\L(I1)\\LC"
+		if !localize {
+			object, counter, path = "O", "C", "\\O(I1)\\C"
+		}
+		assert.Equal(t, object, m.counters[0].objectName)
+		assert.Equal(t, counter, m.counters[0].counter)
+		assert.Equal(t, "I1", m.counters[0].instance)
+		assert.Equal(t, path, m.counters[0].counterPath)
+		assert.Equal(t, "I2", m.counters[1].instance)
+		require.NoError(t, m.query.Close())
+	}
+}
+
 func TestSimpleGather(t *testing.T) {
 	var err error
 	if testing.Short() {
@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/inputs"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
@@ -83,11 +85,15 @@ func (rmr *MgProvider) Connect() (WinServiceManager, error) {
 var sampleConfig = `
   instance_id = "" # unique instance identifier (REQUIRED)
 
-  ## Names of the services to monitor. Leave empty to monitor all the available services on the host
+  ## Names of the services to monitor. Leave empty to monitor all the available services on the host.
+  ## Glob patterns are supported, e.g. "SQL*" matches all SQL Server services.
   service_names = [
     "LanmanServer",
     "TermService",
   ]
+
+  ## Names of services to skip, glob patterns are supported.
+  # excluded_service_names = []
 `
 
 var description = "Input plugin to report Windows services info."
@@ -96,8 +102,11 @@ var description = "Input plugin to report Windows services info."
 type WinServices struct {
 	Log cua.Logger
 
-	ServiceNames []string `toml:"service_names"`
-	mgrProvider  ManagerProvider
+	ServiceNames         []string `toml:"service_names"`
+	ServiceNamesExcluded []string `toml:"excluded_service_names"`
+
+	mgrProvider    ManagerProvider
+	servicesFilter filter.Filter
 }
 
 type ServiceInfo struct {
@@ -115,6 +124,15 @@ func (m *WinServices) SampleConfig() string {
 	return sampleConfig
 }
 
+func (m *WinServices) Init() error {
+	var err error
+	m.servicesFilter, err = filter.NewIncludeExcludeFilter(m.ServiceNames, m.ServiceNamesExcluded)
+	if err != nil {
+		return fmt.Errorf("compiling service name filter: %w", err)
+	}
+	return nil
+}
+
 func (m *WinServices) Gather(ctx context.Context, acc cua.Accumulator) error {
 	scmgr, err := m.mgrProvider.Connect()
 	if err != nil {
@@ -122,7 +140,7 @@ func (m *WinServices) Gather(ctx context.Context, acc cua.Accumulator) error {
 	}
 	defer func() { _ = scmgr.Disconnect() }()
 
-	serviceNames, err := listServices(scmgr, m.ServiceNames)
+	serviceNames, err := m.listServices(scmgr)
 	if err != nil {
 		return err
 	}
@@ -156,17 +174,38 @@ func (m *WinServices) Gather(ctx context.Context, acc cua.Accumulator) error {
 	return nil
 }
 
-// listServices returns a list of services to gather.
-func listServices(scmgr WinServiceManager, userServices []string) ([]string, error) {
-	if len(userServices) != 0 {
-		return userServices, nil
+// listServices returns a list of services to gather. Configured names are
+// used as is unless they contain wildcards or exclusions are configured, in
+// which case the services on the host are listed and filtered.
+func (m *WinServices) listServices(scmgr WinServiceManager) ([]string, error) {
+	if len(m.ServiceNames) != 0 && len(m.ServiceNamesExcluded) == 0 && !hasWildcards(m.ServiceNames) {
+		return m.ServiceNames, nil
 	}
 
 	names, err := scmgr.ListServices()
 	if err != nil {
 		return nil, fmt.Errorf("could not list services: %w", err)
 	}
-	return names, nil
+	if m.servicesFilter == nil {
+		return names, nil
+	}
+
+	services := make([]string, 0, len(names))
+	for _, name := range names {
+		if m.servicesFilter.Match(name) {
+			services = append(services, name)
+		}
+	}
+	return services, nil
+}
+
+func hasWildcards(names []string) bool {
+	for _, name := range names {
+		if strings.ContainsAny(name, "*?[") {
+			return true
+		}
+	}
+	return false
 }
 
 // collectServiceInfo gathers info about a service.
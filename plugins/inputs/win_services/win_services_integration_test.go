@@ -24,7 +24,9 @@ func TestList(t *testing.T) {
 	require.NoError(t, err)
 	defer func() { _ = scmgr.Disconnect() }()
 
-	services, err := listServices(scmgr, KnownServices)
+	ws := &WinServices{ServiceNames: KnownServices}
+	require.NoError(t, ws.Init())
+	services, err := ws.listServices(scmgr)
 	require.NoError(t, err)
 	require.Len(t, services, 2, "Different number of services")
 	require.Equal(t, services[0], KnownServices[0])
@@ -40,7 +42,9 @@ func TestEmptyList(t *testing.T) {
 	require.NoError(t, err)
 	defer func() { _ = scmgr.Disconnect() }()
 
-	services, err := listServices(scmgr, []string{})
+	ws := &WinServices{}
+	require.NoError(t, ws.Init())
+	services, err := ws.listServices(scmgr)
 	require.NoError(t, err)
 	require.Condition(t, func() bool { return len(services) > 20 }, "Too few service")
 }
@@ -127,28 +127,28 @@ var testErrors = []testData{
 
 func TestBasicInfo(t *testing.T) {
 
-	winServices := &WinServices{testutil.Logger{}, nil, &FakeMgProvider{testErrors[0]}}
+	winServices := &WinServices{Log: testutil.Logger{}, mgrProvider: &FakeMgProvider{testErrors[0]}}
 	assert.NotEmpty(t, winServices.SampleConfig())
 	assert.NotEmpty(t, winServices.Description())
 }
 
 func TestMgrErrors(t *testing.T) {
 	// mgr.connect error
-	winServices := &WinServices{testutil.Logger{}, nil, &FakeMgProvider{testErrors[0]}}
+	winServices := &WinServices{Log: testutil.Logger{}, mgrProvider: &FakeMgProvider{testErrors[0]}}
 	var acc1 testutil.Accumulator
 	err := winServices.Gather(context.Background(), &acc1)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), testErrors[0].mgrConnectError.Error())
 
 	// mgr.listServices error
-	winServices = &WinServices{testutil.Logger{}, nil, &FakeMgProvider{testErrors[1]}}
+	winServices = &WinServices{Log: testutil.Logger{}, mgrProvider: &FakeMgProvider{testErrors[1]}}
 	var acc2 testutil.Accumulator
 	err = winServices.Gather(context.Background(), &acc2)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), testErrors[1].mgrListServicesError.Error())
 
 	// mgr.listServices error 2
-	winServices = &WinServices{testutil.Logger{}, []string{"Fake service 1"}, &FakeMgProvider{testErrors[3]}}
+	winServices = &WinServices{Log: testutil.Logger{}, ServiceNames: []string{"Fake service 1"}, mgrProvider: &FakeMgProvider{testErrors[3]}}
 	var acc3 testutil.Accumulator
 
 	buf := &bytes.Buffer{}
@@ -159,7 +159,7 @@ func TestMgrErrors(t *testing.T) {
 }
 
 func TestServiceErrors(t *testing.T) {
-	winServices := &WinServices{testutil.Logger{}, nil, &FakeMgProvider{testErrors[2]}}
+	winServices := &WinServices{Log: testutil.Logger{}, mgrProvider: &FakeMgProvider{testErrors[2]}}
 	var acc1 testutil.Accumulator
 
 	buf := &bytes.Buffer{}
@@ -182,7 +182,7 @@ var testSimpleData = []testData{
 }
 
 func TestGather2(t *testing.T) {
-	winServices := &WinServices{testutil.Logger{}, nil, &FakeMgProvider{testSimpleData[0]}}
+	winServices := &WinServices{Log: testutil.Logger{}, mgrProvider: &FakeMgProvider{testSimpleData[0]}}
 	var acc1 testutil.Accumulator
 	require.NoError(t, winServices.Gather(context.Background(), &acc1))
 	assert.Len(t, acc1.Errors, 0, "There should be no errors after gather")
@@ -197,3 +197,28 @@ func TestGather2(t *testing.T) {
 		acc1.AssertContainsTaggedFields(t, "win_services", fields, tags)
 	}
 }
+
+func TestGatherFilter(t *testing.T) {
+	data := testData{
+		queryServiceList: []string{"Service 1", "Service 2", "Other"},
+		services: []serviceTestInfo{
+			{nil, nil, nil, "Service 1", "Fake service 1", 1, 2},
+			{nil, nil, nil, "Service 2", "Fake service 2", 4, 2},
+			{nil, nil, nil, "Other", "Fake other", 4, 3},
+		},
+	}
+	winServices := &WinServices{
+		Log:                  testutil.Logger{},
+		ServiceNames:         []string{"Service*"},
+		ServiceNamesExcluded: []string{"Service 2"},
+		mgrProvider:          &FakeMgProvider{data},
+	}
+	require.NoError(t, winServices.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, winServices.Gather(context.Background(), &acc))
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "win_services",
+		map[string]interface{}{"state": 1, "startup_mode": 2},
+		map[string]string{"service_name": "Service 1", "display_name": "Fake service 1"})
+}
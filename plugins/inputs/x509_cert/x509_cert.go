@@ -260,6 +260,9 @@ func (c *X509Cert) Init() error {
 	}
 	if tlsCfg == nil {
 		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		if err := _tls.ApplyFIPS(tlsCfg); err != nil {
+			return fmt.Errorf("TLSConfig: %w", err)
+		}
 	}
 
 	c.tlsCfg = tlsCfg
@@ -1,9 +1,22 @@
+//go:build !lite
+// +build !lite
+
 package all
 
 //nolint:golint
 import (
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/amqp"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/circonus"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/execd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/file"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/graphite"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/health"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/http"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/influxdb_v2"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/kafka"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/nats"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/opentelemetry"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/prometheus_client"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/syslog"
 )
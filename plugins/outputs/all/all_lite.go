@@ -0,0 +1,14 @@
+//go:build lite
+// +build lite
+
+package all
+
+// reduced set of plugins of agents built with the lite tag for edge devices
+
+//nolint:golint
+import (
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/circonus"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/discard"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/file"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/outputs/health"
+)
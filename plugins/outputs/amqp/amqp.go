@@ -0,0 +1,376 @@
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+	"github.com/streadway/amqp"
+)
+
+const (
+	DefaultURL             = "amqp://localhost:5672/circonus"
+	DefaultAuthMethod      = "PLAIN"
+	DefaultExchangeType    = "topic"
+	DefaultExchangeDurable = "durable"
+	DefaultDeliveryMode    = "persistent"
+	DefaultTimeout         = 5 * time.Second
+)
+
+var sampleConfig = `
+  ## Brokers to publish to.  If multiple brokers are specified a random broker
+  ## will be selected anytime a connection is established.  This can be
+  ## helpful for load balancing when not using a dedicated load balancer.
+  brokers = ["amqp://localhost:5672/circonus"]
+
+  ## Maximum messages to send over a connection.  Once this is reached, the
+  ## connection is closed and a new connection is made.  This can be helpful for
+  ## load balancing when not using a dedicated load balancer.
+  # max_messages = 0
+
+  ## Exchange to declare and publish to.  If empty, messages are published
+  ## to the default exchange and the routing key names the queue.
+  exchange = "circonus"
+
+  ## Exchange type; common types are "direct", "fanout", "topic", "header", "x-consistent-hash".
+  # exchange_type = "topic"
+
+  ## If true, exchange will be passively declared.
+  # exchange_passive = false
+
+  ## Exchange durability can be either "transient" or "durable".
+  # exchange_durability = "durable"
+
+  ## Additional exchange arguments.
+  # exchange_arguments = { }
+  # exchange_arguments = {"hash_property" = "timestamp"}
+
+  ## Authentication credentials for the PLAIN auth_method.
+  # username = ""
+  # password = ""
+
+  ## Auth method. PLAIN and EXTERNAL are supported
+  ## Using EXTERNAL requires enabling the rabbitmq_auth_mechanism_ssl plugin as
+  ## described here: https://www.rabbitmq.com/plugins.html
+  # auth_method = "PLAIN"
+
+  ## Metric tag to use as a routing key.
+  ##   ie, if this tag exists, its value will be used as the routing key
+  # routing_tag = "host"
+
+  ## Static routing key.  Used when no routing_tag is set or as a fallback
+  ## when the tag specified in routing tag is not found.  If set to "random",
+  ## a random value will be generated for each message.
+  ##   ex: routing_key = "random"
+  ##       routing_key = "circonus"
+  # routing_key = ""
+
+  ## Delivery Mode controls if a published message is persistent.
+  ##   One of "transient" or "persistent".
+  # delivery_mode = "persistent"
+
+  ## Static headers added to each published message.
+  # headers = { }
+  # headers = {"database" = "circonus", "retention_policy" = "default"}
+
+  ## Connection and publisher confirm timeout.  A batch is only considered
+  ## written once the broker confirmed every message of it.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## If true use batch serialization format instead of line based delimiting.
+  ## Only applies to data formats which are not line based such as JSON.
+  ## Metrics of a batch are grouped by routing key, one message per key.
+  # use_batch_format = false
+
+  ## Content encoding for message payloads, can be set to "gzip" or
+  ## "identity" to apply no encoding.
+  # content_encoding = "identity"
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "json"
+`
+
+type externalAuth struct{}
+
+func (a *externalAuth) Mechanism() string {
+	return "EXTERNAL"
+}
+func (a *externalAuth) Response() string {
+	return "\000"
+}
+
+type AMQP struct {
+	Brokers            []string          `toml:"brokers"`
+	Exchange           string            `toml:"exchange"`
+	ExchangeType       string            `toml:"exchange_type"`
+	ExchangeDurability string            `toml:"exchange_durability"`
+	ExchangePassive    bool              `toml:"exchange_passive"`
+	ExchangeArguments  map[string]string `toml:"exchange_arguments"`
+	Username           string            `toml:"username"`
+	Password           string            `toml:"password"`
+	MaxMessages        int               `toml:"max_messages"`
+	AuthMethod         string            `toml:"auth_method"`
+	RoutingTag         string            `toml:"routing_tag"`
+	RoutingKey         string            `toml:"routing_key"`
+	DeliveryMode       string            `toml:"delivery_mode"`
+	Headers            map[string]string `toml:"headers"`
+	Timeout            internal.Duration `toml:"timeout"`
+	UseBatchFormat     bool              `toml:"use_batch_format"`
+	ContentEncoding    string            `toml:"content_encoding"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	serializer   serializers.Serializer
+	connect      func(*clientConfig) (client, error)
+	client       client
+	config       *clientConfig
+	sentMessages int
+	encoder      internal.ContentEncoder
+}
+
+func (q *AMQP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *AMQP) Description() string {
+	return "Publishes metrics to an AMQP broker"
+}
+
+func (q *AMQP) SetSerializer(serializer serializers.Serializer) {
+	q.serializer = serializer
+}
+
+func (q *AMQP) Init() error {
+	var err error
+	q.config, err = q.makeClientConfig()
+	if err != nil {
+		return err
+	}
+
+	q.encoder, err = internal.NewContentEncoder(q.ContentEncoding)
+	if err != nil {
+		return fmt.Errorf("content encoder: %w", err)
+	}
+
+	return nil
+}
+
+func (q *AMQP) Connect() error {
+	var err error
+	q.client, err = q.connect(q.config)
+	return err
+}
+
+func (q *AMQP) Close() error {
+	if q.client != nil {
+		err := q.client.Close()
+		q.client = nil
+		return err
+	}
+	return nil
+}
+
+func (q *AMQP) routingKey(metric cua.Metric) string {
+	if q.RoutingTag != "" {
+		if key, ok := metric.GetTag(q.RoutingTag); ok {
+			return key
+		}
+	}
+
+	if q.RoutingKey == "random" {
+		return internal.RandomString(16)
+	}
+	return q.RoutingKey
+}
+
+func (q *AMQP) Write(metrics []cua.Metric) (int, error) {
+	msgs := q.messages(metrics)
+	if len(msgs) == 0 {
+		return len(metrics), nil
+	}
+
+	if q.client == nil {
+		if err := q.Connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := q.client.Publish(msgs); err != nil {
+		// the connection or channel is in an unknown state, start over
+		// with a fresh one on the next write
+		_ = q.Close()
+		return 0, err
+	}
+
+	q.sentMessages += len(msgs)
+	if q.MaxMessages > 0 && q.sentMessages >= q.MaxMessages {
+		q.Log.Debug("Sent MaxMessages; closing connection")
+		if err := q.Close(); err != nil {
+			q.Log.Errorf("Closing connection: %s", err)
+		}
+		q.sentMessages = 0
+	}
+
+	return len(metrics), nil
+}
+
+// messages serializes the metrics, one message per metric or, in batch
+// format, one per routing key. Metrics which cannot be serialized are
+// logged and dropped.
+func (q *AMQP) messages(metrics []cua.Metric) []message {
+	msgs := make([]message, 0, len(metrics))
+
+	if q.UseBatchFormat {
+		keys := []string{}
+		batches := make(map[string][]cua.Metric)
+		for _, metric := range metrics {
+			key := q.routingKey(metric)
+			if _, ok := batches[key]; !ok {
+				keys = append(keys, key)
+			}
+			batches[key] = append(batches[key], metric)
+		}
+		for _, key := range keys {
+			body, err := q.serializer.SerializeBatch(batches[key])
+			if err != nil {
+				q.Log.Errorf("Could not serialize metrics: %v", err)
+				continue
+			}
+			msgs = q.appendMessage(msgs, key, body)
+		}
+		return msgs
+	}
+
+	for _, metric := range metrics {
+		body, err := q.serializer.Serialize(metric)
+		if err != nil {
+			q.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+		msgs = q.appendMessage(msgs, q.routingKey(metric), body)
+	}
+	return msgs
+}
+
+func (q *AMQP) appendMessage(msgs []message, key string, body []byte) []message {
+	body, err := q.encoder.Encode(body)
+	if err != nil {
+		q.Log.Errorf("Could not encode message: %v", err)
+		return msgs
+	}
+	return append(msgs, message{key: key, body: body})
+}
+
+func (q *AMQP) makeClientConfig() (*clientConfig, error) {
+	if len(q.Brokers) == 0 {
+		return nil, errors.New("no brokers specified")
+	}
+
+	config := &clientConfig{
+		brokers:         q.Brokers,
+		exchange:        q.Exchange,
+		exchangeType:    q.ExchangeType,
+		exchangePassive: q.ExchangePassive,
+		timeout:         q.Timeout.Duration,
+		log:             q.Log,
+	}
+
+	switch q.ExchangeDurability {
+	case "transient":
+		config.exchangeDurable = false
+	case "durable", "":
+		config.exchangeDurable = true
+	default:
+		return nil, fmt.Errorf("invalid exchange_durability %q", q.ExchangeDurability)
+	}
+
+	switch q.DeliveryMode {
+	case "transient":
+		config.deliveryMode = amqp.Transient
+	case "persistent", "":
+		config.deliveryMode = amqp.Persistent
+	default:
+		return nil, fmt.Errorf("invalid delivery_mode %q", q.DeliveryMode)
+	}
+
+	switch q.ContentEncoding {
+	case "gzip":
+		config.contentEncoding = "gzip"
+	case "identity", "":
+	default:
+		return nil, fmt.Errorf("invalid content_encoding %q", q.ContentEncoding)
+	}
+
+	tlsConfig, err := q.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("TLSConfig: %w", err)
+	}
+	config.tlsConfig = tlsConfig
+
+	switch strings.ToUpper(q.AuthMethod) {
+	case "EXTERNAL":
+		config.auth = []amqp.Authentication{&externalAuth{}}
+	case "PLAIN", "":
+		if q.Username != "" || q.Password != "" {
+			config.auth = []amqp.Authentication{
+				&amqp.PlainAuth{
+					Username: q.Username,
+					Password: q.Password,
+				},
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid auth_method %q", q.AuthMethod)
+	}
+
+	if len(q.ExchangeArguments) > 0 {
+		config.exchangeArguments = make(amqp.Table, len(q.ExchangeArguments))
+		for k, v := range q.ExchangeArguments {
+			config.exchangeArguments[k] = v
+		}
+	}
+
+	if len(q.Headers) > 0 {
+		config.headers = make(amqp.Table, len(q.Headers))
+		for k, v := range q.Headers {
+			config.headers[k] = v
+		}
+	}
+
+	return config, nil
+}
+
+func connect(config *clientConfig) (client, error) {
+	return newClient(config)
+}
+
+func init() {
+	outputs.Add("amqp", func() cua.Output {
+		return &AMQP{
+			Brokers:            []string{DefaultURL},
+			AuthMethod:         DefaultAuthMethod,
+			ExchangeType:       DefaultExchangeType,
+			ExchangeDurability: DefaultExchangeDurable,
+			DeliveryMode:       DefaultDeliveryMode,
+			Timeout:            internal.Duration{Duration: DefaultTimeout},
+			connect:            connect,
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package amqp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/json"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+type MockClient struct {
+	PublishF func(msgs []message) error
+	CloseF   func() error
+
+	PublishCallCount int
+	CloseCallCount   int
+}
+
+func (c *MockClient) Publish(msgs []message) error {
+	c.PublishCallCount++
+	return c.PublishF(msgs)
+}
+
+func (c *MockClient) Close() error {
+	c.CloseCallCount++
+	return c.CloseF()
+}
+
+func NewMockClient() client {
+	return &MockClient{
+		PublishF: func(msgs []message) error {
+			return nil
+		},
+		CloseF: func() error {
+			return nil
+		},
+	}
+}
+
+func newPlugin(t *testing.T, mock client) *AMQP {
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+
+	q := &AMQP{
+		Brokers:    []string{DefaultURL},
+		AuthMethod: DefaultAuthMethod,
+		Timeout:    internal.Duration{Duration: DefaultTimeout},
+		Log:        testutil.Logger{},
+		connect: func(_ *clientConfig) (client, error) {
+			return mock, nil
+		},
+	}
+	q.SetSerializer(serializer)
+	require.NoError(t, q.Init())
+	return q
+}
+
+func testMetrics() []cua.Metric {
+	now := time.Unix(0, 0)
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 42.0}, now),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"idle": 43.0}, now),
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 44.0}, now),
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"idle": 45.0}, now),
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   *AMQP
+		expected *clientConfig
+		errFunc  func(t *testing.T, output *AMQP, err error)
+	}{
+		{
+			name: "defaults",
+			output: &AMQP{
+				Brokers: []string{DefaultURL},
+			},
+			expected: &clientConfig{
+				brokers:         []string{DefaultURL},
+				exchangeDurable: true,
+				deliveryMode:    amqp.Persistent,
+			},
+		},
+		{
+			name: "headers and exchange arguments",
+			output: &AMQP{
+				Brokers:           []string{DefaultURL},
+				DeliveryMode:      "transient",
+				Headers:           map[string]string{"foo": "bar"},
+				ExchangeArguments: map[string]string{"hash_property": "timestamp"},
+			},
+			expected: &clientConfig{
+				brokers:           []string{DefaultURL},
+				exchangeDurable:   true,
+				deliveryMode:      amqp.Transient,
+				headers:           amqp.Table{"foo": "bar"},
+				exchangeArguments: amqp.Table{"hash_property": "timestamp"},
+			},
+		},
+		{
+			name: "plain auth",
+			output: &AMQP{
+				Brokers:  []string{DefaultURL},
+				Username: "guest",
+				Password: "secret",
+			},
+			expected: &clientConfig{
+				brokers:         []string{DefaultURL},
+				exchangeDurable: true,
+				deliveryMode:    amqp.Persistent,
+				auth:            []amqp.Authentication{&amqp.PlainAuth{Username: "guest", Password: "secret"}},
+			},
+		},
+		{
+			name: "external auth",
+			output: &AMQP{
+				Brokers:    []string{DefaultURL},
+				AuthMethod: "external",
+			},
+			expected: &clientConfig{
+				brokers:         []string{DefaultURL},
+				exchangeDurable: true,
+				deliveryMode:    amqp.Persistent,
+				auth:            []amqp.Authentication{&externalAuth{}},
+			},
+		},
+		{
+			name: "invalid delivery mode",
+			output: &AMQP{
+				Brokers:      []string{DefaultURL},
+				DeliveryMode: "forever",
+			},
+			errFunc: func(t *testing.T, output *AMQP, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:   "no brokers",
+			output: &AMQP{},
+			errFunc: func(t *testing.T, output *AMQP, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.output.Log = testutil.Logger{}
+			config, err := tt.output.makeClientConfig()
+			if tt.errFunc != nil {
+				tt.errFunc(t, tt.output, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.expected.log = tt.output.Log
+			require.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestWriteRoutingKeys(t *testing.T) {
+	var published []message
+	mock := NewMockClient().(*MockClient)
+	mock.PublishF = func(msgs []message) error {
+		published = append(published, msgs...)
+		return nil
+	}
+
+	q := newPlugin(t, mock)
+	q.RoutingTag = "host"
+	q.RoutingKey = "default"
+	require.NoError(t, q.Connect())
+
+	n, err := q.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	require.Len(t, published, 4)
+	keys := []string{}
+	for _, msg := range published {
+		keys = append(keys, msg.key)
+	}
+	require.Equal(t, []string{"a", "b", "a", "default"}, keys)
+}
+
+func TestWriteBatchGroupsByRoutingKey(t *testing.T) {
+	var published []message
+	mock := NewMockClient().(*MockClient)
+	mock.PublishF = func(msgs []message) error {
+		published = append(published, msgs...)
+		return nil
+	}
+
+	q := newPlugin(t, mock)
+	q.RoutingTag = "host"
+	q.UseBatchFormat = true
+	require.NoError(t, q.Connect())
+
+	n, err := q.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	require.Len(t, published, 3)
+	require.Equal(t, "a", published[0].key)
+	require.Contains(t, string(published[0].body), `"idle":42`)
+	require.Contains(t, string(published[0].body), `"idle":44`)
+	require.Equal(t, "b", published[1].key)
+	require.Equal(t, "", published[2].key)
+}
+
+func TestWriteErrorReconnects(t *testing.T) {
+	mock := NewMockClient().(*MockClient)
+	mock.PublishF = func(msgs []message) error {
+		return errors.New("broker rejected message 1")
+	}
+
+	connects := 0
+	q := newPlugin(t, mock)
+	q.connect = func(_ *clientConfig) (client, error) {
+		connects++
+		return mock, nil
+	}
+	require.NoError(t, q.Connect())
+
+	_, err := q.Write(testMetrics())
+	require.Error(t, err)
+	require.Equal(t, 1, mock.CloseCallCount)
+
+	mock.PublishF = func(msgs []message) error {
+		return nil
+	}
+	_, err = q.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 2, connects)
+}
+
+func TestMaxMessagesClosesConnection(t *testing.T) {
+	mock := NewMockClient().(*MockClient)
+	q := newPlugin(t, mock)
+	q.MaxMessages = 4
+	require.NoError(t, q.Connect())
+
+	_, err := q.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 1, mock.CloseCallCount)
+	require.Nil(t, q.client)
+}
@@ -0,0 +1,196 @@
+package amqp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/streadway/amqp"
+)
+
+type clientConfig struct {
+	brokers           []string
+	exchange          string
+	exchangeType      string
+	exchangePassive   bool
+	exchangeDurable   bool
+	exchangeArguments amqp.Table
+	deliveryMode      uint8
+	headers           amqp.Table
+	contentEncoding   string
+	timeout           time.Duration
+	auth              []amqp.Authentication
+	tlsConfig         *tls.Config
+	log               cua.Logger
+}
+
+// message is a serialized payload and the key it is routed with
+type message struct {
+	key  string
+	body []byte
+}
+
+type client interface {
+	Publish(msgs []message) error
+	Close() error
+}
+
+type amqpClient struct {
+	config   *clientConfig
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+}
+
+// newClient opens a connection to one of the brokers, tried in random
+// order, and a channel in confirm mode.
+func newClient(config *clientConfig) (*amqpClient, error) {
+	c := &amqpClient{config: config}
+
+	amqpConf := amqp.Config{
+		TLSClientConfig: config.tlsConfig,
+		SASL:            config.auth, // if nil, it will be PLAIN
+		Dial:            dialer(config.timeout),
+	}
+
+	for _, n := range rand.Perm(len(config.brokers)) {
+		broker := config.brokers[n]
+		config.log.Debugf("Connecting to %q", broker)
+		conn, err := amqp.DialConfig(broker, amqpConf)
+		if err == nil {
+			c.conn = conn
+			config.log.Debugf("Connected to %q", broker)
+			break
+		}
+		config.log.Debugf("Error connecting to %q: %s", broker, err)
+	}
+
+	if c.conn == nil {
+		return nil, errors.New("could not connect to any broker")
+	}
+
+	channel, err := c.conn.Channel()
+	if err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("error opening channel: %w", err)
+	}
+	c.channel = channel
+
+	if config.exchange != "" {
+		if err := c.declareExchange(); err != nil {
+			c.conn.Close()
+			return nil, err
+		}
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("error enabling publisher confirms: %w", err)
+	}
+	c.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	return c, nil
+}
+
+// dialer mirrors the library's default dial with the configured timeout
+// instead of a fixed 30s; the deadline covers the TLS and AMQP handshake
+// and is cleared by the library once the connection is open.
+func dialer(timeout time.Duration) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		conn, err := net.DialTimeout(network, addr, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("dial: %w", err)
+		}
+		if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("set deadline: %w", err)
+		}
+		return conn, nil
+	}
+}
+
+func (c *amqpClient) declareExchange() error {
+	var err error
+	if c.config.exchangePassive {
+		err = c.channel.ExchangeDeclarePassive(
+			c.config.exchange,
+			c.config.exchangeType,
+			c.config.exchangeDurable,
+			false, // delete when unused
+			false, // internal
+			false, // no-wait
+			c.config.exchangeArguments,
+		)
+	} else {
+		err = c.channel.ExchangeDeclare(
+			c.config.exchange,
+			c.config.exchangeType,
+			c.config.exchangeDurable,
+			false, // delete when unused
+			false, // internal
+			false, // no-wait
+			c.config.exchangeArguments,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("error declaring exchange: %w", err)
+	}
+	return nil
+}
+
+// Publish sends the messages and waits until the broker confirmed all of
+// them; a message the broker could not take fails the whole call.
+func (c *amqpClient) Publish(msgs []message) error {
+	pending := 0
+	var err error
+	for _, msg := range msgs {
+		err = c.channel.Publish(
+			c.config.exchange,
+			msg.key,
+			false, // mandatory
+			false, // immediate
+			amqp.Publishing{
+				Headers:         c.config.headers,
+				ContentType:     "text/plain",
+				ContentEncoding: c.config.contentEncoding,
+				DeliveryMode:    c.config.deliveryMode,
+				Body:            msg.body,
+			})
+		if err != nil {
+			err = fmt.Errorf("error publishing message: %w", err)
+			break
+		}
+		pending++
+	}
+
+	// confirmations for the published messages have to be drained even
+	// after a failed publish, the channel blocks otherwise
+	timer := time.NewTimer(c.config.timeout)
+	defer timer.Stop()
+	for ; pending > 0; pending-- {
+		select {
+		case confirm, ok := <-c.confirms:
+			if !ok {
+				return errors.New("channel closed before all messages were confirmed")
+			}
+			if !confirm.Ack && err == nil {
+				err = fmt.Errorf("broker rejected message %d", confirm.DeliveryTag)
+			}
+		case <-timer.C:
+			return fmt.Errorf("timeout waiting for %d publisher confirms", pending)
+		}
+	}
+
+	return err
+}
+
+func (c *amqpClient) Close() error {
+	if err := c.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+		return fmt.Errorf("error closing connection: %w", err)
+	}
+	return nil
+}
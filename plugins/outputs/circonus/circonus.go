@@ -4,6 +4,9 @@ package circonus
 
 import (
 	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -48,6 +51,17 @@ type Circonus struct {
 	DebugMetrics       bool     `toml:"debug_metrics"`     // output the metrics as they are being parsed, use to verify proper parsing/tags/etc.
 	SubOutput          bool     `toml:"sub_output"`        // a dedicated, special purpose, output, don't send internal cua version, etc.
 	CacheConfigs       bool     `toml:"cache_configs"`     // optional: cache check bundle configurations - efficient for large number of inputs
+	Routes             []Route  `toml:"route"`             // optional: send metrics matching a route to a dedicated check
+
+	SubmitBatchSize   int            `toml:"submit_batch_size"`  // optional: submit to a check once this many metrics are queued for it (default 0, whole batch)
+	SubmitConcurrency int            `toml:"submit_concurrency"` // optional: parallel submissions per check (default 0, unlimited)
+	SubmitCompression string         `toml:"submit_compression"` // optional: gzip (default), deflate or none
+	SubmitTimeout     inter.Duration `toml:"submit_timeout"`     // optional: timeout of a submission, including retries (default 0, none)
+	SubmitRetries     *int           `toml:"submit_retries"`     // optional: retries of a failed submission request (default 7)
+	SubmitProxy       string         `toml:"submit_proxy"`       // optional: proxy for submissions to brokers (default: HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment)
+	SubmitNoProxy     []string       `toml:"submit_no_proxy"`    // optional: broker hosts, domains or networks reached without submit_proxy
+	BrokerTLSCA       string         `toml:"broker_tls_ca"`      // optional: pin the broker CA cert file, the CA provided by the api is not trusted
+	submitConfig      *circmgr.SubmitConfig
 }
 
 // processors handle incoming batches
@@ -83,6 +97,14 @@ func (c *Circonus) Init() error {
 		}
 	}
 
+	if err := c.initRoutes(); err != nil {
+		return err
+	}
+
+	if err := c.initSubmit(); err != nil {
+		return err
+	}
+
 	if c.PoolSize == 0 {
 		c.PoolSize = defaultWorkerPoolSize
 	}
@@ -106,6 +128,48 @@ func (c *Circonus) Init() error {
 	return nil
 }
 
+// initSubmit validates the submission settings and loads the pinned broker
+// CA, retries are counted in the agent metrics
+func (c *Circonus) initSubmit() error {
+	if c.SubmitBatchSize < 0 {
+		return fmt.Errorf("invalid submit_batch_size %d", c.SubmitBatchSize)
+	}
+	if c.SubmitConcurrency < 0 {
+		return fmt.Errorf("invalid submit_concurrency %d", c.SubmitConcurrency)
+	}
+	sc := &circmgr.SubmitConfig{
+		Compression: c.SubmitCompression,
+		Timeout:     c.SubmitTimeout.Duration,
+		MaxRetries:  c.SubmitRetries,
+		ProxyURL:    c.SubmitProxy,
+		NoProxy:     c.SubmitNoProxy,
+		OnRetry: func() {
+			if agentDestination == nil {
+				return
+			}
+			if err := agentDestination.metrics.CounterIncrement("cua_submit_retries", nil); err != nil {
+				c.Log.Warnf("incrementing counter (cua_submit_retries): %s", err)
+			}
+			agentDestination.queuedMetrics++
+		},
+	}
+	if c.BrokerTLSCA != "" {
+		pem, err := os.ReadFile(c.BrokerTLSCA)
+		if err != nil {
+			return fmt.Errorf("reading broker_tls_ca: %w", err)
+		}
+		sc.BrokerCAs = x509.NewCertPool()
+		if !sc.BrokerCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("broker_tls_ca (%s): no certificates found", c.BrokerTLSCA)
+		}
+	}
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("submit settings: %w", err)
+	}
+	c.submitConfig = sc
+	return nil
+}
+
 func (p *processors) run(m []cua.Metric) {
 	p.metrics <- m
 }
@@ -127,6 +191,50 @@ var sampleConfig = `
   ## Debug metrics - this will output the metrics as they are being parsed - to verify parsing of names/tags/values
   ## Optional
   # debug_metrics = false
+
+  ## Submission tuning
+  ## Optional: submit to a check as soon as this many metrics are queued for it,
+  ## default 0 submits everything queued by a batch at once
+  # submit_batch_size = 0
+  ## Optional: parallel submissions per check, default 0 is unlimited
+  # submit_concurrency = 0
+  ## Optional: compression of submissions larger than 1KB - gzip, deflate or none
+  # submit_compression = "gzip"
+  ## Optional: timeout of a submission, including retries, default 0 is none
+  # submit_timeout = "0s"
+  ## Optional: retries of a failed submission request
+  # submit_retries = 7
+
+  ## Proxy for submissions to brokers, for networks that cannot reach the
+  ## brokers directly. http, https and socks5 proxies are supported.
+  ## Optional: default is the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+  # submit_proxy = "http://proxy.example.com:3128"
+  ## Optional: brokers reached without the proxy - host names, domains
+  ## (".example.com") or networks ("10.0.0.0/8"), NO_PROXY syntax
+  # submit_no_proxy = []
+
+  ## Broker CA pinning
+  ## Optional: trust only this CA cert file for broker certificates instead of
+  ## the broker CA provided by the api
+  # broker_tls_ca = "/opt/circonus/unified-agent/etc/broker_ca.pem"
+
+  ## Routes - send the metrics matching a route to a dedicated check instead of
+  ## the check of the input plugin instance they came from. Routes are tried in
+  ## order, the first one matching wins. Every matcher set has to match, globs
+  ## are supported.
+  ## Optional
+  # [[outputs.circonus.route]]
+  #   ## Name of the check, used as its instance id (required)
+  #   name = "network"
+  #   ## Broker for the check, default is the broker of the output
+  #   # broker = "/broker/35"
+  #   ## Input plugins the metrics come from
+  #   plugins = ["snmp", "snmp_trap"]
+  #   ## Metric names
+  #   # names = []
+  #   ## Tag values
+  #   # [outputs.circonus.route.tags]
+  #   #   site = ["dc1", "dc2"]
 `
 
 var description = "Configuration for Circonus output plugin."
@@ -148,7 +256,7 @@ func (c *Circonus) Connect() error {
 			PluginID:   "agent",
 			InstanceID: config.DefaultInstanceID(),
 		}
-		if err := c.initMetricDestination(meta); err != nil {
+		if err := c.initMetricDestination(meta, c.Broker); err != nil {
 			c.Log.Errorf("unable to initialize circonus metric destination (%s)", err)
 			return err
 		}
@@ -165,7 +273,7 @@ func (c *Circonus) Connect() error {
 					PluginID:   "host",
 					InstanceID: config.DefaultInstanceID(),
 				}
-				if err := c.initMetricDestination(meta); err != nil {
+				if err := c.initMetricDestination(meta, c.Broker); err != nil {
 					c.Log.Errorf("unable to initialize circonus metric destination (%s)", err)
 					return err
 				}
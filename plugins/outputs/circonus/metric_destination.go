@@ -14,10 +14,15 @@ type metricDestination struct {
 	metrics       *trapmetrics.TrapMetrics
 	id            string
 	queuedMetrics int64
+	submitters    chan struct{} // limits parallel submissions, nil when unlimited
 }
 
 // getMetricDestination returns a destination for the plugin identified by a plugin and plugin instance id
 func (c *Circonus) getMetricDestination(m cua.Metric) *metricDestination {
+	if r := c.matchRoute(m); r != nil {
+		return c.findMetricDestination(r.meta(), r.Broker)
+	}
+
 	pluginID := m.Origin()
 	instanceID := m.OriginInstance()
 
@@ -63,6 +68,13 @@ func (c *Circonus) getMetricDestination(m cua.Metric) *metricDestination {
 		MetricGroupID: metricGroupID,
 		ProjectID:     projectID,
 	}
+
+	return c.findMetricDestination(metricMeta, c.Broker)
+}
+
+// findMetricDestination returns the destination for the metric meta,
+// creating its check on first use
+func (c *Circonus) findMetricDestination(metricMeta circmgr.MetricMeta, broker string) *metricDestination {
 	destKey := metricMeta.Key()
 
 	c.RLock()
@@ -72,7 +84,7 @@ func (c *Circonus) getMetricDestination(m cua.Metric) *metricDestination {
 		return d
 	}
 
-	if err := c.initMetricDestination(metricMeta); err != nil {
+	if err := c.initMetricDestination(metricMeta, broker); err != nil {
 		c.Log.Errorf("error initializing metric destination: %s", err)
 		os.Exit(1) //nolint:gocritic
 	}
@@ -87,16 +99,17 @@ func (c *Circonus) getMetricDestination(m cua.Metric) *metricDestination {
 	return nil
 }
 
-func (c *Circonus) initMetricDestination(metricMeta circmgr.MetricMeta) error {
+func (c *Circonus) initMetricDestination(metricMeta circmgr.MetricMeta, broker string) error {
 	c.Lock()
 	defer c.Unlock()
 
 	opts := circmgr.MetricDestConfig{
 		MetricMeta:   metricMeta,
 		APIToken:     c.APIToken,
-		Broker:       c.Broker,
+		Broker:       broker,
 		DebugAPI:     c.DebugAPI,
 		TraceMetrics: c.TraceMetrics,
+		Submit:       c.submitConfig,
 	}
 
 	dest, err := circmgr.NewMetricDestination(&opts, c.Log)
@@ -106,10 +119,14 @@ func (c *Circonus) initMetricDestination(metricMeta circmgr.MetricMeta) error {
 
 	destKey := metricMeta.Key()
 
-	c.metricDestinations[destKey] = &metricDestination{
+	md := &metricDestination{
 		metrics: dest,
 		id:      metricMeta.PluginID,
 	}
+	if c.SubmitConcurrency > 0 {
+		md.submitters = make(chan struct{}, c.SubmitConcurrency)
+	}
+	c.metricDestinations[destKey] = md
 
 	return nil
 }
@@ -19,6 +19,12 @@ func (c *Circonus) metricProcessor(id int, metrics []cua.Metric, buf bytes.Buffe
 	start := time.Now()
 	numMetrics := int64(0)
 	for _, m := range metrics {
+		if c.SubmitBatchSize > 0 {
+			// submit early so a submission does not exceed the batch size
+			if d := c.getMetricDestination(m); d != nil && d.queuedMetrics >= int64(c.SubmitBatchSize) {
+				c.flushDestination(d, buf)
+			}
+		}
 		switch m.Type() {
 		case cua.Counter, cua.Gauge, cua.Summary:
 			numMetrics += c.buildNumerics(m)
@@ -37,6 +43,8 @@ func (c *Circonus) metricProcessor(id int, metrics []cua.Metric, buf bytes.Buffe
 			numMetrics += c.buildHistogram(m)
 		case cua.CumulativeHistogram:
 			numMetrics += c.buildCumulativeHistogram(m)
+		case cua.Event:
+			numMetrics += c.buildEvent(m)
 		default:
 			c.Log.Warnf("processor %d, unknown type %T, ignoring", id, m)
 		}
@@ -61,7 +69,6 @@ func (c *Circonus) metricProcessor(id int, metrics []cua.Metric, buf bytes.Buffe
 	sendStart := time.Now()
 	var wg sync.WaitGroup
 	c.RLock()
-	ctx := context.Background()
 	for _, dest := range c.metricDestinations {
 		if dest.queuedMetrics == 0 {
 			continue
@@ -69,23 +76,7 @@ func (c *Circonus) metricProcessor(id int, metrics []cua.Metric, buf bytes.Buffe
 		wg.Add(1)
 		go func(d *metricDestination) {
 			defer wg.Done()
-			subStart := time.Now()
-			d.queuedMetrics = int64(0)
-			result, err := d.metrics.FlushWithBuffer(ctx, buf)
-			if err != nil {
-				c.Log.Warnf("submitting metrics (%s): %s", d.id, err)
-				return
-			}
-			if agentDestination != nil {
-				if err := agentDestination.metrics.HistogramRecordValue("cua_metrics_submitted", nil, float64(result.Stats)); err != nil {
-					c.Log.Warnf("adding histogram sample (cua_metrics_submitted): %s", err)
-				}
-				agentDestination.queuedMetrics++
-				if err := agentDestination.metrics.HistogramRecordValue("cua_submit_latency", trapmetrics.Tags{{Category: "units", Value: "milliseconds"}}, float64(time.Since(subStart).Milliseconds())); err != nil {
-					c.Log.Warnf("adding histogram sample (cua_submit_latency): %s", err)
-				}
-				agentDestination.queuedMetrics++
-			}
+			c.flushDestination(d, buf)
 		}(dest)
 	}
 
@@ -104,6 +95,39 @@ func (c *Circonus) metricProcessor(id int, metrics []cua.Metric, buf bytes.Buffe
 	return numMetrics
 }
 
+// flushDestination submits the metrics queued for a destination, waiting
+// for a free submitter slot of the destination when concurrency is limited
+func (c *Circonus) flushDestination(d *metricDestination, buf bytes.Buffer) {
+	if d.submitters != nil {
+		d.submitters <- struct{}{}
+		defer func() { <-d.submitters }()
+	}
+
+	subStart := time.Now()
+	d.queuedMetrics = int64(0)
+	result, err := d.metrics.FlushWithBuffer(context.Background(), buf)
+	if err != nil {
+		c.Log.Warnf("submitting metrics (%s): %s", d.id, err)
+		if agentDestination != nil {
+			if err := agentDestination.metrics.CounterIncrement("cua_submit_errors", nil); err != nil {
+				c.Log.Warnf("incrementing counter (cua_submit_errors): %s", err)
+			}
+			agentDestination.queuedMetrics++
+		}
+		return
+	}
+	if agentDestination != nil {
+		if err := agentDestination.metrics.HistogramRecordValue("cua_metrics_submitted", nil, float64(result.Stats)); err != nil {
+			c.Log.Warnf("adding histogram sample (cua_metrics_submitted): %s", err)
+		}
+		agentDestination.queuedMetrics++
+		if err := agentDestination.metrics.HistogramRecordValue("cua_submit_latency", trapmetrics.Tags{{Category: "units", Value: "milliseconds"}}, float64(time.Since(subStart).Milliseconds())); err != nil {
+			c.Log.Warnf("adding histogram sample (cua_submit_latency): %s", err)
+		}
+		agentDestination.queuedMetrics++
+	}
+}
+
 // handleGeneric constructs text and numeric metrics from a cua metric
 // Note: for certain cua metric types the actual fields may be either text OR numeric...
 //       and now potentially boolean as well as text and numeric.
@@ -225,6 +249,45 @@ func (c *Circonus) buildCumulativeHistogram(m cua.Metric) int64 {
 	return numMetrics
 }
 
+// buildEvent constructs a text metric from a cua event, the text holding the
+// title and body of the event, with the severity as a tag.
+func (c *Circonus) buildEvent(m cua.Metric) int64 {
+	dest := c.getMetricDestination(m)
+	if dest == nil {
+		c.Log.Warnf("no metric destination found for metric (%#v)", m)
+		return 0
+	}
+
+	mn := m.Name()
+	tags := c.convertTags(m)
+	batchTS := m.Time()
+
+	field, _ := m.GetField("title")
+	text, _ := field.(string)
+	if field, ok := m.GetField("body"); ok {
+		if body, ok := field.(string); ok && body != "" {
+			text += "\n" + body
+		}
+	}
+	if field, ok := m.GetField("severity"); ok {
+		if severity, ok := field.(string); ok {
+			tags = append(tags, trapmetrics.Tag{Category: "severity", Value: severity})
+		}
+	}
+	if c.DebugMetrics {
+		c.Log.Infof("%s %v %s\n", mn, tags.String(), text)
+	}
+
+	if err := dest.metrics.TextSet(mn, tags, text, &batchTS); err != nil {
+		c.Log.Warnf("setting text (%s) (%s): %s", mn, tags.String(), err)
+		return 0
+	}
+
+	dest.queuedMetrics++
+
+	return 1
+}
+
 // convertTags reformats cua tags to cgm tags
 func (c *Circonus) convertTags(m cua.Metric) trapmetrics.Tags { //nolint:unparam
 	var ctags trapmetrics.Tags
@@ -0,0 +1,119 @@
+package circonus
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	circmgr "github.com/circonus-labs/circonus-unified-agent/internal/circonus"
+)
+
+// routePluginID is the plugin id of the checks created for routes
+const routePluginID = "route"
+
+// Route directs the metrics matching all of its matchers to a dedicated
+// check instead of the check of the input plugin instance they came from.
+type Route struct {
+	Name    string              `toml:"name"`    // check instance id, required
+	Broker  string              `toml:"broker"`  // optional: broker for the route's check (default: output broker)
+	Plugins []string            `toml:"plugins"` // input plugin names the metrics originate from (globs)
+	Names   []string            `toml:"names"`   // metric names (globs)
+	Tags    map[string][]string `toml:"tags"`    // tag values (globs), every listed tag has to match
+
+	pluginFilter filter.Filter
+	nameFilter   filter.Filter
+	tagFilters   []tagFilter
+}
+
+type tagFilter struct {
+	key    string
+	filter filter.Filter
+}
+
+func (r *Route) init() error {
+	if r.Name == "" {
+		return fmt.Errorf("route name is required")
+	}
+	if len(r.Plugins) == 0 && len(r.Names) == 0 && len(r.Tags) == 0 {
+		return fmt.Errorf("route %s: at least one of plugins, names or tags is required", r.Name)
+	}
+
+	var err error
+	if r.pluginFilter, err = filter.Compile(r.Plugins); err != nil {
+		return fmt.Errorf("route %s: compiling plugins: %w", r.Name, err)
+	}
+	if r.nameFilter, err = filter.Compile(r.Names); err != nil {
+		return fmt.Errorf("route %s: compiling names: %w", r.Name, err)
+	}
+
+	keys := make([]string, 0, len(r.Tags))
+	for key := range r.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f, err := filter.Compile(r.Tags[key])
+		if err != nil {
+			return fmt.Errorf("route %s: compiling tag %s: %w", r.Name, key, err)
+		}
+		r.tagFilters = append(r.tagFilters, tagFilter{key: key, filter: f})
+	}
+
+	return nil
+}
+
+func (r *Route) match(m cua.Metric) bool {
+	if r.pluginFilter != nil && !r.pluginFilter.Match(m.Origin()) {
+		return false
+	}
+	if r.nameFilter != nil && !r.nameFilter.Match(m.Name()) {
+		return false
+	}
+	for _, tf := range r.tagFilters {
+		v, ok := m.GetTag(tf.key)
+		if !ok || (tf.filter != nil && !tf.filter.Match(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Route) meta() circmgr.MetricMeta {
+	return circmgr.MetricMeta{
+		PluginID:   routePluginID,
+		InstanceID: r.Name,
+	}
+}
+
+// initRoutes validates the routes and compiles their matchers
+func (c *Circonus) initRoutes() error {
+	seen := make(map[string]bool, len(c.Routes))
+	for i := range c.Routes {
+		r := &c.Routes[i]
+		if err := r.init(); err != nil {
+			return err
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate route name %s", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return nil
+}
+
+// matchRoute returns the first route the metric matches, routes are tried
+// in configuration order. Metrics the agent emits about itself are never
+// routed.
+func (c *Circonus) matchRoute(m cua.Metric) *Route {
+	if config.IsAgentPlugin(m.Origin()) && config.IsDefaultInstanceID(m.OriginInstance()) {
+		return nil
+	}
+	for i := range c.Routes {
+		if c.Routes[i].match(m) {
+			return &c.Routes[i]
+		}
+	}
+	return nil
+}
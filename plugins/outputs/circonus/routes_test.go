@@ -0,0 +1,82 @@
+package circonus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func originMetric(plugin, name string, tags map[string]string) cua.Metric {
+	m := testutil.MustMetric(name, tags, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	m.SetOrigin(plugin)
+	m.SetOriginInstance(plugin + "_1")
+	return m
+}
+
+func TestMatchRoute(t *testing.T) {
+	c := &Circonus{
+		Routes: []Route{
+			{Name: "network", Plugins: []string{"snmp*"}},
+			{Name: "database", Names: []string{"mysql*", "postgresql"}},
+			{Name: "dc1", Tags: map[string][]string{"site": {"dc1"}, "env": {"prod*"}}},
+			{Name: "catchall", Names: []string{"*"}},
+		},
+	}
+	require.NoError(t, c.initRoutes())
+
+	tests := []struct {
+		metric   cua.Metric
+		expected string
+	}{
+		{originMetric("snmp_trap", "snmp_trap", nil), "network"},
+		{originMetric("snmp", "ifTable", nil), "network"},
+		{originMetric("mysql", "mysql_innodb", nil), "database"},
+		{originMetric("postgresql", "postgresql", nil), "database"},
+		{originMetric("cpu", "cpu", map[string]string{"site": "dc1", "env": "production"}), "dc1"},
+		{originMetric("cpu", "cpu", map[string]string{"site": "dc1"}), "catchall"},
+		{originMetric("cpu", "cpu", map[string]string{"site": "dc2", "env": "production"}), "catchall"},
+	}
+	for _, tt := range tests {
+		r := c.matchRoute(tt.metric)
+		require.NotNil(t, r, tt.metric.Name())
+		require.Equal(t, tt.expected, r.Name, tt.metric.Name())
+		require.Equal(t, routePluginID, r.meta().PluginID)
+		require.Equal(t, tt.expected, r.meta().InstanceID)
+	}
+
+	c.Routes = c.Routes[:3]
+	require.Nil(t, c.matchRoute(originMetric("cpu", "cpu", nil)))
+}
+
+func TestMatchRouteSkipsAgentMetrics(t *testing.T) {
+	c := &Circonus{
+		Routes: []Route{{Name: "all", Names: []string{"*"}}},
+	}
+	require.NoError(t, c.initRoutes())
+
+	m := testutil.MustMetric("cua_version", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	m.SetOrigin("internal")
+	m.SetOriginInstance("host")
+	require.Nil(t, c.matchRoute(m))
+}
+
+func TestInitRoutesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []Route
+	}{
+		{"missing name", []Route{{Plugins: []string{"snmp"}}}},
+		{"no matchers", []Route{{Name: "network"}}},
+		{"duplicate", []Route{{Name: "a", Names: []string{"x"}}, {Name: "a", Names: []string{"y"}}}},
+		{"bad glob", []Route{{Name: "a", Names: []string{"["}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Circonus{Routes: tt.routes}
+			require.Error(t, c.initRoutes())
+		})
+	}
+}
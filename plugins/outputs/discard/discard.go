@@ -14,6 +14,8 @@ func (d *Discard) Description() string  { return "Send metrics to nowhere at all
 func (d *Discard) Write(metrics []cua.Metric) (int, error) {
 	return 0, nil
 }
+func (d *Discard) RecyclesMetrics() bool  { return true }
+func (d *Discard) ConcurrentWrites() bool { return true }
 
 func init() {
 	outputs.Add("discard", func() cua.Output { return &Discard{} })
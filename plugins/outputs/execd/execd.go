@@ -0,0 +1,162 @@
+package execd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal/process"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  ## eg: command = ["/path/to/your_program", "arg1", "arg2"]
+  command = ["cat"]
+
+  ## Delay before the process is restarted after an unexpected termination
+  # restart_delay = "10s"
+
+  ## Environment variables passed to the program, as "key=value" pairs in
+  ## addition to the environment of the agent.
+  ## eg: environment = ["API_TOKEN=secret", "LD_LIBRARY_PATH=/opt/lib"]
+  # environment = []
+
+  ## Use batch serialization format instead of line based delimiting; the
+  ## batch is written at once.
+  # use_batch_format = false
+
+  ## Data format to export.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "json"
+`
+
+type Execd struct {
+	Command        []string        `toml:"command"`
+	RestartDelay   config.Duration `toml:"restart_delay"`
+	UseBatchFormat bool            `toml:"use_batch_format"`
+	Environment    []string        `toml:"environment"`
+	Log            cua.Logger      `toml:"-"`
+
+	process    *process.Process
+	serializer serializers.Serializer
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) SetSerializer(s serializers.Serializer) {
+	e.serializer = s
+}
+
+func (e *Execd) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("no command specified")
+	}
+
+	var err error
+	e.process, err = process.New(e.Command, e.Environment)
+	if err != nil {
+		return fmt.Errorf("error creating process %s: %w", e.Command, err)
+	}
+	e.process.Log = e.Log
+	e.process.RestartDelay = time.Duration(e.RestartDelay)
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+
+	return nil
+}
+
+func (e *Execd) Connect() error {
+	if err := e.process.Start(); err != nil {
+		// if there was only one argument, and it contained spaces, warn the user
+		// that they may have configured it wrong.
+		if len(e.Command) == 1 && strings.Contains(e.Command[0], " ") {
+			e.Log.Warn("The outputs.execd Command contained spaces but no arguments. " +
+				"This setting expects the program and arguments as an array of strings, " +
+				"not as a space-delimited string. See the plugin readme for an example.")
+		}
+		return fmt.Errorf("failed to start process %s: %w", e.Command, err)
+	}
+
+	return nil
+}
+
+func (e *Execd) Close() error {
+	e.process.Stop()
+	return nil
+}
+
+func (e *Execd) Write(metrics []cua.Metric) (int, error) {
+	if e.UseBatchFormat {
+		b, err := e.serializer.SerializeBatch(metrics)
+		if err != nil {
+			// the batch is dropped, retrying would fail the same way
+			e.Log.Errorf("Could not serialize metrics: %v", err)
+			return 0, nil
+		}
+		if _, err := e.process.Stdin.Write(b); err != nil {
+			return 0, fmt.Errorf("error writing metrics: %w", err)
+		}
+		return len(metrics), nil
+	}
+
+	for i, m := range metrics {
+		b, err := e.serializer.Serialize(m)
+		if err != nil {
+			e.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+		if _, err = e.process.Stdin.Write(b); err != nil {
+			return i, fmt.Errorf("error writing metrics: %w", err)
+		}
+	}
+	return len(metrics), nil
+}
+
+// cmdReadOut mirrors the output of the program to the agent log, the
+// program has no way to send metrics back.
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Infof("stdout: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %s", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %s", err)
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() cua.Output {
+		return &Execd{
+			RestartDelay: config.Duration(10 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package execd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/json"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalOutputWorks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "cat > " + out},
+		RestartDelay: config.Duration(5 * time.Second),
+		Log:          testutil.Logger{},
+	}
+	e.SetSerializer(serializer)
+
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+
+	now := time.Unix(1600000000, 0)
+	metrics := []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 42.0}, now),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"idle": 43.0}, now),
+	}
+	n, err := e.Write(metrics)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// stopping closes stdin and waits for the program to exit
+	require.NoError(t, e.Close())
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"host":"a"`)
+	require.Contains(t, lines[1], `"host":"b"`)
+}
+
+func TestInitRequiresCommand(t *testing.T) {
+	e := &Execd{Log: testutil.Logger{}}
+	require.Error(t, e.Init())
+}
@@ -26,7 +26,7 @@ type File struct {
 }
 
 var sampleConfig = `
-  ## Files to write to, "stdout" is a specially handled file.
+  ## Files to write to, "stdout" and "stderr" are specially handled files.
   files = ["stdout", "/tmp/metrics.out"]
 
   ## Use batch serialization format instead of line based delimiting.  The
@@ -36,14 +36,16 @@ var sampleConfig = `
 
   ## The file will be rotated after the time interval specified.  When set
   ## to 0 no time based rotation is performed.
-  # rotation_interval = "0d"
+  # rotation_interval = "0h"
 
   ## The logfile will be rotated when it becomes larger than the specified
   ## size.  When set to 0 no size based rotation is performed.
   # rotation_max_size = "0MB"
 
   ## Maximum number of rotated archives to keep, any older logs are deleted.
-  ## If set to -1, no archives are removed.
+  ## If set to -1, no archives are removed. Archives are named after the
+  ## file with the rotation date and time inserted before the extension,
+  ## e.g. /tmp/metrics.2021-01-02-1609545600.out
   # rotation_max_archives = 5
 
   ## Data format to output.
@@ -65,13 +67,16 @@ func (f *File) Connect() error {
 	}
 
 	for _, file := range f.Files {
-		if file == "stdout" {
+		switch file {
+		case "stdout":
 			writers = append(writers, os.Stdout)
-		} else {
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		default:
 			of, err := rotate.NewFileWriter(
 				file, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives)
 			if err != nil {
-				return fmt.Errorf("roate new file: %w", err)
+				return fmt.Errorf("rotate new file (%s): %w", file, err)
 			}
 
 			writers = append(writers, of)
@@ -101,6 +106,11 @@ func (f *File) Description() string {
 	return "Send metrics to file(s)"
 }
 
+// RecyclesMetrics answers true, the metrics are serialized when written
+func (f *File) RecyclesMetrics() bool {
+	return true
+}
+
 func (f *File) Write(metrics []cua.Metric) (int, error) {
 	var writeErr error = nil
 
@@ -109,12 +119,17 @@ func (f *File) Write(metrics []cua.Metric) (int, error) {
 	if f.UseBatchFormat {
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
-			f.Log.Errorf("Could not serialize metric: %v", err)
+			// the batch is dropped, retrying would fail the same way
+			f.Log.Errorf("Could not serialize metrics: %v", err)
+			return 0, nil
 		}
 
-		_, err = f.writer.Write(octets)
-		if err != nil {
-			f.Log.Errorf("Error writing to file: %v", err)
+		if _, err = f.writer.Write(octets); err != nil {
+			return 0, fmt.Errorf("E! [outputs.file] failed to write batch: %w", err)
+		}
+
+		for _, metric := range metrics {
+			totMetrics += len(metric.FieldList())
 		}
 	} else {
 		for _, metric := range metrics {
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/internal"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -179,6 +182,58 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileRotationMaxSize(t *testing.T) {
+	dir, err := os.MkdirTemp("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewJSONSerializer(time.Second)
+	f := File{
+		Files:               []string{filepath.Join(dir, "metrics.out")},
+		RotationMaxSize:     internal.Size{Size: 1},
+		RotationMaxArchives: -1,
+		serializer:          s,
+	}
+	require.NoError(t, f.Connect())
+
+	// the write exceeds the size limit and rotates the file, closing
+	// rotates the new one; archive names have a resolution of a second
+	_, err = f.Write(testutil.MockMetrics())
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+	require.NoError(t, f.Close())
+
+	archives, err := filepath.Glob(filepath.Join(dir, "metrics.*-*.out"))
+	require.NoError(t, err)
+	require.Len(t, archives, 2)
+}
+
+func TestFileBatchFormat(t *testing.T) {
+	fh := tmpFile()
+	defer os.Remove(fh)
+
+	s, _ := serializers.NewJSONSerializer(time.Second)
+	f := File{
+		Files:          []string{fh},
+		UseBatchFormat: true,
+		serializer:     s,
+	}
+	require.NoError(t, f.Connect())
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 42.0, "usage_user": 8.0}, time.Unix(1600000000, 0)),
+	}
+	n, err := f.Write(metrics)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.NoError(t, f.Close())
+
+	buf, err := os.ReadFile(fh)
+	require.NoError(t, err)
+	require.Equal(t, `{"metrics":[{"fields":{"usage_idle":42,"usage_user":8},"name":"cpu","tags":{"cpu":"cpu0"},"timestamp":1600000000}]}`, string(buf))
+}
+
 func createFile() *os.File {
 	f, err := os.CreateTemp("", "")
 	if err != nil {
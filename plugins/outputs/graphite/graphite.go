@@ -0,0 +1,266 @@
+package graphite
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+)
+
+const (
+	protocolPlaintext = "plaintext"
+	protocolPickle    = "pickle"
+)
+
+var sampleConfig = `
+  ## TCP endpoints of the Graphite servers, a batch is sent to one of the
+  ## servers picked at random, the others are used when sending fails.
+  servers = ["localhost:2003"]
+
+  ## Protocol, "plaintext" or "pickle". The pickle protocol is usually
+  ## received on port 2004 of carbon.
+  # protocol = "plaintext"
+
+  ## Prefix metrics name
+  prefix = ""
+
+  ## Graphite output template
+  ## see https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  template = "host.tags.measurement.field"
+
+  ## Graphite templates patterns
+  ## 1. Template for cpu
+  ## 2. Template for disk*
+  ## 3. Default template
+  # templates = [
+  #  "cpu tags.measurement.host.field",
+  #  "disk* measurement.field",
+  #  "host.measurement.tags.field"
+  #]
+
+  ## Enable Graphite tags support
+  # graphite_tag_support = false
+
+  ## Character for separating metric name and field for Graphite tags
+  # graphite_separator = "."
+
+  ## timeout in seconds for the write connection to graphite
+  timeout = 2
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Graphite struct {
+	GraphiteTagSupport bool     `toml:"graphite_tag_support"`
+	GraphiteSeparator  string   `toml:"graphite_separator"`
+	Servers            []string `toml:"servers"`
+	Protocol           string   `toml:"protocol"`
+	Prefix             string   `toml:"prefix"`
+	Template           string   `toml:"template"`
+	Templates          []string `toml:"templates"`
+	Timeout            int      `toml:"timeout"`
+	tlsint.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	conns      []net.Conn
+	serializer serializers.Serializer
+	tlsConfig  *tls.Config
+}
+
+func (g *Graphite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *Graphite) Description() string {
+	return "Configuration for Graphite server to send metrics to"
+}
+
+func (g *Graphite) Init() error {
+	switch g.Protocol {
+	case "":
+		g.Protocol = protocolPlaintext
+	case protocolPlaintext, protocolPickle:
+	default:
+		return fmt.Errorf("invalid protocol %q", g.Protocol)
+	}
+
+	if len(g.Servers) == 0 {
+		g.Servers = []string{"localhost:2003"}
+	}
+
+	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.GraphiteSeparator, g.Templates)
+	if err != nil {
+		return fmt.Errorf("graphite serializer: %w", err)
+	}
+	g.serializer = s
+
+	tlsConfig, err := g.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	g.tlsConfig = tlsConfig
+	return nil
+}
+
+func (g *Graphite) Connect() error {
+	// Set tls config
+	conns := make([]net.Conn, 0, len(g.Servers))
+
+	// Get Connections
+	for _, server := range g.Servers {
+		// Dialer with timeout
+		d := net.Dialer{Timeout: time.Duration(g.Timeout) * time.Second}
+
+		// Get secure connection if tls config is set
+		var conn net.Conn
+		var err error
+		if g.tlsConfig != nil {
+			conn, err = tls.DialWithDialer(&d, "tcp", server, g.tlsConfig)
+		} else {
+			conn, err = d.Dial("tcp", server)
+		}
+
+		if err != nil {
+			g.Log.Debugf("Connecting to %s: %v", server, err)
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	g.conns = conns
+	return nil
+}
+
+func (g *Graphite) Close() error {
+	// Closing all connections
+	for _, conn := range g.conns {
+		_ = conn.Close()
+	}
+	g.conns = nil
+	return nil
+}
+
+// We need check eof as we can write to nothing without noticing anything is wrong
+// the connection stays in a close_wait
+// We can detect that by finding an eof
+// if not for this, we can happily write and flush without getting errors (in Go) but getting RST tcp packets back (!)
+// props to Tv via the authors of carbon-relay-ng` for this trick.
+func (g *Graphite) checkEOF(conn net.Conn) {
+	b := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	num, err := conn.Read(b)
+	if errors.Is(err, io.EOF) {
+		g.Log.Errorf("Conn %s is closed. closing conn explicitly", conn)
+		_ = conn.Close()
+		return
+	}
+	// just in case i misunderstand something or the remote behaves badly
+	if num != 0 {
+		g.Log.Infof("conn %s .conn.Read data? did not expect that. data: %s", conn, b[:num])
+	}
+	// Log non-timeout errors or close.
+	var nerr net.Error
+	if !(errors.As(err, &nerr) && nerr.Timeout()) {
+		g.Log.Errorf("conn %s checkEOF .conn.Read returned err != EOF, which is unexpected.  closing conn. error: %s", conn, err)
+		_ = conn.Close()
+	}
+}
+
+// Choose a random server in the cluster to write to until a successful write
+// occurs, logging each unsuccessful. If all servers fail, return error.
+func (g *Graphite) Write(metrics []cua.Metric) (int, error) {
+	// Prepare data
+	var batch []byte
+	for _, metric := range metrics {
+		buf, err := g.serializer.Serialize(metric)
+		if err != nil {
+			g.Log.Errorf("Error serializing some metrics to graphite: %s", err.Error())
+		}
+		batch = append(batch, buf...)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	if g.Protocol == protocolPickle {
+		batch = pickle(batch)
+	}
+
+	err := g.send(batch)
+
+	// If a send failed for a server, try to reconnect to that server
+	if err != nil {
+		g.Log.Debug("Reconnecting and retrying: ")
+		_ = g.Close()
+		_ = g.Connect()
+		err = g.send(batch)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	return len(metrics), nil
+}
+
+func (g *Graphite) send(batch []byte) error {
+	// This will get set to nil if a successful write occurs
+	err := errors.New("could not write to any Graphite server in cluster")
+
+	// Send data to a random server
+	p := rand.Perm(len(g.conns))
+	for _, n := range p {
+		if g.Timeout > 0 {
+			_ = g.conns[n].SetWriteDeadline(time.Now().Add(time.Duration(g.Timeout) * time.Second))
+		}
+		g.checkEOF(g.conns[n])
+		if _, e := g.conns[n].Write(batch); e != nil {
+			// Error
+			g.Log.Errorf("Graphite Error: " + e.Error())
+			// Close explicitly
+			_ = g.conns[n].Close()
+			// Let's try the next one
+		} else {
+			// Success
+			err = nil
+			break
+		}
+	}
+
+	return err
+}
+
+// pickle converts plaintext lines, "path value timestamp", to a message of
+// the pickle protocol: a list of (path, (timestamp, value)) tuples pickled
+// with protocol 2, prefixed with its length.
+func pickle(plaintext []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 0, 0}) // length, set below
+	writePickle(&buf, plaintext)
+
+	b := buf.Bytes()
+	n := uint32(len(b) - 4)
+	b[0], b[1], b[2], b[3] = byte(n>>24), byte(n>>16), byte(n>>8), byte(n)
+	return b
+}
+
+func init() {
+	outputs.Add("graphite", func() cua.Output {
+		return &Graphite{
+			Timeout: 2,
+		}
+	})
+}
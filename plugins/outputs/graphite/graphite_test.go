@@ -0,0 +1,114 @@
+package graphite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) (net.Listener, chan net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conns <- conn
+		}
+	}()
+	return l, conns
+}
+
+func newGraphite(t *testing.T, server, protocol string) *Graphite {
+	g := &Graphite{
+		Servers:  []string{server},
+		Protocol: protocol,
+		Prefix:   "my.prefix",
+		Template: "measurement.host.field",
+		Timeout:  2,
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, g.Init())
+	require.NoError(t, g.Connect())
+	t.Cleanup(func() { require.NoError(t, g.Close()) })
+	return g
+}
+
+func testMetrics() []cua.Metric {
+	now := time.Unix(1600000000, 0)
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "example"},
+			map[string]interface{}{"usage_idle": 98.5}, now),
+		testutil.MustMetric("mem", map[string]string{"host": "example"},
+			map[string]interface{}{"used": int64(42)}, now),
+	}
+}
+
+func TestWritePlaintext(t *testing.T) {
+	l, conns := listen(t)
+	g := newGraphite(t, l.Addr().String(), "")
+
+	n, err := g.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	conn := <-conns
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "my.prefix.cpu.example.usage_idle 98.5 1600000000\n", line)
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "my.prefix.mem.example.used 42 1600000000\n", line)
+}
+
+func TestWritePickle(t *testing.T) {
+	l, conns := listen(t)
+	g := newGraphite(t, l.Addr().String(), protocolPickle)
+
+	_, err := g.Write(testMetrics()[:1])
+	require.NoError(t, err)
+
+	conn := <-conns
+	defer conn.Close()
+	var size uint32
+	require.NoError(t, binary.Read(conn, binary.BigEndian, &size))
+	payload := make([]byte, size)
+	_, err = io.ReadFull(conn, payload)
+	require.NoError(t, err)
+
+	path := "my.prefix.cpu.example.usage_idle"
+	expected := []byte{opProto, 2, opEmptyList, opMark, opBinUnicode, byte(len(path)), 0, 0, 0}
+	expected = append(expected, path...)
+	expected = append(expected,
+		opBinInt, 0x00, 0x10, 0x5e, 0x5f, // 1600000000
+		opBinFloat, 0x40, 0x58, 0xa0, 0, 0, 0, 0, 0, // 98.5
+		opTuple2, opTuple2, opAppends, opStop)
+	require.Equal(t, expected, payload)
+}
+
+func TestWriteNoServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := l.Addr().String()
+	l.Close()
+
+	g := newGraphite(t, server, "")
+	_, err = g.Write(testMetrics())
+	require.Error(t, err)
+}
+
+func TestInvalidProtocol(t *testing.T) {
+	g := &Graphite{Protocol: "udp"}
+	require.Error(t, g.Init())
+}
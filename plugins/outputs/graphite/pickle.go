@@ -0,0 +1,61 @@
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// Opcodes of the pickle protocol used to encode the metrics.
+const (
+	opProto      = 0x80
+	opEmptyList  = ']'
+	opMark       = '('
+	opAppends    = 'e'
+	opBinUnicode = 'X'
+	opBinInt     = 'J'
+	opBinFloat   = 'G'
+	opTuple2     = 0x86
+	opStop       = '.'
+)
+
+// writePickle writes the pickle of the plaintext lines; lines that are not
+// a path, a numeric value and a timestamp are skipped.
+func writePickle(buf *bytes.Buffer, plaintext []byte) {
+	buf.Write([]byte{opProto, 2, opEmptyList, opMark})
+
+	var b4 [4]byte
+	var b8 [8]byte
+	for _, line := range bytes.Split(plaintext, []byte{'\n'}) {
+		parts := bytes.Fields(line)
+		if len(parts) != 3 {
+			continue
+		}
+		value, err := strconv.ParseFloat(string(parts[1]), 64)
+		if err != nil {
+			continue
+		}
+		ts, err := strconv.ParseInt(string(parts[2]), 10, 32)
+		if err != nil {
+			continue
+		}
+
+		buf.WriteByte(opBinUnicode)
+		binary.LittleEndian.PutUint32(b4[:], uint32(len(parts[0])))
+		buf.Write(b4[:])
+		buf.Write(parts[0])
+
+		buf.WriteByte(opBinInt)
+		binary.LittleEndian.PutUint32(b4[:], uint32(int32(ts)))
+		buf.Write(b4[:])
+
+		buf.WriteByte(opBinFloat)
+		binary.BigEndian.PutUint64(b8[:], math.Float64bits(value))
+		buf.Write(b8[:])
+
+		buf.Write([]byte{opTuple2, opTuple2})
+	}
+
+	buf.Write([]byte{opAppends, opStop})
+}
@@ -0,0 +1,377 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	httpconfig "github.com/circonus-labs/circonus-unified-agent/plugins/common/http"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+)
+
+var sampleConfig = `
+  ## URL is the address to send metrics to. The URL may refer to the metric
+  ## name and tags, metrics are then sent in one request per distinct URL.
+  ##   ex: url = "https://collector.example.com/metrics/{{.Tag \"region\"}}"
+  url = "http://127.0.0.1:8080/metrics"
+
+  ## Timeout for HTTP message
+  # timeout = "5s"
+  ## Timeouts of connecting, including the TLS handshake, and of waiting for
+  ## the response headers; 0s for no timeout beyond the one of the message.
+  # connect_timeout = "0s"
+  # response_timeout = "0s"
+
+  ## Idle keep-alive connections kept for reuse, and for how long.
+  # max_idle_conn = 0
+  # max_idle_conn_per_host = 0
+  # idle_conn_timeout = "0s"
+  # disable_keep_alives = false
+
+  ## HTTP method, one of: "POST" or "PUT"
+  # method = "POST"
+
+  ## HTTP Basic Auth credentials
+  # username = "username"
+  # password = "pa$$word"
+
+  ## Kerberos (SPNEGO) authentication with a keytab, e.g. for endpoints in an
+  ## Active Directory domain.  The service principal name defaults to
+  ## HTTP/<host of the url>.
+  # kerberos_principal = "cua@EXAMPLE.COM"
+  # kerberos_keytab = "/opt/circonus/unified-agent/etc/cua.keytab"
+  # kerberos_config = "/etc/krb5.conf"
+  # kerberos_spn = ""
+
+  ## Optional file with Bearer token, read for each request
+  # bearer_token = "/path/to/file"
+
+  ## OAuth2 Client Credentials Grant
+  # client_id = "clientid"
+  # client_secret = "secret"
+  # token_url = "https://indentityprovider/oauth2/v1/token"
+  # scopes = ["urn:opc:idm:__myscopes__"]
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## HTTP Proxy support
+  # http_proxy_url = ""
+
+  ## Data format to output.
+  ## Each data format has it's own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "influx"
+
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "identity"
+
+  ## Additional HTTP headers
+  # [outputs.http.headers]
+  #   # Should be set manually to "application/json" for json data_format
+  #   Content-Type = "text/plain; charset=utf-8"
+
+  ## Retries of a request that failed with a connection error, a 5xx status
+  ## or 429. The wait between retries starts at retry_backoff and doubles,
+  ## up to 30s, unless the server asks for a delay with Retry-After. Metrics
+  ## still failing stay buffered for the next flush.
+  # max_retries = 3
+  # retry_backoff = "1s"
+`
+
+const (
+	defaultURL           = "http://127.0.0.1:8080/metrics"
+	defaultClientTimeout = 5 * time.Second
+	defaultContentType   = "text/plain; charset=utf-8"
+	defaultMethod        = http.MethodPost
+	defaultMaxRetries    = 3
+	defaultRetryBackoff  = time.Second
+	maxRetryBackoff      = 30 * time.Second
+)
+
+type HTTP struct {
+	URL             string            `toml:"url"`
+	Method          string            `toml:"method"`
+	Headers         map[string]string `toml:"headers"`
+	ContentEncoding string            `toml:"content_encoding"`
+	MaxRetries      int               `toml:"max_retries"`
+	RetryBackoff    internal.Duration `toml:"retry_backoff"`
+	httpconfig.HTTPClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client     *http.Client
+	serializer serializers.Serializer
+	urlTmpl    *template.Template
+	done       chan struct{}
+}
+
+// urlData is the data the url template is executed with, once per metric.
+type urlData struct {
+	Name string
+	tags map[string]string
+}
+
+// Tag answers the value of a tag, or an empty string when the metric does
+// not have the tag.
+func (d urlData) Tag(key string) string {
+	return d.tags[key]
+}
+
+// permanentError is a request the server rejected, sending it again would
+// be rejected the same way.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
+	h.serializer = serializer
+}
+
+func (h *HTTP) Init() error {
+	switch h.Method {
+	case "":
+		h.Method = defaultMethod
+	case http.MethodPost, http.MethodPut:
+	default:
+		return fmt.Errorf("invalid method [%s] %s", h.URL, h.Method)
+	}
+
+	if _, err := internal.NewContentEncoder(h.ContentEncoding); err != nil {
+		return fmt.Errorf("content_encoding (%s): %w", h.ContentEncoding, err)
+	}
+
+	if strings.Contains(h.URL, "{{") {
+		tmpl, err := template.New("url").Option("missingkey=zero").Parse(h.URL)
+		if err != nil {
+			return fmt.Errorf("parsing url template (%s): %w", h.URL, err)
+		}
+		h.urlTmpl = tmpl
+	}
+
+	if h.MaxRetries < 0 {
+		h.MaxRetries = 0
+	}
+	return nil
+}
+
+func (h *HTTP) Connect() error {
+	if h.Timeout.Duration == 0 {
+		h.Timeout.Duration = defaultClientTimeout
+	}
+
+	client, err := h.HTTPClientConfig.CreateClient(context.Background())
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	h.client = client
+	h.done = make(chan struct{})
+	return nil
+}
+
+func (h *HTTP) Close() error {
+	if h.done != nil {
+		close(h.done)
+	}
+	return nil
+}
+
+func (h *HTTP) Description() string {
+	return "A plugin that can transmit metrics over HTTP"
+}
+
+func (h *HTTP) SampleConfig() string {
+	return sampleConfig
+}
+
+// RecyclesMetrics answers true, the metrics are serialized when written
+func (h *HTTP) RecyclesMetrics() bool {
+	return true
+}
+
+func (h *HTTP) Write(metrics []cua.Metric) (int, error) {
+	urls, batches, err := h.group(metrics)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, u := range urls {
+		batch := batches[u]
+		reqBody, err := h.serializer.SerializeBatch(batch)
+		if err != nil {
+			return sent, fmt.Errorf("serialize batch: %w", err)
+		}
+
+		if err := h.writeWithRetry(u, reqBody); err != nil {
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				h.Log.Errorf("Dropping %d metrics: %v", len(batch), err)
+				continue
+			}
+			return sent, err
+		}
+		sent += len(batch)
+	}
+
+	return sent, nil
+}
+
+// group splits the metrics by the url they are sent to, keeping the order
+// of the urls as they first appear.
+func (h *HTTP) group(metrics []cua.Metric) ([]string, map[string][]cua.Metric, error) {
+	if h.urlTmpl == nil {
+		return []string{h.URL}, map[string][]cua.Metric{h.URL: metrics}, nil
+	}
+
+	var urls []string
+	batches := make(map[string][]cua.Metric)
+	var buf strings.Builder
+	for _, m := range metrics {
+		buf.Reset()
+		if err := h.urlTmpl.Execute(&buf, urlData{Name: m.Name(), tags: m.Tags()}); err != nil {
+			return nil, nil, fmt.Errorf("executing url template: %w", err)
+		}
+		u := buf.String()
+		if _, ok := batches[u]; !ok {
+			urls = append(urls, u)
+		}
+		batches[u] = append(batches[u], m)
+	}
+	return urls, batches, nil
+}
+
+func (h *HTTP) writeWithRetry(u string, reqBody []byte) error {
+	backoff := h.RetryBackoff.Duration
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := h.write(u, reqBody)
+		if err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= h.MaxRetries {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		h.Log.Debugf("Retrying in %s: %v", wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-h.done:
+			return err
+		}
+
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// write sends one request, answering the delay asked for by the server
+// with the error of a request that may be retried.
+func (h *HTTP) write(u string, reqBody []byte) (time.Duration, error) {
+	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
+
+	if h.ContentEncoding == "gzip" {
+		rc, err := internal.CompressWithGzip(reqBodyBuffer)
+		if err != nil {
+			return 0, fmt.Errorf("compress: %w", err)
+		}
+		defer rc.Close()
+		reqBodyBuffer = rc
+	}
+
+	req, err := http.NewRequest(h.Method, u, reqBodyBuffer)
+	if err != nil {
+		return 0, fmt.Errorf("http new req: %w", err)
+	}
+
+	req.Header.Set("User-Agent", internal.ProductToken())
+	req.Header.Set("Content-Type", defaultContentType)
+	if h.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range h.Headers {
+		if strings.ToLower(k) == "host" {
+			req.Host = v
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("http client do: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("when writing to [%s] received status code: %d", u, resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return retryAfter(resp.Header.Get("Retry-After")), err
+	case resp.StatusCode == http.StatusRequestTimeout:
+		return 0, err
+	default:
+		return 0, &permanentError{err: err}
+	}
+}
+
+// retryAfter answers the delay of a Retry-After header in seconds, the
+// http-date form is not supported.
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	if d := time.Duration(secs) * time.Second; d < maxRetryBackoff {
+		return d
+	}
+	return maxRetryBackoff
+}
+
+func init() {
+	outputs.Add("http", func() cua.Output {
+		return &HTTP{
+			URL:    defaultURL,
+			Method: defaultMethod,
+			HTTPClientConfig: httpconfig.HTTPClientConfig{
+				Timeout: internal.Duration{Duration: defaultClientTimeout},
+			},
+			MaxRetries:   defaultMaxRetries,
+			RetryBackoff: internal.Duration{Duration: defaultRetryBackoff},
+		}
+	})
+}
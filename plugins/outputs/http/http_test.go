@@ -0,0 +1,185 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	httpconfig "github.com/circonus-labs/circonus-unified-agent/plugins/common/http"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetric(region string) cua.Metric {
+	return testutil.MustMetric("cpu", map[string]string{"region": region},
+		map[string]interface{}{"value": 42.0}, time.Unix(1600000000, 0))
+}
+
+func newHTTP(t *testing.T, u string) *HTTP {
+	s, err := serializers.NewJSONSerializer(time.Second)
+	require.NoError(t, err)
+
+	h := &HTTP{
+		URL:          u,
+		MaxRetries:   2,
+		RetryBackoff: internal.Duration{Duration: time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+	h.SetSerializer(s)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+	t.Cleanup(func() { require.NoError(t, h.Close()) })
+	return h
+}
+
+func TestWriteHeadersAndEncoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", pass)
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		require.Equal(t, `{"metrics":[{"fields":{"value":42},"name":"cpu","tags":{"region":"east"},"timestamp":1600000000}]}`, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s, err := serializers.NewJSONSerializer(time.Second)
+	require.NoError(t, err)
+	h := &HTTP{
+		URL:             ts.URL,
+		Method:          http.MethodPut,
+		ContentEncoding: "gzip",
+		HTTPClientConfig: httpconfig.HTTPClientConfig{
+			Username: "user",
+			Password: "secret",
+		},
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Log:     testutil.Logger{},
+	}
+	h.SetSerializer(s)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+
+	n, err := h.Write([]cua.Metric{getMetric("east")})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestWriteURLTemplate(t *testing.T) {
+	var mu sync.Mutex
+	paths := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	h := newHTTP(t, ts.URL+`/{{.Name}}/{{.Tag "region"}}`)
+	n, err := h.Write([]cua.Metric{getMetric("east"), getMetric("west"), getMetric("east")})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, map[string]int{"/cpu/east": 1, "/cpu/west": 1}, paths)
+}
+
+func TestWriteRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		err      bool
+	}{
+		{
+			name:     "recovers",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			requests: 3,
+		},
+		{
+			name:     "retries exhausted",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			requests: 3,
+			err:      true,
+		},
+		{
+			name:     "rejected batch is dropped",
+			statuses: []int{http.StatusBadRequest},
+			requests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[requests])
+				requests++
+			}))
+			defer ts.Close()
+
+			h := newHTTP(t, ts.URL)
+			_, err := h.Write([]cua.Metric{getMetric("east")})
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.requests, requests)
+		})
+	}
+}
+
+func TestOAuthClientCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"s3cr3t","token_type":"Bearer","expires_in":3600}`))
+		case "/metrics":
+			require.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	s, err := serializers.NewJSONSerializer(time.Second)
+	require.NoError(t, err)
+	h := &HTTP{
+		URL: ts.URL + "/metrics",
+		HTTPClientConfig: httpconfig.HTTPClientConfig{
+			ClientID:     "howdy",
+			ClientSecret: "secret",
+			TokenURL:     ts.URL + "/token",
+			Scopes:       []string{"urn:test"},
+		},
+		Log: testutil.Logger{},
+	}
+	h.SetSerializer(s)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+
+	_, err = h.Write([]cua.Metric{getMetric("east")})
+	require.NoError(t, err)
+}
+
+func TestInvalidMethod(t *testing.T) {
+	h := &HTTP{URL: defaultURL, Method: http.MethodGet}
+	require.Error(t, h.Init())
+}
@@ -0,0 +1,347 @@
+package influxdbv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+)
+
+const (
+	defaultURL     = "http://localhost:8086"
+	defaultTimeout = 5 * time.Second
+	maxRetryAfter  = 60 * time.Second
+)
+
+var sampleConfig = `
+  ## The URLs of the InfluxDB cluster nodes.
+  ##
+  ## Multiple URLs can be specified for a single cluster, only ONE of the
+  ## urls will be written to each interval.
+  ##   ex: urls = ["https://us-west-2-1.aws.cloud2.influxdata.com"]
+  urls = ["http://127.0.0.1:8086"]
+
+  ## Token for authentication.
+  token = ""
+
+  ## Organization is the name of the organization you wish to write to; must exist.
+  organization = ""
+
+  ## Destination bucket to write into.
+  bucket = ""
+
+  ## The value of this tag will be used to determine the bucket.  If this
+  ## tag is not set the 'bucket' option is used as the default.
+  # bucket_tag = ""
+
+  ## If true, the bucket tag will not be added to the metric.
+  # exclude_bucket_tag = false
+
+  ## Timeout for HTTP messages.
+  # timeout = "5s"
+
+  ## Additional HTTP headers
+  # http_headers = {"X-Special-Header" = "Special-Value"}
+
+  ## HTTP Proxy override, if unset values the standard proxy environment
+  ## variables are consulted to determine which proxy, if any, should be used.
+  # http_proxy = "http://corporate.proxy:3128"
+
+  ## HTTP User-Agent
+  # user_agent = "circonus-unified-agent"
+
+  ## Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "gzip"
+
+  ## Enable or disable uint support for writing uints influxdb 2.0.
+  # influx_uint_support = false
+
+  ## Optional TLS Config for use on HTTP connections.
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type InfluxDB struct {
+	URLs             []string          `toml:"urls"`
+	Token            string            `toml:"token"`
+	Organization     string            `toml:"organization"`
+	Bucket           string            `toml:"bucket"`
+	BucketTag        string            `toml:"bucket_tag"`
+	ExcludeBucketTag bool              `toml:"exclude_bucket_tag"`
+	Timeout          internal.Duration `toml:"timeout"`
+	HTTPHeaders      map[string]string `toml:"http_headers"`
+	HTTPProxy        string            `toml:"http_proxy"`
+	UserAgent        string            `toml:"user_agent"`
+	ContentEncoding  string            `toml:"content_encoding"`
+	UintSupport      bool              `toml:"influx_uint_support"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	client     *http.Client
+	serializer *influx.Serializer
+	retryAfter time.Time
+}
+
+// APIError is an error answered by the write API.
+type APIError struct {
+	StatusCode int
+	Title      string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Title
+}
+
+func (i *InfluxDB) Description() string {
+	return "Configuration for sending metrics to InfluxDB"
+}
+
+func (i *InfluxDB) SampleConfig() string {
+	return sampleConfig
+}
+
+func (i *InfluxDB) Init() error {
+	if len(i.URLs) == 0 {
+		i.URLs = append(i.URLs, defaultURL)
+	}
+	for _, u := range i.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("error parsing url [%q]: %w", u, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("unsupported scheme [%q]: %q", u, parsed.Scheme)
+		}
+	}
+
+	switch i.ContentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return fmt.Errorf("invalid content_encoding %q", i.ContentEncoding)
+	}
+
+	i.serializer = influx.NewSerializer()
+	if i.UintSupport {
+		i.serializer.SetFieldTypeSupport(influx.UintSupport)
+	}
+	return nil
+}
+
+func (i *InfluxDB) Connect() error {
+	tlsConfig, err := i.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if i.HTTPProxy != "" {
+		proxyURL, err := url.Parse(i.HTTPProxy)
+		if err != nil {
+			return fmt.Errorf("error parsing http_proxy [%q]: %w", i.HTTPProxy, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	if i.Timeout.Duration == 0 {
+		i.Timeout.Duration = defaultTimeout
+	}
+	if i.UserAgent == "" {
+		i.UserAgent = internal.ProductToken()
+	}
+
+	i.client = &http.Client{
+		Timeout: i.Timeout.Duration,
+		Transport: &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return nil
+}
+
+func (i *InfluxDB) Close() error {
+	if i.client != nil {
+		i.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Write sends metrics to one of the configured servers, logging each
+// unsuccessful. If all servers fail, return an error.
+func (i *InfluxDB) Write(metrics []cua.Metric) (int, error) {
+	if time.Now().Before(i.retryAfter) {
+		return 0, fmt.Errorf("retry after %s", i.retryAfter.Format(time.RFC3339))
+	}
+
+	var err error
+	p := rand.Perm(len(i.URLs))
+	for _, n := range p {
+		u := i.URLs[n]
+		err = i.write(u, metrics)
+		if err == nil {
+			return len(metrics), nil
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode < 500 {
+			// the data was rejected, the other servers of the cluster
+			// would reject it as well
+			i.Log.Errorf("When writing to [%s]: %v; dropping %d metrics", u, err, len(metrics))
+			return 0, nil
+		}
+		i.Log.Errorf("When writing to [%s]: %v", u, err)
+	}
+
+	return 0, fmt.Errorf("could not write any address: %w", err)
+}
+
+// write sends the metrics to the server, one request per bucket.
+func (i *InfluxDB) write(u string, metrics []cua.Metric) error {
+	if i.BucketTag == "" {
+		return i.writeBatch(u, i.Bucket, metrics)
+	}
+
+	var buckets []string
+	batches := make(map[string][]cua.Metric)
+	for _, metric := range metrics {
+		bucket, ok := metric.GetTag(i.BucketTag)
+		if !ok {
+			bucket = i.Bucket
+		} else if i.ExcludeBucketTag {
+			// Avoid modifying the metric in case we need to retry the request.
+			metric = metric.Copy()
+			metric.Accept()
+			metric.RemoveTag(i.BucketTag)
+		}
+		if _, ok := batches[bucket]; !ok {
+			buckets = append(buckets, bucket)
+		}
+		batches[bucket] = append(batches[bucket], metric)
+	}
+
+	for _, bucket := range buckets {
+		if err := i.writeBatch(u, bucket, batches[bucket]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *InfluxDB) writeBatch(u, bucket string, metrics []cua.Metric) error {
+	body, err := i.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("serialize batch: %w", err)
+	}
+
+	var reqBody io.Reader = bytes.NewReader(body)
+	if i.ContentEncoding == "gzip" {
+		rc, err := internal.CompressWithGzip(reqBody)
+		if err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		defer rc.Close()
+		reqBody = rc
+	}
+
+	params := url.Values{}
+	params.Set("org", i.Organization)
+	params.Set("bucket", bucket)
+	loc := strings.TrimSuffix(u, "/") + "/api/v2/write?" + params.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.Timeout.Duration)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loc, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request (%s): %w", loc, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("User-Agent", i.UserAgent)
+	req.Header.Set("Authorization", "Token "+i.Token)
+	for k, v := range i.HTTPHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	// the write API answers errors as {"code": ..., "message": ...}
+	var apiResp struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiResp)
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Title:      resp.Status,
+		Message:    apiResp.Message,
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		i.retryAfter = time.Now().Add(retryAfter(resp.Header.Get("Retry-After")))
+	case http.StatusRequestEntityTooLarge:
+		if len(metrics) > 1 {
+			// send the halves separately
+			half := len(metrics) / 2
+			if err := i.writeBatch(u, bucket, metrics[:half]); err != nil {
+				return err
+			}
+			return i.writeBatch(u, bucket, metrics[half:])
+		}
+	}
+	return apiErr
+}
+
+// retryAfter answers the delay asked for in a Retry-After header in
+// seconds, capped, or no delay.
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	if d := time.Duration(secs) * time.Second; d < maxRetryAfter {
+		return d
+	}
+	return maxRetryAfter
+}
+
+func init() {
+	outputs.Add("influxdb_v2", func() cua.Output {
+		return &InfluxDB{
+			Timeout:         internal.Duration{Duration: defaultTimeout},
+			ContentEncoding: "gzip",
+		}
+	})
+}
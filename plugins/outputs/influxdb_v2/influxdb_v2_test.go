@@ -0,0 +1,149 @@
+package influxdbv2
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newInfluxDB(t *testing.T, u string) *InfluxDB {
+	i := &InfluxDB{
+		URLs:         []string{u},
+		Token:        "xyzzy",
+		Organization: "example",
+		Bucket:       "metrics",
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, i.Init())
+	require.NoError(t, i.Connect())
+	t.Cleanup(func() { require.NoError(t, i.Close()) })
+	return i
+}
+
+func getMetric(bucket string) cua.Metric {
+	tags := map[string]string{"host": "example.org"}
+	if bucket != "" {
+		tags["bucket"] = bucket
+	}
+	return testutil.MustMetric("cpu", tags,
+		map[string]interface{}{"usage_idle": 98.5}, time.Unix(1600000000, 0))
+}
+
+func TestWrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v2/write", r.URL.Path)
+		require.Equal(t, "example", r.URL.Query().Get("org"))
+		require.Equal(t, "metrics", r.URL.Query().Get("bucket"))
+		require.Equal(t, "Token xyzzy", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "cpu,host=example.org usage_idle=98.5 1600000000000000000\n", string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	n, err := newInfluxDB(t, ts.URL).Write([]cua.Metric{getMetric("")})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestWriteBucketTagGzip(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+
+		mu.Lock()
+		bodies[r.URL.Query().Get("bucket")] += string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	i := newInfluxDB(t, ts.URL)
+	i.BucketTag = "bucket"
+	i.ExcludeBucketTag = true
+	i.ContentEncoding = "gzip"
+
+	metrics := []cua.Metric{getMetric("audit"), getMetric(""), getMetric("audit")}
+	_, err := i.Write(metrics)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"audit":   "cpu,host=example.org usage_idle=98.5 1600000000000000000\ncpu,host=example.org usage_idle=98.5 1600000000000000000\n",
+		"metrics": "cpu,host=example.org usage_idle=98.5 1600000000000000000\n",
+	}, bodies)
+	// the buffered metric keeps the tag
+	require.True(t, metrics[0].HasTag("bucket"))
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    bool
+	}{
+		{name: "rejected data is dropped", status: http.StatusBadRequest},
+		{name: "unauthorized is dropped", status: http.StatusUnauthorized},
+		{name: "server error is retried", status: http.StatusInternalServerError, err: true},
+		{name: "throttling is retried", status: http.StatusTooManyRequests, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"code":"invalid","message":"failure"}`))
+			}))
+			defer ts.Close()
+
+			_, err := newInfluxDB(t, ts.URL).Write([]cua.Metric{getMetric("")})
+			if tt.err {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "failure")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWriteTooLarge(t *testing.T) {
+	var mu sync.Mutex
+	var lines []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		n := 0
+		for _, b := range body {
+			if b == '\n' {
+				n++
+			}
+		}
+		mu.Lock()
+		lines = append(lines, n)
+		mu.Unlock()
+		if n > 1 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	_, err := newInfluxDB(t, ts.URL).Write([]cua.Metric{getMetric(""), getMetric("")})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 1, 1}, lines)
+}
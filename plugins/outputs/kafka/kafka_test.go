@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type topicSuffixTestpair struct {
+	topicSuffix   TopicSuffix
+	expectedTopic string
+}
+
+func TestTopicSuffixes(t *testing.T) {
+	topic := "Test"
+
+	m := testutil.TestMetric(1)
+	metricTagName := "tag1"
+	metricTagValue := m.Tags()[metricTagName]
+	metricName := m.Name()
+
+	var testcases = []topicSuffixTestpair{
+		// This ensures empty separator is okay
+		{TopicSuffix{Method: "measurement"},
+			topic + metricName},
+		{TopicSuffix{Method: "measurement", Separator: "sep"},
+			topic + "sep" + metricName},
+		{TopicSuffix{Method: "tags", Keys: []string{metricTagName}, Separator: "_"},
+			topic + "_" + metricTagValue},
+		{TopicSuffix{Method: "tags", Keys: []string{metricTagName, metricTagName, metricTagName}, Separator: "___"},
+			topic + "___" + metricTagValue + "___" + metricTagValue + "___" + metricTagValue},
+		{TopicSuffix{Method: "tags", Keys: []string{metricTagName, metricTagName, metricTagName}},
+			topic + metricTagValue + metricTagValue + metricTagValue},
+		// This ensures non-existing tags are ignored
+		{TopicSuffix{Method: "tags", Keys: []string{"non_existing_tag", "non_existing_tag"}, Separator: "___"},
+			topic},
+		{TopicSuffix{Method: "tags", Keys: []string{metricTagName, "non_existing_tag"}, Separator: "___"},
+			topic + "___" + metricTagValue},
+		// This ensures backward compatibility
+		{TopicSuffix{},
+			topic},
+	}
+
+	for _, testcase := range testcases {
+		k := &Kafka{
+			Topic:       topic,
+			TopicSuffix: testcase.topicSuffix,
+		}
+
+		_, topic := k.GetTopicName(m)
+		require.Equal(t, testcase.expectedTopic, topic)
+	}
+}
+
+func TestValidateTopicSuffixMethod(t *testing.T) {
+	for _, method := range ValidTopicSuffixMethods {
+		require.NoError(t, ValidateTopicSuffixMethod(method), "Method %s should be valid", method)
+	}
+	require.Error(t, ValidateTopicSuffixMethod("tag"))
+}
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		kafka  *Kafka
+		metric cua.Metric
+		check  func(t *testing.T, routingKey string)
+	}{
+		{
+			name:  "static routing key",
+			kafka: &Kafka{RoutingKey: "static"},
+			metric: testutil.MustMetric("cpu", map[string]string{},
+				map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+			check: func(t *testing.T, routingKey string) {
+				require.Equal(t, "static", routingKey)
+			},
+		},
+		{
+			name:  "random routing key",
+			kafka: &Kafka{RoutingKey: "random"},
+			metric: testutil.MustMetric("cpu", map[string]string{},
+				map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+			check: func(t *testing.T, routingKey string) {
+				require.Equal(t, 16, len(routingKey))
+			},
+		},
+		{
+			name:  "routing tag preferred",
+			kafka: &Kafka{RoutingTag: "host", RoutingKey: "static"},
+			metric: testutil.MustMetric("cpu", map[string]string{"host": "example.org"},
+				map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+			check: func(t *testing.T, routingKey string) {
+				require.Equal(t, "example.org", routingKey)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, tt.kafka.routingKey(tt.metric))
+		})
+	}
+}
+
+func TestTopicTag(t *testing.T) {
+	m := testutil.MustMetric("cpu", map[string]string{"topic": "xyzzy", "host": "example.org"},
+		map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+
+	k := &Kafka{Topic: "circonus", TopicTag: "topic", ExcludeTopicTag: true}
+	out, topic := k.GetTopicName(m)
+	require.Equal(t, "xyzzy", topic)
+	require.False(t, out.HasTag("topic"))
+	// the buffered metric is left untouched
+	require.True(t, m.HasTag("topic"))
+
+	k = &Kafka{Topic: "circonus", TopicTag: "missing"}
+	_, topic = k.GetTopicName(m)
+	require.Equal(t, "circonus", topic)
+}
+
+func TestWrite(t *testing.T) {
+	s, err := serializers.NewJSONSerializer(time.Second)
+	require.NoError(t, err)
+
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewSyncProducer(t, config)
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+		require.Equal(t, `{"fields":{"value":42},"name":"cpu","tags":{"host":"example.org"},"timestamp":1600000000}`+"\n", string(val))
+		return nil
+	})
+
+	k := &Kafka{
+		Topic:      "circonus",
+		RoutingTag: "host",
+		Log:        testutil.Logger{},
+		producerFunc: func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error) {
+			return producer, nil
+		},
+	}
+	k.SetSerializer(s)
+	require.NoError(t, k.Init())
+	require.NoError(t, k.Connect())
+
+	n, err := k.Write([]cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "example.org"},
+			map[string]interface{}{"value": 42.0}, time.Unix(1600000000, 0)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, k.Close())
+}
+
+func TestWriteMessageTooLarge(t *testing.T) {
+	s, err := serializers.NewJSONSerializer(time.Second)
+	require.NoError(t, err)
+
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewSyncProducer(t, config)
+	producer.ExpectSendMessageAndFail(sarama.ProducerErrors{
+		&sarama.ProducerError{Err: sarama.ErrMessageSizeTooLarge},
+	})
+
+	k := &Kafka{
+		Topic:    "circonus",
+		Log:      testutil.Logger{},
+		producer: producer,
+	}
+	k.SetSerializer(s)
+
+	// the batch is dropped, retrying it would fail the same way
+	_, err = k.Write([]cua.Metric{testutil.TestMetric(1)})
+	require.NoError(t, err)
+	require.NoError(t, k.Close())
+}
@@ -0,0 +1,251 @@
+package nats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers"
+	"github.com/nats-io/nats.go"
+)
+
+var sampleConfig = `
+  ## URLs of NATS servers
+  servers = ["nats://localhost:4222"]
+
+  ## Optional client name
+  # name = ""
+
+  ## Optional credentials
+  # username = ""
+  # password = ""
+
+  ## Optional NATS 2.0 and NATS NGS compatible user credentials
+  # credentials = "/etc/circonus-unified-agent/nats.creds"
+
+  ## NATS subject for producer messages
+  subject = "circonus"
+
+  ## Wait for a publish acknowledgement from the JetStream stream capturing
+  ## the subject; a batch is only considered written once every message was
+  ## acknowledged. The stream has to exist, it is not created by the plugin.
+  # jetstream = false
+
+  ## Timeout for flushing published messages to the server and, with
+  ## jetstream, for each publish acknowledgement.
+  # timeout = "5s"
+
+  ## Use Transport Layer Security
+  # secure = false
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## If true use batch serialization format instead of line based delimiting;
+  ## the batch is published as a single message.
+  # use_batch_format = false
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/circonus-labs/circonus-unified-agent/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "json"
+`
+
+type NATS struct {
+	Servers        []string          `toml:"servers"`
+	Secure         bool              `toml:"secure"`
+	Name           string            `toml:"name"`
+	Username       string            `toml:"username"`
+	Password       string            `toml:"password"`
+	Credentials    string            `toml:"credentials"`
+	Subject        string            `toml:"subject"`
+	JetStream      bool              `toml:"jetstream"`
+	Timeout        internal.Duration `toml:"timeout"`
+	UseBatchFormat bool              `toml:"use_batch_format"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	conn       *nats.Conn
+	serializer serializers.Serializer
+}
+
+// pubAck is the answer of a JetStream stream to a published message
+type pubAck struct {
+	Stream    string `json:"stream"`
+	Sequence  uint64 `json:"seq"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Error     *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+func (n *NATS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NATS) Description() string {
+	return "Send metrics to NATS"
+}
+
+func (n *NATS) SetSerializer(serializer serializers.Serializer) {
+	n.serializer = serializer
+}
+
+func (n *NATS) Init() error {
+	if len(n.Servers) == 0 {
+		return errors.New("no servers specified")
+	}
+	if n.Subject == "" {
+		return errors.New("no subject specified")
+	}
+	return nil
+}
+
+func (n *NATS) Connect() error {
+	var err error
+
+	opts := []nats.Option{
+		nats.MaxReconnects(-1),
+	}
+
+	// override authentication, if any was specified
+	if n.Username != "" {
+		opts = append(opts, nats.UserInfo(n.Username, n.Password))
+	}
+
+	if n.Credentials != "" {
+		opts = append(opts, nats.UserCredentials(n.Credentials))
+	}
+
+	if n.Name != "" {
+		opts = append(opts, nats.Name(n.Name))
+	}
+
+	if n.Secure {
+		tlsConfig, err := n.ClientConfig.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("TLSConfig: %w", err)
+		}
+
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	// try and connect
+	n.conn, err = nats.Connect(strings.Join(n.Servers, ","), opts...)
+	if err != nil {
+		return fmt.Errorf("nats connect (%s): %w", strings.Join(n.Servers, ","), err)
+	}
+
+	return nil
+}
+
+func (n *NATS) Close() error {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	return nil
+}
+
+func (n *NATS) Write(metrics []cua.Metric) (int, error) {
+	if len(metrics) == 0 {
+		return 0, nil
+	}
+
+	if n.UseBatchFormat {
+		buf, err := n.serializer.SerializeBatch(metrics)
+		if err != nil {
+			n.Log.Errorf("Could not serialize metrics: %v", err)
+			return 0, nil
+		}
+		if err := n.publish(buf); err != nil {
+			return 0, err
+		}
+		if err := n.flush(); err != nil {
+			return 0, err
+		}
+		return len(metrics), nil
+	}
+
+	for i, metric := range metrics {
+		buf, err := n.serializer.Serialize(metric)
+		if err != nil {
+			n.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+		if err := n.publish(buf); err != nil {
+			return i, err
+		}
+	}
+	if err := n.flush(); err != nil {
+		return 0, err
+	}
+	return len(metrics), nil
+}
+
+// publish sends one message to the subject, with jetstream it waits for
+// the stream to acknowledge that the message was stored.
+func (n *NATS) publish(buf []byte) error {
+	if !n.JetStream {
+		if err := n.conn.Publish(n.Subject, buf); err != nil {
+			return fmt.Errorf("nats publish (%s): %w", n.Subject, err)
+		}
+		return nil
+	}
+
+	msg, err := n.conn.Request(n.Subject, buf, n.Timeout.Duration)
+	if err != nil {
+		if errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("nats publish (%s): no acknowledgement, is the subject captured by a stream: %w", n.Subject, err)
+		}
+		return fmt.Errorf("nats publish (%s): %w", n.Subject, err)
+	}
+
+	var ack pubAck
+	if err := json.Unmarshal(msg.Data, &ack); err != nil {
+		return fmt.Errorf("nats publish (%s): invalid acknowledgement %q: %w", n.Subject, msg.Data, err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("nats publish (%s): stream error %d: %s", n.Subject, ack.Error.Code, ack.Error.Description)
+	}
+	if ack.Stream == "" {
+		return fmt.Errorf("nats publish (%s): invalid acknowledgement %q", n.Subject, msg.Data)
+	}
+	if ack.Duplicate {
+		n.Log.Debugf("Message was a duplicate of sequence %d in stream %s", ack.Sequence, ack.Stream)
+	}
+	return nil
+}
+
+// flush makes sure the server received the published messages, so that a
+// lost connection fails the write instead of silently dropping the batch
+func (n *NATS) flush() error {
+	if n.JetStream {
+		// every message was acknowledged already
+		return nil
+	}
+	if err := n.conn.FlushTimeout(n.Timeout.Duration); err != nil {
+		return fmt.Errorf("nats flush: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	outputs.Add("nats", func() cua.Output {
+		return &NATS{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
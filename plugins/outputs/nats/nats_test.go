@@ -0,0 +1,126 @@
+package nats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/json"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func runServer(t *testing.T) *server.Server {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	s := natsserver.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func newPlugin(t *testing.T, url string) *NATS {
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+
+	n := &NATS{
+		Servers: []string{url},
+		Subject: "metrics",
+		Timeout: internal.Duration{Duration: time.Second},
+		Log:     testutil.Logger{},
+	}
+	n.SetSerializer(serializer)
+	require.NoError(t, n.Init())
+	require.NoError(t, n.Connect())
+	t.Cleanup(func() { _ = n.Close() })
+	return n
+}
+
+func testMetrics() []cua.Metric {
+	now := time.Unix(0, 0)
+	return []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 42.0}, now),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"idle": 43.0}, now),
+	}
+}
+
+func subscribe(t *testing.T, url string, handler nats.MsgHandler) *nats.Conn {
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	_, err = nc.Subscribe("metrics", handler)
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+	return nc
+}
+
+func TestWrite(t *testing.T) {
+	s := runServer(t)
+
+	received := make(chan *nats.Msg, 10)
+	subscribe(t, s.ClientURL(), func(m *nats.Msg) { received <- m })
+
+	n := newPlugin(t, s.ClientURL())
+	written, err := n.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+
+	for _, host := range []string{"a", "b"} {
+		select {
+		case m := <-received:
+			require.Contains(t, string(m.Data), fmt.Sprintf(`"host":%q`, host))
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestWriteJetStreamAck(t *testing.T) {
+	s := runServer(t)
+
+	// answer like a stream capturing the subject would
+	seq := 0
+	subscribe(t, s.ClientURL(), func(m *nats.Msg) {
+		seq++
+		_ = m.Respond([]byte(fmt.Sprintf(`{"stream":"METRICS","seq":%d}`, seq)))
+	})
+
+	n := newPlugin(t, s.ClientURL())
+	n.JetStream = true
+	n.UseBatchFormat = true
+
+	written, err := n.Write(testMetrics())
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+	require.Equal(t, 1, seq)
+}
+
+func TestWriteJetStreamError(t *testing.T) {
+	s := runServer(t)
+
+	subscribe(t, s.ClientURL(), func(m *nats.Msg) {
+		_ = m.Respond([]byte(`{"error":{"code":503,"description":"storage full"}}`))
+	})
+
+	n := newPlugin(t, s.ClientURL())
+	n.JetStream = true
+
+	_, err := n.Write(testMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "storage full")
+}
+
+func TestWriteJetStreamNoStream(t *testing.T) {
+	s := runServer(t)
+
+	n := newPlugin(t, s.ClientURL())
+	n.JetStream = true
+	n.Timeout = internal.Duration{Duration: 100 * time.Millisecond}
+
+	_, err := n.Write(testMetrics())
+	require.Error(t, err)
+}
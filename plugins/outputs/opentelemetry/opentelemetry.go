@@ -0,0 +1,247 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	defaultServiceAddress = "localhost:4317"
+	defaultTimeout        = 5 * time.Second
+	defaultServiceName    = "circonus-unified-agent"
+	scopeName             = "circonus-unified-agent"
+)
+
+var sampleConfig = `
+  ## Address of the OTLP gRPC receiver, usually an OpenTelemetry collector.
+  # service_address = "localhost:4317"
+
+  ## Timeout of an export request
+  # timeout = "5s"
+
+  ## Compression of the export requests, "gzip" or "none"
+  # compression = "gzip"
+
+  ## Optional TLS Config, when not set the connection is not encrypted
+  # tls_ca = "/etc/circonus-unified-agent/ca.pem"
+  # tls_cert = "/etc/circonus-unified-agent/cert.pem"
+  # tls_key = "/etc/circonus-unified-agent/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Resource attributes sent with every export. service.name defaults to
+  ## "circonus-unified-agent".
+  # [outputs.opentelemetry.attributes]
+  #   "service.name" = "circonus-unified-agent"
+  #   "deployment.environment" = "production"
+
+  ## gRPC metadata sent with every export, e.g. for authentication
+  # [outputs.opentelemetry.headers]
+  #   key1 = "value1"
+`
+
+type OpenTelemetry struct {
+	ServiceAddress string            `toml:"service_address"`
+	Timeout        internal.Duration `toml:"timeout"`
+	Compression    string            `toml:"compression"`
+	Attributes     map[string]string `toml:"attributes"`
+	Headers        map[string]string `toml:"headers"`
+	tls.ClientConfig
+
+	Log cua.Logger `toml:"-"`
+
+	conn      *grpc.ClientConn
+	callOpts  []grpc.CallOption
+	resource  *resource
+	startTime uint64
+}
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Send metrics to an OpenTelemetry collector with OTLP over gRPC"
+}
+
+func (o *OpenTelemetry) Init() error {
+	switch o.Compression {
+	case "", "none":
+	case "gzip":
+		o.callOpts = append(o.callOpts, grpc.UseCompressor(gzip.Name))
+	default:
+		return fmt.Errorf("invalid compression %q", o.Compression)
+	}
+
+	attributes := map[string]string{"service.name": defaultServiceName}
+	for k, v := range o.Attributes {
+		attributes[k] = v
+	}
+	o.resource = &resource{Attributes: keyValues(attributes)}
+	return nil
+}
+
+func (o *OpenTelemetry) Connect() error {
+	tlsCfg, err := o.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+
+	opts := []grpc.DialOption{grpc.WithUserAgent(internal.ProductToken())}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	// the connection is established in the background and reestablished
+	// as needed, failures are reported by the exports
+	conn, err := grpc.Dial(o.ServiceAddress, opts...)
+	if err != nil {
+		return fmt.Errorf("grpc dial (%s): %w", o.ServiceAddress, err)
+	}
+	o.conn = conn
+	o.startTime = uint64(time.Now().UnixNano())
+	return nil
+}
+
+func (o *OpenTelemetry) Close() error {
+	if o.conn == nil {
+		return nil
+	}
+	if err := o.conn.Close(); err != nil {
+		return fmt.Errorf("grpc close: %w", err)
+	}
+	return nil
+}
+
+func (o *OpenTelemetry) Write(metrics []cua.Metric) (int, error) {
+	req := &exportMetricsServiceRequest{
+		ResourceMetrics: []*resourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []*scopeMetrics{{
+				Scope:   &instrumentationScope{Name: scopeName, Version: internal.Version()},
+				Metrics: o.convert(metrics),
+			}},
+		}},
+	}
+	if len(req.ResourceMetrics[0].ScopeMetrics[0].Metrics) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout.Duration)
+	defer cancel()
+	if len(o.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.Headers))
+	}
+
+	var resp exportMetricsServiceResponse
+	if err := o.conn.Invoke(ctx, exportMethod, req, &resp, o.callOpts...); err != nil {
+		return 0, fmt.Errorf("export (%s): %w", o.ServiceAddress, err)
+	}
+	if ps := resp.PartialSuccess; ps != nil && (ps.RejectedDataPoints > 0 || ps.ErrorMessage != "") {
+		// the rejected points would be rejected again
+		o.Log.Warnf("Receiver rejected %d data points: %s", ps.RejectedDataPoints, ps.ErrorMessage)
+	}
+
+	return len(metrics), nil
+}
+
+// convert turns each numeric field into a data point of the metric named
+// after the measurement and field. Counters become cumulative monotonic
+// sums, all other types gauges; string fields are skipped.
+func (o *OpenTelemetry) convert(metrics []cua.Metric) []*metric {
+	type key struct {
+		name    string
+		counter bool
+	}
+
+	var out []*metric
+	index := make(map[key]*metric)
+	for _, m := range metrics {
+		attributes := keyValues(m.Tags())
+		ts := uint64(m.Time().UnixNano())
+		counter := m.Type() == cua.Counter
+
+		for _, field := range m.FieldList() {
+			dp := &numberDataPoint{
+				Attributes:   attributes,
+				TimeUnixNano: ts,
+			}
+			switch v := field.Value.(type) {
+			case float64:
+				dp.AsDouble = &v
+			case int64:
+				dp.AsInt = &v
+			case uint64:
+				i := int64(v)
+				if v > uint64(1<<63-1) {
+					i = 1<<63 - 1
+				}
+				dp.AsInt = &i
+			case bool:
+				var i int64
+				if v {
+					i = 1
+				}
+				dp.AsInt = &i
+			default:
+				continue
+			}
+
+			k := key{name: m.Name() + "_" + field.Key, counter: counter}
+			om, ok := index[k]
+			if !ok {
+				om = &metric{Name: k.name}
+				if counter {
+					om.Sum = &sum{
+						AggregationTemporality: temporalityCumulative,
+						IsMonotonic:            true,
+					}
+				} else {
+					om.Gauge = &gauge{}
+				}
+				index[k] = om
+				out = append(out, om)
+			}
+			if counter {
+				dp.StartTimeUnixNano = o.startTime
+				om.Sum.DataPoints = append(om.Sum.DataPoints, dp)
+			} else {
+				om.Gauge.DataPoints = append(om.Gauge.DataPoints, dp)
+			}
+		}
+	}
+	return out
+}
+
+// keyValues converts tags to attributes, sorted by key.
+func keyValues(tags map[string]string) []*keyValue {
+	kvs := make([]*keyValue, 0, len(tags))
+	for k, v := range tags {
+		kvs = append(kvs, &keyValue{Key: k, Value: stringValue(v)})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+func init() {
+	outputs.Add("opentelemetry", func() cua.Output {
+		return &OpenTelemetry{
+			ServiceAddress: defaultServiceAddress,
+			Timeout:        internal.Duration{Duration: defaultTimeout},
+			Compression:    "gzip",
+		}
+	})
+}
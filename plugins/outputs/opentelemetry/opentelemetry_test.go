@@ -0,0 +1,148 @@
+package opentelemetry
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// receiver is an OTLP metrics service answering every export.
+type receiver struct {
+	requests chan *exportMetricsServiceRequest
+	metadata chan metadata.MD
+	response *exportMetricsServiceResponse
+}
+
+func startReceiver(t *testing.T, r *receiver) string {
+	desc := grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &exportMetricsServiceRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				md, _ := metadata.FromIncomingContext(ctx)
+				r.metadata <- md
+				r.requests <- req
+				return r.response, nil
+			},
+		}},
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	srv.RegisterService(&desc, r)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+	return l.Addr().String()
+}
+
+func TestWrite(t *testing.T) {
+	r := &receiver{
+		requests: make(chan *exportMetricsServiceRequest, 1),
+		metadata: make(chan metadata.MD, 1),
+		response: &exportMetricsServiceResponse{},
+	}
+	addr := startReceiver(t, r)
+
+	o := &OpenTelemetry{
+		ServiceAddress: addr,
+		Timeout:        internal.Duration{Duration: 5 * time.Second},
+		Compression:    "gzip",
+		Attributes:     map[string]string{"deployment.environment": "test"},
+		Headers:        map[string]string{"authorization": "Bearer xyzzy"},
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, o.Init())
+	require.NoError(t, o.Connect())
+	defer o.Close()
+
+	now := time.Unix(1600000000, 0)
+	n, err := o.Write([]cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 0.0, "state": "ok"}, now),
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"usage_idle": 99.5}, now),
+		testutil.MustMetric("net", map[string]string{"interface": "eth0"},
+			map[string]interface{}{"bytes_recv": uint64(42)}, now, cua.Counter),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.Equal(t, []string{"Bearer xyzzy"}, (<-r.metadata).Get("authorization"))
+
+	req := <-r.requests
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	require.Equal(t, keyValues(map[string]string{
+		"deployment.environment": "test",
+		"service.name":           "circonus-unified-agent",
+	}), rm.Resource.Attributes)
+
+	metrics := rm.ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 2)
+
+	require.Equal(t, "cpu_usage_idle", metrics[0].Name)
+	require.Nil(t, metrics[0].Sum)
+	points := metrics[0].Gauge.DataPoints
+	require.Len(t, points, 2)
+	require.Equal(t, 0.0, *points[0].AsDouble)
+	require.Equal(t, 99.5, *points[1].AsDouble)
+	require.Equal(t, uint64(now.UnixNano()), points[1].TimeUnixNano)
+	require.Equal(t, keyValues(map[string]string{"cpu": "cpu1"}), points[1].Attributes)
+
+	require.Equal(t, "net_bytes_recv", metrics[1].Name)
+	require.True(t, metrics[1].Sum.IsMonotonic)
+	require.Equal(t, temporalityCumulative, metrics[1].Sum.AggregationTemporality)
+	require.Equal(t, int64(42), *metrics[1].Sum.DataPoints[0].AsInt)
+	require.NotZero(t, metrics[1].Sum.DataPoints[0].StartTimeUnixNano)
+}
+
+func TestWriteUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	o := &OpenTelemetry{
+		ServiceAddress: addr,
+		Timeout:        internal.Duration{Duration: time.Second},
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, o.Init())
+	require.NoError(t, o.Connect())
+	defer o.Close()
+
+	_, err = o.Write([]cua.Metric{testutil.TestMetric(1.0)})
+	require.Error(t, err)
+}
+
+func TestDataPointEncoding(t *testing.T) {
+	// zero values of the oneof members are encoded, the field numbers and
+	// wire types are those of NumberDataPoint
+	v := 0.0
+	b, err := proto.Marshal(&numberDataPoint{TimeUnixNano: 1, AsDouble: &v})
+	require.NoError(t, err)
+	require.Equal(t, []byte{
+		0x19, 1, 0, 0, 0, 0, 0, 0, 0, // time_unix_nano
+		0x21, 0, 0, 0, 0, 0, 0, 0, 0, // as_double
+	}, b)
+
+	i := int64(-1)
+	b, err = proto.Marshal(&numberDataPoint{AsInt: &i})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x31, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, b)
+}
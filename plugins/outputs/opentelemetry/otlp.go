@@ -0,0 +1,144 @@
+package opentelemetry
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The OTLP metrics messages sent by the output, a subset of the
+// opentelemetry-proto v1 definitions. The members of a oneof are pointers
+// so they are encoded whenever set, zero values included.
+
+const exportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+
+// temporalityCumulative is the AGGREGATION_TEMPORALITY_CUMULATIVE value of
+// the aggregation temporality of a sum.
+const temporalityCumulative int32 = 2
+
+type exportMetricsServiceRequest struct {
+	ResourceMetrics []*resourceMetrics `protobuf:"bytes,1,rep,name=resource_metrics,json=resourceMetrics,proto3"`
+}
+
+func (m *exportMetricsServiceRequest) Reset()         { *m = exportMetricsServiceRequest{} }
+func (m *exportMetricsServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*exportMetricsServiceRequest) ProtoMessage()    {}
+
+type exportMetricsServiceResponse struct {
+	PartialSuccess *exportMetricsPartialSuccess `protobuf:"bytes,1,opt,name=partial_success,json=partialSuccess,proto3"`
+}
+
+func (m *exportMetricsServiceResponse) Reset()         { *m = exportMetricsServiceResponse{} }
+func (m *exportMetricsServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*exportMetricsServiceResponse) ProtoMessage()    {}
+
+type exportMetricsPartialSuccess struct {
+	RejectedDataPoints int64  `protobuf:"varint,1,opt,name=rejected_data_points,json=rejectedDataPoints,proto3"`
+	ErrorMessage       string `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3"`
+}
+
+func (m *exportMetricsPartialSuccess) Reset()         { *m = exportMetricsPartialSuccess{} }
+func (m *exportMetricsPartialSuccess) String() string { return proto.CompactTextString(m) }
+func (*exportMetricsPartialSuccess) ProtoMessage()    {}
+
+type resourceMetrics struct {
+	Resource     *resource       `protobuf:"bytes,1,opt,name=resource,proto3"`
+	ScopeMetrics []*scopeMetrics `protobuf:"bytes,2,rep,name=scope_metrics,json=scopeMetrics,proto3"`
+}
+
+func (m *resourceMetrics) Reset()         { *m = resourceMetrics{} }
+func (m *resourceMetrics) String() string { return proto.CompactTextString(m) }
+func (*resourceMetrics) ProtoMessage()    {}
+
+type resource struct {
+	Attributes []*keyValue `protobuf:"bytes,1,rep,name=attributes,proto3"`
+}
+
+func (m *resource) Reset()         { *m = resource{} }
+func (m *resource) String() string { return proto.CompactTextString(m) }
+func (*resource) ProtoMessage()    {}
+
+type scopeMetrics struct {
+	Scope   *instrumentationScope `protobuf:"bytes,1,opt,name=scope,proto3"`
+	Metrics []*metric             `protobuf:"bytes,2,rep,name=metrics,proto3"`
+}
+
+func (m *scopeMetrics) Reset()         { *m = scopeMetrics{} }
+func (m *scopeMetrics) String() string { return proto.CompactTextString(m) }
+func (*scopeMetrics) ProtoMessage()    {}
+
+type instrumentationScope struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3"`
+}
+
+func (m *instrumentationScope) Reset()         { *m = instrumentationScope{} }
+func (m *instrumentationScope) String() string { return proto.CompactTextString(m) }
+func (*instrumentationScope) ProtoMessage()    {}
+
+type keyValue struct {
+	Key   string    `protobuf:"bytes,1,opt,name=key,proto3"`
+	Value *anyValue `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *keyValue) Reset()         { *m = keyValue{} }
+func (m *keyValue) String() string { return proto.CompactTextString(m) }
+func (*keyValue) ProtoMessage()    {}
+
+// anyValue holds one of its members.
+type anyValue struct {
+	StringValue *string  `protobuf:"bytes,1,opt,name=string_value,json=stringValue"`
+	BoolValue   *bool    `protobuf:"varint,2,opt,name=bool_value,json=boolValue"`
+	IntValue    *int64   `protobuf:"varint,3,opt,name=int_value,json=intValue"`
+	DoubleValue *float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue"`
+}
+
+func (m *anyValue) Reset()         { *m = anyValue{} }
+func (m *anyValue) String() string { return proto.CompactTextString(m) }
+func (*anyValue) ProtoMessage()    {}
+
+// metric holds either a gauge or a sum.
+type metric struct {
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3"`
+	Unit        string `protobuf:"bytes,3,opt,name=unit,proto3"`
+	Gauge       *gauge `protobuf:"bytes,5,opt,name=gauge"`
+	Sum         *sum   `protobuf:"bytes,7,opt,name=sum"`
+}
+
+func (m *metric) Reset()         { *m = metric{} }
+func (m *metric) String() string { return proto.CompactTextString(m) }
+func (*metric) ProtoMessage()    {}
+
+type gauge struct {
+	DataPoints []*numberDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints,proto3"`
+}
+
+func (m *gauge) Reset()         { *m = gauge{} }
+func (m *gauge) String() string { return proto.CompactTextString(m) }
+func (*gauge) ProtoMessage()    {}
+
+type sum struct {
+	DataPoints             []*numberDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints,proto3"`
+	AggregationTemporality int32              `protobuf:"varint,2,opt,name=aggregation_temporality,json=aggregationTemporality,proto3"`
+	IsMonotonic            bool               `protobuf:"varint,3,opt,name=is_monotonic,json=isMonotonic,proto3"`
+}
+
+func (m *sum) Reset()         { *m = sum{} }
+func (m *sum) String() string { return proto.CompactTextString(m) }
+func (*sum) ProtoMessage()    {}
+
+// numberDataPoint holds either a double or an int value.
+type numberDataPoint struct {
+	Attributes        []*keyValue `protobuf:"bytes,7,rep,name=attributes,proto3"`
+	StartTimeUnixNano uint64      `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano,proto3"`
+	TimeUnixNano      uint64      `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano,proto3"`
+	AsDouble          *float64    `protobuf:"fixed64,4,opt,name=as_double,json=asDouble"`
+	AsInt             *int64      `protobuf:"fixed64,6,opt,name=as_int,json=asInt"`
+}
+
+func (m *numberDataPoint) Reset()         { *m = numberDataPoint{} }
+func (m *numberDataPoint) String() string { return proto.CompactTextString(m) }
+func (*numberDataPoint) ProtoMessage()    {}
+
+func stringValue(s string) *anyValue {
+	return &anyValue{StringValue: &s}
+}
@@ -0,0 +1,274 @@
+package prometheusclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	tlsint "github.com/circonus-labs/circonus-unified-agent/plugins/common/tls"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/outputs"
+	serializer "github.com/circonus-labs/circonus-unified-agent/plugins/serializers/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	defaultListen             = ":9273"
+	defaultPath               = "/metrics"
+	defaultExpirationInterval = 60 * time.Second
+	defaultReadTimeout        = 10 * time.Second
+	defaultWriteTimeout       = 10 * time.Second
+)
+
+var sampleConfig = `
+  ## Address to listen on.
+  # listen = ":9273"
+
+  ## Path to publish the metrics on.
+  # path = "/metrics"
+
+  ## Metrics not written again within the expiration interval are no longer
+  ## exposed. When set to 0 metrics never expire.
+  # expiration_interval = "60s"
+
+  ## Username and password to accept for HTTP basic authentication.
+  # basic_username = "Foo"
+  # basic_password = "Bar"
+
+  ## If set, the IP Ranges which are allowed to access metrics.
+  ##   ex: ip_range = ["192.168.0.0/24", "192.168.1.0/30"]
+  # ip_range = []
+
+  ## Send string fields as labels; by default they are discarded.
+  # string_as_label = false
+
+  ## Export the metric timestamp. By default the time of the scrape is used.
+  # export_timestamp = false
+
+  ## The maximum duration for reading the entire request.
+  # read_timeout = "10s"
+  ## The maximum duration for writing the entire response.
+  # write_timeout = "10s"
+
+  ## If set, enable TLS with the given certificate.
+  # tls_cert = "/etc/ssl/cua.crt"
+  # tls_key = "/etc/ssl/cua.key"
+
+  ## Set one or more allowed client CA certificate file names to
+  ## enable mutually authenticated TLS connections
+  # tls_allowed_cacerts = ["/etc/circonus-unified-agent/clientca.pem"]
+`
+
+type PrometheusClient struct {
+	Listen             string            `toml:"listen"`
+	Path               string            `toml:"path"`
+	ExpirationInterval internal.Duration `toml:"expiration_interval"`
+	BasicUsername      string            `toml:"basic_username"`
+	BasicPassword      string            `toml:"basic_password"`
+	IPRange            []string          `toml:"ip_range"`
+	StringAsLabel      bool              `toml:"string_as_label"`
+	ExportTimestamp    bool              `toml:"export_timestamp"`
+	ReadTimeout        internal.Duration `toml:"read_timeout"`
+	WriteTimeout       internal.Duration `toml:"write_timeout"`
+	tlsint.ServerConfig
+
+	Log cua.Logger `toml:"-"`
+
+	server  *http.Server
+	url     *url.URL
+	tlsConf *tls.Config
+	ipRange []*net.IPNet
+	wg      sync.WaitGroup
+
+	mu   sync.Mutex
+	coll *serializer.Collection
+}
+
+func (p *PrometheusClient) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PrometheusClient) Description() string {
+	return "Expose metrics for scraping by Prometheus on a HTTP endpoint"
+}
+
+func (p *PrometheusClient) Init() error {
+	for _, cidr := range p.IPRange {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("parsing ip_range (%s): %w", cidr, err)
+		}
+		p.ipRange = append(p.ipRange, ipNet)
+	}
+
+	tlsConf, err := p.ServerConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("TLSConfig: %w", err)
+	}
+	p.tlsConf = tlsConf
+
+	cfg := serializer.FormatConfig{
+		MetricSortOrder: serializer.SortMetrics,
+	}
+	if p.StringAsLabel {
+		cfg.StringHandling = serializer.StringAsLabel
+	}
+	if p.ExportTimestamp {
+		cfg.TimestampExport = serializer.ExportTimestamp
+	}
+	p.coll = serializer.NewCollection(cfg)
+
+	return nil
+}
+
+// Connect starts the HTTP server.
+func (p *PrometheusClient) Connect() error {
+	authHandler := internal.AuthHandler(p.BasicUsername, p.BasicPassword, "prometheus", onAuthError)
+	rangeHandler := internal.IPRangeHandler(p.ipRange, onError)
+
+	mux := http.NewServeMux()
+	if p.Path == "" {
+		p.Path = defaultPath
+	}
+	mux.Handle(p.Path, authHandler(rangeHandler(p)))
+
+	p.server = &http.Server{
+		Addr:         p.Listen,
+		Handler:      mux,
+		ReadTimeout:  p.ReadTimeout.Duration,
+		WriteTimeout: p.WriteTimeout.Duration,
+		TLSConfig:    p.tlsConf,
+	}
+
+	listener, err := p.listen()
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if p.tlsConf != nil {
+		scheme = "https"
+	}
+	p.url = &url.URL{
+		Scheme: scheme,
+		Host:   listener.Addr().String(),
+		Path:   p.Path,
+	}
+
+	p.Log.Infof("Listening on %s", p.URL())
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		err := p.server.Serve(listener)
+		if !errors.Is(err, http.ErrServerClosed) {
+			p.Log.Errorf("Server error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func onAuthError(_ http.ResponseWriter) {
+}
+
+func onError(rw http.ResponseWriter, code int) {
+	http.Error(rw, http.StatusText(code), code)
+}
+
+func (p *PrometheusClient) listen() (net.Listener, error) {
+	if p.tlsConf != nil {
+		l, err := tls.Listen("tcp", p.Listen, p.tlsConf)
+		if err != nil {
+			return nil, fmt.Errorf("tls listen (%s): %w", p.Listen, err)
+		}
+		return l, nil
+	}
+	l, err := net.Listen("tcp", p.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("listen (%s): %w", p.Listen, err)
+	}
+	return l, nil
+}
+
+// URL returns the address the metrics are published on.
+func (p *PrometheusClient) URL() string {
+	if p.url != nil {
+		return p.url.String()
+	}
+	return ""
+}
+
+// ServeHTTP answers the metrics in the exposition format negotiated with
+// the scraper.
+func (p *PrometheusClient) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	p.expire(time.Now())
+	families := p.coll.GetProto()
+	p.mu.Unlock()
+
+	format := expfmt.Negotiate(req.Header)
+	rw.Header().Set("Content-Type", string(format))
+	enc := expfmt.NewEncoder(rw, format)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			p.Log.Errorf("Encoding %s: %v", mf.GetName(), err)
+			return
+		}
+	}
+}
+
+// Write adds the metrics to the exposed collection, replacing earlier values
+// of the same series.
+func (p *PrometheusClient) Write(metrics []cua.Metric) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, m := range metrics {
+		p.coll.Add(m, now)
+	}
+	p.expire(now)
+	return len(metrics), nil
+}
+
+func (p *PrometheusClient) expire(now time.Time) {
+	if p.ExpirationInterval.Duration > 0 {
+		p.coll.Expire(now, p.ExpirationInterval.Duration)
+	}
+}
+
+// Close shuts down the HTTP server.
+func (p *PrometheusClient) Close() error {
+	if p.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := p.server.Shutdown(ctx)
+	p.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	outputs.Add("prometheus_client", func() cua.Output {
+		return &PrometheusClient{
+			Listen:             defaultListen,
+			Path:               defaultPath,
+			ExpirationInterval: internal.Duration{Duration: defaultExpirationInterval},
+			ReadTimeout:        internal.Duration{Duration: defaultReadTimeout},
+			WriteTimeout:       internal.Duration{Duration: defaultWriteTimeout},
+		}
+	})
+}
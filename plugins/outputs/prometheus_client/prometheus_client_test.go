@@ -0,0 +1,91 @@
+package prometheusclient
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/internal"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, expiration time.Duration) *PrometheusClient {
+	p := &PrometheusClient{
+		Listen:             "127.0.0.1:0",
+		Path:               defaultPath,
+		ExpirationInterval: internal.Duration{Duration: expiration},
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	t.Cleanup(func() { require.NoError(t, p.Close()) })
+	return p
+}
+
+func scrape(t *testing.T, p *PrometheusClient) string {
+	resp, err := http.Get(p.URL())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestWriteAndScrape(t *testing.T) {
+	p := newClient(t, time.Minute)
+
+	_, err := p.Write([]cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42.0, "state": "ok"}, time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+
+	body := scrape(t, p)
+	require.Contains(t, body, "# TYPE cpu_time_idle untyped\n")
+	require.Contains(t, body, `cpu_time_idle{cpu="cpu0"} 42`+"\n")
+	require.NotContains(t, body, "state")
+
+	// a later value of the same series replaces the earlier one
+	_, err = p.Write([]cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 43.0}, time.Unix(10, 0)),
+	})
+	require.NoError(t, err)
+
+	body = scrape(t, p)
+	require.Contains(t, body, `cpu_time_idle{cpu="cpu0"} 43`+"\n")
+	require.NotContains(t, body, " 42\n")
+}
+
+func TestExpiration(t *testing.T) {
+	p := newClient(t, time.Millisecond)
+
+	_, err := p.Write([]cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"time_idle": 42.0}, time.Now()),
+	})
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	require.NotContains(t, scrape(t, p), "cpu_time_idle")
+}
+
+func TestIPRange(t *testing.T) {
+	p := &PrometheusClient{
+		Listen:  "127.0.0.1:0",
+		Path:    defaultPath,
+		IPRange: []string{"192.0.2.0/24"},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Connect())
+	defer p.Close()
+
+	resp, err := http.Get(p.URL())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,216 @@
+package syslog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/influxdata/go-syslog/v2/rfc5424"
+)
+
+// SyslogMapper builds RFC 5424 messages from metrics. Well known tags and
+// fields fill the header and the message, everything else becomes a
+// structured data parameter.
+type SyslogMapper struct {
+	DefaultSdid     string
+	DefaultSeverity uint8
+	DefaultFacility uint8
+	DefaultAppname  string
+	MessageField    string
+	Sdids           []string
+	Separator       string
+	reservedKeys    map[string]bool
+}
+
+// MapMetricToSyslogMessage maps metrics tags/fields to syslog messages
+func (sm *SyslogMapper) MapMetricToSyslogMessage(metric cua.Metric) (*rfc5424.SyslogMessage, error) {
+	msg := &rfc5424.SyslogMessage{}
+
+	sm.mapMsgID(metric, msg)
+	sm.mapVersion(metric, msg)
+	sm.mapSdsdata(metric, msg)
+	sm.mapAppname(metric, msg)
+	mapHostname(metric, msg)
+	mapTimestamp(metric, msg)
+	sm.mapPriority(metric, msg)
+	sm.mapProcID(metric, msg)
+	sm.mapMsg(metric, msg)
+
+	if !msg.Valid() {
+		return nil, errors.New("metric could not produce valid syslog message")
+	}
+	return msg, nil
+}
+
+func (sm *SyslogMapper) mapSdsdata(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	for _, tag := range metric.TagList() {
+		sm.mapSdid(tag.Key, tag.Value, msg)
+	}
+
+	for _, field := range metric.FieldList() {
+		sm.mapSdid(field.Key, formatValue(field.Value), msg)
+	}
+}
+
+func (sm *SyslogMapper) mapSdid(key string, value string, msg *rfc5424.SyslogMessage) {
+	if sm.reservedKeys[key] {
+		return
+	}
+
+	for _, sdid := range sm.Sdids {
+		if k := strings.TrimPrefix(key, sdid+sm.Separator); key != k {
+			msg.SetParameter(sdid, k, value)
+			return
+		}
+	}
+
+	if sm.DefaultSdid != "" {
+		k := strings.TrimPrefix(key, sm.DefaultSdid+sm.Separator)
+		msg.SetParameter(sm.DefaultSdid, k, value)
+	}
+}
+
+func (sm *SyslogMapper) mapMsgID(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	if value, ok := metric.GetField("msgid"); ok {
+		msg.SetMsgID(formatValue(value))
+	} else {
+		// We default to metric name
+		msg.SetMsgID(metric.Name())
+	}
+}
+
+func (sm *SyslogMapper) mapVersion(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	if value, ok := metric.GetField("version"); ok {
+		if v, ok := toUint(value); ok && v > 0 && v <= 999 {
+			msg.SetVersion(uint16(v))
+			return
+		}
+	}
+	msg.SetVersion(1)
+}
+
+func (sm *SyslogMapper) mapPriority(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	severity := sm.DefaultSeverity
+	if value, ok := codeValue(metric, "severity_code"); ok && value <= 7 {
+		severity = uint8(value)
+	}
+
+	facility := sm.DefaultFacility
+	if value, ok := codeValue(metric, "facility_code"); ok && value <= 23 {
+		facility = uint8(value)
+	}
+
+	msg.SetPriority(facility*8 + severity)
+}
+
+func (sm *SyslogMapper) mapProcID(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	if value, ok := metric.GetField("procid"); ok {
+		msg.SetProcID(formatValue(value))
+	}
+}
+
+func (sm *SyslogMapper) mapMsg(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	if value, ok := metric.GetField(sm.MessageField); ok {
+		msg.SetMessage(formatValue(value))
+	}
+}
+
+func (sm *SyslogMapper) mapAppname(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	if value, ok := metric.GetTag("appname"); ok {
+		msg.SetAppname(formatValue(value))
+	} else {
+		msg.SetAppname(sm.DefaultAppname)
+	}
+}
+
+func mapTimestamp(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	timestamp := metric.Time()
+	if value, ok := metric.GetField("timestamp"); ok {
+		if v, ok := value.(int64); ok {
+			timestamp = time.Unix(0, v).UTC()
+		}
+	}
+	msg.SetTimestamp(timestamp.Format(time.RFC3339Nano))
+}
+
+func mapHostname(metric cua.Metric, msg *rfc5424.SyslogMessage) {
+	// Try with hostname, then with source, then with host tags, then take OS Hostname
+	if value, ok := metric.GetTag("hostname"); ok {
+		msg.SetHostname(value)
+	} else if value, ok := metric.GetTag("source"); ok {
+		msg.SetHostname(value)
+	} else if value, ok := metric.GetTag("host"); ok {
+		msg.SetHostname(value)
+	} else if value, err := os.Hostname(); err == nil {
+		msg.SetHostname(value)
+	}
+}
+
+// codeValue looks a severity or facility code up in the fields and then in
+// the tags, so that processors like enum can set it from either.
+func codeValue(metric cua.Metric, key string) (uint64, bool) {
+	if value, ok := metric.GetField(key); ok {
+		return toUint(value)
+	}
+	if value, ok := metric.GetTag(key); ok {
+		v, err := strconv.ParseUint(value, 10, 8)
+		return v, err == nil
+	}
+	return 0, false
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) {
+			return ""
+		}
+
+		if math.IsInf(v, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+func toUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return v, true
+	case int64:
+		return uint64(v), v >= 0
+	case float64:
+		return uint64(v), v >= 0 && v == math.Trunc(v)
+	case string:
+		u, err := strconv.ParseUint(v, 10, 64)
+		return u, err == nil
+	}
+	return 0, false
+}
+
+func newSyslogMapper() *SyslogMapper {
+	return &SyslogMapper{
+		reservedKeys: map[string]bool{
+			"version": true, "severity_code": true, "facility_code": true,
+			"procid": true, "msgid": true, "msg": true, "timestamp": true, "sdid": true,
+			"hostname": true, "source": true, "host": true, "severity": true,
+			"facility": true, "appname": true},
+	}
+}
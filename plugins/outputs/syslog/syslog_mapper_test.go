@@ -0,0 +1,212 @@
+package syslog
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogMapperWithDefaults(t *testing.T) {
+	s := newSyslog()
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<13>1 2010-11-10T23:00:00Z "+hostname+" circonus-unified-agent - testmetric -", str)
+}
+
+func TestSyslogMapperWithHostname(t *testing.T) {
+	s := newSyslog()
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"hostname": "testhost",
+			"source":   "sourcevalue",
+			"host":     "hostvalue",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<13>1 2010-11-10T23:00:00Z testhost circonus-unified-agent - testmetric -", str)
+}
+
+func TestSyslogMapperWithHostnameSourceFallback(t *testing.T) {
+	s := newSyslog()
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"source": "sourcevalue",
+			"host":   "hostvalue",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<13>1 2010-11-10T23:00:00Z sourcevalue circonus-unified-agent - testmetric -", str)
+}
+
+func TestSyslogMapperWithDefaultSdid(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(3),
+			"facility_code":        uint64(3),
+			"msg":                  "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "foo",
+			"value3":               float64(1.2),
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<27>2 2010-11-10T23:30:00Z testhost testapp 25 555 [default@32473 tag1=\"bar\" tag2=\"foobar\" value1=\"2\" value2=\"foo\" value3=\"1.2\"] Test message", str)
+}
+
+func TestSyslogMapperWithDefaultSdidAndOtherSdids(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	s.Sdids = []string{"bar@123", "foo@456"}
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+			"bar@123_tag3":       "barfoobar",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(1),
+			"facility_code":        uint64(3),
+			"msg":                  "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "default",
+			"bar@123_value3":       int64(2),
+			"foo@456_value4":       "foo",
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<25>2 2010-11-10T23:30:00Z testhost testapp 25 555 [bar@123 tag3=\"barfoobar\" value3=\"2\"][default@32473 tag1=\"bar\" tag2=\"foobar\" value1=\"2\" value2=\"default\"][foo@456 value4=\"foo\"] Test message", str)
+}
+
+func TestSyslogMapperWithNoSdids(t *testing.T) {
+	s := newSyslog()
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+			"bar@123_tag3":       "barfoobar",
+			"foo@456_tag4":       "foobarfoo",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(2),
+			"facility_code":        uint64(3),
+			"msg":                  "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "default",
+			"bar@123_value3":       int64(2),
+			"foo@456_value4":       "foo",
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<26>2 2010-11-10T23:30:00Z testhost testapp 25 555 - Test message", str)
+}
+
+func TestSyslogMapperEventLog(t *testing.T) {
+	// a win_eventlog event with the severity set from its level by a processor
+	s := newSyslog()
+	s.DefaultSdid = "event@32473"
+	s.MessageField = "Message"
+	require.NoError(t, s.Init())
+
+	m1, err := metric.New(
+		"win_eventlog",
+		map[string]string{
+			"Computer":      "dc01",
+			"EventID":       "4625",
+			"severity_code": "4",
+		},
+		map[string]interface{}{
+			"Message": "An account failed to log on.",
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	m1.AddTag("hostname", "dc01")
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	require.Equal(t, "<12>1 2010-11-10T23:00:00Z dc01 circonus-unified-agent - win_eventlog [event@32473 Computer=\"dc01\" EventID=\"4625\"] An account failed to log on.", str)
+}
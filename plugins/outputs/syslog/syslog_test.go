@@ -0,0 +1,204 @@
+package syslog
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	framing "github.com/circonus-labs/circonus-unified-agent/internal/syslog"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSyslogMessageWithFramingOctectCounting(t *testing.T) {
+	// Init plugin
+	s := newSyslog()
+	require.NoError(t, s.Init())
+
+	// Init metrics
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"hostname": "testhost",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage.String)
+	require.NoError(t, err)
+
+	require.Equal(t, "73 <13>1 2010-11-10T23:00:00Z testhost circonus-unified-agent - testmetric -", string(messageBytesWithFraming), "Incorrect Octect counting framing")
+}
+
+func TestGetSyslogMessageWithFramingNonTransparent(t *testing.T) {
+	// Init plugin
+	s := newSyslog()
+	require.NoError(t, s.Init())
+	s.Framing = framing.NonTransparent
+
+	// Init metrics
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{
+			"hostname": "testhost",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage.String)
+	require.NoError(t, err)
+
+	require.Equal(t, "<13>1 2010-11-10T23:00:00Z testhost circonus-unified-agent - testmetric -\n", string(messageBytesWithFraming), "Incorrect non-transparent framing")
+}
+
+func TestSyslogWriteWithTcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+
+	err = s.Connect()
+	require.NoError(t, err)
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+
+	testSyslogWriteWithStream(t, s, lconn)
+}
+
+func TestSyslogWriteWithUdp(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := newSyslog()
+	s.Address = "udp://" + listener.LocalAddr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+
+	err = s.Connect()
+	require.NoError(t, err)
+
+	testSyslogWriteWithPacket(t, s, listener)
+}
+
+func testSyslogWriteWithStream(t *testing.T, s *Syslog, lconn net.Conn) {
+	metrics := []cua.Metric{}
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	metrics = append(metrics, m1)
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(metrics[0])
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage.String)
+	require.NoError(t, err)
+
+	_, err = s.Write(metrics)
+	require.NoError(t, err)
+
+	buf := make([]byte, 256)
+	n, err := lconn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, string(messageBytesWithFraming), string(buf[:n]))
+}
+
+func testSyslogWriteWithPacket(t *testing.T, s *Syslog, lconn net.PacketConn) {
+	s.Framing = framing.NonTransparent
+	metrics := []cua.Metric{}
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	metrics = append(metrics, m1)
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(metrics[0])
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage.String)
+	require.NoError(t, err)
+
+	_, err = s.Write(metrics)
+	require.NoError(t, err)
+
+	buf := make([]byte, 256)
+	n, _, err := lconn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, string(messageBytesWithFraming), string(buf[:n]))
+}
+
+func TestSyslogWriteErr(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+
+	err = s.Connect()
+	require.NoError(t, err)
+	err = s.Conn.(*net.TCPConn).SetReadBuffer(256)
+	require.NoError(t, err)
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	err = lconn.(*net.TCPConn).SetWriteBuffer(256)
+	require.NoError(t, err)
+
+	metrics := []cua.Metric{testutil.TestMetric(1, "testerr")}
+
+	// close the socket to generate an error
+	err = lconn.Close()
+	require.NoError(t, err)
+
+	err = s.Conn.Close()
+	require.NoError(t, err)
+
+	_, err = s.Write(metrics)
+	require.Error(t, err)
+	require.Nil(t, s.Conn)
+}
+
+func TestSyslogReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Init())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	// a write without a connection, e.g. after a failed one, connects first
+	_, err = s.Write([]cua.Metric{testutil.TestMetric(1, "test")})
+	require.NoError(t, err)
+	require.NotNil(t, s.Conn)
+	wg.Wait()
+}
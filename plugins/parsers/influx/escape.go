@@ -36,7 +36,7 @@ var (
 
 func unescape(b []byte) string {
 	if bytes.ContainsAny(b, escapes) {
-		return unescaper.Replace(unsafeBytesToString(b))
+		return replace(unescaper, b)
 	} else {
 		return string(b)
 	}
@@ -44,7 +44,7 @@ func unescape(b []byte) string {
 
 func nameUnescape(b []byte) string {
 	if bytes.ContainsAny(b, nameEscapes) {
-		return nameUnescaper.Replace(unsafeBytesToString(b))
+		return replace(nameUnescaper, b)
 	} else {
 		return string(b)
 	}
@@ -52,12 +52,22 @@ func nameUnescape(b []byte) string {
 
 func stringFieldUnescape(b []byte) string {
 	if bytes.ContainsAny(b, stringFieldEscapes) {
-		return stringFieldUnescaper.Replace(unsafeBytesToString(b))
+		return replace(stringFieldUnescaper, b)
 	} else {
 		return string(b)
 	}
 }
 
+// replace answers b with the replacements of r, never sharing the memory of
+// b, which the strings.Replacer answers when nothing was replaced
+func replace(r *strings.Replacer, b []byte) string {
+	s := r.Replace(unsafeBytesToString(b))
+	if len(s) == len(b) {
+		return string(b)
+	}
+	return s
+}
+
 // parseIntBytes is a zero-alloc wrapper around strconv.ParseInt.
 func parseIntBytes(b []byte, base int, bitSize int) (i int64, err error) {
 	s := unsafeBytesToString(b)
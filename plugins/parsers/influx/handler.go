@@ -13,17 +13,51 @@ import (
 )
 
 // MetricHandler implements the Handler interface and produces cua.Metric.
+//
+// The tags and fields of a line are collected in buffers reused for each
+// line, and the metric allocated with its lists once the line is parsed.
+// Measurements, keys and tag values are interned.
 type MetricHandler struct {
 	// err           error
 	timePrecision time.Duration
 	timeFunc      TimeFunc
-	metric        cua.Metric
+
+	// line being parsed, tags sorted by key
+	started bool
+	name    string
+	tags    []cua.Tag
+	fields  []cua.Field
+	tm      time.Time
+
+	keys   *interner
+	values *interner
+
+	// slabs the tags and fields of the metrics are carved from, shared by
+	// consecutive metrics so they are not allocated for each metric
+	tagSlab       []cua.Tag
+	tagListSlab   []*cua.Tag
+	fieldSlab     []cua.Field
+	fieldListSlab []*cua.Field
+}
+
+// slabSize is the number of tags or fields allocated at once, retained as
+// long as one of the metrics using them
+const slabSize = 256
+
+// slabLen answers the length of a slab for n tags or fields
+func slabLen(n int) int {
+	if n > slabSize {
+		return n
+	}
+	return slabSize
 }
 
 func NewMetricHandler() *MetricHandler {
 	return &MetricHandler{
 		timePrecision: time.Nanosecond,
 		timeFunc:      time.Now,
+		keys:          newInterner(maxInternedKeys),
+		values:        newInterner(maxInternedValues),
 	}
 }
 
@@ -41,29 +75,89 @@ func (h *MetricHandler) SetTimeFunc(f TimeFunc) {
 	h.timeFunc = f
 }
 
+// Metric answers the metric of the line parsed last, nil when none was
 func (h *MetricHandler) Metric() (cua.Metric, error) {
-	if h.metric.Time().IsZero() {
-		h.metric.SetTime(h.timeFunc().Truncate(h.timePrecision))
+	if !h.started {
+		return nil, nil
+	}
+	h.started = false
+	if h.tm.IsZero() {
+		h.tm = h.timeFunc().Truncate(h.timePrecision)
 	}
-	return h.metric, nil
+
+	var tags []*cua.Tag
+	if n := len(h.tags); n > 0 {
+		if len(h.tagSlab) < n {
+			h.tagSlab = make([]cua.Tag, slabLen(n))
+			h.tagListSlab = make([]*cua.Tag, slabLen(n))
+		}
+		// capped, so tags added later do not overwrite the next metric
+		tags = h.tagListSlab[:n:n]
+		for i := range tags {
+			h.tagSlab[i] = h.tags[i]
+			tags[i] = &h.tagSlab[i]
+		}
+		h.tagSlab = h.tagSlab[n:]
+		h.tagListSlab = h.tagListSlab[n:]
+	}
+	var fields []*cua.Field
+	if n := len(h.fields); n > 0 {
+		if len(h.fieldSlab) < n {
+			h.fieldSlab = make([]cua.Field, slabLen(n))
+			h.fieldListSlab = make([]*cua.Field, slabLen(n))
+		}
+		fields = h.fieldListSlab[:n:n]
+		for i := range fields {
+			h.fieldSlab[i] = h.fields[i]
+			fields[i] = &h.fieldSlab[i]
+		}
+		h.fieldSlab = h.fieldSlab[n:]
+		h.fieldListSlab = h.fieldListSlab[n:]
+	}
+	return metric.FromLists(h.name, tags, fields, h.tm, cua.Untyped), nil
 }
 
 func (h *MetricHandler) SetMeasurement(name []byte) error {
-	var err error
-	h.metric, err = metric.New(nameUnescape(name),
-		nil, nil, time.Time{})
-	return err
+	h.started = true
+	h.name = h.keys.intern(name, nameUnescape)
+	h.tags = h.tags[:0]
+	h.fields = h.fields[:0]
+	h.tm = time.Time{}
+	return nil
 }
 
 func (h *MetricHandler) AddTag(key []byte, value []byte) error {
-	tk := unescape(key)
-	tv := unescape(value)
-	h.metric.AddTag(tk, tv)
+	tk := h.keys.intern(key, unescape)
+	tv := h.values.intern(value, unescape)
+
+	// keep the tags sorted, the last value of a key wins
+	i := 0
+	for i < len(h.tags) && h.tags[i].Key < tk {
+		i++
+	}
+	if i < len(h.tags) && h.tags[i].Key == tk {
+		h.tags[i].Value = tv
+		return nil
+	}
+	h.tags = append(h.tags, cua.Tag{})
+	copy(h.tags[i+1:], h.tags[i:])
+	h.tags[i] = cua.Tag{Key: tk, Value: tv}
 	return nil
 }
 
+// addField adds a field, the last value of a key wins
+func (h *MetricHandler) addField(key string, value interface{}) {
+	for i := range h.fields {
+		if h.fields[i].Key == key {
+			h.fields[i].Value = value
+			return
+		}
+	}
+	h.fields = append(h.fields, cua.Field{Key: key, Value: value})
+}
+
 func (h *MetricHandler) AddInt(key []byte, value []byte) error {
-	fk := unescape(key)
+	fk := h.keys.intern(key, unescape)
 	fv, err := parseIntBytes(bytes.TrimSuffix(value, []byte("i")), 10, 64)
 	if err != nil {
 		var numErr *strconv.NumError
@@ -72,12 +166,12 @@ func (h *MetricHandler) AddInt(key []byte, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
+	h.addField(fk, fv)
 	return nil
 }
 
 func (h *MetricHandler) AddUint(key []byte, value []byte) error {
-	fk := unescape(key)
+	fk := h.keys.intern(key, unescape)
 	fv, err := parseUintBytes(bytes.TrimSuffix(value, []byte("u")), 10, 64)
 	if err != nil {
 		var numErr *strconv.NumError
@@ -86,12 +180,12 @@ func (h *MetricHandler) AddUint(key []byte, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
+	h.addField(fk, fv)
 	return nil
 }
 
 func (h *MetricHandler) AddFloat(key []byte, value []byte) error {
-	fk := unescape(key)
+	fk := h.keys.intern(key, unescape)
 	fv, err := parseFloatBytes(value, 64)
 	if err != nil {
 		var numErr *strconv.NumError
@@ -100,24 +194,24 @@ func (h *MetricHandler) AddFloat(key []byte, value []byte) error {
 		}
 		return err
 	}
-	h.metric.AddField(fk, fv)
+	h.addField(fk, fv)
 	return nil
 }
 
 func (h *MetricHandler) AddString(key []byte, value []byte) error {
-	fk := unescape(key)
+	fk := h.keys.intern(key, unescape)
 	fv := stringFieldUnescape(value)
-	h.metric.AddField(fk, fv)
+	h.addField(fk, fv)
 	return nil
 }
 
 func (h *MetricHandler) AddBool(key []byte, value []byte) error {
-	fk := unescape(key)
+	fk := h.keys.intern(key, unescape)
 	fv, err := parseBoolBytes(value)
 	if err != nil {
 		return errors.New("unparseable bool")
 	}
-	h.metric.AddField(fk, fv)
+	h.addField(fk, fv)
 	return nil
 }
 
@@ -133,6 +227,6 @@ func (h *MetricHandler) SetTimestamp(tm []byte) error {
 
 	//time precision is overloaded to mean time unit here
 	ns := v * int64(h.timePrecision)
-	h.metric.SetTime(time.Unix(0, ns))
+	h.tm = time.Unix(0, ns)
 	return nil
 }
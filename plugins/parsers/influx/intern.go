@@ -0,0 +1,43 @@
+package influx
+
+const (
+	// maxInternedKeys bounds the measurements, tag keys and field keys
+	// interned by a handler, maxInternedValues its tag values
+	maxInternedKeys   = 4096
+	maxInternedValues = 4096
+)
+
+// interner answers the strings of the keys and values parsed, unescaped, so
+// lines repeating them, e.g. the same tag keys and hosts, do not allocate
+// them again.  Once full, further strings are not interned anymore, the
+// strings of the first lines parsed are kept.
+type interner struct {
+	strings map[string]string
+	max     int
+}
+
+func newInterner(max int) *interner {
+	return &interner{
+		strings: make(map[string]string),
+		max:     max,
+	}
+}
+
+// intern answers the string of b unescaped by unescape
+func (in *interner) intern(b []byte, unescape func([]byte) string) string {
+	// indexing by the conversion does not allocate
+	if s, ok := in.strings[string(b)]; ok {
+		return s
+	}
+	s := unescape(b)
+	if len(in.strings) >= in.max {
+		return s
+	}
+	key := s
+	if len(s) != len(b) {
+		// unescaped, the key is the escaped text
+		key = string(b)
+	}
+	in.strings[key] = s
+	return s
+}
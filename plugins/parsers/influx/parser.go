@@ -3,6 +3,7 @@
 package influx
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -91,7 +92,7 @@ func (h *Parser) SetTimeFunc(f TimeFunc) {
 func (p *Parser) Parse(input []byte) ([]cua.Metric, error) {
 	p.Lock()
 	defer p.Unlock()
-	metrics := make([]cua.Metric, 0)
+	metrics := make([]cua.Metric, 0, bytes.Count(input, []byte("\n"))+1)
 	p.machine.SetData(input)
 
 	for {
@@ -170,7 +171,13 @@ type StreamParser struct {
 }
 
 func NewStreamParser(r io.Reader) *StreamParser {
-	handler := NewMetricHandler()
+	return NewStreamParserWithHandler(r, NewMetricHandler())
+}
+
+// NewStreamParserWithHandler returns a StreamParser producing the metrics
+// with the handler, e.g. one reused for each request of a listener so the
+// strings it interned are reused as well.
+func NewStreamParserWithHandler(r io.Reader, handler *MetricHandler) *StreamParser {
 	return &StreamParser{
 		machine: NewStreamMachine(r, handler),
 		handler: handler,
@@ -5,6 +5,7 @@ package influx
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
@@ -669,6 +670,83 @@ func BenchmarkParser(b *testing.B) {
 	}
 }
 
+// batch answers lines of listener traffic, hosts reporting the same
+// measurements
+func batch(lines int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&buf, "cpu,cpu=cpu%d,dc=us-east-1,host=web%02d,rack=r%d usage_idle=%d.5,usage_system=1.25,usage_user=%di %d\n",
+			i%8, i%50, i%5, i%100, i, 1600000000000000000+int64(i))
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkParserBatch(b *testing.B) {
+	input := batch(1000)
+	parser := NewParser(NewMetricHandler())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for n := 0; n < b.N; n++ {
+		metrics, err := parser.Parse(input)
+		if err != nil || len(metrics) != 1000 {
+			b.Fatal(err, len(metrics))
+		}
+	}
+}
+
+func BenchmarkStreamParserBatch(b *testing.B) {
+	input := batch(1000)
+	handler := NewMetricHandler()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for n := 0; n < b.N; n++ {
+		parser := NewStreamParserWithHandler(bytes.NewReader(input), handler)
+		for {
+			_, err := parser.Next()
+			if errors.Is(err, EOF) {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestParserCopiesInput(t *testing.T) {
+	// escaped, unescapable and plain keys and values
+	input := []byte(`cpu\ 0,host=a\,b,path=a\b value="a\\b",x="a\"b" 0` + "\n")
+	parser := NewParser(NewMetricHandler())
+	metrics, err := parser.Parse(input)
+	require.NoError(t, err)
+	for i := range input {
+		input[i] = 'X'
+	}
+	require.Equal(t, "cpu 0", metrics[0].Name())
+	require.Equal(t, map[string]string{"host": "a,b", "path": `a\b`}, metrics[0].Tags())
+	require.Equal(t, map[string]interface{}{"value": `a\b`, "x": `a"b`}, metrics[0].Fields())
+}
+
+func TestParserInternsBounded(t *testing.T) {
+	handler := NewMetricHandler()
+	parser := NewParser(handler)
+	for i := 0; i < maxInternedValues+10; i++ {
+		m, err := parser.ParseLine(fmt.Sprintf("cpu,host=host%d value=1 0", i))
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"host": fmt.Sprintf("host%d", i)}, m.Tags())
+	}
+	require.Len(t, handler.values.strings, maxInternedValues)
+	require.Len(t, handler.keys.strings, 3)
+
+	// the last value of a key wins, tags sorted and fields in order
+	m, err := parser.ParseLine("cpu,b=1,a=1,b=2 y=1,x=2,y=3 0")
+	require.NoError(t, err)
+	require.Equal(t, "a", m.TagList()[0].Key)
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, m.Tags())
+	require.Equal(t, "y", m.FieldList()[0].Key)
+	require.Equal(t, map[string]interface{}{"x": 2.0, "y": 3.0}, m.Fields())
+}
+
 func TestStreamParser(t *testing.T) {
 	for _, tt := range ptests {
 		t.Run(tt.name, func(t *testing.T) {
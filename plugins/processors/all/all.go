@@ -1,3 +1,6 @@
+//go:build !lite
+// +build !lite
+
 package all
 
 //nolint:golint
@@ -5,12 +8,14 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/clone"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/converter"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/date"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/deadband"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/dedup"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/defaults"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/enum"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/execd"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/filepath"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/ifname"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/lookup"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/override"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/parser"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/pivot"
@@ -20,10 +25,12 @@ import (
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/rename"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/reverse_dns"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/s2geo"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/sample"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/starlark"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/strings"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/tag_limit"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/template"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/threshold"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/topk"
 	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/unpivot"
 )
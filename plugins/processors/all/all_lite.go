@@ -0,0 +1,14 @@
+//go:build lite
+// +build lite
+
+package all
+
+// reduced set of plugins of agents built with the lite tag for edge devices
+
+//nolint:golint
+import (
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/converter"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/override"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/rename"
+	_ "github.com/circonus-labs/circonus-unified-agent/plugins/processors/strings"
+)
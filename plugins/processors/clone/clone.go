@@ -11,6 +11,10 @@ var sampleConfig = `
   # name_prefix = "new_name_prefix"
   # name_suffix = "new_name_suffix"
 
+  ## Tags to be removed, e.g. the team tag for a global copy of team
+  ## scoped metrics
+  # remove_tags = []
+
   ## Tags to be added (all values must be strings)
   # [processors.clone.tags]
   #   additional_tag = "tag_value"
@@ -20,6 +24,7 @@ type Clone struct {
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+	RemoveTags   []string `toml:"remove_tags"`
 	Tags         map[string]string
 }
 
@@ -46,6 +51,9 @@ func (c *Clone) Apply(in ...cua.Metric) []cua.Metric {
 		if len(c.NameSuffix) > 0 {
 			metric.AddSuffix(c.NameSuffix)
 		}
+		for _, key := range c.RemoveTags {
+			metric.RemoveTag(key)
+		}
 		for key, value := range c.Tags {
 			metric.AddTag(key, value)
 		}
@@ -55,6 +55,15 @@ func TestOverwritesPresentTagValues(t *testing.T) {
 	assert.Equal(t, "from_config", value, "Value of Tag was not changed")
 }
 
+func TestRemoveTags(t *testing.T) {
+	processor := Clone{RemoveTags: []string{"metric_tag"}, Tags: map[string]string{"scope": "global"}}
+
+	processed := processor.Apply(createTestMetric())
+
+	assert.Equal(t, map[string]string{"scope": "global"}, processed[0].Tags())
+	assert.Equal(t, map[string]string{"metric_tag": "from_metric"}, processed[1].Tags())
+}
+
 func TestOverridesName(t *testing.T) {
 	processor := Clone{NameOverride: "overridden"}
 
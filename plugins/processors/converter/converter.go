@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
@@ -23,6 +26,8 @@ var sampleConfig = `
     unsigned = []
     boolean = []
     float = []
+    duration = []
+    bytes = []
 
   ## Fields to convert
   ##
@@ -37,6 +42,13 @@ var sampleConfig = `
     unsigned = []
     boolean = []
     float = []
+    duration = []
+    bytes = []
+
+  ## The duration target type parses values with a unit, e.g. "10ms" or
+  ## "1m30s", into float seconds, the bytes target type parses values with a
+  ## size suffix, e.g. "4K" or "1.5GiB", into integer bytes (base 2).  Values
+  ## without a unit are taken as seconds and bytes.
 `
 
 type Conversion struct {
@@ -47,6 +59,8 @@ type Conversion struct {
 	Unsigned    []string `toml:"unsigned"`
 	Boolean     []string `toml:"boolean"`
 	Float       []string `toml:"float"`
+	Duration    []string `toml:"duration"`
+	Bytes       []string `toml:"bytes"`
 }
 
 type Converter struct {
@@ -66,6 +80,8 @@ type ConversionFilter struct {
 	Unsigned    filter.Filter
 	Boolean     filter.Filter
 	Float       filter.Filter
+	Duration    filter.Filter
+	Bytes       filter.Filter
 }
 
 func (p *Converter) SampleConfig() string {
@@ -152,6 +168,16 @@ func compileFilter(conv *Conversion) (*ConversionFilter, error) {
 		return nil, fmt.Errorf(errFmt, err)
 	}
 
+	cf.Duration, err = filter.Compile(conv.Duration)
+	if err != nil {
+		return nil, fmt.Errorf(errFmt, err)
+	}
+
+	cf.Bytes, err = filter.Compile(conv.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf(errFmt, err)
+	}
+
 	return cf, nil
 }
 
@@ -224,6 +250,32 @@ func (p *Converter) convertTags(metric cua.Metric) {
 			metric.AddField(key, v)
 			continue
 		}
+
+		if p.tagConversions.Duration != nil && p.tagConversions.Duration.Match(key) {
+			v, ok := toDuration(value)
+			if !ok {
+				metric.RemoveTag(key)
+				p.Log.Errorf("error converting to duration [%T]: %v", value, value)
+				continue
+			}
+
+			metric.RemoveTag(key)
+			metric.AddField(key, v)
+			continue
+		}
+
+		if p.tagConversions.Bytes != nil && p.tagConversions.Bytes.Match(key) {
+			v, ok := toBytes(value)
+			if !ok {
+				metric.RemoveTag(key)
+				p.Log.Errorf("error converting to bytes [%T]: %v", value, value)
+				continue
+			}
+
+			metric.RemoveTag(key)
+			metric.AddField(key, v)
+			continue
+		}
 	}
 }
 
@@ -312,6 +364,32 @@ func (p *Converter) convertFields(metric cua.Metric) {
 			continue
 		}
 
+		if p.fieldConversions.Duration != nil && p.fieldConversions.Duration.Match(key) {
+			v, ok := toDuration(value)
+			if !ok {
+				metric.RemoveField(key)
+				p.Log.Errorf("error converting to duration [%T]: %v", value, value)
+				continue
+			}
+
+			metric.RemoveField(key)
+			metric.AddField(key, v)
+			continue
+		}
+
+		if p.fieldConversions.Bytes != nil && p.fieldConversions.Bytes.Match(key) {
+			v, ok := toBytes(value)
+			if !ok {
+				metric.RemoveField(key)
+				p.Log.Errorf("error converting to bytes [%T]: %v", value, value)
+				continue
+			}
+
+			metric.RemoveField(key)
+			metric.AddField(key, v)
+			continue
+		}
+
 		if p.fieldConversions.String != nil && p.fieldConversions.String.Match(key) {
 			v, ok := toString(value)
 			if !ok {
@@ -436,6 +514,39 @@ func toFloat(v interface{}) (float64, bool) {
 	return 0.0, false
 }
 
+// toDuration answers the value in seconds, strings may carry a unit, e.g.
+// "10ms", numbers are taken as seconds
+func toDuration(v interface{}) (float64, bool) {
+	if value, ok := v.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err == nil {
+			return d.Seconds(), true
+		}
+	}
+	return toFloat(v)
+}
+
+// toBytes answers the value in bytes, strings may carry a base 2 size
+// suffix, e.g. "4K", "4KB" or "4KiB", numbers are taken as bytes
+func toBytes(v interface{}) (int64, bool) {
+	value, ok := v.(string)
+	if !ok {
+		return toInteger(v)
+	}
+
+	value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	value = strings.TrimSuffix(value, "IB")
+	value = strings.TrimSuffix(value, "B")
+	if value == "" {
+		return 0, false
+	}
+	if c := value[len(value)-1]; c >= '0' && c <= '9' || c == '.' {
+		return toInteger(value)
+	}
+	n, err := units.ParseBase2Bytes(value + "B")
+	return int64(n), err == nil
+}
+
 func toString(v interface{}) (string, bool) {
 	switch value := v.(type) {
 	case int64:
@@ -432,6 +432,49 @@ func TestConverter(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "from string with units",
+			converter: &Converter{
+				Tags: &Conversion{
+					Bytes: []string{"size"},
+				},
+				Fields: &Conversion{
+					Duration: []string{"latency*"},
+					Bytes:    []string{"mem*"},
+				},
+			},
+			input: testutil.MustMetric(
+				"exec",
+				map[string]string{
+					"size": "4K",
+				},
+				map[string]interface{}{
+					"latency":         "10ms",
+					"latency_seconds": "2",
+					"latency_bad":     "soon",
+					"mem_used":        "1.5GiB",
+					"mem_free":        "512 MB",
+					"mem_shared":      int64(42),
+					"mem_bad":         "4X",
+				},
+				time.Unix(0, 0),
+			),
+			expected: []cua.Metric{
+				testutil.MustMetric(
+					"exec",
+					map[string]string{},
+					map[string]interface{}{
+						"size":            int64(4096),
+						"latency":         0.01,
+						"latency_seconds": 2.0,
+						"mem_used":        int64(1610612736),
+						"mem_free":        int64(536870912),
+						"mem_shared":      int64(42),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -33,6 +33,13 @@ const sampleConfig = `
 	## in the IANA Time Zone database.
 	##   example: timezone = "America/Los_Angeles"
 	# timezone = "UTC"
+
+	## Tags to create in addition to tag_key, each with its own date format,
+	## e.g. for breakdowns by hour of day, weekday and month.
+	# [processors.date.tags]
+	#   hour = "15"
+	#   weekday = "Mon"
+	#   month = "Jan"
 `
 
 const defaultTimezone = "UTC"
@@ -43,6 +50,7 @@ type Date struct {
 	DateFormat string            `toml:"date_format"`
 	DateOffset internal.Duration `toml:"date_offset"`
 	Timezone   string            `toml:"timezone"`
+	Tags       map[string]string `toml:"tags"`
 
 	location *time.Location
 }
@@ -59,14 +67,17 @@ func (d *Date) Init() error {
 	// Check either TagKey or FieldKey specified
 	if len(d.FieldKey) > 0 && len(d.TagKey) > 0 {
 		return errors.New("Only one of field_key or tag_key can be specified")
-	} else if len(d.FieldKey) == 0 && len(d.TagKey) == 0 {
-		return errors.New("One of field_key or tag_key must be specified")
+	} else if len(d.FieldKey) == 0 && len(d.TagKey) == 0 && len(d.Tags) == 0 {
+		return errors.New("One of field_key, tag_key or tags must be specified")
 	}
 
 	var err error
 	// LoadLocation returns UTC if timezone is the empty string.
 	d.location, err = time.LoadLocation(d.Timezone)
-	return fmt.Errorf("load location: %w", err)
+	if err != nil {
+		return fmt.Errorf("load location: %w", err)
+	}
+	return nil
 }
 
 func (d *Date) Apply(in ...cua.Metric) []cua.Metric {
@@ -88,6 +99,9 @@ func (d *Date) Apply(in ...cua.Metric) []cua.Metric {
 				point.AddField(d.FieldKey, tm.Format(d.DateFormat))
 			}
 		}
+		for key, format := range d.Tags {
+			point.AddTag(key, tm.Format(format))
+		}
 	}
 
 	return in
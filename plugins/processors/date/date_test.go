@@ -197,3 +197,52 @@ func TestDateOffset(t *testing.T) {
 	actual := plugin.Apply(metric)
 	testutil.RequireMetricsEqual(t, expected, actual)
 }
+
+func TestTags(t *testing.T) {
+	plugin := &Date{
+		Timezone: "America/New_York",
+		Tags: map[string]string{
+			"hour":    "15",
+			"weekday": "Mon",
+			"month":   "Jan",
+		},
+	}
+
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	metric := testutil.MustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"time_idle": 42.0,
+		},
+		time.Unix(1578603600, 0),
+	)
+
+	expected := []cua.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{
+				"hour":    "16",
+				"weekday": "Thu",
+				"month":   "Jan",
+			},
+			map[string]interface{}{
+				"time_idle": 42.0,
+			},
+			time.Unix(1578603600, 0),
+		),
+	}
+
+	actual := plugin.Apply(metric)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestInvalidTimezone(t *testing.T) {
+	plugin := &Date{
+		TagKey:   "month",
+		Timezone: "Nowhere/Special",
+	}
+	require.Error(t, plugin.Init())
+}
@@ -0,0 +1,122 @@
+package deadband
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Float fields to filter, may contain globs.
+  fields = ["*"]
+
+  ## Changes of a field from its last sent value within the deadband are
+  ## suppressed.  The deadband is the larger of the absolute change and the
+  ## relative change, a fraction of the last sent value, e.g. 0.01 for 1%.
+  # absolute = 0.0
+  # relative = 0.0
+
+  ## Send a field at least this often, even when it did not change beyond
+  ## the deadband, "0s" never sends unchanged fields.
+  # max_interval = "10m"
+`
+
+// sent is the last sent value of a field
+type sent struct {
+	value float64
+	time  time.Time
+}
+
+type key struct {
+	id    uint64
+	field string
+}
+
+type Deadband struct {
+	Fields      []string        `toml:"fields"`
+	Absolute    float64         `toml:"absolute"`
+	Relative    float64         `toml:"relative"`
+	MaxInterval config.Duration `toml:"max_interval"`
+
+	fieldFilter filter.Filter
+	last        map[key]sent
+}
+
+func (d *Deadband) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Deadband) Description() string {
+	return "Suppress float field changes within a deadband"
+}
+
+func (d *Deadband) Init() error {
+	if d.Absolute < 0 || d.Relative < 0 {
+		return fmt.Errorf("absolute and relative must not be negative")
+	}
+	if d.Absolute == 0 && d.Relative == 0 {
+		return fmt.Errorf("no absolute or relative deadband configured")
+	}
+
+	var err error
+	d.fieldFilter, err = filter.Compile(d.Fields)
+	if err != nil {
+		return fmt.Errorf("fields: %w", err)
+	}
+	d.last = make(map[key]sent)
+	return nil
+}
+
+func (d *Deadband) Apply(in ...cua.Metric) []cua.Metric {
+	out := in[:0]
+	for _, m := range in {
+		id := m.HashID()
+		var suppressed []string
+		for _, f := range m.FieldList() {
+			v, ok := f.Value.(float64)
+			if !ok || (d.fieldFilter != nil && !d.fieldFilter.Match(f.Key)) {
+				continue
+			}
+			k := key{id: id, field: f.Key}
+			if last, ok := d.last[k]; ok && d.within(last, v, m.Time()) {
+				suppressed = append(suppressed, f.Key)
+				continue
+			}
+			d.last[k] = sent{value: v, time: m.Time()}
+		}
+
+		for _, field := range suppressed {
+			m.RemoveField(field)
+		}
+		if len(m.FieldList()) == 0 {
+			m.Drop()
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// within answers whether the value at t is to be suppressed given the last
+// sent value
+func (d *Deadband) within(last sent, v float64, t time.Time) bool {
+	if d.MaxInterval > 0 && t.Sub(last.time) >= time.Duration(d.MaxInterval) {
+		return false
+	}
+	band := math.Max(d.Absolute, d.Relative*math.Abs(last.value))
+	return math.Abs(v-last.value) <= band
+}
+
+func init() {
+	processors.Add("deadband", func() cua.Processor {
+		return &Deadband{
+			Fields:      []string{"*"},
+			MaxInterval: config.Duration(10 * time.Minute),
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package deadband
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func sensor(temp, humidity float64, status int64, ts int64) cua.Metric {
+	return testutil.MustMetric("modbus",
+		map[string]string{"slave": "1"},
+		map[string]interface{}{"temperature": temp, "humidity": humidity, "status": status},
+		time.Unix(ts, 0),
+	)
+}
+
+func TestDeadband(t *testing.T) {
+	d := &Deadband{
+		Fields:      []string{"temperature", "humidity"},
+		Absolute:    0.5,
+		Relative:    0.05,
+		MaxInterval: config.Duration(time.Minute),
+	}
+	require.NoError(t, d.Init())
+
+	// first values pass
+	actual := d.Apply(sensor(20.0, 40.0, 0, 0))
+	testutil.RequireMetricsEqual(t, []cua.Metric{sensor(20.0, 40.0, 0, 0)}, actual)
+
+	// within the deadband of max(0.5, 5%), 1.0 for temperature and 2.0 for
+	// humidity
+	actual = d.Apply(sensor(20.4, 41.9, 0, 10))
+	testutil.RequireMetricsEqual(t, []cua.Metric{
+		testutil.MustMetric("modbus",
+			map[string]string{"slave": "1"},
+			map[string]interface{}{"status": int64(0)},
+			time.Unix(10, 0),
+		),
+	}, actual)
+
+	// temperature beyond the band of its last sent value
+	actual = d.Apply(sensor(21.1, 41.9, 0, 20))
+	testutil.RequireMetricsEqual(t, []cua.Metric{
+		testutil.MustMetric("modbus",
+			map[string]string{"slave": "1"},
+			map[string]interface{}{"temperature": 21.1, "status": int64(0)},
+			time.Unix(20, 0),
+		),
+	}, actual)
+
+	// unchanged values are sent after max_interval
+	actual = d.Apply(sensor(21.1, 40.0, 0, 60))
+	testutil.RequireMetricsEqual(t, []cua.Metric{
+		testutil.MustMetric("modbus",
+			map[string]string{"slave": "1"},
+			map[string]interface{}{"humidity": 40.0, "status": int64(0)},
+			time.Unix(60, 0),
+		),
+	}, actual)
+}
+
+func TestDropsEmptyMetrics(t *testing.T) {
+	d := &Deadband{
+		Fields:   []string{"*"},
+		Absolute: 1.0,
+	}
+	require.NoError(t, d.Init())
+
+	m := testutil.MustMetric("ipmi_sensor",
+		map[string]string{"name": "fan1"},
+		map[string]interface{}{"value": 3000.0},
+		time.Unix(0, 0),
+	)
+	require.Len(t, d.Apply(m), 1)
+
+	other := testutil.MustMetric("ipmi_sensor",
+		map[string]string{"name": "fan2"},
+		map[string]interface{}{"value": 3000.5},
+		time.Unix(10, 0),
+	)
+	m = testutil.MustMetric("ipmi_sensor",
+		map[string]string{"name": "fan1"},
+		map[string]interface{}{"value": 3000.5},
+		time.Unix(10, 0),
+	)
+	actual := d.Apply(m, other)
+	testutil.RequireMetricsEqual(t, []cua.Metric{other}, actual)
+}
+
+func TestInitErrors(t *testing.T) {
+	require.Error(t, (&Deadband{}).Init())
+	require.Error(t, (&Deadband{Absolute: -1}).Init())
+}
@@ -1,13 +1,32 @@
 package defaults
 
 import (
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/config"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
 )
 
 const sampleConfig = `
+  ## Zero-fill: each interval a metric with only the default fields is
+  ## emitted for every series, measurement and tags, seen before but without
+  ## a metric during the last interval, e.g. when an input produced nothing.
+  ## Set to the interval of the input, "0s" disables zero-fill.
+  # zero_fill_interval = "0s"
+  ## A series is filled once it has missed a full interval plus this grace
+  ## period, which should cover the jitter and gather time of the input.
+  ## The fill metrics carry the timestamps of the missed intervals, following
+  ## the timestamp of the last metric of the series.  Defaults to half of
+  ## zero_fill_interval.
+  # zero_fill_grace = "0s"
+  ## Series missing for longer than this are no longer filled.
+  # zero_fill_expiry = "1h"
+
   ## Ensures a set of fields always exists on your metric(s) with their 
   ## respective default value.
   ## For any given field pair (key = default), if it's not set, a field 
@@ -27,6 +46,25 @@ const sampleConfig = `
 // on your Metrics with at least a default value.
 type Defaults struct {
 	DefaultFieldsSets map[string]interface{} `toml:"fields"`
+	ZeroFillInterval  config.Duration        `toml:"zero_fill_interval"`
+	ZeroFillGrace     config.Duration        `toml:"zero_fill_grace"`
+	ZeroFillExpiry    config.Duration        `toml:"zero_fill_expiry"`
+
+	acc    cua.Accumulator
+	now    func() time.Time
+	mu     sync.Mutex
+	series map[uint64]*series
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// series is a series seen by the zero-fill
+type series struct {
+	name     string
+	tags     map[string]string
+	tp       cua.ValueType
+	lastSeen time.Time
+	next     time.Time // timestamp of the next fill
 }
 
 // SampleConfig represents a sample toml config for this plugin.
@@ -39,6 +77,125 @@ func (def *Defaults) Description() string {
 	return "Defaults sets default value(s) for specified fields that are not set on incoming metrics."
 }
 
+// Init validates the zero-fill settings.
+func (def *Defaults) Init() error {
+	if def.ZeroFillInterval < 0 || def.ZeroFillGrace < 0 || def.ZeroFillExpiry < 0 {
+		return fmt.Errorf("zero_fill_interval, zero_fill_grace and zero_fill_expiry must not be negative")
+	}
+	if def.ZeroFillInterval > 0 && len(def.DefaultFieldsSets) == 0 {
+		return fmt.Errorf("zero_fill_interval requires default fields")
+	}
+	if def.ZeroFillGrace == 0 {
+		def.ZeroFillGrace = def.ZeroFillInterval / 2
+	}
+	return nil
+}
+
+// Start starts the zero-fill when enabled.
+func (def *Defaults) Start(acc cua.Accumulator) error {
+	def.acc = acc
+	if def.ZeroFillInterval <= 0 {
+		return nil
+	}
+	if def.now == nil {
+		def.now = time.Now
+	}
+	def.series = make(map[uint64]*series)
+	def.done = make(chan struct{})
+	def.wg.Add(1)
+	go func() {
+		defer def.wg.Done()
+		ticker := time.NewTicker(time.Duration(def.ZeroFillInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-def.done:
+				return
+			case <-ticker.C:
+				def.fill()
+			}
+		}
+	}()
+	return nil
+}
+
+// Add sets the default fields of the metric and records its series for the
+// zero-fill.
+func (def *Defaults) Add(m cua.Metric, acc cua.Accumulator) error {
+	def.Apply(m)
+	if def.series != nil {
+		def.seen(m)
+	}
+	acc.AddMetric(m)
+	return nil
+}
+
+// Stop stops the zero-fill.
+func (def *Defaults) Stop() error {
+	if def.done != nil {
+		close(def.done)
+		def.wg.Wait()
+	}
+	return nil
+}
+
+func (def *Defaults) seen(m cua.Metric) {
+	id := m.HashID()
+	now := def.now()
+	next := m.Time().Add(time.Duration(def.ZeroFillInterval))
+
+	def.mu.Lock()
+	defer def.mu.Unlock()
+	if s, ok := def.series[id]; ok {
+		s.lastSeen = now
+		if next.After(s.next) {
+			s.next = next
+		}
+		return
+	}
+	def.series[id] = &series{
+		name:     m.Name(),
+		tags:     m.Tags(),
+		tp:       m.Type(),
+		lastSeen: now,
+		next:     next,
+	}
+}
+
+// fill emits the default fields for the series which missed an interval,
+// including the grace period, and forgets the expired series.  The fill
+// metrics are timestamped with the missed intervals, so a metric arriving
+// late within the grace period is not preceded by a fill for its interval.
+func (def *Defaults) fill() {
+	now := def.now()
+	interval := time.Duration(def.ZeroFillInterval)
+	grace := time.Duration(def.ZeroFillGrace)
+	expiry := time.Duration(def.ZeroFillExpiry)
+
+	def.mu.Lock()
+	defer def.mu.Unlock()
+	for id, s := range def.series {
+		missing := now.Sub(s.lastSeen)
+		if expiry > 0 && missing > expiry {
+			delete(def.series, id)
+			continue
+		}
+		if missing <= interval+grace || s.next.After(now) {
+			continue
+		}
+		fields := make(map[string]interface{}, len(def.DefaultFieldsSets))
+		for k, v := range def.DefaultFieldsSets {
+			fields[k] = v
+		}
+		m, err := metric.New(s.name, s.tags, fields, s.next, s.tp)
+		if err != nil {
+			continue
+		}
+		def.acc.AddMetric(m)
+		s.next = s.next.Add(interval)
+	}
+}
+
 // Apply contains the main implementation of this processor.
 // For each metric in 'inputMetrics', it goes over each default pair.
 // If the field in the pair does not exist on the metric, the associated default is added.
@@ -68,7 +225,9 @@ func maybeTrimmedString(v interface{}) (string, bool) {
 }
 
 func init() {
-	processors.Add("defaults", func() cua.Processor {
-		return &Defaults{}
+	processors.AddStreaming("defaults", func() cua.StreamingProcessor {
+		return &Defaults{
+			ZeroFillExpiry: config.Duration(time.Hour),
+		}
 	})
 }
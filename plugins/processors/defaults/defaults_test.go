@@ -4,9 +4,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/circonus-labs/circonus-unified-agent/config"
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaults(t *testing.T) {
@@ -130,3 +132,120 @@ func TestDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestZeroFill(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	now := t0
+	def := &Defaults{
+		DefaultFieldsSets: map[string]interface{}{
+			"jobs": int64(0),
+		},
+		ZeroFillInterval: config.Duration(10 * time.Second),
+		ZeroFillExpiry:   config.Duration(time.Minute),
+		now:              func() time.Time { return now },
+	}
+	require.NoError(t, def.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, def.Start(acc))
+	defer def.Stop()
+
+	require.NoError(t, def.Add(testutil.MustMetric("queue",
+		map[string]string{"name": "mail"},
+		map[string]interface{}{"jobs": int64(3), "workers": int64(2)},
+		t0,
+	), acc))
+	require.NoError(t, def.Add(testutil.MustMetric("queue",
+		map[string]string{"name": "print"},
+		map[string]interface{}{"jobs": int64(1)},
+		t0,
+	), acc))
+
+	now = t0.Add(10 * time.Second)
+	require.NoError(t, def.Add(testutil.MustMetric("queue",
+		map[string]string{"name": "print"},
+		map[string]interface{}{"jobs": int64(1)},
+		now,
+	), acc))
+	acc.ClearMetrics()
+
+	// the mail series is within the grace period
+	now = t0.Add(11 * time.Second)
+	def.fill()
+	require.Empty(t, acc.GetCUAMetrics())
+
+	// the mail series missed an interval, the print series did not, the fill
+	// is timestamped with the missed interval
+	now = t0.Add(16 * time.Second)
+	def.fill()
+	testutil.RequireMetricsEqual(t, []cua.Metric{
+		testutil.MustMetric("queue",
+			map[string]string{"name": "mail"},
+			map[string]interface{}{"jobs": int64(0)},
+			t0.Add(10*time.Second),
+		),
+	}, acc.GetCUAMetrics())
+
+	// the next fills follow the interval of each series
+	acc.ClearMetrics()
+	now = t0.Add(26 * time.Second)
+	def.fill()
+	require.Len(t, acc.GetCUAMetrics(), 2)
+	for _, m := range acc.GetCUAMetrics() {
+		require.Equal(t, t0.Add(20*time.Second), m.Time())
+	}
+
+	// expired series are no longer filled
+	acc.ClearMetrics()
+	now = now.Add(2 * time.Minute)
+	def.fill()
+	require.Empty(t, acc.GetCUAMetrics())
+}
+
+func TestZeroFillLateMetric(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	now := t0
+	def := &Defaults{
+		DefaultFieldsSets: map[string]interface{}{
+			"jobs": int64(0),
+		},
+		ZeroFillInterval: config.Duration(10 * time.Second),
+		ZeroFillGrace:    config.Duration(5 * time.Second),
+		now:              func() time.Time { return now },
+	}
+	require.NoError(t, def.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, def.Start(acc))
+	defer def.Stop()
+
+	queue := func(tm time.Time) cua.Metric {
+		return testutil.MustMetric("queue",
+			map[string]string{"name": "mail"},
+			map[string]interface{}{"jobs": int64(3)},
+			tm,
+		)
+	}
+
+	// the fill ticks just before the late metrics arrive, e.g. because of
+	// the jitter or gather time of the input
+	require.NoError(t, def.Add(queue(t0), acc))
+	for i := 1; i <= 3; i++ {
+		interval := t0.Add(time.Duration(i) * 10 * time.Second)
+		now = interval.Add(4 * time.Second)
+		def.fill()
+		now = interval.Add(4*time.Second + 500*time.Millisecond)
+		require.NoError(t, def.Add(queue(interval), acc))
+	}
+	for _, m := range acc.GetCUAMetrics() {
+		require.Equal(t, int64(3), m.Fields()["jobs"])
+	}
+	require.Len(t, acc.GetCUAMetrics(), 4)
+}
+
+func TestZeroFillRequiresFields(t *testing.T) {
+	def := &Defaults{
+		ZeroFillInterval: config.Duration(10 * time.Second),
+	}
+	require.Error(t, def.Init())
+}
@@ -23,11 +23,17 @@ const sampleConfig = `
 
   ## Delay before the process is restarted after an unexpected termination
   restart_delay = "10s"
+
+  ## Environment variables passed to the program, as "key=value" pairs in
+  ## addition to the environment of the agent.
+  ## eg: environment = ["API_TOKEN=secret", "LD_LIBRARY_PATH=/opt/lib"]
+  # environment = []
 `
 
 type Execd struct {
 	Command      []string        `toml:"command"`
 	RestartDelay config.Duration `toml:"restart_delay"`
+	Environment  []string        `toml:"environment"`
 	Log          cua.Logger
 
 	parserConfig     *parsers.Config
@@ -70,7 +76,7 @@ func (e *Execd) Start(acc cua.Accumulator) error {
 	}
 	e.acc = acc
 
-	e.process, err = process.New(e.Command)
+	e.process, err = process.New(e.Command, e.Environment)
 	if err != nil {
 		return fmt.Errorf("error creating new process: %w", err)
 	}
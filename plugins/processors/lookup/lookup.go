@@ -0,0 +1,249 @@
+package lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Files mapping keys to the tags to add, later files take precedence.
+  ##   csv:  the header names the tags, the first column holds the key,
+  ##         e.g. "key,circuit_id,provider"
+  ##   json: an object of keys, each an object of tag names and values,
+  ##         e.g. {"3": {"circuit_id": "ckt-1234", "provider": "acme"}}
+  files = []
+
+  ## Format of the files, "csv" or "json", by default taken from the file
+  ## extension.
+  # format = ""
+
+  ## Tags holding the key to look up, the values of several tags are joined
+  ## with ":", e.g. "router1:3" for key_tags = ["agent_host", "ifIndex"].
+  key_tags = []
+
+  ## Interval to check the files for changes, modified files are reloaded.
+  ## Set to "0s" to load the files only once.
+  # reload_interval = "5m"
+`
+
+const keySeparator = ":"
+
+type Lookup struct {
+	Files          []string        `toml:"files"`
+	Format         string          `toml:"format"`
+	KeyTags        []string        `toml:"key_tags"`
+	ReloadInterval config.Duration `toml:"reload_interval"`
+	Log            cua.Logger      `toml:"-"`
+
+	mappings   map[string]map[string]string
+	modTimes   []time.Time
+	lastCheck  time.Time
+	now        func() time.Time
+	keyBuilder strings.Builder
+}
+
+func (l *Lookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lookup) Description() string {
+	return "Add tags looked up by a tag value in CSV or JSON mapping files"
+}
+
+func (l *Lookup) Init() error {
+	if len(l.Files) == 0 {
+		return fmt.Errorf("no files configured")
+	}
+	if len(l.KeyTags) == 0 {
+		return fmt.Errorf("no key_tags configured")
+	}
+	for _, file := range l.Files {
+		if _, err := l.format(file); err != nil {
+			return err
+		}
+	}
+	if l.now == nil {
+		l.now = time.Now
+	}
+
+	l.modTimes = make([]time.Time, len(l.Files))
+	mappings, err := l.load()
+	if err != nil {
+		return err
+	}
+	l.mappings = mappings
+	l.lastCheck = l.now()
+	return nil
+}
+
+func (l *Lookup) Apply(in ...cua.Metric) []cua.Metric {
+	l.reload()
+
+	for _, m := range in {
+		key, ok := l.key(m)
+		if !ok {
+			continue
+		}
+		for k, v := range l.mappings[key] {
+			m.AddTag(k, v)
+		}
+	}
+	return in
+}
+
+// key answers the lookup key of the metric, false when a key tag is missing
+func (l *Lookup) key(m cua.Metric) (string, bool) {
+	if len(l.KeyTags) == 1 {
+		return m.GetTag(l.KeyTags[0])
+	}
+
+	l.keyBuilder.Reset()
+	for i, tag := range l.KeyTags {
+		v, ok := m.GetTag(tag)
+		if !ok {
+			return "", false
+		}
+		if i > 0 {
+			l.keyBuilder.WriteString(keySeparator)
+		}
+		l.keyBuilder.WriteString(v)
+	}
+	return l.keyBuilder.String(), true
+}
+
+// reload reloads the files when one of them changed since the last load, at
+// most once per reload interval; on errors the previous mappings are kept
+func (l *Lookup) reload() {
+	if l.ReloadInterval <= 0 {
+		return
+	}
+	now := l.now()
+	if now.Sub(l.lastCheck) < time.Duration(l.ReloadInterval) {
+		return
+	}
+	l.lastCheck = now
+
+	changed := false
+	for i, file := range l.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			l.Log.Errorf("checking %s: %v", file, err)
+			return
+		}
+		if !info.ModTime().Equal(l.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	mappings, err := l.load()
+	if err != nil {
+		l.Log.Errorf("reloading, keeping the previous mappings: %v", err)
+		return
+	}
+	l.mappings = mappings
+	l.Log.Debugf("reloaded %d keys", len(mappings))
+}
+
+// load reads the mappings of all files
+func (l *Lookup) load() (map[string]map[string]string, error) {
+	mappings := make(map[string]map[string]string)
+	for i, file := range l.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("stat: %w", err)
+		}
+		format, _ := l.format(file)
+		if err := loadFile(file, format, mappings); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		l.modTimes[i] = info.ModTime()
+	}
+	return mappings, nil
+}
+
+// format answers the format of the file, the configured or by extension
+func (l *Lookup) format(file string) (string, error) {
+	format := l.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	}
+	switch format {
+	case "csv", "json":
+		return format, nil
+	}
+	return "", fmt.Errorf("%s: unknown format %q, must be csv or json", file, format)
+}
+
+func loadFile(file, format string, mappings map[string]map[string]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	if format == "json" {
+		var obj map[string]map[string]string
+		if err := json.NewDecoder(f).Decode(&obj); err != nil {
+			return fmt.Errorf("json decode: %w", err)
+		}
+		for key, tags := range obj {
+			for k, v := range tags {
+				addTag(mappings, key, k, v)
+			}
+		}
+		return nil
+	}
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("csv read: %w", err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	header := records[0]
+	if len(header) < 2 {
+		return fmt.Errorf("csv header: need a key and at least one tag column")
+	}
+	for _, record := range records[1:] {
+		for i, v := range record[1:] {
+			if v == "" {
+				continue
+			}
+			addTag(mappings, record[0], header[i+1], v)
+		}
+	}
+	return nil
+}
+
+func addTag(mappings map[string]map[string]string, key, tag, value string) {
+	tags, ok := mappings[key]
+	if !ok {
+		tags = make(map[string]string)
+		mappings[key] = tags
+	}
+	tags[tag] = value
+}
+
+func init() {
+	processors.Add("lookup", func() cua.Processor {
+		return &Lookup{
+			ReloadInterval: config.Duration(5 * time.Minute),
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package lookup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func ifMetric(host, index string) cua.Metric {
+	return testutil.MustMetric("interface",
+		map[string]string{"agent_host": host, "ifIndex": index},
+		map[string]interface{}{"ifHCInOctets": uint64(42)},
+		time.Unix(0, 0),
+	)
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	circuits := writeFile(t, dir, "circuits.csv", `# circuits
+key,circuit_id,provider
+router1:3,ckt-1234,acme
+router2:3,ckt-5678,
+`)
+	owners := writeFile(t, dir, "owners.json", `{"router2:3": {"provider": "globex"}}`)
+
+	plugin := &Lookup{
+		Files:   []string{circuits, owners},
+		KeyTags: []string{"agent_host", "ifIndex"},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	actual := plugin.Apply(
+		ifMetric("router1", "3"),
+		ifMetric("router2", "3"),
+		ifMetric("router3", "3"),
+		testutil.MustMetric("interface",
+			map[string]string{"ifIndex": "3"},
+			map[string]interface{}{"ifHCInOctets": uint64(42)},
+			time.Unix(0, 0),
+		),
+	)
+
+	expected := []cua.Metric{
+		testutil.MustMetric("interface",
+			map[string]string{"agent_host": "router1", "ifIndex": "3", "circuit_id": "ckt-1234", "provider": "acme"},
+			map[string]interface{}{"ifHCInOctets": uint64(42)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("interface",
+			map[string]string{"agent_host": "router2", "ifIndex": "3", "circuit_id": "ckt-5678", "provider": "globex"},
+			map[string]interface{}{"ifHCInOctets": uint64(42)},
+			time.Unix(0, 0),
+		),
+		ifMetric("router3", "3"),
+		testutil.MustMetric("interface",
+			map[string]string{"ifIndex": "3"},
+			map[string]interface{}{"ifHCInOctets": uint64(42)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	owners := writeFile(t, dir, "owners.json", `{"web01": {"team": "web"}}`)
+
+	now := time.Unix(1600000000, 0)
+	plugin := &Lookup{
+		Files:          []string{owners},
+		KeyTags:        []string{"host"},
+		ReloadInterval: config.Duration(time.Minute),
+		Log:            testutil.Logger{},
+		now:            func() time.Time { return now },
+	}
+	require.NoError(t, plugin.Init())
+
+	team := func() string {
+		m := testutil.MustMetric("cpu", map[string]string{"host": "web01"}, map[string]interface{}{"usage_idle": 99.5}, now)
+		v, _ := plugin.Apply(m)[0].GetTag("team")
+		return v
+	}
+	require.Equal(t, "web", team())
+
+	writeFile(t, dir, "owners.json", `{"web01": {"team": "platform"}}`)
+	require.NoError(t, os.Chtimes(owners, now, now.Add(time.Second)))
+	require.Equal(t, "web", team(), "reloaded before the interval elapsed")
+
+	now = now.Add(time.Minute)
+	require.Equal(t, "platform", team())
+
+	// an invalid file keeps the previous mappings
+	writeFile(t, dir, "owners.json", `{"web01":`)
+	require.NoError(t, os.Chtimes(owners, now, now.Add(2*time.Second)))
+	now = now.Add(time.Minute)
+	require.Equal(t, "platform", team())
+}
+
+func TestInitErrors(t *testing.T) {
+	dir := t.TempDir()
+	mapping := writeFile(t, dir, "mapping.txt", "key,team\nweb01,web\n")
+
+	require.Error(t, (&Lookup{KeyTags: []string{"host"}}).Init())
+	require.Error(t, (&Lookup{Files: []string{mapping}}).Init())
+	require.Error(t, (&Lookup{Files: []string{mapping}, KeyTags: []string{"host"}}).Init())
+	require.Error(t, (&Lookup{Files: []string{filepath.Join(dir, "missing.csv")}, KeyTags: []string{"host"}}).Init())
+	require.NoError(t, (&Lookup{Files: []string{mapping}, Format: "csv", KeyTags: []string{"host"}}).Init())
+}
@@ -0,0 +1,71 @@
+package sample
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Keep on average one in rate metrics, chosen at random.
+  rate = 10
+
+  ## Tag holding the rate on the kept metrics, e.g. to scale counts by it,
+  ## set to "" to not add the tag.
+  # rate_tag = "sample_rate"
+`
+
+type Sample struct {
+	Rate    int    `toml:"rate"`
+	RateTag string `toml:"rate_tag"`
+
+	rate string
+	rand *rand.Rand
+}
+
+func (s *Sample) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Sample) Description() string {
+	return "Keep a random sample of one in rate metrics"
+}
+
+func (s *Sample) Init() error {
+	if s.Rate < 1 {
+		return fmt.Errorf("rate must be at least 1")
+	}
+	s.rate = strconv.Itoa(s.Rate)
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // G404
+	}
+	return nil
+}
+
+func (s *Sample) Apply(in ...cua.Metric) []cua.Metric {
+	out := in[:0]
+	for _, m := range in {
+		if s.Rate > 1 && s.rand.Intn(s.Rate) != 0 {
+			m.Drop()
+			continue
+		}
+		if s.RateTag != "" {
+			m.AddTag(s.RateTag, s.rate)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func init() {
+	processors.Add("sample", func() cua.Processor {
+		return &Sample{
+			Rate:    10,
+			RateTag: "sample_rate",
+		}
+	})
+}
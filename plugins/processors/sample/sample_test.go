@@ -0,0 +1,53 @@
+package sample
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func flows(n int) []cua.Metric {
+	metrics := make([]cua.Metric, 0, n)
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, testutil.MustMetric("netflow",
+			map[string]string{"src": "10.0.0.1"},
+			map[string]interface{}{"in_bytes": int64(i)},
+			time.Unix(0, 0),
+		))
+	}
+	return metrics
+}
+
+func TestSample(t *testing.T) {
+	s := &Sample{
+		Rate:    10,
+		RateTag: "sample_rate",
+		rand:    rand.New(rand.NewSource(1)), //nolint:gosec // G404
+	}
+	require.NoError(t, s.Init())
+
+	actual := s.Apply(flows(10000)...)
+	require.InDelta(t, 1000, len(actual), 100)
+	for _, m := range actual {
+		v, ok := m.GetTag("sample_rate")
+		require.True(t, ok)
+		require.Equal(t, "10", v)
+	}
+}
+
+func TestRateOne(t *testing.T) {
+	s := &Sample{Rate: 1}
+	require.NoError(t, s.Init())
+
+	input := flows(5)
+	expected := flows(5)
+	testutil.RequireMetricsEqual(t, expected, s.Apply(input...))
+}
+
+func TestInvalidRate(t *testing.T) {
+	require.Error(t, (&Sample{}).Init())
+}
@@ -27,12 +27,21 @@ def apply(metric):
 
   ## File containing a Starlark script.
   # script = "/usr/local/bin/myscript.star"
+
+  ## The constants of the Starlark script, available as global variables, so
+  ## a script can be shared by processors with different settings.
+  # [processors.starlark.constants]
+  #   max_size = 10
+  #   threshold = 0.75
+  #   default_name = "Julia"
+  #   debug_mode = true
 `
 )
 
 type Starlark struct {
-	Source string `toml:"source"`
-	Script string `toml:"script"`
+	Source    string                 `toml:"source"`
+	Script    string                 `toml:"script"`
+	Constants map[string]interface{} `toml:"constants"`
 
 	Log cua.Logger `toml:"-"`
 
@@ -61,6 +70,13 @@ func (s *Starlark) Init() error {
 	builtins["Metric"] = starlark.NewBuiltin("Metric", newMetric)
 	builtins["deepcopy"] = starlark.NewBuiltin("deepcopy", deepcopy)
 	builtins["catch"] = starlark.NewBuiltin("catch", catch)
+	for name, value := range s.Constants {
+		v, err := asStarlarkConstant(value)
+		if err != nil {
+			return fmt.Errorf("constant %q: %w", name, err)
+		}
+		builtins[name] = v
+	}
 
 	program, err := s.sourceProgram(builtins)
 	if err != nil {
@@ -113,10 +129,16 @@ func (s *Starlark) Init() error {
 func (s *Starlark) sourceProgram(builtins starlark.StringDict) (*starlark.Program, error) {
 	if s.Source != "" {
 		_, program, err := starlark.SourceProgram("processor.starlark", s.Source, builtins.Has)
-		return program, fmt.Errorf("source program (source:%s): %w", s.Source, err)
+		if err != nil {
+			return nil, fmt.Errorf("source program (source:%s): %w", s.Source, err)
+		}
+		return program, nil
 	}
 	_, program, err := starlark.SourceProgram(s.Script, nil, builtins.Has)
-	return program, fmt.Errorf("source program (script:%s): %w", s.Script, err)
+	if err != nil {
+		return nil, fmt.Errorf("source program (script:%s): %w", s.Script, err)
+	}
+	return program, nil
 }
 
 func (s *Starlark) SampleConfig() string {
@@ -143,7 +165,8 @@ func (s *Starlark) Add(metric cua.Metric, acc cua.Accumulator) error {
 			}
 		}
 		metric.Reject()
-		return fmt.Errorf("starlark call: %w", err)
+		// the error of the script is reported as is, the backtrace is logged
+		return err //nolint:wrapcheck
 	}
 
 	switch rv := rv.(type) {
@@ -198,6 +221,46 @@ func (s *Starlark) Stop() error {
 	return nil
 }
 
+// asStarlarkConstant converts a constant of the configuration, a field value
+// or a list or table of them, to a frozen starlark.Value.
+func asStarlarkConstant(value interface{}) (starlark.Value, error) {
+	var v starlark.Value
+	switch value := value.(type) {
+	case []interface{}:
+		elems := make([]starlark.Value, 0, len(value))
+		for _, e := range value {
+			sv, err := asStarlarkConstant(e)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, sv)
+		}
+		v = starlark.NewList(elems)
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(value))
+		for k, e := range value {
+			sv, err := asStarlarkConstant(e)
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(k), sv); err != nil {
+				return nil, fmt.Errorf("set key %q: %w", k, err)
+			}
+		}
+		v = dict
+	case int:
+		v = starlark.MakeInt(value)
+	default:
+		sv, err := asStarlarkValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%T: %w", value, err)
+		}
+		v = sv
+	}
+	v.Freeze()
+	return v, nil
+}
+
 func containsMetric(metrics []cua.Metric, metric cua.Metric) bool {
 	for _, m := range metrics {
 		if m == metric {
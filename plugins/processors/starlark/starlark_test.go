@@ -3008,6 +3008,77 @@ def apply(metric):
 	}
 }
 
+func TestConstants(t *testing.T) {
+	plugin := &Starlark{
+		Source: `
+def apply(metric):
+    metric.tags["name"] = default_name
+    metric.fields["over"] = metric.fields["value"] > threshold
+    metric.fields["max_size"] = max_size
+    metric.fields["first"] = hosts[0]
+    metric.fields["limit"] = limits["cpu"]
+    return metric
+`,
+		Constants: map[string]interface{}{
+			"default_name": "Julia",
+			"threshold":    0.75,
+			"max_size":     int64(10),
+			"hosts":        []interface{}{"a", "b"},
+			"limits":       map[string]interface{}{"cpu": int64(90)},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, plugin.Start(acc))
+	require.NoError(t, plugin.Add(testutil.MustMetric("cpu", nil,
+		map[string]interface{}{"value": 0.9}, time.Unix(0, 0)), acc))
+	require.NoError(t, plugin.Stop())
+
+	expected := []cua.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"name": "Julia"},
+			map[string]interface{}{
+				"value":    0.9,
+				"over":     true,
+				"max_size": int64(10),
+				"first":    "a",
+				"limit":    int64(90),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetCUAMetrics())
+}
+
+func TestConstantsFrozen(t *testing.T) {
+	plugin := &Starlark{
+		Source: `
+def apply(metric):
+    hosts.append("c")
+    return metric
+`,
+		Constants: map[string]interface{}{"hosts": []interface{}{"a"}},
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, plugin.Start(acc))
+	err := plugin.Add(testutil.MustMetric("cpu", nil,
+		map[string]interface{}{"value": 1}, time.Unix(0, 0)), acc)
+	require.EqualError(t, err, "append: cannot append to frozen list")
+}
+
+func TestConstantsInvalidType(t *testing.T) {
+	plugin := &Starlark{
+		Source:    "def apply(metric):\n    return metric\n",
+		Constants: map[string]interface{}{"when": time.Unix(0, 0)},
+		Log:       testutil.Logger{},
+	}
+	require.Error(t, plugin.Init())
+}
+
 func TestAllScriptTestData(t *testing.T) {
 	// can be run from multiple folders
 	paths := []string{"testdata", "plugins/processors/starlark/testdata"}
@@ -3040,7 +3111,6 @@ func TestAllScriptTestData(t *testing.T) {
 
 				for _, m := range inputMetrics {
 					err = plugin.Add(m, acc)
-					require.NoError(t, err)
 					if expectedErrorStr != "" {
 						require.EqualError(t, err, expectedErrorStr)
 					} else {
@@ -0,0 +1,229 @@
+package threshold
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
+)
+
+const (
+	stateFiring   = "firing"
+	stateResolved = "resolved"
+)
+
+var sampleConfig = `
+  ## Name of the event metrics emitted when an alert fires or resolves.
+  # event_name = "threshold"
+
+  ## Each rule compares a field of the matching metrics with a threshold.
+  ## Rules are evaluated per series, the measurement and tags of a metric.
+  [[processors.threshold.rule]]
+    ## Name of the rule, set as the rule tag of the events.
+    name = "cpu_high"
+    ## Measurements to evaluate, may contain globs.
+    measurement = ["cpu"]
+    ## Field to compare, metrics without the field are ignored.
+    field = "usage_user"
+    ## The alert condition, field operator value; one of >, >=, < or <=.
+    operator = ">"
+    value = 90.0
+    ## A firing alert resolves only when the field is beyond the threshold
+    ## by hysteresis, e.g. at 85.0 or below for the rule above with a
+    ## hysteresis of 5.0, so a value near the threshold does not flap.
+    # hysteresis = 0.0
+    ## The condition must hold for this long before the alert fires.
+    # for = "0s"
+    ## Severity of the alert, set as the severity tag of the events.
+    # severity = ""
+`
+
+type Rule struct {
+	Name        string          `toml:"name"`
+	Measurement []string        `toml:"measurement"`
+	Field       string          `toml:"field"`
+	Operator    string          `toml:"operator"`
+	Value       float64         `toml:"value"`
+	Hysteresis  float64         `toml:"hysteresis"`
+	For         config.Duration `toml:"for"`
+	Severity    string          `toml:"severity"`
+
+	filter  filter.Filter
+	compare func(v, threshold float64) bool
+}
+
+// state of a rule for a series
+type state struct {
+	since   time.Time
+	pending bool
+	firing  bool
+}
+
+type key struct {
+	rule int
+	id   uint64
+}
+
+type Threshold struct {
+	EventName string     `toml:"event_name"`
+	Rules     []*Rule    `toml:"rule"`
+	Log       cua.Logger `toml:"-"`
+
+	states map[key]*state
+}
+
+func (t *Threshold) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *Threshold) Description() string {
+	return "Emit events when fields cross thresholds"
+}
+
+func (t *Threshold) Init() error {
+	if len(t.Rules) == 0 {
+		return fmt.Errorf("no rules configured")
+	}
+	for _, r := range t.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule without name")
+		}
+		if r.Field == "" {
+			return fmt.Errorf("rule %s: no field", r.Name)
+		}
+		if r.Hysteresis < 0 {
+			return fmt.Errorf("rule %s: hysteresis must not be negative", r.Name)
+		}
+		switch r.Operator {
+		case ">":
+			r.compare = func(v, threshold float64) bool { return v > threshold }
+		case ">=":
+			r.compare = func(v, threshold float64) bool { return v >= threshold }
+		case "<":
+			r.compare = func(v, threshold float64) bool { return v < threshold }
+		case "<=":
+			r.compare = func(v, threshold float64) bool { return v <= threshold }
+		default:
+			return fmt.Errorf("rule %s: invalid operator %q", r.Name, r.Operator)
+		}
+		var err error
+		r.filter, err = filter.Compile(r.Measurement)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+	}
+	t.states = make(map[key]*state)
+	return nil
+}
+
+func (t *Threshold) Apply(in ...cua.Metric) []cua.Metric {
+	out := in
+	for _, m := range in {
+		for i, r := range t.Rules {
+			if r.filter != nil && !r.filter.Match(m.Name()) {
+				continue
+			}
+			field, ok := m.GetField(r.Field)
+			if !ok {
+				continue
+			}
+			v, ok := toFloat(field)
+			if !ok {
+				continue
+			}
+			if event := t.evaluate(key{rule: i, id: m.HashID()}, r, m, v); event != nil {
+				out = append(out, event)
+			}
+		}
+	}
+	return out
+}
+
+// evaluate advances the state of the rule for the series of the metric,
+// answering the event metric when the alert fires or resolves.
+func (t *Threshold) evaluate(k key, r *Rule, m cua.Metric, v float64) cua.Metric {
+	s, ok := t.states[k]
+	if !ok {
+		s = &state{}
+		t.states[k] = s
+	}
+
+	if s.firing {
+		if r.compare(v, r.clearThreshold()) {
+			return nil
+		}
+		delete(t.states, k)
+		return t.event(r, m, v, stateResolved)
+	}
+
+	if !r.compare(v, r.Value) {
+		delete(t.states, k)
+		return nil
+	}
+	if !s.pending {
+		s.pending = true
+		s.since = m.Time()
+	}
+	if m.Time().Sub(s.since) < time.Duration(r.For) {
+		return nil
+	}
+	s.firing = true
+	return t.event(r, m, v, stateFiring)
+}
+
+// clearThreshold is the threshold a firing alert has to stay beyond
+func (r *Rule) clearThreshold() float64 {
+	if r.Operator == ">" || r.Operator == ">=" {
+		return r.Value - r.Hysteresis
+	}
+	return r.Value + r.Hysteresis
+}
+
+func (t *Threshold) event(r *Rule, m cua.Metric, v float64, st string) cua.Metric {
+	tags := m.Tags()
+	tags["rule"] = r.Name
+	if r.Severity != "" {
+		tags["severity"] = r.Severity
+	}
+	event, err := metric.New(t.EventName, tags, map[string]interface{}{
+		"state":     st,
+		"value":     v,
+		"threshold": r.Value,
+	}, m.Time())
+	if err != nil {
+		t.Log.Errorf("Creating event of rule %s: %v", r.Name, err)
+		return nil
+	}
+	t.Log.Debugf("Rule %s %s for %s", r.Name, st, m.Name())
+	return event
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("threshold", func() cua.Processor {
+		return &Threshold{
+			EventName: "threshold",
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package threshold
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/config"
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newThreshold(rules ...*Rule) *Threshold {
+	return &Threshold{
+		EventName: "threshold",
+		Rules:     rules,
+		Log:       testutil.Logger{},
+	}
+}
+
+func cpu(v float64, sec int64) cua.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_user": v},
+		time.Unix(sec, 0))
+}
+
+func event(st string, v float64, sec int64) cua.Metric {
+	return testutil.MustMetric("threshold",
+		map[string]string{"cpu": "cpu0", "rule": "cpu_high", "severity": "critical"},
+		map[string]interface{}{"state": st, "value": v, "threshold": 90.0},
+		time.Unix(sec, 0))
+}
+
+func TestHysteresis(t *testing.T) {
+	p := newThreshold(&Rule{
+		Name:        "cpu_high",
+		Measurement: []string{"cpu"},
+		Field:       "usage_user",
+		Operator:    ">",
+		Value:       90,
+		Hysteresis:  5,
+		Severity:    "critical",
+	})
+	require.NoError(t, p.Init())
+
+	var events []cua.Metric
+	for i, v := range []float64{50, 95, 97, 88, 91, 84, 86, 92} {
+		in := cpu(v, int64(i))
+		out := p.Apply(in)
+		require.Equal(t, in, out[0])
+		events = append(events, out[1:]...)
+	}
+
+	expected := []cua.Metric{
+		event(stateFiring, 95, 1),
+		event(stateResolved, 84, 5),
+		event(stateFiring, 92, 7),
+	}
+	testutil.RequireMetricsEqual(t, expected, events)
+}
+
+func TestFor(t *testing.T) {
+	p := newThreshold(&Rule{
+		Name:     "cpu_high",
+		Field:    "usage_user",
+		Operator: ">=",
+		Value:    90,
+		For:      config.Duration(2 * time.Second),
+		Severity: "critical",
+	})
+	require.NoError(t, p.Init())
+
+	var events []cua.Metric
+	// the condition is interrupted at 2s, so it holds for 2s only at 5s
+	for i, v := range []float64{95, 95, 50, 90, 95, 95, 80} {
+		events = append(events, p.Apply(cpu(v, int64(i)))[1:]...)
+	}
+
+	expected := []cua.Metric{
+		event(stateFiring, 95, 5),
+		event(stateResolved, 80, 6),
+	}
+	testutil.RequireMetricsEqual(t, expected, events)
+}
+
+func TestSeries(t *testing.T) {
+	p := newThreshold(&Rule{
+		Name:        "mem_low",
+		Measurement: []string{"mem"},
+		Field:       "available",
+		Operator:    "<",
+		Value:       100,
+	})
+	require.NoError(t, p.Init())
+
+	now := time.Unix(0, 0)
+	in := []cua.Metric{
+		testutil.MustMetric("mem", map[string]string{"host": "a"},
+			map[string]interface{}{"available": int64(10)}, now),
+		testutil.MustMetric("mem", map[string]string{"host": "b"},
+			map[string]interface{}{"available": int64(1000)}, now),
+		// other measurements and metrics without the field are ignored
+		testutil.MustMetric("cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"available": int64(10)}, now),
+		testutil.MustMetric("mem", map[string]string{"host": "c"},
+			map[string]interface{}{"free": int64(10)}, now),
+	}
+	out := p.Apply(in...)
+	require.Len(t, out, 5)
+
+	expected := testutil.MustMetric("threshold",
+		map[string]string{"host": "a", "rule": "mem_low"},
+		map[string]interface{}{"state": stateFiring, "value": 10.0, "threshold": 100.0},
+		now)
+	testutil.RequireMetricEqual(t, expected, out[4])
+
+	// firing alerts do not fire again
+	require.Len(t, p.Apply(in[0]), 1)
+}
+
+func TestInitErrors(t *testing.T) {
+	require.Error(t, newThreshold().Init())
+	require.Error(t, newThreshold(&Rule{Field: "usage_user", Operator: ">"}).Init())
+	require.Error(t, newThreshold(&Rule{Name: "a", Operator: ">"}).Init())
+	require.Error(t, newThreshold(&Rule{Name: "a", Field: "usage_user", Operator: "=="}).Init())
+	require.Error(t, newThreshold(&Rule{Name: "a", Field: "usage_user", Operator: ">", Hysteresis: -1}).Init())
+}
@@ -1,7 +1,10 @@
 package unpivot
 
 import (
+	"fmt"
+
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/filter"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/processors"
 )
 
@@ -12,12 +15,18 @@ const (
   tag_key = "name"
   ## Field to use for the name of the value.
   value_key = "value"
+  ## Fields to rotate, globs accepted.  Other fields are kept in a metric
+  ## of the original series.  All fields are rotated when empty.
+  # fields = []
 `
 )
 
 type Unpivot struct {
-	TagKey   string `toml:"tag_key"`
-	ValueKey string `toml:"value_key"`
+	TagKey   string   `toml:"tag_key"`
+	ValueKey string   `toml:"value_key"`
+	Fields   []string `toml:"fields"`
+
+	fieldFilter filter.Filter
 }
 
 func (p *Unpivot) SampleConfig() string {
@@ -28,6 +37,15 @@ func (p *Unpivot) Description() string {
 	return description
 }
 
+func (p *Unpivot) Init() error {
+	f, err := filter.Compile(p.Fields)
+	if err != nil {
+		return fmt.Errorf("fields: %w", err)
+	}
+	p.fieldFilter = f
+	return nil
+}
+
 func copyWithoutFields(metric cua.Metric) cua.Metric {
 	m := metric.Copy()
 
@@ -53,12 +71,23 @@ func (p *Unpivot) Apply(metrics ...cua.Metric) []cua.Metric {
 
 	for _, m := range metrics {
 		base := copyWithoutFields(m)
+		var kept cua.Metric
 		for _, field := range m.FieldList() {
+			if p.fieldFilter != nil && !p.fieldFilter.Match(field.Key) {
+				if kept == nil {
+					kept = base.Copy()
+				}
+				kept.AddField(field.Key, field.Value)
+				continue
+			}
 			newMetric := base.Copy()
 			newMetric.AddField(p.ValueKey, field.Value)
 			newMetric.AddTag(p.TagKey, field.Key)
 			results = append(results, newMetric)
 		}
+		if kept != nil {
+			results = append(results, kept)
+		}
 		m.Accept()
 	}
 	return results
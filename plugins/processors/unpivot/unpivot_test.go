@@ -6,6 +6,7 @@ import (
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
 	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnpivot(t *testing.T) {
@@ -80,10 +81,49 @@ func TestUnpivot(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "selected fields",
+			unpivot: &Unpivot{
+				TagKey:   "name",
+				ValueKey: "value",
+				Fields:   []string{"idle_*"},
+			},
+			metrics: []cua.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"idle_time": int64(42),
+						"usage":     float64(0.5),
+						"state":     "ok",
+					},
+					now,
+				),
+			},
+			expected: []cua.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"name": "idle_time",
+					},
+					map[string]interface{}{
+						"value": int64(42),
+					},
+					now,
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"usage": float64(0.5),
+						"state": "ok",
+					},
+					now,
+				),
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.unpivot.Init())
 			actual := tt.unpivot.Apply(tt.metrics...)
 			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
 		})
@@ -0,0 +1,297 @@
+// Package histogram converts metrics carrying Circonus histograms for data
+// formats without a histogram type, so the distribution is kept as
+// percentile gauges or prometheus histogram buckets instead of being dropped.
+package histogram
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/openhistogram/circonusllhist"
+)
+
+const (
+	// FormatPercentiles converts a histogram to a gauge metric with count,
+	// min, max, mean and percentile fields
+	FormatPercentiles = "percentiles"
+	// FormatPrometheus converts a histogram to the prometheus histogram
+	// metrics of the prometheus input: _bucket fields with an le tag, _sum
+	// and _count
+	FormatPrometheus = "prometheus"
+)
+
+// DefaultPercentiles are used when no percentiles are configured
+var DefaultPercentiles = []float64{50, 90, 95, 99}
+
+type serializer interface {
+	Serialize(metric cua.Metric) ([]byte, error)
+	SerializeBatch(metrics []cua.Metric) ([]byte, error)
+}
+
+// Serializer converts the histograms of a batch before handing it to the
+// serializer of the data format.
+type Serializer struct {
+	serializer
+	format      string
+	percentiles []float64
+}
+
+// NewSerializer wraps a serializer, converting histograms to format
+func NewSerializer(s serializer, format string, percentiles []float64) (*Serializer, error) {
+	switch format {
+	case FormatPercentiles, FormatPrometheus:
+	default:
+		return nil, fmt.Errorf("invalid histogram format %q, must be %s or %s", format, FormatPercentiles, FormatPrometheus)
+	}
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	percentiles = append([]float64(nil), percentiles...)
+	sort.Float64s(percentiles)
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid histogram percentile %v, must be 0-100", p)
+		}
+	}
+	return &Serializer{serializer: s, format: format, percentiles: percentiles}, nil
+}
+
+func (s *Serializer) Serialize(m cua.Metric) ([]byte, error) {
+	// a histogram may become several metrics which have to be serialized
+	// together, e.g. for the buckets of a prometheus histogram
+	return s.serializer.SerializeBatch(s.Convert([]cua.Metric{m}))
+}
+
+func (s *Serializer) SerializeBatch(metrics []cua.Metric) ([]byte, error) {
+	return s.serializer.SerializeBatch(s.Convert(metrics))
+}
+
+// Convert answers the metrics with the histograms converted, other metrics
+// are passed as is
+func (s *Serializer) Convert(metrics []cua.Metric) []cua.Metric {
+	var out []cua.Metric
+	for i, m := range metrics {
+		converted, ok := s.convert(m)
+		if !ok {
+			if out != nil {
+				out = append(out, m)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]cua.Metric, i, len(metrics)+len(converted))
+			copy(out, metrics[:i])
+		}
+		out = append(out, converted...)
+	}
+	if out == nil {
+		return metrics
+	}
+	return out
+}
+
+func (s *Serializer) convert(m cua.Metric) ([]cua.Metric, bool) {
+	if h := fromBins(m); h != nil {
+		name := strings.TrimSuffix(m.Name(), "__value")
+		return s.histogramMetrics(m, name, h), true
+	}
+
+	// fields holding encoded histograms, H[1.2e+00]=3 bins
+	var hists map[string]*circonusllhist.Histogram
+	for _, field := range m.FieldList() {
+		if h := fromString(field.Value); h != nil {
+			if hists == nil {
+				hists = make(map[string]*circonusllhist.Histogram)
+			}
+			hists[field.Key] = h
+		}
+	}
+	if hists == nil {
+		return nil, false
+	}
+
+	var out []cua.Metric
+	if len(hists) < len(m.FieldList()) {
+		rest := m.Copy()
+		for key := range hists {
+			rest.RemoveField(key)
+		}
+		out = append(out, rest)
+	}
+	keys := make([]string, 0, len(hists))
+	for key := range hists {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out = append(out, s.histogramMetrics(m, m.Name()+"_"+key, hists[key])...)
+	}
+	return out, true
+}
+
+// fromBins answers the histogram of a Histogram or CumulativeHistogram
+// metric with the bins as fields, the bin value as key and the count as
+// value, nil for other metrics
+func fromBins(m cua.Metric) *circonusllhist.Histogram {
+	if m.Type() != cua.Histogram && m.Type() != cua.CumulativeHistogram {
+		return nil
+	}
+	fields := m.FieldList()
+	if len(fields) == 0 {
+		return nil
+	}
+	h := circonusllhist.New(circonusllhist.NoLocks())
+	for _, field := range fields {
+		// prometheus histograms have _bucket, _sum and _count fields instead
+		v, err := strconv.ParseFloat(field.Key, 64)
+		if err != nil {
+			return nil
+		}
+		var n int64
+		switch c := field.Value.(type) {
+		case int64:
+			n = c
+		case uint64:
+			n = int64(c)
+		default:
+			return nil
+		}
+		_ = h.RecordValues(v, n)
+	}
+	return h
+}
+
+func fromString(value interface{}) *circonusllhist.Histogram {
+	s, ok := value.(string)
+	if !ok || !strings.Contains(s, "H[") || !strings.Contains(s, "]=") {
+		return nil
+	}
+	bins := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+	h, err := circonusllhist.NewFromStrings(bins, false)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+func (s *Serializer) histogramMetrics(m cua.Metric, name string, h *circonusllhist.Histogram) []cua.Metric {
+	if s.format == FormatPrometheus {
+		return prometheusMetrics(m, name, h)
+	}
+	return []cua.Metric{s.percentileMetric(m, name, h)}
+}
+
+func (s *Serializer) percentileMetric(m cua.Metric, name string, h *circonusllhist.Histogram) cua.Metric {
+	fields := map[string]interface{}{
+		"count": h.Count(),
+	}
+	if h.Count() > 0 {
+		fields["min"] = h.Min()
+		fields["max"] = h.Max()
+		fields["mean"] = h.ApproxMean()
+		qs := make([]float64, len(s.percentiles))
+		for i, p := range s.percentiles {
+			qs[i] = p / 100
+		}
+		if values, err := h.ApproxQuantile(qs); err == nil {
+			for i, p := range s.percentiles {
+				fields[percentileField(p)] = values[i]
+			}
+		}
+	}
+	out, _ := metric.New(name, m.Tags(), fields, m.Time(), cua.Gauge)
+	return out
+}
+
+// percentileField names the field of a percentile, p99 or p99_9
+func percentileField(p float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+}
+
+type bucket struct {
+	upper float64
+	count uint64
+}
+
+// prometheusMetrics answers the metrics of a prometheus histogram, in the
+// form the prometheus input produces and the prometheus data formats expect
+func prometheusMetrics(m cua.Metric, name string, h *circonusllhist.Histogram) []cua.Metric {
+	var buckets []bucket
+	for _, bin := range h.DecStrings() {
+		upper, count, err := parseBin(bin)
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, bucket{upper: upper, count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upper < buckets[j].upper })
+
+	out := make([]cua.Metric, 0, len(buckets)+2)
+	sum, _ := metric.New("prometheus", m.Tags(), map[string]interface{}{
+		name + "_sum":   h.ApproxSum(),
+		name + "_count": h.Count(),
+	}, m.Time(), cua.Histogram)
+	out = append(out, sum)
+
+	var cumulative uint64
+	for i, b := range buckets {
+		cumulative += b.count
+		if i+1 < len(buckets) && buckets[i+1].upper == b.upper {
+			continue
+		}
+		out = append(out, bucketMetric(m, name, strconv.FormatFloat(b.upper, 'g', -1, 64), cumulative))
+	}
+	out = append(out, bucketMetric(m, name, "+Inf", h.Count()))
+	return out
+}
+
+func bucketMetric(m cua.Metric, name, le string, count uint64) cua.Metric {
+	tags := m.Tags()
+	tags["le"] = le
+	b, _ := metric.New("prometheus", tags, map[string]interface{}{name + "_bucket": count}, m.Time(), cua.Histogram)
+	return b
+}
+
+// parseBin answers the upper bound and count of a bin of DecStrings. A bin
+// H[1.2e+00] holds the values from 1.2 up to 1.3, H[-1.2e+00] those from
+// -1.3 up to -1.2, H[0.0e+00] zero only.
+func parseBin(s string) (float64, uint64, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "H["), "]=", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("parsing bin %q", s)
+	}
+	count, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing bin %q: %w", s, err)
+	}
+	num := strings.SplitN(parts[0], "e", 2)
+	if len(num) != 2 {
+		return 0, 0, fmt.Errorf("parsing bin %q", s)
+	}
+	mantissa, err := strconv.ParseFloat(num[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing bin %q: %w", s, err)
+	}
+	exp, err := strconv.Atoi(num[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing bin %q: %w", s, err)
+	}
+
+	// the two significant digits of the bin, 12 for 1.2e+00
+	digits := int(math.Round(mantissa * 10))
+	if digits > 0 {
+		digits++
+	}
+	upper, err := strconv.ParseFloat(fmt.Sprintf("%de%d", digits, exp-1), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing bin %q: %w", s, err)
+	}
+	return upper, count, nil
+}
@@ -0,0 +1,140 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/prometheus"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertPercentiles(t *testing.T) {
+	ts := time.Unix(0, 0)
+	s, err := NewSerializer(nil, FormatPercentiles, []float64{50, 99.9})
+	require.NoError(t, err)
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"usage_idle": 42.0}, ts),
+		testutil.MustMetric("latency__value", map[string]string{"host": "a"},
+			map[string]interface{}{"1": int64(10), "2": int64(10)}, ts, cua.Histogram),
+	}
+
+	expected := []cua.Metric{
+		metrics[0],
+		testutil.MustMetric("latency", map[string]string{"host": "a"},
+			map[string]interface{}{
+				"count": uint64(20),
+				"min":   1.0,
+				"max":   2.1,
+				"mean":  1.55,
+				"p50":   1.1,
+				"p99_9": 2.0998,
+			}, ts, cua.Gauge),
+	}
+	testutil.RequireMetricsEqual(t, expected, s.Convert(metrics), cmpopts.EquateApprox(0, 1e-9))
+}
+
+func TestConvertEncodedField(t *testing.T) {
+	ts := time.Unix(0, 0)
+	s, err := NewSerializer(nil, FormatPercentiles, []float64{50})
+	require.NoError(t, err)
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("http", map[string]string{"host": "a"},
+			map[string]interface{}{"requests": int64(3), "duration": "H[1.0e+00]=1,H[2.0e+00]=1"}, ts),
+	}
+
+	expected := []cua.Metric{
+		testutil.MustMetric("http", map[string]string{"host": "a"},
+			map[string]interface{}{"requests": int64(3)}, ts),
+		testutil.MustMetric("http_duration", map[string]string{"host": "a"},
+			map[string]interface{}{
+				"count": uint64(2),
+				"min":   1.0,
+				"max":   2.1,
+				"mean":  1.55,
+				"p50":   1.1,
+			}, ts, cua.Gauge),
+	}
+	testutil.RequireMetricsEqual(t, expected, s.Convert(metrics), cmpopts.EquateApprox(0, 1e-9))
+}
+
+func TestConvertPrometheus(t *testing.T) {
+	ts := time.Unix(0, 0)
+	s, err := NewSerializer(nil, FormatPrometheus, nil)
+	require.NoError(t, err)
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("latency", map[string]string{"host": "a"},
+			map[string]interface{}{"0": int64(1), "1.25": int64(2), "-3": int64(1)}, ts, cua.CumulativeHistogram),
+	}
+
+	expected := []cua.Metric{
+		testutil.MustMetric("prometheus", map[string]string{"host": "a"},
+			map[string]interface{}{"latency_sum": -0.55, "latency_count": uint64(4)}, ts, cua.Histogram),
+		testutil.MustMetric("prometheus", map[string]string{"host": "a", "le": "-3"},
+			map[string]interface{}{"latency_bucket": uint64(1)}, ts, cua.Histogram),
+		testutil.MustMetric("prometheus", map[string]string{"host": "a", "le": "0"},
+			map[string]interface{}{"latency_bucket": uint64(2)}, ts, cua.Histogram),
+		testutil.MustMetric("prometheus", map[string]string{"host": "a", "le": "1.3"},
+			map[string]interface{}{"latency_bucket": uint64(4)}, ts, cua.Histogram),
+		testutil.MustMetric("prometheus", map[string]string{"host": "a", "le": "+Inf"},
+			map[string]interface{}{"latency_bucket": uint64(4)}, ts, cua.Histogram),
+	}
+	testutil.RequireMetricsEqual(t, expected, s.Convert(metrics), cmpopts.EquateApprox(0, 1e-9))
+}
+
+func TestPrometheusHistogramsPassed(t *testing.T) {
+	ts := time.Unix(0, 0)
+	s, err := NewSerializer(nil, FormatPrometheus, nil)
+	require.NoError(t, err)
+
+	metrics := []cua.Metric{
+		testutil.MustMetric("prometheus", map[string]string{"le": "0.5"},
+			map[string]interface{}{"http_request_duration_seconds_bucket": 129389.0}, ts, cua.Histogram),
+	}
+	testutil.RequireMetricsEqual(t, metrics, s.Convert(metrics))
+}
+
+func TestSerialize(t *testing.T) {
+	ts := time.Unix(0, 0)
+	metrics := []cua.Metric{
+		testutil.MustMetric("latency", nil,
+			map[string]interface{}{"1": int64(1), "2": int64(3)}, ts, cua.Histogram),
+	}
+
+	is := influx.NewSerializer()
+	is.SetFieldSortOrder(influx.SortFields)
+	s, err := NewSerializer(is, FormatPercentiles, []float64{50})
+	require.NoError(t, err)
+	out, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "latency count=4i,max=2.1,mean=1.7999999999999998,min=1,p50=2.033333333333333 0\n", string(out))
+
+	ps, err := prometheus.NewSerializer(prometheus.FormatConfig{MetricSortOrder: prometheus.SortMetrics})
+	require.NoError(t, err)
+	s, err = NewSerializer(ps, FormatPrometheus, nil)
+	require.NoError(t, err)
+	out, err = s.Serialize(metrics[0])
+	require.NoError(t, err)
+	require.Equal(t, `# HELP latency Circonus Unified Agent collected metric
+# TYPE latency histogram
+latency_bucket{le="1.1"} 1
+latency_bucket{le="2.1"} 4
+latency_bucket{le="+Inf"} 4
+latency_sum 7.199999999999999
+latency_count 4
+`, string(out))
+}
+
+func TestNewSerializerErrors(t *testing.T) {
+	_, err := NewSerializer(nil, "buckets", nil)
+	require.Error(t, err)
+	_, err = NewSerializer(nil, FormatPercentiles, []float64{101})
+	require.Error(t, err)
+}
@@ -134,13 +134,19 @@ func (s *Serializer) Write(w io.Writer, m cua.Metric) (int, error) {
 func (s *Serializer) writeString(w io.Writer, str string) error {
 	n, err := io.WriteString(w, str)
 	s.bytesWritten += n
-	return fmt.Errorf("io write string: %w", err)
+	if err != nil {
+		return fmt.Errorf("io write string: %w", err)
+	}
+	return nil
 }
 
 func (s *Serializer) write(w io.Writer, b []byte) error {
 	n, err := w.Write(b)
 	s.bytesWritten += n
-	return fmt.Errorf("write: %w", err)
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
 }
 
 func (s *Serializer) buildHeader(m cua.Metric) error {
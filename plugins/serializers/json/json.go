@@ -63,12 +63,11 @@ func (s *Serializer) createObject(metric cua.Metric) map[string]interface{} {
 		switch fv := field.Value.(type) {
 		case float64:
 			// JSON does not support these special values
-			if math.IsNaN(fv) || math.IsInf(fv, 0) { //nolint:staticcheck
+			if math.IsNaN(fv) || math.IsInf(fv, 0) {
 				continue
 			}
-		default:
-			fields[field.Key] = field.Value
 		}
+		fields[field.Key] = field.Value
 	}
 	m["fields"] = fields
 
@@ -0,0 +1,91 @@
+package serializers
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+)
+
+// chunkSize is the number of metrics of a batch serialized by a worker at
+// once.  Batches are split every chunkSize metrics whatever the number of
+// workers, so a batch is serialized the same way on every host.
+const chunkSize = 1000
+
+// parallelSerializer serializes the batches of more than one chunk with
+// a serializer per worker, serializers not being thread-safe, joining the
+// chunks in the order of the batch.
+type parallelSerializer struct {
+	Serializer // serializes single metrics and batches of one chunk
+
+	workers []Serializer
+}
+
+func newParallelSerializer(config *Config) (Serializer, error) {
+	switch config.DataFormat {
+	case "carbon2", "circonus", "graphite", "influx", "splunkmetric":
+		// a batch is the concatenation of its metrics
+	default:
+		return nil, fmt.Errorf("serializer_workers is not supported by data format %s", config.DataFormat)
+	}
+
+	ps := &parallelSerializer{}
+	for i := 0; i < config.SerializerWorkers; i++ {
+		s, err := newSerializer(config)
+		if err != nil {
+			return nil, err
+		}
+		ps.workers = append(ps.workers, s)
+	}
+	ps.Serializer = ps.workers[0]
+	return ps, nil
+}
+
+func (ps *parallelSerializer) SerializeBatch(metrics []cua.Metric) ([]byte, error) {
+	if len(metrics) <= chunkSize {
+		return ps.Serializer.SerializeBatch(metrics) //nolint:wrapcheck
+	}
+
+	chunks := (len(metrics) + chunkSize - 1) / chunkSize
+	out := make([][]byte, chunks)
+	errs := make([]error, chunks)
+	next := int64(-1)
+
+	workers := ps.workers
+	if len(workers) > chunks {
+		workers = workers[:chunks]
+	}
+	var wg sync.WaitGroup
+	for _, s := range workers {
+		wg.Add(1)
+		go func(s Serializer) {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= chunks {
+					return
+				}
+				end := (i + 1) * chunkSize
+				if end > len(metrics) {
+					end = len(metrics)
+				}
+				out[i], errs[i] = s.SerializeBatch(metrics[i*chunkSize : end])
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	size := 0
+	for i, b := range out {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		size += len(b)
+	}
+	batch := make([]byte, 0, size)
+	for _, b := range out {
+		batch = append(batch, b...)
+	}
+	return batch, nil
+}
@@ -0,0 +1,65 @@
+package serializers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func batch(n int) []cua.Metric {
+	metrics := make([]cua.Metric, 0, n)
+	for i := 0; i < n; i++ {
+		m, _ := metric.New("cpu",
+			map[string]string{"host": fmt.Sprintf("host%d", i%10), "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": float64(i)},
+			time.Unix(int64(i), 0))
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestParallelSerializer(t *testing.T) {
+	for _, format := range []string{"carbon2", "circonus", "graphite", "influx", "splunkmetric"} {
+		t.Run(format, func(t *testing.T) {
+			s, err := NewSerializer(&Config{DataFormat: format})
+			require.NoError(t, err)
+			ps, err := NewSerializer(&Config{DataFormat: format, SerializerWorkers: 4})
+			require.NoError(t, err)
+
+			// chunks joined in the order of the batch, single chunks as is
+			for _, n := range []int{10, chunkSize, 2*chunkSize + 500, 10 * chunkSize} {
+				metrics := batch(n)
+				expected, err := s.SerializeBatch(metrics)
+				require.NoError(t, err)
+				actual, err := ps.SerializeBatch(metrics)
+				require.NoError(t, err)
+				require.Equal(t, string(expected), string(actual), "%d metrics", n)
+			}
+		})
+	}
+}
+
+func TestParallelSerializerFormats(t *testing.T) {
+	_, err := NewSerializer(&Config{DataFormat: "json", SerializerWorkers: 2})
+	require.Error(t, err)
+	_, err = NewSerializer(&Config{DataFormat: "json", SerializerWorkers: 1})
+	require.NoError(t, err)
+}
+
+func BenchmarkParallelSerializer(b *testing.B) {
+	metrics := batch(100 * chunkSize)
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s, err := NewSerializer(&Config{DataFormat: "influx", SerializerWorkers: workers})
+			require.NoError(b, err)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				_, _ = s.SerializeBatch(metrics)
+			}
+		})
+	}
+}
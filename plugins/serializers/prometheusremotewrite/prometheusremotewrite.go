@@ -0,0 +1,353 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/prometheus"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+// MetricSortOrder controls if the time series are sorted.
+type MetricSortOrder int
+
+const (
+	NoSortMetrics MetricSortOrder = iota
+	SortMetrics
+)
+
+// StringHandling defines how to process string fields.
+type StringHandling int
+
+const (
+	DiscardStrings StringHandling = iota
+	StringAsLabel
+)
+
+type FormatConfig struct {
+	MetricSortOrder MetricSortOrder
+	StringHandling  StringHandling
+}
+
+// Serializer writes metrics as a snappy compressed remote write request
+// (prometheus.WriteRequest protobuf), the body a remote write receiver
+// expects.
+type Serializer struct {
+	config FormatConfig
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64 // milliseconds
+}
+
+type timeSeries struct {
+	labels []label // __name__ first, then sorted by name
+	sample sample
+}
+
+func NewSerializer(config FormatConfig) (*Serializer, error) {
+	return &Serializer{config: config}, nil
+}
+
+func (s *Serializer) Serialize(metric cua.Metric) ([]byte, error) {
+	return s.SerializeBatch([]cua.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []cua.Metric) ([]byte, error) {
+	// a series is sent once per request, with its latest sample
+	entries := make(map[uint64]timeSeries)
+	for _, metric := range metrics {
+		commonLabels := s.createLabels(metric)
+		ts := metric.Time().UnixNano() / int64(time.Millisecond)
+		for _, field := range metric.FieldList() {
+			metricName := prometheus.MetricName(metric.Name(), field.Key, metric.Type())
+			metricName, ok := prometheus.SanitizeMetricName(metricName)
+			if !ok {
+				continue
+			}
+
+			var series timeSeries
+			switch metric.Type() {
+			case cua.Histogram:
+				series, ok = histogramSeries(metric, field, metricName, commonLabels)
+			case cua.Summary:
+				series, ok = summarySeries(metric, field, metricName, commonLabels)
+			default:
+				var value float64
+				if value, ok = prometheus.SampleValue(field.Value); ok {
+					series = newSeries(metricName, commonLabels, value)
+				}
+			}
+			if !ok {
+				continue
+			}
+			series.sample.timestamp = ts
+
+			key := seriesKey(series.labels)
+			if prev, found := entries[key]; found && prev.sample.timestamp > ts {
+				continue
+			}
+			entries[key] = series
+		}
+	}
+
+	all := make([]timeSeries, 0, len(entries))
+	for _, series := range entries {
+		all = append(all, series)
+	}
+	if s.config.MetricSortOrder == SortMetrics {
+		sort.Slice(all, func(i, j int) bool {
+			return lessLabels(all[i].labels, all[j].labels)
+		})
+	}
+
+	data, err := marshalWriteRequest(all)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+// histogramSeries converts a field of a prometheus style histogram, the
+// buckets are fields with a _bucket suffix and an le tag.
+func histogramSeries(metric cua.Metric, field *cua.Field, name string, common []label) (timeSeries, bool) {
+	switch {
+	case strings.HasSuffix(field.Key, "_bucket"):
+		le, ok := metric.GetTag("le")
+		if !ok {
+			return timeSeries{}, false
+		}
+		bound, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			return timeSeries{}, false
+		}
+		count, ok := prometheus.SampleCount(field.Value)
+		if !ok {
+			return timeSeries{}, false
+		}
+		labels := append(append(make([]label, 0, len(common)+1), common...), label{name: "le", value: formatFloat(bound)})
+		return newSeries(name+"_bucket", labels, float64(count)), true
+	case strings.HasSuffix(field.Key, "_sum"):
+		sum, ok := prometheus.SampleSum(field.Value)
+		if !ok {
+			return timeSeries{}, false
+		}
+		return newSeries(name+"_sum", common, sum), true
+	case strings.HasSuffix(field.Key, "_count"):
+		count, ok := prometheus.SampleCount(field.Value)
+		if !ok {
+			return timeSeries{}, false
+		}
+		return newSeries(name+"_count", common, float64(count)), true
+	}
+	return timeSeries{}, false
+}
+
+// summarySeries converts a field of a prometheus style summary, quantiles
+// are fields without suffix and a quantile tag.
+func summarySeries(metric cua.Metric, field *cua.Field, name string, common []label) (timeSeries, bool) {
+	switch {
+	case strings.HasSuffix(field.Key, "_sum"):
+		sum, ok := prometheus.SampleSum(field.Value)
+		if !ok {
+			return timeSeries{}, false
+		}
+		return newSeries(name+"_sum", common, sum), true
+	case strings.HasSuffix(field.Key, "_count"):
+		count, ok := prometheus.SampleCount(field.Value)
+		if !ok {
+			return timeSeries{}, false
+		}
+		return newSeries(name+"_count", common, float64(count)), true
+	}
+
+	q, ok := metric.GetTag("quantile")
+	if !ok {
+		return timeSeries{}, false
+	}
+	quantile, err := strconv.ParseFloat(q, 64)
+	if err != nil {
+		return timeSeries{}, false
+	}
+	value, ok := prometheus.SampleValue(field.Value)
+	if !ok {
+		return timeSeries{}, false
+	}
+	labels := append(append(make([]label, 0, len(common)+1), common...), label{name: "quantile", value: formatFloat(quantile)})
+	return newSeries(name, labels, value), true
+}
+
+func newSeries(name string, labels []label, value float64) timeSeries {
+	all := make([]label, 0, len(labels)+1)
+	all = append(all, labels...)
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+	all = append([]label{{name: "__name__", value: name}}, all...)
+	return timeSeries{labels: all, sample: sample{value: value}}
+}
+
+func (s *Serializer) createLabels(metric cua.Metric) []label {
+	labels := make([]label, 0, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		// the bucket and quantile tags are added to their own series only
+		switch metric.Type() {
+		case cua.Histogram:
+			if tag.Key == "le" {
+				continue
+			}
+		case cua.Summary:
+			if tag.Key == "quantile" {
+				continue
+			}
+		}
+
+		name, ok := prometheus.SanitizeLabelName(tag.Key)
+		if !ok {
+			continue
+		}
+		labels = append(labels, label{name: name, value: tag.Value})
+	}
+
+	if s.config.StringHandling != StringAsLabel {
+		return labels
+	}
+
+	for _, field := range metric.FieldList() {
+		value, ok := field.Value.(string)
+		if !ok {
+			continue
+		}
+		name, ok := prometheus.SanitizeLabelName(field.Key)
+		if !ok || hasLabel(name, labels) {
+			// a tag of the same name wins
+			continue
+		}
+		labels = append(labels, label{name: name, value: value})
+	}
+	return labels
+}
+
+func hasLabel(name string, labels []label) bool {
+	for _, l := range labels {
+		if l.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func seriesKey(labels []label) uint64 {
+	h := fnv.New64a()
+	for _, l := range labels {
+		h.Write([]byte(l.name))
+		h.Write([]byte{0})
+		h.Write([]byte(l.value))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+func lessLabels(a, b []label) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].name != b[i].name {
+			return a[i].name < b[i].name
+		}
+		if a[i].value != b[i].value {
+			return a[i].value < b[i].value
+		}
+	}
+	return len(a) < len(b)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// marshalWriteRequest encodes the series as a prometheus.WriteRequest:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func marshalWriteRequest(series []timeSeries) ([]byte, error) {
+	req := proto.NewBuffer(nil)
+	for _, ts := range series {
+		tsBuf := proto.NewBuffer(nil)
+		for _, l := range ts.labels {
+			lBuf := proto.NewBuffer(nil)
+			if err := encodeString(lBuf, 1, l.name); err != nil {
+				return nil, err
+			}
+			if err := encodeString(lBuf, 2, l.value); err != nil {
+				return nil, err
+			}
+			if err := encodeMessage(tsBuf, 1, lBuf.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+
+		sBuf := proto.NewBuffer(nil)
+		if err := encodeKey(sBuf, 1, proto.WireFixed64); err != nil {
+			return nil, err
+		}
+		if err := sBuf.EncodeFixed64(math.Float64bits(ts.sample.value)); err != nil {
+			return nil, fmt.Errorf("encoding sample: %w", err)
+		}
+		if err := encodeKey(sBuf, 2, proto.WireVarint); err != nil {
+			return nil, err
+		}
+		if err := sBuf.EncodeVarint(uint64(ts.sample.timestamp)); err != nil {
+			return nil, fmt.Errorf("encoding sample: %w", err)
+		}
+		if err := encodeMessage(tsBuf, 2, sBuf.Bytes()); err != nil {
+			return nil, err
+		}
+
+		if err := encodeMessage(req, 1, tsBuf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return req.Bytes(), nil
+}
+
+func encodeKey(b *proto.Buffer, field int, wireType int) error {
+	if err := b.EncodeVarint(uint64(field<<3 | wireType)); err != nil {
+		return fmt.Errorf("encoding field %d: %w", field, err)
+	}
+	return nil
+}
+
+func encodeString(b *proto.Buffer, field int, s string) error {
+	if err := encodeKey(b, field, proto.WireBytes); err != nil {
+		return err
+	}
+	if err := b.EncodeStringBytes(s); err != nil {
+		return fmt.Errorf("encoding field %d: %w", field, err)
+	}
+	return nil
+}
+
+func encodeMessage(b *proto.Buffer, field int, msg []byte) error {
+	if err := encodeKey(b, field, proto.WireBytes); err != nil {
+		return err
+	}
+	if err := b.EncodeRawBytes(msg); err != nil {
+		return fmt.Errorf("encoding field %d: %w", field, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/testutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRequest mirrors prometheus.WriteRequest for decoding in the tests
+type writeRequest struct {
+	Timeseries []*series `protobuf:"bytes,1,rep,name=timeseries"`
+}
+
+type series struct {
+	Labels  []*promLabel  `protobuf:"bytes,1,rep,name=labels"`
+	Samples []*promSample `protobuf:"bytes,2,rep,name=samples"`
+}
+
+type promLabel struct {
+	Name  string `protobuf:"bytes,1,opt,name=name"`
+	Value string `protobuf:"bytes,2,opt,name=value"`
+}
+
+type promSample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp"`
+}
+
+func (m *writeRequest) Reset()         { *m = writeRequest{} }
+func (m *writeRequest) String() string { return proto.CompactTextString(m) }
+func (*writeRequest) ProtoMessage()    {}
+func (m *series) Reset()               { *m = series{} }
+func (m *series) String() string       { return proto.CompactTextString(m) }
+func (*series) ProtoMessage()          {}
+func (m *promLabel) Reset()            { *m = promLabel{} }
+func (m *promLabel) String() string    { return proto.CompactTextString(m) }
+func (*promLabel) ProtoMessage()       {}
+func (m *promSample) Reset()           { *m = promSample{} }
+func (m *promSample) String() string   { return proto.CompactTextString(m) }
+func (*promSample) ProtoMessage()      {}
+
+// decode answers the series of a serialized request in the text exposition
+// style, one per line: name{labels} value timestamp
+func decode(t *testing.T, data []byte) string {
+	t.Helper()
+	raw, err := snappy.Decode(nil, data)
+	require.NoError(t, err)
+	var req writeRequest
+	require.NoError(t, proto.Unmarshal(raw, &req))
+
+	var lines []string
+	for _, ts := range req.Timeseries {
+		require.Len(t, ts.Samples, 1)
+		name := ""
+		labels := make([]string, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			if l.Name == "__name__" {
+				name = l.Value
+				continue
+			}
+			labels = append(labels, fmt.Sprintf("%s=%q", l.Name, l.Value))
+		}
+		lines = append(lines, fmt.Sprintf("%s{%s} %g %d", name, strings.Join(labels, ","), ts.Samples[0].Value, ts.Samples[0].Timestamp))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestSerializeBatch(t *testing.T) {
+	ts := time.Unix(0, 0)
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		metrics  []cua.Metric
+		expected string
+	}{
+		{
+			name: "simple",
+			metrics: []cua.Metric{
+				testutil.MustMetric("cpu", map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_idle": 42.0}, ts),
+				testutil.MustMetric("cpu", map[string]string{"host": "example.org"},
+					map[string]interface{}{"time_guest": 42.0}, ts),
+			},
+			expected: `
+cpu_time_guest{host="example.org"} 42 0
+cpu_time_idle{host="example.org"} 42 0
+`,
+		},
+		{
+			name: "newest sample wins",
+			metrics: []cua.Metric{
+				testutil.MustMetric("cpu", nil, map[string]interface{}{"time_idle": 43.0}, ts.Add(time.Second)),
+				testutil.MustMetric("cpu", nil, map[string]interface{}{"time_idle": 42.0}, ts),
+			},
+			expected: `
+cpu_time_idle{} 43 1000
+`,
+		},
+		{
+			name: "strings as labels",
+			config: FormatConfig{
+				StringHandling: StringAsLabel,
+			},
+			metrics: []cua.Metric{
+				testutil.MustMetric("cpu", nil,
+					map[string]interface{}{"time_idle": 42.0, "cpu": "cpu0"}, ts),
+			},
+			expected: `
+cpu_time_idle{cpu="cpu0"} 42 0
+`,
+		},
+		{
+			name: "histogram",
+			metrics: []cua.Metric{
+				testutil.MustMetric("prometheus", nil,
+					map[string]interface{}{"http_request_duration_seconds_sum": 53423.0, "http_request_duration_seconds_count": 144320.0}, ts, cua.Histogram),
+				testutil.MustMetric("prometheus", map[string]string{"le": "0.5"},
+					map[string]interface{}{"http_request_duration_seconds_bucket": 129389.0}, ts, cua.Histogram),
+				testutil.MustMetric("prometheus", map[string]string{"le": "+Inf"},
+					map[string]interface{}{"http_request_duration_seconds_bucket": 144320.0}, ts, cua.Histogram),
+			},
+			expected: `
+http_request_duration_seconds_bucket{le="+Inf"} 144320 0
+http_request_duration_seconds_bucket{le="0.5"} 129389 0
+http_request_duration_seconds_count{} 144320 0
+http_request_duration_seconds_sum{} 53423 0
+`,
+		},
+		{
+			name: "summary",
+			metrics: []cua.Metric{
+				testutil.MustMetric("prometheus", nil,
+					map[string]interface{}{"rpc_duration_seconds_sum": 1.7560473e+07, "rpc_duration_seconds_count": 2693.0}, ts, cua.Summary),
+				testutil.MustMetric("prometheus", map[string]string{"quantile": "0.5"},
+					map[string]interface{}{"rpc_duration_seconds": 4773.0}, ts, cua.Summary),
+			},
+			expected: `
+rpc_duration_seconds{quantile="0.5"} 4773 0
+rpc_duration_seconds_count{} 2693 0
+rpc_duration_seconds_sum{} 1.7560473e+07 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.MetricSortOrder = SortMetrics
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			data, err := s.SerializeBatch(tt.metrics)
+			require.NoError(t, err)
+			require.Equal(t, strings.TrimSpace(tt.expected), decode(t, data))
+		})
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/carbon2"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/circonus"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/graphite"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/histogram"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/influx"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/json"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/nowmetric"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/prometheus"
+	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/prometheusremotewrite"
 	"github.com/circonus-labs/circonus-unified-agent/plugins/serializers/splunkmetric"
 )
 
@@ -56,15 +59,15 @@ type Config struct {
 	// Character for separating metric name and field for Graphite tags
 	GraphiteSeparator string `toml:"graphite_separator"`
 
-	// // Maximum line length in bytes; influx format only
-	// InfluxMaxLineBytes int `toml:"influx_max_line_bytes"`
+	// Maximum line length in bytes; influx format only
+	InfluxMaxLineBytes int `toml:"influx_max_line_bytes"`
 
-	// // Sort field keys, set to true only when debugging as it less performant
-	// // than unsorted fields; influx format only
-	// InfluxSortFields bool `toml:"influx_sort_fields"`
+	// Sort field keys, set to true only when debugging as it less performant
+	// than unsorted fields; influx format only
+	InfluxSortFields bool `toml:"influx_sort_fields"`
 
-	// // Support unsigned integer output; influx format only
-	// InfluxUintSupport bool `toml:"influx_uint_support"`
+	// Support unsigned integer output; influx format only
+	InfluxUintSupport bool `toml:"influx_uint_support"`
 
 	// Prefix to add to all measurements, only supports Graphite
 	Prefix string `toml:"prefix"`
@@ -102,17 +105,35 @@ type Config struct {
 	// Output string fields as metric labels; when false string fields are
 	// discarded.
 	PrometheusStringAsLabel bool `toml:"prometheus_string_as_label"`
+
+	// Convert circonus histograms to "percentiles" or "prometheus"
+	// histograms; all formats but circonus.
+	HistogramFormat string `toml:"histogram_format"`
+
+	// Percentiles to output for the percentiles histogram format.
+	HistogramPercentiles []float64 `toml:"histogram_percentiles"`
+
+	// Goroutines serializing the chunks of large batches in parallel; line
+	// oriented formats only.
+	SerializerWorkers int `toml:"serializer_workers"`
 }
 
 // NewSerializer a Serializer interface based on the given config.
 func NewSerializer(config *Config) (Serializer, error) {
+	if config.SerializerWorkers > 1 {
+		return newParallelSerializer(config)
+	}
+	return newSerializer(config)
+}
+
+func newSerializer(config *Config) (Serializer, error) {
 	var err error
 	var serializer Serializer
 	switch config.DataFormat {
 	case "circonus":
 		serializer, err = NewCirconusSerializer(time.Millisecond)
-	// case "influx":
-	// 	serializer, err = NewInfluxSerializerConfig(config)
+	case "influx":
+		serializer, err = NewInfluxSerializerConfig(config)
 	case "graphite":
 		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport, config.GraphiteSeparator, config.Templates)
 	case "json":
@@ -127,10 +148,19 @@ func NewSerializer(config *Config) (Serializer, error) {
 	// 	serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	case "prometheus":
 		serializer, err = NewPrometheusSerializer(config)
+	case "prometheusremotewrite":
+		serializer, err = NewPrometheusRemoteWriteSerializer(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
-	return serializer, err
+	if err != nil {
+		return nil, err
+	}
+
+	if config.HistogramFormat != "" && config.HistogramFormat != "none" && config.DataFormat != "circonus" {
+		return histogram.NewSerializer(serializer, config.HistogramFormat, config.HistogramPercentiles) //nolint:wrapcheck
+	}
+	return serializer, nil
 }
 
 func NewPrometheusSerializer(config *Config) (Serializer, error) {
@@ -140,7 +170,7 @@ func NewPrometheusSerializer(config *Config) (Serializer, error) {
 	}
 
 	sortMetrics := prometheus.NoSortMetrics
-	if config.PrometheusExportTimestamp {
+	if config.PrometheusSortMetrics {
 		sortMetrics = prometheus.SortMetrics
 	}
 
@@ -156,6 +186,23 @@ func NewPrometheusSerializer(config *Config) (Serializer, error) {
 	})
 }
 
+func NewPrometheusRemoteWriteSerializer(config *Config) (Serializer, error) {
+	sortMetrics := prometheusremotewrite.NoSortMetrics
+	if config.PrometheusSortMetrics {
+		sortMetrics = prometheusremotewrite.SortMetrics
+	}
+
+	stringAsLabels := prometheusremotewrite.DiscardStrings
+	if config.PrometheusStringAsLabel {
+		stringAsLabels = prometheusremotewrite.StringAsLabel
+	}
+
+	return prometheusremotewrite.NewSerializer(prometheusremotewrite.FormatConfig{
+		MetricSortOrder: sortMetrics,
+		StringHandling:  stringAsLabels,
+	})
+}
+
 // func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
 // 	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 // }
@@ -180,27 +227,27 @@ func NewCirconusSerializer(timestampUnits time.Duration) (Serializer, error) {
 	return circonus.NewSerializer(timestampUnits)
 }
 
-// func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
-// 	var sort influx.FieldSortOrder
-// 	if config.InfluxSortFields {
-// 		sort = influx.SortFields
-// 	}
-
-// 	var typeSupport influx.FieldTypeSupport
-// 	if config.InfluxUintSupport {
-// 		typeSupport += influx.UintSupport
-// 	}
-
-// 	s := influx.NewSerializer()
-// 	s.SetMaxLineBytes(config.InfluxMaxLineBytes)
-// 	s.SetFieldSortOrder(sort)
-// 	s.SetFieldTypeSupport(typeSupport)
-// 	return s, nil
-// }
+func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
+	var sort influx.FieldSortOrder
+	if config.InfluxSortFields {
+		sort = influx.SortFields
+	}
 
-// func NewInfluxSerializer() (Serializer, error) {
-// 	return influx.NewSerializer(), nil
-// }
+	var typeSupport influx.FieldTypeSupport
+	if config.InfluxUintSupport {
+		typeSupport += influx.UintSupport
+	}
+
+	s := influx.NewSerializer()
+	s.SetMaxLineBytes(config.InfluxMaxLineBytes)
+	s.SetFieldSortOrder(sort)
+	s.SetFieldTypeSupport(typeSupport)
+	return s, nil
+}
+
+func NewInfluxSerializer() (Serializer, error) {
+	return influx.NewSerializer(), nil
+}
 
 func NewGraphiteSerializer(prefix, template string, tagSupport bool, separator string, templates []string) (Serializer, error) {
 	graphiteTemplates, defaultTemplate, err := graphite.InitGraphiteTemplates(templates)
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/circonus-labs/circonus-unified-agent/cua"
+	"github.com/circonus-labs/circonus-unified-agent/metric"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -197,10 +198,58 @@ func (a *Accumulator) AddCumulativeHistogram(
 	a.addFields(measurement, tags, fields, cua.CumulativeHistogram, timestamp...)
 }
 
+func (a *Accumulator) AddSamples(
+	measurement string,
+	samples []float64,
+	tags map[string]string,
+	timestamp ...time.Time,
+) {
+	fields := metric.SampleFields(samples)
+	if len(fields) == 0 {
+		return
+	}
+	a.addFields(measurement, tags, fields, cua.Histogram, timestamp...)
+}
+
+func (a *Accumulator) AddEvent(
+	title, body string,
+	severity cua.Severity,
+	tags map[string]string,
+	timestamp ...time.Time,
+) {
+	a.addFields(metric.EventName, tags, metric.EventFields(title, body, severity), cua.Event, timestamp...)
+}
+
 func (a *Accumulator) AddMetric(m cua.Metric) {
 	a.addFields(m.Name(), m.Tags(), m.Fields(), m.Type(), m.Time())
 }
 
+// NewStream answers a stream adding the metrics as they are sent
+func (a *Accumulator) NewStream(size int) cua.MetricStream {
+	return &metricStream{acc: a}
+}
+
+type metricStream struct {
+	acc *Accumulator
+}
+
+func (s *metricStream) NewMetric(
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	t time.Time,
+	tp cua.ValueType,
+) cua.Metric {
+	m, _ := metric.New(measurement, tags, fields, t, tp)
+	return m
+}
+
+func (s *metricStream) AddMetric(m cua.Metric) {
+	s.acc.AddMetric(m)
+}
+
+func (s *metricStream) Close() {}
+
 func (a *Accumulator) WithTracking(maxTracked int) cua.TrackingAccumulator {
 	return a
 }
@@ -743,6 +792,10 @@ func (n *NopAccumulator) AddHistogram(measurement string, fields map[string]inte
 }
 func (n *NopAccumulator) AddCumulativeHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
 }
+func (n *NopAccumulator) AddSamples(measurement string, samples []float64, tags map[string]string, t ...time.Time) {
+}
+func (n *NopAccumulator) AddEvent(title, body string, severity cua.Severity, tags map[string]string, t ...time.Time) {
+}
 func (n *NopAccumulator) AddMetric(cua.Metric)                                {}
 func (n *NopAccumulator) SetPrecision(precision time.Duration)                {}
 func (n *NopAccumulator) AddError(err error)                                  {}